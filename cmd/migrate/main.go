@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"go_app/internal/service"
 	"go_app/pkg/database"
 	"go_app/pkg/logger"
 )
@@ -12,7 +13,7 @@ import (
 func main() {
 	// Parse command line flags
 	var (
-		action = flag.String("action", "up", "Migration action: up, down, status")
+		action = flag.String("action", "up", "Migration action: up, down, status, locations")
 		help   = flag.Bool("help", false, "Show help")
 	)
 	flag.Parse()
@@ -46,8 +47,16 @@ func main() {
 			logger.Fatalf("Failed to check migration status: %v", err)
 		}
 
+	case "locations":
+		result, err := service.NewLocationService().ImportBundledDataset()
+		if err != nil {
+			logger.Fatalf("Failed to import address directory: %v", err)
+		}
+		logger.Infof("Address directory %s imported: %d provinces, %d districts, %d wards",
+			result.Version, result.Provinces, result.Districts, result.Wards)
+
 	default:
-		logger.Fatalf("Unknown action: %s. Use 'up', 'down', or 'status'", *action)
+		logger.Fatalf("Unknown action: %s. Use 'up', 'down', 'status', or 'locations'", *action)
 	}
 }
 
@@ -57,19 +66,21 @@ func showHelp() {
 	fmt.Println("")
 	fmt.Println("Options:")
 	fmt.Println("  -action string")
-	fmt.Println("        Migration action: up, down, status (default \"up\")")
+	fmt.Println("        Migration action: up, down, status, locations (default \"up\")")
 	fmt.Println("  -help")
 	fmt.Println("        Show this help message")
 	fmt.Println("")
 	fmt.Println("Actions:")
-	fmt.Println("  up      Run all pending migrations")
-	fmt.Println("  down    Rollback last migration (not implemented)")
-	fmt.Println("  status  Show migration status")
+	fmt.Println("  up         Run all pending migrations")
+	fmt.Println("  down       Rollback last migration (not implemented)")
+	fmt.Println("  status     Show migration status")
+	fmt.Println("  locations  Import the bundled province/district/ward directory")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  go run cmd/migrate/main.go")
 	fmt.Println("  go run cmd/migrate/main.go -action up")
 	fmt.Println("  go run cmd/migrate/main.go -action status")
+	fmt.Println("  go run cmd/migrate/main.go -action locations")
 }
 
 func checkMigrationStatus() error {
@@ -25,8 +25,15 @@ func main() {
 		return
 	}
 
-	// Set environment
-	os.Setenv("GIN_MODE", *env)
+	// Set environment (an explicit -env flag wins over GIN_MODE from the environment)
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "env" {
+			os.Setenv("GIN_MODE", *env)
+		}
+	})
+	if mode := os.Getenv("GIN_MODE"); mode != "" {
+		*env = mode
+	}
 
 	logger.Infof("Starting e-commerce server on port %s in %s mode", *port, *env)
 
@@ -8,14 +8,22 @@ import (
 
 // Config holds all configuration for our application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Email    EmailConfig
-	Upload   UploadConfig
-	LogLevel string
-	GinMode  string
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Redis         RedisConfig
+	JWT           JWTConfig
+	Session       SessionConfig
+	TwoFactor     TwoFactorConfig
+	Login         LoginSecurityConfig
+	OAuth         OAuthConfig
+	Privacy       PrivacyConfig
+	APIKey        APIKeyConfig
+	Impersonation ImpersonationConfig
+	Email         EmailConfig
+	Upload        UploadConfig
+	Shipping      ShippingConfig
+	LogLevel      string
+	GinMode       string
 }
 
 // ServerConfig holds server configuration
@@ -44,9 +52,103 @@ type RedisConfig struct {
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	SecretKey     string
-	AccessExpiry  int // in hours
-	RefreshExpiry int // in hours
+	SecretKey         string
+	Algorithm         string // RS256, EdDSA or HS256 (shared secret, single service only)
+	AccessExpiry      int    // in minutes
+	RefreshExpiry     int    // in hours
+	KeyRotationDays   int    // How often a new signing key is generated
+	KeyRetentionHours int    // How long a retired key stays in the JWKS for verification
+}
+
+// defaultJWTSecrets are the placeholder secrets shipped in the sample configs
+var defaultJWTSecrets = []string{
+	"your-secret-key",
+	"default-secret-key",
+	"your-jwt-secret-key-change-in-production",
+}
+
+// UsesDefaultSecret reports whether JWT_SECRET is unset or still one of the sample placeholders
+func (c JWTConfig) UsesDefaultSecret() bool {
+	for _, secret := range defaultJWTSecrets {
+		if c.SecretKey == secret {
+			return true
+		}
+	}
+	return c.SecretKey == ""
+}
+
+// TwoFactorConfig holds TOTP two-factor authentication configuration
+type TwoFactorConfig struct {
+	Issuer                  string   // Name shown in authenticator apps
+	RequiredRoles           []string // Roles that must enroll before they can log in
+	RequireAdminPermissions bool     // Also require 2FA for anyone holding an admin-level permission
+	ChallengeMinutes        int      // How long the second login step stays open
+	MaxAttempts             int      // Wrong codes allowed per login challenge
+}
+
+// LoginSecurityConfig holds per-account brute-force protection configuration
+type LoginSecurityConfig struct {
+	DelayAfterAttempts int    // Failed attempts before each retry must wait
+	DelayBaseSeconds   int    // First wait, doubled for every further failure
+	DelayMaxSeconds    int    // Cap on the wait between attempts
+	MaxFailedAttempts  int    // Failed attempts that lock the account
+	LockoutMinutes     int    // First lock duration, doubled for every repeated lockout
+	LockoutMaxMinutes  int    // Cap on the lock duration
+	CountryHeader      string // Header set by the CDN/proxy with the client's ISO country code
+}
+
+// OAuthConfig holds social login (OAuth2 / OpenID Connect) configuration. A provider is
+// enabled when its client ID is set.
+type OAuthConfig struct {
+	RedirectURL  string // Callback registered with the providers; {provider} is replaced by the provider name
+	StateMinutes int    // How long an authorization request stays valid
+
+	GoogleClientID       string
+	GoogleClientSecret   string
+	FacebookClientID     string
+	FacebookClientSecret string
+	ZaloAppID            string
+	ZaloSecretKey        string
+
+	MockEnabled bool   // Serve a local mock OIDC issuer at /oauth/mock (never in release mode)
+	MockIssuer  string // Public URL of the mock issuer
+}
+
+// MockAllowed reports whether the mock OIDC issuer may run in the given Gin mode; it is never
+// served in release mode
+func (c OAuthConfig) MockAllowed(ginMode string) bool {
+	return c.MockEnabled && !IsReleaseMode(ginMode)
+}
+
+// PrivacyConfig holds personal data export and account erasure configuration
+type PrivacyConfig struct {
+	ExportPath          string // Private directory for export archives; must not be served statically
+	ExportTTLHours      int    // How long a finished archive can be downloaded
+	ExportCooldownHours int    // Minimum time between two export requests of a user
+	ErasureGraceDays    int    // Days before a requested erasure runs, during which it can be cancelled
+}
+
+// APIKeyConfig holds personal access token / API key configuration
+type APIKeyConfig struct {
+	MaxPerUser           int // Active keys per user (0 = unlimited)
+	DefaultExpiryDays    int // Expiry applied when the request does not set one
+	MaxExpiryDays        int // Longest lifetime a key may be given (0 = keys may never expire)
+	TouchIntervalSeconds int // Minimum time between two last-used updates (and use audit entries) of a key
+	RejectAuditSeconds   int // Unknown keys from one IP are audited at most once per window (0 = every attempt)
+}
+
+// ImpersonationConfig holds "login as customer" configuration for support agents
+type ImpersonationConfig struct {
+	TTLMinutes     int      // Lifetime of an impersonation token; it cannot be refreshed
+	AllowedRoles   []string // Roles that may be impersonated, e.g. customers only
+	MinReasonChars int      // Agents must say why, e.g. a ticket number
+}
+
+// SessionConfig holds concurrent login session configuration
+type SessionConfig struct {
+	MaxSessions     int            // Concurrent sessions per user (0 = unlimited)
+	RoleMaxSessions map[string]int // Per-role overrides, e.g. admin:1 for single-device admins
+	CacheSeconds    int            // How long AuthMiddleware trusts a cached session check
 }
 
 // EmailConfig holds email configuration
@@ -69,6 +171,33 @@ type UploadConfig struct {
 	DocumentMaxSize int64    // Maximum document file size
 }
 
+// ShippingConfig holds shipping document configuration
+type ShippingConfig struct {
+	LabelFontPath   string // UTF-8 TTF font used on labels and packing slips
+	LabelPath       string // Private directory for printed label batches; must not be served statically
+	SlotHoldMinutes int    // How long a delivery slot stays held for a cart before checkout
+
+	// SLA monitoring
+	SLAPickupHours        int // Hours a carrier has to pick up a shipment
+	SLATransitGraceHours  int // Hours allowed past the estimated delivery before a shipment is late
+	SLAMaxFailedAttempts  int // Failed delivery attempts that raise an exception
+	SLADefaultTransitDays int // Transit days when neither the carrier estimate nor a rate is known
+
+	// GHTK account used by providers without their own configuration
+	GHTKBaseURL       string
+	GHTKToken         string
+	GHTKShopID        string
+	GHTKWebhookSecret string // Shared secret for HMAC-SHA256 webhook signatures
+
+	FakeCarrierEnabled bool // Offer the offline fake carrier (never in release mode)
+}
+
+// FakeCarrierAllowed reports whether the fake carrier may be used in the given Gin mode; it is
+// never offered in release mode
+func (c ShippingConfig) FakeCarrierAllowed(ginMode string) bool {
+	return c.FakeCarrierEnabled && !IsReleaseMode(ginMode)
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
@@ -91,9 +220,65 @@ func Load() *Config {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			SecretKey:     getEnv("JWT_SECRET", "your-secret-key"),
-			AccessExpiry:  getEnvAsInt("JWT_ACCESS_EXPIRY", 24),   // 24 hours
-			RefreshExpiry: getEnvAsInt("JWT_REFRESH_EXPIRY", 168), // 7 days
+			SecretKey:         getEnv("JWT_SECRET", "your-secret-key"),
+			Algorithm:         getEnv("JWT_ALGORITHM", "RS256"),
+			AccessExpiry:      getEnvAsInt("JWT_ACCESS_EXPIRY_MINUTES", 15), // 15 minutes
+			RefreshExpiry:     getEnvAsInt("JWT_REFRESH_EXPIRY", 168),       // 7 days
+			KeyRotationDays:   getEnvAsInt("JWT_KEY_ROTATION_DAYS", 30),
+			KeyRetentionHours: getEnvAsInt("JWT_KEY_RETENTION_HOURS", 24),
+		},
+		Session: SessionConfig{
+			MaxSessions:     getEnvAsInt("SESSION_MAX_PER_USER", 5),
+			RoleMaxSessions: getEnvAsIntMap("SESSION_ROLE_LIMITS", map[string]int{"admin": 1}),
+			CacheSeconds:    getEnvAsInt("SESSION_CACHE_SECONDS", 60),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:                  getEnv("TWO_FACTOR_ISSUER", "Go App"),
+			RequiredRoles:           getEnvAsStringSlice("TWO_FACTOR_REQUIRED_ROLES", []string{"admin", "moderator"}),
+			RequireAdminPermissions: getEnvAsBool("TWO_FACTOR_REQUIRE_ADMIN_PERMISSIONS", true),
+			ChallengeMinutes:        getEnvAsInt("TWO_FACTOR_CHALLENGE_MINUTES", 5),
+			MaxAttempts:             getEnvAsInt("TWO_FACTOR_MAX_ATTEMPTS", 5),
+		},
+		Login: LoginSecurityConfig{
+			DelayAfterAttempts: getEnvAsInt("LOGIN_DELAY_AFTER_ATTEMPTS", 3),
+			DelayBaseSeconds:   getEnvAsInt("LOGIN_DELAY_BASE_SECONDS", 2),
+			DelayMaxSeconds:    getEnvAsInt("LOGIN_DELAY_MAX_SECONDS", 60),
+			MaxFailedAttempts:  getEnvAsInt("LOGIN_MAX_FAILED_ATTEMPTS", 10),
+			LockoutMinutes:     getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+			LockoutMaxMinutes:  getEnvAsInt("LOGIN_LOCKOUT_MAX_MINUTES", 1440), // 24 hours
+			CountryHeader:      getEnv("LOGIN_COUNTRY_HEADER", "CF-IPCountry"),
+		},
+		OAuth: OAuthConfig{
+			RedirectURL:  getEnv("OAUTH_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oauth/{provider}/callback"),
+			StateMinutes: getEnvAsInt("OAUTH_STATE_MINUTES", 10),
+
+			GoogleClientID:       getEnv("GOOGLE_CLIENT_ID", ""),
+			GoogleClientSecret:   getEnv("GOOGLE_CLIENT_SECRET", ""),
+			FacebookClientID:     getEnv("FACEBOOK_CLIENT_ID", ""),
+			FacebookClientSecret: getEnv("FACEBOOK_CLIENT_SECRET", ""),
+			ZaloAppID:            getEnv("ZALO_APP_ID", ""),
+			ZaloSecretKey:        getEnv("ZALO_SECRET_KEY", ""),
+
+			MockEnabled: getEnvAsBool("OAUTH_MOCK_ENABLED", false),
+			MockIssuer:  getEnv("OAUTH_MOCK_ISSUER", "http://localhost:8080/oauth/mock"),
+		},
+		Privacy: PrivacyConfig{
+			ExportPath:          getEnv("PRIVACY_EXPORT_PATH", "storage/exports"),
+			ExportTTLHours:      getEnvAsInt("PRIVACY_EXPORT_TTL_HOURS", 72),
+			ExportCooldownHours: getEnvAsInt("PRIVACY_EXPORT_COOLDOWN_HOURS", 24),
+			ErasureGraceDays:    getEnvAsInt("PRIVACY_ERASURE_GRACE_DAYS", 14),
+		},
+		APIKey: APIKeyConfig{
+			MaxPerUser:           getEnvAsInt("API_KEY_MAX_PER_USER", 10),
+			DefaultExpiryDays:    getEnvAsInt("API_KEY_DEFAULT_EXPIRY_DAYS", 90),
+			MaxExpiryDays:        getEnvAsInt("API_KEY_MAX_EXPIRY_DAYS", 365),
+			TouchIntervalSeconds: getEnvAsInt("API_KEY_TOUCH_INTERVAL_SECONDS", 300),
+			RejectAuditSeconds:   getEnvAsInt("API_KEY_REJECT_AUDIT_SECONDS", 60),
+		},
+		Impersonation: ImpersonationConfig{
+			TTLMinutes:     getEnvAsInt("IMPERSONATION_TTL_MINUTES", 30),
+			AllowedRoles:   getEnvAsStringSlice("IMPERSONATION_ALLOWED_ROLES", []string{"user"}),
+			MinReasonChars: getEnvAsInt("IMPERSONATION_MIN_REASON_CHARS", 10),
 		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -111,8 +296,40 @@ func Load() *Config {
 			ImageMaxSize:    getEnvAsInt64("UPLOAD_IMAGE_MAX_SIZE", 5*1024*1024),     // 5MB
 			DocumentMaxSize: getEnvAsInt64("UPLOAD_DOCUMENT_MAX_SIZE", 20*1024*1024), // 20MB
 		},
+		Shipping: ShippingConfig{
+			LabelFontPath:   getEnv("SHIPPING_LABEL_FONT", ""),
+			LabelPath:       getEnv("SHIPPING_LABEL_PATH", "storage/shipping-labels"),
+			SlotHoldMinutes: getEnvAsInt("DELIVERY_SLOT_HOLD_MINUTES", 30),
+
+			SLAPickupHours:        getEnvAsInt("SHIPPING_SLA_PICKUP_HOURS", 24),
+			SLATransitGraceHours:  getEnvAsInt("SHIPPING_SLA_TRANSIT_GRACE_HOURS", 12),
+			SLAMaxFailedAttempts:  getEnvAsInt("SHIPPING_SLA_MAX_FAILED_ATTEMPTS", 2),
+			SLADefaultTransitDays: getEnvAsInt("SHIPPING_SLA_DEFAULT_TRANSIT_DAYS", 5),
+
+			GHTKBaseURL:       getEnv("GHTK_BASE_URL", "https://services.ghtk.vn"),
+			GHTKToken:         getEnv("GHTK_TOKEN", ""),
+			GHTKShopID:        getEnv("GHTK_SHOP_ID", ""),
+			GHTKWebhookSecret: getEnv("GHTK_WEBHOOK_SECRET", ""),
+
+			FakeCarrierEnabled: getEnvAsBool("SHIPPING_FAKE_CARRIER_ENABLED", false),
+		},
 		LogLevel: getEnv("LOG_LEVEL", "info"),
-		GinMode:  "release",
+		GinMode:  getGinMode(),
+	}
+}
+
+// IsReleaseMode reports whether a Gin mode is the release mode
+func IsReleaseMode(ginMode string) bool {
+	return ginMode == "release"
+}
+
+// getGinMode reads GIN_MODE for gin.SetMode, defaulting to release; "production" is accepted as release
+func getGinMode() string {
+	switch mode := getEnv("GIN_MODE", "release"); mode {
+	case "debug", "test":
+		return mode
+	default:
+		return "release"
 	}
 }
 
@@ -134,6 +351,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool gets an environment variable as bool or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsInt64 gets an environment variable as int64 or returns a default value
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	if value := os.Getenv(key); value != "" {
@@ -162,3 +389,23 @@ func getEnvAsStringSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getEnvAsIntMap gets an environment variable of comma-separated key:value pairs or returns a default value
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	if value := os.Getenv(key); value != "" {
+		result := make(map[string]int)
+		for _, part := range strings.Split(value, ",") {
+			pair := strings.SplitN(strings.TrimSpace(part), ":", 2)
+			if len(pair) != 2 {
+				continue
+			}
+			if intValue, err := strconv.Atoi(strings.TrimSpace(pair[1])); err == nil {
+				result[strings.TrimSpace(pair[0])] = intValue
+			}
+		}
+		if len(result) > 0 {
+			return result
+		}
+	}
+	return defaultValue
+}
@@ -10,6 +10,7 @@ import (
 	"go_app/internal/service"
 	"go_app/internal/worker"
 	"go_app/pkg/database"
+	"go_app/pkg/jwt"
 	"go_app/pkg/logger"
 	"go_app/pkg/redis"
 
@@ -28,6 +29,11 @@ func New() *App {
 	// Load configuration
 	config := configs.Load()
 
+	// Refuse to run in production with a sample JWT secret (it also encrypts the signing keys)
+	if configs.IsReleaseMode(config.GinMode) && config.JWT.UsesDefaultSecret() {
+		logger.Fatalf("JWT_SECRET is not set or still uses a sample value; refusing to start in release mode")
+	}
+
 	// Initialize random seed for OTP generation
 	rand.Seed(time.Now().UnixNano())
 
@@ -46,6 +52,13 @@ func New() *App {
 		logger.Warnf("Failed to connect to Redis: %v", err)
 	}
 
+	// Make sure an access token signing key exists before serving requests
+	jwt.SetKeyStore(service.NewSigningKeyStore())
+	jwtManager := jwt.NewJWTManager()
+	if err := jwtManager.EnsureSigningKey(); err != nil {
+		logger.Fatalf("Failed to prepare JWT signing key: %v", err)
+	}
+
 	// Initialize Gin router
 	gin.SetMode(config.GinMode)
 	r := gin.Default()
@@ -61,6 +74,39 @@ func New() *App {
 	notificationWorker := worker.NewNotificationWorker(notificationService)
 	go notificationWorker.Start()
 
+	// Start inventory worker (lot expiry alerts)
+	eventService := service.NewEventService(notificationService, nil, nil)
+	inventoryWorker := worker.NewInventoryWorker(service.NewInventoryServiceWithEvent(eventService))
+	go inventoryWorker.Start()
+
+	// Start backorder worker (restock fulfillment and customer notifications)
+	backorderWorker := worker.NewBackorderWorker(service.NewOrderServiceWithEvent(eventService))
+	go backorderWorker.Start()
+
+	// Start tracking sync worker (carrier polling)
+	trackingSyncWorker := worker.NewTrackingSyncWorker(service.NewOrderTrackingService())
+	go trackingSyncWorker.Start()
+
+	// Start delivery slot worker (expire checkout holds)
+	deliverySlotWorker := worker.NewDeliverySlotWorker(service.NewDeliverySlotService())
+	go deliverySlotWorker.Start()
+
+	// Start shipping SLA worker (late shipment exceptions and admin alerts)
+	shippingSLAWorker := worker.NewShippingSLAWorker(service.NewShippingSLAServiceWithEvent(eventService))
+	go shippingSLAWorker.Start()
+
+	// Start JWT key worker (scheduled signing key rotation)
+	jwtKeyWorker := worker.NewJWTKeyWorker(jwtManager)
+	go jwtKeyWorker.Start()
+
+	// Start privacy worker (personal data exports and account erasure)
+	privacyWorker := worker.NewPrivacyWorker(service.NewPrivacyService())
+	go privacyWorker.Start()
+
+	// Start permission worker (time-bound role grants and expiring user permissions)
+	permissionWorker := worker.NewPermissionWorker(service.NewPermissionService())
+	go permissionWorker.Start()
+
 	return &App{
 		Config: config,
 		Router: r,
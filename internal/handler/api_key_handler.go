@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles personal access tokens used by integrations
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: service.NewAPIKeyService(),
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Create a scoped API key for an integration. The key is only returned once; send it as X-API-Key or "Authorization: Bearer ak_...".
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateAPIKeyRequest true "Name, scopes (resource.action or resource.*), allowed IPs and expiry"
+// @Success 201 {object} response.Response{data=service.CreateAPIKeyResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req service.CreateAPIKeyRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	result, err := h.apiKeyService.CreateAPIKey(userID.(uint), &req, auditRequestInfo(c))
+	if err != nil {
+		h.handleError(c, "Failed to create API key", err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	response.SuccessResponse(c, http.StatusCreated, "API key created, store it now as it will not be shown again", result)
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List the user's API keys with scopes, expiry and last use
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.APIKeyResponse}
+// @Failure 401 {object} response.Response
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	keys, err := h.apiKeyService.GetAPIKeys(userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve API keys", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Revoke one of the user's API keys; requests made with it are rejected immediately
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID", err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if err := h.apiKeyService.RevokeAPIKey(userID.(uint), uint(id), auditRequestInfo(c)); err != nil {
+		h.handleError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// GetAllAPIKeys godoc
+// @Summary List all API keys
+// @Description List API keys of all users, optionally filtered by owner and status
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param user_id query int false "Owner user ID"
+// @Param status query string false "active, revoked or expired"
+// @Success 200 {object} response.Response{data=[]model.APIKeyResponse}
+// @Failure 500 {object} response.Response
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) GetAllAPIKeys(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if userID := c.Query("user_id"); userID != "" {
+		if id, err := strconv.ParseUint(userID, 10, 32); err == nil {
+			filters["user_id"] = uint(id)
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	keys, total, err := h.apiKeyService.GetAllAPIKeys(page, limit, filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve API keys", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "API keys retrieved successfully", keys, page, limit, total)
+}
+
+// AdminRevokeAPIKey godoc
+// @Summary Revoke any API key
+// @Description Revoke another user's API key, e.g. when a connector is decommissioned or a key has leaked
+// @Tags admin
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) AdminRevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID", err.Error())
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.apiKeyService.AdminRevokeAPIKey(adminID.(uint), uint(id), auditRequestInfo(c)); err != nil {
+		h.handleError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+func (h *APIKeyHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
+
+// auditRequestInfo collects the request details recorded in the audit log
+func auditRequestInfo(c *gin.Context) service.AuditRequestInfo {
+	info := service.AuditRequestInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+	if sessionID, exists := c.Get("session_id"); exists {
+		info.SessionID = fmt.Sprint(sessionID)
+	}
+	return info
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CODRemittanceHandler handles COD remittance reconciliation HTTP requests
+type CODRemittanceHandler struct {
+	remittanceService service.CODRemittanceService
+}
+
+// NewCODRemittanceHandler creates a new CODRemittanceHandler
+func NewCODRemittanceHandler() *CODRemittanceHandler {
+	return &CODRemittanceHandler{
+		remittanceService: service.NewCODRemittanceService(),
+	}
+}
+
+// ImportRemittance imports a carrier's COD remittance statement, either as a JSON body
+// or as an uploaded .csv/.json file with provider_id, statement_number and statement_date form fields
+func (h *CODRemittanceHandler) ImportRemittance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	var req model.CODRemittanceImportRequest
+	var parseErrors []string
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		var ok bool
+		parseErrors, ok = h.bindStatementFile(c, &req)
+		if !ok {
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	remittance, err := h.remittanceService.ImportCODRemittance(&req, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to import COD remittance", err)
+		return
+	}
+	remittance.Errors = parseErrors
+
+	response.SuccessResponse(c, http.StatusCreated, "COD remittance imported successfully", remittance)
+}
+
+// bindStatementFile reads the statement fields and lines of a multipart upload
+func (h *CODRemittanceHandler) bindStatementFile(c *gin.Context, req *model.CODRemittanceImportRequest) ([]string, bool) {
+	providerID, err := strconv.ParseUint(c.PostForm("provider_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid provider ID", "provider_id is required")
+		return nil, false
+	}
+	req.ProviderID = uint(providerID)
+	req.StatementNumber = c.PostForm("statement_number")
+	if statementDate := c.PostForm("statement_date"); statementDate != "" {
+		date, err := time.Parse("2006-01-02", statementDate)
+		if err != nil {
+			response.ErrorResponse(c, http.StatusBadRequest, "Invalid statement date", "statement_date must be YYYY-MM-DD")
+			return nil, false
+		}
+		req.StatementDate = &date
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "No file uploaded", err.Error())
+		return nil, false
+	}
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".csv" && ext != ".json" {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid file type", "only .csv and .json files are accepted")
+		return nil, false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", err.Error())
+		return nil, false
+	}
+	defer src.Close()
+
+	var parseErrors []string
+	if ext == ".csv" {
+		req.Lines, parseErrors, err = h.remittanceService.ParseCODStatementCSV(src)
+	} else {
+		req.Lines, err = h.remittanceService.ParseCODStatementJSON(src)
+	}
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to read statement", err.Error())
+		return nil, false
+	}
+
+	req.Source = strings.TrimPrefix(ext, ".")
+	req.FileName = file.Filename
+	return parseErrors, true
+}
+
+// GetRemittances retrieves imported statements with pagination and filters
+func (h *CODRemittanceHandler) GetRemittances(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if providerID := c.Query("provider_id"); providerID != "" {
+		if id, err := strconv.ParseUint(providerID, 10, 32); err == nil {
+			filters["provider_id"] = uint(id)
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		if date, err := time.Parse("2006-01-02", dateFrom); err == nil {
+			filters["date_from"] = date
+		}
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		if date, err := time.Parse("2006-01-02", dateTo); err == nil {
+			filters["date_to"] = date
+		}
+	}
+
+	remittances, total, err := h.remittanceService.GetCODRemittances(page, limit, filters)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve COD remittances", err.Error())
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "COD remittances retrieved successfully", remittances, page, limit, total)
+}
+
+// GetRemittanceByID retrieves a statement with its lines; ?line_status=discrepancy lists only flagged lines
+func (h *CODRemittanceHandler) GetRemittanceByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid remittance ID", err.Error())
+		return
+	}
+
+	remittance, err := h.remittanceService.GetCODRemittance(uint(id), c.Query("line_status"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve COD remittance", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "COD remittance retrieved successfully", remittance)
+}
+
+// GetOutstanding reports the COD not yet remitted by each carrier
+func (h *CODRemittanceHandler) GetOutstanding(c *gin.Context) {
+	reports, err := h.remittanceService.GetOutstandingCOD()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve outstanding COD", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Outstanding COD retrieved successfully", reports)
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *CODRemittanceHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case err.Error() == "cod remittance not found", err.Error() == "shipping provider not found":
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case err.Error() == "cod remittance statement already imported":
+		response.ErrorResponse(c, http.StatusConflict, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
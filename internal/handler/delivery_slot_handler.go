@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeliverySlotHandler handles delivery time slot HTTP requests
+type DeliverySlotHandler struct {
+	slotService service.DeliverySlotService
+}
+
+// NewDeliverySlotHandler creates a new DeliverySlotHandler
+func NewDeliverySlotHandler() *DeliverySlotHandler {
+	return &DeliverySlotHandler{
+		slotService: service.NewDeliverySlotService(),
+	}
+}
+
+// Templates
+
+// CreateTemplate creates a new delivery slot template
+func (h *DeliverySlotHandler) CreateTemplate(c *gin.Context) {
+	var req model.DeliverySlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	template, err := h.slotService.CreateTemplate(&req)
+	if err != nil {
+		h.handleError(c, "Failed to create delivery slot template", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Delivery slot template created successfully", template)
+}
+
+// GetTemplates lists delivery slot templates
+func (h *DeliverySlotHandler) GetTemplates(c *gin.Context) {
+	filters := make(map[string]interface{})
+	if province := c.Query("province"); province != "" {
+		filters["province"] = province
+		if district := c.Query("district"); district != "" {
+			filters["district"] = district
+		}
+	}
+	if isActive := c.Query("is_active"); isActive != "" {
+		filters["is_active"] = isActive == "true"
+	}
+
+	templates, err := h.slotService.GetTemplates(filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve delivery slot templates", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot templates retrieved successfully", templates)
+}
+
+// GetTemplateByID retrieves a delivery slot template by its ID
+func (h *DeliverySlotHandler) GetTemplateByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid template ID", err.Error())
+		return
+	}
+
+	template, err := h.slotService.GetTemplateByID(uint(id))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve delivery slot template", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot template retrieved successfully", template)
+}
+
+// UpdateTemplate updates a delivery slot template
+func (h *DeliverySlotHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid template ID", err.Error())
+		return
+	}
+
+	var req model.DeliverySlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	template, err := h.slotService.UpdateTemplate(uint(id), &req)
+	if err != nil {
+		h.handleError(c, "Failed to update delivery slot template", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot template updated successfully", template)
+}
+
+// DeleteTemplate deletes a delivery slot template
+func (h *DeliverySlotHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid template ID", err.Error())
+		return
+	}
+
+	if err := h.slotService.DeleteTemplate(uint(id)); err != nil {
+		h.handleError(c, "Failed to delete delivery slot template", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot template deleted successfully", nil)
+}
+
+// Availability
+
+// GetAvailability lists the delivery windows offered to an address with their remaining capacity
+func (h *DeliverySlotHandler) GetAvailability(c *gin.Context) {
+	province := c.Query("province")
+	district := c.Query("district")
+	if province == "" || district == "" {
+		response.ErrorResponse(c, http.StatusBadRequest, "Province and district are required", nil)
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+
+	slots, err := h.slotService.GetAvailability(province, district, c.Query("from"), days)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve delivery slots", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slots retrieved successfully", slots)
+}
+
+// Cart reservations
+
+// ReserveCartSlot holds a delivery slot for a cart during checkout
+func (h *DeliverySlotHandler) ReserveCartSlot(c *gin.Context) {
+	cartID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid cart ID", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req model.DeliverySlotReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	req.CartID = uint(cartID)
+
+	reservation, err := h.slotService.ReserveSlot(&req, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to reserve delivery slot", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Delivery slot reserved successfully", reservation)
+}
+
+// GetCartSlot retrieves the delivery slot held for a cart
+func (h *DeliverySlotHandler) GetCartSlot(c *gin.Context) {
+	cartID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid cart ID", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	reservation, err := h.slotService.GetCartReservation(uint(cartID), userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve delivery slot", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot retrieved successfully", reservation)
+}
+
+// ReleaseCartSlot gives back the delivery slot held for a cart
+func (h *DeliverySlotHandler) ReleaseCartSlot(c *gin.Context) {
+	cartID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid cart ID", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.slotService.ReleaseCartReservation(uint(cartID), userID.(uint)); err != nil {
+		h.handleError(c, "Failed to release delivery slot", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot released successfully", nil)
+}
+
+// Reporting
+
+// GetUtilization reports slot bookings against capacity per window and day
+func (h *DeliverySlotHandler) GetUtilization(c *gin.Context) {
+	report, err := h.slotService.GetUtilization(c.Query("from"), c.Query("to"), c.Query("province"), c.Query("district"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve delivery slot utilization", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Delivery slot utilization retrieved successfully", report)
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *DeliverySlotHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasSuffix(err.Error(), "fully booked"):
+		response.ErrorResponse(c, http.StatusConflict, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
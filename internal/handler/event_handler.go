@@ -105,7 +105,7 @@ func (h *EventHandler) TriggerOrderShipped(c *gin.Context) {
 		return
 	}
 
-	err := h.eventService.OnOrderShipped(&req.Order, req.TrackingNumber)
+	err := h.eventService.OnOrderShipped(&req.Order, &model.Shipment{OrderID: req.Order.ID, TrackingNumber: req.TrackingNumber})
 	if err != nil {
 		logger.Errorf("Failed to trigger order shipped event: %v", err)
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to trigger event", err.Error())
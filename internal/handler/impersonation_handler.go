@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationHandler handles support agents acting as a customer
+type ImpersonationHandler struct {
+	impersonationService *service.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new ImpersonationHandler
+func NewImpersonationHandler() *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: service.NewImpersonationService(),
+	}
+}
+
+// StartImpersonation godoc
+// @Summary Impersonate customer
+// @Description Get a short-lived token acting as the customer to reproduce cart or checkout problems. Requires customer.impersonate. Password changes, payments and other account management are blocked while impersonating.
+// @Tags impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer user ID"
+// @Param request body service.StartImpersonationRequest true "Reason, e.g. a support ticket number"
+// @Success 201 {object} response.Response{data=service.StartImpersonationResponse}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /impersonation/users/{id} [post]
+func (h *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req service.StartImpersonationRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	actorID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+	result, err := h.impersonationService.StartImpersonation(actorID.(uint), sessionID.(uint), uint(id), &req, auditRequestInfo(c))
+	if err != nil {
+		h.handleError(c, "Failed to start impersonation", err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	response.SuccessResponse(c, http.StatusCreated, "Impersonation started", result)
+}
+
+// GetImpersonation godoc
+// @Summary Get impersonation banner
+// @Description Tell the frontend whether the current token acts as a customer, and for whom, so it can show a banner
+// @Tags impersonation
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.ImpersonationBanner}
+// @Failure 401 {object} response.Response
+// @Router /impersonation [get]
+func (h *ImpersonationHandler) GetImpersonation(c *gin.Context) {
+	impersonationID, exists := c.Get("impersonation_id")
+	if !exists {
+		response.SuccessResponse(c, http.StatusOK, "Not impersonating", model.ImpersonationBanner{})
+		return
+	}
+
+	banner, err := h.impersonationService.GetImpersonation(impersonationID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve impersonation", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Impersonation retrieved successfully", banner)
+}
+
+// StopImpersonation godoc
+// @Summary Stop impersonation
+// @Description End the impersonation made with the current token; the agent continues with their own token
+// @Tags impersonation
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /impersonation [delete]
+func (h *ImpersonationHandler) StopImpersonation(c *gin.Context) {
+	impersonationID, exists := c.Get("impersonation_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to stop impersonation", "this token is not impersonating anyone")
+		return
+	}
+
+	actorID, _ := c.Get("impersonator_id")
+	if err := h.impersonationService.StopImpersonation(impersonationID.(uint), actorID.(uint), auditRequestInfo(c)); err != nil {
+		h.handleError(c, "Failed to stop impersonation", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Impersonation stopped", nil)
+}
+
+// GetImpersonations godoc
+// @Summary List impersonations
+// @Description List support impersonations, optionally filtered by agent, customer or only running ones
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param actor_id query int false "Agent user ID"
+// @Param subject_id query int false "Customer user ID"
+// @Param active query bool false "Only running impersonations"
+// @Success 200 {object} response.Response{data=[]model.Impersonation}
+// @Failure 500 {object} response.Response
+// @Router /admin/impersonations [get]
+func (h *ImpersonationHandler) GetImpersonations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"actor_id", "subject_id"} {
+		if value := c.Query(key); value != "" {
+			if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+				filters[key] = uint(id)
+			}
+		}
+	}
+	if c.Query("active") == "true" {
+		filters["active"] = true
+	}
+
+	impersonations, total, err := h.impersonationService.GetImpersonations(page, limit, filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve impersonations", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Impersonations retrieved successfully", impersonations, page, limit, total)
+}
+
+// RevokeImpersonation godoc
+// @Summary Revoke impersonation
+// @Description End another agent's impersonation immediately
+// @Tags admin
+// @Produce json
+// @Param id path int true "Impersonation ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/impersonations/{id} [delete]
+func (h *ImpersonationHandler) RevokeImpersonation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid impersonation ID", err.Error())
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.impersonationService.RevokeImpersonation(uint(id), adminID.(uint), auditRequestInfo(c)); err != nil {
+		h.handleError(c, "Failed to revoke impersonation", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Impersonation revoked successfully", nil)
+}
+
+func (h *ImpersonationHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
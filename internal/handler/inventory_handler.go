@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"go_app/internal/model"
+	"go_app/internal/repository"
 	"go_app/internal/service"
 	"go_app/pkg/response"
 
@@ -19,8 +21,12 @@ type InventoryHandler struct {
 
 // NewInventoryHandler creates a new InventoryHandler
 func NewInventoryHandler() *InventoryHandler {
+	// Event service delivers near-expiry lot alerts
+	notificationService := service.NewNotificationService(repository.NewNotificationRepository(), repository.NewUserRepository())
+	eventService := service.NewEventService(notificationService, nil, nil)
+
 	return &InventoryHandler{
-		inventoryService: service.NewInventoryService(),
+		inventoryService: service.NewInventoryServiceWithEvent(eventService),
 	}
 }
 
@@ -190,6 +196,10 @@ func (h *InventoryHandler) ApproveMovement(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeMovementConditions(c, uint(id)) {
+		return
+	}
+
 	movement, err := h.inventoryService.ApproveMovement(uint(id), userID.(uint))
 	if err != nil {
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to approve movement", err.Error())
@@ -208,6 +218,10 @@ func (h *InventoryHandler) CompleteMovement(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeMovementConditions(c, uint(id)) {
+		return
+	}
+
 	movement, err := h.inventoryService.CompleteMovement(uint(id))
 	if err != nil {
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to complete movement", err.Error())
@@ -217,6 +231,32 @@ func (h *InventoryHandler) CompleteMovement(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Movement completed successfully", movement)
 }
 
+// authorizeMovementConditions checks a conditional inventory.manage grant against the movement,
+// e.g. {"attribute":"amount","operator":"lt","value":10000000} to approve only movements under 10M VND
+func (h *InventoryHandler) authorizeMovementConditions(c *gin.Context, id uint) bool {
+	if !c.GetBool("permission_conditional") {
+		return true
+	}
+
+	movement, err := h.inventoryService.GetMovementByID(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "movement not found" {
+			status = http.StatusNotFound
+		}
+		response.ErrorResponse(c, status, "Failed to retrieve movement", err.Error())
+		return false
+	}
+
+	return authorizeResourceConditions(c, model.ResourceTypeInventory, model.PermissionTypeManage, &id, map[string]interface{}{
+		"amount":     math.Abs(movement.TotalCost),
+		"quantity":   movement.Quantity,
+		"type":       string(movement.Type),
+		"product_id": movement.ProductID,
+		"created_by": movement.CreatedBy,
+	})
+}
+
 // GetMovementsByProduct retrieves movements for a specific product/variant
 func (h *InventoryHandler) GetMovementsByProduct(c *gin.Context) {
 	productIDStr := c.Param("product_id")
@@ -312,6 +352,9 @@ func (h *InventoryHandler) GetAllStockLevels(c *gin.Context) {
 			filters["variant_id"] = &vID
 		}
 	}
+	if location := c.Query("location"); location != "" {
+		filters["location"] = location
+	}
 	if lowStock := c.Query("low_stock"); lowStock == "true" {
 		filters["low_stock"] = true
 	}
@@ -647,3 +690,137 @@ func (h *InventoryHandler) ReleaseStock(c *gin.Context) {
 
 	response.SuccessResponse(c, http.StatusOK, "Stock released successfully", nil)
 }
+
+// Inventory Lots
+
+// GetLots retrieves inventory lots with pagination and filters
+func (h *InventoryHandler) GetLots(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := h.lotFilters(c)
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if lotNumber := c.Query("lot_number"); lotNumber != "" {
+		filters["lot_number"] = lotNumber
+	}
+	if inStock := c.Query("in_stock"); inStock != "" {
+		filters["in_stock"] = inStock == "true"
+	}
+	if expiringBefore := c.Query("expiring_before"); expiringBefore != "" {
+		if date, err := time.Parse("2006-01-02", expiringBefore); err == nil {
+			filters["expiring_before"] = date
+		}
+	}
+
+	lots, total, err := h.inventoryService.GetLots(page, limit, filters)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory lots", err.Error())
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Inventory lots retrieved successfully", lots, page, limit, total)
+}
+
+// GetLotByID retrieves an inventory lot by its ID
+func (h *InventoryHandler) GetLotByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid lot ID", err.Error())
+		return
+	}
+
+	lot, err := h.inventoryService.GetLotByID(uint(id))
+	if err != nil {
+		if err.Error() == "lot not found" {
+			response.ErrorResponse(c, http.StatusNotFound, "Inventory lot not found", err.Error())
+			return
+		}
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory lot", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Inventory lot retrieved successfully", lot)
+}
+
+// GetPickPlan previews the FEFO lots that would be picked for a quantity
+func (h *InventoryHandler) GetPickPlan(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Query("product_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	quantity, err := strconv.Atoi(c.Query("quantity"))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid quantity", err.Error())
+		return
+	}
+
+	var variantID *uint
+	if variantIDStr := c.Query("variant_id"); variantIDStr != "" {
+		if id, err := strconv.ParseUint(variantIDStr, 10, 32); err == nil {
+			vID := uint(id)
+			variantID = &vID
+		}
+	}
+
+	plan, err := h.inventoryService.GetPickPlan(uint(productID), variantID, quantity)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to build pick plan", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Pick plan retrieved successfully", plan)
+}
+
+// GetLotStockReport retrieves the stock-by-lot report
+func (h *InventoryHandler) GetLotStockReport(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid days", err.Error())
+		return
+	}
+
+	report, err := h.inventoryService.GetLotStockReport(days, h.lotFilters(c))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve lot stock report", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Lot stock report retrieved successfully", report)
+}
+
+// CheckNearExpiryLots sends alerts for lots expiring within the given number of days
+func (h *InventoryHandler) CheckNearExpiryLots(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid days", err.Error())
+		return
+	}
+
+	result, err := h.inventoryService.CheckNearExpiryLots(days)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check near-expiry lots", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Near-expiry lots checked successfully", result)
+}
+
+// lotFilters parses the product/variant filters shared by lot endpoints
+func (h *InventoryHandler) lotFilters(c *gin.Context) map[string]interface{} {
+	filters := make(map[string]interface{})
+	if productID := c.Query("product_id"); productID != "" {
+		if id, err := strconv.ParseUint(productID, 10, 32); err == nil {
+			filters["product_id"] = uint(id)
+		}
+	}
+	if variantID := c.Query("variant_id"); variantID != "" {
+		if id, err := strconv.ParseUint(variantID, 10, 32); err == nil {
+			filters["variant_id"] = uint(id)
+		}
+	}
+	return filters
+}
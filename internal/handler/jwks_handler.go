@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"go_app/pkg/jwt"
+	"go_app/pkg/logger"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys used to sign access tokens
+type JWKSHandler struct {
+	jwtManager *jwt.JWTManager
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{
+		jwtManager: jwt.NewJWTManager(),
+	}
+}
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys (by kid) that other services use to verify access tokens. Returned as a bare JWKS document, not wrapped in the usual response envelope
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Failure 500 {object} response.Response
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	keys, err := h.jwtManager.JWKS()
+	if err != nil {
+		logger.Errorf("Failed to load JWKS: %v", err)
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to load signing keys")
+		return
+	}
+
+	// Verifiers refetch on an unknown kid, so a short cache is enough to pick up rotations
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys)
+}
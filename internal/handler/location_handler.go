@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocationHandler handles Vietnamese administrative directory HTTP requests
+type LocationHandler struct {
+	locationService service.LocationService
+}
+
+// NewLocationHandler creates a new LocationHandler
+func NewLocationHandler() *LocationHandler {
+	return &LocationHandler{
+		locationService: service.NewLocationService(),
+	}
+}
+
+// GetProvinces lists all provinces
+func (h *LocationHandler) GetProvinces(c *gin.Context) {
+	provinces, err := h.locationService.GetProvinces()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve provinces", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Provinces retrieved successfully", provinces)
+}
+
+// GetDistricts lists the districts of a province
+func (h *LocationHandler) GetDistricts(c *gin.Context) {
+	districts, err := h.locationService.GetDistricts(c.Param("code"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve districts", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Districts retrieved successfully", districts)
+}
+
+// GetWards lists the wards of a district
+func (h *LocationHandler) GetWards(c *gin.Context) {
+	wards, err := h.locationService.GetWards(c.Param("code"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve wards", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Wards retrieved successfully", wards)
+}
+
+// Resolve matches free-text province, district and ward names to the directory
+func (h *LocationHandler) Resolve(c *gin.Context) {
+	province := c.Query("province")
+	if province == "" {
+		response.ErrorResponse(c, http.StatusBadRequest, "Province is required", "province query parameter is required")
+		return
+	}
+
+	resolved, err := h.locationService.Resolve(province, c.Query("district"), c.Query("ward"))
+	if err != nil {
+		h.handleError(c, "Failed to resolve location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Location resolved successfully", resolved)
+}
+
+// ImportDataset loads the bundled directory, or an uploaded .json dataset in the same format
+func (h *LocationHandler) ImportDataset(c *gin.Context) {
+	var result *model.LocationImportResult
+	var err error
+
+	if file, fileErr := c.FormFile("file"); fileErr == nil {
+		if strings.ToLower(filepath.Ext(file.Filename)) != ".json" {
+			response.ErrorResponse(c, http.StatusBadRequest, "Invalid file type", "only .json files are accepted")
+			return
+		}
+
+		src, openErr := file.Open()
+		if openErr != nil {
+			response.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", openErr.Error())
+			return
+		}
+		defer src.Close()
+
+		result, err = h.locationService.ImportDataset(src)
+	} else {
+		result, err = h.locationService.ImportBundledDataset()
+	}
+	if err != nil {
+		h.handleError(c, "Failed to import address dataset", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Address dataset imported successfully", result)
+}
+
+// NormalizeLocations rewrites stored addresses and shipping rate zones to the directory's names
+func (h *LocationHandler) NormalizeLocations(c *gin.Context) {
+	var req model.LocationNormalizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	results, err := h.locationService.NormalizeLocations(&req)
+	if err != nil {
+		h.handleError(c, "Failed to normalize locations", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Locations normalized successfully", results)
+}
+
+// GetCarrierCodes lists a carrier's code mappings; ?level= filters by level
+func (h *LocationHandler) GetCarrierCodes(c *gin.Context) {
+	codes, err := h.locationService.GetCarrierCodes(c.Param("carrier"), c.Query("level"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve carrier location codes", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Carrier location codes retrieved successfully", codes)
+}
+
+// UpsertCarrierCodes creates or updates a carrier's code mappings
+func (h *LocationHandler) UpsertCarrierCodes(c *gin.Context) {
+	var req model.CarrierLocationCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	codes, err := h.locationService.UpsertCarrierCodes(c.Param("carrier"), &req)
+	if err != nil {
+		h.handleError(c, "Failed to save carrier location codes", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Carrier location codes saved successfully", codes)
+}
+
+// GetCarrierLocation translates province_code, district_code and ward_code to a carrier's codes and names
+func (h *LocationHandler) GetCarrierLocation(c *gin.Context) {
+	location, err := h.locationService.GetCarrierLocation(c.Param("carrier"), c.Query("province_code"), c.Query("district_code"), c.Query("ward_code"))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve carrier location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Carrier location retrieved successfully", location)
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *LocationHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
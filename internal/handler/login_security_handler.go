@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginSecurityHandler handles admin endpoints for locked accounts and login history
+type LoginSecurityHandler struct {
+	loginSecurityService *service.LoginSecurityService
+}
+
+// NewLoginSecurityHandler creates a new LoginSecurityHandler
+func NewLoginSecurityHandler() *LoginSecurityHandler {
+	return &LoginSecurityHandler{
+		loginSecurityService: service.NewLoginSecurityService(),
+	}
+}
+
+// GetLockedAccounts godoc
+// @Summary List locked accounts
+// @Description List accounts temporarily locked after too many failed logins
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} response.Response{data=[]model.LockedAccountResponse}
+// @Failure 500 {object} response.Response
+// @Router /admin/locked-accounts [get]
+func (h *LoginSecurityHandler) GetLockedAccounts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	accounts, total, err := h.loginSecurityService.GetLockedAccounts(page, limit)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve locked accounts", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Locked accounts retrieved successfully", accounts, page, limit, total)
+}
+
+// UnlockAccount godoc
+// @Summary Unlock account
+// @Description Lift a temporary login lock before it expires
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/users/{id}/unlock [post]
+func (h *LoginSecurityHandler) UnlockAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.loginSecurityService.UnlockAccount(uint(id), adminID.(uint)); err != nil {
+		h.handleError(c, "Failed to unlock account", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Account unlocked successfully", nil)
+}
+
+// GetLoginAttempts godoc
+// @Summary List login attempts
+// @Description List a user's login attempts, newest first, including failures and suspicious logins
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=[]model.LoginAttempt}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/users/{id}/login-attempts [get]
+func (h *LoginSecurityHandler) GetLoginAttempts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	attempts, total, err := h.loginSecurityService.GetLoginAttempts(uint(id), page, limit)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve login attempts", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Login attempts retrieved successfully", attempts, page, limit, total)
+}
+
+func (h *LoginSecurityHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"go_app/configs"
+	"go_app/pkg/logger"
+	"go_app/pkg/oauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MockOIDCHandler serves the local mock OpenID Connect issuer used to develop social login
+type MockOIDCHandler struct {
+	server http.Handler
+}
+
+// NewMockOIDCHandler creates the mock issuer, or returns nil when OAUTH_MOCK_ENABLED is off
+// or the app runs in release mode
+func NewMockOIDCHandler() *MockOIDCHandler {
+	appConfig := configs.Load()
+	config := appConfig.OAuth
+	if !config.MockAllowed(appConfig.GinMode) {
+		return nil
+	}
+
+	server, err := oauth.NewMockServer(oauth.MockServerConfig{
+		Issuer:       config.MockIssuer,
+		ClientID:     oauth.MockClientID,
+		ClientSecret: oauth.MockClientSecret,
+	})
+	if err != nil {
+		logger.Errorf("Failed to start mock OIDC issuer: %v", err)
+		return nil
+	}
+
+	logger.Warnf("Mock OIDC issuer enabled at %s, do not use in production", config.MockIssuer)
+	return &MockOIDCHandler{
+		server: http.StripPrefix("/oauth/mock", server),
+	}
+}
+
+// Serve handles discovery, authorize, token, userinfo and jwks requests of the mock issuer
+func (h *MockOIDCHandler) Serve(c *gin.Context) {
+	h.server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+	"go_app/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthLinkCookie holds the state of a link flow in the browser that started it
+const oauthLinkCookie = "oauth_link_state"
+
+// setOAuthLinkCookie stores (or with an empty state and maxAge -1, clears) the link flow state
+// in an HttpOnly cookie; Lax so it is sent on the provider's top-level redirect back
+func setOAuthLinkCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthLinkCookie, state, maxAge, "/", "", gin.Mode() == gin.ReleaseMode, true)
+}
+
+// GetOAuthProviders godoc
+// @Summary List social login providers
+// @Description Providers that are configured on this server (google, facebook, zalo, and mock in development)
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response{data=model.OAuthProvidersResponse}
+// @Router /auth/oauth/providers [get]
+func (h *AuthHandler) GetOAuthProviders(c *gin.Context) {
+	response.SuccessResponse(c, http.StatusOK, "OAuth providers retrieved successfully", h.authService.GetOAuthProviders())
+}
+
+// AuthorizeOAuth godoc
+// @Summary Start social login
+// @Description Start the authorization code + PKCE flow. Redirect the browser to authorization_url; the provider sends it back to the callback with code and state
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider" Enums(google, facebook, zalo, mock)
+// @Param request body service.OAuthAuthorizeRequest true "Device of the login"
+// @Success 200 {object} response.Response{data=model.OAuthAuthorizeResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/oauth/{provider}/authorize [post]
+func (h *AuthHandler) AuthorizeOAuth(c *gin.Context) {
+	var req service.OAuthAuthorizeRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	authorize, err := h.authService.StartOAuth(c.Param("provider"), model.OAuthPurposeLogin, nil, req.DeviceID)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to start social login", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Redirect to the authorization URL", authorize)
+}
+
+// OAuthCallback godoc
+// @Summary Finish social login
+// @Description Exchange the code for the provider identity. Accepts code and state as query parameters (provider redirect) or as a JSON body (forwarded by the frontend). Signs in the linked user, links an existing account with the same verified email, or registers a new one. Returns tokens or a two-factor challenge like /auth/login; for a link flow returns the linked identity
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider" Enums(google, facebook, zalo, mock)
+// @Param code query string false "Authorization code"
+// @Param state query string false "State from the authorize step"
+// @Success 200 {object} response.Response{data=service.AuthResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/oauth/{provider}/callback [get]
+// @Router /auth/oauth/{provider}/callback [post]
+func (h *AuthHandler) OAuthCallback(c *gin.Context) {
+	// The user cancelled or the provider refused the request
+	if providerError := c.Query("error"); providerError != "" {
+		response.ErrorResponse(c, http.StatusBadRequest, "Authorization was not granted", strings.TrimSpace(providerError+" "+c.Query("error_description")))
+		return
+	}
+
+	var req service.OAuthCallbackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := validator.ValidateStruct(req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.GetHeader("User-Agent")
+	if linkState, err := c.Cookie(oauthLinkCookie); err == nil {
+		req.LinkState = linkState
+		setOAuthLinkCookie(c, "", -1)
+	}
+	if country := strings.TrimSpace(c.GetHeader(h.countryHeader)); h.countryHeader != "" && len(country) == 2 {
+		req.Country = strings.ToUpper(country)
+	}
+
+	authResponse, identity, err := h.authService.OAuthCallback(c.Param("provider"), &req)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to sign in with provider", err, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case identity != nil:
+		response.SuccessResponse(c, http.StatusOK, "Account linked successfully", identity)
+	case authResponse.TwoFactorRequired:
+		response.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", authResponse)
+	default:
+		response.SuccessResponse(c, http.StatusOK, "Login successful", authResponse)
+	}
+}
+
+// LinkOAuth godoc
+// @Summary Link a social account
+// @Description Start the authorization flow that links a provider account to the signed-in user; the callback returns the linked identity. The state is also set in an HttpOnly cookie, so the callback must be made by the same browser
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider" Enums(google, facebook, zalo, mock)
+// @Success 200 {object} response.Response{data=model.OAuthAuthorizeResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/oauth/{provider}/link [post]
+func (h *AuthHandler) LinkOAuth(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id := userID.(uint)
+
+	authorize, err := h.authService.StartOAuth(c.Param("provider"), model.OAuthPurposeLink, &id, "")
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to start account linking", err, http.StatusBadRequest)
+		return
+	}
+
+	setOAuthLinkCookie(c, authorize.State, int(time.Until(authorize.ExpiresAt).Seconds()))
+	response.SuccessResponse(c, http.StatusOK, "Redirect to the authorization URL", authorize)
+}
+
+// GetIdentities godoc
+// @Summary List linked social accounts
+// @Description Social login accounts linked to the current user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response{data=[]model.UserIdentity}
+// @Router /auth/identities [get]
+func (h *AuthHandler) GetIdentities(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	identities, err := h.authService.GetIdentities(userID.(uint))
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to get linked accounts", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Linked accounts retrieved successfully", identities)
+}
+
+// UnlinkIdentity godoc
+// @Summary Unlink a social account
+// @Description Remove a linked social account. The last sign-in method of an account without a password cannot be removed
+// @Tags auth
+// @Produce json
+// @Param id path int true "Identity ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/identities/{id} [delete]
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid identity ID")
+		return
+	}
+	userID, _ := c.Get("user_id")
+
+	if err := h.authService.UnlinkIdentity(userID.(uint), uint(id)); err != nil {
+		h.handleTwoFactorError(c, "Failed to unlink account", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Account unlinked successfully", nil)
+}
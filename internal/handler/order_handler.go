@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go_app/internal/model"
 	"go_app/internal/repository"
@@ -21,9 +22,9 @@ type OrderHandler struct {
 // NewOrderHandler creates a new OrderHandler
 func NewOrderHandler() *OrderHandler {
 	// Initialize services
-	orderService := service.NewOrderService()
 	notificationService := service.NewNotificationService(repository.NewNotificationRepository(), repository.NewUserRepository())
 	eventService := service.NewEventService(notificationService, nil, nil)
+	orderService := service.NewOrderServiceWithEvent(eventService)
 
 	return &OrderHandler{
 		orderService: orderService,
@@ -165,6 +166,11 @@ func (h *OrderHandler) GetAllOrders(c *gin.Context) {
 	if paymentMethod := c.Query("payment_method"); paymentMethod != "" {
 		filters["payment_method"] = paymentMethod
 	}
+	if pickupLocationID := c.Query("pickup_location_id"); pickupLocationID != "" {
+		if id, err := strconv.ParseUint(pickupLocationID, 10, 32); err == nil {
+			filters["pickup_location_id"] = uint(id)
+		}
+	}
 	if userID := c.Query("user_id"); userID != "" {
 		if id, err := strconv.ParseUint(userID, 10, 32); err == nil {
 			filters["user_id"] = uint(id)
@@ -423,6 +429,99 @@ func (h *OrderHandler) DeliverOrder(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Order delivered successfully", nil)
 }
 
+// MarkReadyForPickup marks a pickup order as waiting at its pickup location
+func (h *OrderHandler) MarkReadyForPickup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	if err := h.orderService.MarkReadyForPickup(uint(id), userID.(uint)); err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to mark order ready for pickup", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Order is ready for pickup", nil)
+}
+
+// CreateShipment ships part of an order as a separate shipment
+func (h *OrderHandler) CreateShipment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	var req model.OrderShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	// Conditional grants, e.g. order.manage only for warehouse HN01, are checked against the shipment
+	orderID := uint(id)
+	if !authorizeResourceConditions(c, model.ResourceTypeOrder, model.PermissionTypeManage, &orderID, map[string]interface{}{
+		"warehouse": req.Warehouse,
+		"order_id":  orderID,
+	}) {
+		return
+	}
+
+	shipment, err := h.orderService.CreateShipment(uint(id), &req, userID.(uint))
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			response.ErrorResponse(c, http.StatusNotFound, "Failed to create shipment", err.Error())
+		case strings.HasPrefix(err.Error(), "failed to"):
+			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to create shipment", err.Error())
+		default:
+			response.ErrorResponse(c, http.StatusBadRequest, "Failed to create shipment", err.Error())
+		}
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Shipment created successfully", shipment)
+}
+
+// GetShipments retrieves every shipment of an order
+func (h *OrderHandler) GetShipments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	shipments, err := h.orderService.GetShipments(uint(id))
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			response.ErrorResponse(c, http.StatusNotFound, "Failed to retrieve shipments", err.Error())
+			return
+		}
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve shipments", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipments retrieved successfully", shipments)
+}
+
 // GetOrderItems retrieves order items for an order
 func (h *OrderHandler) GetOrderItems(c *gin.Context) {
 	orderIDStr := c.Param("order_id")
@@ -725,6 +824,43 @@ func (h *OrderHandler) ConvertCartToOrder(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusCreated, "Cart converted to order successfully", order)
 }
 
+// Backorders
+
+// GetBackorders retrieves backordered items that are still waiting for stock
+func (h *OrderHandler) GetBackorders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if productID := c.Query("product_id"); productID != "" {
+		if id, err := strconv.ParseUint(productID, 10, 32); err == nil {
+			filters["product_id"] = uint(id)
+		}
+	}
+	if backorderType := c.Query("backorder_type"); backorderType != "" {
+		filters["backorder_type"] = backorderType
+	}
+
+	items, total, err := h.orderService.GetBackorders(page, limit, filters)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve backorders", err.Error())
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Backorders retrieved successfully", items, page, limit, total)
+}
+
+// ProcessBackorders fulfills backordered items that are now in stock
+func (h *OrderHandler) ProcessBackorders(c *gin.Context) {
+	result, err := h.orderService.ProcessBackorders()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to process backorders", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Backorders processed successfully", result)
+}
+
 // Statistics
 
 // GetOrderStats retrieves order statistics
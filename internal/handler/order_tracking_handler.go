@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go_app/internal/model"
 	"go_app/internal/service"
+	"go_app/pkg/logger"
 	"go_app/pkg/response"
 	"go_app/pkg/validator"
 
@@ -105,14 +108,14 @@ func (h *OrderTrackingHandler) GetOrderTrackingByID(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Order tracking retrieved successfully", tracking)
 }
 
-// GetOrderTrackingByOrderID gets order tracking by order ID
+// GetOrderTrackingByOrderID gets the shipments and tracking timeline of an order
 // @Summary Get order tracking by order ID
-// @Description Get order tracking information by order ID
+// @Description Get every shipment of an order with its items and the merged tracking timeline
 // @Tags order-tracking
 // @Accept json
 // @Produce json
 // @Param order_id path int true "Order ID"
-// @Success 200 {object} response.SuccessResponse{data=model.OrderTrackingResponse}
+// @Success 200 {object} response.SuccessResponse{data=model.OrderFulfillmentResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
@@ -125,10 +128,10 @@ func (h *OrderTrackingHandler) GetOrderTrackingByOrderID(c *gin.Context) {
 		return
 	}
 
-	tracking, err := h.orderTrackingService.GetOrderTrackingByOrderID(uint(orderID))
+	tracking, err := h.orderTrackingService.GetOrderFulfillment(uint(orderID))
 	if err != nil {
-		if err.Error() == "order tracking not found" {
-			response.Error(c, http.StatusNotFound, "Order tracking not found", err.Error())
+		if err.Error() == "order not found" {
+			response.Error(c, http.StatusNotFound, "Order not found", err.Error())
 			return
 		}
 		response.Error(c, http.StatusInternalServerError, "Failed to get order tracking", err.Error())
@@ -375,9 +378,12 @@ func (h *OrderTrackingHandler) GetTrackingEvents(c *gin.Context) {
 // @Produce json
 // @Param carrier path string true "Carrier Code"
 // @Param carrier_code path string true "Carrier Code"
+// @Param X-Signature header string true "Hex HMAC-SHA256 of the body with the webhook secret"
 // @Param webhook body model.OrderTrackingWebhookRequest true "Webhook data"
 // @Success 200 {object} response.SuccessResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /api/v1/order-tracking/webhook/{carrier}/{carrier_code} [post]
 func (h *OrderTrackingHandler) ProcessWebhook(c *gin.Context) {
@@ -389,8 +395,21 @@ func (h *OrderTrackingHandler) ProcessWebhook(c *gin.Context) {
 		return
 	}
 
+	body, err := c.GetRawData()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Reject calls the carrier did not sign
+	if err := h.orderTrackingService.VerifyWebhook(carrier, carrierCode, c.GetHeader("X-Signature"), body); err != nil {
+		logger.Warnf("Rejected order tracking webhook from %s/%s: %v", carrier, carrierCode, err)
+		response.Error(c, http.StatusUnauthorized, "Invalid webhook signature", err.Error())
+		return
+	}
+
 	var req model.OrderTrackingWebhookRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
@@ -401,8 +420,12 @@ func (h *OrderTrackingHandler) ProcessWebhook(c *gin.Context) {
 		return
 	}
 
-	err := h.orderTrackingService.ProcessWebhook(carrier, carrierCode, &req)
+	err = h.orderTrackingService.ProcessWebhook(carrier, carrierCode, &req)
 	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			response.Error(c, http.StatusNotFound, "Failed to process webhook", err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to process webhook", err.Error())
 		return
 	}
@@ -419,7 +442,7 @@ func (h *OrderTrackingHandler) ProcessWebhook(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param limit query int false "Maximum number of trackings to sync" default(50)
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {object} response.SuccessResponse{data=model.OrderTrackingSyncResult}
 // @Failure 500 {object} response.ErrorResponse
 // @Router /api/v1/order-tracking/sync [post]
 func (h *OrderTrackingHandler) SyncOrderTrackings(c *gin.Context) {
@@ -428,13 +451,13 @@ func (h *OrderTrackingHandler) SyncOrderTrackings(c *gin.Context) {
 		limit = 50
 	}
 
-	err := h.orderTrackingService.SyncOrderTrackings(limit)
+	result, err := h.orderTrackingService.SyncOrderTrackings(limit)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to sync order trackings", err.Error())
 		return
 	}
 
-	response.SuccessResponse(c, http.StatusOK, "Order trackings synced successfully", nil)
+	response.SuccessResponse(c, http.StatusOK, "Order trackings synced successfully", result)
 }
 
 // ===== STATISTICS ENDPOINTS =====
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"go_app/internal/model"
@@ -474,6 +475,42 @@ func (h *PermissionHandler) UpdateRolePermissions(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Role permissions updated successfully", nil)
 }
 
+// UpdateRolePermissionConditions limits a permission of a role by attribute conditions,
+// e.g. inventory.manage only for movements under 10,000,000 VND
+func (h *PermissionHandler) UpdateRolePermissionConditions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("role_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid role ID", err.Error())
+		return
+	}
+
+	permissionID, err := strconv.ParseUint(c.Param("permission_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid permission ID", err.Error())
+		return
+	}
+
+	var req model.RolePermissionConditionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	if err := h.permissionService.UpdateRolePermissionConditions(uint(roleID), uint(permissionID), &req, userID.(uint)); err != nil {
+		h.handleError(c, "Failed to update role permission conditions", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Role permission conditions updated successfully", nil)
+}
+
 // User Permissions
 
 // AssignPermissionToUser assigns a permission to a user
@@ -499,7 +536,7 @@ func (h *PermissionHandler) AssignPermissionToUser(c *gin.Context) {
 	}
 
 	if err := h.permissionService.AssignPermissionToUser(uint(userID), req.PermissionID, grantedBy.(uint), &req); err != nil {
-		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to assign permission to user", err.Error())
+		h.handleError(c, "Failed to assign permission to user", err)
 		return
 	}
 
@@ -573,9 +610,88 @@ func (h *PermissionHandler) GetUserEffectivePermissions(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "User effective permissions retrieved successfully", permissions)
 }
 
+// Time-bound Role Grants
+
+// GrantRoleToUser gives a user an additional role for a period of time
+func (h *PermissionHandler) GrantRoleToUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req model.UserRoleGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Get granted by user ID from context
+	grantedBy, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	grant, err := h.permissionService.GrantRoleToUser(uint(userID), &req, grantedBy.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to grant role", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Role granted successfully", grant)
+}
+
+// GetUserRoleGrants retrieves the scheduled, active and ended role grants of a user
+func (h *PermissionHandler) GetUserRoleGrants(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	grants, err := h.permissionService.GetUserRoleGrants(uint(userID))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve role grants", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Role grants retrieved successfully", grants)
+}
+
+// RevokeUserRoleGrant ends a role grant before its end time
+func (h *PermissionHandler) RevokeUserRoleGrant(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	grantID, err := strconv.ParseUint(c.Param("grant_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid role grant ID", err.Error())
+		return
+	}
+
+	// Get revoking user ID from context
+	revokedBy, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	if err := h.permissionService.RevokeUserRoleGrant(uint(userID), uint(grantID), revokedBy.(uint)); err != nil {
+		h.handleError(c, "Failed to revoke role grant", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Role grant revoked successfully", nil)
+}
+
 // Permission Checking
 
-// CheckPermission checks if a user has permission for a specific resource and action
+// CheckPermission checks if a user has permission for a specific resource and action,
+// evaluating conditional grants against the given attributes
 func (h *PermissionHandler) CheckPermission(c *gin.Context) {
 	var req model.PermissionCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -583,7 +699,7 @@ func (h *PermissionHandler) CheckPermission(c *gin.Context) {
 		return
 	}
 
-	permissionCheck, err := h.permissionService.CheckPermission(req.UserID, req.Resource, req.Action, req.ResourceID)
+	permissionCheck, err := h.permissionService.CheckPermissionWithAttributes(req.UserID, req.Resource, req.Action, req.ResourceID, req.Attributes)
 	if err != nil {
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 		return
@@ -728,3 +844,46 @@ func (h *PermissionHandler) SyncUserRole(c *gin.Context) {
 
 	response.SuccessResponse(c, http.StatusOK, "User role synchronized successfully", nil)
 }
+
+func (h *PermissionHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
+
+// authorizeResourceConditions finishes a check started by AttributePermissionMiddleware: when the
+// user's permission is limited by conditions on the resource, they are evaluated against the
+// resource attributes together with the request attributes. Writes a 403 and returns false if
+// they do not hold.
+func authorizeResourceConditions(c *gin.Context, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}) bool {
+	if !c.GetBool("permission_conditional") {
+		return true
+	}
+
+	merged := make(map[string]interface{})
+	if requestAttributes, exists := c.Get("permission_attributes"); exists {
+		for key, value := range requestAttributes.(map[string]interface{}) {
+			merged[key] = value
+		}
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+
+	userID, _ := c.Get("user_id")
+	permissionCheck, err := service.NewPermissionService().CheckPermissionWithAttributes(userID.(uint), resource, action, resourceID, merged)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
+		return false
+	}
+	if !permissionCheck.HasPermission {
+		response.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", permissionCheck.Reason)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PickupLocationHandler handles click-and-collect pickup location HTTP requests
+type PickupLocationHandler struct {
+	pickupService service.PickupLocationService
+}
+
+// NewPickupLocationHandler creates a new PickupLocationHandler
+func NewPickupLocationHandler() *PickupLocationHandler {
+	return &PickupLocationHandler{
+		pickupService: service.NewPickupLocationService(),
+	}
+}
+
+// CreatePickupLocation creates a new pickup location
+func (h *PickupLocationHandler) CreatePickupLocation(c *gin.Context) {
+	var req model.PickupLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	location, err := h.pickupService.CreatePickupLocation(&req)
+	if err != nil {
+		h.handleError(c, "Failed to create pickup location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Pickup location created successfully", location)
+}
+
+// GetPickupLocations lists pickup locations; customers only see active ones
+func (h *PickupLocationHandler) GetPickupLocations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filters := map[string]interface{}{"is_active": true}
+	if provinceCode := c.Query("province_code"); provinceCode != "" {
+		filters["province_code"] = provinceCode
+	}
+	if districtCode := c.Query("district_code"); districtCode != "" {
+		filters["district_code"] = districtCode
+	}
+	if city := c.Query("city"); city != "" {
+		filters["city"] = city
+	}
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+
+	locations, total, err := h.pickupService.GetPickupLocations(page, limit, filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve pickup locations", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Pickup locations retrieved successfully", locations, page, limit, total)
+}
+
+// GetAllPickupLocations lists every pickup location for management, including inactive ones
+func (h *PickupLocationHandler) GetAllPickupLocations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filters := make(map[string]interface{})
+	if isActive := c.Query("is_active"); isActive != "" {
+		filters["is_active"] = isActive == "true"
+	}
+	if provinceCode := c.Query("province_code"); provinceCode != "" {
+		filters["province_code"] = provinceCode
+	}
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+
+	locations, total, err := h.pickupService.GetPickupLocations(page, limit, filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve pickup locations", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Pickup locations retrieved successfully", locations, page, limit, total)
+}
+
+// GetPickupLocationByID retrieves a pickup location by its ID
+func (h *PickupLocationHandler) GetPickupLocationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid pickup location ID", err.Error())
+		return
+	}
+
+	location, err := h.pickupService.GetPickupLocationByID(uint(id))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve pickup location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Pickup location retrieved successfully", location)
+}
+
+// GetNearestPickupLocations finds the active pickup locations closest to a point
+func (h *PickupLocationHandler) GetNearestPickupLocations(c *gin.Context) {
+	latitude, err := strconv.ParseFloat(c.Query("latitude"), 64)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid latitude", err.Error())
+		return
+	}
+
+	longitude, err := strconv.ParseFloat(c.Query("longitude"), 64)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid longitude", err.Error())
+		return
+	}
+
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius", "10"), 64) // Default 10km radius
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid radius", err.Error())
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	locations, err := h.pickupService.FindNearest(latitude, longitude, radius, limit)
+	if err != nil {
+		h.handleError(c, "Failed to find pickup locations", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Nearest pickup locations retrieved successfully", locations)
+}
+
+// UpdatePickupLocation updates an existing pickup location
+func (h *PickupLocationHandler) UpdatePickupLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid pickup location ID", err.Error())
+		return
+	}
+
+	var req model.PickupLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	location, err := h.pickupService.UpdatePickupLocation(uint(id), &req)
+	if err != nil {
+		h.handleError(c, "Failed to update pickup location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Pickup location updated successfully", location)
+}
+
+// DeletePickupLocation deletes a pickup location
+func (h *PickupLocationHandler) DeletePickupLocation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid pickup location ID", err.Error())
+		return
+	}
+
+	if err := h.pickupService.DeletePickupLocation(uint(id)); err != nil {
+		h.handleError(c, "Failed to delete pickup location", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Pickup location deleted successfully", nil)
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *PickupLocationHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyHandler handles personal data export and account deletion requests
+type PrivacyHandler struct {
+	privacyService *service.PrivacyService
+}
+
+// NewPrivacyHandler creates a new PrivacyHandler
+func NewPrivacyHandler() *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: service.NewPrivacyService(),
+	}
+}
+
+// RequestDataExport godoc
+// @Summary Request personal data export
+// @Description Queue an archive of the user's profile, orders, addresses, reviews, wishlists, notifications and audit entries
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} response.Response{data=model.DataExportRequest}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/privacy/exports [post]
+func (h *PrivacyHandler) RequestDataExport(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	export, err := h.privacyService.RequestDataExport(userID.(uint), c.ClientIP())
+	if err != nil {
+		h.handleError(c, "Failed to request data export", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusAccepted, "Data export requested, you will be notified when it is ready", export)
+}
+
+// GetDataExports godoc
+// @Summary List personal data exports
+// @Description List the user's data export requests and their status
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.DataExportRequest}
+// @Failure 401 {object} response.Response
+// @Router /auth/privacy/exports [get]
+func (h *PrivacyHandler) GetDataExports(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	exports, err := h.privacyService.GetDataExports(userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve data exports", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Data exports retrieved successfully", exports)
+}
+
+// DownloadDataExport godoc
+// @Summary Download personal data export
+// @Description Download a finished data export as a ZIP archive of JSON files
+// @Tags privacy
+// @Produce application/zip
+// @Security BearerAuth
+// @Param id path int true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/privacy/exports/{id}/download [get]
+func (h *PrivacyHandler) DownloadDataExport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid export ID", err.Error())
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	path, err := h.privacyService.GetDataExportFile(userID.(uint), uint(id))
+	if err != nil {
+		h.handleError(c, "Failed to download data export", err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(path, fmt.Sprintf("personal-data-%d.zip", id))
+}
+
+// RequestAccountDeletion godoc
+// @Summary Request account deletion
+// @Description Schedule the account for deletion after a grace period. Personal data is then anonymized; orders and payments are kept for accounting.
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.DeleteAccountRequest true "Password confirmation"
+// @Success 202 {object} response.Response{data=model.AccountErasureRequest}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/privacy/account-deletion [post]
+func (h *PrivacyHandler) RequestAccountDeletion(c *gin.Context) {
+	var req service.DeleteAccountRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	erasure, err := h.privacyService.RequestAccountErasure(userID.(uint), &req, c.ClientIP())
+	if err != nil {
+		h.handleError(c, "Failed to request account deletion", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusAccepted, "Account deletion scheduled", erasure)
+}
+
+// GetAccountDeletion godoc
+// @Summary Get account deletion status
+// @Description Get the user's most recent account deletion request
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.AccountErasureRequest}
+// @Failure 404 {object} response.Response
+// @Router /auth/privacy/account-deletion [get]
+func (h *PrivacyHandler) GetAccountDeletion(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	erasure, err := h.privacyService.GetAccountErasure(userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve account deletion request", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Account deletion request retrieved successfully", erasure)
+}
+
+// CancelAccountDeletion godoc
+// @Summary Cancel account deletion
+// @Description Cancel a scheduled account deletion during the grace period
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/privacy/account-deletion [delete]
+func (h *PrivacyHandler) CancelAccountDeletion(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.privacyService.CancelAccountErasure(userID.(uint)); err != nil {
+		h.handleError(c, "Failed to cancel account deletion", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Account deletion cancelled", nil)
+}
+
+func (h *PrivacyHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
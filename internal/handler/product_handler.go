@@ -624,6 +624,89 @@ func (h *ProductHandler) GetProductStats(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Product statistics retrieved successfully", stats)
 }
 
+// ===== PRODUCT BUNDLES ENDPOINTS =====
+
+// GetBundleItems gets the components and availability of a bundle product
+// @Summary Get bundle components
+// @Description Get the components of a bundle product with stock and computed bundle availability
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param product_id path int true "Product ID"
+// @Success 200 {object} response.SuccessResponse{data=model.BundleAvailabilityResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /api/v1/products/{product_id}/bundle-items [get]
+func (h *ProductHandler) GetBundleItems(c *gin.Context) {
+	productIDStr := c.Param("product_id")
+	productID, err := strconv.ParseUint(productIDStr, 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", "Product ID must be a valid number")
+		return
+	}
+
+	availability, err := h.productService.GetBundleItems(uint(productID))
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			response.Error(c, http.StatusNotFound, "Product not found", err.Error())
+		case "product is not a bundle":
+			response.Error(c, http.StatusBadRequest, "Product is not a bundle", err.Error())
+		default:
+			response.Error(c, http.StatusInternalServerError, "Failed to get bundle items", err.Error())
+		}
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Bundle items retrieved successfully", availability)
+}
+
+// UpdateBundleItems replaces the components of a bundle product
+// @Summary Update bundle components
+// @Description Replace the components of a bundle product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param product_id path int true "Product ID"
+// @Param items body model.ProductBundleItemsUpdateRequest true "Bundle components"
+// @Success 200 {object} response.SuccessResponse{data=model.BundleAvailabilityResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/products/{product_id}/bundle-items [put]
+func (h *ProductHandler) UpdateBundleItems(c *gin.Context) {
+	productIDStr := c.Param("product_id")
+	productID, err := strconv.ParseUint(productIDStr, 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", "Product ID must be a valid number")
+		return
+	}
+
+	var req model.ProductBundleItemsUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	availability, err := h.productService.UpdateBundleItems(uint(productID), &req)
+	if err != nil {
+		if err.Error() == "product not found" {
+			response.Error(c, http.StatusNotFound, "Product not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to update bundle items", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Bundle items updated successfully", availability)
+}
+
 // ===== PRODUCT VARIANTS ENDPOINTS =====
 
 // GetProductVariants gets all variants for a product
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShippingExceptionHandler handles shipping SLA exception HTTP requests
+type ShippingExceptionHandler struct {
+	slaService service.ShippingSLAService
+}
+
+// NewShippingExceptionHandler creates a new ShippingExceptionHandler
+func NewShippingExceptionHandler() *ShippingExceptionHandler {
+	return &ShippingExceptionHandler{
+		slaService: service.NewShippingSLAService(),
+	}
+}
+
+// GetDashboard summarizes the unresolved shipping exceptions
+func (h *ShippingExceptionHandler) GetDashboard(c *gin.Context) {
+	dashboard, err := h.slaService.GetDashboard()
+	if err != nil {
+		h.handleError(c, "Failed to retrieve shipping exception dashboard", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipping exception dashboard retrieved successfully", dashboard)
+}
+
+// GetExceptions lists shipping exceptions; ?status=unresolved lists open and acknowledged ones
+func (h *ShippingExceptionHandler) GetExceptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if exceptionType := c.Query("type"); exceptionType != "" {
+		filters["type"] = exceptionType
+	}
+	if carrierCode := c.Query("carrier_code"); carrierCode != "" {
+		filters["carrier_code"] = carrierCode
+	}
+	if providerID := c.Query("provider_id"); providerID != "" {
+		if id, err := strconv.ParseUint(providerID, 10, 32); err == nil {
+			filters["provider_id"] = uint(id)
+		}
+	}
+	if orderID := c.Query("order_id"); orderID != "" {
+		if id, err := strconv.ParseUint(orderID, 10, 32); err == nil {
+			filters["order_id"] = uint(id)
+		}
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		if date, err := time.Parse("2006-01-02", dateFrom); err == nil {
+			filters["date_from"] = date
+		}
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		if date, err := time.Parse("2006-01-02", dateTo); err == nil {
+			filters["date_to"] = date.AddDate(0, 0, 1)
+		}
+	}
+
+	exceptions, total, err := h.slaService.GetExceptions(page, limit, filters)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve shipping exceptions", err)
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Shipping exceptions retrieved successfully", exceptions, page, limit, total)
+}
+
+// GetExceptionByID retrieves a shipping exception by its ID
+func (h *ShippingExceptionHandler) GetExceptionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid exception ID", err.Error())
+		return
+	}
+
+	exception, err := h.slaService.GetExceptionByID(uint(id))
+	if err != nil {
+		h.handleError(c, "Failed to retrieve shipping exception", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipping exception retrieved successfully", exception)
+}
+
+// UpdateException acknowledges or resolves a shipping exception
+func (h *ShippingExceptionHandler) UpdateException(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid exception ID", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req model.ShippingExceptionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	exception, err := h.slaService.UpdateException(uint(id), &req, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to update shipping exception", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipping exception updated successfully", exception)
+}
+
+// RunMonitor checks shipments against the SLA immediately instead of waiting for the worker
+func (h *ShippingExceptionHandler) RunMonitor(c *gin.Context) {
+	result, err := h.slaService.RunMonitor()
+	if err != nil {
+		h.handleError(c, "Failed to run shipping SLA monitor", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipping SLA monitor completed successfully", result)
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *ShippingExceptionHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
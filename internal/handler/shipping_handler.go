@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go_app/internal/model"
 	"go_app/internal/service"
@@ -197,7 +200,7 @@ func (h *ShippingHandler) DeleteShippingProvider(c *gin.Context) {
 // Shipping Calculation
 // CalculateShipping calculates shipping fees
 // @Summary Calculate shipping fees
-// @Description Calculate shipping fees for given parameters
+// @Description Calculate ranked shipping quotes for a package, a list of products or a cart
 // @Tags shipping
 // @Accept json
 // @Produce json
@@ -215,6 +218,12 @@ func (h *ShippingHandler) CalculateShipping(c *gin.Context) {
 
 	responses, err := h.shippingService.CalculateShipping(&req)
 	if err != nil {
+		switch err.Error() {
+		case "product not found", "cart is empty", "invalid item quantity", "package weight is required",
+			"coupon not found", "coupon is not a free shipping coupon", "coupon cannot be applied to this order":
+			response.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate shipping", err.Error())
+			return
+		}
 		logger.Errorf("Failed to calculate shipping: %v", err)
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate shipping", err.Error())
 		return
@@ -418,6 +427,131 @@ func (h *ShippingHandler) CancelShippingOrder(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Shipping order cancelled successfully", nil)
 }
 
+// GetShippingLabel gets the carrier label of a shipping order
+// @Summary Get shipping label
+// @Description Get the printable label of a shipping order from its carrier
+// @Tags shipping-orders
+// @Produce application/pdf
+// @Param id path int true "Shipping order ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/shipping/orders/{id}/label [get]
+func (h *ShippingHandler) GetShippingLabel(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	label, err := h.shippingService.GetShippingLabel(uint(id))
+	if err != nil {
+		if err.Error() == "shipping order not found" {
+			response.ErrorResponse(c, http.StatusNotFound, "Shipping order not found", nil)
+			return
+		}
+		logger.Errorf("Failed to get shipping label: %v", err)
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to get shipping label", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=label-%d", id))
+	c.Data(http.StatusOK, http.DetectContentType(label), label)
+}
+
+// CreateShippingLabelBatch prints labels and packing slips for many shipping orders
+// @Summary Print shipping label batch
+// @Description Fetch or generate labels and packing slips for many shipping orders, merged into one stored PDF
+// @Tags shipping-orders
+// @Accept json
+// @Produce json
+// @Param request body model.ShippingLabelBatchRequest true "Label batch request"
+// @Success 201 {object} response.Response{data=model.ShippingLabelBatchResponse}
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/shipping/orders/labels [post]
+func (h *ShippingHandler) CreateShippingLabelBatch(c *gin.Context) {
+	var req model.ShippingLabelBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if userID, exists := c.Get("user_id"); exists {
+		if id, ok := userID.(uint); ok {
+			req.CreatedBy = &id
+		}
+	}
+
+	batch, err := h.shippingService.CreateShippingLabelBatch(&req)
+	if err != nil {
+		if err.Error() == "nothing to print" || err.Error() == "no shipping orders could be printed" {
+			response.ErrorResponse(c, http.StatusBadRequest, "Failed to print shipping labels", err.Error())
+			return
+		}
+		logger.Errorf("Failed to create shipping label batch: %v", err)
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to print shipping labels", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Shipping labels generated successfully", batch)
+}
+
+// GetShippingLabelBatch gets a generated label batch
+// @Summary Get shipping label batch
+// @Description Get a generated batch of shipping labels and packing slips
+// @Tags shipping-orders
+// @Produce json
+// @Param batch_id path int true "Label batch ID"
+// @Success 200 {object} response.Response{data=model.ShippingLabelBatchResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/shipping/orders/labels/{batch_id} [get]
+func (h *ShippingHandler) GetShippingLabelBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("batch_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid batch ID", err.Error())
+		return
+	}
+
+	batch, err := h.shippingService.GetShippingLabelBatch(uint(id))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusNotFound, "Shipping label batch not found", nil)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Shipping label batch retrieved successfully", batch)
+}
+
+// DownloadShippingLabelBatch downloads the PDF of a label batch
+// @Summary Download shipping label batch
+// @Description Download the stored PDF of a label batch for re-printing
+// @Tags shipping-orders
+// @Produce application/pdf
+// @Param batch_id path int true "Label batch ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/shipping/orders/labels/{batch_id}/download [get]
+func (h *ShippingHandler) DownloadShippingLabelBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("batch_id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid batch ID", err.Error())
+		return
+	}
+
+	batch, data, err := h.shippingService.DownloadShippingLabelBatch(uint(id))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusNotFound, "Shipping label batch not found", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", batch.FileName))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
 // GetShippingOrders gets shipping orders with pagination
 // @Summary Get shipping orders
 // @Description Get shipping orders with pagination and filters
@@ -475,11 +609,11 @@ func (h *ShippingHandler) GetShippingOrders(c *gin.Context) {
 // Tracking
 // GetShippingTracking gets shipping tracking information
 // @Summary Get shipping tracking
-// @Description Get shipping tracking information for an order
+// @Description Get every shipment of an order with the merged tracking timeline
 // @Tags shipping-tracking
 // @Produce json
 // @Param order_id path int true "Order ID"
-// @Success 200 {object} response.Response{data=[]model.ShippingTracking}
+// @Success 200 {object} response.Response{data=model.OrderFulfillmentResponse}
 // @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/shipping/tracking/{order_id} [get]
@@ -493,6 +627,10 @@ func (h *ShippingHandler) GetShippingTracking(c *gin.Context) {
 
 	tracking, err := h.shippingService.GetShippingTracking(uint(orderID))
 	if err != nil {
+		if err.Error() == "order not found" {
+			response.ErrorResponse(c, http.StatusNotFound, "Order not found", err.Error())
+			return
+		}
 		logger.Errorf("Failed to get shipping tracking: %v", err)
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to get shipping tracking", err.Error())
 		return
@@ -512,6 +650,8 @@ func (h *ShippingHandler) GetShippingTracking(c *gin.Context) {
 // @Param webhook body model.WebhookData true "Webhook data"
 // @Success 200 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/shipping/webhook/{provider} [post]
 func (h *ShippingHandler) HandleShippingWebhook(c *gin.Context) {
@@ -521,15 +661,32 @@ func (h *ShippingHandler) HandleShippingWebhook(c *gin.Context) {
 		return
 	}
 
+	body, err := c.GetRawData()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook data", err.Error())
+		return
+	}
+
+	// Reject calls the carrier did not sign
+	if err := h.shippingService.VerifyWebhook(provider, c.GetHeader("X-Signature"), body); err != nil {
+		logger.Warnf("Rejected shipping webhook from %s: %v", provider, err)
+		response.ErrorResponse(c, http.StatusUnauthorized, "Invalid webhook signature", err.Error())
+		return
+	}
+
 	var webhookData model.WebhookData
-	if err := c.ShouldBindJSON(&webhookData); err != nil {
+	if err := json.Unmarshal(body, &webhookData); err != nil {
 		response.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook data", err.Error())
 		return
 	}
 
-	err := h.shippingService.UpdateShippingStatusFromWebhook(&webhookData)
+	err = h.shippingService.UpdateShippingStatusFromWebhook(provider, &webhookData)
 	if err != nil {
 		logger.Errorf("Failed to handle shipping webhook: %v", err)
+		if strings.HasSuffix(err.Error(), "not found") {
+			response.ErrorResponse(c, http.StatusNotFound, "Failed to handle webhook", err.Error())
+			return
+		}
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to handle webhook", err.Error())
 		return
 	}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/service"
+	"go_app/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StocktakeHandler handles stocktake (cycle count) HTTP requests
+type StocktakeHandler struct {
+	stocktakeService service.StocktakeService
+}
+
+// NewStocktakeHandler creates a new StocktakeHandler
+func NewStocktakeHandler() *StocktakeHandler {
+	return &StocktakeHandler{
+		stocktakeService: service.NewStocktakeService(),
+	}
+}
+
+// CreateStocktake creates a new stocktake session
+func (h *StocktakeHandler) CreateStocktake(c *gin.Context) {
+	var req model.StocktakeCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	stocktake, err := h.stocktakeService.CreateStocktake(&req, userID.(uint))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to create stocktake", err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusCreated, "Stocktake created successfully", stocktake)
+}
+
+// GetStocktakes retrieves stocktake sessions with pagination and filters
+func (h *StocktakeHandler) GetStocktakes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		if id, err := strconv.ParseUint(categoryID, 10, 32); err == nil {
+			filters["category_id"] = uint(id)
+		}
+	}
+	if location := c.Query("location"); location != "" {
+		filters["location"] = location
+	}
+	if createdBy := c.Query("created_by"); createdBy != "" {
+		if id, err := strconv.ParseUint(createdBy, 10, 32); err == nil {
+			filters["created_by"] = uint(id)
+		}
+	}
+	if dateFrom := c.Query("date_from"); dateFrom != "" {
+		if date, err := time.Parse("2006-01-02", dateFrom); err == nil {
+			filters["date_from"] = date
+		}
+	}
+	if dateTo := c.Query("date_to"); dateTo != "" {
+		if date, err := time.Parse("2006-01-02", dateTo); err == nil {
+			filters["date_to"] = date
+		}
+	}
+
+	stocktakes, total, err := h.stocktakeService.GetStocktakes(page, limit, filters)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stocktakes", err.Error())
+		return
+	}
+
+	response.SuccessResponseWithPagination(c, http.StatusOK, "Stocktakes retrieved successfully", stocktakes, page, limit, total)
+}
+
+// GetStocktakeByID retrieves a stocktake session with its items
+func (h *StocktakeHandler) GetStocktakeByID(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stocktake, err := h.stocktakeService.GetStocktakeByID(id)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve stocktake", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Stocktake retrieved successfully", stocktake)
+}
+
+// StartStocktake freezes expected quantities and opens counting
+func (h *StocktakeHandler) StartStocktake(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stocktake, err := h.stocktakeService.StartStocktake(id)
+	if err != nil {
+		h.handleError(c, "Failed to start stocktake", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Stocktake started successfully", stocktake)
+}
+
+// SubmitCounts records counted quantities by SKU
+func (h *StocktakeHandler) SubmitCounts(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	var req model.StocktakeCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	result, err := h.stocktakeService.SubmitCounts(id, &req, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to submit counts", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Counts submitted successfully", result)
+}
+
+// UploadCounts records counted quantities from a CSV file (columns: sku,quantity[,notes])
+func (h *StocktakeHandler) UploadCounts(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "No file uploaded", err.Error())
+		return
+	}
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".csv" {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid file type", "only .csv files are accepted")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", err.Error())
+		return
+	}
+	defer src.Close()
+
+	result, err := h.stocktakeService.ImportCountsCSV(id, src, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to import counts", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Counts imported successfully", result)
+}
+
+// SubmitForReview closes counting and moves the stocktake into review
+func (h *StocktakeHandler) SubmitForReview(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stocktake, err := h.stocktakeService.SubmitForReview(id)
+	if err != nil {
+		h.handleError(c, "Failed to submit stocktake", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Stocktake submitted for review successfully", stocktake)
+}
+
+// GetVarianceReport retrieves the variance review of a stocktake
+func (h *StocktakeHandler) GetVarianceReport(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.stocktakeService.GetVarianceReport(id)
+	if err != nil {
+		h.handleError(c, "Failed to retrieve variance report", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Variance report retrieved successfully", report)
+}
+
+// ApproveStocktake approves a stocktake and applies its variances
+func (h *StocktakeHandler) ApproveStocktake(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	var req model.StocktakeApproveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+		return
+	}
+
+	stocktake, err := h.stocktakeService.ApproveStocktake(id, &req, userID.(uint))
+	if err != nil {
+		h.handleError(c, "Failed to approve stocktake", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Stocktake approved successfully", stocktake)
+}
+
+// CancelStocktake cancels a stocktake
+func (h *StocktakeHandler) CancelStocktake(c *gin.Context) {
+	id, ok := h.parseStocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stocktake, err := h.stocktakeService.CancelStocktake(id)
+	if err != nil {
+		h.handleError(c, "Failed to cancel stocktake", err)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Stocktake cancelled successfully", stocktake)
+}
+
+// parseStocktakeID parses the :id path parameter
+func (h *StocktakeHandler) parseStocktakeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid stocktake ID", err.Error())
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError maps service errors to HTTP status codes
+func (h *StocktakeHandler) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case err.Error() == "stocktake not found":
+		response.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	default:
+		response.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_app/internal/service"
+	"go_app/pkg/response"
+	"go_app/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTwoFactor godoc
+// @Summary Complete two-factor login
+// @Description Finish a login that returned a challenge token, using a TOTP code, a recovery code or an email code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body service.TwoFactorVerifyRequest true "Two-factor verify request"
+// @Success 200 {object} response.Response{data=service.AuthResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req service.TwoFactorVerifyRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	if country := strings.TrimSpace(c.GetHeader(h.countryHeader)); h.countryHeader != "" && len(country) == 2 {
+		req.Country = strings.ToUpper(country)
+	}
+
+	authResponse, err := h.authService.VerifyTwoFactor(&req)
+	if err != nil {
+		var throttled *service.LoginThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			response.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		h.handleTwoFactorError(c, "Failed to verify code", err, http.StatusUnauthorized)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Login successful", authResponse)
+}
+
+// SetupTwoFactorChallenge godoc
+// @Summary Enroll in two-factor authentication during login
+// @Description For roles that require 2FA: returns the authenticator QR code so the login can be completed with its first code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body service.TwoFactorChallengeRequest true "Challenge token"
+// @Success 200 {object} response.Response{data=model.TwoFactorSetupResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/2fa/challenge/setup [post]
+func (h *AuthHandler) SetupTwoFactorChallenge(c *gin.Context) {
+	var req service.TwoFactorChallengeRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	setup, err := h.authService.SetupTwoFactorChallenge(&req)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to start two-factor setup", err, http.StatusUnauthorized)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Scan the QR code with your authenticator app", setup)
+}
+
+// SendTwoFactorEmail godoc
+// @Summary Email a login code
+// @Description Fallback for users without their authenticator app: sends a one-time code to the account email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body service.TwoFactorChallengeRequest true "Challenge token"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/2fa/challenge/email [post]
+func (h *AuthHandler) SendTwoFactorEmail(c *gin.Context) {
+	var req service.TwoFactorChallengeRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	if err := h.authService.SendTwoFactorEmail(&req); err != nil {
+		h.handleTwoFactorError(c, "Failed to send login code", err, http.StatusUnauthorized)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Login code sent to your email", nil)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Two-factor status
+// @Description Whether 2FA is enabled, required for the user's role, and how many recovery codes are left
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.TwoFactorStatusResponse}
+// @Router /auth/2fa [get]
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	status, err := h.authService.GetTwoFactorStatus(userID.(uint))
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to get two-factor status", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Two-factor status retrieved successfully", status)
+}
+
+// BeginTwoFactorSetup godoc
+// @Summary Start two-factor setup
+// @Description Generates a TOTP secret and QR code; confirm with /auth/2fa/enable
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.TwoFactorSetupResponse}
+// @Failure 400 {object} response.Response
+// @Router /auth/2fa/setup [post]
+func (h *AuthHandler) BeginTwoFactorSetup(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	setup, err := h.authService.BeginTwoFactorSetup(userID.(uint))
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to start two-factor setup", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Scan the QR code with your authenticator app", setup)
+}
+
+// EnableTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Confirms setup with a code from the authenticator app and returns one-time recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} response.Response{data=model.TwoFactorRecoveryCodesResponse}
+// @Failure 400 {object} response.Response
+// @Router /auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req service.TwoFactorCodeRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	userID, _ := c.Get("user_id")
+
+	codes, err := h.authService.EnableTwoFactor(userID.(uint), &req)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to enable two-factor authentication", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled, store your recovery codes safely", codes)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Requires the password and a TOTP or recovery code; not allowed for roles that require 2FA
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.TwoFactorDisableRequest true "Password and code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req service.TwoFactorDisableRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	userID, _ := c.Get("user_id")
+
+	if err := h.authService.DisableTwoFactor(userID.(uint), &req); err != nil {
+		h.handleTwoFactorError(c, "Failed to disable two-factor authentication", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes; the previous ones stop working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response{data=model.TwoFactorRecoveryCodesResponse}
+// @Failure 400 {object} response.Response
+// @Router /auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req service.TwoFactorCodeRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	userID, _ := c.Get("user_id")
+
+	codes, err := h.authService.RegenerateRecoveryCodes(userID.(uint), &req)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to regenerate recovery codes", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Recovery codes regenerated, store them safely", codes)
+}
+
+// bindAndValidate binds the JSON body and runs struct validation, writing the 400 response on failure
+func bindAndValidate(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if err := validator.ValidateStruct(req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// handleTwoFactorError maps service errors: "failed to" → 500, "not found" → 404, otherwise status
+func (h *AuthHandler) handleTwoFactorError(c *gin.Context, message string, err error, status int) {
+	switch {
+	case strings.HasPrefix(err.Error(), "failed to"):
+		response.ErrorResponse(c, http.StatusInternalServerError, message)
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.ErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		response.ErrorResponse(c, status, err.Error())
+	}
+}
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"go_app/configs"
 	"go_app/internal/service"
 	"go_app/pkg/response"
 	"go_app/pkg/validator"
@@ -11,12 +16,14 @@ import (
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService   *service.AuthService
+	countryHeader string // Header carrying the client's country code, set by the CDN/proxy
 }
 
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
-		authService: service.NewAuthService(),
+		authService:   service.NewAuthService(),
+		countryHeader: configs.Load().Login.CountryHeader,
 	}
 }
 
@@ -55,7 +62,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user and create session
+// @Description Authenticate user and create session. When two-factor authentication is on (or required for the role) no tokens are issued; the response carries a challenge token for /auth/2fa/verify instead. Repeated wrong passwords slow down and then temporarily lock the account; the wait is returned in the Retry-After header
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -63,6 +70,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Success 200 {object} response.Response{data=service.AuthResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req service.LoginRequest
@@ -80,16 +88,70 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Get client IP and User-Agent
 	req.IPAddress = c.ClientIP()
 	req.UserAgent = c.GetHeader("User-Agent")
+	if country := strings.TrimSpace(c.GetHeader(h.countryHeader)); h.countryHeader != "" && len(country) == 2 {
+		req.Country = strings.ToUpper(country)
+	}
 
 	authResponse, err := h.authService.Login(&req)
 	if err != nil {
+		var throttled *service.LoginThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			response.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		if strings.HasPrefix(err.Error(), "failed to") {
+			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to login")
+			return
+		}
 		response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
+	if authResponse.TwoFactorRequired {
+		response.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", authResponse)
+		return
+	}
 	response.SuccessResponse(c, http.StatusOK, "Login successful", authResponse)
 }
 
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Refresh tokens are single-use; reusing one revokes the session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body service.RefreshTokenRequest true "Refresh token request"
+// @Success 200 {object} response.Response{data=service.AuthResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req service.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate request
+	if err := validator.ValidateStruct(req); err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	authResponse, err := h.authService.Refresh(&req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh token")
+			return
+		}
+		response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", authResponse)
+}
+
 // ForgotPassword godoc
 // @Summary Request password reset
 // @Description Send OTP to user email for password reset
@@ -193,13 +255,13 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 // @Failure 401 {object} response.Response
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	sessionToken, exists := c.Get("session_token")
+	sessionID, exists := c.Get("session_id")
 	if !exists {
 		response.ErrorResponse(c, http.StatusUnauthorized, "Session not found")
 		return
 	}
 
-	err := h.authService.Logout(sessionToken.(string))
+	err := h.authService.Logout(sessionID.(uint))
 	if err != nil {
 		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
 		return
@@ -208,6 +270,81 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	response.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
 }
 
+// GetSessions godoc
+// @Summary List active sessions
+// @Description List the devices the user is signed in on, with user agent, IP and last activity
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.SessionResponse}
+// @Failure 401 {object} response.Response
+// @Router /auth/sessions [get]
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+
+	sessions, err := h.authService.GetSessions(userID.(uint), sessionID.(uint))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign out one of the user's other devices
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if err := h.authService.RevokeSession(userID.(uint), uint(id)); err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke session")
+			return
+		}
+		response.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Sign out every device except the current one
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/sessions [delete]
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+
+	revoked, err := h.authService.RevokeOtherSessions(userID.(uint), sessionID.(uint))
+	if err != nil {
+		response.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Other sessions revoked successfully", gin.H{"revoked": revoked})
+}
+
 // GetProfile godoc
 // @Summary Get user profile
 // @Description Get current user profile information
@@ -235,3 +372,59 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	response.SuccessResponse(c, http.StatusOK, "Profile retrieved successfully", user)
 }
+
+// UpdateProfile godoc
+// @Summary Update user profile
+// @Description Update the current user's name, phone and avatar
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.UpdateProfileRequest true "Profile"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/profile [put]
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	var req service.UpdateProfileRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	user, err := h.authService.UpdateProfile(userID.(uint), &req)
+	if err != nil {
+		h.handleTwoFactorError(c, "Failed to update profile", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Profile updated successfully", user)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the current user's password and sign out every other session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req service.ChangePasswordRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+	if err := h.authService.ChangePassword(userID.(uint), sessionID.(uint), &req); err != nil {
+		h.handleTwoFactorError(c, "Failed to change password", err, http.StatusBadRequest)
+		return
+	}
+
+	response.SuccessResponse(c, http.StatusOK, "Password changed successfully", nil)
+}
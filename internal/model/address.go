@@ -44,6 +44,11 @@ type Address struct {
 	Country      string `json:"country" gorm:"size:100;default:Vietnam"` // Quốc gia
 	PostalCode   string `json:"postal_code" gorm:"size:20"`              // Mã bưu điện
 
+	// Administrative Codes (GSO)
+	ProvinceCode string `json:"province_code" gorm:"size:10;index"` // Mã tỉnh/thành phố
+	DistrictCode string `json:"district_code" gorm:"size:10;index"` // Mã quận/huyện
+	WardCode     string `json:"ward_code" gorm:"size:10;index"`     // Mã phường/xã
+
 	// Geographic Information
 	Latitude  *float64 `json:"latitude" gorm:"type:decimal(10,8)"`  // Vĩ độ
 	Longitude *float64 `json:"longitude" gorm:"type:decimal(11,8)"` // Kinh độ
@@ -75,13 +80,18 @@ type AddressCreateRequest struct {
 	// Address Details
 	AddressLine1 string `json:"address_line1" binding:"required,min=5,max=255"`
 	AddressLine2 string `json:"address_line2" binding:"omitempty,max=255"`
-	Ward         string `json:"ward" binding:"required,min=2,max=100"`
-	District     string `json:"district" binding:"required,min=2,max=100"`
-	City         string `json:"city" binding:"required,min=2,max=100"`
+	Ward         string `json:"ward" binding:"required_without=WardCode,omitempty,min=2,max=100"`
+	District     string `json:"district" binding:"required_without=DistrictCode,omitempty,min=2,max=100"`
+	City         string `json:"city" binding:"required_without=ProvinceCode,omitempty,min=2,max=100"`
 	State        string `json:"state" binding:"omitempty,max=100"`
 	Country      string `json:"country" binding:"omitempty,max=100"`
 	PostalCode   string `json:"postal_code" binding:"omitempty,max=20"`
 
+	// Administrative Codes - names are filled from the directory when codes are given
+	ProvinceCode string `json:"province_code" binding:"omitempty,max=10"`
+	DistrictCode string `json:"district_code" binding:"omitempty,max=10"`
+	WardCode     string `json:"ward_code" binding:"omitempty,max=10"`
+
 	// Geographic Information
 	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
 	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
@@ -113,6 +123,11 @@ type AddressUpdateRequest struct {
 	Country      string `json:"country" binding:"omitempty,max=100"`
 	PostalCode   string `json:"postal_code" binding:"omitempty,max=20"`
 
+	// Administrative Codes
+	ProvinceCode string `json:"province_code" binding:"omitempty,max=10"`
+	DistrictCode string `json:"district_code" binding:"omitempty,max=10"`
+	WardCode     string `json:"ward_code" binding:"omitempty,max=10"`
+
 	// Geographic Information
 	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
 	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
@@ -145,6 +160,9 @@ type AddressResponse struct {
 	State        string `json:"state"`
 	Country      string `json:"country"`
 	PostalCode   string `json:"postal_code"`
+	ProvinceCode string `json:"province_code"`
+	DistrictCode string `json:"district_code"`
+	WardCode     string `json:"ward_code"`
 
 	// Geographic Information
 	Latitude  *float64 `json:"latitude"`
@@ -238,6 +256,9 @@ func (a *Address) ToResponse() *AddressResponse {
 		State:        a.State,
 		Country:      a.Country,
 		PostalCode:   a.PostalCode,
+		ProvinceCode: a.ProvinceCode,
+		DistrictCode: a.DistrictCode,
+		WardCode:     a.WardCode,
 		Latitude:     a.Latitude,
 		Longitude:    a.Longitude,
 		Landmark:     a.Landmark,
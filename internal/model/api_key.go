@@ -0,0 +1,104 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix marks raw API keys so they can be told apart from JWT access tokens
+const APIKeyPrefix = "ak_"
+
+// APIKeyScopeWildcard grants every action on a resource, e.g. "product.*"
+const APIKeyScopeWildcard = "*"
+
+// APIKey is a personal access token used by integrations (ERP, marketplace connectors)
+// instead of logging in as a human. It acts with the owner's permissions, limited to its scopes.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	User       *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:20;not null"`        // Vài ký tự đầu của key để nhận diện, không đủ để đăng nhập
+	KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"` // SHA-256 của key, key gốc chỉ hiển thị một lần
+	Scopes     string     `json:"-" gorm:"type:text;not null"`           // Danh sách "resource.action" phân tách bằng dấu phẩy
+	AllowedIPs string     `json:"-" gorm:"type:text"`                    // IP hoặc CIDR phân tách bằng dấu phẩy, rỗng = mọi IP
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`               // NULL = không hết hạn
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:45"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"index"`
+	RevokedBy  *uint      `json:"revoked_by"` // Chủ key hoặc admin đã thu hồi
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsExpired checks if the key has passed its expiry time
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// IsActive checks if the key can still be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && !k.IsExpired()
+}
+
+// ScopeList returns the key's scopes
+func (k *APIKey) ScopeList() []string {
+	return splitList(k.Scopes)
+}
+
+// AllowedIPList returns the key's IP allowlist
+func (k *APIKey) AllowedIPList() []string {
+	return splitList(k.AllowedIPs)
+}
+
+// APIKeyResponse is an API key as shown to its owner or an admin
+type APIKeyResponse struct {
+	APIKey
+	Scopes     []string `json:"scopes"`
+	AllowedIPs []string `json:"allowed_ips"`
+	Status     string   `json:"status"` // active, expired, revoked
+}
+
+// ToResponse converts the key to its response form
+func (k *APIKey) ToResponse() APIKeyResponse {
+	status := "active"
+	switch {
+	case k.RevokedAt != nil:
+		status = "revoked"
+	case k.IsExpired():
+		status = "expired"
+	}
+	return APIKeyResponse{
+		APIKey:     *k,
+		Scopes:     k.ScopeList(),
+		AllowedIPs: k.AllowedIPList(),
+		Status:     status,
+	}
+}
+
+// APIKeyScopeAllows checks if a scope list ("resource.action" or "resource.*") covers the action
+func APIKeyScopeAllows(scopes []string, resource ResourceType, action PermissionType) bool {
+	wanted := GetPermissionName(resource, action)
+	wildcard := string(resource) + "." + APIKeyScopeWildcard
+	for _, scope := range scopes {
+		if scope == wanted || scope == wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -145,6 +145,14 @@ const (
 	ActionBackup           = "backup"
 	ActionRestore          = "restore"
 	ActionSystemConfig     = "system_config"
+	ActionAPIKeyCreate     = "api_key_create"
+	ActionAPIKeyRevoke     = "api_key_revoke"
+	ActionAPIKeyUse        = "api_key_use"
+	ActionAPIKeyReject     = "api_key_reject"
+	ActionImpersonateStart = "impersonate_start"
+	ActionImpersonateStop  = "impersonate_stop"
+	ActionImpersonateUse   = "impersonate_request"
+	ActionImpersonateBlock = "impersonate_blocked"
 
 	// Resources
 	ResourceUser         = "user"
@@ -175,6 +183,7 @@ const (
 	ResourceAddress      = "address"
 	ResourceRateLimit    = "rate_limit"
 	ResourceEvent        = "event"
+	ResourceAPIKey       = "api_key"
 
 	// Operations
 	OperationCreate   = "CREATE"
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"time"
+)
+
+// CODRemittance represents a carrier's COD remittance statement: the cash collected on delivery
+// for a batch of shipments, minus the carrier's fees, paid back to the shop
+type CODRemittance struct {
+	ID              uint              `json:"id" gorm:"primaryKey"`
+	ProviderID      uint              `json:"provider_id" gorm:"not null;index"`
+	Provider        *ShippingProvider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
+	StatementNumber string            `json:"statement_number" gorm:"size:100;not null"` // Mã bảng kê của hãng vận chuyển
+	StatementDate   *time.Time        `json:"statement_date"`
+	Source          string            `json:"source" gorm:"size:20;not null"` // csv, json
+	FileName        string            `json:"file_name" gorm:"size:255"`
+
+	// Totals
+	TotalLines       int     `json:"total_lines"`
+	MatchedLines     int     `json:"matched_lines"`
+	DiscrepancyLines int     `json:"discrepancy_lines"`                                    // Dòng lệch cần kiểm tra
+	CollectedAmount  float64 `json:"collected_amount" gorm:"type:decimal(12,2);default:0"` // Tổng tiền thu hộ
+	FeeAmount        float64 `json:"fee_amount" gorm:"type:decimal(12,2);default:0"`       // Tổng phí
+	NetAmount        float64 `json:"net_amount" gorm:"type:decimal(12,2);default:0"`       // Thực nhận
+	ExpectedAmount   float64 `json:"expected_amount" gorm:"type:decimal(12,2);default:0"`  // Tiền thu hộ theo vận đơn
+
+	Status string `json:"status" gorm:"size:20;not null;index"` // reconciled, discrepancy
+
+	Lines []CODRemittanceLine `json:"lines,omitempty" gorm:"foreignKey:RemittanceID"`
+
+	ImportedBy *uint     `json:"imported_by" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CODRemittanceLine represents one shipment on a remittance statement
+type CODRemittanceLine struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	RemittanceID    uint   `json:"remittance_id" gorm:"not null;index"`
+	TrackingCode    string `json:"tracking_code" gorm:"size:100;not null;index"`
+	ShippingOrderID *uint  `json:"shipping_order_id" gorm:"index"`
+	OrderID         *uint  `json:"order_id" gorm:"index"`
+	PaymentID       *uint  `json:"payment_id" gorm:"index"` // Thanh toán COD được ghi nhận
+
+	CollectedAmount float64 `json:"collected_amount" gorm:"type:decimal(12,2);default:0"`
+	Fee             float64 `json:"fee" gorm:"type:decimal(12,2);default:0"`
+	NetAmount       float64 `json:"net_amount" gorm:"type:decimal(12,2);default:0"`
+	ExpectedAmount  float64 `json:"expected_amount" gorm:"type:decimal(12,2);default:0"` // ShippingOrder.COD
+	ExpectedFee     float64 `json:"expected_fee" gorm:"type:decimal(12,2);default:0"`    // ShippingOrder.CODFee
+	Difference      float64 `json:"difference" gorm:"type:decimal(12,2);default:0"`      // Thu hộ - dự kiến
+
+	Status  string `json:"status" gorm:"size:30;not null;index"`
+	Message string `json:"message" gorm:"size:500"`
+	RawData string `json:"raw_data" gorm:"type:text"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// COD remittance statuses
+const (
+	CODRemittanceStatusReconciled  = "reconciled"
+	CODRemittanceStatusDiscrepancy = "discrepancy"
+)
+
+// COD remittance line statuses
+const (
+	CODLineStatusMatched        = "matched"         // Khớp, đã ghi nhận thanh toán
+	CODLineStatusAmountMismatch = "amount_mismatch" // Tiền thu hộ khác vận đơn
+	CODLineStatusFeeMismatch    = "fee_mismatch"    // Đã ghi nhận thanh toán, phí khác dự kiến
+	CODLineStatusUnmatched      = "unmatched"       // Không tìm thấy vận đơn
+	CODLineStatusNotCOD         = "not_cod"         // Vận đơn không thu hộ
+	CODLineStatusDuplicate      = "duplicate"       // Trùng trong bảng kê
+	CODLineStatusAlreadySettled = "already_settled" // Đã đối soát ở bảng kê khác
+)
+
+// IsDiscrepancy checks if the line needs manual review
+func (l *CODRemittanceLine) IsDiscrepancy() bool {
+	return l.Status != CODLineStatusMatched
+}
+
+// Request/Response structs
+
+// CODRemittanceLineInput represents a statement line as sent by the carrier
+type CODRemittanceLineInput struct {
+	TrackingCode    string  `json:"tracking_code" binding:"required"`
+	CollectedAmount float64 `json:"collected_amount" binding:"min=0"`
+	Fee             float64 `json:"fee" binding:"min=0"`
+	Note            string  `json:"note"`
+}
+
+// CODRemittanceImportRequest represents a remittance statement to import
+type CODRemittanceImportRequest struct {
+	ProviderID      uint                     `json:"provider_id" form:"provider_id" binding:"required"`
+	StatementNumber string                   `json:"statement_number" form:"statement_number" binding:"required"`
+	StatementDate   *time.Time               `json:"statement_date"`
+	Lines           []CODRemittanceLineInput `json:"lines"`
+	Source          string                   `json:"-"`
+	FileName        string                   `json:"-"`
+}
+
+// CODRemittanceResponse represents a remittance statement in API responses
+type CODRemittanceResponse struct {
+	ID               uint                `json:"id"`
+	ProviderID       uint                `json:"provider_id"`
+	ProviderName     string              `json:"provider_name"`
+	ProviderCode     string              `json:"provider_code"`
+	StatementNumber  string              `json:"statement_number"`
+	StatementDate    *time.Time          `json:"statement_date"`
+	Source           string              `json:"source"`
+	FileName         string              `json:"file_name"`
+	TotalLines       int                 `json:"total_lines"`
+	MatchedLines     int                 `json:"matched_lines"`
+	DiscrepancyLines int                 `json:"discrepancy_lines"`
+	CollectedAmount  float64             `json:"collected_amount"`
+	FeeAmount        float64             `json:"fee_amount"`
+	NetAmount        float64             `json:"net_amount"`
+	ExpectedAmount   float64             `json:"expected_amount"`
+	Status           string              `json:"status"`
+	Lines            []CODRemittanceLine `json:"lines,omitempty"`
+	Errors           []string            `json:"errors,omitempty"` // Dòng không đọc được khi nhập file
+	ImportedBy       *uint               `json:"imported_by"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+// CODOutstandingReport represents the COD still owed by a carrier
+type CODOutstandingReport struct {
+	ProviderID   uint   `json:"provider_id"`
+	ProviderName string `json:"provider_name"`
+	ProviderCode string `json:"provider_code"`
+
+	// Delivered: cash collected by the carrier, not yet remitted
+	DeliveredOrders  int64      `json:"delivered_orders"`
+	DeliveredAmount  float64    `json:"delivered_amount"`
+	OldestDelivered  *time.Time `json:"oldest_delivered_at"`
+	InTransitOrders  int64      `json:"in_transit_orders"` // Not yet collected
+	InTransitAmount  float64    `json:"in_transit_amount"`
+	DiscrepancyLines int64      `json:"discrepancy_lines"` // Statement lines flagged for review
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliverySlotReservationStatus defines the status of a delivery slot reservation
+type DeliverySlotReservationStatus string
+
+const (
+	DeliverySlotStatusHeld      DeliverySlotReservationStatus = "held"      // Đang giữ chỗ trong giỏ hàng
+	DeliverySlotStatusConfirmed DeliverySlotReservationStatus = "confirmed" // Đã gắn với đơn hàng
+	DeliverySlotStatusReleased  DeliverySlotReservationStatus = "released"  // Khách đổi khung giờ hoặc hủy đơn
+	DeliverySlotStatusExpired   DeliverySlotReservationStatus = "expired"   // Hết thời gian giữ chỗ
+)
+
+// DeliverySlotTemplate is a recurring delivery window offered in a zone, e.g. 09:00-12:00 on weekdays
+type DeliverySlotTemplate struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:100;not null"` // Sáng, Chiều, Tối...
+
+	// Zone Information ("*" matches any province/district)
+	Province string `json:"province" gorm:"size:100;not null;index:idx_delivery_slot_templates_zone"`
+	District string `json:"district" gorm:"size:100;not null;index:idx_delivery_slot_templates_zone"`
+
+	// Schedule
+	DaysOfWeek    string  `json:"days_of_week" gorm:"size:20"`             // "1,2,3,4,5" (0 = Chủ nhật), rỗng = mọi ngày
+	StartTime     string  `json:"start_time" gorm:"size:5;not null"`       // HH:MM
+	EndTime       string  `json:"end_time" gorm:"size:5;not null"`         // HH:MM
+	Capacity      int     `json:"capacity" gorm:"not null"`                // Số đơn tối đa mỗi khung giờ mỗi ngày
+	CutoffMinutes int     `json:"cutoff_minutes" gorm:"default:120"`       // Ngừng nhận đặt trước giờ bắt đầu (phút)
+	Fee           float64 `json:"fee" gorm:"type:decimal(10,2);default:0"` // Phụ phí chọn khung giờ
+	SortOrder     int     `json:"sort_order" gorm:"default:0"`
+	IsActive      bool    `json:"is_active" gorm:"default:true;index"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// DeliverySlotReservation holds one unit of a slot's capacity for a cart, then for the order placed from it
+type DeliverySlotReservation struct {
+	ID           uint                  `json:"id" gorm:"primaryKey"`
+	TemplateID   uint                  `json:"template_id" gorm:"not null;index:idx_delivery_slot_reservations_slot"`
+	Template     *DeliverySlotTemplate `json:"template,omitempty" gorm:"foreignKey:TemplateID"`
+	DeliveryDate time.Time             `json:"delivery_date" gorm:"type:date;not null;index:idx_delivery_slot_reservations_slot"`
+	WindowStart  time.Time             `json:"window_start"` // Thời điểm bắt đầu khung giờ
+	WindowEnd    time.Time             `json:"window_end"`   // Thời điểm kết thúc khung giờ
+
+	UserID  uint  `json:"user_id" gorm:"not null;index"`
+	CartID  *uint `json:"cart_id" gorm:"index"`
+	OrderID *uint `json:"order_id" gorm:"index"`
+
+	Status     DeliverySlotReservationStatus `json:"status" gorm:"size:20;not null;index"`
+	ExpiresAt  *time.Time                    `json:"expires_at" gorm:"index"` // Hạn giữ chỗ khi chưa đặt hàng
+	ReleasedAt *time.Time                    `json:"released_at"`
+
+	// Snapshot at booking time
+	Province string  `json:"province" gorm:"size:100"`
+	District string  `json:"district" gorm:"size:100"`
+	Fee      float64 `json:"fee" gorm:"type:decimal(10,2);default:0"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsActiveAt checks if the reservation still takes up slot capacity
+func (r *DeliverySlotReservation) IsActiveAt(now time.Time) bool {
+	switch r.Status {
+	case DeliverySlotStatusConfirmed:
+		return true
+	case DeliverySlotStatusHeld:
+		return r.ExpiresAt != nil && r.ExpiresAt.After(now)
+	}
+	return false
+}
+
+// Request/Response structs
+
+// DeliverySlotTemplateRequest represents the request body for creating or updating a slot template
+type DeliverySlotTemplateRequest struct {
+	Name          string  `json:"name" binding:"required,min=2,max=100"`
+	Province      string  `json:"province" binding:"required,max=100"`
+	District      string  `json:"district" binding:"required,max=100"`
+	DaysOfWeek    []int   `json:"days_of_week" binding:"omitempty,dive,min=0,max=6"`
+	StartTime     string  `json:"start_time" binding:"required,len=5"`
+	EndTime       string  `json:"end_time" binding:"required,len=5"`
+	Capacity      int     `json:"capacity" binding:"required,min=1"`
+	CutoffMinutes *int    `json:"cutoff_minutes" binding:"omitempty,min=0"`
+	Fee           float64 `json:"fee" binding:"min=0"`
+	SortOrder     int     `json:"sort_order"`
+	IsActive      *bool   `json:"is_active"`
+}
+
+// DeliverySlotReserveRequest represents the request body for holding a delivery slot during checkout
+type DeliverySlotReserveRequest struct {
+	CartID     uint   `json:"-"` // Taken from the URL
+	TemplateID uint   `json:"template_id" binding:"required"`
+	Date       string `json:"date" binding:"required"` // YYYY-MM-DD
+	Province   string `json:"province" binding:"required"`
+	District   string `json:"district" binding:"required"`
+}
+
+// DeliverySlotAvailability represents one bookable window on one day
+type DeliverySlotAvailability struct {
+	TemplateID  uint      `json:"template_id"`
+	Name        string    `json:"name"`
+	Date        string    `json:"date"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Capacity    int       `json:"capacity"`
+	Booked      int64     `json:"booked"`
+	Remaining   int64     `json:"remaining"`
+	Fee         float64   `json:"fee"`
+	IsAvailable bool      `json:"is_available"`
+	Reason      string    `json:"reason,omitempty"` // full, cutoff_passed
+}
+
+// Slot unavailability reasons
+const (
+	DeliverySlotReasonFull   = "full"
+	DeliverySlotReasonCutoff = "cutoff_passed"
+)
+
+// DeliverySlotUtilization represents the bookings of one window on one day for the admin view
+type DeliverySlotUtilization struct {
+	TemplateID      uint      `json:"template_id"`
+	Name            string    `json:"name"`
+	Province        string    `json:"province"`
+	District        string    `json:"district"`
+	Date            string    `json:"date"`
+	WindowStart     time.Time `json:"window_start"`
+	WindowEnd       time.Time `json:"window_end"`
+	Capacity        int       `json:"capacity"`
+	Confirmed       int64     `json:"confirmed"`
+	Held            int64     `json:"held"`
+	Remaining       int64     `json:"remaining"`
+	UtilizationRate float64   `json:"utilization_rate"` // % capacity taken by confirmed and held bookings
+}
+
+// DeliverySlotBookingCount is the number of active bookings of a template on a date by status
+type DeliverySlotBookingCount struct {
+	TemplateID   uint
+	DeliveryDate time.Time
+	Status       DeliverySlotReservationStatus
+	Count        int64
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// Impersonation end reasons
+const (
+	ImpersonationEndStopped  = "stopped"  // Nhân viên hỗ trợ tự kết thúc
+	ImpersonationEndReplaced = "replaced" // Bắt đầu phiên đăng nhập thay khác
+	ImpersonationEndRevoked  = "revoked"  // Admin chấm dứt
+)
+
+// Impersonation is a support agent acting as a customer for a limited time, e.g. to reproduce a
+// cart or checkout problem. The token is tied to the agent's own session.
+type Impersonation struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	ActorID        uint       `json:"actor_id" gorm:"not null;index"` // Nhân viên thực sự thao tác
+	Actor          *User      `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	SubjectID      uint       `json:"subject_id" gorm:"not null;index"` // Khách hàng bị đăng nhập thay
+	Subject        *User      `json:"subject,omitempty" gorm:"foreignKey:SubjectID"`
+	ActorSessionID uint       `json:"-" gorm:"not null;index"` // Phiên của nhân viên; đăng xuất là token hết hiệu lực
+	Reason         string     `json:"reason" gorm:"size:500;not null"`
+	IPAddress      string     `json:"ip_address" gorm:"size:45"`
+	UserAgent      string     `json:"user_agent" gorm:"size:500"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	EndedAt        *time.Time `json:"ended_at"`
+	EndReason      string     `json:"end_reason" gorm:"size:20"` // stopped, replaced, revoked
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Impersonation model
+func (Impersonation) TableName() string {
+	return "impersonations"
+}
+
+// IsActive checks if the impersonation has neither ended nor expired
+func (i *Impersonation) IsActive() bool {
+	return i.EndedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// ImpersonationBanner tells the frontend to show a "you are acting as ..." banner
+type ImpersonationBanner struct {
+	Active          bool      `json:"active"`
+	ImpersonationID uint      `json:"impersonation_id"`
+	ActorID         uint      `json:"actor_id"`
+	ActorUsername   string    `json:"actor_username"`
+	SubjectID       uint      `json:"subject_id"`
+	SubjectUsername string    `json:"subject_username"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
@@ -42,6 +42,11 @@ type InventoryMovement struct {
 	Reference      string                  `json:"reference" gorm:"type:varchar(255)"`     // Số tham chiếu (PO, SO, etc.)
 	ReferenceType  string                  `json:"reference_type" gorm:"type:varchar(50)"` // purchase_order, sales_order, etc.
 	Notes          string                  `json:"notes" gorm:"type:text"`
+	LotID          *uint                   `json:"lot_id" gorm:"index"` // Lô hàng (nhập kho theo lô)
+	Lot            *InventoryLot           `json:"lot,omitempty" gorm:"foreignKey:LotID"`
+	LotNumber      string                  `json:"lot_number" gorm:"size:100;index"` // Số lô
+	ExpiryDate     *time.Time              `json:"expiry_date"`                      // Hạn sử dụng
+	ManufacturedAt *time.Time              `json:"manufactured_at"`                  // Ngày sản xuất
 	CreatedBy      uint                    `json:"created_by" gorm:"not null;index"`
 	CreatedByUser  *User                   `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy"`
 	ApprovedBy     *uint                   `json:"approved_by" gorm:"index"`
@@ -67,6 +72,7 @@ type StockLevel struct {
 	MinStockLevel     int             `json:"min_stock_level" gorm:"default:0"`    // Mức tồn kho tối thiểu
 	MaxStockLevel     int             `json:"max_stock_level" gorm:"default:0"`    // Mức tồn kho tối đa
 	ReorderPoint      int             `json:"reorder_point" gorm:"default:0"`      // Điểm đặt hàng lại
+	Location          string          `json:"location" gorm:"size:100;index"`      // Vị trí kho (kệ, khu vực)
 	LastMovementAt    *time.Time      `json:"last_movement_at"`                    // Lần di chuyển cuối
 	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
@@ -104,6 +110,11 @@ type InventoryMovementCreateRequest struct {
 	Reference     string                `json:"reference"`
 	ReferenceType string                `json:"reference_type"`
 	Notes         string                `json:"notes"`
+
+	// Lot tracking (required for inbound/return of lot-tracked products)
+	LotNumber      string     `json:"lot_number" binding:"max=100"`
+	ExpiryDate     *time.Time `json:"expiry_date"`
+	ManufacturedAt *time.Time `json:"manufactured_at"`
 }
 
 // InventoryMovementUpdateRequest represents the request body for updating an inventory movement
@@ -123,9 +134,10 @@ type InventoryAdjustmentCreateRequest struct {
 
 // StockLevelUpdateRequest represents the request body for updating stock levels
 type StockLevelUpdateRequest struct {
-	MinStockLevel int `json:"min_stock_level" binding:"gte=0"`
-	MaxStockLevel int `json:"max_stock_level" binding:"gte=0"`
-	ReorderPoint  int `json:"reorder_point" binding:"gte=0"`
+	MinStockLevel int    `json:"min_stock_level" binding:"gte=0"`
+	MaxStockLevel int    `json:"max_stock_level" binding:"gte=0"`
+	ReorderPoint  int    `json:"reorder_point" binding:"gte=0"`
+	Location      string `json:"location" binding:"max=100"`
 }
 
 // InventoryMovementResponse represents the response body for an inventory movement
@@ -143,6 +155,10 @@ type InventoryMovementResponse struct {
 	Reference      string                  `json:"reference"`
 	ReferenceType  string                  `json:"reference_type"`
 	Notes          string                  `json:"notes"`
+	LotID          *uint                   `json:"lot_id"`
+	LotNumber      string                  `json:"lot_number,omitempty"`
+	ExpiryDate     *time.Time              `json:"expiry_date,omitempty"`
+	ManufacturedAt *time.Time              `json:"manufactured_at,omitempty"`
 	CreatedBy      uint                    `json:"created_by"`
 	CreatedByName  string                  `json:"created_by_name,omitempty"`
 	ApprovedBy     *uint                   `json:"approved_by"`
@@ -167,6 +183,7 @@ type StockLevelResponse struct {
 	MinStockLevel     int        `json:"min_stock_level"`
 	MaxStockLevel     int        `json:"max_stock_level"`
 	ReorderPoint      int        `json:"reorder_point"`
+	Location          string     `json:"location"`
 	LastMovementAt    *time.Time `json:"last_movement_at"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InventoryLotStatus defines the status of an inventory lot
+type InventoryLotStatus string
+
+const (
+	LotStatusActive   InventoryLotStatus = "active"   // Còn hàng
+	LotStatusDepleted InventoryLotStatus = "depleted" // Đã xuất hết
+	LotStatusExpired  InventoryLotStatus = "expired"  // Đã hết hạn
+)
+
+// InventoryLot represents a batch of a lot-tracked product received with a lot number and expiry date
+type InventoryLot struct {
+	ID                   uint               `json:"id" gorm:"primaryKey"`
+	ProductID            uint               `json:"product_id" gorm:"not null;index"`
+	Product              *Product           `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	VariantID            *uint              `json:"variant_id" gorm:"index"`
+	Variant              *ProductVariant    `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	LotNumber            string             `json:"lot_number" gorm:"size:100;not null;index"` // Số lô
+	ExpiryDate           *time.Time         `json:"expiry_date" gorm:"index"`                  // Hạn sử dụng
+	ManufacturedAt       *time.Time         `json:"manufactured_at"`                           // Ngày sản xuất
+	ReceivedQuantity     int                `json:"received_quantity" gorm:"default:0"`        // Tổng số lượng đã nhập
+	AvailableQuantity    int                `json:"available_quantity" gorm:"default:0"`       // Số lượng còn lại
+	UnitCost             float64            `json:"unit_cost" gorm:"type:decimal(10,2);default:0.00"`
+	Status               InventoryLotStatus `json:"status" gorm:"type:varchar(20);default:'active';index"`
+	NearExpiryNotifiedAt *time.Time         `json:"near_expiry_notified_at"` // Lần cảnh báo sắp hết hạn gần nhất
+	ReceivedAt           time.Time          `json:"received_at"`
+	CreatedAt            time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt            time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt            gorm.DeletedAt     `json:"deleted_at" gorm:"index"`
+}
+
+// IsExpired checks if the lot is past its expiry date
+func (l *InventoryLot) IsExpired(now time.Time) bool {
+	return l.ExpiryDate != nil && l.ExpiryDate.Before(now)
+}
+
+// DaysUntilExpiry returns the number of whole days until expiry, or nil when the lot has no expiry date
+func (l *InventoryLot) DaysUntilExpiry(now time.Time) *int {
+	if l.ExpiryDate == nil {
+		return nil
+	}
+	days := int(l.ExpiryDate.Sub(now).Hours() / 24)
+	return &days
+}
+
+// InventoryLotAllocation records how many units of an outbound movement were picked from a lot
+type InventoryLotAllocation struct {
+	ID         uint               `json:"id" gorm:"primaryKey"`
+	MovementID uint               `json:"movement_id" gorm:"not null;index"`
+	Movement   *InventoryMovement `json:"movement,omitempty" gorm:"foreignKey:MovementID"`
+	LotID      uint               `json:"lot_id" gorm:"not null;index"`
+	Lot        *InventoryLot      `json:"lot,omitempty" gorm:"foreignKey:LotID"`
+	Quantity   int                `json:"quantity" gorm:"not null"`
+	Reference  string             `json:"reference" gorm:"size:255;index"` // Số tham chiếu của phiếu xuất
+	ReleasedAt *time.Time         `json:"released_at"`                     // Thời điểm hoàn trả về lô (hủy đơn)
+	CreatedAt  time.Time          `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Request/Response structs
+
+// InventoryLotResponse represents the response body for an inventory lot
+type InventoryLotResponse struct {
+	ID                uint               `json:"id"`
+	ProductID         uint               `json:"product_id"`
+	ProductName       string             `json:"product_name,omitempty"`
+	VariantID         *uint              `json:"variant_id"`
+	VariantName       string             `json:"variant_name,omitempty"`
+	LotNumber         string             `json:"lot_number"`
+	ExpiryDate        *time.Time         `json:"expiry_date"`
+	ManufacturedAt    *time.Time         `json:"manufactured_at"`
+	DaysUntilExpiry   *int               `json:"days_until_expiry,omitempty"`
+	ReceivedQuantity  int                `json:"received_quantity"`
+	AvailableQuantity int                `json:"available_quantity"`
+	UnitCost          float64            `json:"unit_cost"`
+	Status            InventoryLotStatus `json:"status"`
+	ReceivedAt        time.Time          `json:"received_at"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+// LotPickLine represents a single lot picked for an outbound quantity
+type LotPickLine struct {
+	LotID      uint       `json:"lot_id"`
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date"`
+	Quantity   int        `json:"quantity"`
+}
+
+// LotPickPlan represents a FEFO (first-expiry-first-out) picking plan
+type LotPickPlan struct {
+	ProductID         uint          `json:"product_id"`
+	VariantID         *uint         `json:"variant_id"`
+	RequestedQuantity int           `json:"requested_quantity"`
+	PickedQuantity    int           `json:"picked_quantity"`
+	ShortQuantity     int           `json:"short_quantity"`
+	Lines             []LotPickLine `json:"lines"`
+}
+
+// LotStockReportItem represents stock of one product/variant broken down by lot
+type LotStockReportItem struct {
+	ProductID       uint                   `json:"product_id"`
+	ProductName     string                 `json:"product_name"`
+	VariantID       *uint                  `json:"variant_id"`
+	VariantName     string                 `json:"variant_name,omitempty"`
+	TotalQuantity   int                    `json:"total_quantity"`
+	ExpiredQuantity int                    `json:"expired_quantity"`
+	NearExpiryQty   int                    `json:"near_expiry_quantity"`
+	Lots            []InventoryLotResponse `json:"lots"`
+}
+
+// LotStockReport represents the stock-by-lot report
+type LotStockReport struct {
+	GeneratedAt    time.Time            `json:"generated_at"`
+	NearExpiryDays int                  `json:"near_expiry_days"`
+	TotalLots      int                  `json:"total_lots"`
+	TotalQuantity  int                  `json:"total_quantity"`
+	ExpiredLots    int                  `json:"expired_lots"`
+	NearExpiryLots int                  `json:"near_expiry_lots"`
+	Items          []LotStockReportItem `json:"items"`
+}
+
+// NearExpiryAlertResult summarizes a near-expiry alert run
+type NearExpiryAlertResult struct {
+	Days        int    `json:"days"`
+	LotsChecked int    `json:"lots_checked"`
+	AlertsSent  int    `json:"alerts_sent"`
+	ExpiredLots int    `json:"expired_lots"`
+	AlertedLots []uint `json:"alerted_lots,omitempty"`
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"time"
+)
+
+// Province represents a province or centrally-run city (Tỉnh/Thành phố trực thuộc TW)
+type Province struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"size:10;not null;uniqueIndex"` // Mã GSO
+	Name      string    `json:"name" gorm:"size:100;not null"`            // Hồ Chí Minh
+	FullName  string    `json:"full_name" gorm:"size:150;not null"`       // Thành phố Hồ Chí Minh
+	Type      string    `json:"type" gorm:"size:30"`                      // tinh, thanh_pho_trung_uong
+	NameKey   string    `json:"-" gorm:"size:150;index"`                  // Tên không dấu để so khớp
+	Aliases   string    `json:"-" gorm:"type:text"`                       // Khóa tên gọi khác, phân cách bằng "|"
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+}
+
+// District represents a district-level unit (Quận/Huyện/Thị xã/Thành phố thuộc tỉnh)
+type District struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	Code         string    `json:"code" gorm:"size:10;not null;uniqueIndex"`
+	ProvinceCode string    `json:"province_code" gorm:"size:10;not null;index"`
+	Name         string    `json:"name" gorm:"size:100;not null"`
+	FullName     string    `json:"full_name" gorm:"size:150;not null"`
+	Type         string    `json:"type" gorm:"size:30"` // quan, huyen, thi_xa, thanh_pho
+	NameKey      string    `json:"-" gorm:"size:150;index"`
+	Aliases      string    `json:"-" gorm:"type:text"`
+	CreatedAt    time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"-" gorm:"autoUpdateTime"`
+}
+
+// Ward represents a commune-level unit (Phường/Xã/Thị trấn)
+type Ward struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	Code         string    `json:"code" gorm:"size:10;not null;uniqueIndex"`
+	DistrictCode string    `json:"district_code" gorm:"size:10;not null;index"`
+	ProvinceCode string    `json:"province_code" gorm:"size:10;not null;index"`
+	Name         string    `json:"name" gorm:"size:100;not null"`
+	FullName     string    `json:"full_name" gorm:"size:150;not null"`
+	Type         string    `json:"type" gorm:"size:30"` // phuong, xa, thi_tran
+	NameKey      string    `json:"-" gorm:"size:150;index"`
+	Aliases      string    `json:"-" gorm:"type:text"`
+	CreatedAt    time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"-" gorm:"autoUpdateTime"`
+}
+
+// CarrierLocationCode maps an administrative unit to the identifier or name a carrier expects
+type CarrierLocationCode struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Carrier      string    `json:"carrier" gorm:"size:50;not null;uniqueIndex:idx_carrier_location_code"` // Mã hãng vận chuyển
+	Level        string    `json:"level" gorm:"size:20;not null;uniqueIndex:idx_carrier_location_code"`   // province, district, ward
+	Code         string    `json:"code" gorm:"size:10;not null;uniqueIndex:idx_carrier_location_code"`    // Mã GSO
+	ExternalID   string    `json:"external_id" gorm:"size:50"`                                            // Mã của hãng (GHN ProvinceID, DistrictID, WardCode...)
+	ExternalName string    `json:"external_name" gorm:"size:150"`                                         // Tên theo hãng (GHTK so khớp theo tên)
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Location levels
+const (
+	LocationLevelProvince = "province"
+	LocationLevelDistrict = "district"
+	LocationLevelWard     = "ward"
+)
+
+// Location normalization targets
+const (
+	LocationTargetAddresses     = "addresses"
+	LocationTargetShippingRates = "shipping_rates"
+)
+
+// Request/Response structs
+
+// ResolvedLocation represents free-text province/district/ward names matched to the directory
+type ResolvedLocation struct {
+	ProvinceCode string `json:"province_code"`
+	Province     string `json:"province"`
+	DistrictCode string `json:"district_code"`
+	District     string `json:"district"`
+	WardCode     string `json:"ward_code"`
+	Ward         string `json:"ward"`
+	Complete     bool   `json:"complete"`             // Khớp đủ các cấp đã nhập
+	Unresolved   string `json:"unresolved,omitempty"` // Cấp đầu tiên không khớp
+}
+
+// CarrierLocation represents an address translated to a carrier's codes and names
+type CarrierLocation struct {
+	Carrier        string `json:"carrier"`
+	ProvinceCode   string `json:"province_code"`
+	ProvinceID     string `json:"province_id"`
+	ProvinceName   string `json:"province_name"`
+	DistrictCode   string `json:"district_code"`
+	DistrictID     string `json:"district_id"`
+	DistrictName   string `json:"district_name"`
+	WardCode       string `json:"ward_code"`
+	WardID         string `json:"ward_id"`
+	WardName       string `json:"ward_name"`
+	MissingMapping bool   `json:"missing_mapping"` // Có cấp chưa có mã riêng của hãng
+}
+
+// CarrierLocationCodeRequest represents carrier code mappings to create or update
+type CarrierLocationCodeRequest struct {
+	Codes []CarrierLocationCodeInput `json:"codes" binding:"required,min=1,dive"`
+}
+
+// CarrierLocationCodeInput represents one carrier code mapping
+type CarrierLocationCodeInput struct {
+	Level        string `json:"level" binding:"required,oneof=province district ward"`
+	Code         string `json:"code" binding:"required"`
+	ExternalID   string `json:"external_id"`
+	ExternalName string `json:"external_name"`
+}
+
+// LocationImportResult represents the outcome of loading a directory dataset
+type LocationImportResult struct {
+	Version      string `json:"version"`
+	Provinces    int    `json:"provinces"`
+	Districts    int    `json:"districts"`
+	Wards        int    `json:"wards"`
+	CarrierCodes int    `json:"carrier_codes"`
+}
+
+// LocationNormalizeRequest represents a request to normalize stored location names
+type LocationNormalizeRequest struct {
+	Targets []string `json:"targets" binding:"omitempty,dive,oneof=addresses shipping_rates"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// LocationNormalizeResult represents the outcome of normalizing one table
+type LocationNormalizeResult struct {
+	Target     string                   `json:"target"`
+	Scanned    int                      `json:"scanned"`
+	Updated    int                      `json:"updated"`
+	Unchanged  int                      `json:"unchanged"`
+	Unresolved int                      `json:"unresolved"`
+	Issues     []LocationNormalizeIssue `json:"issues,omitempty"` // Tối đa maxNormalizeIssues dòng
+	DryRun     bool                     `json:"dry_run"`
+}
+
+// LocationNormalizeIssue represents a record whose location could not be matched
+type LocationNormalizeIssue struct {
+	ID     uint   `json:"id"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+)
+
+// Login attempt failure reasons
+const (
+	LoginFailureInvalidPassword  = "invalid_password"
+	LoginFailureInvalidTwoFactor = "invalid_two_factor" // Sai mã xác thực hai lớp
+	LoginFailureUnknownEmail     = "unknown_email"
+	LoginFailureLocked           = "locked"    // Tài khoản đang bị khóa tạm thời
+	LoginFailureThrottled        = "throttled" // Đăng nhập lại quá sớm sau lần sai
+	LoginFailureInactive         = "inactive"
+)
+
+// Suspicious login reasons
+const (
+	SuspiciousLoginNewDevice  = "new_device"
+	SuspiciousLoginNewCountry = "new_country"
+)
+
+// LoginAttempt records every password login, successful or not
+type LoginAttempt struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        *uint     `json:"user_id" gorm:"index"` // Trống nếu email không tồn tại
+	Email         string    `json:"email" gorm:"size:100;not null;index"`
+	IPAddress     string    `json:"ip_address" gorm:"size:45;index"`
+	Country       string    `json:"country" gorm:"size:2"` // Mã quốc gia ISO theo IP (từ header của CDN)
+	DeviceID      string    `json:"device_id" gorm:"size:100"`
+	UserAgent     string    `json:"user_agent" gorm:"size:500"`
+	Success       bool      `json:"success" gorm:"default:false;index"`
+	FailureReason string    `json:"failure_reason,omitempty" gorm:"size:30"` // invalid_password, unknown_email, locked, throttled, inactive
+	Suspicious    string    `json:"suspicious,omitempty" gorm:"size:100"`    // new_device, new_country (phân tách bằng dấu phẩy)
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName returns the table name for LoginAttempt model
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// AccountLockout records a temporary lock after too many failed logins
+type AccountLockout struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	FailedAttempts int        `json:"failed_attempts"`
+	IPAddress      string     `json:"ip_address" gorm:"size:45"` // IP của lần sai cuối cùng
+	LockedAt       time.Time  `json:"locked_at"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at"`
+	UnlockedBy     *uint      `json:"unlocked_by"` // Admin mở khóa thủ công
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Relations
+	User *User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for AccountLockout model
+func (AccountLockout) TableName() string {
+	return "account_lockouts"
+}
+
+// LockedAccountResponse is a locked account in the admin list
+type LockedAccountResponse struct {
+	UserID         uint      `json:"user_id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	LockedAt       time.Time `json:"locked_at"`
+	LockedUntil    time.Time `json:"locked_until"`
+	LockoutCount   int       `json:"lockout_count"` // Số lần bị khóa liên tiếp
+	FailedAttempts int       `json:"failed_attempts"`
+	LastIPAddress  string    `json:"last_ip_address"`
+}
@@ -10,14 +10,16 @@ import (
 type OrderStatus string
 
 const (
-	OrderStatusPending    OrderStatus = "pending"    // Chờ xử lý
-	OrderStatusConfirmed  OrderStatus = "confirmed"  // Đã xác nhận
-	OrderStatusProcessing OrderStatus = "processing" // Đang xử lý
-	OrderStatusShipped    OrderStatus = "shipped"    // Đã giao hàng
-	OrderStatusDelivered  OrderStatus = "delivered"  // Đã giao thành công
-	OrderStatusCancelled  OrderStatus = "cancelled"  // Đã hủy
-	OrderStatusReturned   OrderStatus = "returned"   // Đã trả hàng
-	OrderStatusRefunded   OrderStatus = "refunded"   // Đã hoàn tiền
+	OrderStatusPending          OrderStatus = "pending"           // Chờ xử lý
+	OrderStatusConfirmed        OrderStatus = "confirmed"         // Đã xác nhận
+	OrderStatusProcessing       OrderStatus = "processing"        // Đang xử lý
+	OrderStatusShipped          OrderStatus = "shipped"           // Đã giao hàng
+	OrderStatusPartiallyShipped OrderStatus = "partially_shipped" // Đã giao một phần
+	OrderStatusReadyForPickup   OrderStatus = "ready_for_pickup"  // Sẵn sàng nhận tại cửa hàng
+	OrderStatusDelivered        OrderStatus = "delivered"         // Đã giao thành công
+	OrderStatusCancelled        OrderStatus = "cancelled"         // Đã hủy
+	OrderStatusReturned         OrderStatus = "returned"          // Đã trả hàng
+	OrderStatusRefunded         OrderStatus = "refunded"          // Đã hoàn tiền
 )
 
 // PaymentStatus defines the payment status
@@ -43,12 +45,14 @@ const (
 type ShippingStatus string
 
 const (
-	ShippingStatusPending   ShippingStatus = "pending"    // Chờ giao hàng
-	ShippingStatusPickedUp  ShippingStatus = "picked_up"  // Đã lấy hàng
-	ShippingStatusInTransit ShippingStatus = "in_transit" // Đang vận chuyển
-	ShippingStatusDelivered ShippingStatus = "delivered"  // Đã giao hàng
-	ShippingStatusFailed    ShippingStatus = "failed"     // Giao hàng thất bại
-	ShippingStatusReturned  ShippingStatus = "returned"   // Trả hàng
+	ShippingStatusPending          ShippingStatus = "pending"           // Chờ giao hàng
+	ShippingStatusPickedUp         ShippingStatus = "picked_up"         // Đã lấy hàng
+	ShippingStatusInTransit        ShippingStatus = "in_transit"        // Đang vận chuyển
+	ShippingStatusPartiallyShipped ShippingStatus = "partially_shipped" // Đã gửi một phần
+	ShippingStatusReadyForPickup   ShippingStatus = "ready_for_pickup"  // Chờ khách đến nhận
+	ShippingStatusDelivered        ShippingStatus = "delivered"         // Đã giao hàng
+	ShippingStatusFailed           ShippingStatus = "failed"            // Giao hàng thất bại
+	ShippingStatusReturned         ShippingStatus = "returned"          // Trả hàng
 )
 
 // Order represents an order in the system
@@ -94,6 +98,20 @@ type Order struct {
 	ShippedAt      *time.Time `json:"shipped_at"`                      // Thời gian giao hàng
 	DeliveredAt    *time.Time `json:"delivered_at"`                    // Thời gian nhận hàng
 
+	// Pickup Information (click-and-collect)
+	PickupLocationID *uint           `json:"pickup_location_id" gorm:"index"` // Điểm nhận hàng
+	PickupLocation   *PickupLocation `json:"pickup_location,omitempty" gorm:"foreignKey:PickupLocationID"`
+	ReadyForPickupAt *time.Time      `json:"ready_for_pickup_at"` // Thời gian hàng sẵn sàng tại điểm nhận
+	PickupDeadline   *time.Time      `json:"pickup_deadline"`     // Hạn chót khách đến nhận
+
+	// Delivery Slot (same-city delivery window chosen at checkout)
+	DeliverySlotID      *uint      `json:"delivery_slot_id" gorm:"index"` // Mã giữ chỗ khung giờ giao
+	DeliveryWindowStart *time.Time `json:"delivery_window_start"`         // Giao từ
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end"`           // Giao đến
+
+	// Inventory
+	StockReservedAt *time.Time `json:"stock_reserved_at"` // Thời điểm đã trừ kho; nil = chưa giữ hàng hoặc đã trả lại kho
+
 	// Additional Information
 	Notes      string `json:"notes" gorm:"type:text"`       // Ghi chú
 	AdminNotes string `json:"admin_notes" gorm:"type:text"` // Ghi chú admin
@@ -136,6 +154,16 @@ type OrderItem struct {
 	Dimensions string  `json:"dimensions" gorm:"size:100"`                // Kích thước (LxWxH)
 	Notes      string  `json:"notes" gorm:"type:text"`                    // Ghi chú
 
+	// Backorder Information
+	BackorderedQuantity  int             `json:"backordered_quantity" gorm:"default:0"` // Số lượng chờ hàng về
+	BackorderType        BackorderPolicy `json:"backorder_type" gorm:"size:20"`         // backorder, preorder
+	ExpectedAvailableAt  *time.Time      `json:"expected_available_at"`                 // Ngày dự kiến có hàng
+	BackorderFulfilledAt *time.Time      `json:"backorder_fulfilled_at"`                // Ngày hàng về đủ
+	ShippedQuantity      int             `json:"shipped_quantity" gorm:"default:0"`     // Số lượng đã giao
+
+	// Bundle breakdown (for bundle products)
+	Components []OrderItemComponent `json:"components,omitempty" gorm:"foreignKey:OrderItemID"`
+
 	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -368,7 +396,7 @@ type OrderCreateRequest struct {
 	CustomerPhone string `json:"customer_phone" binding:"required,min=10,max=20"`
 
 	// Address Information
-	ShippingAddress string `json:"shipping_address" binding:"required,min=10"`
+	ShippingAddress string `json:"shipping_address" binding:"required_unless=ShippingMethod pickup,omitempty,min=10"`
 	BillingAddress  string `json:"billing_address"`
 
 	// Address References (optional - for linking to saved addresses)
@@ -379,7 +407,8 @@ type OrderCreateRequest struct {
 	PaymentMethod PaymentMethod `json:"payment_method" binding:"required,oneof=cash bank card wallet cod"`
 
 	// Shipping Information
-	ShippingMethod string `json:"shipping_method" binding:"required,min=2,max=100"`
+	ShippingMethod   string `json:"shipping_method" binding:"required,min=2,max=100"`
+	PickupLocationID *uint  `json:"pickup_location_id" binding:"required_if=ShippingMethod pickup"` // Điểm nhận hàng khi chọn nhận tại cửa hàng
 
 	// Additional Information
 	Notes string `json:"notes"`
@@ -390,9 +419,9 @@ type OrderCreateRequest struct {
 
 // OrderUpdateRequest represents the request body for updating an order
 type OrderUpdateRequest struct {
-	Status         *OrderStatus    `json:"status" binding:"omitempty,oneof=pending confirmed processing shipped delivered cancelled returned refunded"`
+	Status         *OrderStatus    `json:"status" binding:"omitempty,oneof=pending confirmed processing shipped partially_shipped ready_for_pickup delivered cancelled returned refunded"`
 	PaymentStatus  *PaymentStatus  `json:"payment_status" binding:"omitempty,oneof=pending paid failed refunded cancelled"`
-	ShippingStatus *ShippingStatus `json:"shipping_status" binding:"omitempty,oneof=pending picked_up in_transit delivered failed returned"`
+	ShippingStatus *ShippingStatus `json:"shipping_status" binding:"omitempty,oneof=pending picked_up in_transit ready_for_pickup delivered failed returned"`
 
 	// Customer Information
 	CustomerName  string `json:"customer_name" binding:"omitempty,min=2,max=255"`
@@ -484,6 +513,17 @@ type OrderResponse struct {
 	ShippedAt      *time.Time `json:"shipped_at"`
 	DeliveredAt    *time.Time `json:"delivered_at"`
 
+	// Pickup Information
+	PickupLocationID *uint      `json:"pickup_location_id,omitempty"`
+	PickupLocation   string     `json:"pickup_location,omitempty"`
+	ReadyForPickupAt *time.Time `json:"ready_for_pickup_at,omitempty"`
+	PickupDeadline   *time.Time `json:"pickup_deadline,omitempty"`
+
+	// Delivery Slot
+	DeliverySlotID      *uint      `json:"delivery_slot_id,omitempty"`
+	DeliveryWindowStart *time.Time `json:"delivery_window_start,omitempty"`
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end,omitempty"`
+
 	// Additional Information
 	Notes      string `json:"notes"`
 	AdminNotes string `json:"admin_notes"`
@@ -516,6 +556,32 @@ type OrderItemResponse struct {
 	Notes            string    `json:"notes"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+
+	IsBackordered        bool            `json:"is_backordered"`
+	BackorderedQuantity  int             `json:"backordered_quantity"`
+	BackorderType        BackorderPolicy `json:"backorder_type,omitempty"`
+	ExpectedAvailableAt  *time.Time      `json:"expected_available_at,omitempty"`
+	BackorderFulfilledAt *time.Time      `json:"backorder_fulfilled_at,omitempty"`
+	ShippedQuantity      int             `json:"shipped_quantity"`
+
+	Components []OrderItemComponentResponse `json:"components,omitempty"`
+}
+
+// BackorderItemResponse represents a backordered order item waiting for stock
+type BackorderItemResponse struct {
+	OrderID      uint              `json:"order_id"`
+	OrderNumber  string            `json:"order_number"`
+	OrderStatus  OrderStatus       `json:"order_status"`
+	CustomerName string            `json:"customer_name"`
+	Item         OrderItemResponse `json:"item"`
+}
+
+// BackorderProcessResult represents the outcome of a backorder fulfillment run
+type BackorderProcessResult struct {
+	ItemsChecked      int    `json:"items_checked"`
+	ItemsFulfilled    int    `json:"items_fulfilled"`
+	NotificationsSent int    `json:"notifications_sent"`
+	FulfilledItems    []uint `json:"fulfilled_items,omitempty"`
 }
 
 // CartResponse represents the response body for a cart
@@ -620,17 +686,62 @@ func (o *Order) IsCancelled() bool {
 
 // CanBeCancelled checks if order can be cancelled
 func (o *Order) CanBeCancelled() bool {
-	return o.Status == OrderStatusPending || o.Status == OrderStatusConfirmed
+	return o.Status == OrderStatusPending || o.Status == OrderStatusConfirmed || o.Status == OrderStatusReadyForPickup
 }
 
 // CanBeShipped checks if order can be shipped
 func (o *Order) CanBeShipped() bool {
-	return o.Status == OrderStatusConfirmed || o.Status == OrderStatusProcessing
+	return o.Status == OrderStatusConfirmed || o.Status == OrderStatusProcessing || o.Status == OrderStatusPartiallyShipped
 }
 
 // CanBeDelivered checks if order can be delivered
 func (o *Order) CanBeDelivered() bool {
-	return o.Status == OrderStatusShipped
+	return o.Status == OrderStatusShipped || o.Status == OrderStatusReadyForPickup
+}
+
+// IsPickup checks if the customer collects the order at a pickup location
+func (o *Order) IsPickup() bool {
+	return o.ShippingMethod == ShippingMethodPickup && o.PickupLocationID != nil
+}
+
+// CanBeReadyForPickup checks if a pickup order can be handed over at the counter
+func (o *Order) CanBeReadyForPickup() bool {
+	if !o.IsPickup() || o.HasOpenBackorders() {
+		return false
+	}
+	return o.Status == OrderStatusConfirmed || o.Status == OrderStatusProcessing || o.Status == OrderStatusShipped
+}
+
+// HasOpenBackorders checks if any item is still waiting for stock
+func (o *Order) HasOpenBackorders() bool {
+	for i := range o.OrderItems {
+		if o.OrderItems[i].IsBackordered() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBackordered checks if the item is still waiting for stock
+func (oi *OrderItem) IsBackordered() bool {
+	return oi.BackorderedQuantity > 0 && oi.BackorderFulfilledAt == nil
+}
+
+// InStockQuantity returns the quantity that was reserved from stock
+func (oi *OrderItem) InStockQuantity() int {
+	if oi.IsBackordered() {
+		return oi.Quantity - oi.BackorderedQuantity
+	}
+	return oi.Quantity
+}
+
+// ReadyToShipQuantity returns the quantity that can be shipped now
+func (oi *OrderItem) ReadyToShipQuantity() int {
+	qty := oi.InStockQuantity() - oi.ShippedQuantity
+	if qty < 0 {
+		return 0
+	}
+	return qty
 }
 
 // IsPaid checks if order is paid
@@ -641,14 +752,16 @@ func (o *Order) IsPaid() bool {
 // GetStatusDisplayName returns display name for order status
 func (o *Order) GetStatusDisplayName() string {
 	statusMap := map[OrderStatus]string{
-		OrderStatusPending:    "Chờ xử lý",
-		OrderStatusConfirmed:  "Đã xác nhận",
-		OrderStatusProcessing: "Đang xử lý",
-		OrderStatusShipped:    "Đã giao hàng",
-		OrderStatusDelivered:  "Đã giao thành công",
-		OrderStatusCancelled:  "Đã hủy",
-		OrderStatusReturned:   "Đã trả hàng",
-		OrderStatusRefunded:   "Đã hoàn tiền",
+		OrderStatusPending:          "Chờ xử lý",
+		OrderStatusConfirmed:        "Đã xác nhận",
+		OrderStatusProcessing:       "Đang xử lý",
+		OrderStatusShipped:          "Đã giao hàng",
+		OrderStatusPartiallyShipped: "Đã giao một phần",
+		OrderStatusReadyForPickup:   "Sẵn sàng nhận tại cửa hàng",
+		OrderStatusDelivered:        "Đã giao thành công",
+		OrderStatusCancelled:        "Đã hủy",
+		OrderStatusReturned:         "Đã trả hàng",
+		OrderStatusRefunded:         "Đã hoàn tiền",
 	}
 	return statusMap[o.Status]
 }
@@ -668,12 +781,14 @@ func (o *Order) GetPaymentStatusDisplayName() string {
 // GetShippingStatusDisplayName returns display name for shipping status
 func (o *Order) GetShippingStatusDisplayName() string {
 	statusMap := map[ShippingStatus]string{
-		ShippingStatusPending:   "Chờ giao hàng",
-		ShippingStatusPickedUp:  "Đã lấy hàng",
-		ShippingStatusInTransit: "Đang vận chuyển",
-		ShippingStatusDelivered: "Đã giao hàng",
-		ShippingStatusFailed:    "Giao hàng thất bại",
-		ShippingStatusReturned:  "Trả hàng",
+		ShippingStatusPending:          "Chờ giao hàng",
+		ShippingStatusPickedUp:         "Đã lấy hàng",
+		ShippingStatusInTransit:        "Đang vận chuyển",
+		ShippingStatusPartiallyShipped: "Đã gửi một phần",
+		ShippingStatusReadyForPickup:   "Chờ khách đến nhận",
+		ShippingStatusDelivered:        "Đã giao hàng",
+		ShippingStatusFailed:           "Giao hàng thất bại",
+		ShippingStatusReturned:         "Trả hàng",
 	}
 	return statusMap[o.ShippingStatus]
 }
@@ -34,6 +34,11 @@ type OrderTracking struct {
 	LastUpdatedAt time.Time `json:"last_updated_at"`
 	LastSyncAt    time.Time `json:"last_sync_at"`
 
+	// Sync Backoff
+	SyncFailures  int        `json:"sync_failures" gorm:"default:0"` // Số lần đồng bộ lỗi liên tiếp
+	NextSyncAt    *time.Time `json:"next_sync_at" gorm:"index"`      // Thời điểm được đồng bộ lại
+	LastSyncError string     `json:"last_sync_error" gorm:"size:500"`
+
 	// Settings
 	AutoSync   bool `json:"auto_sync" gorm:"default:true"`
 	NotifyUser bool `json:"notify_user" gorm:"default:true"`
@@ -170,6 +175,9 @@ type OrderTrackingResponse struct {
 	TrackingURL       string     `json:"tracking_url"`
 	LastUpdatedAt     time.Time  `json:"last_updated_at"`
 	LastSyncAt        time.Time  `json:"last_sync_at"`
+	SyncFailures      int        `json:"sync_failures"`
+	NextSyncAt        *time.Time `json:"next_sync_at"`
+	LastSyncError     string     `json:"last_sync_error,omitempty"`
 	AutoSync          bool       `json:"auto_sync"`
 	NotifyUser        bool       `json:"notify_user"`
 	IsActive          bool       `json:"is_active"`
@@ -214,6 +222,15 @@ type OrderTrackingSyncRequest struct {
 	ForceSync        bool   `json:"force_sync"`
 }
 
+// OrderTrackingSyncResult represents the outcome of a carrier sync run
+type OrderTrackingSyncResult struct {
+	Checked       int `json:"checked"`
+	Updated       int `json:"updated"`
+	Failed        int `json:"failed"`
+	Skipped       int `json:"skipped"` // Hãng vận chuyển chưa được tích hợp
+	EventsCreated int `json:"events_created"`
+}
+
 // OrderTrackingStatsResponse represents tracking statistics
 type OrderTrackingStatsResponse struct {
 	TotalTrackings      int64   `json:"total_trackings"`
@@ -12,9 +12,10 @@ type OTPType string
 const (
 	OTPTypePasswordReset OTPType = "password_reset"
 	OTPTypeEmailVerify   OTPType = "email_verify"
+	OTPTypeTwoFactor     OTPType = "two_factor" // Mã đăng nhập bước 2 gửi qua email
 )
 
-// OTP represents OTP model for password reset and email verification
+// OTP represents OTP model for password reset, email verification and the email 2FA fallback
 type OTP struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	UserID    uint           `json:"user_id" gorm:"not null;index"`
@@ -15,6 +15,8 @@ const (
 	PermissionTypeDelete PermissionType = "delete" // Xóa dữ liệu
 	PermissionTypeManage PermissionType = "manage" // Quản lý
 	PermissionTypeAdmin  PermissionType = "admin"  // Quản trị
+
+	PermissionTypeImpersonate PermissionType = "impersonate" // Đăng nhập thay khách hàng để hỗ trợ
 )
 
 // ResourceType defines the type of resource
@@ -43,6 +45,40 @@ const (
 	ResourceTypeAudit        ResourceType = "audit"
 )
 
+// AllPermissionTypes lists every permission action
+var AllPermissionTypes = []PermissionType{
+	PermissionTypeRead, PermissionTypeWrite, PermissionTypeDelete, PermissionTypeManage, PermissionTypeAdmin,
+	PermissionTypeImpersonate,
+}
+
+// AllResourceTypes lists every resource that permissions can be granted on
+var AllResourceTypes = []ResourceType{
+	ResourceTypeUser, ResourceTypeBrand, ResourceTypeCategory, ResourceTypeProduct, ResourceTypeInventory,
+	ResourceTypeUpload, ResourceTypeOrder, ResourceTypeAddress, ResourceTypeReview, ResourceTypeCoupon,
+	ResourceTypePoint, ResourceTypeBanner, ResourceTypeSlider, ResourceTypeWishlist, ResourceTypeSearch,
+	ResourceTypeNotification, ResourceTypeCustomer, ResourceTypeReport, ResourceTypeSystem, ResourceTypeAudit,
+}
+
+// IsValidResourceType checks if the value is a known resource
+func IsValidResourceType(value string) bool {
+	for _, resource := range AllResourceTypes {
+		if string(resource) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidPermissionType checks if the value is a known permission action
+func IsValidPermissionType(value string) bool {
+	for _, action := range AllPermissionTypes {
+		if string(action) == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Permission represents a permission in the system
 type Permission struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
@@ -88,6 +124,7 @@ type RolePermission struct {
 	GrantedBy     uint           `json:"granted_by" gorm:"not null;index"`
 	GrantedByUser *User          `json:"granted_by_user,omitempty" gorm:"foreignKey:GrantedBy"`
 	GrantedAt     time.Time      `json:"granted_at" gorm:"autoCreateTime"`
+	Conditions    string         `json:"conditions,omitempty" gorm:"type:text"` // Điều kiện ABAC (JSON), rỗng = không giới hạn
 	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -106,8 +143,9 @@ type UserPermission struct {
 	IsGranted     bool           `json:"is_granted" gorm:"default:true"` // true = grant, false = deny
 	GrantedBy     uint           `json:"granted_by" gorm:"not null;index"`
 	GrantedByUser *User          `json:"granted_by_user,omitempty" gorm:"foreignKey:GrantedBy"`
-	Reason        string         `json:"reason" gorm:"type:text"` // Reason for granting/denying
-	ExpiresAt     *time.Time     `json:"expires_at"`              // Optional expiration
+	Reason        string         `json:"reason" gorm:"type:text"`               // Reason for granting/denying
+	ExpiresAt     *time.Time     `json:"expires_at"`                            // Optional expiration
+	Conditions    string         `json:"conditions,omitempty" gorm:"type:text"` // Điều kiện ABAC (JSON), rỗng = không giới hạn
 	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -173,10 +211,16 @@ type RolePermissionRequest struct {
 
 // UserPermissionRequest represents the request body for assigning permissions to user
 type UserPermissionRequest struct {
-	PermissionID uint       `json:"permission_id" binding:"required"`
-	IsGranted    bool       `json:"is_granted"`
-	Reason       string     `json:"reason"`
-	ExpiresAt    *time.Time `json:"expires_at"`
+	PermissionID uint                  `json:"permission_id" binding:"required"`
+	IsGranted    bool                  `json:"is_granted"`
+	Reason       string                `json:"reason"`
+	ExpiresAt    *time.Time            `json:"expires_at"`
+	Conditions   []PermissionCondition `json:"conditions,omitempty"` // All must hold, e.g. warehouse in [...]
+}
+
+// RolePermissionConditionsRequest represents the request body for limiting a role permission by conditions
+type RolePermissionConditionsRequest struct {
+	Conditions []PermissionCondition `json:"conditions"` // Empty removes the conditions
 }
 
 // PermissionResponse represents the response body for a permission
@@ -209,32 +253,42 @@ type RoleResponse struct {
 
 // UserPermissionResponse represents the response body for user permission
 type UserPermissionResponse struct {
-	ID            uint               `json:"id"`
-	UserID        uint               `json:"user_id"`
-	UserName      string             `json:"user_name,omitempty"`
-	Permission    PermissionResponse `json:"permission"`
-	IsGranted     bool               `json:"is_granted"`
-	Reason        string             `json:"reason"`
-	ExpiresAt     *time.Time         `json:"expires_at"`
-	GrantedBy     uint               `json:"granted_by"`
-	GrantedByName string             `json:"granted_by_name,omitempty"`
-	CreatedAt     time.Time          `json:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at"`
+	ID            uint                  `json:"id"`
+	UserID        uint                  `json:"user_id"`
+	UserName      string                `json:"user_name,omitempty"`
+	Permission    PermissionResponse    `json:"permission"`
+	IsGranted     bool                  `json:"is_granted"`
+	Reason        string                `json:"reason"`
+	ExpiresAt     *time.Time            `json:"expires_at"`
+	Conditions    []PermissionCondition `json:"conditions,omitempty"`
+	GrantedBy     uint                  `json:"granted_by"`
+	GrantedByName string                `json:"granted_by_name,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
 }
 
 // PermissionCheckRequest represents the request body for checking permissions
 type PermissionCheckRequest struct {
-	UserID     uint           `json:"user_id" binding:"required"`
-	Resource   ResourceType   `json:"resource" binding:"required"`
-	Action     PermissionType `json:"action" binding:"required"`
-	ResourceID *uint          `json:"resource_id,omitempty"` // For resource-specific permissions
+	UserID     uint                   `json:"user_id" binding:"required"`
+	Resource   ResourceType           `json:"resource" binding:"required"`
+	Action     PermissionType         `json:"action" binding:"required"`
+	ResourceID *uint                  `json:"resource_id,omitempty"` // For resource-specific permissions
+	Attributes map[string]interface{} `json:"attributes,omitempty"`  // Request/resource attributes for conditional grants
 }
 
 // PermissionCheckResponse represents the response body for permission check
 type PermissionCheckResponse struct {
 	HasPermission bool   `json:"has_permission"`
-	Source        string `json:"source"` // role, user_permission, system
+	Source        string `json:"source"` // role, role_grant, user_permission, system
 	Reason        string `json:"reason,omitempty"`
+	Conditional   bool   `json:"conditional,omitempty"` // Granted only if conditions on resource attributes also hold
+}
+
+// PermissionGrant is one active grant of a permission to a user with its conditions
+type PermissionGrant struct {
+	Source     string `json:"source"`    // user_permission, role, role_grant
+	SourceID   uint   `json:"source_id"` // ID of the user permission, role or role grant
+	Conditions string `json:"conditions"`
 }
 
 // PermissionStatsResponse represents permission statistics
@@ -0,0 +1,255 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Condition operators
+const (
+	ConditionOpEq    = "eq"     // Bằng
+	ConditionOpNe    = "ne"     // Khác
+	ConditionOpIn    = "in"     // Thuộc danh sách
+	ConditionOpNotIn = "not_in" // Không thuộc danh sách
+	ConditionOpLt    = "lt"     // Nhỏ hơn
+	ConditionOpLte   = "lte"    // Nhỏ hơn hoặc bằng
+	ConditionOpGt    = "gt"     // Lớn hơn
+	ConditionOpGte   = "gte"    // Lớn hơn hoặc bằng
+	ConditionOpCIDR  = "cidr"   // Địa chỉ IP thuộc dải mạng
+)
+
+// ConditionResult is the outcome of evaluating conditions against the known attributes
+type ConditionResult int
+
+const (
+	ConditionMet       ConditionResult = iota // Tất cả điều kiện thỏa mãn
+	ConditionFailed                           // Có điều kiện không thỏa mãn
+	ConditionUndecided                        // Thiếu thuộc tính để đánh giá (ví dụ: chưa tải tài nguyên)
+)
+
+// PermissionCondition limits a role or user permission by an attribute of the request or the
+// resource, e.g. {"attribute":"amount","operator":"lt","value":10000000}. A value starting with
+// "$" refers to another attribute, e.g. {"attribute":"created_by","operator":"ne","value":"$user_id"}.
+type PermissionCondition struct {
+	Attribute string      `json:"attribute" binding:"required"`
+	Operator  string      `json:"operator" binding:"required"`
+	Value     interface{} `json:"value"`
+}
+
+// ParsePermissionConditions decodes the conditions stored on a grant; empty means unconditional
+func ParsePermissionConditions(raw string) ([]PermissionCondition, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var conditions []PermissionCondition
+	if err := json.Unmarshal([]byte(raw), &conditions); err != nil {
+		return nil, err
+	}
+	return conditions, nil
+}
+
+// EncodePermissionConditions validates conditions and encodes them for storage
+func EncodePermissionConditions(conditions []PermissionCondition) (string, error) {
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	for i := range conditions {
+		if err := conditions[i].Validate(); err != nil {
+			return "", fmt.Errorf("condition %d: %v", i+1, err)
+		}
+	}
+	encoded, err := json.Marshal(conditions)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// Validate checks the operator and that the value has a matching type
+func (pc *PermissionCondition) Validate() error {
+	pc.Attribute = strings.TrimSpace(pc.Attribute)
+	if pc.Attribute == "" {
+		return errors.New("attribute is required")
+	}
+	if _, isRef := conditionReference(pc.Value); isRef {
+		switch pc.Operator {
+		case ConditionOpEq, ConditionOpNe, ConditionOpLt, ConditionOpLte, ConditionOpGt, ConditionOpGte:
+			return nil
+		}
+		return fmt.Errorf("operator %s cannot compare with another attribute", pc.Operator)
+	}
+
+	switch pc.Operator {
+	case ConditionOpEq, ConditionOpNe:
+		if pc.Value == nil {
+			return errors.New("value is required")
+		}
+	case ConditionOpIn, ConditionOpNotIn:
+		if list, ok := pc.Value.([]interface{}); !ok || len(list) == 0 {
+			return errors.New("value must be a non-empty list")
+		}
+	case ConditionOpLt, ConditionOpLte, ConditionOpGt, ConditionOpGte:
+		if _, ok := conditionNumber(pc.Value); !ok {
+			return errors.New("value must be a number")
+		}
+	case ConditionOpCIDR:
+		for _, value := range conditionList(pc.Value) {
+			if _, _, err := net.ParseCIDR(fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("invalid CIDR %v", value)
+			}
+		}
+		if len(conditionList(pc.Value)) == 0 {
+			return errors.New("value must be a CIDR or a list of CIDRs")
+		}
+	default:
+		return fmt.Errorf("unknown operator %s", pc.Operator)
+	}
+	return nil
+}
+
+// Evaluate checks the condition against the attributes. Missing attributes leave it undecided.
+func (pc *PermissionCondition) Evaluate(attributes map[string]interface{}) ConditionResult {
+	actual, ok := attributes[pc.Attribute]
+	if !ok || actual == nil {
+		return ConditionUndecided
+	}
+	expected := pc.Value
+	if name, isRef := conditionReference(expected); isRef {
+		if expected, ok = attributes[name]; !ok || expected == nil {
+			return ConditionUndecided
+		}
+	}
+
+	var met bool
+	switch pc.Operator {
+	case ConditionOpEq:
+		met = conditionEqual(actual, expected)
+	case ConditionOpNe:
+		met = !conditionEqual(actual, expected)
+	case ConditionOpIn, ConditionOpNotIn:
+		for _, value := range conditionList(expected) {
+			if conditionEqual(actual, value) {
+				met = true
+				break
+			}
+		}
+		if pc.Operator == ConditionOpNotIn {
+			met = !met
+		}
+	case ConditionOpLt, ConditionOpLte, ConditionOpGt, ConditionOpGte:
+		a, okA := conditionNumber(actual)
+		b, okB := conditionNumber(expected)
+		if !okA || !okB {
+			return ConditionFailed
+		}
+		switch pc.Operator {
+		case ConditionOpLt:
+			met = a < b
+		case ConditionOpLte:
+			met = a <= b
+		case ConditionOpGt:
+			met = a > b
+		default:
+			met = a >= b
+		}
+	case ConditionOpCIDR:
+		ip := net.ParseIP(fmt.Sprint(actual))
+		if ip == nil {
+			return ConditionFailed
+		}
+		for _, value := range conditionList(expected) {
+			if _, network, err := net.ParseCIDR(fmt.Sprint(value)); err == nil && network.Contains(ip) {
+				met = true
+				break
+			}
+		}
+	}
+
+	if met {
+		return ConditionMet
+	}
+	return ConditionFailed
+}
+
+// EvaluatePermissionConditions combines conditions with AND: any failed condition fails the grant,
+// otherwise a missing attribute leaves it undecided
+func EvaluatePermissionConditions(conditions []PermissionCondition, attributes map[string]interface{}) ConditionResult {
+	result := ConditionMet
+	for i := range conditions {
+		switch conditions[i].Evaluate(attributes) {
+		case ConditionFailed:
+			return ConditionFailed
+		case ConditionUndecided:
+			result = ConditionUndecided
+		}
+	}
+	return result
+}
+
+// conditionReference returns the attribute name of a "$attribute" value
+func conditionReference(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok || len(s) < 2 || !strings.HasPrefix(s, "$") {
+		return "", false
+	}
+	return s[1:], true
+}
+
+func conditionList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	if list, ok := value.([]string); ok {
+		result := make([]interface{}, len(list))
+		for i, v := range list {
+			result[i] = v
+		}
+		return result
+	}
+	if value == nil {
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// conditionEqual compares numbers by value and everything else by its text
+func conditionEqual(a, b interface{}) bool {
+	if x, ok := conditionNumber(a); ok {
+		if y, ok := conditionNumber(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func conditionNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
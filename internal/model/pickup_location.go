@@ -0,0 +1,99 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShippingMethodPickup is the order shipping method for click-and-collect at a pickup location
+const ShippingMethodPickup = "pickup" // Nhận tại cửa hàng
+
+// PickupLocation represents a store or pickup point where customers collect their orders
+type PickupLocation struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Code        string `json:"code" gorm:"size:50;not null;uniqueIndex"` // Mã điểm nhận hàng
+	Name        string `json:"name" gorm:"size:255;not null"`            // Tên cửa hàng/điểm nhận
+	Description string `json:"description" gorm:"type:text"`
+	Phone       string `json:"phone" gorm:"size:20"`
+	Email       string `json:"email" gorm:"size:255"`
+
+	// Address Details
+	AddressLine  string `json:"address_line" gorm:"size:255;not null"` // Số nhà, tên đường
+	Ward         string `json:"ward" gorm:"size:100"`                  // Phường/Xã
+	District     string `json:"district" gorm:"size:100;not null"`     // Quận/Huyện
+	City         string `json:"city" gorm:"size:100;not null"`         // Tỉnh/Thành phố
+	ProvinceCode string `json:"province_code" gorm:"size:10;index"`    // Mã tỉnh/thành phố
+	DistrictCode string `json:"district_code" gorm:"size:10;index"`    // Mã quận/huyện
+	WardCode     string `json:"ward_code" gorm:"size:10"`              // Mã phường/xã
+
+	// Geographic Information
+	Latitude  float64 `json:"latitude" gorm:"type:decimal(10,8);not null;index:idx_pickup_locations_coordinates"`  // Vĩ độ
+	Longitude float64 `json:"longitude" gorm:"type:decimal(11,8);not null;index:idx_pickup_locations_coordinates"` // Kinh độ
+
+	// Operations
+	OpeningHours string  `json:"-" gorm:"type:json"`                             // Giờ mở cửa theo ngày trong tuần (JSON)
+	Capacity     int     `json:"capacity" gorm:"default:0"`                      // Số đơn tối đa đang chờ nhận (0 = không giới hạn)
+	HoldDays     int     `json:"hold_days" gorm:"default:7"`                     // Số ngày giữ hàng chờ khách đến nhận
+	PickupFee    float64 `json:"pickup_fee" gorm:"type:decimal(10,2);default:0"` // Phí nhận tại cửa hàng
+	IsActive     bool    `json:"is_active" gorm:"default:true;index"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// PickupOpeningHours is one opening period of a pickup location; a day may have several
+type PickupOpeningHours struct {
+	Weekday int    `json:"weekday" binding:"min=0,max=6"` // 0 = Chủ nhật
+	Open    string `json:"open" binding:"required"`       // HH:MM
+	Close   string `json:"close" binding:"required"`      // HH:MM
+}
+
+// FullAddress returns the single-line address written on pickup orders
+func (l *PickupLocation) FullAddress() string {
+	parts := []string{l.AddressLine}
+	for _, part := range []string{l.Ward, l.District, l.City} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Request/Response structs
+
+// PickupLocationRequest represents the request body for creating or updating a pickup location
+type PickupLocationRequest struct {
+	Code         string               `json:"code" binding:"required,min=2,max=50"`
+	Name         string               `json:"name" binding:"required,min=2,max=255"`
+	Description  string               `json:"description"`
+	Phone        string               `json:"phone" binding:"omitempty,min=10,max=20"`
+	Email        string               `json:"email" binding:"omitempty,email"`
+	AddressLine  string               `json:"address_line" binding:"required,min=5,max=255"`
+	Ward         string               `json:"ward" binding:"omitempty,max=100"`
+	District     string               `json:"district" binding:"required_without=DistrictCode,omitempty,max=100"`
+	City         string               `json:"city" binding:"required_without=ProvinceCode,omitempty,max=100"`
+	ProvinceCode string               `json:"province_code" binding:"omitempty,max=10"`
+	DistrictCode string               `json:"district_code" binding:"omitempty,max=10"`
+	WardCode     string               `json:"ward_code" binding:"omitempty,max=10"`
+	Latitude     float64              `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude    float64              `json:"longitude" binding:"required,min=-180,max=180"`
+	OpeningHours []PickupOpeningHours `json:"opening_hours" binding:"omitempty,dive"`
+	Capacity     int                  `json:"capacity" binding:"min=0"`
+	HoldDays     int                  `json:"hold_days" binding:"omitempty,min=1,max=30"`
+	PickupFee    float64              `json:"pickup_fee" binding:"min=0"`
+	IsActive     *bool                `json:"is_active"`
+}
+
+// PickupLocationResponse represents a pickup location with its schedule and current load
+type PickupLocationResponse struct {
+	PickupLocation
+	FullAddress       string               `json:"full_address"`
+	OpeningHours      []PickupOpeningHours `json:"opening_hours"`
+	IsOpenNow         bool                 `json:"is_open_now"`
+	OpenOrders        int64                `json:"open_orders"`                  // Đơn đang chờ nhận tại điểm
+	AvailableCapacity *int                 `json:"available_capacity,omitempty"` // nil = không giới hạn
+	DistanceKm        *float64             `json:"distance_km,omitempty"`        // Chỉ có khi tìm điểm gần nhất
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Data export statuses
+const (
+	DataExportStatusPending    = "pending"    // Chờ worker xử lý
+	DataExportStatusProcessing = "processing" // Đang tạo file
+	DataExportStatusCompleted  = "completed"  // Sẵn sàng tải về
+	DataExportStatusFailed     = "failed"
+	DataExportStatusExpired    = "expired" // File đã bị xóa sau thời hạn tải
+)
+
+// Account erasure statuses
+const (
+	AccountErasureStatusPending   = "pending"   // Đang trong thời gian chờ, người dùng có thể hủy
+	AccountErasureStatusCancelled = "cancelled" // Người dùng đã hủy yêu cầu
+	AccountErasureStatusCompleted = "completed" // Dữ liệu cá nhân đã được ẩn danh
+)
+
+// DataExportRequest is a user's request for a copy of their personal data (Decree 13/2023)
+type DataExportRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';index"` // pending, processing, completed, failed, expired
+	FilePath    string     `json:"-" gorm:"size:500"`                                      // Đường dẫn file ZIP trong thư mục riêng, không public
+	FileSize    int64      `json:"file_size"`
+	Error       string     `json:"error,omitempty" gorm:"size:500"`
+	RequestedIP string     `json:"-" gorm:"size:45"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"` // Sau thời điểm này file bị xóa
+	Downloads   int        `json:"downloads" gorm:"default:0"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for DataExportRequest model
+func (DataExportRequest) TableName() string {
+	return "data_export_requests"
+}
+
+// IsDownloadable checks if the archive is ready and has not expired yet
+func (r *DataExportRequest) IsDownloadable() bool {
+	return r.Status == DataExportStatusCompleted && r.ExpiresAt != nil && time.Now().Before(*r.ExpiresAt)
+}
+
+// AccountErasureRequest is a user's request to close their account. Personal data is
+// anonymized once the grace period ends; orders and payments are kept for accounting.
+type AccountErasureRequest struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';index"` // pending, cancelled, completed
+	Reason      string     `json:"reason" gorm:"size:500"`
+	RequestedIP string     `json:"-" gorm:"size:45"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"index"` // Hết thời gian chờ, worker sẽ ẩn danh dữ liệu
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	LastError   string     `json:"-" gorm:"size:500"` // Lý do chưa xử lý được, ví dụ còn đơn hàng đang giao
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for AccountErasureRequest model
+func (AccountErasureRequest) TableName() string {
+	return "account_erasure_requests"
+}
+
+// ErasedUserEmail returns the placeholder email written over an erased account
+func ErasedUserEmail(userID uint) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", userID)
+}
+
+// ErasedUserName returns the placeholder username written over an erased account
+func ErasedUserName(userID uint) string {
+	return fmt.Sprintf("erased_%d", userID)
+}
+
+// ErasedPlaceholder replaces free-text personal data on records that must be kept
+const ErasedPlaceholder = "[đã xóa]"
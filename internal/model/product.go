@@ -12,6 +12,7 @@ type ProductType string
 const (
 	ProductTypeSimple   ProductType = "simple"   // Sản phẩm đơn giản
 	ProductTypeVariable ProductType = "variable" // Sản phẩm có biến thể
+	ProductTypeBundle   ProductType = "bundle"   // Sản phẩm combo (hộp quà, bộ kit)
 )
 
 // ProductStatus represents the status of product
@@ -24,6 +25,15 @@ const (
 	ProductStatusArchived ProductStatus = "archived" // Lưu trữ
 )
 
+// BackorderPolicy represents whether a product can be ordered beyond its stock
+type BackorderPolicy string
+
+const (
+	BackorderPolicyNone      BackorderPolicy = "none"      // Không cho đặt khi hết hàng
+	BackorderPolicyBackorder BackorderPolicy = "backorder" // Cho đặt trước khi hàng về
+	BackorderPolicyPreorder  BackorderPolicy = "preorder"  // Đặt trước sản phẩm sắp ra mắt
+)
+
 // Product represents a product in the system
 type Product struct {
 	ID               uint          `json:"id" gorm:"primaryKey"`
@@ -32,7 +42,7 @@ type Product struct {
 	Description      string        `json:"description" gorm:"type:text"`
 	ShortDescription string        `json:"short_description" gorm:"size:500"`
 	SKU              string        `json:"sku" gorm:"size:100;uniqueIndex"`
-	Type             ProductType   `json:"type" gorm:"type:enum('simple','variable');default:'simple'"`
+	Type             ProductType   `json:"type" gorm:"type:enum('simple','variable','bundle');default:'simple'"`
 	Status           ProductStatus `json:"status" gorm:"type:enum('draft','active','inactive','archived');default:'draft'"`
 
 	// Pricing
@@ -45,6 +55,12 @@ type Product struct {
 	StockQuantity     int    `json:"stock_quantity" gorm:"default:0"`
 	LowStockThreshold int    `json:"low_stock_threshold" gorm:"default:5"`
 	StockStatus       string `json:"stock_status" gorm:"size:20;default:'instock'"` // instock, outofstock, onbackorder
+	TrackLots         bool   `json:"track_lots" gorm:"default:false"`               // Quản lý theo lô/hạn sử dụng
+
+	// Backorders & Pre-orders
+	BackorderPolicy     BackorderPolicy `json:"backorder_policy" gorm:"size:20;default:'none'"`
+	BackorderLimit      int             `json:"backorder_limit" gorm:"default:0"` // Số lượng đặt trước tối đa (0 = không giới hạn)
+	BackorderExpectedAt *time.Time      `json:"backorder_expected_at"`            // Ngày dự kiến có hàng
 
 	// Dimensions & Weight
 	Weight *float64 `json:"weight" gorm:"type:decimal(8,2)"`
@@ -70,6 +86,9 @@ type Product struct {
 	// Variants (for variable products)
 	Variants []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
 
+	// Components (for bundle products)
+	BundleItems []ProductBundleItem `json:"bundle_items,omitempty" gorm:"foreignKey:BundleID"`
+
 	// Attributes
 	Attributes []ProductAttribute `json:"attributes,omitempty" gorm:"foreignKey:ProductID"`
 
@@ -85,6 +104,11 @@ type Product struct {
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// AllowsBackorder checks if the product can be ordered beyond its stock
+func (p *Product) AllowsBackorder() bool {
+	return p.BackorderPolicy == BackorderPolicyBackorder || p.BackorderPolicy == BackorderPolicyPreorder
+}
+
 // ProductVariant represents a variant of a variable product
 type ProductVariant struct {
 	ID        uint     `json:"id" gorm:"primaryKey"`
@@ -150,7 +174,7 @@ type ProductCreateRequest struct {
 	Description      string        `json:"description"`
 	ShortDescription string        `json:"short_description"`
 	SKU              string        `json:"sku"`
-	Type             ProductType   `json:"type" validate:"oneof=simple variable"`
+	Type             ProductType   `json:"type" validate:"oneof=simple variable bundle"`
 	Status           ProductStatus `json:"status" validate:"oneof=draft active inactive archived"`
 
 	// Pricing
@@ -163,6 +187,12 @@ type ProductCreateRequest struct {
 	StockQuantity     int    `json:"stock_quantity" validate:"min=0"`
 	LowStockThreshold int    `json:"low_stock_threshold" validate:"min=0"`
 	StockStatus       string `json:"stock_status" validate:"oneof=instock outofstock onbackorder"`
+	TrackLots         *bool  `json:"track_lots"`
+
+	// Backorders & Pre-orders
+	BackorderPolicy     BackorderPolicy `json:"backorder_policy" validate:"omitempty,oneof=none backorder preorder"`
+	BackorderLimit      int             `json:"backorder_limit" validate:"min=0"`
+	BackorderExpectedAt *time.Time      `json:"backorder_expected_at"`
 
 	// Dimensions & Weight
 	Weight *float64 `json:"weight" validate:"omitempty,min=0"`
@@ -186,6 +216,9 @@ type ProductCreateRequest struct {
 	// Variants (for variable products)
 	Variants []ProductVariantCreateRequest `json:"variants"`
 
+	// Components (for bundle products)
+	BundleItems []ProductBundleItemRequest `json:"bundle_items" validate:"omitempty,dive"`
+
 	// Attributes
 	Attributes []ProductAttributeCreateRequest `json:"attributes"`
 
@@ -280,7 +313,7 @@ type ProductUpdateRequest struct {
 	Description      string        `json:"description"`
 	ShortDescription string        `json:"short_description"`
 	SKU              string        `json:"sku"`
-	Type             ProductType   `json:"type" validate:"omitempty,oneof=simple variable"`
+	Type             ProductType   `json:"type" validate:"omitempty,oneof=simple variable bundle"`
 	Status           ProductStatus `json:"status" validate:"omitempty,oneof=draft active inactive archived"`
 
 	// Pricing
@@ -293,6 +326,12 @@ type ProductUpdateRequest struct {
 	StockQuantity     *int   `json:"stock_quantity" validate:"omitempty,min=0"`
 	LowStockThreshold *int   `json:"low_stock_threshold" validate:"omitempty,min=0"`
 	StockStatus       string `json:"stock_status" validate:"omitempty,oneof=instock outofstock onbackorder"`
+	TrackLots         *bool  `json:"track_lots"`
+
+	// Backorders & Pre-orders
+	BackorderPolicy     BackorderPolicy `json:"backorder_policy" validate:"omitempty,oneof=none backorder preorder"`
+	BackorderLimit      *int            `json:"backorder_limit" validate:"omitempty,min=0"`
+	BackorderExpectedAt *time.Time      `json:"backorder_expected_at"`
 
 	// Dimensions & Weight
 	Weight *float64 `json:"weight" validate:"omitempty,min=0"`
@@ -341,6 +380,12 @@ type ProductResponse struct {
 	StockQuantity     int    `json:"stock_quantity"`
 	LowStockThreshold int    `json:"low_stock_threshold"`
 	StockStatus       string `json:"stock_status"`
+	TrackLots         bool   `json:"track_lots"`
+
+	// Backorders & Pre-orders
+	BackorderPolicy     BackorderPolicy `json:"backorder_policy"`
+	BackorderLimit      int             `json:"backorder_limit"`
+	BackorderExpectedAt *time.Time      `json:"backorder_expected_at"`
 
 	// Dimensions & Weight
 	Weight *float64 `json:"weight"`
@@ -366,6 +411,9 @@ type ProductResponse struct {
 	// Variants
 	Variants []ProductVariantResponse `json:"variants,omitempty"`
 
+	// Components (for bundle products)
+	BundleItems []ProductBundleItemResponse `json:"bundle_items,omitempty"`
+
 	// Attributes
 	Attributes []ProductAttributeResponse `json:"attributes,omitempty"`
 
@@ -434,37 +482,41 @@ type ProductAttributeResponse struct {
 // ToResponse converts Product to ProductResponse
 func (p *Product) ToResponse() ProductResponse {
 	response := ProductResponse{
-		ID:                p.ID,
-		Name:              p.Name,
-		Slug:              p.Slug,
-		Description:       p.Description,
-		ShortDescription:  p.ShortDescription,
-		SKU:               p.SKU,
-		Type:              p.Type,
-		Status:            p.Status,
-		RegularPrice:      p.RegularPrice,
-		SalePrice:         p.SalePrice,
-		CostPrice:         p.CostPrice,
-		ManageStock:       p.ManageStock,
-		StockQuantity:     p.StockQuantity,
-		LowStockThreshold: p.LowStockThreshold,
-		StockStatus:       p.StockStatus,
-		Weight:            p.Weight,
-		Length:            p.Length,
-		Width:             p.Width,
-		Height:            p.Height,
-		FeaturedImage:     p.FeaturedImage,
-		MetaTitle:         p.MetaTitle,
-		MetaDescription:   p.MetaDescription,
-		MetaKeywords:      p.MetaKeywords,
-		BrandID:           p.BrandID,
-		CategoryID:        p.CategoryID,
-		IsFeatured:        p.IsFeatured,
-		IsDigital:         p.IsDigital,
-		RequiresShipping:  p.RequiresShipping,
-		IsDownloadable:    p.IsDownloadable,
-		CreatedAt:         p.CreatedAt,
-		UpdatedAt:         p.UpdatedAt,
+		ID:                  p.ID,
+		Name:                p.Name,
+		Slug:                p.Slug,
+		Description:         p.Description,
+		ShortDescription:    p.ShortDescription,
+		SKU:                 p.SKU,
+		Type:                p.Type,
+		Status:              p.Status,
+		RegularPrice:        p.RegularPrice,
+		SalePrice:           p.SalePrice,
+		CostPrice:           p.CostPrice,
+		ManageStock:         p.ManageStock,
+		StockQuantity:       p.StockQuantity,
+		LowStockThreshold:   p.LowStockThreshold,
+		StockStatus:         p.StockStatus,
+		TrackLots:           p.TrackLots,
+		BackorderPolicy:     p.BackorderPolicy,
+		BackorderLimit:      p.BackorderLimit,
+		BackorderExpectedAt: p.BackorderExpectedAt,
+		Weight:              p.Weight,
+		Length:              p.Length,
+		Width:               p.Width,
+		Height:              p.Height,
+		FeaturedImage:       p.FeaturedImage,
+		MetaTitle:           p.MetaTitle,
+		MetaDescription:     p.MetaDescription,
+		MetaKeywords:        p.MetaKeywords,
+		BrandID:             p.BrandID,
+		CategoryID:          p.CategoryID,
+		IsFeatured:          p.IsFeatured,
+		IsDigital:           p.IsDigital,
+		RequiresShipping:    p.RequiresShipping,
+		IsDownloadable:      p.IsDownloadable,
+		CreatedAt:           p.CreatedAt,
+		UpdatedAt:           p.UpdatedAt,
 	}
 
 	// Parse images JSON
@@ -494,6 +546,15 @@ func (p *Product) ToResponse() ProductResponse {
 		response.Variants = variants
 	}
 
+	// Add bundle components if exists
+	if len(p.BundleItems) > 0 {
+		bundleItems := make([]ProductBundleItemResponse, len(p.BundleItems))
+		for i, item := range p.BundleItems {
+			bundleItems[i] = item.ToResponse()
+		}
+		response.BundleItems = bundleItems
+	}
+
 	// Add attributes if exists
 	if len(p.Attributes) > 0 {
 		attributes := make([]ProductAttributeResponse, len(p.Attributes))
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+)
+
+// ProductBundleItem represents a component SKU of a bundle (gift box/kit) product
+type ProductBundleItem struct {
+	ID                 uint            `json:"id" gorm:"primaryKey"`
+	BundleID           uint            `json:"bundle_id" gorm:"not null;index"` // Sản phẩm combo
+	Bundle             *Product        `json:"bundle,omitempty" gorm:"foreignKey:BundleID"`
+	ComponentProductID uint            `json:"component_product_id" gorm:"not null;index"` // Sản phẩm thành phần
+	ComponentProduct   *Product        `json:"component_product,omitempty" gorm:"foreignKey:ComponentProductID"`
+	ComponentVariantID *uint           `json:"component_variant_id" gorm:"index"`
+	ComponentVariant   *ProductVariant `json:"component_variant,omitempty" gorm:"foreignKey:ComponentVariantID"`
+	Quantity           int             `json:"quantity" gorm:"not null;default:1"` // Số lượng thành phần trong một combo
+	SortOrder          int             `json:"sort_order" gorm:"default:0"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// OrderItemComponent is a snapshot of one bundle component sold with an order item
+type OrderItemComponent struct {
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	OrderItemID       uint            `json:"order_item_id" gorm:"not null;index"`
+	ProductID         uint            `json:"product_id" gorm:"not null;index"`
+	Product           *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	ProductVariantID  *uint           `json:"product_variant_id" gorm:"index"`
+	ProductVariant    *ProductVariant `json:"product_variant,omitempty" gorm:"foreignKey:ProductVariantID"`
+	ProductName       string          `json:"product_name" gorm:"size:255;not null"`
+	ProductSKU        string          `json:"product_sku" gorm:"size:100"`
+	VariantName       string          `json:"variant_name" gorm:"size:255"`
+	QuantityPerBundle int             `json:"quantity_per_bundle" gorm:"not null"` // Số lượng trong một combo
+	Quantity          int             `json:"quantity" gorm:"not null"`            // Tổng số lượng (x số combo)
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// IsBundle checks if the product is a bundle of other products
+func (p *Product) IsBundle() bool {
+	return p.Type == ProductTypeBundle
+}
+
+// ToResponse converts ProductBundleItem to ProductBundleItemResponse (without stock figures)
+func (bi *ProductBundleItem) ToResponse() ProductBundleItemResponse {
+	response := ProductBundleItemResponse{
+		ID:                 bi.ID,
+		ComponentProductID: bi.ComponentProductID,
+		ComponentVariantID: bi.ComponentVariantID,
+		Quantity:           bi.Quantity,
+		SortOrder:          bi.SortOrder,
+	}
+	if bi.ComponentProduct != nil {
+		response.ComponentName = bi.ComponentProduct.Name
+		response.ComponentSKU = bi.ComponentProduct.SKU
+	}
+	if bi.ComponentVariant != nil {
+		response.VariantName = bi.ComponentVariant.Name
+		if bi.ComponentVariant.SKU != "" {
+			response.ComponentSKU = bi.ComponentVariant.SKU
+		}
+	}
+	return response
+}
+
+// Request/Response structs
+
+// ProductBundleItemRequest represents a component line when defining a bundle
+type ProductBundleItemRequest struct {
+	ComponentProductID uint  `json:"component_product_id" validate:"required"`
+	ComponentVariantID *uint `json:"component_variant_id"`
+	Quantity           int   `json:"quantity" validate:"required,min=1"`
+	SortOrder          int   `json:"sort_order"`
+}
+
+// ProductBundleItemsUpdateRequest represents the request to replace the components of a bundle
+type ProductBundleItemsUpdateRequest struct {
+	Items []ProductBundleItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// ProductBundleItemResponse represents a bundle component with its stock
+type ProductBundleItemResponse struct {
+	ID                 uint   `json:"id"`
+	ComponentProductID uint   `json:"component_product_id"`
+	ComponentName      string `json:"component_name,omitempty"`
+	ComponentSKU       string `json:"component_sku,omitempty"`
+	ComponentVariantID *uint  `json:"component_variant_id"`
+	VariantName        string `json:"variant_name,omitempty"`
+	Quantity           int    `json:"quantity"`
+	SortOrder          int    `json:"sort_order"`
+	AvailableQuantity  int    `json:"available_quantity"` // Tồn kho của thành phần
+	AvailableBundles   int    `json:"available_bundles"`  // Số combo có thể tạo từ thành phần này
+}
+
+// BundleAvailabilityResponse represents the availability of a bundle computed from its components
+type BundleAvailabilityResponse struct {
+	BundleID          uint                        `json:"bundle_id"`
+	AvailableQuantity int                         `json:"available_quantity"`
+	StockStatus       string                      `json:"stock_status"`
+	Components        []ProductBundleItemResponse `json:"components"`
+}
+
+// OrderItemComponentResponse represents a bundle component of an order item
+type OrderItemComponentResponse struct {
+	ProductID         uint   `json:"product_id"`
+	ProductName       string `json:"product_name"`
+	ProductSKU        string `json:"product_sku"`
+	ProductVariantID  *uint  `json:"product_variant_id"`
+	VariantName       string `json:"variant_name"`
+	QuantityPerBundle int    `json:"quantity_per_bundle"`
+	Quantity          int    `json:"quantity"`
+}
@@ -6,22 +6,29 @@ import (
 	"gorm.io/gorm"
 )
 
-// Session represents user session model for managing single device login
+// Session represents a login on one device; a user can hold several up to their role's limit
 type Session struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	Token     string         `json:"token" gorm:"uniqueIndex;size:255;not null"`
-	DeviceID  string         `json:"device_id" gorm:"size:100;not null"`
-	UserAgent string         `json:"user_agent" gorm:"size:500"`
-	IPAddress string         `json:"ip_address" gorm:"size:45"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	ExpiresAt time.Time      `json:"expires_at" gorm:"not null"`
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Token      string     `json:"token" gorm:"uniqueIndex;size:255;not null"`
+	DeviceID   string     `json:"device_id" gorm:"size:100;not null"`
+	UserAgent  string     `json:"user_agent" gorm:"size:500"`
+	IPAddress  string     `json:"ip_address" gorm:"size:45"`
+	IsActive   bool       `json:"is_active" gorm:"default:true"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"` // Hết hạn theo refresh token mới nhất
+	LastSeenAt *time.Time `json:"last_seen_at"`               // Lần hoạt động gần nhất
+
+	// Revocation
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason" gorm:"size:50"` // logout, replaced, session_limit, revoked_by_user, password_reset, refresh_token_reuse
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relations
-	User User `json:"user" gorm:"foreignKey:UserID;references:ID"`
+	User          User           `json:"user" gorm:"foreignKey:UserID;references:ID"`
+	RefreshTokens []RefreshToken `json:"-" gorm:"foreignKey:SessionID"`
 }
 
 // TableName returns the table name for Session model
@@ -38,3 +45,65 @@ func (s *Session) IsExpired() bool {
 func (s *Session) IsValid() bool {
 	return s.IsActive && !s.IsExpired()
 }
+
+// Session revoke reasons
+const (
+	SessionRevokeLogout         = "logout"
+	SessionRevokeReplaced       = "replaced"        // Đăng nhập lại trên cùng thiết bị
+	SessionRevokeLimit          = "session_limit"   // Vượt số phiên tối đa của vai trò
+	SessionRevokeByUser         = "revoked_by_user" // Người dùng đăng xuất thiết bị từ xa
+	SessionRevokePasswordReset  = "password_reset"
+	SessionRevokePasswordChange = "password_changed"
+	SessionRevokeAccountErased  = "account_erased"      // Tài khoản đã bị xóa theo yêu cầu
+	SessionRevokeTokenReuse     = "refresh_token_reuse" // Refresh token đã xoay vòng bị dùng lại
+)
+
+// LastActivity returns when the session was last used
+func (s *Session) LastActivity() time.Time {
+	if s.LastSeenAt != nil {
+		return *s.LastSeenAt
+	}
+	return s.CreatedAt
+}
+
+// SessionResponse represents a login session in API responses
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	DeviceID   string    `json:"device_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // Phiên đang gọi API
+}
+
+// RefreshToken represents an opaque refresh token issued to a session. Only its hash is stored;
+// each use rotates it to a new token, and every token of a session belongs to the same family.
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	SessionID    uint       `json:"session_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"size:64;uniqueIndex;not null"` // SHA-256 của refresh token
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RotatedAt    *time.Time `json:"rotated_at"`     // Đã đổi sang token mới
+	ReplacedByID *uint      `json:"replaced_by_id"` // Token thay thế
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Session *Session `json:"session,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// TableName returns the table name for RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsRotated checks if the token was already exchanged for a new one
+func (t *RefreshToken) IsRotated() bool {
+	return t.RotatedAt != nil
+}
+
+// IsUsable checks if the token can still be exchanged
+func (t *RefreshToken) IsUsable() bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
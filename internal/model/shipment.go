@@ -0,0 +1,157 @@
+package model
+
+import (
+	"time"
+)
+
+// Shipment represents one parcel sent for an order. An order can have several
+// shipments (e.g. backordered items following later), each with its own items
+// and a single event timeline fed by carrier webhooks, polling and staff actions.
+type Shipment struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	OrderID uint   `json:"order_id" gorm:"not null;index"`
+	Order   *Order `json:"order,omitempty" gorm:"foreignKey:OrderID"`
+
+	// Carrier records linked to this shipment
+	ShippingOrderID *uint          `json:"shipping_order_id" gorm:"index"` // Vận đơn đặt qua hãng vận chuyển
+	ShippingOrder   *ShippingOrder `json:"shipping_order,omitempty" gorm:"foreignKey:ShippingOrderID"`
+	OrderTrackingID *uint          `json:"order_tracking_id" gorm:"index"` // Theo dõi vận chuyển
+	OrderTracking   *OrderTracking `json:"order_tracking,omitempty" gorm:"foreignKey:OrderTrackingID"`
+
+	Carrier        string `json:"carrier" gorm:"size:50"`
+	CarrierCode    string `json:"carrier_code" gorm:"size:20;index"`
+	TrackingNumber string `json:"tracking_number" gorm:"size:100;index"`
+	Warehouse      string `json:"warehouse" gorm:"size:100;index"` // Kho xuất hàng
+
+	// Current Status (tracking statuses: pending, picked_up, in_transit, ...)
+	Status      string     `json:"status" gorm:"size:50;not null;default:'pending';index"`
+	StatusText  string     `json:"status_text" gorm:"size:255"`
+	Location    string     `json:"location" gorm:"size:255"`
+	LastEventAt *time.Time `json:"last_event_at"` // Thời điểm sự kiện mới nhất
+	ShippedAt   *time.Time `json:"shipped_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	DeliveryDue *time.Time `json:"delivery_due"` // Hạn giao theo SLA
+
+	Items  []ShipmentItem  `json:"items,omitempty" gorm:"foreignKey:ShipmentID"`
+	Events []ShipmentEvent `json:"events,omitempty" gorm:"foreignKey:ShipmentID"`
+
+	CreatedBy *uint     `json:"created_by" gorm:"index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShipmentItem represents the quantity of an order item packed into a shipment
+type ShipmentItem struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ShipmentID  uint       `json:"shipment_id" gorm:"not null;index"`
+	OrderItemID uint       `json:"order_item_id" gorm:"not null;index"`
+	OrderItem   *OrderItem `json:"order_item,omitempty" gorm:"foreignKey:OrderItemID"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ShipmentEvent represents a single step on a shipment's timeline
+type ShipmentEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ShipmentID uint      `json:"shipment_id" gorm:"not null;index"`
+	OrderID    uint      `json:"order_id" gorm:"not null;index"`
+	Status     string    `json:"status" gorm:"size:50;not null"`
+	StatusText string    `json:"status_text" gorm:"size:255"`
+	Location   string    `json:"location" gorm:"size:255"`
+	Note       string    `json:"note" gorm:"type:text"`
+	EventCode  string    `json:"event_code" gorm:"size:50"`      // Mã trạng thái gốc của hãng vận chuyển
+	Source     string    `json:"source" gorm:"size:50;not null"` // api, webhook, manual, sync
+	SourceData string    `json:"source_data" gorm:"type:text"`   // Raw data from source
+	CreatedBy  *uint     `json:"created_by" gorm:"index"`        // Nhân viên thao tác (nếu có)
+	EventTime  time.Time `json:"event_time" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// IsFinished checks if the shipment has reached a final status
+func (s *Shipment) IsFinished() bool {
+	return s.Status == TrackingStatusDelivered || s.Status == TrackingStatusReturned || s.Status == TrackingStatusCancelled
+}
+
+// Request/Response structs
+
+// ShipmentItemRequest represents an order item quantity to put in a shipment
+type ShipmentItemRequest struct {
+	OrderItemID uint `json:"order_item_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,min=1"`
+}
+
+// ShipmentCreateRequest represents the data needed to open a shipment
+type ShipmentCreateRequest struct {
+	OrderID         uint                  `json:"order_id" binding:"required"`
+	Carrier         string                `json:"carrier"`
+	CarrierCode     string                `json:"carrier_code"`
+	TrackingNumber  string                `json:"tracking_number"`
+	ShippingOrderID *uint                 `json:"shipping_order_id"`
+	OrderTrackingID *uint                 `json:"order_tracking_id"`
+	Warehouse       string                `json:"warehouse"`
+	Items           []ShipmentItemRequest `json:"items"`
+	CreatedBy       *uint                 `json:"-"`
+}
+
+// OrderShipmentRequest represents a manual shipment of part of an order
+type OrderShipmentRequest struct {
+	Items          []ShipmentItemRequest `json:"items" binding:"required,min=1,dive"`
+	Carrier        string                `json:"carrier"`
+	CarrierCode    string                `json:"carrier_code"`
+	TrackingNumber string                `json:"tracking_number" binding:"required"`
+	Warehouse      string                `json:"warehouse"`
+}
+
+// ShipmentItemResponse represents a shipment item in API responses
+type ShipmentItemResponse struct {
+	OrderItemID uint   `json:"order_item_id"`
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	ProductSKU  string `json:"product_sku"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ShipmentEventResponse represents a timeline event in API responses
+type ShipmentEventResponse struct {
+	ID         uint      `json:"id"`
+	ShipmentID uint      `json:"shipment_id"`
+	Status     string    `json:"status"`
+	StatusText string    `json:"status_text"`
+	Location   string    `json:"location"`
+	Note       string    `json:"note"`
+	EventCode  string    `json:"event_code"`
+	Source     string    `json:"source"`
+	EventTime  time.Time `json:"event_time"`
+}
+
+// ShipmentResponse represents a shipment in API responses
+type ShipmentResponse struct {
+	ID              uint                    `json:"id"`
+	OrderID         uint                    `json:"order_id"`
+	ShippingOrderID *uint                   `json:"shipping_order_id,omitempty"`
+	OrderTrackingID *uint                   `json:"order_tracking_id,omitempty"`
+	Carrier         string                  `json:"carrier"`
+	CarrierCode     string                  `json:"carrier_code"`
+	TrackingNumber  string                  `json:"tracking_number"`
+	Warehouse       string                  `json:"warehouse"`
+	Status          string                  `json:"status"`
+	StatusText      string                  `json:"status_text"`
+	Location        string                  `json:"location"`
+	LastEventAt     *time.Time              `json:"last_event_at"`
+	ShippedAt       *time.Time              `json:"shipped_at"`
+	DeliveredAt     *time.Time              `json:"delivered_at"`
+	DeliveryDue     *time.Time              `json:"delivery_due"`
+	Items           []ShipmentItemResponse  `json:"items"`
+	Events          []ShipmentEventResponse `json:"events"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+// OrderFulfillmentResponse represents every shipment of an order and their merged timeline
+type OrderFulfillmentResponse struct {
+	OrderID        uint                    `json:"order_id"`
+	OrderNumber    string                  `json:"order_number"`
+	OrderStatus    OrderStatus             `json:"order_status"`
+	ShippingStatus ShippingStatus          `json:"shipping_status"`
+	Shipments      []ShipmentResponse      `json:"shipments"`
+	Timeline       []ShipmentEventResponse `json:"timeline"` // Newest first
+}
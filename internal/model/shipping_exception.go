@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+)
+
+// ShippingException represents a shipment breaching the shipping SLA: not picked up in time,
+// stuck in transit past its estimated delivery, or failing delivery repeatedly
+type ShippingException struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	OrderID    uint      `json:"order_id" gorm:"not null;index"`
+	Order      *Order    `json:"order,omitempty" gorm:"foreignKey:OrderID"`
+	ShipmentID uint      `json:"shipment_id" gorm:"not null;index"`
+	Shipment   *Shipment `json:"shipment,omitempty" gorm:"foreignKey:ShipmentID"`
+	ProviderID *uint     `json:"provider_id" gorm:"index"` // Hãng vận chuyển (nếu đặt qua hãng)
+
+	Carrier        string `json:"carrier" gorm:"size:50"`
+	CarrierCode    string `json:"carrier_code" gorm:"size:20;index"`
+	TrackingNumber string `json:"tracking_number" gorm:"size:100"`
+
+	Type           string     `json:"type" gorm:"size:30;not null;index"`   // not_picked_up, transit_delayed, delivery_failures
+	Status         string     `json:"status" gorm:"size:20;not null;index"` // open, acknowledged, resolved
+	Message        string     `json:"message" gorm:"size:500"`
+	DueAt          *time.Time `json:"due_at"`          // Hạn SLA bị vi phạm
+	FailedAttempts int        `json:"failed_attempts"` // Số lần giao thất bại
+	DetectedAt     time.Time  `json:"detected_at" gorm:"not null;index"`
+	LastCheckedAt  time.Time  `json:"last_checked_at"`
+
+	AcknowledgedBy *uint      `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	ResolvedBy     *uint      `json:"resolved_by"` // Trống nếu hệ thống tự đóng
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Note           string     `json:"note" gorm:"type:text"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Shipping exception types
+const (
+	ShippingExceptionNotPickedUp      = "not_picked_up"     // Hãng chưa lấy hàng quá hạn
+	ShippingExceptionTransitDelayed   = "transit_delayed"   // Vận chuyển quá ngày dự kiến
+	ShippingExceptionDeliveryFailures = "delivery_failures" // Giao thất bại nhiều lần
+)
+
+// Shipping exception statuses
+const (
+	ShippingExceptionStatusOpen         = "open"
+	ShippingExceptionStatusAcknowledged = "acknowledged" // Đã tiếp nhận, đang xử lý
+	ShippingExceptionStatusResolved     = "resolved"
+)
+
+// IsResolved checks if the exception is closed
+func (e *ShippingException) IsResolved() bool {
+	return e.Status == ShippingExceptionStatusResolved
+}
+
+// Request/Response structs
+
+// ShippingExceptionUpdateRequest represents a staff action on an exception
+type ShippingExceptionUpdateRequest struct {
+	Status string `json:"status" binding:"required,oneof=acknowledged resolved"`
+	Note   string `json:"note" binding:"max=2000"`
+}
+
+// ShippingSLAResult represents the outcome of one SLA monitor run
+type ShippingSLAResult struct {
+	Checked  int `json:"checked"`  // Shipments evaluated
+	Opened   int `json:"opened"`   // New exceptions raised
+	Resolved int `json:"resolved"` // Exceptions closed because the shipment recovered
+	Failed   int `json:"failed"`
+}
+
+// ShippingExceptionCount represents the number of unresolved exceptions of one type
+type ShippingExceptionCount struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+// ShippingExceptionCarrierSummary represents the unresolved exceptions of one carrier
+type ShippingExceptionCarrierSummary struct {
+	CarrierCode      string `json:"carrier_code"`
+	Carrier          string `json:"carrier"`
+	NotPickedUp      int64  `json:"not_picked_up"`
+	TransitDelayed   int64  `json:"transit_delayed"`
+	DeliveryFailures int64  `json:"delivery_failures"`
+	Total            int64  `json:"total"`
+}
+
+// ShippingExceptionDashboard represents the exceptions dashboard
+type ShippingExceptionDashboard struct {
+	Open         int64                             `json:"open"`
+	Acknowledged int64                             `json:"acknowledged"`
+	ByType       []ShippingExceptionCount          `json:"by_type"`
+	ByCarrier    []ShippingExceptionCarrierSummary `json:"by_carrier"`
+	Oldest       []ShippingException               `json:"oldest"` // Longest-standing unresolved exceptions
+}
@@ -47,7 +47,7 @@ type ShippingRate struct {
 	ProviderID uint              `json:"provider_id" gorm:"not null;index"`
 	Provider   *ShippingProvider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
 
-	// Zone Information
+	// Zone Information ("*" matches any province/district)
 	FromProvince string `json:"from_province" gorm:"size:100;not null"`
 	FromDistrict string `json:"from_district" gorm:"size:100;not null"`
 	ToProvince   string `json:"to_province" gorm:"size:100;not null"`
@@ -67,6 +67,9 @@ type ShippingRate struct {
 	Insurance float64 `json:"insurance_fee" gorm:"type:decimal(10,2);default:0"` // Insurance fee
 	Fragile   float64 `json:"fragile_fee" gorm:"type:decimal(10,2);default:0"`   // Fragile fee
 
+	// Free Shipping
+	FreeShippingThreshold float64 `json:"free_shipping_threshold" gorm:"type:decimal(10,2);default:0"` // Miễn phí vận chuyển cho đơn từ mức này (0 = không áp dụng)
+
 	// Delivery Time
 	MinDays int `json:"min_days" gorm:"default:1"`
 	MaxDays int `json:"max_days" gorm:"default:3"`
@@ -108,6 +111,10 @@ type ShippingOrder struct {
 	COD       float64 `json:"cod" gorm:"type:decimal(10,2);default:0"`       // COD amount
 	Insurance float64 `json:"insurance" gorm:"type:decimal(10,2);default:0"` // Insurance amount
 
+	// COD Remittance
+	CODRemittanceID *uint      `json:"cod_remittance_id" gorm:"index"` // Bảng kê đối soát COD
+	CODRemittedAt   *time.Time `json:"cod_remitted_at"`                // Thời điểm hãng trả tiền thu hộ
+
 	// Fees
 	ShippingFee  float64 `json:"shipping_fee" gorm:"type:decimal(10,2);not null"`
 	CODFee       float64 `json:"cod_fee" gorm:"type:decimal(10,2);default:0"`
@@ -146,6 +153,7 @@ const (
 	ProviderCodeViettelPost = "viettel_post"
 	ProviderCodeJT          = "jt"
 	ProviderCodeBest        = "best"
+	ProviderCodeFake        = "fake" // Offline carrier for development and tests
 )
 
 // Shipping Status Constants
@@ -170,6 +178,10 @@ type ShippingOrderRequest struct {
 	Insurance  float64  `json:"insurance,omitempty"`
 	Notes      string   `json:"notes,omitempty"`
 	Tags       []string `json:"tags,omitempty"`
+
+	// Split shipments: the order items in this parcel (defaults to everything left to ship)
+	Items     []ShipmentItemRequest `json:"items,omitempty"`
+	Warehouse string                `json:"warehouse,omitempty"`
 }
 
 // ShippingOrderResponse represents shipping order response
@@ -188,6 +200,9 @@ type ShippingOrderResponse struct {
 	CreatedAt    time.Time  `json:"created_at"`
 	ShippedAt    *time.Time `json:"shipped_at,omitempty"`
 	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+
+	COD           float64    `json:"cod"`
+	CODRemittedAt *time.Time `json:"cod_remitted_at,omitempty"`
 }
 
 // CalculateShippingRequest represents request to calculate shipping
@@ -201,6 +216,25 @@ type CalculateShippingRequest struct {
 	ProviderID   *uint   `json:"provider_id,omitempty"`
 	COD          float64 `json:"cod,omitempty"`
 	Insurance    float64 `json:"insurance,omitempty"`
+	Fragile      bool    `json:"fragile,omitempty"`
+
+	// Package contents; when given, weight and value are computed from the products
+	Items      []ShippingPackageItem `json:"items,omitempty"`
+	CartID     *uint                 `json:"cart_id,omitempty"`
+	CouponCode string                `json:"coupon_code,omitempty"`
+}
+
+// ShippingPackageItem represents a product line in a package to be shipped
+type ShippingPackageItem struct {
+	ProductID uint `json:"product_id" validate:"required"`
+	Quantity  int  `json:"quantity" validate:"required,min=1"`
+}
+
+// ShippingSurcharge represents an extra fee added to a shipping quote
+type ShippingSurcharge struct {
+	Code   string  `json:"code"` // cod, insurance, fragile
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
 }
 
 // CalculateShippingResponse represents shipping calculation response
@@ -215,8 +249,35 @@ type CalculateShippingResponse struct {
 	MinDays      int     `json:"min_days"`
 	MaxDays      int     `json:"max_days"`
 	IsAvailable  bool    `json:"is_available"`
+	Source       string  `json:"source"`            // carrier, table_rate
+	Message      string  `json:"message,omitempty"` // Why the quote is unavailable
+
+	// Package and pricing breakdown
+	ActualWeight       float64             `json:"actual_weight"`     // in kg
+	VolumetricWeight   float64             `json:"volumetric_weight"` // in kg
+	ChargeableWeight   float64             `json:"chargeable_weight"` // in kg
+	Surcharges         []ShippingSurcharge `json:"surcharges,omitempty"`
+	ShippingDiscount   float64             `json:"shipping_discount"`
+	FreeShipping       bool                `json:"free_shipping"`
+	FreeShippingReason string              `json:"free_shipping_reason,omitempty"` // threshold, coupon
+	Rank               int                 `json:"rank"`                           // 1 = best quote
 }
 
+// Quote sources
+const (
+	QuoteSourceCarrier   = "carrier"
+	QuoteSourceTableRate = "table_rate"
+)
+
+// Free shipping reasons
+const (
+	FreeShippingReasonThreshold = "threshold"
+	FreeShippingReasonCoupon    = "coupon"
+)
+
+// ShippingZoneWildcard matches any province or district in a shipping rate
+const ShippingZoneWildcard = "*"
+
 // WebhookData represents webhook data from shipping providers
 type WebhookData struct {
 	LabelID     string         `json:"label_id"`
@@ -257,4 +318,67 @@ type ShippingStats struct {
 	TotalRevenue    float64 `json:"total_revenue"`
 	AverageFee      float64 `json:"average_fee"`
 	SuccessRate     float64 `json:"success_rate"`
+
+	// Delivery SLA
+	MeasuredDeliveries int64   `json:"measured_deliveries"` // Delivered shipments with a due date
+	OnTimeDeliveries   int64   `json:"on_time_deliveries"`
+	LateDeliveries     int64   `json:"late_deliveries"`
+	OnTimeRate         float64 `json:"on_time_rate"`
+	OpenExceptions     int64   `json:"open_exceptions"` // Unresolved SLA exceptions
+}
+
+// ShippingLabelBatch represents a generated PDF of shipping labels and packing slips for a warehouse batch
+type ShippingLabelBatch struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	FileName         string    `json:"file_name" gorm:"size:255;not null"`
+	FilePath         string    `json:"file_path" gorm:"size:500;not null"`
+	FileURL          string    `json:"file_url" gorm:"size:500;not null"`
+	FileSize         int64     `json:"file_size"`
+	PageCount        int       `json:"page_count"`
+	LabelCount       int       `json:"label_count"`        // Số nhãn vận chuyển
+	PackingSlipCount int       `json:"packing_slip_count"` // Số phiếu đóng gói
+	FailedCount      int       `json:"failed_count"`       // Số vận đơn không in được
+	Items            string    `json:"-" gorm:"type:json"` // []ShippingLabelBatchItem
+	CreatedBy        *uint     `json:"created_by" gorm:"index"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ShippingLabelBatchItem records how a shipping order was printed in a batch
+type ShippingLabelBatchItem struct {
+	ShippingOrderID uint   `json:"shipping_order_id"`
+	OrderNumber     string `json:"order_number"`
+	TrackingCode    string `json:"tracking_code"`
+	LabelSource     string `json:"label_source,omitempty"` // carrier, generated
+	PackingSlip     bool   `json:"packing_slip"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Label sources
+const (
+	LabelSourceCarrier   = "carrier"
+	LabelSourceGenerated = "generated"
+)
+
+// ShippingLabelBatchRequest represents a request to print labels for many shipping orders
+type ShippingLabelBatchRequest struct {
+	ShippingOrderIDs    []uint `json:"shipping_order_ids" binding:"required,min=1,max=200"`
+	IncludeLabels       *bool  `json:"include_labels"`        // Mặc định: true
+	IncludePackingSlips *bool  `json:"include_packing_slips"` // Mặc định: true
+	CreatedBy           *uint  `json:"-"`
+}
+
+// ShippingLabelBatchResponse represents a generated label batch in API responses
+type ShippingLabelBatchResponse struct {
+	ID               uint                     `json:"id"`
+	FileName         string                   `json:"file_name"`
+	FileURL          string                   `json:"file_url,omitempty"` // Chỉ có ở các lô in trước khi chuyển sang thư mục riêng
+	DownloadURL      string                   `json:"download_url"`
+	FileSize         int64                    `json:"file_size"`
+	PageCount        int                      `json:"page_count"`
+	LabelCount       int                      `json:"label_count"`
+	PackingSlipCount int                      `json:"packing_slip_count"`
+	FailedCount      int                      `json:"failed_count"`
+	Items            []ShippingLabelBatchItem `json:"items"`
+	CreatedBy        *uint                    `json:"created_by"`
+	CreatedAt        time.Time                `json:"created_at"`
 }
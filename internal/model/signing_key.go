@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// SigningKey represents an asymmetric key pair used to sign access tokens. Only one key signs at
+// a time; retired keys stay published in the JWKS until their tokens can no longer be valid.
+type SigningKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	KID        string     `json:"kid" gorm:"column:kid;size:64;uniqueIndex;not null"`
+	Algorithm  string     `json:"algorithm" gorm:"size:10;not null"`                   // RS256, EdDSA
+	PublicKey  string     `json:"public_key" gorm:"type:text;not null"`                // PEM (PKIX)
+	PrivateKey string     `json:"-" gorm:"type:text;not null"`                         // PEM (PKCS8) mã hóa AES-GCM bằng JWT_SECRET
+	Status     string     `json:"status" gorm:"size:20;not null;index;default:active"` // active, retired
+	RetiredAt  *time.Time `json:"retired_at"`                                          // Ngừng ký token mới
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`                             // Gỡ khỏi JWKS sau thời điểm này
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for SigningKey model
+func (SigningKey) TableName() string {
+	return "jwt_signing_keys"
+}
+
+// Signing key statuses
+const (
+	SigningKeyStatusActive  = "active"
+	SigningKeyStatusRetired = "retired"
+)
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StocktakeStatus defines the status of a stocktake session
+type StocktakeStatus string
+
+const (
+	StocktakeStatusDraft     StocktakeStatus = "draft"     // Bản nháp
+	StocktakeStatusCounting  StocktakeStatus = "counting"  // Đang kiểm đếm (đã chốt số lượng dự kiến)
+	StocktakeStatusReview    StocktakeStatus = "review"    // Chờ duyệt chênh lệch
+	StocktakeStatusApproved  StocktakeStatus = "approved"  // Đã duyệt
+	StocktakeStatusCancelled StocktakeStatus = "cancelled" // Đã hủy
+)
+
+// Stocktake represents a cycle count session scoped to a category and/or location
+type Stocktake struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	Code           string          `json:"code" gorm:"size:50;not null;uniqueIndex"` // Mã phiếu kiểm kê
+	Name           string          `json:"name" gorm:"size:255;not null"`
+	CategoryID     *uint           `json:"category_id" gorm:"index"`
+	Category       *Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Location       string          `json:"location" gorm:"size:100;index"`
+	Status         StocktakeStatus `json:"status" gorm:"type:varchar(20);default:'draft';index"`
+	Notes          string          `json:"notes" gorm:"type:text"`
+	FrozenAt       *time.Time      `json:"frozen_at"`    // Thời điểm chốt số lượng dự kiến
+	SubmittedAt    *time.Time      `json:"submitted_at"` // Thời điểm gửi duyệt
+	ApprovedAt     *time.Time      `json:"approved_at"`
+	ApprovedBy     *uint           `json:"approved_by" gorm:"index"`
+	ApprovedByUser *User           `json:"approved_by_user,omitempty" gorm:"foreignKey:ApprovedBy"`
+	CreatedBy      uint            `json:"created_by" gorm:"not null;index"`
+	CreatedByUser  *User           `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Items          []StocktakeItem `json:"items,omitempty" gorm:"foreignKey:StocktakeID"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+}
+
+// StocktakeItem represents a single SKU line within a stocktake session
+type StocktakeItem struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	StocktakeID      uint            `json:"stocktake_id" gorm:"not null;index"`
+	ProductID        uint            `json:"product_id" gorm:"not null;index"`
+	Product          *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	VariantID        *uint           `json:"variant_id" gorm:"index"`
+	Variant          *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	SKU              string          `json:"sku" gorm:"size:100;index"`
+	ExpectedQuantity int             `json:"expected_quantity" gorm:"not null"` // Số lượng hệ thống tại thời điểm chốt
+	CountedQuantity  *int            `json:"counted_quantity"`                  // Số lượng thực đếm
+	Variance         int             `json:"variance" gorm:"default:0"`         // Chênh lệch (thực đếm - dự kiến)
+	Notes            string          `json:"notes" gorm:"type:text"`
+	CountedBy        *uint           `json:"counted_by" gorm:"index"`
+	CountedAt        *time.Time      `json:"counted_at"`
+	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsCounted checks if the item has been counted
+func (i *StocktakeItem) IsCounted() bool {
+	return i.CountedQuantity != nil
+}
+
+// Request/Response structs
+
+// StocktakeCreateRequest represents the request body for creating a stocktake session
+type StocktakeCreateRequest struct {
+	Name       string `json:"name" binding:"required,min=3,max=255"`
+	CategoryID *uint  `json:"category_id"`
+	Location   string `json:"location" binding:"max=100"`
+	Notes      string `json:"notes"`
+}
+
+// StocktakeCountLine represents a single counted SKU
+type StocktakeCountLine struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"gte=0"`
+	Notes    string `json:"notes"`
+}
+
+// StocktakeCountRequest represents the request body for submitting counts
+type StocktakeCountRequest struct {
+	Counts []StocktakeCountLine `json:"counts" binding:"required,min=1,dive"`
+}
+
+// StocktakeCountResult summarizes a count submission
+type StocktakeCountResult struct {
+	Updated     int      `json:"updated"`
+	UnknownSKUs []string `json:"unknown_skus,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// StocktakeApproveRequest represents the request body for approving a stocktake
+type StocktakeApproveRequest struct {
+	Notes string `json:"notes"`
+}
+
+// StocktakeItemResponse represents the response body for a stocktake item
+type StocktakeItemResponse struct {
+	ID               uint       `json:"id"`
+	ProductID        uint       `json:"product_id"`
+	ProductName      string     `json:"product_name,omitempty"`
+	VariantID        *uint      `json:"variant_id"`
+	VariantName      string     `json:"variant_name,omitempty"`
+	SKU              string     `json:"sku"`
+	ExpectedQuantity int        `json:"expected_quantity"`
+	CountedQuantity  *int       `json:"counted_quantity"`
+	Variance         int        `json:"variance"`
+	Notes            string     `json:"notes"`
+	CountedBy        *uint      `json:"counted_by"`
+	CountedAt        *time.Time `json:"counted_at"`
+}
+
+// StocktakeResponse represents the response body for a stocktake session
+type StocktakeResponse struct {
+	ID             uint                    `json:"id"`
+	Code           string                  `json:"code"`
+	Name           string                  `json:"name"`
+	CategoryID     *uint                   `json:"category_id"`
+	CategoryName   string                  `json:"category_name,omitempty"`
+	Location       string                  `json:"location"`
+	Status         StocktakeStatus         `json:"status"`
+	Notes          string                  `json:"notes"`
+	TotalItems     int                     `json:"total_items"`
+	CountedItems   int                     `json:"counted_items"`
+	VarianceItems  int                     `json:"variance_items"`
+	FrozenAt       *time.Time              `json:"frozen_at"`
+	SubmittedAt    *time.Time              `json:"submitted_at"`
+	ApprovedAt     *time.Time              `json:"approved_at"`
+	ApprovedBy     *uint                   `json:"approved_by"`
+	ApprovedByName string                  `json:"approved_by_name,omitempty"`
+	CreatedBy      uint                    `json:"created_by"`
+	CreatedByName  string                  `json:"created_by_name,omitempty"`
+	Items          []StocktakeItemResponse `json:"items,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+// StocktakeVarianceReport represents the variance review of a stocktake session
+type StocktakeVarianceReport struct {
+	StocktakeID    uint                    `json:"stocktake_id"`
+	Code           string                  `json:"code"`
+	Status         StocktakeStatus         `json:"status"`
+	TotalItems     int                     `json:"total_items"`
+	CountedItems   int                     `json:"counted_items"`
+	UncountedItems int                     `json:"uncounted_items"`
+	VarianceItems  int                     `json:"variance_items"`
+	TotalShortage  int                     `json:"total_shortage"` // Tổng số lượng thiếu
+	TotalSurplus   int                     `json:"total_surplus"`  // Tổng số lượng thừa
+	VarianceValue  float64                 `json:"variance_value"` // Giá trị chênh lệch theo giá vốn
+	Items          []StocktakeItemResponse `json:"items"`
+	UncountedSKUs  []string                `json:"uncounted_skus,omitempty"`
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+)
+
+// Two-factor verification methods
+const (
+	TwoFactorMethodTOTP         = "totp"
+	TwoFactorMethodRecoveryCode = "recovery_code"
+	TwoFactorMethodEmail        = "email" // Mã OTP qua email, dự phòng khi không có ứng dụng xác thực
+)
+
+// UserTwoFactor holds a user's TOTP enrollment. The secret is stored while setup is pending and
+// only protects logins once Enabled is set after the first code is confirmed.
+type UserTwoFactor struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	Secret       string     `json:"-" gorm:"type:text;not null"` // Secret base32 mã hóa AES-GCM
+	Enabled      bool       `json:"enabled" gorm:"default:false"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-" gorm:"default:0"` // Chống dùng lại mã TOTP trong cùng khoảng 30 giây
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for UserTwoFactor model
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+// TwoFactorRecoveryCode is a single-use backup code for when the authenticator app is unavailable
+type TwoFactorRecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"` // SHA-256 của mã khôi phục
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for TwoFactorRecoveryCode model
+func (TwoFactorRecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
+
+// LoginChallenge is the pending second step of a login after the password was accepted
+type LoginChallenge struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash     string     `json:"-" gorm:"size:64;uniqueIndex;not null"` // SHA-256 của challenge token
+	DeviceID      string     `json:"device_id" gorm:"size:100;not null"`
+	UserAgent     string     `json:"user_agent" gorm:"size:500"`
+	IPAddress     string     `json:"ip_address" gorm:"size:45"`
+	SetupRequired bool       `json:"setup_required" gorm:"default:false"` // Vai trò bắt buộc 2FA nhưng chưa đăng ký
+	Attempts      int        `json:"attempts" gorm:"default:0"`           // Số lần nhập sai
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for LoginChallenge model
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
+
+// IsOpen checks if the challenge can still be answered
+func (c *LoginChallenge) IsOpen(maxAttempts int) bool {
+	return c.CompletedAt == nil && c.Attempts < maxAttempts && time.Now().Before(c.ExpiresAt)
+}
+
+// TwoFactorStatusResponse describes the user's 2FA state
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // Bắt buộc theo vai trò/quyền quản trị
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse carries what an authenticator app needs to enroll
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`      // Nhập tay nếu không quét được QR
+	OTPAuthURL string `json:"otpauth_url"` // otpauth://totp/...
+	QRCode     string `json:"qr_code"`     // data:image/png;base64,...
+}
+
+// TwoFactorRecoveryCodesResponse returns freshly generated recovery codes; they are shown only once
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
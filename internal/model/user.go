@@ -25,9 +25,17 @@ type User struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
+	// Brute-force protection
+	FailedLoginAttempts int        `json:"-" gorm:"default:0"`                  // Số lần sai mật khẩu liên tiếp
+	LastFailedLoginAt   *time.Time `json:"-"`                                   // Dùng tính thời gian chờ tăng dần
+	LockedUntil         *time.Time `json:"locked_until,omitempty" gorm:"index"` // Khóa tạm thời do đăng nhập sai nhiều lần
+	LockoutCount        int        `json:"-" gorm:"default:0"`                  // Số lần bị khóa liên tiếp, mỗi lần khóa lâu hơn
+
 	// Relations
 	Sessions []Session `json:"sessions,omitempty" gorm:"foreignKey:UserID"`
 	OTPs     []OTP     `json:"otps,omitempty" gorm:"foreignKey:UserID"`
+
+	GrantedRoles []Role `json:"-" gorm:"-"` // Vai trò được cấp có thời hạn đang hiệu lực, do repository nạp
 }
 
 // TableName returns the table name for User model
@@ -55,29 +63,45 @@ func (u *User) GetFullName() string {
 	return u.Username
 }
 
+// IsLocked checks if the account is temporarily locked after too many failed logins
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
 // IsAdmin checks if user is admin
 func (u *User) IsAdmin() bool {
-	return u.UserRole != nil && u.UserRole.Name == "admin"
+	return u.HasRole("admin")
 }
 
 // IsModerator checks if user is moderator or admin
 func (u *User) IsModerator() bool {
-	return u.UserRole != nil && (u.UserRole.Name == "moderator" || u.UserRole.Name == "admin")
+	return u.HasAnyRole("moderator", "admin")
+}
+
+// RoleNames returns the user's own role and the roles granted to them that are in effect
+func (u *User) RoleNames() []string {
+	var names []string
+	if u.UserRole != nil {
+		names = append(names, u.UserRole.Name)
+	}
+	for _, role := range u.GrantedRoles {
+		names = append(names, role.Name)
+	}
+	return names
 }
 
 // HasRole checks if user has a specific role
 func (u *User) HasRole(roleName string) bool {
-	return u.UserRole != nil && u.UserRole.Name == roleName
+	return u.HasAnyRole(roleName)
 }
 
 // HasAnyRole checks if user has any of the specified roles
 func (u *User) HasAnyRole(roleNames ...string) bool {
-	if u.UserRole == nil {
-		return false
-	}
-	for _, roleName := range roleNames {
-		if u.UserRole.Name == roleName {
-			return true
+	for _, name := range u.RoleNames() {
+		for _, roleName := range roleNames {
+			if name == roleName {
+				return true
+			}
 		}
 	}
 	return false
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+)
+
+// OAuth flow purposes
+const (
+	OAuthPurposeLogin = "login" // Đăng nhập hoặc đăng ký bằng mạng xã hội
+	OAuthPurposeLink  = "link"  // Liên kết thêm tài khoản mạng xã hội cho người dùng đã đăng nhập
+)
+
+// UserIdentity links a user to an account at a social login provider. A user may have
+// several identities, at most one per provider account.
+type UserIdentity struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	Provider      string     `json:"provider" gorm:"size:30;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject       string     `json:"-" gorm:"size:255;not null;uniqueIndex:idx_user_identities_provider_subject"` // ID người dùng phía nhà cung cấp
+	Email         string     `json:"email" gorm:"size:100"`
+	EmailVerified bool       `json:"email_verified" gorm:"default:false"`
+	Name          string     `json:"name" gorm:"size:100"`
+	AvatarURL     string     `json:"avatar_url" gorm:"size:500"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for UserIdentity model
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState is a pending authorization request, consumed once by the callback
+type OAuthState struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	StateHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"` // SHA-256 của tham số state
+	Provider     string     `json:"provider" gorm:"size:30;not null"`
+	Purpose      string     `json:"purpose" gorm:"size:10;not null"` // login, link
+	UserID       *uint      `json:"user_id" gorm:"index"`            // Người dùng liên kết (purpose = link)
+	CodeVerifier string     `json:"-" gorm:"size:128;not null"`      // PKCE code verifier
+	Nonce        string     `json:"-" gorm:"size:64"`                // Chống phát lại ID token
+	RedirectURI  string     `json:"redirect_uri" gorm:"size:500;not null"`
+	DeviceID     string     `json:"device_id" gorm:"size:100"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt       *time.Time `json:"used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for OAuthState model
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
+
+// OAuthAuthorizeResponse is returned when a social login or link flow starts
+type OAuthAuthorizeResponse struct {
+	Provider         string    `json:"provider"`
+	AuthorizationURL string    `json:"authorization_url"` // Chuyển hướng trình duyệt tới URL này
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OAuthProvidersResponse lists the social login providers that are configured
+type OAuthProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
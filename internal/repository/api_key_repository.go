@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	GetByID(id uint) (*model.APIKey, error)
+	GetByHash(hash string) (*model.APIKey, error)
+	GetByUserID(userID uint) ([]model.APIKey, error)
+	GetAll(page, limit int, filters map[string]interface{}) ([]model.APIKey, int64, error)
+	CountActiveByUserID(userID uint) (int64, error)
+	Revoke(id, revokedBy uint) (bool, error)
+	TouchLastUsed(id uint, ip string, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository() APIKeyRepository {
+	return &apiKeyRepository{
+		db: database.GetDB(),
+	}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.First(&key, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash looks up a key together with its owner for authentication
+func (r *apiKeyRepository) GetByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Preload("User.UserRole").Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByUserID(userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// GetAll lists keys of all users for admins. Filters: user_id, status (active, revoked, expired)
+func (r *apiKeyRepository) GetAll(page, limit int, filters map[string]interface{}) ([]model.APIKey, int64, error) {
+	var keys []model.APIKey
+	var total int64
+
+	query := r.db.Model(&model.APIKey{})
+	if userID, ok := filters["user_id"]; ok {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status, ok := filters["status"]; ok {
+		now := time.Now()
+		switch status {
+		case "active":
+			query = query.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)
+		case "revoked":
+			query = query.Where("revoked_at IS NOT NULL")
+		case "expired":
+			query = query.Where("revoked_at IS NULL AND expires_at <= ?", now)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("User").Order("created_at DESC").Offset(offset).Limit(limit).Find(&keys).Error
+	return keys, total, err
+}
+
+func (r *apiKeyRepository) CountActiveByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke marks a key as revoked; returns false if it was already revoked
+func (r *apiKeyRepository) Revoke(id, revokedBy uint) (bool, error) {
+	result := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_by": revokedBy})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(id uint, ip string, usedAt time.Time) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": usedAt, "last_used_ip": ip}).Error
+}
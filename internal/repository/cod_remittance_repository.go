@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrCODAlreadySettled is returned when a shipping order was settled by another statement meanwhile
+var ErrCODAlreadySettled = errors.New("shipping order COD already settled")
+
+// CODSettlement holds the records written when a statement line is matched to a shipping order
+type CODSettlement struct {
+	Line            *model.CODRemittanceLine
+	ShippingOrderID uint
+	Payment         *model.Payment // Created when new, updated otherwise
+}
+
+// CODOrderPayment holds an order's payment status after settlement
+type CODOrderPayment struct {
+	OrderID          uint
+	PaymentStatus    model.PaymentStatus
+	PaidAt           *time.Time
+	CancelPaymentIDs []uint // Pending COD payments replaced by the remitted ones
+}
+
+// CODRemittanceRepository defines methods for interacting with COD remittance data
+type CODRemittanceRepository interface {
+	// Statements
+	SettleRemittance(remittance *model.CODRemittance, settlements []CODSettlement, orders []CODOrderPayment) error
+	GetRemittanceByID(id uint) (*model.CODRemittance, error)
+	GetRemittanceByStatement(providerID uint, statementNumber string) (*model.CODRemittance, error)
+	GetRemittances(page, limit int, filters map[string]interface{}) ([]model.CODRemittance, int64, error)
+	GetRemittanceLines(remittanceID uint, status string) ([]model.CODRemittanceLine, error)
+
+	// Reports
+	GetOutstandingCOD() ([]model.CODOutstandingReport, error)
+}
+
+// codRemittanceRepository implements CODRemittanceRepository
+type codRemittanceRepository struct {
+	db *gorm.DB
+}
+
+// NewCODRemittanceRepository creates a new CODRemittanceRepository
+func NewCODRemittanceRepository() CODRemittanceRepository {
+	return &codRemittanceRepository{
+		db: database.DB,
+	}
+}
+
+// SettleRemittance saves a statement with its lines and, in the same transaction, records the COD
+// payments, links the shipping orders to the statement and updates the orders' payment status
+func (r *codRemittanceRepository) SettleRemittance(remittance *model.CODRemittance, settlements []CODSettlement, orders []CODOrderPayment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, settlement := range settlements {
+			payment := settlement.Payment
+			if payment.ID == 0 {
+				if err := tx.Create(payment).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Omit("Order", "User").Save(payment).Error; err != nil {
+				return err
+			}
+			settlement.Line.PaymentID = &payment.ID
+		}
+
+		if err := tx.Create(remittance).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, settlement := range settlements {
+			result := tx.Model(&model.ShippingOrder{}).
+				Where("id = ? AND cod_remittance_id IS NULL", settlement.ShippingOrderID).
+				Updates(map[string]interface{}{
+					"cod_remittance_id": remittance.ID,
+					"cod_remitted_at":   now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrCODAlreadySettled
+			}
+		}
+
+		for _, order := range orders {
+			updates := map[string]interface{}{"payment_status": order.PaymentStatus}
+			if order.PaidAt != nil {
+				updates["paid_at"] = order.PaidAt
+			}
+			if err := tx.Model(&model.Order{}).Where("id = ?", order.OrderID).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			if len(order.CancelPaymentIDs) > 0 {
+				if err := tx.Model(&model.Payment{}).
+					Where("id IN ? AND status = ?", order.CancelPaymentIDs, model.PaymentStatusPending).
+					Update("status", model.PaymentStatusCancelled).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetRemittanceByID retrieves a statement with its lines
+func (r *codRemittanceRepository) GetRemittanceByID(id uint) (*model.CODRemittance, error) {
+	var remittance model.CODRemittance
+	err := r.db.Preload("Provider").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&remittance, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &remittance, nil
+}
+
+// GetRemittanceByStatement retrieves a carrier's statement by its number
+func (r *codRemittanceRepository) GetRemittanceByStatement(providerID uint, statementNumber string) (*model.CODRemittance, error) {
+	var remittance model.CODRemittance
+	err := r.db.Where("provider_id = ? AND statement_number = ?", providerID, statementNumber).First(&remittance).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &remittance, nil
+}
+
+// GetRemittances retrieves statements with pagination and filters
+func (r *codRemittanceRepository) GetRemittances(page, limit int, filters map[string]interface{}) ([]model.CODRemittance, int64, error) {
+	var remittances []model.CODRemittance
+	var total int64
+	db := r.db.Model(&model.CODRemittance{}).Preload("Provider")
+
+	// Apply filters
+	for key, value := range filters {
+		switch key {
+		case "provider_id":
+			db = db.Where("provider_id = ?", value)
+		case "status":
+			db = db.Where("status = ?", value)
+		case "date_from":
+			db = db.Where("created_at >= ?", value)
+		case "date_to":
+			db = db.Where("created_at <= ?", value)
+		}
+	}
+
+	// Count total records
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Order("created_at DESC").Find(&remittances).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return remittances, total, nil
+}
+
+// GetRemittanceLines retrieves the lines of a statement; status "discrepancy" selects every flagged line
+func (r *codRemittanceRepository) GetRemittanceLines(remittanceID uint, status string) ([]model.CODRemittanceLine, error) {
+	var lines []model.CODRemittanceLine
+	db := r.db.Where("remittance_id = ?", remittanceID)
+	switch status {
+	case "":
+	case model.CODRemittanceStatusDiscrepancy:
+		db = db.Where("status <> ?", model.CODLineStatusMatched)
+	default:
+		db = db.Where("status = ?", status)
+	}
+	err := db.Order("id ASC").Find(&lines).Error
+	return lines, err
+}
+
+// GetOutstandingCOD sums the COD not yet remitted by each carrier
+func (r *codRemittanceRepository) GetOutstandingCOD() ([]model.CODOutstandingReport, error) {
+	var reports []model.CODOutstandingReport
+	err := r.db.Table("shipping_orders so").
+		Select(`sp.id AS provider_id, sp.display_name AS provider_name, sp.code AS provider_code,
+			SUM(CASE WHEN so.status = ? THEN 1 ELSE 0 END) AS delivered_orders,
+			SUM(CASE WHEN so.status = ? THEN so.cod ELSE 0 END) AS delivered_amount,
+			MIN(CASE WHEN so.status = ? THEN so.delivered_at END) AS oldest_delivered,
+			SUM(CASE WHEN so.status <> ? THEN 1 ELSE 0 END) AS in_transit_orders,
+			SUM(CASE WHEN so.status <> ? THEN so.cod ELSE 0 END) AS in_transit_amount`,
+			model.ShippingOrderStatusDelivered, model.ShippingOrderStatusDelivered, model.ShippingOrderStatusDelivered,
+			model.ShippingOrderStatusDelivered, model.ShippingOrderStatusDelivered).
+		Joins("JOIN shipping_providers sp ON sp.id = so.provider_id").
+		Where("so.cod > 0 AND so.cod_remittance_id IS NULL").
+		Where("so.status IN ?", []string{
+			model.ShippingOrderStatusCreated,
+			model.ShippingOrderStatusPickedUp,
+			model.ShippingOrderStatusInTransit,
+			model.ShippingOrderStatusDelivered,
+		}).
+		Group("sp.id, sp.display_name, sp.code").
+		Order("delivered_amount DESC").
+		Scan(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Flagged statement lines per carrier
+	var discrepancies []struct {
+		ProviderID uint
+		Lines      int64
+	}
+	err = r.db.Table("cod_remittance_lines l").
+		Select("r.provider_id, COUNT(*) AS lines").
+		Joins("JOIN cod_remittances r ON r.id = l.remittance_id").
+		Where("l.status <> ?", model.CODLineStatusMatched).
+		Group("r.provider_id").
+		Scan(&discrepancies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider := make(map[uint]int64, len(discrepancies))
+	for _, d := range discrepancies {
+		byProvider[d.ProviderID] = d.Lines
+	}
+	for i := range reports {
+		reports[i].DiscrepancyLines = byProvider[reports[i].ProviderID]
+		delete(byProvider, reports[i].ProviderID)
+	}
+
+	// Carriers with flagged lines but nothing outstanding
+	if len(byProvider) > 0 {
+		var providers []model.ShippingProvider
+		ids := make([]uint, 0, len(byProvider))
+		for id := range byProvider {
+			ids = append(ids, id)
+		}
+		if err := r.db.Where("id IN ?", ids).Find(&providers).Error; err != nil {
+			return nil, err
+		}
+		for _, provider := range providers {
+			reports = append(reports, model.CODOutstandingReport{
+				ProviderID:       provider.ID,
+				ProviderName:     provider.DisplayName,
+				ProviderCode:     provider.Code,
+				DiscrepancyLines: byProvider[provider.ID],
+			})
+		}
+	}
+
+	return reports, nil
+}
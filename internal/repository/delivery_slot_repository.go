@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDeliverySlotFull is returned when a slot has no capacity left for the requested date
+var ErrDeliverySlotFull = errors.New("delivery slot is fully booked")
+
+// DeliverySlotRepository defines methods for interacting with delivery slot templates and reservations
+type DeliverySlotRepository interface {
+	// Templates
+	CreateTemplate(template *model.DeliverySlotTemplate) error
+	GetTemplateByID(id uint) (*model.DeliverySlotTemplate, error)
+	UpdateTemplate(template *model.DeliverySlotTemplate) error
+	DeleteTemplate(id uint) error
+	GetTemplates(filters map[string]interface{}) ([]model.DeliverySlotTemplate, error)
+	GetActiveTemplatesForZone(province, district string) ([]model.DeliverySlotTemplate, error)
+
+	// Reservations
+	HoldSlot(reservation *model.DeliverySlotReservation, capacity int) error
+	GetReservationByID(id uint) (*model.DeliverySlotReservation, error)
+	GetHeldByCart(cartID uint) (*model.DeliverySlotReservation, error)
+	ConfirmReservation(id, orderID uint, now time.Time) error
+	ReleaseByCart(cartID uint, now time.Time) error
+	ReleaseByOrder(orderID uint, now time.Time) error
+	ExpireHolds(now time.Time) (int64, error)
+	CountBookings(templateIDs []uint, from, to time.Time, now time.Time) ([]model.DeliverySlotBookingCount, error)
+}
+
+// deliverySlotRepository implements DeliverySlotRepository
+type deliverySlotRepository struct {
+	db *gorm.DB
+}
+
+// NewDeliverySlotRepository creates a new DeliverySlotRepository
+func NewDeliverySlotRepository() DeliverySlotRepository {
+	return &deliverySlotRepository{
+		db: database.DB,
+	}
+}
+
+// CreateTemplate creates a new delivery slot template
+func (r *deliverySlotRepository) CreateTemplate(template *model.DeliverySlotTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// GetTemplateByID retrieves a delivery slot template by its ID
+func (r *deliverySlotRepository) GetTemplateByID(id uint) (*model.DeliverySlotTemplate, error) {
+	var template model.DeliverySlotTemplate
+	if err := r.db.First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateTemplate updates a delivery slot template
+func (r *deliverySlotRepository) UpdateTemplate(template *model.DeliverySlotTemplate) error {
+	return r.db.Save(template).Error
+}
+
+// DeleteTemplate soft deletes a delivery slot template
+func (r *deliverySlotRepository) DeleteTemplate(id uint) error {
+	return r.db.Delete(&model.DeliverySlotTemplate{}, id).Error
+}
+
+// GetTemplates lists delivery slot templates with optional filters
+func (r *deliverySlotRepository) GetTemplates(filters map[string]interface{}) ([]model.DeliverySlotTemplate, error) {
+	var templates []model.DeliverySlotTemplate
+	query := r.db.Model(&model.DeliverySlotTemplate{})
+
+	if province, ok := filters["province"]; ok {
+		query = query.Where("province = ?", province)
+	}
+	if district, ok := filters["district"]; ok {
+		query = query.Where("district = ?", district)
+	}
+	if isActive, ok := filters["is_active"]; ok {
+		query = query.Where("is_active = ?", isActive)
+	}
+
+	err := query.Order("province ASC, district ASC, sort_order ASC, start_time ASC").Find(&templates).Error
+	return templates, err
+}
+
+// GetActiveTemplatesForZone retrieves active templates covering a delivery zone, including wildcard zones
+func (r *deliverySlotRepository) GetActiveTemplatesForZone(province, district string) ([]model.DeliverySlotTemplate, error) {
+	var templates []model.DeliverySlotTemplate
+	wildcard := model.ShippingZoneWildcard
+	err := r.db.Where("province IN (?, ?) AND district IN (?, ?) AND is_active = ?",
+		province, wildcard, district, wildcard, true).
+		Order("sort_order ASC, start_time ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// HoldSlot holds one unit of a slot for a cart, replacing the cart's previous hold.
+// The template row is locked so concurrent checkouts cannot overbook the slot.
+func (r *deliverySlotRepository) HoldSlot(reservation *model.DeliverySlotReservation, capacity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var template model.DeliverySlotTemplate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, reservation.TemplateID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if reservation.CartID != nil {
+			if err := releaseHeld(tx.Where("cart_id = ?", *reservation.CartID), now); err != nil {
+				return err
+			}
+		}
+
+		var booked int64
+		if err := activeBookings(tx.Model(&model.DeliverySlotReservation{}), now).
+			Where("template_id = ? AND delivery_date = ?", reservation.TemplateID, reservation.DeliveryDate).
+			Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked >= int64(capacity) {
+			return ErrDeliverySlotFull
+		}
+
+		return tx.Create(reservation).Error
+	})
+}
+
+// GetReservationByID retrieves a reservation by its ID
+func (r *deliverySlotRepository) GetReservationByID(id uint) (*model.DeliverySlotReservation, error) {
+	var reservation model.DeliverySlotReservation
+	if err := r.db.Preload("Template").First(&reservation, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetHeldByCart retrieves the latest hold of a cart, whether or not it has expired yet
+func (r *deliverySlotRepository) GetHeldByCart(cartID uint) (*model.DeliverySlotReservation, error) {
+	var reservation model.DeliverySlotReservation
+	err := r.db.Preload("Template").
+		Where("cart_id = ? AND status = ?", cartID, model.DeliverySlotStatusHeld).
+		Order("id DESC").
+		First(&reservation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ConfirmReservation attaches a still valid hold to an order
+func (r *deliverySlotRepository) ConfirmReservation(id, orderID uint, now time.Time) error {
+	result := r.db.Model(&model.DeliverySlotReservation{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, model.DeliverySlotStatusHeld, now).
+		Updates(map[string]interface{}{
+			"status":     model.DeliverySlotStatusConfirmed,
+			"order_id":   orderID,
+			"expires_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReleaseByCart releases the hold of a cart
+func (r *deliverySlotRepository) ReleaseByCart(cartID uint, now time.Time) error {
+	return releaseHeld(r.db.Where("cart_id = ?", cartID), now)
+}
+
+// ReleaseByOrder frees the slot booked by an order
+func (r *deliverySlotRepository) ReleaseByOrder(orderID uint, now time.Time) error {
+	return r.db.Model(&model.DeliverySlotReservation{}).
+		Where("order_id = ? AND status = ?", orderID, model.DeliverySlotStatusConfirmed).
+		Updates(map[string]interface{}{
+			"status":      model.DeliverySlotStatusReleased,
+			"released_at": now,
+		}).Error
+}
+
+// ExpireHolds marks holds past their expiry as expired
+func (r *deliverySlotRepository) ExpireHolds(now time.Time) (int64, error) {
+	result := r.db.Model(&model.DeliverySlotReservation{}).
+		Where("status = ? AND expires_at <= ?", model.DeliverySlotStatusHeld, now).
+		Updates(map[string]interface{}{
+			"status":      model.DeliverySlotStatusExpired,
+			"released_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// CountBookings counts confirmed and unexpired held reservations per template, date and status
+func (r *deliverySlotRepository) CountBookings(templateIDs []uint, from, to time.Time, now time.Time) ([]model.DeliverySlotBookingCount, error) {
+	var counts []model.DeliverySlotBookingCount
+	if len(templateIDs) == 0 {
+		return counts, nil
+	}
+
+	err := activeBookings(r.db.Model(&model.DeliverySlotReservation{}), now).
+		Select("template_id, delivery_date, status, COUNT(*) AS count").
+		Where("template_id IN ? AND delivery_date BETWEEN ? AND ?", templateIDs, from, to).
+		Group("template_id, delivery_date, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// activeBookings scopes a query to reservations that take up slot capacity
+func activeBookings(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("(status = ? OR (status = ? AND expires_at > ?))",
+		model.DeliverySlotStatusConfirmed, model.DeliverySlotStatusHeld, now)
+}
+
+// releaseHeld releases the holds selected by query
+func releaseHeld(query *gorm.DB, now time.Time) error {
+	return query.Model(&model.DeliverySlotReservation{}).
+		Where("status = ?", model.DeliverySlotStatusHeld).
+		Updates(map[string]interface{}{
+			"status":      model.DeliverySlotStatusReleased,
+			"released_at": now,
+		}).Error
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	// Identities
+	Create(identity *model.UserIdentity) error
+	CreateWithUser(user *model.User, identity *model.UserIdentity) error
+	Update(identity *model.UserIdentity) error
+	GetByProviderSubject(provider, subject string) (*model.UserIdentity, error)
+	GetByUserID(userID uint) ([]model.UserIdentity, error)
+	CountByUserID(userID uint) (int64, error)
+	Delete(userID, id uint) (bool, error)
+
+	// Authorization states
+	CreateState(state *model.OAuthState) error
+	ConsumeState(hash string) (*model.OAuthState, error)
+	DeleteExpiredStates() error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository() IdentityRepository {
+	return &identityRepository{
+		db: database.GetDB(),
+	}
+}
+
+func (r *identityRepository) Create(identity *model.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateWithUser registers a new user together with the identity they signed up with
+func (r *identityRepository) CreateWithUser(user *model.User, identity *model.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *identityRepository) Update(identity *model.UserIdentity) error {
+	return r.db.Save(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) GetByUserID(userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *identityRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Delete removes one of the user's identities; false means the user has no such identity
+func (r *identityRepository) Delete(userID, id uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserIdentity{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *identityRepository) CreateState(state *model.OAuthState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeState marks an unused, unexpired state as used and returns it; nil means the state
+// is unknown, expired or was already used by a concurrent callback
+func (r *identityRepository) ConsumeState(hash string) (*model.OAuthState, error) {
+	var state model.OAuthState
+	err := r.db.Where("state_hash = ?", hash).First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	result := r.db.Model(&model.OAuthState{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", state.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	state.UsedAt = &now
+	return &state, nil
+}
+
+func (r *identityRepository) DeleteExpiredStates() error {
+	return r.db.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&model.OAuthState{}).Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ImpersonationRepository interface {
+	Create(impersonation *model.Impersonation) error
+	GetByID(id uint) (*model.Impersonation, error)
+	GetAll(page, limit int, filters map[string]interface{}) ([]model.Impersonation, int64, error)
+	End(id uint, reason string) (bool, error)
+	EndActiveByActor(actorID uint, reason string) (int64, error)
+}
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+func NewImpersonationRepository() ImpersonationRepository {
+	return &impersonationRepository{
+		db: database.GetDB(),
+	}
+}
+
+func (r *impersonationRepository) Create(impersonation *model.Impersonation) error {
+	return r.db.Create(impersonation).Error
+}
+
+// GetByID returns an impersonation with the agent and the customer
+func (r *impersonationRepository) GetByID(id uint) (*model.Impersonation, error) {
+	var impersonation model.Impersonation
+	err := r.db.Preload("Actor").Preload("Subject").First(&impersonation, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+// GetAll lists impersonations, newest first. Filters: actor_id, subject_id, active
+func (r *impersonationRepository) GetAll(page, limit int, filters map[string]interface{}) ([]model.Impersonation, int64, error) {
+	var impersonations []model.Impersonation
+	var total int64
+
+	query := r.db.Model(&model.Impersonation{})
+	if actorID, ok := filters["actor_id"]; ok {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if subjectID, ok := filters["subject_id"]; ok {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	if active, ok := filters["active"]; ok && active == true {
+		query = query.Where("ended_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Actor").Preload("Subject").Order("created_at DESC").Offset(offset).Limit(limit).Find(&impersonations).Error
+	return impersonations, total, err
+}
+
+// End finishes an impersonation; returns false if it had already ended
+func (r *impersonationRepository) End(id uint, reason string) (bool, error) {
+	result := r.db.Model(&model.Impersonation{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "end_reason": reason})
+	return result.RowsAffected > 0, result.Error
+}
+
+// EndActiveByActor finishes the agent's running impersonations, so each agent acts as one customer at a time
+func (r *impersonationRepository) EndActiveByActor(actorID uint, reason string) (int64, error) {
+	result := r.db.Model(&model.Impersonation{}).
+		Where("actor_id = ? AND ended_at IS NULL AND expires_at > ?", actorID, time.Now()).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "end_reason": reason})
+	return result.RowsAffected, result.Error
+}
@@ -1,13 +1,35 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
 	"go_app/internal/model"
 	"go_app/pkg/database"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInsufficientStock is returned when a locked stock level cannot cover a deduction
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInsufficientLotStock is returned when the non-expired lots of a product cannot cover a pick
+var ErrInsufficientLotStock = errors.New("insufficient non-expired lot stock")
+
+// ErrLotNotFound is returned when a movement names a lot the product does not have
+var ErrLotNotFound = errors.New("lot not found")
+
+// StockLine is a quantity of one product/variant taken from or returned to stock
+type StockLine struct {
+	ProductID    uint
+	VariantID    *uint
+	Quantity     int
+	ManageStock  bool // Check and update the stock level; otherwise only the movement is recorded
+	AllocateLots bool // Pick the deducted quantity from lots, earliest expiry first
+}
+
 // InventoryRepository defines methods for interacting with inventory data
 type InventoryRepository interface {
 	// Inventory Movements
@@ -31,6 +53,12 @@ type InventoryRepository interface {
 	UpdateStockQuantity(productID uint, variantID *uint, quantity int) error
 	ReserveStock(productID uint, variantID *uint, quantity int) error
 	ReleaseStock(productID uint, variantID *uint, quantity int) error
+	ApplyMovement(movement *model.InventoryMovement, trackLots bool) error
+	DeductStockLines(lines []StockLine, reference, referenceType string) error
+	ReturnStockLines(lines []StockLine, reference, referenceType string) error
+	ReserveOrderStock(orderID uint, lines []StockLine, reference string) (bool, error)
+	ReleaseOrderStock(orderID uint, lines []StockLine, reference string) (bool, error)
+	FulfillBackorderItem(itemID uint, lines []StockLine, reference string) (bool, error)
 	GetLowStockProducts(threshold int) ([]model.StockLevel, error)
 	GetOutOfStockProducts() ([]model.StockLevel, error)
 
@@ -42,6 +70,21 @@ type InventoryRepository interface {
 	DeleteAdjustment(id uint) error
 	GetAdjustmentsByProduct(productID uint, variantID *uint) ([]model.InventoryAdjustment, error)
 
+	// Inventory Lots
+	GetLotByID(id uint) (*model.InventoryLot, error)
+	GetLotByNumber(productID uint, variantID *uint, lotNumber string) (*model.InventoryLot, error)
+	GetLots(page, limit int, filters map[string]interface{}) ([]model.InventoryLot, int64, error)
+	GetAvailableLotsFEFO(productID uint, variantID *uint, asOf time.Time) ([]model.InventoryLot, error)
+	GetLotsExpiringBefore(date time.Time) ([]model.InventoryLot, error)
+	UpdateLot(lot *model.InventoryLot) error
+	ReceiveLot(movement *model.InventoryMovement) (*model.InventoryLot, error)
+	AdjustLotQuantity(lotID uint, diff int) error
+	AllocateLotsFEFO(movement *model.InventoryMovement, quantity int) ([]model.InventoryLotAllocation, error)
+	ReleaseLotAllocations(reference string) error
+	ReleaseProductLotAllocations(reference string, productID uint, variantID *uint) error
+	GetLotAllocationsByMovement(movementID uint) ([]model.InventoryLotAllocation, error)
+	MarkExpiredLots(asOf time.Time) (int64, error)
+
 	// Statistics
 	GetInventoryStats() (*model.InventoryStatsResponse, error)
 	GetLowStockAlerts() ([]model.LowStockAlert, error)
@@ -227,6 +270,8 @@ func (r *inventoryRepository) GetAllStockLevels(page, limit int, filters map[str
 			db = db.Where("product_id = ?", value)
 		case "variant_id":
 			db = db.Where("variant_id = ?", value)
+		case "location":
+			db = db.Where("location = ?", value)
 		case "low_stock":
 			if value.(bool) {
 				db = db.Where("available_quantity <= min_stock_level")
@@ -318,6 +363,302 @@ func (r *inventoryRepository) ReleaseStock(productID uint, variantID *uint, quan
 	}).Error
 }
 
+// ApplyMovement applies a movement to its stock level and, for lot-tracked products, to its lots
+// in one transaction. The stock level is locked first, creating it if missing, so the lot
+// correction of an adjustment is computed from the quantity actually being replaced.
+func (r *inventoryRepository) ApplyMovement(movement *model.InventoryMovement, trackLots bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var stockLevel model.StockLevel
+		err := lotScope(tx.Clauses(clause.Locking{Strength: "UPDATE"}), movement.ProductID, movement.VariantID).First(&stockLevel).Error
+		if err == gorm.ErrRecordNotFound {
+			stockLevel = model.StockLevel{ProductID: movement.ProductID, VariantID: movement.VariantID}
+			err = tx.Create(&stockLevel).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		var newQuantity int
+		switch movement.Type {
+		case model.MovementTypeInbound, model.MovementTypeReturn:
+			newQuantity = stockLevel.AvailableQuantity + movement.Quantity
+		case model.MovementTypeOutbound, model.MovementTypeTransfer:
+			newQuantity = stockLevel.AvailableQuantity - movement.Quantity
+			if newQuantity < 0 {
+				return ErrInsufficientStock
+			}
+		case model.MovementTypeAdjustment:
+			newQuantity = movement.Quantity
+		}
+
+		if trackLots {
+			if err := applyLotMovement(tx, movement, newQuantity-stockLevel.AvailableQuantity); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.StockLevel{}).Where("id = ?", stockLevel.ID).Updates(map[string]interface{}{
+			"available_quantity": newQuantity,
+			"total_quantity":     newQuantity,
+			"last_movement_at":   &movement.CreatedAt,
+		}).Error
+	})
+}
+
+// applyLotMovement keeps the lots of a lot-tracked product in sync with a movement. Inbound and
+// return movements add to their lot, outbound and transfer movements are picked FEFO from
+// non-expired lots, adjustments with a lot number correct that lot by diff.
+func applyLotMovement(tx *gorm.DB, movement *model.InventoryMovement, diff int) error {
+	switch movement.Type {
+	case model.MovementTypeInbound, model.MovementTypeReturn:
+		// Returns without a lot number go back to the lots picked for the same reference
+		if movement.Type == model.MovementTypeReturn && movement.LotNumber == "" && movement.Reference != "" {
+			lots := lotScope(tx.Model(&model.InventoryLot{}).Select("id"), movement.ProductID, movement.VariantID)
+			return returnLotAllocations(tx, tx.Where("reference = ? AND released_at IS NULL AND lot_id IN (?)", movement.Reference, lots))
+		}
+		_, err := receiveLot(tx, movement)
+		return err
+	case model.MovementTypeOutbound, model.MovementTypeTransfer:
+		quantity := movement.Quantity
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		_, err := allocateLotsFEFO(tx, movement, quantity)
+		return err
+	case model.MovementTypeAdjustment:
+		if movement.LotNumber == "" || diff == 0 {
+			return nil
+		}
+		var lot model.InventoryLot
+		err := lotScope(tx, movement.ProductID, movement.VariantID).Where("lot_number = ?", movement.LotNumber).First(&lot).Error
+		if err == gorm.ErrRecordNotFound {
+			return ErrLotNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return adjustLotQuantity(tx, lot.ID, diff)
+	}
+	return nil
+}
+
+// DeductStockLines records a completed outbound movement for every line and lowers the stock
+// levels in the same transaction, picking lots for lot-tracked lines. The stock levels are locked
+// and checked first, so either every line is taken from stock or nothing is written.
+func (r *inventoryRepository) DeductStockLines(lines []StockLine, reference, referenceType string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deductStockLines(tx, lines, reference, referenceType)
+	})
+}
+
+// ReturnStockLines records a completed return movement for every line and adds the quantities
+// back to the stock levels in the same transaction
+func (r *inventoryRepository) ReturnStockLines(lines []StockLine, reference, referenceType string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return returnStockLines(tx, lines, reference, referenceType)
+	})
+}
+
+// ReserveOrderStock deducts the stock lines of an order and marks the order's stock as reserved in
+// one transaction; returns false without touching stock if the order already holds its stock
+func (r *inventoryRepository) ReserveOrderStock(orderID uint, lines []StockLine, reference string) (bool, error) {
+	reserved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Order{}).
+			Where("id = ? AND stock_reserved_at IS NULL", orderID).
+			Update("stock_reserved_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := deductStockLines(tx, lines, reference, "order"); err != nil {
+			return err
+		}
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+// ReleaseOrderStock returns the stock lines of an order, releases its lot picks and clears the
+// order's reservation in one transaction; returns false without touching stock if the order holds
+// no stock, so cancelling or refunding twice cannot return it twice
+func (r *inventoryRepository) ReleaseOrderStock(orderID uint, lines []StockLine, reference string) (bool, error) {
+	released := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Order{}).
+			Where("id = ? AND stock_reserved_at IS NOT NULL", orderID).
+			Update("stock_reserved_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := returnStockLines(tx, lines, reference, "order_cancellation"); err != nil {
+			return err
+		}
+		if err := returnLotAllocations(tx, tx.Where("reference = ? AND released_at IS NULL", reference)); err != nil {
+			return err
+		}
+		released = true
+		return nil
+	})
+	return released, err
+}
+
+// FulfillBackorderItem takes the backordered quantity of an order item from stock and marks the
+// item fulfilled in one transaction; returns false without touching stock if it was already fulfilled
+func (r *inventoryRepository) FulfillBackorderItem(itemID uint, lines []StockLine, reference string) (bool, error) {
+	fulfilled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.OrderItem{}).
+			Where("id = ? AND backorder_fulfilled_at IS NULL", itemID).
+			Update("backorder_fulfilled_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := deductStockLines(tx, lines, reference, "order"); err != nil {
+			return err
+		}
+		fulfilled = true
+		return nil
+	})
+	return fulfilled, err
+}
+
+// deductStockLines locks and checks the stock levels of the lines, then takes every line from stock
+func deductStockLines(tx *gorm.DB, lines []StockLine, reference, referenceType string) error {
+	levels, err := lockStockLevels(tx, lines)
+	if err != nil {
+		return err
+	}
+
+	// The same product may appear on several lines, so check the total per stock level
+	needed := make(map[string]int)
+	for _, line := range lines {
+		if line.ManageStock {
+			needed[stockLineKey(line.ProductID, line.VariantID)] += line.Quantity
+		}
+	}
+	for _, line := range lines {
+		key := stockLineKey(line.ProductID, line.VariantID)
+		if quantity, ok := needed[key]; ok {
+			if level := levels[key]; level == nil || level.AvailableQuantity < quantity {
+				return fmt.Errorf("%w for product %d", ErrInsufficientStock, line.ProductID)
+			}
+			delete(needed, key)
+		}
+	}
+
+	for _, line := range lines {
+		movement, err := applyStockLine(tx, levels, line, model.MovementTypeOutbound, reference, referenceType)
+		if err != nil {
+			return err
+		}
+		if line.AllocateLots {
+			if _, err := allocateLotsFEFO(tx, movement, line.Quantity); err != nil {
+				return fmt.Errorf("failed to allocate lots for product %d: %w", line.ProductID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// returnStockLines locks the stock levels of the lines and puts every line back
+func returnStockLines(tx *gorm.DB, lines []StockLine, reference, referenceType string) error {
+	levels, err := lockStockLevels(tx, lines)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := applyStockLine(tx, levels, line, model.MovementTypeReturn, reference, referenceType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockStockLevels locks the stock levels of the lines in a fixed order, so concurrent
+// deductions cannot deadlock. Products without a stock level are missing from the result.
+func lockStockLevels(tx *gorm.DB, lines []StockLine) (map[string]*model.StockLevel, error) {
+	keys := make([]string, 0, len(lines))
+	byKey := make(map[string]StockLine)
+	for _, line := range lines {
+		key := stockLineKey(line.ProductID, line.VariantID)
+		if _, ok := byKey[key]; !ok && line.ManageStock {
+			byKey[key] = line
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	levels := make(map[string]*model.StockLevel)
+	for _, key := range keys {
+		line := byKey[key]
+		var level model.StockLevel
+		err := lotScope(tx.Clauses(clause.Locking{Strength: "UPDATE"}), line.ProductID, line.VariantID).First(&level).Error
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		levels[key] = &level
+	}
+	return levels, nil
+}
+
+// applyStockLine writes the movement of one line and moves its locked stock level by the quantity
+func applyStockLine(tx *gorm.DB, levels map[string]*model.StockLevel, line StockLine, movementType model.InventoryMovementType, reference, referenceType string) (*model.InventoryMovement, error) {
+	now := time.Now()
+	movement := &model.InventoryMovement{
+		ProductID:     line.ProductID,
+		VariantID:     line.VariantID,
+		Type:          movementType,
+		Status:        model.MovementStatusCompleted,
+		Quantity:      line.Quantity,
+		Reference:     reference,
+		ReferenceType: referenceType,
+		CompletedAt:   &now,
+	}
+	if err := tx.Create(movement).Error; err != nil {
+		return nil, err
+	}
+
+	level := levels[stockLineKey(line.ProductID, line.VariantID)]
+	if !line.ManageStock || level == nil {
+		return movement, nil
+	}
+
+	diff := line.Quantity
+	if movementType == model.MovementTypeOutbound {
+		diff = -line.Quantity
+	}
+	if err := tx.Model(&model.StockLevel{}).Where("id = ?", level.ID).UpdateColumns(map[string]interface{}{
+		"available_quantity": gorm.Expr("available_quantity + ?", diff),
+		"total_quantity":     gorm.Expr("total_quantity + ?", diff),
+		"last_movement_at":   now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	level.AvailableQuantity += diff
+	level.TotalQuantity += diff
+	return movement, nil
+}
+
+func stockLineKey(productID uint, variantID *uint) string {
+	if variantID == nil {
+		return fmt.Sprintf("%d", productID)
+	}
+	return fmt.Sprintf("%d:%d", productID, *variantID)
+}
+
 // GetLowStockProducts retrieves products with low stock
 func (r *inventoryRepository) GetLowStockProducts(threshold int) ([]model.StockLevel, error) {
 	var stockLevels []model.StockLevel
@@ -415,6 +756,337 @@ func (r *inventoryRepository) GetAdjustmentsByProduct(productID uint, variantID
 	return adjustments, err
 }
 
+// Inventory Lots
+
+// lotScope restricts a query to a product/variant pair
+func lotScope(db *gorm.DB, productID uint, variantID *uint) *gorm.DB {
+	db = db.Where("product_id = ?", productID)
+	if variantID != nil {
+		return db.Where("variant_id = ?", *variantID)
+	}
+	return db.Where("variant_id IS NULL")
+}
+
+// fefoOrder orders lots first-expiry-first-out; lots without expiry date are picked last
+const fefoOrder = "expiry_date IS NULL ASC, expiry_date ASC, received_at ASC, id ASC"
+
+// GetLotByID retrieves an inventory lot by its ID
+func (r *inventoryRepository) GetLotByID(id uint) (*model.InventoryLot, error) {
+	var lot model.InventoryLot
+	if err := r.db.Preload("Product").Preload("Variant").First(&lot, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetLotByNumber retrieves a lot of a product/variant by lot number
+func (r *inventoryRepository) GetLotByNumber(productID uint, variantID *uint, lotNumber string) (*model.InventoryLot, error) {
+	var lot model.InventoryLot
+	if err := lotScope(r.db, productID, variantID).Where("lot_number = ?", lotNumber).First(&lot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetLots retrieves inventory lots with pagination and filters
+func (r *inventoryRepository) GetLots(page, limit int, filters map[string]interface{}) ([]model.InventoryLot, int64, error) {
+	var lots []model.InventoryLot
+	var total int64
+	db := r.db.Model(&model.InventoryLot{}).Preload("Product").Preload("Variant")
+
+	// Apply filters
+	for key, value := range filters {
+		switch key {
+		case "product_id":
+			db = db.Where("product_id = ?", value)
+		case "variant_id":
+			db = db.Where("variant_id = ?", value)
+		case "lot_number":
+			db = db.Where("lot_number LIKE ?", "%"+value.(string)+"%")
+		case "status":
+			db = db.Where("status = ?", value)
+		case "in_stock":
+			if value.(bool) {
+				db = db.Where("available_quantity > 0")
+			}
+		case "expiring_before":
+			db = db.Where("expiry_date IS NOT NULL AND expiry_date <= ?", value)
+		}
+	}
+
+	// Count total records
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Order("product_id ASC, variant_id ASC, " + fefoOrder).Find(&lots).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return lots, total, nil
+}
+
+// GetAvailableLotsFEFO retrieves non-expired lots with stock in FEFO order
+func (r *inventoryRepository) GetAvailableLotsFEFO(productID uint, variantID *uint, asOf time.Time) ([]model.InventoryLot, error) {
+	var lots []model.InventoryLot
+	err := lotScope(r.db, productID, variantID).
+		Where("available_quantity > 0 AND (expiry_date IS NULL OR expiry_date >= ?)", asOf).
+		Order(fefoOrder).Find(&lots).Error
+	return lots, err
+}
+
+// GetLotsExpiringBefore retrieves lots with stock that expire on or before the given date
+func (r *inventoryRepository) GetLotsExpiringBefore(date time.Time) ([]model.InventoryLot, error) {
+	var lots []model.InventoryLot
+	err := r.db.Where("available_quantity > 0 AND expiry_date IS NOT NULL AND expiry_date <= ?", date).
+		Preload("Product").Preload("Variant").Order("expiry_date ASC").Find(&lots).Error
+	return lots, err
+}
+
+// UpdateLot updates an existing inventory lot
+func (r *inventoryRepository) UpdateLot(lot *model.InventoryLot) error {
+	return r.db.Omit("Product", "Variant").Save(lot).Error
+}
+
+// ReceiveLot adds an inbound/return movement quantity to its lot, creating the lot if needed
+func (r *inventoryRepository) ReceiveLot(movement *model.InventoryMovement) (*model.InventoryLot, error) {
+	var lot *model.InventoryLot
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		lot, err = receiveLot(tx, movement)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// receiveLot adds a movement to its lot inside the caller's transaction
+func receiveLot(tx *gorm.DB, movement *model.InventoryMovement) (*model.InventoryLot, error) {
+	var lot model.InventoryLot
+	quantity := movement.Quantity
+	if quantity < 0 {
+		quantity = -quantity
+	}
+
+	err := lotScope(tx.Clauses(clause.Locking{Strength: "UPDATE"}), movement.ProductID, movement.VariantID).
+		Where("lot_number = ?", movement.LotNumber).First(&lot).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		lot = model.InventoryLot{
+			ProductID:      movement.ProductID,
+			VariantID:      movement.VariantID,
+			LotNumber:      movement.LotNumber,
+			ExpiryDate:     movement.ExpiryDate,
+			ManufacturedAt: movement.ManufacturedAt,
+			UnitCost:       movement.UnitCost,
+			Status:         model.LotStatusActive,
+			ReceivedAt:     time.Now(),
+		}
+	}
+
+	if movement.Type == model.MovementTypeInbound {
+		lot.ReceivedQuantity += quantity
+	}
+	lot.AvailableQuantity += quantity
+	if lot.ExpiryDate == nil && movement.ExpiryDate != nil {
+		lot.ExpiryDate = movement.ExpiryDate
+	}
+	if lot.Status == model.LotStatusDepleted {
+		lot.Status = model.LotStatusActive
+	}
+
+	if err := tx.Save(&lot).Error; err != nil {
+		return nil, err
+	}
+
+	movement.LotID = &lot.ID
+	if err := tx.Model(&model.InventoryMovement{}).Where("id = ?", movement.ID).Update("lot_id", lot.ID).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// AdjustLotQuantity changes the available quantity of a lot by diff
+func (r *inventoryRepository) AdjustLotQuantity(lotID uint, diff int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return adjustLotQuantity(tx, lotID, diff)
+	})
+}
+
+// adjustLotQuantity locks a lot and changes its available quantity inside the caller's transaction
+func adjustLotQuantity(tx *gorm.DB, lotID uint, diff int) error {
+	var lot model.InventoryLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lot, lotID).Error; err != nil {
+		return err
+	}
+
+	lot.AvailableQuantity += diff
+	if lot.AvailableQuantity < 0 {
+		lot.AvailableQuantity = 0
+	}
+	if lot.AvailableQuantity == 0 && lot.Status == model.LotStatusActive {
+		lot.Status = model.LotStatusDepleted
+	} else if lot.AvailableQuantity > 0 && lot.Status == model.LotStatusDepleted {
+		lot.Status = model.LotStatusActive
+	}
+	return tx.Save(&lot).Error
+}
+
+// AllocateLotsFEFO picks quantity for an outbound movement from non-expired lots, earliest expiry first
+func (r *inventoryRepository) AllocateLotsFEFO(movement *model.InventoryMovement, quantity int) ([]model.InventoryLotAllocation, error) {
+	var allocations []model.InventoryLotAllocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		allocations, err = allocateLotsFEFO(tx, movement, quantity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// allocateLotsFEFO picks lots for a movement inside the caller's transaction
+func allocateLotsFEFO(tx *gorm.DB, movement *model.InventoryMovement, quantity int) ([]model.InventoryLotAllocation, error) {
+	var lots []model.InventoryLot
+	if err := lotScope(tx.Clauses(clause.Locking{Strength: "UPDATE"}), movement.ProductID, movement.VariantID).
+		Where("available_quantity > 0 AND (expiry_date IS NULL OR expiry_date >= ?)", time.Now()).
+		Order(fefoOrder).Find(&lots).Error; err != nil {
+		return nil, err
+	}
+
+	var allocations []model.InventoryLotAllocation
+	remaining := quantity
+	for i := range lots {
+		if remaining == 0 {
+			break
+		}
+
+		picked := lots[i].AvailableQuantity
+		if picked > remaining {
+			picked = remaining
+		}
+
+		lots[i].AvailableQuantity -= picked
+		if lots[i].AvailableQuantity == 0 {
+			lots[i].Status = model.LotStatusDepleted
+		}
+		if err := tx.Save(&lots[i]).Error; err != nil {
+			return nil, err
+		}
+
+		allocation := model.InventoryLotAllocation{
+			MovementID: movement.ID,
+			LotID:      lots[i].ID,
+			Quantity:   picked,
+			Reference:  movement.Reference,
+		}
+		if err := tx.Create(&allocation).Error; err != nil {
+			return nil, err
+		}
+		allocation.Lot = &lots[i]
+		allocations = append(allocations, allocation)
+		remaining -= picked
+	}
+
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: short by %d", ErrInsufficientLotStock, remaining)
+	}
+
+	// Single-lot picks are stamped on the movement for easy tracing
+	if len(allocations) == 1 {
+		lot := allocations[0].Lot
+		movement.LotID = &lot.ID
+		movement.LotNumber = lot.LotNumber
+		movement.ExpiryDate = lot.ExpiryDate
+		if err := tx.Model(&model.InventoryMovement{}).Where("id = ?", movement.ID).Updates(map[string]interface{}{
+			"lot_id":      lot.ID,
+			"lot_number":  lot.LotNumber,
+			"expiry_date": lot.ExpiryDate,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return allocations, nil
+}
+
+// ReleaseLotAllocations returns picked quantities to their lots for all open allocations of a reference
+func (r *inventoryRepository) ReleaseLotAllocations(reference string) error {
+	return r.releaseLotAllocations(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("reference = ? AND released_at IS NULL", reference)
+	})
+}
+
+// ReleaseProductLotAllocations returns picked quantities to their lots for the open allocations
+// of a reference that belong to one product/variant
+func (r *inventoryRepository) ReleaseProductLotAllocations(reference string, productID uint, variantID *uint) error {
+	return r.releaseLotAllocations(func(tx *gorm.DB) *gorm.DB {
+		lots := lotScope(tx.Model(&model.InventoryLot{}).Select("id"), productID, variantID)
+		return tx.Where("reference = ? AND released_at IS NULL AND lot_id IN (?)", reference, lots)
+	})
+}
+
+// releaseLotAllocations releases the open allocations selected by scope
+func (r *inventoryRepository) releaseLotAllocations(scope func(tx *gorm.DB) *gorm.DB) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return returnLotAllocations(tx, scope(tx))
+	})
+}
+
+// returnLotAllocations returns the quantities of the allocations found by query to their lots
+func returnLotAllocations(tx *gorm.DB, query *gorm.DB) error {
+	var allocations []model.InventoryLotAllocation
+	if err := query.Find(&allocations).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, allocation := range allocations {
+		if err := tx.Model(&model.InventoryLot{}).Where("id = ?", allocation.LotID).Updates(map[string]interface{}{
+			"available_quantity": gorm.Expr("available_quantity + ?", allocation.Quantity),
+			"status":             gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", model.LotStatusDepleted, model.LotStatusActive),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.InventoryLotAllocation{}).Where("id = ?", allocation.ID).Update("released_at", &now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLotAllocationsByMovement retrieves lot allocations of a movement
+func (r *inventoryRepository) GetLotAllocationsByMovement(movementID uint) ([]model.InventoryLotAllocation, error) {
+	var allocations []model.InventoryLotAllocation
+	err := r.db.Where("movement_id = ?", movementID).Preload("Lot").Order("id ASC").Find(&allocations).Error
+	return allocations, err
+}
+
+// MarkExpiredLots flags lots past their expiry date as expired
+func (r *inventoryRepository) MarkExpiredLots(asOf time.Time) (int64, error) {
+	result := r.db.Model(&model.InventoryLot{}).
+		Where("status = ? AND expiry_date IS NOT NULL AND expiry_date < ?", model.LotStatusActive, asOf).
+		Update("status", model.LotStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
 // Statistics
 
 // GetInventoryStats retrieves inventory statistics
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// locationBatchSize bounds the rows written per insert when importing the directory
+const locationBatchSize = 500
+
+// LocationRepository defines methods for interacting with the administrative directory
+type LocationRepository interface {
+	// Directory
+	ImportDirectory(provinces []model.Province, districts []model.District, wards []model.Ward, codes []model.CarrierLocationCode) error
+	GetProvinces() ([]model.Province, error)
+	GetProvinceByCode(code string) (*model.Province, error)
+	GetDistrictsByProvince(provinceCode string) ([]model.District, error)
+	GetDistrictByCode(code string) (*model.District, error)
+	GetWardsByDistrict(districtCode string) ([]model.Ward, error)
+	GetWardByCode(code string) (*model.Ward, error)
+	GetAllDistricts() ([]model.District, error)
+	GetAllWards() ([]model.Ward, error)
+
+	// Carrier codes
+	UpsertCarrierCodes(codes []model.CarrierLocationCode) error
+	GetCarrierCodes(carrier, level string) ([]model.CarrierLocationCode, error)
+	GetCarrierCodesFor(carrier string, codes map[string]string) ([]model.CarrierLocationCode, error)
+
+	// Normalization
+	GetAddressesAfter(afterID uint, limit int) ([]model.Address, error)
+	UpdateAddressLocation(address *model.Address) error
+	GetShippingRatesAfter(afterID uint, limit int) ([]model.ShippingRate, error)
+	UpdateShippingRateZones(rate *model.ShippingRate) error
+}
+
+// locationRepository implements LocationRepository
+type locationRepository struct {
+	db *gorm.DB
+}
+
+// NewLocationRepository creates a new LocationRepository
+func NewLocationRepository() LocationRepository {
+	return &locationRepository{
+		db: database.DB,
+	}
+}
+
+// ImportDirectory inserts or updates the directory units and carrier codes by code
+func (r *locationRepository) ImportDirectory(provinces []model.Province, districts []model.District, wards []model.Ward, codes []model.CarrierLocationCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		upsert := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "full_name", "type", "name_key", "aliases", "updated_at"}),
+		}
+
+		if len(provinces) > 0 {
+			if err := tx.Clauses(upsert).CreateInBatches(provinces, locationBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(districts) > 0 {
+			districtUpsert := upsert
+			districtUpsert.DoUpdates = clause.AssignmentColumns([]string{"province_code", "name", "full_name", "type", "name_key", "aliases", "updated_at"})
+			if err := tx.Clauses(districtUpsert).CreateInBatches(districts, locationBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(wards) > 0 {
+			wardUpsert := upsert
+			wardUpsert.DoUpdates = clause.AssignmentColumns([]string{"district_code", "province_code", "name", "full_name", "type", "name_key", "aliases", "updated_at"})
+			if err := tx.Clauses(wardUpsert).CreateInBatches(wards, locationBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(codes) > 0 {
+			if err := upsertCarrierCodes(tx, codes); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetProvinces retrieves all provinces ordered by code
+func (r *locationRepository) GetProvinces() ([]model.Province, error) {
+	var provinces []model.Province
+	err := r.db.Order("code ASC").Find(&provinces).Error
+	return provinces, err
+}
+
+// GetProvinceByCode retrieves a province by its GSO code
+func (r *locationRepository) GetProvinceByCode(code string) (*model.Province, error) {
+	var province model.Province
+	if err := r.db.Where("code = ?", code).First(&province).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &province, nil
+}
+
+// GetDistrictsByProvince retrieves the districts of a province
+func (r *locationRepository) GetDistrictsByProvince(provinceCode string) ([]model.District, error) {
+	var districts []model.District
+	err := r.db.Where("province_code = ?", provinceCode).Order("code ASC").Find(&districts).Error
+	return districts, err
+}
+
+// GetDistrictByCode retrieves a district by its GSO code
+func (r *locationRepository) GetDistrictByCode(code string) (*model.District, error) {
+	var district model.District
+	if err := r.db.Where("code = ?", code).First(&district).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &district, nil
+}
+
+// GetWardsByDistrict retrieves the wards of a district
+func (r *locationRepository) GetWardsByDistrict(districtCode string) ([]model.Ward, error) {
+	var wards []model.Ward
+	err := r.db.Where("district_code = ?", districtCode).Order("code ASC").Find(&wards).Error
+	return wards, err
+}
+
+// GetWardByCode retrieves a ward by its GSO code
+func (r *locationRepository) GetWardByCode(code string) (*model.Ward, error) {
+	var ward model.Ward
+	if err := r.db.Where("code = ?", code).First(&ward).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ward, nil
+}
+
+// GetAllDistricts retrieves every district, used to build the in-memory directory
+func (r *locationRepository) GetAllDistricts() ([]model.District, error) {
+	var districts []model.District
+	err := r.db.Order("code ASC").Find(&districts).Error
+	return districts, err
+}
+
+// GetAllWards retrieves every ward, used to build the in-memory directory
+func (r *locationRepository) GetAllWards() ([]model.Ward, error) {
+	var wards []model.Ward
+	err := r.db.Order("code ASC").Find(&wards).Error
+	return wards, err
+}
+
+// Carrier codes
+
+// UpsertCarrierCodes inserts or updates carrier code mappings
+func (r *locationRepository) UpsertCarrierCodes(codes []model.CarrierLocationCode) error {
+	return upsertCarrierCodes(r.db, codes)
+}
+
+func upsertCarrierCodes(db *gorm.DB, codes []model.CarrierLocationCode) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "carrier"}, {Name: "level"}, {Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"external_id", "external_name", "updated_at"}),
+	}).CreateInBatches(codes, locationBatchSize).Error
+}
+
+// GetCarrierCodes retrieves a carrier's mappings, optionally for one level
+func (r *locationRepository) GetCarrierCodes(carrier, level string) ([]model.CarrierLocationCode, error) {
+	var codes []model.CarrierLocationCode
+	db := r.db.Where("carrier = ?", carrier)
+	if level != "" {
+		db = db.Where("level = ?", level)
+	}
+	err := db.Order("level ASC, code ASC").Find(&codes).Error
+	return codes, err
+}
+
+// GetCarrierCodesFor retrieves a carrier's mappings for the given level => code pairs
+func (r *locationRepository) GetCarrierCodesFor(carrier string, codes map[string]string) ([]model.CarrierLocationCode, error) {
+	var mappings []model.CarrierLocationCode
+	if len(codes) == 0 {
+		return mappings, nil
+	}
+
+	conditions := r.db.Where("1 = 0")
+	for level, code := range codes {
+		conditions = conditions.Or("level = ? AND code = ?", level, code)
+	}
+	err := r.db.Where("carrier = ?", carrier).Where(conditions).Find(&mappings).Error
+	return mappings, err
+}
+
+// Normalization
+
+// GetAddressesAfter retrieves a page of addresses by ascending ID
+func (r *locationRepository) GetAddressesAfter(afterID uint, limit int) ([]model.Address, error) {
+	var addresses []model.Address
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&addresses).Error
+	return addresses, err
+}
+
+// UpdateAddressLocation saves the normalized location names and codes of an address
+func (r *locationRepository) UpdateAddressLocation(address *model.Address) error {
+	return r.db.Model(&model.Address{}).Where("id = ?", address.ID).Updates(map[string]interface{}{
+		"city":          address.City,
+		"district":      address.District,
+		"ward":          address.Ward,
+		"province_code": address.ProvinceCode,
+		"district_code": address.DistrictCode,
+		"ward_code":     address.WardCode,
+	}).Error
+}
+
+// GetShippingRatesAfter retrieves a page of shipping rates by ascending ID
+func (r *locationRepository) GetShippingRatesAfter(afterID uint, limit int) ([]model.ShippingRate, error) {
+	var rates []model.ShippingRate
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&rates).Error
+	return rates, err
+}
+
+// UpdateShippingRateZones saves the normalized zone names of a shipping rate
+func (r *locationRepository) UpdateShippingRateZones(rate *model.ShippingRate) error {
+	return r.db.Model(&model.ShippingRate{}).Where("id = ?", rate.ID).Updates(map[string]interface{}{
+		"from_province": rate.FromProvince,
+		"from_district": rate.FromDistrict,
+		"to_province":   rate.ToProvince,
+		"to_district":   rate.ToDistrict,
+	}).Error
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginSecurityRepository interface {
+	// Login attempts
+	CreateAttempt(attempt *model.LoginAttempt) error
+	GetAttempts(userID uint, page, limit int) ([]model.LoginAttempt, int64, error)
+	CountSuccessfulLogins(userID uint) (int64, error)
+	HasLoginFromDevice(userID uint, deviceID string) (bool, error)
+	HasLoginFromCountry(userID uint, country string) (bool, error)
+
+	// Failure counters and lockouts
+	RecordFailure(userID uint, at time.Time) (*model.User, error)
+	ResetFailures(userID uint) error
+	LockAccount(lockout *model.AccountLockout) error
+	UnlockAccount(userID, adminID uint) (bool, error)
+	GetActiveLockouts(page, limit int) ([]model.AccountLockout, int64, error)
+}
+
+type loginSecurityRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginSecurityRepository() LoginSecurityRepository {
+	return &loginSecurityRepository{
+		db: database.GetDB(),
+	}
+}
+
+func (r *loginSecurityRepository) CreateAttempt(attempt *model.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *loginSecurityRepository) GetAttempts(userID uint, page, limit int) ([]model.LoginAttempt, int64, error) {
+	var attempts []model.LoginAttempt
+	var total int64
+	db := r.db.Model(&model.LoginAttempt{}).Where("user_id = ?", userID)
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Order("created_at DESC").Find(&attempts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return attempts, total, nil
+}
+
+func (r *loginSecurityRepository) CountSuccessfulLogins(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("user_id = ? AND success = ?", userID, true).
+		Count(&count).Error
+	return count, err
+}
+
+// HasLoginFromDevice reports whether the user has logged in successfully from the device before
+func (r *loginSecurityRepository) HasLoginFromDevice(userID uint, deviceID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("user_id = ? AND success = ? AND device_id = ?", userID, true, deviceID).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// HasLoginFromCountry reports whether the user has logged in successfully from the country before
+func (r *loginSecurityRepository) HasLoginFromCountry(userID uint, country string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("user_id = ? AND success = ? AND country = ?", userID, true, country).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// RecordFailure increments the user's failed login counter under a row lock and returns the updated user
+func (r *loginSecurityRepository) RecordFailure(userID uint, at time.Time) (*model.User, error) {
+	var user model.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		user.FailedLoginAttempts++
+		user.LastFailedLoginAt = &at
+		return tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"failed_login_attempts": user.FailedLoginAttempts,
+			"last_failed_login_at":  at,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetFailures clears the failure counters after a successful login
+func (r *loginSecurityRepository) ResetFailures(userID uint) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+		"lockout_count":         0,
+	}).Error
+}
+
+// LockAccount locks the user until lockout.LockedUntil and records the lockout
+func (r *loginSecurityRepository) LockAccount(lockout *model.AccountLockout) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", lockout.UserID).Updates(map[string]interface{}{
+			"locked_until":          lockout.LockedUntil,
+			"lockout_count":         gorm.Expr("lockout_count + 1"),
+			"failed_login_attempts": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(lockout).Error
+	})
+}
+
+// UnlockAccount lifts an active lock; false means the account was not locked
+func (r *loginSecurityRepository) UnlockAccount(userID, adminID uint) (bool, error) {
+	var unlocked bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND locked_until > ?", userID, now).
+			Updates(map[string]interface{}{
+				"locked_until":          nil,
+				"failed_login_attempts": 0,
+				"last_failed_login_at":  nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		unlocked = true
+		return tx.Model(&model.AccountLockout{}).
+			Where("user_id = ? AND unlocked_at IS NULL AND locked_until > ?", userID, now).
+			Updates(map[string]interface{}{
+				"unlocked_at": now,
+				"unlocked_by": adminID,
+			}).Error
+	})
+	return unlocked, err
+}
+
+// GetActiveLockouts lists lockouts that have not expired or been lifted yet
+func (r *loginSecurityRepository) GetActiveLockouts(page, limit int) ([]model.AccountLockout, int64, error) {
+	var lockouts []model.AccountLockout
+	var total int64
+	db := r.db.Model(&model.AccountLockout{}).
+		Where("unlocked_at IS NULL AND locked_until > ?", time.Now())
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Preload("User").Order("locked_until DESC").Find(&lockouts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return lockouts, total, nil
+}
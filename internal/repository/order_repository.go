@@ -28,6 +28,10 @@ type OrderRepository interface {
 	UpdateOrderItem(orderItem *model.OrderItem) error
 	DeleteOrderItem(id uint) error
 
+	// Backorders
+	GetOpenBackorderItems(page, limit int, filters map[string]interface{}) ([]model.OrderItem, int64, error)
+	GetOpenBackorderedQuantity(productID uint, variantID *uint) (int, error)
+
 	// Cart
 	CreateCart(cart *model.Cart) error
 	GetCartByID(id uint) (*model.Cart, error)
@@ -89,8 +93,10 @@ func (r *orderRepository) GetOrderByID(id uint) (*model.Order, error) {
 	if err := r.db.Preload("User").
 		Preload("OrderItems.Product").
 		Preload("OrderItems.ProductVariant").
+		Preload("OrderItems.Components").
 		Preload("Payments").
 		Preload("ShippingHistory.UpdatedByUser").
+		Preload("PickupLocation").
 		First(&order, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -107,8 +113,10 @@ func (r *orderRepository) GetOrderByOrderNumber(orderNumber string) (*model.Orde
 		Preload("User").
 		Preload("OrderItems.Product").
 		Preload("OrderItems.ProductVariant").
+		Preload("OrderItems.Components").
 		Preload("Payments").
 		Preload("ShippingHistory.UpdatedByUser").
+		Preload("PickupLocation").
 		First(&order).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -135,6 +143,8 @@ func (r *orderRepository) GetAllOrders(page, limit int, filters map[string]inter
 			db = db.Where("shipping_status = ?", value)
 		case "payment_method":
 			db = db.Where("payment_method = ?", value)
+		case "pickup_location_id":
+			db = db.Where("pickup_location_id = ?", value)
 		case "user_id":
 			db = db.Where("user_id = ?", value)
 		case "customer_email":
@@ -262,6 +272,7 @@ func (r *orderRepository) GetOrderItemsByOrder(orderID uint) ([]model.OrderItem,
 	err := r.db.Where("order_id = ?", orderID).
 		Preload("Product").
 		Preload("ProductVariant").
+		Preload("Components").
 		Order("created_at ASC").
 		Find(&orderItems).Error
 	return orderItems, err
@@ -277,6 +288,61 @@ func (r *orderRepository) DeleteOrderItem(id uint) error {
 	return r.db.Delete(&model.OrderItem{}, id).Error
 }
 
+// Backorders
+
+// openBackorderScope limits order items to unfulfilled backorders of active orders
+func (r *orderRepository) openBackorderScope() *gorm.DB {
+	return r.db.Model(&model.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.backordered_quantity > 0 AND order_items.backorder_fulfilled_at IS NULL").
+		Where("orders.status NOT IN ?", []model.OrderStatus{model.OrderStatusCancelled, model.OrderStatusReturned, model.OrderStatusRefunded})
+}
+
+// GetOpenBackorderItems retrieves unfulfilled backordered items, oldest first
+func (r *orderRepository) GetOpenBackorderItems(page, limit int, filters map[string]interface{}) ([]model.OrderItem, int64, error) {
+	var items []model.OrderItem
+	var total int64
+
+	query := r.openBackorderScope()
+
+	if productID, ok := filters["product_id"]; ok {
+		query = query.Where("order_items.product_id = ?", productID)
+	}
+	if backorderType, ok := filters["backorder_type"]; ok {
+		query = query.Where("order_items.backorder_type = ?", backorderType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Preload("Order").
+		Preload("Product").
+		Preload("ProductVariant").
+		Preload("Components").
+		Order("order_items.created_at ASC")
+
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+
+	err := query.Find(&items).Error
+	return items, total, err
+}
+
+// GetOpenBackorderedQuantity sums the quantity still waiting for stock for a product
+func (r *orderRepository) GetOpenBackorderedQuantity(productID uint, variantID *uint) (int, error) {
+	var total int64
+	query := r.openBackorderScope().Where("order_items.product_id = ?", productID)
+	if variantID != nil {
+		query = query.Where("order_items.product_variant_id = ?", *variantID)
+	} else {
+		query = query.Where("order_items.product_variant_id IS NULL")
+	}
+	err := query.Select("COALESCE(SUM(order_items.backordered_quantity), 0)").Scan(&total).Error
+	return int(total), err
+}
+
 // Cart
 
 // CreateCart creates a new cart
@@ -123,11 +123,14 @@ func (r *OrderTrackingRepository) DeleteOrderTracking(id uint) error {
 func (r *OrderTrackingRepository) GetOrderTrackingsForSync(limit int) ([]model.OrderTracking, error) {
 	var trackings []model.OrderTracking
 
-	// Get trackings that are active, have auto_sync enabled, and haven't been synced recently
-	cutoffTime := time.Now().Add(-1 * time.Hour) // Sync at least once per hour
+	// Get trackings that are active, have auto_sync enabled, and haven't been synced recently.
+	// Trackings that failed to sync wait for their backoff instead.
+	now := time.Now()
+	cutoffTime := now.Add(-1 * time.Hour) // Sync at least once per hour
 
 	if err := r.db.Preload("Order").
-		Where("is_active = ? AND auto_sync = ? AND (last_sync_at < ? OR last_sync_at IS NULL)", true, true, cutoffTime).
+		Where("is_active = ? AND auto_sync = ?", true, true).
+		Where("(next_sync_at IS NULL AND (last_sync_at < ? OR last_sync_at IS NULL)) OR next_sync_at <= ?", cutoffTime, now).
 		Order("last_sync_at ASC").
 		Limit(limit).
 		Find(&trackings).Error; err != nil {
@@ -169,6 +172,21 @@ func (r *OrderTrackingRepository) GetOrderTrackingEvents(trackingID uint, page,
 	return events, total, nil
 }
 
+// HasOrderTrackingEvent checks if a tracking already has an event with the given code and time
+func (r *OrderTrackingRepository) HasOrderTrackingEvent(trackingID uint, eventCode string, eventTime time.Time) (bool, error) {
+	query := r.db.Model(&model.OrderTrackingEvent{}).
+		Where("order_tracking_id = ? AND event_code = ?", trackingID, eventCode)
+	if !eventTime.IsZero() {
+		query = query.Where("event_time = ?", eventTime)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check tracking event: %w", err)
+	}
+	return count > 0, nil
+}
+
 // GetLatestOrderTrackingEvent gets the latest event for a tracking
 func (r *OrderTrackingRepository) GetLatestOrderTrackingEvent(trackingID uint) (*model.OrderTrackingEvent, error) {
 	var event model.OrderTrackingEvent
@@ -15,6 +15,7 @@ type OTPRepository interface {
 	GetByUserID(userID uint) ([]*model.OTP, error)
 	GetValidByCode(code string) (*model.OTP, error)
 	GetValidByEmail(email string, otpType model.OTPType) (*model.OTP, error)
+	GetValidByUserCode(userID uint, code string, otpType model.OTPType) (*model.OTP, error)
 	Update(otp *model.OTP) error
 	Delete(id uint) error
 	DeleteExpired() error
@@ -83,6 +84,17 @@ func (r *otpRepository) GetValidByEmail(email string, otpType model.OTPType) (*m
 	return &otp, nil
 }
 
+// GetValidByUserCode finds an unused OTP of the given type issued to the user
+func (r *otpRepository) GetValidByUserCode(userID uint, code string, otpType model.OTPType) (*model.OTP, error) {
+	var otp model.OTP
+	err := r.db.Where("user_id = ? AND code = ? AND type = ? AND is_used = ? AND expires_at > ?",
+		userID, code, otpType, false, time.Now()).First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
 func (r *otpRepository) Update(otp *model.OTP) error {
 	return r.db.Save(otp).Error
 }
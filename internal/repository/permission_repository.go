@@ -36,17 +36,30 @@ type PermissionRepository interface {
 	GetRolePermissions(roleID uint) ([]model.Permission, error)
 	GetRolePermissionIDs(roleID uint) ([]uint, error)
 	CheckRoleHasPermission(roleID uint, permissionName string) (bool, error)
+	UpdateRolePermissionConditions(roleID, permissionID uint, conditions string) (bool, error)
 
 	// User Permissions
-	AssignPermissionToUser(userID, permissionID, grantedBy uint, reason string, expiresAt *time.Time) error
+	AssignPermissionToUser(userID, permissionID, grantedBy uint, reason string, expiresAt *time.Time, conditions string) error
 	RevokePermissionFromUser(userID, permissionID uint) error
 	GetUserPermissions(userID uint) ([]model.UserPermission, error)
 	GetUserPermissionIDs(userID uint) ([]uint, error)
 	CheckUserHasPermission(userID uint, permissionName string) (bool, error)
 	GetUserEffectivePermissions(userID uint) ([]model.Permission, error)
+	GetExpiredUserPermissions(limit int) ([]model.UserPermission, error)
+	DeleteExpiredUserPermission(id uint) (bool, error)
+
+	// Time-bound Role Grants
+	CreateUserRoleGrant(grant *model.UserRoleGrant) error
+	GetUserRoleGrantByID(id uint) (*model.UserRoleGrant, error)
+	GetUserRoleGrants(userID uint) ([]model.UserRoleGrant, error)
+	RevokeUserRoleGrant(id, revokedBy uint) (bool, error)
+	GetUserRoleGrantsToActivate(limit int) ([]model.UserRoleGrant, error)
+	MarkUserRoleGrantActivated(id uint) (bool, error)
+	GetUserRoleGrantsToExpire(limit int) ([]model.UserRoleGrant, error)
+	MarkUserRoleGrantExpired(id uint) (bool, error)
 
 	// Permission Checking
-	CheckPermission(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint) (bool, string, error)
+	GetActivePermissionGrants(userID uint, permissionName string) ([]model.PermissionGrant, error)
 	GetUserPermissionsForResource(userID uint, resource model.ResourceType) ([]model.Permission, error)
 
 	// Audit Logging
@@ -341,20 +354,31 @@ func (r *permissionRepository) CheckRoleHasPermission(roleID uint, permissionNam
 	return count > 0, err
 }
 
+// UpdateRolePermissionConditions sets the conditions of a role permission; returns false if the role does not have it
+func (r *permissionRepository) UpdateRolePermissionConditions(roleID, permissionID uint, conditions string) (bool, error) {
+	result := r.db.Model(&model.RolePermission{}).
+		Where("role_id = ? AND permission_id = ?", roleID, permissionID).
+		Update("conditions", conditions)
+	return result.RowsAffected > 0, result.Error
+}
+
 // User Permissions
 
 // AssignPermissionToUser assigns a permission to a user
-func (r *permissionRepository) AssignPermissionToUser(userID, permissionID, grantedBy uint, reason string, expiresAt *time.Time) error {
-	// Check if assignment already exists
+func (r *permissionRepository) AssignPermissionToUser(userID, permissionID, grantedBy uint, reason string, expiresAt *time.Time, conditions string) error {
+	// Check if assignment already exists; revoked or expired ones are kept soft-deleted for the
+	// audit trail and restored here, since the pair is unique
 	var existing model.UserPermission
-	err := r.db.Where("user_id = ? AND permission_id = ?", userID, permissionID).First(&existing).Error
+	err := r.db.Unscoped().Where("user_id = ? AND permission_id = ?", userID, permissionID).First(&existing).Error
 	if err == nil {
 		// Update existing assignment
 		existing.IsGranted = true
 		existing.Reason = reason
 		existing.ExpiresAt = expiresAt
+		existing.Conditions = conditions
 		existing.GrantedBy = grantedBy
-		return r.db.Save(&existing).Error
+		existing.DeletedAt = gorm.DeletedAt{}
+		return r.db.Unscoped().Save(&existing).Error
 	}
 	if err != gorm.ErrRecordNotFound {
 		return err
@@ -366,6 +390,7 @@ func (r *permissionRepository) AssignPermissionToUser(userID, permissionID, gran
 		IsGranted:    true,
 		Reason:       reason,
 		ExpiresAt:    expiresAt,
+		Conditions:   conditions,
 		GrantedBy:    grantedBy,
 	}
 
@@ -414,14 +439,17 @@ func (r *permissionRepository) CheckUserHasPermission(userID uint, permissionNam
 		return true, nil
 	}
 
-	// Check role permissions
+	// Check permissions of the user's role and of roles granted to them
+	roleIDs, err := r.userRoleIDs(userID)
+	if err != nil {
+		return false, err
+	}
 	var rolePermissionCount int64
 	err = r.db.Table("role_permissions").
 		Joins("JOIN permissions ON role_permissions.permission_id = permissions.id").
-		Joins("JOIN users ON users.role = roles.name").
 		Joins("JOIN roles ON role_permissions.role_id = roles.id").
-		Where("users.id = ? AND permissions.name = ? AND permissions.is_active = ? AND roles.is_active = ?",
-			userID, permissionName, true, true).
+		Where("roles.id IN ? AND permissions.name = ? AND permissions.is_active = ? AND roles.is_active = ? AND role_permissions.deleted_at IS NULL",
+			roleIDs, permissionName, true, true).
 		Count(&rolePermissionCount).Error
 	if err != nil {
 		return false, err
@@ -430,37 +458,63 @@ func (r *permissionRepository) CheckUserHasPermission(userID uint, permissionNam
 	return rolePermissionCount > 0, nil
 }
 
-// GetUserEffectivePermissions retrieves all effective permissions for a user
-func (r *permissionRepository) GetUserEffectivePermissions(userID uint) ([]model.Permission, error) {
-	var permissions []model.Permission
-
-	// Get user's role
+// userRoleIDs returns the user's own role and the roles granted to them that are in effect now
+func (r *permissionRepository) userRoleIDs(userID uint) ([]uint, error) {
 	var user model.User
-	if err := r.db.First(&user, userID).Error; err != nil {
+	if err := r.db.Select("id", "role_id").First(&user, userID).Error; err != nil {
 		return nil, err
 	}
 
-	// Get permissions from role
-	rolePermissions, err := r.GetRolePermissionsByRoleName(user.UserRole.Name)
+	now := time.Now()
+	var grantedRoleIDs []uint
+	err := r.db.Table("user_role_grants").
+		Where("user_role_grants.user_id = ?", userID).
+		Where(activeRoleGrantCondition, now, now).
+		Pluck("user_role_grants.role_id", &grantedRoleIDs).Error
 	if err != nil {
 		return nil, err
 	}
-	permissions = append(permissions, rolePermissions...)
+
+	return append([]uint{user.RoleID}, grantedRoleIDs...), nil
+}
+
+// userEffectivePermissions merges the permissions of the user's roles with their direct
+// permissions; an empty resource returns permissions of every resource
+func (r *permissionRepository) userEffectivePermissions(userID uint, resource model.ResourceType) ([]model.Permission, error) {
+	roleIDs, err := r.userRoleIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get permissions from roles
+	var rolePermissions []model.Permission
+	roleQuery := r.db.Table("permissions").
+		Joins("JOIN role_permissions ON permissions.id = role_permissions.permission_id").
+		Joins("JOIN roles ON role_permissions.role_id = roles.id").
+		Where("roles.id IN ? AND permissions.is_active = ? AND roles.is_active = ? AND role_permissions.deleted_at IS NULL", roleIDs, true, true)
+	if resource != "" {
+		roleQuery = roleQuery.Where("permissions.resource = ?", resource)
+	}
+	if err := roleQuery.Find(&rolePermissions).Error; err != nil {
+		return nil, err
+	}
 
 	// Get direct user permissions
 	var userPermissions []model.Permission
-	err = r.db.Table("permissions").
+	userQuery := r.db.Table("permissions").
 		Joins("JOIN user_permissions ON permissions.id = user_permissions.permission_id").
-		Where("user_permissions.user_id = ? AND user_permissions.is_granted = ? AND permissions.is_active = ? AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > ?)",
-			userID, true, true, time.Now()).
-		Find(&userPermissions).Error
-	if err != nil {
+		Where("user_permissions.user_id = ? AND user_permissions.is_granted = ? AND permissions.is_active = ? AND user_permissions.deleted_at IS NULL AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > ?)",
+			userID, true, true, time.Now())
+	if resource != "" {
+		userQuery = userQuery.Where("permissions.resource = ?", resource)
+	}
+	if err := userQuery.Find(&userPermissions).Error; err != nil {
 		return nil, err
 	}
 
 	// Merge and deduplicate
 	permissionMap := make(map[uint]model.Permission)
-	for _, p := range permissions {
+	for _, p := range rolePermissions {
 		permissionMap[p.ID] = p
 	}
 	for _, p := range userPermissions {
@@ -475,6 +529,12 @@ func (r *permissionRepository) GetUserEffectivePermissions(userID uint) ([]model
 	return result, nil
 }
 
+// GetUserEffectivePermissions retrieves all effective permissions for a user, including roles
+// granted for a period of time that are in effect
+func (r *permissionRepository) GetUserEffectivePermissions(userID uint) ([]model.Permission, error) {
+	return r.userEffectivePermissions(userID, "")
+}
+
 // GetRolePermissionsByRoleName retrieves permissions by role name
 func (r *permissionRepository) GetRolePermissionsByRoleName(roleName string) ([]model.Permission, error) {
 	var permissions []model.Permission
@@ -489,90 +549,160 @@ func (r *permissionRepository) GetRolePermissionsByRoleName(roleName string) ([]
 
 // Permission Checking
 
-// CheckPermission checks if a user has permission for a specific resource and action
-func (r *permissionRepository) CheckPermission(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint) (bool, string, error) {
-	permissionName := model.GetPermissionName(resource, action)
+// GetActivePermissionGrants returns every current grant of the permission to the user: direct
+// user permissions, the user's role and roles granted for a period of time that are in effect now
+func (r *permissionRepository) GetActivePermissionGrants(userID uint, permissionName string) ([]model.PermissionGrant, error) {
+	now := time.Now()
+	var grants []model.PermissionGrant
 
-	// Check user permissions first (direct assignments)
-	var userPermission model.UserPermission
+	var userGrants []model.PermissionGrant
 	err := r.db.Table("user_permissions").
+		Select("'user_permission' AS source, user_permissions.id AS source_id, user_permissions.conditions").
 		Joins("JOIN permissions ON user_permissions.permission_id = permissions.id").
-		Where("user_permissions.user_id = ? AND permissions.name = ? AND user_permissions.is_granted = ? AND permissions.is_active = ? AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > ?)",
-			userID, permissionName, true, true, time.Now()).
-		First(&userPermission).Error
-	if err == nil {
-		return true, "user_permission", nil
-	}
-	if err != gorm.ErrRecordNotFound {
-		return false, "", err
+		Where("user_permissions.user_id = ? AND permissions.name = ? AND user_permissions.is_granted = ? AND permissions.is_active = ? AND user_permissions.deleted_at IS NULL AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > ?)",
+			userID, permissionName, true, true, now).
+		Scan(&userGrants).Error
+	if err != nil {
+		return nil, err
 	}
+	grants = append(grants, userGrants...)
 
-	// Check role permissions
-	var rolePermission model.RolePermission
+	var roleGrants []model.PermissionGrant
 	err = r.db.Table("role_permissions").
+		Select("'role' AS source, roles.id AS source_id, role_permissions.conditions").
 		Joins("JOIN permissions ON role_permissions.permission_id = permissions.id").
-		Joins("JOIN users ON users.role = roles.name").
 		Joins("JOIN roles ON role_permissions.role_id = roles.id").
-		Where("users.id = ? AND permissions.name = ? AND permissions.is_active = ? AND roles.is_active = ?",
+		Joins("JOIN users ON users.role_id = roles.id").
+		Where("users.id = ? AND permissions.name = ? AND permissions.is_active = ? AND roles.is_active = ? AND role_permissions.deleted_at IS NULL",
 			userID, permissionName, true, true).
-		First(&rolePermission).Error
-	if err == nil {
-		return true, "role", nil
+		Scan(&roleGrants).Error
+	if err != nil {
+		return nil, err
 	}
-	if err != gorm.ErrRecordNotFound {
-		return false, "", err
+	grants = append(grants, roleGrants...)
+
+	// Roles granted for a period of time count only between their start and end
+	var timedGrants []model.PermissionGrant
+	err = r.db.Table("role_permissions").
+		Select("'role_grant' AS source, user_role_grants.id AS source_id, role_permissions.conditions").
+		Joins("JOIN permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON role_permissions.role_id = roles.id").
+		Joins("JOIN user_role_grants ON user_role_grants.role_id = roles.id").
+		Where("user_role_grants.user_id = ? AND permissions.name = ? AND permissions.is_active = ? AND roles.is_active = ? AND role_permissions.deleted_at IS NULL",
+			userID, permissionName, true, true).
+		Where(activeRoleGrantCondition, now, now).
+		Scan(&timedGrants).Error
+	if err != nil {
+		return nil, err
 	}
+	grants = append(grants, timedGrants...)
 
-	return false, "", nil
+	return grants, nil
 }
 
 // GetUserPermissionsForResource retrieves user permissions for a specific resource
 func (r *permissionRepository) GetUserPermissionsForResource(userID uint, resource model.ResourceType) ([]model.Permission, error) {
-	var permissions []model.Permission
+	return r.userEffectivePermissions(userID, resource)
+}
 
-	// Get permissions from role
-	var user model.User
-	if err := r.db.First(&user, userID).Error; err != nil {
-		return nil, err
-	}
+// GetExpiredUserPermissions returns direct user permissions whose expiry has passed
+func (r *permissionRepository) GetExpiredUserPermissions(limit int) ([]model.UserPermission, error) {
+	var userPermissions []model.UserPermission
+	err := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+		Preload("Permission").
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&userPermissions).Error
+	return userPermissions, err
+}
 
-	rolePermissions, err := r.GetRolePermissionsByRoleName(user.UserRole.Name)
-	if err != nil {
-		return nil, err
-	}
+// DeleteExpiredUserPermission soft-deletes an expired user permission, keeping the row for the
+// audit trail; returns false if it was renewed or removed meanwhile
+func (r *permissionRepository) DeleteExpiredUserPermission(id uint) (bool, error) {
+	result := r.db.Where("id = ? AND expires_at IS NOT NULL AND expires_at <= ?", id, time.Now()).
+		Delete(&model.UserPermission{})
+	return result.RowsAffected > 0, result.Error
+}
 
-	for _, p := range rolePermissions {
-		if p.Resource == resource {
-			permissions = append(permissions, p)
-		}
-	}
+// Time-bound Role Grants
 
-	// Get direct user permissions for resource
-	var userPermissions []model.Permission
-	err = r.db.Table("permissions").
-		Joins("JOIN user_permissions ON permissions.id = user_permissions.permission_id").
-		Where("user_permissions.user_id = ? AND permissions.resource = ? AND user_permissions.is_granted = ? AND permissions.is_active = ? AND (user_permissions.expires_at IS NULL OR user_permissions.expires_at > ?)",
-			userID, resource, true, true, time.Now()).
-		Find(&userPermissions).Error
-	if err != nil {
-		return nil, err
-	}
+// activeRoleGrantCondition matches role grants in effect at the time bound twice as its arguments
+const activeRoleGrantCondition = "user_role_grants.deleted_at IS NULL AND user_role_grants.revoked_at IS NULL AND user_role_grants.expired_at IS NULL AND user_role_grants.starts_at <= ? AND (user_role_grants.ends_at IS NULL OR user_role_grants.ends_at > ?)"
 
-	// Merge and deduplicate
-	permissionMap := make(map[uint]model.Permission)
-	for _, p := range permissions {
-		permissionMap[p.ID] = p
-	}
-	for _, p := range userPermissions {
-		permissionMap[p.ID] = p
-	}
+// CreateUserRoleGrant creates a role grant
+func (r *permissionRepository) CreateUserRoleGrant(grant *model.UserRoleGrant) error {
+	return r.db.Create(grant).Error
+}
 
-	var result []model.Permission
-	for _, p := range permissionMap {
-		result = append(result, p)
+// GetUserRoleGrantByID retrieves a role grant with its user and role
+func (r *permissionRepository) GetUserRoleGrantByID(id uint) (*model.UserRoleGrant, error) {
+	var grant model.UserRoleGrant
+	err := r.db.Preload("User").Preload("Role").Preload("GrantedByUser").First(&grant, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &grant, nil
+}
 
-	return result, nil
+// GetUserRoleGrants retrieves all role grants of a user, latest start first
+func (r *permissionRepository) GetUserRoleGrants(userID uint) ([]model.UserRoleGrant, error) {
+	var grants []model.UserRoleGrant
+	err := r.db.Where("user_id = ?", userID).
+		Preload("Role").
+		Preload("GrantedByUser").
+		Order("starts_at DESC").
+		Find(&grants).Error
+	return grants, err
+}
+
+// RevokeUserRoleGrant ends a grant early; returns false if it had already ended
+func (r *permissionRepository) RevokeUserRoleGrant(id, revokedBy uint) (bool, error) {
+	result := r.db.Model(&model.UserRoleGrant{}).
+		Where("id = ? AND revoked_at IS NULL AND expired_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_by": revokedBy})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserRoleGrantsToActivate returns grants whose start has come but are not yet recorded as active
+func (r *permissionRepository) GetUserRoleGrantsToActivate(limit int) ([]model.UserRoleGrant, error) {
+	now := time.Now()
+	var grants []model.UserRoleGrant
+	err := r.db.Where("activated_at IS NULL AND revoked_at IS NULL AND expired_at IS NULL AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Preload("Role").
+		Order("starts_at ASC").
+		Limit(limit).
+		Find(&grants).Error
+	return grants, err
+}
+
+// MarkUserRoleGrantActivated records that a grant has taken effect
+func (r *permissionRepository) MarkUserRoleGrantActivated(id uint) (bool, error) {
+	result := r.db.Model(&model.UserRoleGrant{}).
+		Where("id = ? AND activated_at IS NULL", id).
+		Update("activated_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserRoleGrantsToExpire returns grants whose end has passed but are not yet recorded as expired
+func (r *permissionRepository) GetUserRoleGrantsToExpire(limit int) ([]model.UserRoleGrant, error) {
+	var grants []model.UserRoleGrant
+	err := r.db.Where("expired_at IS NULL AND revoked_at IS NULL AND ends_at IS NOT NULL AND ends_at <= ?", time.Now()).
+		Preload("Role").
+		Order("ends_at ASC").
+		Limit(limit).
+		Find(&grants).Error
+	return grants, err
+}
+
+// MarkUserRoleGrantExpired records that a grant has ended
+func (r *permissionRepository) MarkUserRoleGrantExpired(id uint) (bool, error) {
+	result := r.db.Model(&model.UserRoleGrant{}).
+		Where("id = ? AND expired_at IS NULL AND revoked_at IS NULL", id).
+		Update("expired_at", time.Now())
+	return result.RowsAffected > 0, result.Error
 }
 
 // Audit Logging
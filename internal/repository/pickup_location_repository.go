@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// PickupOpenOrderStatuses are the order statuses that hold a slot at a pickup location
+var PickupOpenOrderStatuses = []model.OrderStatus{
+	model.OrderStatusPending,
+	model.OrderStatusConfirmed,
+	model.OrderStatusProcessing,
+	model.OrderStatusShipped,
+	model.OrderStatusPartiallyShipped,
+	model.OrderStatusReadyForPickup,
+}
+
+// PickupLocationRepository defines methods for interacting with pickup locations
+type PickupLocationRepository interface {
+	Create(location *model.PickupLocation) error
+	GetByID(id uint) (*model.PickupLocation, error)
+	GetByCode(code string) (*model.PickupLocation, error)
+	Update(location *model.PickupLocation) error
+	Delete(id uint) error
+	GetAll(page, limit int, filters map[string]interface{}) ([]model.PickupLocation, int64, error)
+	GetActiveWithinBounds(minLat, maxLat, minLng, maxLng float64) ([]model.PickupLocation, error)
+	CountOpenOrders(locationIDs []uint) (map[uint]int64, error)
+}
+
+// pickupLocationRepository implements PickupLocationRepository
+type pickupLocationRepository struct {
+	db *gorm.DB
+}
+
+// NewPickupLocationRepository creates a new PickupLocationRepository
+func NewPickupLocationRepository() PickupLocationRepository {
+	return &pickupLocationRepository{
+		db: database.DB,
+	}
+}
+
+// Create creates a new pickup location
+func (r *pickupLocationRepository) Create(location *model.PickupLocation) error {
+	return r.db.Create(location).Error
+}
+
+// GetByID retrieves a pickup location by its ID
+func (r *pickupLocationRepository) GetByID(id uint) (*model.PickupLocation, error) {
+	var location model.PickupLocation
+	if err := r.db.First(&location, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetByCode retrieves a pickup location by its code
+func (r *pickupLocationRepository) GetByCode(code string) (*model.PickupLocation, error) {
+	var location model.PickupLocation
+	if err := r.db.Where("code = ?", code).First(&location).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+// Update updates an existing pickup location
+func (r *pickupLocationRepository) Update(location *model.PickupLocation) error {
+	return r.db.Save(location).Error
+}
+
+// Delete soft deletes a pickup location
+func (r *pickupLocationRepository) Delete(id uint) error {
+	return r.db.Delete(&model.PickupLocation{}, id).Error
+}
+
+// GetAll retrieves pickup locations with pagination and filters
+func (r *pickupLocationRepository) GetAll(page, limit int, filters map[string]interface{}) ([]model.PickupLocation, int64, error) {
+	var locations []model.PickupLocation
+	var total int64
+
+	db := r.db.Model(&model.PickupLocation{})
+
+	if isActive, ok := filters["is_active"]; ok {
+		db = db.Where("is_active = ?", isActive)
+	}
+	if provinceCode, ok := filters["province_code"]; ok {
+		db = db.Where("province_code = ?", provinceCode)
+	}
+	if districtCode, ok := filters["district_code"]; ok {
+		db = db.Where("district_code = ?", districtCode)
+	}
+	if city, ok := filters["city"]; ok {
+		db = db.Where("city = ?", city)
+	}
+	if search, ok := filters["search"]; ok {
+		like := "%" + search.(string) + "%"
+		db = db.Where("name LIKE ? OR code LIKE ? OR address_line LIKE ?", like, like, like)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Order("name ASC").Find(&locations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return locations, total, nil
+}
+
+// GetActiveWithinBounds retrieves the active pickup locations inside a bounding box
+func (r *pickupLocationRepository) GetActiveWithinBounds(minLat, maxLat, minLng, maxLng float64) ([]model.PickupLocation, error) {
+	var locations []model.PickupLocation
+	err := r.db.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ? AND is_active = ?",
+		minLat, maxLat, minLng, maxLng, true).
+		Find(&locations).Error
+	return locations, err
+}
+
+// CountOpenOrders counts the pickup orders not yet collected or cancelled at each location
+func (r *pickupLocationRepository) CountOpenOrders(locationIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(locationIDs))
+	if len(locationIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PickupLocationID uint
+		Count            int64
+	}
+	err := r.db.Model(&model.Order{}).
+		Select("pickup_location_id, COUNT(*) AS count").
+		Where("pickup_location_id IN ? AND shipping_method = ? AND status IN ?", locationIDs, model.ShippingMethodPickup, PickupOpenOrderStatuses).
+		Group("pickup_location_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PickupLocationID] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyRepository interface {
+	// Data exports
+	CreateExport(export *model.DataExportRequest) error
+	UpdateExport(export *model.DataExportRequest) error
+	GetExportByID(id uint) (*model.DataExportRequest, error)
+	GetExportsByUserID(userID uint) ([]model.DataExportRequest, error)
+	GetLatestExport(userID uint) (*model.DataExportRequest, error)
+	ClaimPendingExports(limit int) ([]model.DataExportRequest, error)
+	GetExpiredExports(now time.Time) ([]model.DataExportRequest, error)
+	IncrementDownloads(id uint) error
+
+	// Personal data gathered into an export
+	GetOrders(userID uint) ([]model.Order, error)
+	GetAddresses(userID uint) ([]model.Address, error)
+	GetReviews(userID uint) ([]model.Review, error)
+	GetWishlists(userID uint) ([]model.Wishlist, error)
+	GetFavorites(userID uint) ([]model.Favorite, error)
+	GetNotifications(userID uint) ([]model.Notification, error)
+	GetAuditLogs(userID uint) ([]model.AuditLog, error)
+	GetLoginAttempts(userID uint) ([]model.LoginAttempt, error)
+	GetIdentities(userID uint) ([]model.UserIdentity, error)
+
+	// Account erasure
+	CreateErasure(erasure *model.AccountErasureRequest) error
+	UpdateErasure(erasure *model.AccountErasureRequest) error
+	GetPendingErasure(userID uint) (*model.AccountErasureRequest, error)
+	GetLatestErasure(userID uint) (*model.AccountErasureRequest, error)
+	GetDueErasures(now time.Time, limit int) ([]model.AccountErasureRequest, error)
+	CountOpenOrders(userID uint) (int64, error)
+	EraseUser(erasure *model.AccountErasureRequest) error
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository() PrivacyRepository {
+	return &privacyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// openOrderStatuses are orders still being fulfilled; an account is not erased while it has any
+var openOrderStatuses = []model.OrderStatus{
+	model.OrderStatusPending,
+	model.OrderStatusConfirmed,
+	model.OrderStatusProcessing,
+	model.OrderStatusShipped,
+	model.OrderStatusPartiallyShipped,
+	model.OrderStatusReadyForPickup,
+}
+
+func (r *privacyRepository) CreateExport(export *model.DataExportRequest) error {
+	return r.db.Create(export).Error
+}
+
+func (r *privacyRepository) UpdateExport(export *model.DataExportRequest) error {
+	return r.db.Save(export).Error
+}
+
+func (r *privacyRepository) GetExportByID(id uint) (*model.DataExportRequest, error) {
+	var export model.DataExportRequest
+	if err := r.db.First(&export, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *privacyRepository) GetExportsByUserID(userID uint) ([]model.DataExportRequest, error) {
+	var exports []model.DataExportRequest
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *privacyRepository) GetLatestExport(userID uint) (*model.DataExportRequest, error) {
+	var export model.DataExportRequest
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimPendingExports marks up to limit pending exports as processing and returns them, so
+// two workers never build the same archive
+func (r *privacyRepository) ClaimPendingExports(limit int) ([]model.DataExportRequest, error) {
+	var exports []model.DataExportRequest
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", model.DataExportStatusPending).
+			Order("created_at ASC").Limit(limit).
+			Find(&exports).Error; err != nil {
+			return err
+		}
+		if len(exports) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		ids := make([]uint, len(exports))
+		for i := range exports {
+			ids[i] = exports[i].ID
+			exports[i].Status = model.DataExportStatusProcessing
+			exports[i].StartedAt = &now
+		}
+		return tx.Model(&model.DataExportRequest{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":     model.DataExportStatusProcessing,
+			"started_at": now,
+		}).Error
+	})
+	return exports, err
+}
+
+func (r *privacyRepository) GetExpiredExports(now time.Time) ([]model.DataExportRequest, error) {
+	var exports []model.DataExportRequest
+	err := r.db.Where("status = ? AND expires_at <= ?", model.DataExportStatusCompleted, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *privacyRepository) IncrementDownloads(id uint) error {
+	return r.db.Model(&model.DataExportRequest{}).Where("id = ?", id).
+		UpdateColumn("downloads", gorm.Expr("downloads + 1")).Error
+}
+
+// GetOrders includes deleted orders, which are still stored
+func (r *privacyRepository) GetOrders(userID uint) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.Unscoped().Preload("OrderItems").Preload("Payments").Preload("ShippingHistory").
+		Where("user_id = ?", userID).Order("created_at ASC").Find(&orders).Error
+	return orders, err
+}
+
+// GetAddresses includes deleted addresses, which are still stored
+func (r *privacyRepository) GetAddresses(userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	err := r.db.Unscoped().Where("user_id = ?", userID).Order("created_at ASC").Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *privacyRepository) GetReviews(userID uint) ([]model.Review, error) {
+	var reviews []model.Review
+	err := r.db.Unscoped().Preload("Images").Where("user_id = ?", userID).Order("created_at ASC").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *privacyRepository) GetWishlists(userID uint) ([]model.Wishlist, error) {
+	var wishlists []model.Wishlist
+	err := r.db.Preload("Items").Where("user_id = ?", userID).Order("created_at ASC").Find(&wishlists).Error
+	return wishlists, err
+}
+
+func (r *privacyRepository) GetFavorites(userID uint) ([]model.Favorite, error) {
+	var favorites []model.Favorite
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&favorites).Error
+	return favorites, err
+}
+
+func (r *privacyRepository) GetNotifications(userID uint) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&notifications).Error
+	return notifications, err
+}
+
+// GetAuditLogs returns the entries of actions by the user and of actions on their account
+func (r *privacyRepository) GetAuditLogs(userID uint) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	err := r.db.Where("user_id = ? OR target_user_id = ?", userID, userID).
+		Order("created_at ASC").Find(&logs).Error
+	return logs, err
+}
+
+func (r *privacyRepository) GetLoginAttempts(userID uint) ([]model.LoginAttempt, error) {
+	var attempts []model.LoginAttempt
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *privacyRepository) GetIdentities(userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *privacyRepository) CreateErasure(erasure *model.AccountErasureRequest) error {
+	return r.db.Create(erasure).Error
+}
+
+func (r *privacyRepository) UpdateErasure(erasure *model.AccountErasureRequest) error {
+	return r.db.Save(erasure).Error
+}
+
+func (r *privacyRepository) GetPendingErasure(userID uint) (*model.AccountErasureRequest, error) {
+	var erasure model.AccountErasureRequest
+	if err := r.db.Where("user_id = ? AND status = ?", userID, model.AccountErasureStatusPending).
+		First(&erasure).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &erasure, nil
+}
+
+func (r *privacyRepository) GetLatestErasure(userID uint) (*model.AccountErasureRequest, error) {
+	var erasure model.AccountErasureRequest
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&erasure).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &erasure, nil
+}
+
+func (r *privacyRepository) GetDueErasures(now time.Time, limit int) ([]model.AccountErasureRequest, error) {
+	var erasures []model.AccountErasureRequest
+	err := r.db.Where("status = ? AND scheduled_at <= ?", model.AccountErasureStatusPending, now).
+		Order("scheduled_at ASC").Limit(limit).Find(&erasures).Error
+	return erasures, err
+}
+
+func (r *privacyRepository) CountOpenOrders(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Order{}).
+		Where("user_id = ? AND status IN ?", userID, openOrderStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// EraseUser anonymizes the personal data of the user in one transaction and completes the
+// erasure request. Orders, order items, payments and shipments keep their amounts for
+// accounting; only the contact details on them are replaced. Reviews stay published as
+// anonymous so product ratings do not change.
+func (r *privacyRepository) EraseUser(erasure *model.AccountErasureRequest) error {
+	userID := erasure.UserID
+	erasedEmail := model.ErasedUserEmail(userID)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		originalEmail := user.Email
+
+		// Orders: thay thông tin liên hệ, giữ nguyên số tiền và trạng thái thanh toán
+		orderIDs := tx.Unscoped().Model(&model.Order{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Unscoped().Model(&model.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"customer_name":    model.ErasedPlaceholder,
+			"customer_email":   erasedEmail,
+			"customer_phone":   "",
+			"shipping_address": model.ErasedPlaceholder,
+			"billing_address":  "",
+			"notes":            "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.ShippingOrder{}).Where("order_id IN (?)", orderIDs).Updates(map[string]interface{}{
+			"to_name":    model.ErasedPlaceholder,
+			"to_address": model.ErasedPlaceholder,
+			"to_phone":   "",
+			"to_email":   "",
+		}).Error; err != nil {
+			return err
+		}
+
+		// Addresses: orders may still reference them, so they are blanked and soft deleted
+		if err := tx.Unscoped().Model(&model.Address{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"full_name":     model.ErasedPlaceholder,
+			"phone":         "",
+			"email":         "",
+			"address_line1": model.ErasedPlaceholder,
+			"address_line2": "",
+			"postal_code":   "",
+			"latitude":      nil,
+			"longitude":     nil,
+			"landmark":      "",
+			"instructions":  "",
+			"notes":         "",
+			"is_default":    false,
+			"is_active":     false,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Address{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&model.Review{}).Where("user_id = ?", userID).
+			Update("is_anonymous", true).Error; err != nil {
+			return err
+		}
+
+		// Wishlists, favorites and carts have no value once the account is gone
+		wishlistIDs := tx.Model(&model.Wishlist{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("wishlist_id IN (?)", wishlistIDs).Delete(&model.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("wishlist_id IN (?) OR shared_by = ? OR shared_with = ?", wishlistIDs, userID, userID).
+			Delete(&model.WishlistShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Wishlist{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Favorite{}).Error; err != nil {
+			return err
+		}
+		cartIDs := tx.Model(&model.Cart{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("cart_id IN (?)", cartIDs).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Cart{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+
+		// Audit entries are kept for accountability, without network identifiers
+		if err := tx.Unscoped().Model(&model.AuditLog{}).
+			Where("user_id = ? OR target_user_id = ?", userID, userID).
+			Updates(map[string]interface{}{"ip_address": "", "user_agent": "", "session_id": ""}).Error; err != nil {
+			return err
+		}
+
+		// Sign-in data
+		if err := tx.Model(&model.Session{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR email = ?", userID, originalEmail).Delete(&model.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.AccountLockout{}).Where("user_id = ?", userID).Update("ip_address", "").Error; err != nil {
+			return err
+		}
+		for _, record := range []interface{}{
+			&model.LoginChallenge{},
+			&model.UserTwoFactor{},
+			&model.TwoFactorRecoveryCode{},
+			&model.UserIdentity{},
+			&model.OAuthState{},
+			&model.APIKey{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.OTP{}).Error; err != nil {
+			return err
+		}
+
+		// The account row stays (orders reference it) but holds nothing personal
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":          model.ErasedUserName(userID),
+			"email":             erasedEmail,
+			"password":          "",
+			"first_name":        "",
+			"last_name":         "",
+			"phone":             "",
+			"avatar":            "",
+			"is_active":         false,
+			"is_email_verified": false,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.User{}, userID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		erasure.Status = model.AccountErasureStatusCompleted
+		erasure.CompletedAt = &now
+		erasure.LastError = ""
+		erasure.Reason = ""
+		return tx.Save(erasure).Error
+	})
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+
+	"go_app/internal/model"
+	"go_app/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+type ProductBundleRepository struct {
+	db *gorm.DB
+}
+
+func NewProductBundleRepository() *ProductBundleRepository {
+	return &ProductBundleRepository{
+		db: database.GetDB(),
+	}
+}
+
+// GetByBundleID gets all components of a bundle product
+func (r *ProductBundleRepository) GetByBundleID(bundleID uint) ([]model.ProductBundleItem, error) {
+	var items []model.ProductBundleItem
+	if err := r.db.Preload("ComponentProduct").Preload("ComponentVariant").
+		Where("bundle_id = ?", bundleID).
+		Order("sort_order ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bundle items: %w", err)
+	}
+	return items, nil
+}
+
+// ReplaceItems replaces all components of a bundle product
+func (r *ProductBundleRepository) ReplaceItems(bundleID uint, items []model.ProductBundleItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundleID).Delete(&model.ProductBundleItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete bundle items: %w", err)
+		}
+
+		for i := range items {
+			items[i].ID = 0
+			items[i].BundleID = bundleID
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return fmt.Errorf("failed to create bundle items: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteByBundleID deletes all components of a bundle product
+func (r *ProductBundleRepository) DeleteByBundleID(bundleID uint) error {
+	if err := r.db.Where("bundle_id = ?", bundleID).Delete(&model.ProductBundleItem{}).Error; err != nil {
+		return fmt.Errorf("failed to delete bundle items: %w", err)
+	}
+	return nil
+}
+
+// ExistsAsComponent checks if a product is used as a component of any bundle
+func (r *ProductBundleRepository) ExistsAsComponent(productID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.ProductBundleItem{}).Where("component_product_id = ?", productID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check bundle components: %w", err)
+	}
+	return count > 0, nil
+}
@@ -1,15 +1,28 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"go_app/configs"
 	"go_app/internal/model"
 	"go_app/pkg/database"
+	"go_app/pkg/logger"
+	"go_app/pkg/redis"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
 type SessionRepository interface {
 	Create(session *model.Session) error
+	CreateWithRefreshToken(session *model.Session, token *model.RefreshToken) error
+	GetByID(id uint) (*model.Session, error)
+	GetValidSession(id uint) (*model.Session, error)
 	GetByToken(token string) (*model.Session, error)
 	GetByUserID(userID uint) ([]*model.Session, error)
 	GetActiveByUserID(userID uint) ([]*model.Session, error)
@@ -20,15 +33,30 @@ type SessionRepository interface {
 	DeleteByUserID(userID uint) error
 	DeleteExpired() error
 	DeactivateAllUserSessions(userID uint) error
+
+	// Refresh tokens
+	GetRefreshTokenByHash(hash string) (*model.RefreshToken, error)
+	RotateRefreshToken(currentID uint, next *model.RefreshToken) error
+	RevokeSession(sessionID uint, reason string) error
+	RevokeAllUserSessions(userID uint, reason string) error
+	RevokeOtherUserSessions(userID, keepSessionID uint, reason string) (int, error)
 }
 
 type sessionRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	cacheTTL time.Duration
+}
+
+// sessionCacheEntry is what AuthMiddleware needs to trust a session without hitting the database
+type sessionCacheEntry struct {
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 func NewSessionRepository() SessionRepository {
 	return &sessionRepository{
-		db: database.GetDB(),
+		db:       database.GetDB(),
+		cacheTTL: time.Duration(configs.Load().Session.CacheSeconds) * time.Second,
 	}
 }
 
@@ -36,6 +64,71 @@ func (r *sessionRepository) Create(session *model.Session) error {
 	return r.db.Create(session).Error
 }
 
+// CreateWithRefreshToken creates a session together with its first refresh token
+func (r *sessionRepository) CreateWithRefreshToken(session *model.Session, token *model.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+func (r *sessionRepository) GetByID(id uint) (*model.Session, error) {
+	var session model.Session
+	err := r.db.First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetValidSession returns an active, unexpired session, or nil if it was revoked or expired. Valid sessions
+// are cached in Redis for a short while; every write that may end a session drops its cache entry. Each database check also
+// records the session's last activity, so last-seen is accurate to the cache lifetime.
+func (r *sessionRepository) GetValidSession(id uint) (*model.Session, error) {
+	ctx := context.Background()
+	key := sessionCacheKey(id)
+
+	if r.cacheEnabled() {
+		var entry sessionCacheEntry
+		if err := redis.Get(ctx, key, &entry); err == nil && time.Now().Before(entry.ExpiresAt) {
+			return &model.Session{ID: id, UserID: entry.UserID, IsActive: true, ExpiresAt: entry.ExpiresAt}, nil
+		}
+	}
+
+	var session model.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !session.IsValid() {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if err := r.db.Model(&session).UpdateColumn("last_seen_at", now).Error; err != nil {
+		logger.Warnf("Failed to update last seen of session %d: %v", id, err)
+	}
+	session.LastSeenAt = &now
+
+	if r.cacheEnabled() {
+		ttl := r.cacheTTL
+		if remaining := time.Until(session.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+		entry := sessionCacheEntry{UserID: session.UserID, ExpiresAt: session.ExpiresAt}
+		if err := redis.Set(ctx, key, entry, ttl); err != nil {
+			logger.Warnf("Failed to cache session %d: %v", id, err)
+		}
+	}
+
+	return &session, nil
+}
+
 func (r *sessionRepository) GetByToken(token string) (*model.Session, error) {
 	var session model.Session
 	err := r.db.Preload("User").Where("token = ?", token).First(&session).Error
@@ -74,19 +167,31 @@ func (r *sessionRepository) GetByDeviceID(userID uint, deviceID string) (*model.
 }
 
 func (r *sessionRepository) Update(session *model.Session) error {
-	return r.db.Save(session).Error
+	if err := r.db.Save(session).Error; err != nil {
+		return err
+	}
+	r.dropCachedSessions([]uint{session.ID})
+	return nil
 }
 
 func (r *sessionRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Session{}, id).Error
+	if err := r.db.Delete(&model.Session{}, id).Error; err != nil {
+		return err
+	}
+	r.dropCachedSessions([]uint{id})
+	return nil
 }
 
 func (r *sessionRepository) DeleteByToken(token string) error {
-	return r.db.Where("token = ?", token).Delete(&model.Session{}).Error
+	return r.endSessionsWhere(func(tx *gorm.DB) error {
+		return tx.Delete(&model.Session{}).Error
+	}, "token = ?", token)
 }
 
 func (r *sessionRepository) DeleteByUserID(userID uint) error {
-	return r.db.Where("user_id = ?", userID).Delete(&model.Session{}).Error
+	return r.endSessionsWhere(func(tx *gorm.DB) error {
+		return tx.Delete(&model.Session{}).Error
+	}, "user_id = ?", userID)
 }
 
 func (r *sessionRepository) DeleteExpired() error {
@@ -94,5 +199,143 @@ func (r *sessionRepository) DeleteExpired() error {
 }
 
 func (r *sessionRepository) DeactivateAllUserSessions(userID uint) error {
-	return r.db.Model(&model.Session{}).Where("user_id = ?", userID).Update("is_active", false).Error
+	return r.endSessionsWhere(func(tx *gorm.DB) error {
+		return tx.Model(&model.Session{}).Update("is_active", false).Error
+	}, "user_id = ?", userID)
+}
+
+// endSessionsWhere runs a write that ends the matching sessions, then drops their cached checks
+func (r *sessionRepository) endSessionsWhere(write func(tx *gorm.DB) error, query string, args ...interface{}) error {
+	var sessionIDs []uint
+	if r.cacheEnabled() {
+		if err := r.db.Model(&model.Session{}).Where(query, args...).Pluck("id", &sessionIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := write(r.db.Where(query, args...)); err != nil {
+		return err
+	}
+	r.dropCachedSessions(sessionIDs)
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token with its session, or nil if the hash is unknown
+func (r *sessionRepository) GetRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Preload("Session").Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken replaces a refresh token with the next one of its session and extends the session.
+// The current token is locked, so two concurrent uses of the same token cannot both succeed; the loser
+// gets ErrRefreshTokenReused.
+func (r *sessionRepository) RotateRefreshToken(currentID uint, next *model.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current model.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, currentID).Error; err != nil {
+			return err
+		}
+		if current.RotatedAt != nil || current.RevokedAt != nil {
+			return ErrRefreshTokenReused
+		}
+
+		next.SessionID = current.SessionID
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"rotated_at":     now,
+			"replaced_by_id": next.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Session{}).
+			Where("id = ?", current.SessionID).
+			Updates(map[string]interface{}{
+				"expires_at":   next.ExpiresAt,
+				"last_seen_at": now,
+			}).Error
+	})
+}
+
+// RevokeSession deactivates a session and revokes every refresh token of its family
+func (r *sessionRepository) RevokeSession(sessionID uint, reason string) error {
+	_, err := r.revoke(reason, "id = ?", sessionID)
+	return err
+}
+
+// RevokeAllUserSessions deactivates every session of a user and revokes their refresh tokens
+func (r *sessionRepository) RevokeAllUserSessions(userID uint, reason string) error {
+	_, err := r.revoke(reason, "user_id = ?", userID)
+	return err
+}
+
+// RevokeOtherUserSessions deactivates every session of a user except one and returns how many were revoked
+func (r *sessionRepository) RevokeOtherUserSessions(userID, keepSessionID uint, reason string) (int, error) {
+	return r.revoke(reason, "user_id = ? AND id <> ?", userID, keepSessionID)
+}
+
+// revoke deactivates the matching sessions with their refresh tokens, then drops their cached checks
+func (r *sessionRepository) revoke(reason string, query string, args ...interface{}) (int, error) {
+	var sessionIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Session{}).Where(query, args...).
+			Where("revoked_at IS NULL").
+			Pluck("id", &sessionIDs).Error; err != nil {
+			return err
+		}
+		if len(sessionIDs) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.Session{}).Where("id IN ?", sessionIDs).Updates(map[string]interface{}{
+			"is_active":     false,
+			"revoked_at":    now,
+			"revoke_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RefreshToken{}).
+			Where("session_id IN ? AND revoked_at IS NULL", sessionIDs).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.dropCachedSessions(sessionIDs)
+	return len(sessionIDs), nil
+}
+
+// dropCachedSessions removes cached validity checks, so the next request re-reads the sessions
+func (r *sessionRepository) dropCachedSessions(sessionIDs []uint) {
+	if !r.cacheEnabled() {
+		return
+	}
+	ctx := context.Background()
+	for _, id := range sessionIDs {
+		if err := redis.Delete(ctx, sessionCacheKey(id)); err != nil {
+			logger.Warnf("Failed to drop cached session %d: %v", id, err)
+		}
+	}
+}
+
+func (r *sessionRepository) cacheEnabled() bool {
+	return r.cacheTTL > 0 && redis.GetClient() != nil
+}
+
+func sessionCacheKey(id uint) string {
+	return fmt.Sprintf("session:%d", id)
 }
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrShipmentQuantityExceeded is returned when a shipment holds more of an item than is left to ship
+	ErrShipmentQuantityExceeded = errors.New("shipment quantity exceeds the quantity left to ship")
+	// ErrShipmentItemNotInOrder is returned when a shipment item belongs to another order
+	ErrShipmentItemNotInOrder = errors.New("shipment item does not belong to the order")
+)
+
+// ShipmentRepository defines methods for interacting with shipment data
+type ShipmentRepository interface {
+	// Shipments
+	CreateShipment(shipment *model.Shipment) error
+	GetShipmentByID(id uint) (*model.Shipment, error)
+	GetShipmentByShippingOrderID(shippingOrderID uint) (*model.Shipment, error)
+	GetShipmentByOrderTrackingID(orderTrackingID uint) (*model.Shipment, error)
+	GetShipmentByTrackingNumber(orderID uint, trackingNumber string) (*model.Shipment, error)
+	GetShipmentsByOrder(orderID uint) ([]model.Shipment, error)
+	UpdateShipment(shipment *model.Shipment) error
+	CreateShipmentItems(items []model.ShipmentItem) error
+	AllocateShipmentItems(shipment *model.Shipment, items []model.ShipmentItem) error
+
+	// Timeline
+	CreateShipmentEvent(event *model.ShipmentEvent) error
+	HasShipmentEvent(shipmentID uint, status, eventCode string, eventTime time.Time) (bool, error)
+}
+
+// shipmentRepository implements ShipmentRepository
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+// NewShipmentRepository creates a new ShipmentRepository
+func NewShipmentRepository() ShipmentRepository {
+	return &shipmentRepository{
+		db: database.DB,
+	}
+}
+
+// CreateShipment creates a shipment together with its items
+func (r *shipmentRepository) CreateShipment(shipment *model.Shipment) error {
+	if err := r.db.Create(shipment).Error; err != nil {
+		return fmt.Errorf("failed to create shipment: %w", err)
+	}
+	return nil
+}
+
+// GetShipmentByID retrieves a shipment by ID
+func (r *shipmentRepository) GetShipmentByID(id uint) (*model.Shipment, error) {
+	return r.findShipment("id = ?", id)
+}
+
+// GetShipmentByShippingOrderID retrieves the shipment booked through a shipping order
+func (r *shipmentRepository) GetShipmentByShippingOrderID(shippingOrderID uint) (*model.Shipment, error) {
+	return r.findShipment("shipping_order_id = ?", shippingOrderID)
+}
+
+// GetShipmentByOrderTrackingID retrieves the shipment followed by an order tracking
+func (r *shipmentRepository) GetShipmentByOrderTrackingID(orderTrackingID uint) (*model.Shipment, error) {
+	return r.findShipment("order_tracking_id = ?", orderTrackingID)
+}
+
+// GetShipmentByTrackingNumber retrieves an order's shipment by its carrier tracking number
+func (r *shipmentRepository) GetShipmentByTrackingNumber(orderID uint, trackingNumber string) (*model.Shipment, error) {
+	return r.findShipment("order_id = ? AND tracking_number = ?", orderID, trackingNumber)
+}
+
+func (r *shipmentRepository) findShipment(query string, args ...interface{}) (*model.Shipment, error) {
+	var shipment model.Shipment
+	if err := r.db.Preload("Items").Where(query, args...).First(&shipment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// GetShipmentsByOrder retrieves all shipments of an order with their items and timeline
+func (r *shipmentRepository) GetShipmentsByOrder(orderID uint) ([]model.Shipment, error) {
+	var shipments []model.Shipment
+	err := r.db.Where("order_id = ?", orderID).
+		Preload("Items.OrderItem").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("event_time DESC, id DESC")
+		}).
+		Order("created_at ASC").
+		Find(&shipments).Error
+	return shipments, err
+}
+
+// UpdateShipment updates a shipment's own columns
+func (r *shipmentRepository) UpdateShipment(shipment *model.Shipment) error {
+	return r.db.Omit("Items", "Events", "Order", "ShippingOrder", "OrderTracking").Save(shipment).Error
+}
+
+// CreateShipmentItems adds items to an existing shipment
+func (r *shipmentRepository) CreateShipmentItems(items []model.ShipmentItem) error {
+	if err := r.db.Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to create shipment items: %w", err)
+	}
+	return nil
+}
+
+// AllocateShipmentItems packs order item quantities into a shipment, creating the shipment when it is new.
+// The order items are locked so that concurrent shipments cannot ship the same quantity twice.
+func (r *shipmentRepository) AllocateShipmentItems(shipment *model.Shipment, items []model.ShipmentItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var orderItems []model.OrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", shipment.OrderID).
+			Find(&orderItems).Error; err != nil {
+			return err
+		}
+
+		byID := make(map[uint]*model.OrderItem, len(orderItems))
+		for i := range orderItems {
+			byID[orderItems[i].ID] = &orderItems[i]
+		}
+
+		for _, item := range items {
+			orderItem, ok := byID[item.OrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: order item %d", ErrShipmentItemNotInOrder, item.OrderItemID)
+			}
+			if item.Quantity > orderItem.ReadyToShipQuantity() {
+				return fmt.Errorf("%w: order item %d has %d left", ErrShipmentQuantityExceeded, item.OrderItemID, orderItem.ReadyToShipQuantity())
+			}
+			// Count the item once when it appears twice in the request
+			orderItem.ShippedQuantity += item.Quantity
+
+			if err := tx.Model(&model.OrderItem{}).Where("id = ?", item.OrderItemID).
+				Update("shipped_quantity", gorm.Expr("shipped_quantity + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+
+		if shipment.ID == 0 {
+			shipment.Items = items
+			return tx.Create(shipment).Error
+		}
+
+		for i := range items {
+			items[i].ShipmentID = shipment.ID
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+// CreateShipmentEvent appends an event to a shipment's timeline
+func (r *shipmentRepository) CreateShipmentEvent(event *model.ShipmentEvent) error {
+	if err := r.db.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create shipment event: %w", err)
+	}
+	return nil
+}
+
+// HasShipmentEvent checks if the timeline already holds the same event, so replayed webhooks and polls are ignored
+func (r *shipmentRepository) HasShipmentEvent(shipmentID uint, status, eventCode string, eventTime time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.ShipmentEvent{}).
+		Where("shipment_id = ? AND status = ? AND event_code = ? AND event_time = ?", shipmentID, status, eventCode, eventTime).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShippingExceptionRepository defines methods for interacting with shipping SLA data
+type ShippingExceptionRepository interface {
+	// Monitoring
+	GetShipmentsForSLA() ([]model.Shipment, error)
+	GetFailedAttempts(shipmentIDs []uint) (map[uint]int, error)
+	SetShipmentDeliveryDue(shipmentID uint, due time.Time) error
+
+	// Exceptions
+	CreateException(exception *model.ShippingException) error
+	UpdateException(exception *model.ShippingException) error
+	GetExceptionByID(id uint) (*model.ShippingException, error)
+	GetUnresolvedExceptions(shipmentIDs []uint) ([]model.ShippingException, error)
+	GetExceptions(page, limit int, filters map[string]interface{}) ([]model.ShippingException, int64, error)
+
+	// Dashboard
+	CountUnresolvedByStatus() (map[string]int64, error)
+	CountUnresolvedByType() ([]model.ShippingExceptionCount, error)
+	CountUnresolvedByCarrier() ([]model.ShippingExceptionCarrierSummary, error)
+	GetOldestUnresolved(limit int) ([]model.ShippingException, error)
+}
+
+// shippingExceptionRepository implements ShippingExceptionRepository
+type shippingExceptionRepository struct {
+	db *gorm.DB
+}
+
+// NewShippingExceptionRepository creates a new ShippingExceptionRepository
+func NewShippingExceptionRepository() ShippingExceptionRepository {
+	return &shippingExceptionRepository{
+		db: database.DB,
+	}
+}
+
+// GetShipmentsForSLA retrieves the shipments the SLA monitor has to look at: those still on the way,
+// delivered ones without a due date yet, and finished ones that still have unresolved exceptions
+func (r *shippingExceptionRepository) GetShipmentsForSLA() ([]model.Shipment, error) {
+	finished := []string{model.TrackingStatusDelivered, model.TrackingStatusReturned, model.TrackingStatusCancelled}
+	unresolved := r.db.Model(&model.ShippingException{}).
+		Select("shipment_id").
+		Where("status <> ?", model.ShippingExceptionStatusResolved)
+
+	var shipments []model.Shipment
+	err := r.db.Preload("ShippingOrder").
+		Preload("OrderTracking").
+		Where("status NOT IN ?", finished).
+		Or("shipped_at IS NOT NULL AND delivery_due IS NULL").
+		Or("id IN (?)", unresolved).
+		Order("id ASC").
+		Find(&shipments).Error
+	return shipments, err
+}
+
+// GetFailedAttempts counts the failed delivery events on the timeline of each shipment
+func (r *shippingExceptionRepository) GetFailedAttempts(shipmentIDs []uint) (map[uint]int, error) {
+	attempts := make(map[uint]int)
+	if len(shipmentIDs) == 0 {
+		return attempts, nil
+	}
+
+	var rows []struct {
+		ShipmentID uint
+		Attempts   int
+	}
+	err := r.db.Model(&model.ShipmentEvent{}).
+		Select("shipment_id, COUNT(*) AS attempts").
+		Where("shipment_id IN ? AND status = ?", shipmentIDs, model.TrackingStatusFailed).
+		Group("shipment_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		attempts[row.ShipmentID] = row.Attempts
+	}
+	return attempts, nil
+}
+
+// SetShipmentDeliveryDue records the SLA delivery due date of a shipment
+func (r *shippingExceptionRepository) SetShipmentDeliveryDue(shipmentID uint, due time.Time) error {
+	return r.db.Model(&model.Shipment{}).
+		Where("id = ? AND delivery_due IS NULL", shipmentID).
+		UpdateColumn("delivery_due", due).Error
+}
+
+// CreateException creates a new shipping exception
+func (r *shippingExceptionRepository) CreateException(exception *model.ShippingException) error {
+	return r.db.Create(exception).Error
+}
+
+// UpdateException updates a shipping exception
+func (r *shippingExceptionRepository) UpdateException(exception *model.ShippingException) error {
+	return r.db.Omit("Order", "Shipment").Save(exception).Error
+}
+
+// GetExceptionByID retrieves a shipping exception by ID
+func (r *shippingExceptionRepository) GetExceptionByID(id uint) (*model.ShippingException, error) {
+	var exception model.ShippingException
+	if err := r.db.Preload("Shipment").First(&exception, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &exception, nil
+}
+
+// GetUnresolvedExceptions retrieves the open and acknowledged exceptions of the given shipments
+func (r *shippingExceptionRepository) GetUnresolvedExceptions(shipmentIDs []uint) ([]model.ShippingException, error) {
+	var exceptions []model.ShippingException
+	if len(shipmentIDs) == 0 {
+		return exceptions, nil
+	}
+	err := r.db.Where("shipment_id IN ? AND status <> ?", shipmentIDs, model.ShippingExceptionStatusResolved).
+		Find(&exceptions).Error
+	return exceptions, err
+}
+
+// GetExceptions retrieves exceptions with pagination and filters; status "unresolved" selects open and acknowledged ones
+func (r *shippingExceptionRepository) GetExceptions(page, limit int, filters map[string]interface{}) ([]model.ShippingException, int64, error) {
+	var exceptions []model.ShippingException
+	var total int64
+	db := r.db.Model(&model.ShippingException{})
+
+	// Apply filters
+	for key, value := range filters {
+		switch key {
+		case "status":
+			if value == "unresolved" {
+				db = db.Where("status <> ?", model.ShippingExceptionStatusResolved)
+			} else {
+				db = db.Where("status = ?", value)
+			}
+		case "type":
+			db = db.Where("type = ?", value)
+		case "carrier_code":
+			db = db.Where("carrier_code = ?", value)
+		case "provider_id":
+			db = db.Where("provider_id = ?", value)
+		case "order_id":
+			db = db.Where("order_id = ?", value)
+		case "date_from":
+			db = db.Where("detected_at >= ?", value)
+		case "date_to":
+			db = db.Where("detected_at <= ?", value)
+		}
+	}
+
+	// Count total records
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Preload("Shipment").Order("detected_at DESC").Find(&exceptions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return exceptions, total, nil
+}
+
+// CountUnresolvedByStatus counts the open and acknowledged exceptions
+func (r *shippingExceptionRepository) CountUnresolvedByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&model.ShippingException{}).
+		Select("status, COUNT(*) AS count").
+		Where("status <> ?", model.ShippingExceptionStatusResolved).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountUnresolvedByType counts the unresolved exceptions of each type
+func (r *shippingExceptionRepository) CountUnresolvedByType() ([]model.ShippingExceptionCount, error) {
+	var counts []model.ShippingExceptionCount
+	err := r.db.Model(&model.ShippingException{}).
+		Select("type, COUNT(*) AS count").
+		Where("status <> ?", model.ShippingExceptionStatusResolved).
+		Group("type").
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountUnresolvedByCarrier breaks the unresolved exceptions down by carrier
+func (r *shippingExceptionRepository) CountUnresolvedByCarrier() ([]model.ShippingExceptionCarrierSummary, error) {
+	var summaries []model.ShippingExceptionCarrierSummary
+	err := r.db.Model(&model.ShippingException{}).
+		Select(`carrier_code, MAX(carrier) AS carrier,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS not_picked_up,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS transit_delayed,
+			SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS delivery_failures,
+			COUNT(*) AS total`,
+			model.ShippingExceptionNotPickedUp, model.ShippingExceptionTransitDelayed, model.ShippingExceptionDeliveryFailures).
+		Where("status <> ?", model.ShippingExceptionStatusResolved).
+		Group("carrier_code").
+		Order("total DESC").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+// GetOldestUnresolved retrieves the longest-standing unresolved exceptions
+func (r *shippingExceptionRepository) GetOldestUnresolved(limit int) ([]model.ShippingException, error) {
+	var exceptions []model.ShippingException
+	err := r.db.Where("status <> ?", model.ShippingExceptionStatusResolved).
+		Order("detected_at ASC").
+		Limit(limit).
+		Find(&exceptions).Error
+	return exceptions, err
+}
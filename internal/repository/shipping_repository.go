@@ -40,6 +40,10 @@ type ShippingRepository interface {
 	GetShippingTrackingByOrderID(orderID uint) ([]model.ShippingTracking, error)
 	GetShippingTrackingByShippingOrderID(shippingOrderID uint) ([]model.ShippingTracking, error)
 
+	// Label Batches
+	CreateShippingLabelBatch(batch *model.ShippingLabelBatch) error
+	GetShippingLabelBatchByID(id uint) (*model.ShippingLabelBatch, error)
+
 	// Statistics
 	GetShippingStats() (*model.ShippingStats, error)
 	GetShippingStatsByProvider(providerID uint) (*model.ShippingStats, error)
@@ -119,14 +123,16 @@ func (r *shippingRepository) GetShippingRatesByProvider(providerID uint) ([]mode
 func (r *shippingRepository) GetShippingRatesForCalculation(req *model.CalculateShippingRequest) ([]model.ShippingRate, error) {
 	var rates []model.ShippingRate
 
+	// Zones set to the wildcard match any province/district
+	wildcard := model.ShippingZoneWildcard
 	query := r.db.Where(`
-		from_province = ? AND from_district = ? AND 
-		to_province = ? AND to_district = ? AND
+		from_province IN (?, ?) AND from_district IN (?, ?) AND 
+		to_province IN (?, ?) AND to_district IN (?, ?) AND
 		? BETWEEN min_weight AND max_weight AND
 		? BETWEEN min_value AND max_value AND
 		is_active = ? AND deleted_at IS NULL`,
-		req.FromProvince, req.FromDistrict,
-		req.ToProvince, req.ToDistrict,
+		req.FromProvince, wildcard, req.FromDistrict, wildcard,
+		req.ToProvince, wildcard, req.ToDistrict, wildcard,
 		req.Weight, req.Value, true)
 
 	if req.ProviderID != nil {
@@ -259,6 +265,20 @@ func (r *shippingRepository) GetShippingTrackingByShippingOrderID(shippingOrderI
 	return tracking, err
 }
 
+// Label Batches
+func (r *shippingRepository) CreateShippingLabelBatch(batch *model.ShippingLabelBatch) error {
+	return r.db.Create(batch).Error
+}
+
+func (r *shippingRepository) GetShippingLabelBatchByID(id uint) (*model.ShippingLabelBatch, error) {
+	var batch model.ShippingLabelBatch
+	err := r.db.Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
 // Statistics
 func (r *shippingRepository) GetShippingStats() (*model.ShippingStats, error) {
 	stats := &model.ShippingStats{}
@@ -287,6 +307,10 @@ func (r *shippingRepository) GetShippingStats() (*model.ShippingStats, error) {
 		stats.SuccessRate = float64(successfulOrders) / float64(totalProcessedOrders) * 100
 	}
 
+	if err := r.applyDeliveryPerformance(stats, r.db.Table("shipments s"), r.db.Model(&model.ShippingException{})); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
@@ -317,5 +341,43 @@ func (r *shippingRepository) GetShippingStatsByProvider(providerID uint) (*model
 		stats.SuccessRate = float64(successfulOrders) / float64(totalProcessedOrders) * 100
 	}
 
+	// On-time deliveries: shipments booked through the provider or sent with its carrier code
+	var provider model.ShippingProvider
+	if err := r.db.Select("id, code").First(&provider, providerID).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	shipments := r.db.Table("shipments s").
+		Joins("LEFT JOIN shipping_orders so ON so.id = s.shipping_order_id").
+		Where("so.provider_id = ? OR (s.shipping_order_id IS NULL AND s.carrier_code <> '' AND s.carrier_code = ?)", providerID, provider.Code)
+	exceptions := r.db.Model(&model.ShippingException{}).Where("provider_id = ?", providerID)
+	if err := r.applyDeliveryPerformance(stats, shipments, exceptions); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
+
+// applyDeliveryPerformance adds the on-time delivery rate of the given shipments and the number
+// of unresolved SLA exceptions to the stats
+func (r *shippingRepository) applyDeliveryPerformance(stats *model.ShippingStats, shipments, exceptions *gorm.DB) error {
+	var performance struct {
+		Measured int64
+		OnTime   int64
+	}
+	err := shipments.
+		Select("COUNT(*) AS measured, COALESCE(SUM(CASE WHEN s.delivered_at <= s.delivery_due THEN 1 ELSE 0 END), 0) AS on_time").
+		Where("s.delivered_at IS NOT NULL AND s.delivery_due IS NOT NULL").
+		Scan(&performance).Error
+	if err != nil {
+		return err
+	}
+
+	stats.MeasuredDeliveries = performance.Measured
+	stats.OnTimeDeliveries = performance.OnTime
+	stats.LateDeliveries = performance.Measured - performance.OnTime
+	if performance.Measured > 0 {
+		stats.OnTimeRate = float64(performance.OnTime) / float64(performance.Measured) * 100
+	}
+
+	return exceptions.Where("status <> ?", model.ShippingExceptionStatusResolved).Count(&stats.OpenExceptions).Error
+}
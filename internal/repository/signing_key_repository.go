@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SigningKeyRepository interface {
+	GetPublishedKeys() ([]*model.SigningKey, error)
+	RotateActiveKey(next *model.SigningKey, dueBefore, retireUntil time.Time, force bool) (bool, error)
+	DeleteExpired() (int64, error)
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository() SigningKeyRepository {
+	return &signingKeyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// GetPublishedKeys returns the active key and the retired keys still accepted for verification, newest first
+func (r *signingKeyRepository) GetPublishedKeys() ([]*model.SigningKey, error) {
+	var keys []*model.SigningKey
+	err := r.db.Where("status = ? OR expires_at > ?", model.SigningKeyStatusActive, time.Now()).
+		Order("created_at DESC, id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// RotateActiveKey retires the active key(s) and stores next as the new signing key. Unless force is
+// set, nothing happens when the newest active key was created after dueBefore, so several app
+// instances running the rotation at the same time only rotate once.
+func (r *signingKeyRepository) RotateActiveKey(next *model.SigningKey, dueBefore, retireUntil time.Time, force bool) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var active []model.SigningKey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", model.SigningKeyStatusActive).
+			Order("created_at DESC, id DESC").
+			Find(&active).Error; err != nil {
+			return err
+		}
+		if !force && len(active) > 0 && active[0].CreatedAt.After(dueBefore) {
+			return nil
+		}
+
+		if len(active) > 0 {
+			ids := make([]uint, len(active))
+			for i, key := range active {
+				ids[i] = key.ID
+			}
+			if err := tx.Model(&model.SigningKey{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":     model.SigningKeyStatusRetired,
+				"retired_at": time.Now(),
+				"expires_at": retireUntil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		next.Status = model.SigningKeyStatusActive
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// DeleteExpired removes retired keys that are no longer published
+func (r *signingKeyRepository) DeleteExpired() (int64, error) {
+	result := r.db.Where("status = ? AND expires_at < ?", model.SigningKeyStatusRetired, time.Now()).
+		Delete(&model.SigningKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStocktakeNotInReview is returned when a stocktake left review before its approval was applied
+var ErrStocktakeNotInReview = errors.New("stocktake is not in review")
+
+// StocktakeRepository defines methods for interacting with stocktake data
+type StocktakeRepository interface {
+	// Stocktake sessions
+	CreateStocktake(stocktake *model.Stocktake) error
+	GetStocktakeByID(id uint) (*model.Stocktake, error)
+	GetStocktakes(page, limit int, filters map[string]interface{}) ([]model.Stocktake, int64, error)
+	UpdateStocktake(stocktake *model.Stocktake) error
+	CountStocktakesByDate(date time.Time) (int64, error)
+
+	// Scope and items
+	GetStockLevelsForScope(categoryID *uint, location string) ([]model.StockLevel, error)
+	FreezeStocktake(stocktake *model.Stocktake, items []model.StocktakeItem) error
+	GetItemsByStocktake(stocktakeID uint) ([]model.StocktakeItem, error)
+	UpdateItems(items []model.StocktakeItem) error
+
+	// Approval
+	ApproveStocktake(stocktake *model.Stocktake, approvedBy uint) ([]model.InventoryAdjustment, error)
+}
+
+// stocktakeRepository implements StocktakeRepository
+type stocktakeRepository struct {
+	db *gorm.DB
+}
+
+// NewStocktakeRepository creates a new StocktakeRepository
+func NewStocktakeRepository() StocktakeRepository {
+	return &stocktakeRepository{
+		db: database.DB,
+	}
+}
+
+// Stocktake sessions
+
+// CreateStocktake creates a new stocktake session
+func (r *stocktakeRepository) CreateStocktake(stocktake *model.Stocktake) error {
+	return r.db.Create(stocktake).Error
+}
+
+// GetStocktakeByID retrieves a stocktake session with its items
+func (r *stocktakeRepository) GetStocktakeByID(id uint) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := r.db.Preload("Category").Preload("CreatedByUser").Preload("ApprovedByUser").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sku ASC") }).
+		Preload("Items.Product").Preload("Items.Variant").
+		First(&stocktake, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+// GetStocktakes retrieves stocktake sessions with pagination and filters
+func (r *stocktakeRepository) GetStocktakes(page, limit int, filters map[string]interface{}) ([]model.Stocktake, int64, error) {
+	var stocktakes []model.Stocktake
+	var total int64
+	db := r.db.Model(&model.Stocktake{}).Preload("Category").Preload("CreatedByUser").Preload("ApprovedByUser").Preload("Items")
+
+	// Apply filters
+	for key, value := range filters {
+		switch key {
+		case "status":
+			db = db.Where("status = ?", value)
+		case "category_id":
+			db = db.Where("category_id = ?", value)
+		case "location":
+			db = db.Where("location = ?", value)
+		case "created_by":
+			db = db.Where("created_by = ?", value)
+		case "date_from":
+			db = db.Where("created_at >= ?", value)
+		case "date_to":
+			db = db.Where("created_at <= ?", value)
+		}
+	}
+
+	// Count total records
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if page > 0 && limit > 0 {
+		offset := (page - 1) * limit
+		db = db.Offset(offset).Limit(limit)
+	}
+
+	if err := db.Order("created_at DESC").Find(&stocktakes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return stocktakes, total, nil
+}
+
+// UpdateStocktake updates a stocktake session (without touching its items)
+func (r *stocktakeRepository) UpdateStocktake(stocktake *model.Stocktake) error {
+	return r.db.Omit(clause.Associations).Save(stocktake).Error
+}
+
+// CountStocktakesByDate counts stocktake sessions created on the given day, used for code generation
+func (r *stocktakeRepository) CountStocktakesByDate(date time.Time) (int64, error) {
+	var count int64
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	err := r.db.Unscoped().Model(&model.Stocktake{}).
+		Where("created_at >= ? AND created_at < ?", start, start.AddDate(0, 0, 1)).
+		Count(&count).Error
+	return count, err
+}
+
+// Scope and items
+
+// GetStockLevelsForScope retrieves stock levels matching a category and/or location
+func (r *stocktakeRepository) GetStockLevelsForScope(categoryID *uint, location string) ([]model.StockLevel, error) {
+	var stockLevels []model.StockLevel
+	db := r.db.Model(&model.StockLevel{}).Preload("Product").Preload("Variant")
+
+	if categoryID != nil {
+		db = db.Joins("JOIN products ON products.id = stock_levels.product_id AND products.deleted_at IS NULL").
+			Where("products.category_id = ?", *categoryID)
+	}
+	if location != "" {
+		db = db.Where("stock_levels.location = ?", location)
+	}
+
+	err := db.Order("stock_levels.product_id ASC, stock_levels.variant_id ASC").Find(&stockLevels).Error
+	return stockLevels, err
+}
+
+// FreezeStocktake stores the expected quantities snapshot and moves the session into counting
+func (r *stocktakeRepository) FreezeStocktake(stocktake *model.Stocktake, items []model.StocktakeItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, 200).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		stocktake.Status = model.StocktakeStatusCounting
+		stocktake.FrozenAt = &now
+		return tx.Model(&model.Stocktake{}).Where("id = ?", stocktake.ID).Updates(map[string]interface{}{
+			"status":    stocktake.Status,
+			"frozen_at": stocktake.FrozenAt,
+		}).Error
+	})
+}
+
+// GetItemsByStocktake retrieves all items of a stocktake session
+func (r *stocktakeRepository) GetItemsByStocktake(stocktakeID uint) ([]model.StocktakeItem, error) {
+	var items []model.StocktakeItem
+	err := r.db.Where("stocktake_id = ?", stocktakeID).Preload("Product").Preload("Variant").Order("sku ASC").Find(&items).Error
+	return items, err
+}
+
+// UpdateItems saves counted quantities for a batch of items
+func (r *stocktakeRepository) UpdateItems(items []model.StocktakeItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			if err := tx.Model(&model.StocktakeItem{}).Where("id = ?", items[i].ID).Updates(map[string]interface{}{
+				"counted_quantity": items[i].CountedQuantity,
+				"variance":         items[i].Variance,
+				"notes":            items[i].Notes,
+				"counted_by":       items[i].CountedBy,
+				"counted_at":       items[i].CountedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Approval
+
+// ApproveStocktake applies all counted variances to stock levels in one transaction.
+// For every counted item with a non-zero variance it records an InventoryAdjustment and a
+// completed adjustment InventoryMovement referencing the stocktake code. The variance is
+// applied on top of the current on-hand stock so movements made while counting are preserved;
+// reserved units come out of the counted stock first, and any shortfall is noted on the item.
+func (r *stocktakeRepository) ApproveStocktake(stocktake *model.Stocktake, approvedBy uint) ([]model.InventoryAdjustment, error) {
+	var adjustments []model.InventoryAdjustment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		reason := fmt.Sprintf("Stocktake %s", stocktake.Code)
+
+		// Claim the approval first so a concurrent approval cannot apply the variances twice
+		result := tx.Model(&model.Stocktake{}).
+			Where("id = ? AND status = ?", stocktake.ID, model.StocktakeStatusReview).
+			Updates(map[string]interface{}{
+				"status":      model.StocktakeStatusApproved,
+				"approved_by": approvedBy,
+				"approved_at": &now,
+				"notes":       stocktake.Notes,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStocktakeNotInReview
+		}
+
+		for _, item := range stocktake.Items {
+			if !item.IsCounted() || item.Variance == 0 {
+				continue
+			}
+
+			var stockLevel model.StockLevel
+			db := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ?", item.ProductID)
+			if item.VariantID != nil {
+				db = db.Where("variant_id = ?", *item.VariantID)
+			} else {
+				db = db.Where("variant_id IS NULL")
+			}
+			if err := db.First(&stockLevel).Error; err != nil {
+				return fmt.Errorf("stock level for SKU %s: %w", item.SKU, err)
+			}
+
+			onHand := stockLevel.AvailableQuantity + stockLevel.ReservedQuantity + item.Variance
+			if onHand < 0 {
+				onHand = 0
+			}
+			quantityAfter := onHand - stockLevel.ReservedQuantity
+			notes := item.Notes
+			if quantityAfter < 0 {
+				shortfall := fmt.Sprintf("Reserved quantity exceeds counted stock by %d", -quantityAfter)
+				notes = strings.TrimSpace(notes + "\n" + shortfall)
+				if err := tx.Model(&model.StocktakeItem{}).Where("id = ?", item.ID).Update("notes", notes).Error; err != nil {
+					return err
+				}
+				quantityAfter = 0
+			}
+
+			adjustment := model.InventoryAdjustment{
+				ProductID:      item.ProductID,
+				VariantID:      item.VariantID,
+				Reason:         reason,
+				QuantityBefore: stockLevel.AvailableQuantity,
+				QuantityAfter:  quantityAfter,
+				QuantityDiff:   quantityAfter - stockLevel.AvailableQuantity,
+				Notes:          notes,
+				CreatedBy:      approvedBy,
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return err
+			}
+
+			// Adjustment movements carry the resulting quantity, matching ProcessStockMovement
+			movement := model.InventoryMovement{
+				ProductID:     item.ProductID,
+				VariantID:     item.VariantID,
+				Type:          model.MovementTypeAdjustment,
+				Status:        model.MovementStatusCompleted,
+				Quantity:      quantityAfter,
+				Reference:     stocktake.Code,
+				ReferenceType: "stocktake",
+				Notes:         fmt.Sprintf("Expected %d, counted %d, variance %+d", item.ExpectedQuantity, *item.CountedQuantity, item.Variance),
+				CreatedBy:     approvedBy,
+				ApprovedBy:    &approvedBy,
+				ApprovedAt:    &now,
+				CompletedAt:   &now,
+			}
+			if err := tx.Create(&movement).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&model.StockLevel{}).Where("id = ?", stockLevel.ID).Updates(map[string]interface{}{
+				"available_quantity": quantityAfter,
+				"total_quantity":     onHand,
+				"last_movement_at":   &now,
+			}).Error; err != nil {
+				return err
+			}
+
+			adjustments = append(adjustments, adjustment)
+		}
+
+		stocktake.Status = model.StocktakeStatusApproved
+		stocktake.ApprovedBy = &approvedBy
+		stocktake.ApprovedAt = &now
+		return nil
+	})
+	return adjustments, err
+}
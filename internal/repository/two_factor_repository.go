@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"go_app/internal/model"
+	"go_app/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	GetByUserID(userID uint) (*model.UserTwoFactor, error)
+	Save(twoFactor *model.UserTwoFactor) error
+	Enable(userID uint, step int64, codeHashes []string) error
+	Disable(userID uint) error
+	ConsumeStep(userID uint, step int64) (bool, error)
+
+	// Recovery codes
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uint) (int64, error)
+
+	// Login challenges
+	CreateChallenge(challenge *model.LoginChallenge) error
+	GetChallengeByHash(hash string) (*model.LoginChallenge, error)
+	IncrementChallengeAttempts(id uint) error
+	CompleteChallenge(id uint) (bool, error)
+	DeleteExpiredChallenges() error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository() TwoFactorRepository {
+	return &twoFactorRepository{
+		db: database.GetDB(),
+	}
+}
+
+func (r *twoFactorRepository) GetByUserID(userID uint) (*model.UserTwoFactor, error) {
+	var twoFactor model.UserTwoFactor
+	err := r.db.Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *twoFactorRepository) Save(twoFactor *model.UserTwoFactor) error {
+	return r.db.Save(twoFactor).Error
+}
+
+// Enable turns on 2FA after the first code was confirmed and stores a fresh set of recovery codes
+func (r *twoFactorRepository) Enable(userID uint, step int64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.UserTwoFactor{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"enabled":        true,
+			"enabled_at":     now,
+			"last_used_step": step,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// Disable removes the enrollment together with its recovery codes
+func (r *twoFactorRepository) Disable(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserTwoFactor{}).Error
+	})
+}
+
+// ConsumeStep records the TOTP time step as used; false means the code was already used
+func (r *twoFactorRepository) ConsumeStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&model.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.TwoFactorRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]model.TwoFactorRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = model.TwoFactorRecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused recovery code as used; false means no such unused code
+func (r *twoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := r.db.Model(&model.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepository) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *twoFactorRepository) CreateChallenge(challenge *model.LoginChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *twoFactorRepository) GetChallengeByHash(hash string) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	err := r.db.Where("token_hash = ?", hash).First(&challenge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *twoFactorRepository) IncrementChallengeAttempts(id uint) error {
+	return r.db.Model(&model.LoginChallenge{}).Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// CompleteChallenge closes the challenge; false means it was already completed by a concurrent request
+func (r *twoFactorRepository) CompleteChallenge(id uint) (bool, error) {
+	result := r.db.Model(&model.LoginChallenge{}).
+		Where("id = ? AND completed_at IS NULL", id).
+		Update("completed_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *twoFactorRepository) DeleteExpiredChallenges() error {
+	return r.db.Where("expires_at < ?", time.Now().Add(-24*time.Hour)).Delete(&model.LoginChallenge{}).Error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"go_app/internal/model"
 	"go_app/pkg/database"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -38,6 +39,9 @@ func (r *userRepository) GetByID(id uint) (*model.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadGrantedRoles(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -47,6 +51,9 @@ func (r *userRepository) GetByEmail(email string) (*model.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadGrantedRoles(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -56,9 +63,22 @@ func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadGrantedRoles(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
+// loadGrantedRoles loads the roles granted to the user for a period of time that are in effect now
+func (r *userRepository) loadGrantedRoles(user *model.User) error {
+	now := time.Now()
+	return r.db.Table("roles").
+		Joins("JOIN user_role_grants ON user_role_grants.role_id = roles.id").
+		Where("user_role_grants.user_id = ? AND roles.is_active = ? AND roles.deleted_at IS NULL", user.ID, true).
+		Where(activeRoleGrantCondition, now, now).
+		Find(&user.GrantedRoles).Error
+}
+
 func (r *userRepository) Update(user *model.User) error {
 	return r.db.Save(user).Error
 }
@@ -9,7 +9,6 @@ import (
 	"go_app/pkg/middleware"
 	"go_app/pkg/payment"
 	"go_app/pkg/ratelimit"
-	"go_app/pkg/shipping"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,14 +28,23 @@ func SetupRoutes(r *gin.Engine) {
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler()
+	jwksHandler := handler.NewJWKSHandler()
+	mockOIDCHandler := handler.NewMockOIDCHandler()
+	privacyHandler := handler.NewPrivacyHandler()
+	apiKeyHandler := handler.NewAPIKeyHandler()
+	impersonationHandler := handler.NewImpersonationHandler()
 	brandHandler := handler.NewBrandHandler()
 	categoryHandler := handler.NewCategoryHandler()
 	productHandler := handler.NewProductHandler()
 	uploadHandler := handler.NewUploadHandler()
 	inventoryHandler := handler.NewInventoryHandler()
+	stocktakeHandler := handler.NewStocktakeHandler()
 	permissionHandler := handler.NewPermissionHandler()
 	orderHandler := handler.NewOrderHandler()
 	addressHandler := handler.NewAddressHandler()
+	locationHandler := handler.NewLocationHandler()
+	pickupLocationHandler := handler.NewPickupLocationHandler()
+	deliverySlotHandler := handler.NewDeliverySlotHandler()
 	reviewHandler := handler.NewReviewHandler()
 	couponHandler := handler.NewCouponHandler()
 	bannerHandler := handler.NewBannerHandler()
@@ -63,15 +71,11 @@ func SetupRoutes(r *gin.Engine) {
 
 	// Initialize shipping service
 	shippingRepo := repository.NewShippingRepository(database.GetDB())
-	ghtkConfig := shipping.GHTKConfig{
-		BaseURL:    "https://services.ghtk.vn",
-		Token:      "YOUR_GHTK_TOKEN", // TODO: Get from config
-		ShopID:     "YOUR_SHOP_ID",    // TODO: Get from config
-		Timeout:    30,
-		IsTestMode: false,
-	}
-	shippingService := service.NewShippingService(shippingRepo, repository.NewOrderRepository(), ghtkConfig)
+	shippingService := service.NewShippingService(shippingRepo, repository.NewOrderRepository(), service.GHTKConfigFromApp())
 	shippingHandler := handler.NewShippingHandler(shippingService)
+	codRemittanceHandler := handler.NewCODRemittanceHandler()
+	shippingExceptionHandler := handler.NewShippingExceptionHandler()
+	loginSecurityHandler := handler.NewLoginSecurityHandler()
 
 	// Initialize rate limit service
 	rateLimitRepo := repository.NewRateLimitRepository(database.GetDB())
@@ -110,6 +114,14 @@ func SetupRoutes(r *gin.Engine) {
 
 	authMiddleware := middleware.NewAuthMiddleware()
 
+	// Public keys for verifying access tokens in other services
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	// Local mock OpenID Connect issuer for developing social login (OAUTH_MOCK_ENABLED, never in release mode)
+	if mockOIDCHandler != nil {
+		r.Any("/oauth/mock/*path", mockOIDCHandler.Serve)
+	}
+
 	// API v1 group
 	v1 := r.Group("/api/v1")
 	{
@@ -118,9 +130,21 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			auth.POST("/register", ratelimit.IPBasedRateLimit(3, time.Minute), authHandler.Register)            // 3 registrations per minute
 			auth.POST("/login", ratelimit.IPBasedRateLimit(5, time.Minute), authHandler.Login)                  // 5 login attempts per minute
+			auth.POST("/refresh", ratelimit.IPBasedRateLimit(30, time.Minute), authHandler.Refresh)             // 30 token refreshes per minute
 			auth.POST("/forgot-password", ratelimit.IPBasedRateLimit(3, time.Hour), authHandler.ForgotPassword) // 3 password resets per hour
 			auth.POST("/reset-password", ratelimit.IPBasedRateLimit(3, time.Hour), authHandler.ResetPassword)
 			auth.POST("/verify-email", ratelimit.IPBasedRateLimit(5, time.Minute), authHandler.VerifyEmail)
+
+			// Second login step (challenge token from /login)
+			auth.POST("/2fa/verify", ratelimit.IPBasedRateLimit(10, time.Minute), authHandler.VerifyTwoFactor)
+			auth.POST("/2fa/challenge/setup", ratelimit.IPBasedRateLimit(5, time.Minute), authHandler.SetupTwoFactorChallenge)
+			auth.POST("/2fa/challenge/email", ratelimit.IPBasedRateLimit(3, time.Minute), authHandler.SendTwoFactorEmail) // 3 email codes per minute
+
+			// Social login (authorization code + PKCE)
+			auth.GET("/oauth/providers", authHandler.GetOAuthProviders)
+			auth.POST("/oauth/:provider/authorize", ratelimit.IPBasedRateLimit(10, time.Minute), authHandler.AuthorizeOAuth)
+			auth.GET("/oauth/:provider/callback", ratelimit.IPBasedRateLimit(10, time.Minute), authHandler.OAuthCallback)
+			auth.POST("/oauth/:provider/callback", ratelimit.IPBasedRateLimit(10, time.Minute), authHandler.OAuthCallback)
 		}
 
 		// Brand routes (public for reading, protected for writing)
@@ -171,6 +195,9 @@ func SetupRoutes(r *gin.Engine) {
 			// Product Variants - Public routes (no authentication required)
 			products.GET("/:product_id/variants", ratelimit.IPBasedRateLimit(200, time.Hour), productHandler.GetProductVariants)
 			products.GET("/:product_id/variants/:variant_id", ratelimit.IPBasedRateLimit(200, time.Hour), productHandler.GetProductVariant)
+
+			// Product Bundles - Public routes (no authentication required)
+			products.GET("/:product_id/bundle-items", ratelimit.IPBasedRateLimit(200, time.Hour), productHandler.GetBundleItems)
 		}
 
 		// Upload routes (public for reading, protected for writing)
@@ -220,6 +247,29 @@ func SetupRoutes(r *gin.Engine) {
 			addresses.GET("/stats/city", addressHandler.GetAddressStatsByCity)
 		}
 
+		// Administrative directory routes (public cascading lookups)
+		locations := v1.Group("/locations")
+		{
+			locations.GET("/provinces", locationHandler.GetProvinces)
+			locations.GET("/provinces/:code/districts", locationHandler.GetDistricts)
+			locations.GET("/districts/:code/wards", locationHandler.GetWards)
+			locations.GET("/resolve", locationHandler.Resolve)
+		}
+
+		// Pickup location routes (public store locator for click-and-collect)
+		pickupLocations := v1.Group("/pickup-locations")
+		{
+			pickupLocations.GET("", pickupLocationHandler.GetPickupLocations)
+			pickupLocations.GET("/nearest", pickupLocationHandler.GetNearestPickupLocations)
+			pickupLocations.GET("/:id", pickupLocationHandler.GetPickupLocationByID)
+		}
+
+		// Delivery slot routes (public availability for same-city delivery)
+		deliverySlots := v1.Group("/delivery-slots")
+		{
+			deliverySlots.GET("/availability", deliverySlotHandler.GetAvailability)
+		}
+
 		// Review routes (public for reading, protected for writing)
 		reviews := v1.Group("/reviews")
 		{
@@ -303,11 +353,53 @@ func SetupRoutes(r *gin.Engine) {
 		protected := v1.Group("/")
 		protected.Use(authMiddleware.AuthMiddleware())
 		{
-			// Auth protected routes
+			// Current user's profile, also shown to a support agent acting as the customer
+			protected.GET("/auth/profile", middleware.APIKeyScopeMiddleware(model.ResourceTypeUser, model.PermissionTypeRead), authHandler.GetProfile)
+
+			// Auth protected routes (account management needs the account holder's own session,
+			// not an API key or an impersonation token)
 			authProtected := protected.Group("/auth")
+			authProtected.Use(authMiddleware.SessionOnlyMiddleware())
 			{
-				authProtected.GET("/profile", authHandler.GetProfile)
+				authProtected.PUT("/profile", authHandler.UpdateProfile)
+				authProtected.POST("/change-password", authHandler.ChangePassword)
 				authProtected.POST("/logout", authHandler.Logout)
+				authProtected.GET("/sessions", authHandler.GetSessions)
+				authProtected.DELETE("/sessions", authHandler.RevokeOtherSessions)
+				authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
+
+				// Two-factor authentication
+				authProtected.GET("/2fa", authHandler.GetTwoFactorStatus)
+				authProtected.POST("/2fa/setup", authHandler.BeginTwoFactorSetup)
+				authProtected.POST("/2fa/enable", authHandler.EnableTwoFactor)
+				authProtected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+				authProtected.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+				// Linked social accounts
+				authProtected.POST("/oauth/:provider/link", authHandler.LinkOAuth)
+				authProtected.GET("/identities", authHandler.GetIdentities)
+				authProtected.DELETE("/identities/:id", authHandler.UnlinkIdentity)
+
+				// Personal data (Decree 13/2023): export and account deletion
+				authProtected.POST("/privacy/exports", privacyHandler.RequestDataExport)
+				authProtected.GET("/privacy/exports", privacyHandler.GetDataExports)
+				authProtected.GET("/privacy/exports/:id/download", privacyHandler.DownloadDataExport)
+				authProtected.POST("/privacy/account-deletion", privacyHandler.RequestAccountDeletion)
+				authProtected.GET("/privacy/account-deletion", privacyHandler.GetAccountDeletion)
+				authProtected.DELETE("/privacy/account-deletion", privacyHandler.CancelAccountDeletion)
+
+				// API keys for integrations (ERP, marketplace connectors)
+				authProtected.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+				authProtected.GET("/api-keys", apiKeyHandler.GetAPIKeys)
+				authProtected.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+			}
+
+			// Support impersonation ("login as customer")
+			impersonation := protected.Group("/impersonation")
+			{
+				impersonation.POST("/users/:id", authMiddleware.SessionOnlyMiddleware(), middleware.PermissionMiddleware(model.ResourceTypeCustomer, model.PermissionTypeImpersonate), impersonationHandler.StartImpersonation)
+				impersonation.GET("", middleware.APIKeyScopeMiddleware(model.ResourceTypeCustomer, model.PermissionTypeImpersonate), impersonationHandler.GetImpersonation)
+				impersonation.DELETE("", middleware.APIKeyScopeMiddleware(model.ResourceTypeCustomer, model.PermissionTypeImpersonate), impersonationHandler.StopImpersonation)
 			}
 
 			// Brand management routes (require authentication and permissions)
@@ -364,6 +456,9 @@ func SetupRoutes(r *gin.Engine) {
 				productManagement.DELETE("/:product_id/variants/:variant_id", middleware.DeletePermissionMiddleware(model.ResourceTypeProduct), productHandler.DeleteProductVariant)
 				productManagement.PATCH("/:product_id/variants/:variant_id/stock", middleware.WritePermissionMiddleware(model.ResourceTypeProduct), productHandler.UpdateProductVariantStock)
 				productManagement.PATCH("/:product_id/variants/:variant_id/status", middleware.WritePermissionMiddleware(model.ResourceTypeProduct), productHandler.UpdateProductVariantStatus)
+
+				// Product Bundles - requires write permission
+				productManagement.PUT("/:product_id/bundle-items", middleware.WritePermissionMiddleware(model.ResourceTypeProduct), productHandler.UpdateBundleItems)
 			}
 
 			// Upload management routes (require authentication and permissions)
@@ -397,10 +492,10 @@ func SetupRoutes(r *gin.Engine) {
 				inventoryManagement.PUT("/movements/:id", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.UpdateMovement)
 				// Delete movement - requires delete permission
 				inventoryManagement.DELETE("/movements/:id", middleware.DeletePermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.DeleteMovement)
-				// Approve movement - requires manage permission
-				inventoryManagement.PATCH("/movements/:id/approve", middleware.ManagePermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.ApproveMovement)
-				// Complete movement - requires manage permission
-				inventoryManagement.PATCH("/movements/:id/complete", middleware.ManagePermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.CompleteMovement)
+				// Approve movement - requires manage permission (conditions checked against the movement amount)
+				inventoryManagement.PATCH("/movements/:id/approve", middleware.AttributePermissionMiddleware(model.ResourceTypeInventory, model.PermissionTypeManage), inventoryHandler.ApproveMovement)
+				// Complete movement - requires manage permission (conditions checked against the movement amount)
+				inventoryManagement.PATCH("/movements/:id/complete", middleware.AttributePermissionMiddleware(model.ResourceTypeInventory, model.PermissionTypeManage), inventoryHandler.CompleteMovement)
 				// Get movements by product - requires read permission
 				inventoryManagement.GET("/movements/product/:product_id", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetMovementsByProduct)
 				// Get movements by reference - requires read permission
@@ -427,6 +522,40 @@ func SetupRoutes(r *gin.Engine) {
 				// Reports
 				// Get movement stats - requires read permission
 				inventoryManagement.GET("/movements/stats", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetMovementStats)
+
+				// Inventory Lots
+				// Get lots - requires read permission
+				inventoryManagement.GET("/lots", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetLots)
+				// Get FEFO pick plan - requires read permission
+				inventoryManagement.GET("/lots/pick-plan", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetPickPlan)
+				// Get stock-by-lot report - requires read permission
+				inventoryManagement.GET("/lots/report", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetLotStockReport)
+				// Run near-expiry alerts - requires manage permission
+				inventoryManagement.POST("/lots/expiry-alerts", middleware.ManagePermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.CheckNearExpiryLots)
+				// Get lot by ID - requires read permission
+				inventoryManagement.GET("/lots/:id", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), inventoryHandler.GetLotByID)
+
+				// Stocktakes (cycle counts)
+				// Create stocktake - requires write permission
+				inventoryManagement.POST("/stocktakes", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.CreateStocktake)
+				// Get stocktakes - requires read permission
+				inventoryManagement.GET("/stocktakes", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.GetStocktakes)
+				// Get stocktake by ID - requires read permission
+				inventoryManagement.GET("/stocktakes/:id", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.GetStocktakeByID)
+				// Start stocktake (freeze expected quantities) - requires write permission
+				inventoryManagement.POST("/stocktakes/:id/start", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.StartStocktake)
+				// Submit counts - requires write permission
+				inventoryManagement.POST("/stocktakes/:id/counts", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.SubmitCounts)
+				// Upload counts from CSV - requires write permission
+				inventoryManagement.POST("/stocktakes/:id/counts/upload", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.UploadCounts)
+				// Submit for review - requires write permission
+				inventoryManagement.POST("/stocktakes/:id/submit", middleware.WritePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.SubmitForReview)
+				// Get variance report - requires read permission
+				inventoryManagement.GET("/stocktakes/:id/variances", middleware.ReadPermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.GetVarianceReport)
+				// Approve stocktake - requires manage permission
+				inventoryManagement.POST("/stocktakes/:id/approve", middleware.ManagePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.ApproveStocktake)
+				// Cancel stocktake - requires manage permission
+				inventoryManagement.POST("/stocktakes/:id/cancel", middleware.ManagePermissionMiddleware(model.ResourceTypeInventory), stocktakeHandler.CancelStocktake)
 			}
 
 			// Admin routes (require admin role and system permissions)
@@ -441,6 +570,19 @@ func SetupRoutes(r *gin.Engine) {
 				admin.GET("/system", middleware.AdminPermissionMiddleware(model.ResourceTypeSystem), func(c *gin.Context) {
 					c.JSON(200, gin.H{"message": "System management endpoint"})
 				})
+
+				// Login security - locked accounts and login history
+				admin.GET("/locked-accounts", middleware.ReadPermissionMiddleware(model.ResourceTypeUser), loginSecurityHandler.GetLockedAccounts)
+				admin.POST("/users/:id/unlock", middleware.ManagePermissionMiddleware(model.ResourceTypeUser), loginSecurityHandler.UnlockAccount)
+				admin.GET("/users/:id/login-attempts", middleware.ReadPermissionMiddleware(model.ResourceTypeUser), loginSecurityHandler.GetLoginAttempts)
+
+				// API keys of all users
+				admin.GET("/api-keys", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), apiKeyHandler.GetAllAPIKeys)
+				admin.DELETE("/api-keys/:id", middleware.ManagePermissionMiddleware(model.ResourceTypeSystem), apiKeyHandler.AdminRevokeAPIKey)
+
+				// Support impersonations
+				admin.GET("/impersonations", middleware.ReadPermissionMiddleware(model.ResourceTypeAudit), impersonationHandler.GetImpersonations)
+				admin.DELETE("/impersonations/:id", middleware.ManagePermissionMiddleware(model.ResourceTypeUser), impersonationHandler.RevokeImpersonation)
 			}
 
 			// Permission management routes (require authentication and system permissions)
@@ -468,6 +610,7 @@ func SetupRoutes(r *gin.Engine) {
 				permissionManagement.DELETE("/roles/:role_id/permissions/:permission_id", middleware.ManagePermissionMiddleware(model.ResourceTypeSystem), permissionHandler.RevokePermissionFromRole)
 				permissionManagement.GET("/roles/:role_id/permissions", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), permissionHandler.GetRolePermissions)
 				permissionManagement.PUT("/roles/:role_id/permissions", middleware.ManagePermissionMiddleware(model.ResourceTypeSystem), permissionHandler.UpdateRolePermissions)
+				permissionManagement.PUT("/roles/:role_id/permissions/:permission_id/conditions", middleware.ManagePermissionMiddleware(model.ResourceTypeSystem), permissionHandler.UpdateRolePermissionConditions)
 
 				// User Permissions - require user manage permission
 				permissionManagement.POST("/users/:user_id/permissions", middleware.ManagePermissionMiddleware(model.ResourceTypeUser), permissionHandler.AssignPermissionToUser)
@@ -476,6 +619,11 @@ func SetupRoutes(r *gin.Engine) {
 				permissionManagement.GET("/users/:user_id/effective-permissions", middleware.ReadPermissionMiddleware(model.ResourceTypeUser), permissionHandler.GetUserEffectivePermissions)
 				permissionManagement.GET("/users/:user_id/permissions/resource/:resource", middleware.ReadPermissionMiddleware(model.ResourceTypeUser), permissionHandler.GetUserPermissionsForResource)
 
+				// Time-bound role grants - require user manage permission
+				permissionManagement.POST("/users/:user_id/role-grants", middleware.ManagePermissionMiddleware(model.ResourceTypeUser), permissionHandler.GrantRoleToUser)
+				permissionManagement.GET("/users/:user_id/role-grants", middleware.ReadPermissionMiddleware(model.ResourceTypeUser), permissionHandler.GetUserRoleGrants)
+				permissionManagement.DELETE("/users/:user_id/role-grants/:grant_id", middleware.ManagePermissionMiddleware(model.ResourceTypeUser), permissionHandler.RevokeUserRoleGrant)
+
 				// Audit & Logging - require system read permission
 				permissionManagement.GET("/logs", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), permissionHandler.GetPermissionLogs)
 
@@ -503,6 +651,11 @@ func SetupRoutes(r *gin.Engine) {
 				orderManagement.POST("/:id/confirm", middleware.ManagePermissionMiddleware(model.ResourceTypeOrder), orderHandler.ConfirmOrder)
 				orderManagement.POST("/:id/ship", middleware.ManagePermissionMiddleware(model.ResourceTypeOrder), orderHandler.ShipOrder)
 				orderManagement.POST("/:id/deliver", middleware.ManagePermissionMiddleware(model.ResourceTypeOrder), orderHandler.DeliverOrder)
+				orderManagement.POST("/:id/ready-for-pickup", middleware.ManagePermissionMiddleware(model.ResourceTypeOrder), orderHandler.MarkReadyForPickup)
+
+				// Split shipments - each with its own items, carrier and tracking (conditions checked against the warehouse)
+				orderManagement.POST("/:id/shipments", middleware.AttributePermissionMiddleware(model.ResourceTypeOrder, model.PermissionTypeManage), orderHandler.CreateShipment)
+				orderManagement.GET("/:id/shipments", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), orderHandler.GetShipments)
 
 				// Order items - requires read permission
 				orderManagement.GET("/:id/items", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), orderHandler.GetOrderItems)
@@ -510,8 +663,12 @@ func SetupRoutes(r *gin.Engine) {
 				// User orders - requires read permission
 				orderManagement.GET("/user/:user_id", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), orderHandler.GetOrdersByUser)
 
+				// Backorders - waiting items and fulfillment runs
+				orderManagement.GET("/backorders", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), orderHandler.GetBackorders)
+				orderManagement.POST("/backorders/process", middleware.ManagePermissionMiddleware(model.ResourceTypeOrder), orderHandler.ProcessBackorders)
+
 				// Payment routes for orders
-				orderManagement.POST("/:id/payment/link", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), paymentHandler.CreatePaymentLink)
+				orderManagement.POST("/:id/payment/link", authMiddleware.NoImpersonationMiddleware(), middleware.WritePermissionMiddleware(model.ResourceTypeOrder), paymentHandler.CreatePaymentLink)
 			}
 
 			// Admin order management routes (require admin role and order permissions)
@@ -543,6 +700,11 @@ func SetupRoutes(r *gin.Engine) {
 
 				// Convert cart to order - requires order write permission
 				cartManagement.POST("/:id/convert-to-order", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), orderHandler.ConvertCartToOrder)
+
+				// Delivery slot held during checkout - requires order write permission
+				cartManagement.POST("/:id/delivery-slot", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), deliverySlotHandler.ReserveCartSlot)
+				cartManagement.GET("/:id/delivery-slot", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), deliverySlotHandler.GetCartSlot)
+				cartManagement.DELETE("/:id/delivery-slot", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), deliverySlotHandler.ReleaseCartSlot)
 			}
 
 			// Advanced Cart Features routes (require authentication)
@@ -582,8 +744,9 @@ func SetupRoutes(r *gin.Engine) {
 				orderStats.GET("/revenue", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), orderHandler.GetRevenueStats)
 			}
 
-			// Payment management routes (require authentication and permissions)
+			// Payment management routes (require authentication and permissions; never while impersonating)
 			paymentManagement := protected.Group("/payments")
+			paymentManagement.Use(authMiddleware.NoImpersonationMiddleware())
 			{
 				// Payment processing - requires order write permission
 				paymentManagement.GET("/process/:order_code", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), paymentHandler.ProcessPayment)
@@ -621,7 +784,7 @@ func SetupRoutes(r *gin.Engine) {
 			// Order Tracking Webhook routes (no authentication required)
 			orderTrackingWebhook := v1.Group("/order-tracking/webhook")
 			{
-				// Webhook processing - no authentication required
+				// Webhook processing - no authentication, calls must be signed
 				orderTrackingWebhook.POST("/:carrier/:carrier_code", orderTrackingHandler.ProcessWebhook)
 			}
 
@@ -653,6 +816,39 @@ func SetupRoutes(r *gin.Engine) {
 				addressManagement.GET("/user/:user_id/stats", middleware.ReadPermissionMiddleware(model.ResourceTypeAddress), addressHandler.GetAddressStatsByUser)
 			}
 
+			// Administrative directory management routes
+			locationManagement := protected.Group("/locations")
+			{
+				// Dataset import and normalization of stored addresses - requires system write permission
+				locationManagement.POST("/import", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), locationHandler.ImportDataset)
+				locationManagement.POST("/normalize", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), locationHandler.NormalizeLocations)
+
+				// Carrier code mappings
+				locationManagement.GET("/carriers/:carrier/codes", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), locationHandler.GetCarrierCodes)
+				locationManagement.PUT("/carriers/:carrier/codes", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), locationHandler.UpsertCarrierCodes)
+				locationManagement.GET("/carriers/:carrier/resolve", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), locationHandler.GetCarrierLocation)
+			}
+
+			// Pickup location management routes
+			pickupLocationManagement := protected.Group("/pickup-locations")
+			{
+				pickupLocationManagement.POST("", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), pickupLocationHandler.CreatePickupLocation)
+				pickupLocationManagement.GET("", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), pickupLocationHandler.GetAllPickupLocations)
+				pickupLocationManagement.PUT("/:id", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), pickupLocationHandler.UpdatePickupLocation)
+				pickupLocationManagement.DELETE("/:id", middleware.DeletePermissionMiddleware(model.ResourceTypeSystem), pickupLocationHandler.DeletePickupLocation)
+			}
+
+			// Delivery slot management routes
+			deliverySlotManagement := protected.Group("/delivery-slots")
+			{
+				deliverySlotManagement.POST("/templates", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), deliverySlotHandler.CreateTemplate)
+				deliverySlotManagement.GET("/templates", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), deliverySlotHandler.GetTemplates)
+				deliverySlotManagement.GET("/templates/:id", middleware.ReadPermissionMiddleware(model.ResourceTypeSystem), deliverySlotHandler.GetTemplateByID)
+				deliverySlotManagement.PUT("/templates/:id", middleware.WritePermissionMiddleware(model.ResourceTypeSystem), deliverySlotHandler.UpdateTemplate)
+				deliverySlotManagement.DELETE("/templates/:id", middleware.DeletePermissionMiddleware(model.ResourceTypeSystem), deliverySlotHandler.DeleteTemplate)
+				deliverySlotManagement.GET("/utilization", middleware.ReadPermissionMiddleware(model.ResourceTypeReport), deliverySlotHandler.GetUtilization)
+			}
+
 			// Review management routes (require authentication and permissions)
 			reviewManagement := protected.Group("/reviews")
 			{
@@ -829,9 +1025,9 @@ func SetupRoutes(r *gin.Engine) {
 				// Analytics - requires read permission
 				wishlistManagement.GET("/:id/analytics", middleware.ReadPermissionMiddleware(model.ResourceTypeWishlist), wishlistHandler.GetWishlistAnalytics)
 				wishlistManagement.GET("/stats", middleware.ReadPermissionMiddleware(model.ResourceTypeWishlist), wishlistHandler.GetUserWishlistStats)
-				wishlistManagement.POST("/:id/track-view", wishlistHandler.TrackWishlistView)
-				wishlistManagement.POST("/items/:id/track-view", wishlistHandler.TrackWishlistItemView)
-				wishlistManagement.POST("/items/:id/track-click", wishlistHandler.TrackWishlistItemClick)
+				wishlistManagement.POST("/:id/track-view", middleware.APIKeyScopeMiddleware(model.ResourceTypeWishlist, model.PermissionTypeWrite), wishlistHandler.TrackWishlistView)
+				wishlistManagement.POST("/items/:id/track-view", middleware.APIKeyScopeMiddleware(model.ResourceTypeWishlist, model.PermissionTypeWrite), wishlistHandler.TrackWishlistItemView)
+				wishlistManagement.POST("/items/:id/track-click", middleware.APIKeyScopeMiddleware(model.ResourceTypeWishlist, model.PermissionTypeWrite), wishlistHandler.TrackWishlistItemClick)
 
 				// Price tracking - requires read permission
 				wishlistManagement.POST("/update-prices", middleware.ReadPermissionMiddleware(model.ResourceTypeWishlist), wishlistHandler.UpdateWishlistItemPrices)
@@ -866,49 +1062,49 @@ func SetupRoutes(r *gin.Engine) {
 				searchProtected.DELETE("/logs/delete", middleware.WritePermissionMiddleware(model.ResourceTypeProduct), searchHandler.DeleteSearchLogs)
 			}
 
-			// Notification routes (require authentication)
+			// Notification routes (require authentication; API keys need a notification scope)
 			notifications := protected.Group("/notifications")
 			{
 				// Notification CRUD
-				notifications.POST("", notificationHandler.CreateNotification)
-				notifications.GET("", notificationHandler.GetNotificationsByUser)
-				notifications.GET("/:id", notificationHandler.GetNotificationByID)
-				notifications.PUT("/:id", notificationHandler.UpdateNotification)
-				notifications.DELETE("/:id", notificationHandler.DeleteNotification)
+				notifications.POST("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.CreateNotification)
+				notifications.GET("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationsByUser)
+				notifications.GET("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationByID)
+				notifications.PUT("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.UpdateNotification)
+				notifications.DELETE("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeDelete), notificationHandler.DeleteNotification)
 
 				// Notification actions
-				notifications.POST("/:id/read", notificationHandler.MarkAsRead)
-				notifications.POST("/:id/unread", notificationHandler.MarkAsUnread)
-				notifications.POST("/:id/archive", notificationHandler.MarkAsArchived)
-				notifications.POST("/:id/unarchive", notificationHandler.MarkAsUnarchived)
+				notifications.POST("/:id/read", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.MarkAsRead)
+				notifications.POST("/:id/unread", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.MarkAsUnread)
+				notifications.POST("/:id/archive", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.MarkAsArchived)
+				notifications.POST("/:id/unarchive", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.MarkAsUnarchived)
 
 				// Bulk actions
-				notifications.POST("/bulk/read", notificationHandler.BulkMarkAsRead)
-				notifications.POST("/bulk/archive", notificationHandler.BulkMarkAsArchived)
+				notifications.POST("/bulk/read", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.BulkMarkAsRead)
+				notifications.POST("/bulk/archive", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.BulkMarkAsArchived)
 
 				// Statistics and search
-				notifications.GET("/unread-count", notificationHandler.GetUnreadNotificationCount)
-				notifications.GET("/stats", notificationHandler.GetNotificationStats)
-				notifications.GET("/search", notificationHandler.SearchNotifications)
+				notifications.GET("/unread-count", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetUnreadNotificationCount)
+				notifications.GET("/stats", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationStats)
+				notifications.GET("/search", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.SearchNotifications)
 			}
 
-			// Notification Templates routes (require authentication)
+			// Notification Templates routes (require notification permissions)
 			notificationTemplates := protected.Group("/notification-templates")
 			{
-				notificationTemplates.POST("", notificationHandler.CreateNotificationTemplate)
-				notificationTemplates.GET("", notificationHandler.GetNotificationTemplates)
-				notificationTemplates.GET("/:id", notificationHandler.GetNotificationTemplateByID)
-				notificationTemplates.PUT("/:id", notificationHandler.UpdateNotificationTemplate)
-				notificationTemplates.DELETE("/:id", notificationHandler.DeleteNotificationTemplate)
+				notificationTemplates.POST("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.CreateNotificationTemplate)
+				notificationTemplates.GET("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationTemplates)
+				notificationTemplates.GET("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationTemplateByID)
+				notificationTemplates.PUT("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.UpdateNotificationTemplate)
+				notificationTemplates.DELETE("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeDelete), notificationHandler.DeleteNotificationTemplate)
 			}
 
-			// Notification Preferences routes (require authentication)
+			// Notification Preferences routes (require authentication; API keys need a notification scope)
 			notificationPreferences := protected.Group("/notification-preferences")
 			{
-				notificationPreferences.POST("", notificationHandler.CreateNotificationPreference)
-				notificationPreferences.GET("", notificationHandler.GetNotificationPreferencesByUser)
-				notificationPreferences.PUT("/:id", notificationHandler.UpdateNotificationPreference)
-				notificationPreferences.DELETE("/:id", notificationHandler.DeleteNotificationPreference)
+				notificationPreferences.POST("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.CreateNotificationPreference)
+				notificationPreferences.GET("", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeRead), notificationHandler.GetNotificationPreferencesByUser)
+				notificationPreferences.PUT("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeWrite), notificationHandler.UpdateNotificationPreference)
+				notificationPreferences.DELETE("/:id", middleware.APIKeyScopeMiddleware(model.ResourceTypeNotification, model.PermissionTypeDelete), notificationHandler.DeleteNotificationPreference)
 			}
 
 			// Moderator routes (require moderator role and appropriate permissions)
@@ -1021,6 +1217,10 @@ func SetupRoutes(r *gin.Engine) {
 				shippingOrders.GET("/:id", shippingHandler.GetShippingOrderByID)
 				shippingOrders.GET("/order/:order_id", shippingHandler.GetShippingOrderByOrderID)
 				shippingOrders.POST("/:id/cancel", shippingHandler.CancelShippingOrder)
+				shippingOrders.GET("/:id/label", shippingHandler.GetShippingLabel)
+				shippingOrders.POST("/labels", shippingHandler.CreateShippingLabelBatch)
+				shippingOrders.GET("/labels/:batch_id", shippingHandler.GetShippingLabelBatch)
+				shippingOrders.GET("/labels/:batch_id/download", shippingHandler.DownloadShippingLabelBatch)
 			}
 
 			// Shipping tracking - requires order read permission
@@ -1037,6 +1237,25 @@ func SetupRoutes(r *gin.Engine) {
 				shippingStats.GET("", shippingHandler.GetShippingStats)
 				shippingStats.GET("/provider/:provider_id", shippingHandler.GetShippingStatsByProvider)
 			}
+
+			// COD remittance reconciliation
+			codRemittances := shippingManagement.Group("/cod")
+			{
+				codRemittances.POST("/remittances", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), codRemittanceHandler.ImportRemittance)
+				codRemittances.GET("/remittances", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), codRemittanceHandler.GetRemittances)
+				codRemittances.GET("/remittances/:id", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), codRemittanceHandler.GetRemittanceByID)
+				codRemittances.GET("/outstanding", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), codRemittanceHandler.GetOutstanding)
+			}
+
+			// Shipping SLA exceptions
+			shippingExceptions := shippingManagement.Group("/exceptions")
+			{
+				shippingExceptions.GET("/dashboard", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), shippingExceptionHandler.GetDashboard)
+				shippingExceptions.GET("", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), shippingExceptionHandler.GetExceptions)
+				shippingExceptions.GET("/:id", middleware.ReadPermissionMiddleware(model.ResourceTypeOrder), shippingExceptionHandler.GetExceptionByID)
+				shippingExceptions.PUT("/:id", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), shippingExceptionHandler.UpdateException)
+				shippingExceptions.POST("/scan", middleware.WritePermissionMiddleware(model.ResourceTypeOrder), shippingExceptionHandler.RunMonitor)
+			}
 		}
 
 		// Audit Management routes (require authentication)
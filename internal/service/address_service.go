@@ -45,6 +45,7 @@ type AddressService interface {
 type addressService struct {
 	addressRepo repository.AddressRepository
 	userRepo    repository.UserRepository
+	locations   LocationService
 }
 
 // NewAddressService creates a new AddressService
@@ -52,6 +53,7 @@ func NewAddressService() AddressService {
 	return &addressService{
 		addressRepo: repository.NewAddressRepository(),
 		userRepo:    repository.NewUserRepository(),
+		locations:   NewLocationService(),
 	}
 }
 
@@ -86,6 +88,9 @@ func (s *addressService) CreateAddress(req *model.AddressCreateRequest, userID u
 		State:        req.State,
 		Country:      req.Country,
 		PostalCode:   req.PostalCode,
+		ProvinceCode: req.ProvinceCode,
+		DistrictCode: req.DistrictCode,
+		WardCode:     req.WardCode,
 		Latitude:     req.Latitude,
 		Longitude:    req.Longitude,
 		Landmark:     req.Landmark,
@@ -93,6 +98,11 @@ func (s *addressService) CreateAddress(req *model.AddressCreateRequest, userID u
 		Notes:        req.Notes,
 	}
 
+	// Match the location to the administrative directory
+	if err := s.locations.ApplyToAddress(address); err != nil {
+		return nil, err
+	}
+
 	// Validate address
 	if err := s.ValidateAddress(address); err != nil {
 		logger.Errorf("Address validation failed: %v", err)
@@ -144,7 +154,7 @@ func (s *addressService) GetAddressByID(id uint, userID uint) (*model.AddressRes
 	}
 
 	// Allow access if user owns the address or is admin
-	if address.UserID != userID && (user.UserRole == nil || (user.UserRole.Name != "admin" && user.UserRole.Name != "super_admin")) {
+	if address.UserID != userID && !user.HasAnyRole("admin", "super_admin") {
 		return nil, errors.New("access denied: you can only view your own addresses")
 	}
 
@@ -202,7 +212,7 @@ func (s *addressService) UpdateAddress(id uint, req *model.AddressUpdateRequest,
 		return nil, errors.New("user not found")
 	}
 
-	if address.UserID != userID && (user.UserRole == nil || (user.UserRole.Name != "admin" && user.UserRole.Name != "super_admin")) {
+	if address.UserID != userID && !user.HasAnyRole("admin", "super_admin") {
 		return nil, errors.New("access denied: you can only update your own addresses")
 	}
 
@@ -249,6 +259,12 @@ func (s *addressService) UpdateAddress(id uint, req *model.AddressUpdateRequest,
 	if req.PostalCode != "" {
 		address.PostalCode = req.PostalCode
 	}
+	if req.City != "" || req.District != "" || req.Ward != "" || req.ProvinceCode != "" || req.DistrictCode != "" || req.WardCode != "" {
+		// Location changed: derive the codes again from what was sent
+		address.ProvinceCode = req.ProvinceCode
+		address.DistrictCode = req.DistrictCode
+		address.WardCode = req.WardCode
+	}
 	if req.Latitude != nil {
 		address.Latitude = req.Latitude
 	}
@@ -265,6 +281,11 @@ func (s *addressService) UpdateAddress(id uint, req *model.AddressUpdateRequest,
 		address.Notes = req.Notes
 	}
 
+	// Match the location to the administrative directory
+	if err := s.locations.ApplyToAddress(address); err != nil {
+		return nil, err
+	}
+
 	// Validate updated address
 	if err := s.ValidateAddress(address); err != nil {
 		logger.Errorf("Address validation failed: %v", err)
@@ -314,7 +335,7 @@ func (s *addressService) DeleteAddress(id uint, userID uint) error {
 		return errors.New("user not found")
 	}
 
-	if address.UserID != userID && (user.UserRole == nil || (user.UserRole.Name != "admin" && user.UserRole.Name != "super_admin")) {
+	if address.UserID != userID && !user.HasAnyRole("admin", "super_admin") {
 		return errors.New("access denied: you can only delete your own addresses")
 	}
 
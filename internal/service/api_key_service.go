@@ -0,0 +1,453 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/database"
+	"go_app/pkg/logger"
+)
+
+// CreateAPIKeyRequest represents a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`           // "resource.action" or "resource.*", e.g. "product.read", "order.*"
+	AllowedIPs    []string `json:"allowed_ips"`                                // IPs or CIDRs; empty allows any address
+	ExpiresInDays *int     `json:"expires_in_days" validate:"omitempty,min=0"` // 0 = never expires (only if allowed by config)
+}
+
+// CreateAPIKeyResponse carries the raw key, which is only shown once
+type CreateAPIKeyResponse struct {
+	Key    string               `json:"key"`
+	APIKey model.APIKeyResponse `json:"api_key"`
+}
+
+// APIKeyService manages personal access tokens for integrations and authenticates requests made with them
+type APIKeyService struct {
+	repo              repository.APIKeyRepository
+	auditRepo         repository.AuditRepository
+	permissionService PermissionService
+	config            configs.APIKeyConfig
+	rejectAudits      *rejectAuditThrottle
+}
+
+// rejectAuditThrottle limits audit entries for unknown keys, so guessing keys cannot flood the
+// audit log; attempts inside a window are counted and reported with the next entry
+type rejectAuditThrottle struct {
+	mu      sync.Mutex
+	windows map[string]*rejectAuditWindow
+}
+
+type rejectAuditWindow struct {
+	startedAt  time.Time
+	suppressed int
+}
+
+// rejectAuditThrottleMaxEntries bounds the tracked IPs; older windows are dropped past it
+const rejectAuditThrottleMaxEntries = 10000
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService() *APIKeyService {
+	config := configs.Load()
+
+	return &APIKeyService{
+		repo:              repository.NewAPIKeyRepository(),
+		auditRepo:         repository.NewAuditRepository(database.GetDB()),
+		permissionService: NewPermissionService(),
+		config:            config.APIKey,
+		rejectAudits:      &rejectAuditThrottle{windows: map[string]*rejectAuditWindow{}},
+	}
+}
+
+// CreateAPIKey issues a new key for the user. Scopes must name known resources and actions the
+// user currently holds; the key can never do more than its owner.
+func (s *APIKeyService) CreateAPIKey(userID uint, req *CreateAPIKeyRequest, info AuditRequestInfo) (*CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	scopes, err := s.validateScopes(userID, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := s.expiryFor(req.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.MaxPerUser > 0 {
+		count, err := s.repo.CountActiveByUserID(userID)
+		if err != nil {
+			logger.Errorf("Failed to count API keys for user %d: %v", userID, err)
+			return nil, fmt.Errorf("failed to create API key")
+		}
+		if count >= int64(s.config.MaxPerUser) {
+			return nil, fmt.Errorf("maximum number of active API keys (%d) reached, revoke an unused key first", s.config.MaxPerUser)
+		}
+	}
+
+	raw, err := generateAPIKey()
+	if err != nil {
+		logger.Errorf("Failed to generate API key: %v", err)
+		return nil, fmt.Errorf("failed to create API key")
+	}
+
+	key := &model.APIKey{
+		UserID:     userID,
+		Name:       name,
+		Prefix:     raw[:len(model.APIKeyPrefix)+8],
+		KeyHash:    hashSecretCode(raw),
+		Scopes:     strings.Join(scopes, ","),
+		AllowedIPs: strings.Join(allowedIPs, ","),
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.repo.Create(key); err != nil {
+		logger.Errorf("Failed to create API key for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to create API key")
+	}
+
+	s.audit(&userID, model.ActionAPIKeyCreate, model.OperationCreate, model.StatusSuccess, model.SeverityWarning, key,
+		fmt.Sprintf("API key %q created", key.Name), map[string]interface{}{
+			"scopes":      scopes,
+			"allowed_ips": allowedIPs,
+			"expires_at":  expiresAt,
+		}, info)
+
+	return &CreateAPIKeyResponse{Key: raw, APIKey: key.ToResponse()}, nil
+}
+
+// GetAPIKeys lists the user's keys, including revoked and expired ones
+func (s *APIKeyService) GetAPIKeys(userID uint) ([]model.APIKeyResponse, error) {
+	keys, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		logger.Errorf("Failed to get API keys for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to retrieve API keys")
+	}
+	return toAPIKeyResponses(keys), nil
+}
+
+// RevokeAPIKey revokes one of the user's own keys
+func (s *APIKeyService) RevokeAPIKey(userID, keyID uint, info AuditRequestInfo) error {
+	key, err := s.repo.GetByID(keyID)
+	if err != nil {
+		logger.Errorf("Failed to get API key %d: %v", keyID, err)
+		return fmt.Errorf("failed to revoke API key")
+	}
+	if key == nil || key.UserID != userID {
+		return errors.New("API key not found")
+	}
+	return s.revoke(userID, key, info)
+}
+
+// GetAllAPIKeys lists keys of all users for admins
+func (s *APIKeyService) GetAllAPIKeys(page, limit int, filters map[string]interface{}) ([]model.APIKeyResponse, int64, error) {
+	keys, total, err := s.repo.GetAll(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Failed to get API keys: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve API keys")
+	}
+	return toAPIKeyResponses(keys), total, nil
+}
+
+// AdminRevokeAPIKey revokes any user's key, e.g. when a connector is decommissioned or a key leaked
+func (s *APIKeyService) AdminRevokeAPIKey(adminID, keyID uint, info AuditRequestInfo) error {
+	key, err := s.repo.GetByID(keyID)
+	if err != nil {
+		logger.Errorf("Failed to get API key %d: %v", keyID, err)
+		return fmt.Errorf("failed to revoke API key")
+	}
+	if key == nil {
+		return errors.New("API key not found")
+	}
+	return s.revoke(adminID, key, info)
+}
+
+func (s *APIKeyService) revoke(actorID uint, key *model.APIKey, info AuditRequestInfo) error {
+	revoked, err := s.repo.Revoke(key.ID, actorID)
+	if err != nil {
+		logger.Errorf("Failed to revoke API key %d: %v", key.ID, err)
+		return fmt.Errorf("failed to revoke API key")
+	}
+	if !revoked {
+		return errors.New("API key is already revoked")
+	}
+
+	s.audit(&actorID, model.ActionAPIKeyRevoke, model.OperationRevoke, model.StatusSuccess, model.SeverityWarning, key,
+		fmt.Sprintf("API key %q revoked", key.Name), nil, info)
+	return nil
+}
+
+// Authenticate resolves a raw key to an active key and its owner. Rejections are audited, unknown keys
+// at most once per window and IP; successful use is audited and recorded as last-used at most once
+// per touch interval, or when the IP changes.
+func (s *APIKeyService) Authenticate(raw string, info AuditRequestInfo) (*model.APIKey, error) {
+	key, err := s.repo.GetByHash(hashSecretCode(raw))
+	if err != nil {
+		logger.Errorf("Failed to look up API key: %v", err)
+		return nil, fmt.Errorf("failed to authenticate API key")
+	}
+	if key == nil {
+		window := time.Duration(s.config.RejectAuditSeconds) * time.Second
+		if suppressed, ok := s.rejectAudits.allow(info.IPAddress, time.Now(), window); ok {
+			var metadata map[string]interface{}
+			if suppressed > 0 {
+				metadata = map[string]interface{}{"suppressed_attempts": suppressed}
+			}
+			s.audit(nil, model.ActionAPIKeyReject, model.OperationLogin, model.StatusFailure, model.SeverityWarning,
+				&model.APIKey{Prefix: apiKeyDisplayPrefix(raw)}, "Unknown API key", metadata, info)
+		}
+		return nil, errors.New("invalid API key")
+	}
+
+	reason := ""
+	switch {
+	case key.RevokedAt != nil:
+		reason = "API key has been revoked"
+	case key.IsExpired():
+		reason = "API key has expired"
+	case !ipAllowed(key.AllowedIPList(), info.IPAddress):
+		reason = "API key is not allowed from this IP address"
+	case key.User == nil || !key.User.IsActive:
+		reason = "API key owner is inactive"
+	}
+	if reason != "" {
+		s.audit(&key.UserID, model.ActionAPIKeyReject, model.OperationLogin, model.StatusFailure, model.SeverityWarning, key,
+			reason, nil, info)
+		return nil, errors.New(reason)
+	}
+
+	now := time.Now()
+	interval := time.Duration(s.config.TouchIntervalSeconds) * time.Second
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= interval || key.LastUsedIP != info.IPAddress {
+		if err := s.repo.TouchLastUsed(key.ID, info.IPAddress, now); err != nil {
+			logger.Warnf("Failed to record API key %d usage: %v", key.ID, err)
+		}
+		s.audit(&key.UserID, model.ActionAPIKeyUse, model.OperationLogin, model.StatusSuccess, model.SeverityInfo, key,
+			fmt.Sprintf("API key %q used", key.Name), nil, info)
+		key.LastUsedAt = &now
+		key.LastUsedIP = info.IPAddress
+	}
+
+	return key, nil
+}
+
+// allow reports whether an unknown key from the IP should be audited now, and how many attempts
+// from it were not audited since the last entry
+func (t *rejectAuditThrottle) allow(ip string, now time.Time, window time.Duration) (int, bool) {
+	if window <= 0 {
+		return 0, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.windows[ip]
+	if current != nil && now.Sub(current.startedAt) < window {
+		current.suppressed++
+		return 0, false
+	}
+
+	suppressed := 0
+	if current != nil {
+		suppressed = current.suppressed
+	}
+	if len(t.windows) >= rejectAuditThrottleMaxEntries {
+		for key, w := range t.windows {
+			if now.Sub(w.startedAt) >= window {
+				delete(t.windows, key)
+			}
+		}
+	}
+	t.windows[ip] = &rejectAuditWindow{startedAt: now}
+	return suppressed, true
+}
+
+// validateScopes normalizes scopes and checks them against the model enums and the owner's permissions
+func (s *APIKeyService) validateScopes(userID uint, requested []string) ([]string, error) {
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, scope := range requested {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+
+		parts := strings.Split(scope, ".")
+		if len(parts) != 2 || !model.IsValidResourceType(parts[0]) ||
+			(parts[1] != model.APIKeyScopeWildcard && !model.IsValidPermissionType(parts[1])) {
+			return nil, fmt.Errorf("invalid scope %q, expected resource.action such as product.read or order.*", scope)
+		}
+
+		resource := model.ResourceType(parts[0])
+		actions := []model.PermissionType{model.PermissionType(parts[1])}
+		if parts[1] == model.APIKeyScopeWildcard {
+			actions = model.AllPermissionTypes
+		}
+		// Conditional grants count here; their conditions are still checked on every request
+		held := false
+		for _, action := range actions {
+			check, err := s.permissionService.CheckPermissionDeferred(userID, resource, action, nil, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check permissions for scope %s", scope)
+			}
+			if check.HasPermission {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return nil, fmt.Errorf("you do not have the permissions granted by scope %s", scope)
+		}
+
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// expiryFor applies the configured default and maximum lifetime
+func (s *APIKeyService) expiryFor(expiresInDays *int) (*time.Time, error) {
+	days := s.config.DefaultExpiryDays
+	if expiresInDays != nil {
+		days = *expiresInDays
+	}
+	if days == 0 {
+		if s.config.MaxExpiryDays > 0 {
+			return nil, fmt.Errorf("API keys must expire within %d days", s.config.MaxExpiryDays)
+		}
+		return nil, nil
+	}
+	if s.config.MaxExpiryDays > 0 && days > s.config.MaxExpiryDays {
+		return nil, fmt.Errorf("API keys must expire within %d days", s.config.MaxExpiryDays)
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, days)
+	return &expiresAt, nil
+}
+
+// audit writes an API key event to the audit log; failures are logged, never returned
+func (s *APIKeyService) audit(userID *uint, action, operation, status, severity string, key *model.APIKey, message string, metadata map[string]interface{}, info AuditRequestInfo) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["prefix"] = key.Prefix
+	encoded, _ := json.Marshal(metadata)
+
+	entry := &model.AuditLog{
+		UserID:       userID,
+		Action:       action,
+		Resource:     model.ResourceAPIKey,
+		ResourceName: key.Name,
+		Operation:    operation,
+		Status:       status,
+		Message:      message,
+		IPAddress:    info.IPAddress,
+		UserAgent:    info.UserAgent,
+		SessionID:    info.SessionID,
+		Metadata:     string(encoded),
+		Tags:         "api_key,security",
+		Severity:     severity,
+	}
+	if key.ID != 0 {
+		entry.ResourceID = &key.ID
+		if userID == nil || *userID != key.UserID {
+			entry.TargetUserID = &key.UserID
+		}
+	}
+
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		logger.Errorf("Failed to write API key audit log (%s): %v", action, err)
+	}
+}
+
+func toAPIKeyResponses(keys []model.APIKey) []model.APIKeyResponse {
+	responses := make([]model.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, keys[i].ToResponse())
+	}
+	return responses
+}
+
+// generateAPIKey creates "ak_" followed by 64 hex characters from crypto/rand
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return model.APIKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// apiKeyDisplayPrefix returns the part of a raw key that is safe to log
+func apiKeyDisplayPrefix(raw string) string {
+	if n := len(model.APIKeyPrefix) + 8; len(raw) > n {
+		return raw[:n]
+	}
+	return ""
+}
+
+// normalizeAllowedIPs validates the allowlist; plain IPs are kept as-is, CIDRs are canonicalized
+func normalizeAllowedIPs(values []string) ([]string, error) {
+	allowed := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			_, network, err := net.ParseCIDR(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q in allowed IPs", value)
+			}
+			allowed = append(allowed, network.String())
+			continue
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q in allowed IPs", value)
+		}
+		allowed = append(allowed, ip.String())
+	}
+	return allowed, nil
+}
+
+// ipAllowed checks the client IP against an allowlist of IPs and CIDRs; an empty list allows any IP
+func ipAllowed(allowed []string, clientIP string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
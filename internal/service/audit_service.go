@@ -8,6 +8,13 @@ import (
 	"time"
 )
 
+// AuditRequestInfo describes the HTTP request a security-relevant action came from, for the audit log
+type AuditRequestInfo struct {
+	IPAddress string
+	UserAgent string
+	SessionID string
+}
+
 // AuditService defines methods for audit logging business logic
 type AuditService interface {
 	// Audit Logs
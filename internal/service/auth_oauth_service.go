@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/pkg/logger"
+	"go_app/pkg/oauth"
+)
+
+// oauthRequestTimeout bounds the calls made to the provider during a callback
+const oauthRequestTimeout = 20 * time.Second
+
+type OAuthAuthorizeRequest struct {
+	DeviceID string `json:"device_id" validate:"required"`
+}
+
+type OAuthCallbackRequest struct {
+	Code      string `json:"code" form:"code" validate:"required"`
+	State     string `json:"state" form:"state" validate:"required"`
+	LinkState string `json:"-" form:"-"` // State stored in the browser that started a link flow
+	UserAgent string `json:"-" form:"-"`
+	IPAddress string `json:"-" form:"-"`
+	Country   string `json:"-" form:"-"`
+}
+
+// newOAuthRegistry registers every provider that has credentials configured
+func newOAuthRegistry(config configs.OAuthConfig, ginMode string) *oauth.Registry {
+	registry := oauth.NewRegistry()
+	if config.GoogleClientID != "" {
+		registry.Register(oauth.NewGoogleProvider(config.GoogleClientID, config.GoogleClientSecret))
+	}
+	if config.FacebookClientID != "" {
+		registry.Register(oauth.NewFacebookProvider(config.FacebookClientID, config.FacebookClientSecret))
+	}
+	if config.ZaloAppID != "" {
+		registry.Register(oauth.NewZaloProvider(config.ZaloAppID, config.ZaloSecretKey))
+	}
+	if config.MockAllowed(ginMode) {
+		registry.Register(oauth.NewMockProvider(oauth.MockServerConfig{
+			Issuer:       config.MockIssuer,
+			ClientID:     oauth.MockClientID,
+			ClientSecret: oauth.MockClientSecret,
+		}))
+	}
+	return registry
+}
+
+// GetOAuthProviders lists the configured social login providers
+func (s *AuthService) GetOAuthProviders() *model.OAuthProvidersResponse {
+	return &model.OAuthProvidersResponse{Providers: s.oauthProviders.Names()}
+}
+
+// StartOAuth creates a single-use state with a PKCE verifier and returns the provider's
+// authorization URL. For purpose link the state is bound to the signed-in user.
+func (s *AuthService) StartOAuth(providerName, purpose string, userID *uint, deviceID string) (*model.OAuthAuthorizeResponse, error) {
+	provider, err := s.oauthProviders.Get(providerName)
+	if err != nil {
+		return nil, errors.New("oauth provider not found")
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate oauth state: %w", err)
+	}
+	nonce, err := oauth.GenerateState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate oauth nonce: %w", err)
+	}
+	verifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pkce verifier: %w", err)
+	}
+
+	redirectURI := strings.ReplaceAll(s.oauthConfig.RedirectURL, "{provider}", providerName)
+	authURL, err := provider.AuthCodeURL(state, oauth.CodeChallenge(verifier), nonce, redirectURI)
+	if err != nil {
+		logger.Errorf("Failed to build %s authorization URL: %v", providerName, err)
+		return nil, fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	oauthState := &model.OAuthState{
+		StateHash:    hashSecretCode(state),
+		Provider:     providerName,
+		Purpose:      purpose,
+		UserID:       userID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		RedirectURI:  redirectURI,
+		DeviceID:     deviceID,
+		ExpiresAt:    time.Now().Add(time.Duration(s.oauthConfig.StateMinutes) * time.Minute),
+	}
+	if err := s.identityRepo.CreateState(oauthState); err != nil {
+		return nil, fmt.Errorf("failed to save oauth state: %w", err)
+	}
+
+	return &model.OAuthAuthorizeResponse{
+		Provider:         providerName,
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresAt:        oauthState.ExpiresAt,
+	}, nil
+}
+
+// OAuthCallback finishes the authorization code flow. A login callback returns the same
+// response as Login (tokens, or a two-factor challenge); a link callback returns the identity
+// that was linked to the user who started the flow.
+func (s *AuthService) OAuthCallback(providerName string, req *OAuthCallbackRequest) (*AuthResponse, *model.UserIdentity, error) {
+	provider, err := s.oauthProviders.Get(providerName)
+	if err != nil {
+		return nil, nil, errors.New("oauth provider not found")
+	}
+
+	state, err := s.identityRepo.ConsumeState(hashSecretCode(req.State))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get oauth state: %w", err)
+	}
+	if state == nil || state.Provider != providerName {
+		return nil, nil, errors.New("invalid or expired oauth state")
+	}
+	// A link callback must come from the browser that started it, or a forged callback
+	// could attach someone else's provider account to the user
+	if state.Purpose == model.OAuthPurposeLink && subtle.ConstantTimeCompare([]byte(req.LinkState), []byte(req.State)) != 1 {
+		return nil, nil, errors.New("oauth link must be finished in the browser that started it")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauthRequestTimeout)
+	defer cancel()
+
+	token, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, state.RedirectURI)
+	if err != nil {
+		logger.Warnf("OAuth %s code exchange failed: %v", providerName, err)
+		return nil, nil, errors.New("authorization code was rejected by the provider")
+	}
+	info, err := provider.UserInfo(ctx, token, state.Nonce)
+	if err != nil {
+		logger.Warnf("OAuth %s user info failed: %v", providerName, err)
+		return nil, nil, errors.New("could not verify the provider identity")
+	}
+
+	if state.Purpose == model.OAuthPurposeLink && state.UserID != nil {
+		identity, err := s.linkIdentity(*state.UserID, providerName, info)
+		return nil, identity, err
+	}
+
+	user, err := s.resolveOAuthUser(providerName, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("account is deactivated")
+	}
+
+	loginReq := &LoginRequest{
+		Email:     user.Email,
+		DeviceID:  state.DeviceID,
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+		Country:   req.Country,
+	}
+
+	// A locked account stays locked whichever way the user signs in
+	if err := s.loginSecurity.CheckAllowed(user, loginReq); err != nil {
+		return nil, nil, err
+	}
+
+	// The provider replaces the password, not the second factor
+	challenge, err := s.startTwoFactorChallenge(user, loginReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return challenge, nil, nil
+	}
+
+	authResponse, err := s.completeLogin(user, loginReq)
+	return authResponse, nil, err
+}
+
+// GetIdentities lists the social accounts linked to the user
+func (s *AuthService) GetIdentities(userID uint) ([]model.UserIdentity, error) {
+	identities, err := s.identityRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes a linked social account, as long as the user keeps a way to sign in
+func (s *AuthService) UnlinkIdentity(userID, identityID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.Password == "" {
+		count, err := s.identityRepo.CountByUserID(userID)
+		if err != nil {
+			return fmt.Errorf("failed to count identities: %w", err)
+		}
+		if count <= 1 {
+			return errors.New("cannot unlink the only sign-in method, set a password first")
+		}
+	}
+
+	deleted, err := s.identityRepo.Delete(userID, identityID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink identity: %w", err)
+	}
+	if !deleted {
+		return errors.New("identity not found")
+	}
+
+	logger.Infof("User %d unlinked identity %d", userID, identityID)
+	return nil
+}
+
+// resolveOAuthUser finds the user behind a provider identity: an identity linked before, an
+// existing account with the same verified email (which gets linked), or a new account
+func (s *AuthService) resolveOAuthUser(providerName string, info *oauth.UserInfo) (*model.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(providerName, info.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	if identity != nil {
+		user, err := s.userRepo.GetByID(identity.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		s.refreshIdentity(identity, info)
+		return user, nil
+	}
+
+	// Without a verified email the identity cannot be matched or used for a new account
+	if info.Email == "" || !info.EmailVerified {
+		return nil, errors.New("the provider did not share a verified email, sign in with your password and link this account instead")
+	}
+
+	now := time.Now()
+	identity = &model.UserIdentity{
+		Provider:      providerName,
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AvatarURL:     urlOrEmpty(info.Picture, 500),
+		LastLoginAt:   &now,
+	}
+
+	if existing, _ := s.userRepo.GetByEmail(info.Email); existing != nil {
+		// Someone may have registered the address without owning it; only link to accounts
+		// that proved ownership of the email themselves
+		if !existing.IsEmailVerified {
+			return nil, errors.New("an account with this email exists but is not verified, sign in with your password and link this account instead")
+		}
+		identity.UserID = existing.ID
+		if err := s.identityRepo.Create(identity); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %w", err)
+		}
+		logger.Infof("Linked %s identity to existing user %d by verified email", providerName, existing.ID)
+		return existing, nil
+	}
+
+	username, err := s.generateUsername(info.Email)
+	if err != nil {
+		return nil, err
+	}
+	firstName, lastName := info.GivenName, info.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName = info.Name
+	}
+	user := &model.User{
+		Username:        username,
+		Email:           info.Email,
+		Password:        "", // Chỉ đăng nhập qua mạng xã hội cho đến khi đặt mật khẩu
+		FirstName:       truncateString(firstName, 50),
+		LastName:        truncateString(lastName, 50),
+		Avatar:          urlOrEmpty(info.Picture, 255),
+		RoleID:          1, // Default role ID (assuming 'user' role has ID = 1)
+		IsActive:        true,
+		IsEmailVerified: true,
+	}
+	if err := s.identityRepo.CreateWithUser(user, identity); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	logger.Infof("Registered user %d through %s", user.ID, providerName)
+	return s.userRepo.GetByID(user.ID)
+}
+
+// linkIdentity attaches a provider identity to the signed-in user who started the flow
+func (s *AuthService) linkIdentity(userID uint, providerName string, info *oauth.UserInfo) (*model.UserIdentity, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(providerName, info.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, errors.New("this account is already linked to another user")
+		}
+		s.refreshIdentity(identity, info)
+		return identity, nil
+	}
+
+	identities, err := s.identityRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identities: %w", err)
+	}
+	for _, linked := range identities {
+		if linked.Provider == providerName {
+			return nil, fmt.Errorf("a %s account is already linked, unlink it first", providerName)
+		}
+	}
+
+	identity = &model.UserIdentity{
+		UserID:        userID,
+		Provider:      providerName,
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AvatarURL:     urlOrEmpty(info.Picture, 500),
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	logger.Infof("User %d linked a %s identity", userID, providerName)
+	return identity, nil
+}
+
+// refreshIdentity keeps the stored profile in sync with the provider
+func (s *AuthService) refreshIdentity(identity *model.UserIdentity, info *oauth.UserInfo) {
+	now := time.Now()
+	identity.Email = info.Email
+	identity.EmailVerified = info.EmailVerified
+	identity.Name = info.Name
+	identity.AvatarURL = urlOrEmpty(info.Picture, 500)
+	identity.LastLoginAt = &now
+	if err := s.identityRepo.Update(identity); err != nil {
+		logger.Warnf("Failed to update identity %d: %v", identity.ID, err)
+	}
+}
+
+// generateUsername derives a free username (3-20 letters, digits, _ or -) from the email
+func (s *AuthService) generateUsername(email string) (string, error) {
+	var base strings.Builder
+	for _, char := range strings.ToLower(strings.Split(email, "@")[0]) {
+		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '_' || char == '-' {
+			base.WriteRune(char)
+		}
+	}
+	name := truncateString(base.String(), 15)
+	if len(name) < 3 {
+		name = "user" + name
+	}
+
+	candidate := name
+	for i := 0; i < 10; i++ {
+		if existing, _ := s.userRepo.GetByUsername(candidate); existing == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%04d", name, rand.Intn(10000))
+	}
+	return "", errors.New("failed to generate a unique username")
+}
+
+// truncateString cuts value to at most max bytes without splitting a multi-byte character
+func truncateString(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
+
+// urlOrEmpty drops URLs that do not fit the column rather than storing a broken link
+func urlOrEmpty(value string, max int) string {
+	if len(value) > max {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/pkg/logger"
+	"go_app/pkg/totp"
+	"go_app/pkg/utils"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // Bỏ ký tự dễ nhầm (0/o, 1/l/i)
+	totpSkewSteps        = 1                                 // Chấp nhận lệch đồng hồ ±30 giây
+)
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	Method         string `json:"method" validate:"omitempty,oneof=totp recovery_code email"` // Mặc định totp
+	IPAddress      string `json:"-"`
+	Country        string `json:"-"` // Mã quốc gia từ header của CDN
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // Mã TOTP hoặc mã khôi phục
+}
+
+// startTwoFactorChallenge opens the second login step when the user has 2FA enabled or their role
+// requires it. It returns nil when the password alone is enough.
+func (s *AuthService) startTwoFactorChallenge(user *model.User, req *LoginRequest) (*AuthResponse, error) {
+	twoFactor, err := s.twoFactorRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	setupRequired := false
+	if twoFactor == nil || !twoFactor.Enabled {
+		required, err := s.isTwoFactorRequired(user)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+		setupRequired = true
+	}
+
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	challenge := &model.LoginChallenge{
+		UserID:        user.ID,
+		TokenHash:     hashSecretCode(token),
+		DeviceID:      req.DeviceID,
+		UserAgent:     req.UserAgent,
+		IPAddress:     req.IPAddress,
+		SetupRequired: setupRequired,
+		ExpiresAt:     time.Now().Add(time.Duration(s.twoFactorConfig.ChallengeMinutes) * time.Minute),
+	}
+	if err := s.twoFactorRepo.CreateChallenge(challenge); err != nil {
+		return nil, fmt.Errorf("failed to create login challenge: %w", err)
+	}
+
+	methods := []string{model.TwoFactorMethodTOTP, model.TwoFactorMethodRecoveryCode, model.TwoFactorMethodEmail}
+	if setupRequired {
+		methods = []string{model.TwoFactorMethodTOTP}
+	}
+
+	return &AuthResponse{
+		TwoFactorRequired:      true,
+		TwoFactorSetupRequired: setupRequired,
+		TwoFactorMethods:       methods,
+		ChallengeToken:         token,
+		ChallengeExpiresAt:     &challenge.ExpiresAt,
+	}, nil
+}
+
+// VerifyTwoFactor completes a login challenge with a TOTP code, a recovery code or an email OTP.
+// For users enrolling during login, the first valid TOTP code also turns 2FA on and the recovery
+// codes are returned with the tokens.
+func (s *AuthService) VerifyTwoFactor(req *TwoFactorVerifyRequest) (*AuthResponse, error) {
+	challenge, user, err := s.getOpenChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// The login continues from the device that passed the password step
+	loginReq := &LoginRequest{
+		Email:     user.Email,
+		DeviceID:  challenge.DeviceID,
+		UserAgent: challenge.UserAgent,
+		IPAddress: req.IPAddress,
+		Country:   req.Country,
+	}
+	if loginReq.IPAddress == "" {
+		loginReq.IPAddress = challenge.IPAddress
+	}
+	if err := s.loginSecurity.CheckAllowed(user, loginReq); err != nil {
+		return nil, err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = model.TwoFactorMethodTOTP
+	}
+	if challenge.SetupRequired && method != model.TwoFactorMethodTOTP {
+		return nil, errors.New("two-factor setup must be completed with an authenticator code")
+	}
+
+	var recoveryCodes []string
+	var ok bool
+	switch method {
+	case model.TwoFactorMethodRecoveryCode:
+		ok, err = s.twoFactorRepo.UseRecoveryCode(user.ID, hashSecretCode(normalizeRecoveryCode(req.Code)))
+	case model.TwoFactorMethodEmail:
+		ok, err = s.useEmailCode(user.ID, req.Code)
+	default:
+		if challenge.SetupRequired {
+			recoveryCodes, ok, err = s.confirmEnrollment(user.ID, req.Code)
+		} else {
+			ok, err = s.checkTOTP(user.ID, req.Code)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.twoFactorRepo.IncrementChallengeAttempts(challenge.ID); err != nil {
+			logger.Warnf("Failed to count attempt on login challenge %d: %v", challenge.ID, err)
+		}
+		if err := s.loginSecurity.RecordTwoFactorFailure(user, loginReq); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid verification code")
+	}
+
+	completed, err := s.twoFactorRepo.CompleteChallenge(challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete login challenge: %w", err)
+	}
+	if !completed {
+		return nil, errors.New("invalid or expired login challenge")
+	}
+
+	resp, err := s.completeLogin(user, loginReq)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// SetupTwoFactorChallenge returns the enrollment QR code for a user whose role requires 2FA but
+// who has not enrolled yet, so they can finish logging in
+func (s *AuthService) SetupTwoFactorChallenge(req *TwoFactorChallengeRequest) (*model.TwoFactorSetupResponse, error) {
+	challenge, user, err := s.getOpenChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.SetupRequired {
+		return nil, errors.New("two-factor authentication is already set up")
+	}
+	return s.beginEnrollment(user)
+}
+
+// SendTwoFactorEmail emails a one-time login code as a fallback for the authenticator app
+func (s *AuthService) SendTwoFactorEmail(req *TwoFactorChallengeRequest) error {
+	challenge, user, err := s.getOpenChallenge(req.ChallengeToken)
+	if err != nil {
+		return err
+	}
+	if challenge.SetupRequired {
+		return errors.New("two-factor setup must be completed with an authenticator code")
+	}
+
+	otpCode, err := generateSecureOTPCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate OTP: %w", err)
+	}
+	otp := &model.OTP{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Code:      otpCode,
+		Type:      model.OTPTypeTwoFactor,
+		ExpiresAt: time.Now().Add(10 * time.Minute), // 10 minutes expiry
+	}
+	if err := s.otpRepo.Create(otp); err != nil {
+		return fmt.Errorf("failed to create OTP: %w", err)
+	}
+
+	if err := s.emailService.SendOTPEmail(user.Email, otpCode, string(model.OTPTypeTwoFactor)); err != nil {
+		logger.Warnf("Failed to send two-factor email: %v", err)
+	}
+	return nil
+}
+
+// GetTwoFactorStatus reports whether 2FA is on for the user and whether their role requires it
+func (s *AuthService) GetTwoFactorStatus(userID uint) (*model.TwoFactorStatusResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	twoFactor, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	required, err := s.isTwoFactorRequired(user)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TwoFactorStatusResponse{Required: required}
+	if twoFactor != nil && twoFactor.Enabled {
+		status.Enabled = true
+		status.EnabledAt = twoFactor.EnabledAt
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// BeginTwoFactorSetup generates a new TOTP secret; 2FA is enabled once EnableTwoFactor confirms a code
+func (s *AuthService) BeginTwoFactorSetup(userID uint) (*model.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.beginEnrollment(user)
+}
+
+// EnableTwoFactor confirms the pending secret with a code from the app and returns the recovery codes
+func (s *AuthService) EnableTwoFactor(userID uint, req *TwoFactorCodeRequest) (*model.TwoFactorRecoveryCodesResponse, error) {
+	recoveryCodes, ok, err := s.confirmEnrollment(userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+	return &model.TwoFactorRecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor turns 2FA off after re-checking the password and a second factor
+func (s *AuthService) DisableTwoFactor(userID uint, req *TwoFactorDisableRequest) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	required, err := s.isTwoFactorRequired(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+	if !s.verifyPassword(req.Password, user.Password) {
+		return errors.New("invalid password")
+	}
+	if err := s.verifySecondFactor(userID, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Disable(userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; the old ones stop working immediately
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, req *TwoFactorCodeRequest) (*model.TwoFactorRecoveryCodesResponse, error) {
+	if err := s.verifySecondFactor(userID, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return &model.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// isTwoFactorRequired applies the policy: listed roles and holders of admin-level permissions must use 2FA
+func (s *AuthService) isTwoFactorRequired(user *model.User) (bool, error) {
+	for _, name := range user.RoleNames() {
+		for _, role := range s.twoFactorConfig.RequiredRoles {
+			if strings.EqualFold(role, name) {
+				return true, nil
+			}
+		}
+	}
+	if !s.twoFactorConfig.RequireAdminPermissions {
+		return false, nil
+	}
+
+	// Effective permissions cover the user's role, roles granted to them and direct grants
+	permissions, err := s.permissionRepo.GetUserEffectivePermissions(user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check two-factor policy: %w", err)
+	}
+	for _, permission := range permissions {
+		if permission.Action == model.PermissionTypeAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getOpenChallenge loads a login challenge that can still be answered, with its user
+func (s *AuthService) getOpenChallenge(token string) (*model.LoginChallenge, *model.User, error) {
+	challenge, err := s.twoFactorRepo.GetChallengeByHash(hashSecretCode(token))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	if challenge == nil || challenge.CompletedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, nil, errors.New("invalid or expired login challenge")
+	}
+	if !challenge.IsOpen(s.twoFactorConfig.MaxAttempts) {
+		return nil, nil, errors.New("too many invalid codes, please log in again")
+	}
+
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("account is deactivated")
+	}
+	return challenge, user, nil
+}
+
+// beginEnrollment stores a new pending secret and returns the provisioning data
+func (s *AuthService) beginEnrollment(user *model.User) (*model.TwoFactorSetupResponse, error) {
+	twoFactor, err := s.twoFactorRepo.GetByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+	encrypted, err := utils.EncryptString(s.twoFactorKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+
+	if twoFactor == nil {
+		twoFactor = &model.UserTwoFactor{UserID: user.ID}
+	}
+	twoFactor.Secret = encrypted
+	twoFactor.LastUsedStep = 0
+	if err := s.twoFactorRepo.Save(twoFactor); err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	uri := totp.ProvisioningURI(s.twoFactorConfig.Issuer, user.Email, secret)
+	qrCode, err := totp.QRCodeDataURL(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	return &model.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: uri,
+		QRCode:     qrCode,
+	}, nil
+}
+
+// confirmEnrollment enables 2FA when code matches the pending secret, returning the new recovery codes
+func (s *AuthService) confirmEnrollment(userID uint, code string) ([]string, bool, error) {
+	twoFactor, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	if twoFactor == nil {
+		return nil, false, errors.New("two-factor setup has not been started")
+	}
+	if twoFactor.Enabled {
+		return nil, false, errors.New("two-factor authentication is already enabled")
+	}
+
+	step, ok, err := s.validateTOTP(twoFactor, code)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := s.twoFactorRepo.Enable(userID, step, hashes); err != nil {
+		return nil, false, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return codes, true, nil
+}
+
+// checkTOTP verifies a code for an enabled enrollment; each code works only once
+func (s *AuthService) checkTOTP(userID uint, code string) (bool, error) {
+	twoFactor, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return false, errors.New("two-factor authentication is not enabled")
+	}
+
+	step, ok, err := s.validateTOTP(twoFactor, code)
+	if err != nil || !ok {
+		return false, err
+	}
+	consumed, err := s.twoFactorRepo.ConsumeStep(userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	return consumed, nil
+}
+
+func (s *AuthService) validateTOTP(twoFactor *model.UserTwoFactor, code string) (int64, bool, error) {
+	secret, err := utils.DecryptString(s.twoFactorKey, twoFactor.Secret)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkewSteps)
+	if !ok || step <= twoFactor.LastUsedStep {
+		return 0, false, nil
+	}
+	return step, true, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or a recovery code for account changes
+func (s *AuthService) verifySecondFactor(userID uint, code string) error {
+	ok, err := s.checkTOTP(userID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if ok, err = s.twoFactorRepo.UseRecoveryCode(userID, hashSecretCode(normalizeRecoveryCode(code))); err != nil {
+			return fmt.Errorf("failed to check recovery code: %w", err)
+		}
+	}
+	if !ok {
+		return errors.New("invalid verification code")
+	}
+	return nil
+}
+
+// useEmailCode consumes an email OTP issued for the login challenge
+func (s *AuthService) useEmailCode(userID uint, code string) (bool, error) {
+	otp, err := s.otpRepo.GetValidByUserCode(userID, strings.TrimSpace(code), model.OTPTypeTwoFactor)
+	if err != nil {
+		return false, nil
+	}
+	otp.IsUsed = true
+	if err := s.otpRepo.Update(otp); err != nil {
+		return false, fmt.Errorf("failed to mark OTP as used: %w", err)
+	}
+	return true, nil
+}
+
+// generateRecoveryCodes returns codes formatted xxxxx-xxxxx and their hashes for storage
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := range codes {
+		raw := make([]byte, 10)
+		for j := range raw {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			raw[j] = recoveryCodeAlphabet[n.Int64()]
+		}
+		codes[i] = string(raw[:5]) + "-" + string(raw[5:])
+		hashes[i] = hashSecretCode(string(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes the user may type
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// generateSecureOTPCode creates a 6-digit code from crypto/rand
+func generateSecureOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashSecretCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
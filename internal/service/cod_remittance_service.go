@@ -0,0 +1,494 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/database"
+	"go_app/pkg/logger"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// codAmountTolerance absorbs rounding differences between carrier statements and shipping orders
+const codAmountTolerance = 0.5
+
+// CODRemittanceService defines methods for COD remittance reconciliation
+type CODRemittanceService interface {
+	ImportCODRemittance(req *model.CODRemittanceImportRequest, userID uint) (*model.CODRemittanceResponse, error)
+	ParseCODStatementCSV(reader io.Reader) ([]model.CODRemittanceLineInput, []string, error)
+	ParseCODStatementJSON(reader io.Reader) ([]model.CODRemittanceLineInput, error)
+	GetCODRemittance(id uint, lineStatus string) (*model.CODRemittanceResponse, error)
+	GetCODRemittances(page, limit int, filters map[string]interface{}) ([]model.CODRemittanceResponse, int64, error)
+	GetOutstandingCOD() ([]model.CODOutstandingReport, error)
+}
+
+// codRemittanceService implements CODRemittanceService
+type codRemittanceService struct {
+	remittanceRepo repository.CODRemittanceRepository
+	shippingRepo   repository.ShippingRepository
+	orderRepo      repository.OrderRepository
+}
+
+// NewCODRemittanceService creates a new CODRemittanceService
+func NewCODRemittanceService() CODRemittanceService {
+	return &codRemittanceService{
+		remittanceRepo: repository.NewCODRemittanceRepository(),
+		shippingRepo:   repository.NewShippingRepository(database.GetDB()),
+		orderRepo:      repository.NewOrderRepository(),
+	}
+}
+
+// codOrderState tracks an order's payments while the lines of a statement are settled
+type codOrderState struct {
+	order    *model.Order
+	payments []model.Payment
+	paid     float64
+	changed  bool
+}
+
+// ImportCODRemittance matches a carrier statement to shipping orders by tracking code,
+// records the COD payments of matched lines and flags the lines that need review
+func (s *codRemittanceService) ImportCODRemittance(req *model.CODRemittanceImportRequest, userID uint) (*model.CODRemittanceResponse, error) {
+	req.StatementNumber = strings.TrimSpace(req.StatementNumber)
+	if req.StatementNumber == "" {
+		return nil, errors.New("statement number is required")
+	}
+	if len(req.Lines) == 0 {
+		return nil, errors.New("statement contains no lines")
+	}
+
+	provider, err := s.shippingRepo.GetShippingProviderByID(req.ProviderID)
+	if err != nil {
+		return nil, errors.New("shipping provider not found")
+	}
+
+	existing, err := s.remittanceRepo.GetRemittanceByStatement(provider.ID, req.StatementNumber)
+	if err != nil {
+		logger.Errorf("Failed to check COD remittance %s: %v", req.StatementNumber, err)
+		return nil, fmt.Errorf("failed to import cod remittance")
+	}
+	if existing != nil {
+		return nil, errors.New("cod remittance statement already imported")
+	}
+
+	source := req.Source
+	if source == "" {
+		source = "json"
+	}
+	remittance := &model.CODRemittance{
+		ProviderID:      provider.ID,
+		StatementNumber: req.StatementNumber,
+		StatementDate:   req.StatementDate,
+		Source:          source,
+		FileName:        req.FileName,
+		ImportedBy:      &userID,
+		Lines:           make([]model.CODRemittanceLine, len(req.Lines)),
+	}
+
+	now := time.Now()
+	orders := make(map[uint]*codOrderState)
+	var orderIDs []uint
+	seen := make(map[string]bool)
+	var settlements []repository.CODSettlement
+
+	for i, input := range req.Lines {
+		line := &remittance.Lines[i]
+		line.TrackingCode = strings.TrimSpace(input.TrackingCode)
+		line.CollectedAmount = input.CollectedAmount
+		line.Fee = input.Fee
+		line.NetAmount = input.CollectedAmount - input.Fee
+		line.Message = input.Note
+		if raw, err := json.Marshal(input); err == nil {
+			line.RawData = string(raw)
+		}
+
+		remittance.CollectedAmount += line.CollectedAmount
+		remittance.FeeAmount += line.Fee
+
+		if seen[line.TrackingCode] {
+			line.Status = model.CODLineStatusDuplicate
+			line.Message = "tracking code appears more than once in the statement"
+			continue
+		}
+		seen[line.TrackingCode] = true
+
+		shippingOrder, err := s.shippingRepo.GetShippingOrderByTrackingCode(line.TrackingCode)
+		if err != nil || shippingOrder.ProviderID != provider.ID {
+			line.Status = model.CODLineStatusUnmatched
+			line.Message = "no shipping order with this tracking code for the carrier"
+			continue
+		}
+
+		line.ShippingOrderID = &shippingOrder.ID
+		line.OrderID = &shippingOrder.OrderID
+		line.ExpectedAmount = shippingOrder.COD
+		line.ExpectedFee = shippingOrder.CODFee
+		line.Difference = line.CollectedAmount - shippingOrder.COD
+		remittance.ExpectedAmount += shippingOrder.COD
+
+		switch {
+		case shippingOrder.COD <= 0:
+			line.Status = model.CODLineStatusNotCOD
+			line.Message = "shipping order has no COD amount"
+			continue
+		case shippingOrder.CODRemittanceID != nil:
+			line.Status = model.CODLineStatusAlreadySettled
+			line.Message = fmt.Sprintf("already settled by remittance %d", *shippingOrder.CODRemittanceID)
+			continue
+		case math.Abs(line.Difference) > codAmountTolerance:
+			line.Status = model.CODLineStatusAmountMismatch
+			line.Message = fmt.Sprintf("collected %.0f, expected %.0f", line.CollectedAmount, shippingOrder.COD)
+			continue
+		}
+
+		state, ok := orders[shippingOrder.OrderID]
+		if !ok {
+			state, err = s.loadOrderState(shippingOrder)
+			if err != nil {
+				return nil, err
+			}
+			orders[shippingOrder.OrderID] = state
+			orderIDs = append(orderIDs, shippingOrder.OrderID)
+		}
+
+		payment := state.takePendingPayment(line.CollectedAmount)
+		if payment == nil {
+			payment = &model.Payment{
+				OrderID:       state.order.ID,
+				UserID:        state.order.UserID,
+				PaymentMethod: model.PaymentMethodCOD,
+				Amount:        line.CollectedAmount,
+				Currency:      "VND",
+				TransactionID: fmt.Sprintf("COD-%s", line.TrackingCode),
+				Description:   fmt.Sprintf("COD collected by %s", provider.DisplayName),
+			}
+		}
+		payment.Status = model.PaymentStatusPaid
+		payment.ReferenceID = remittance.StatementNumber
+		payment.ProcessedAt = &now
+		payment.Notes = fmt.Sprintf("COD remittance %s, tracking %s", remittance.StatementNumber, line.TrackingCode)
+		state.paid += line.CollectedAmount
+		state.changed = true
+
+		settlements = append(settlements, repository.CODSettlement{
+			Line:            line,
+			ShippingOrderID: shippingOrder.ID,
+			Payment:         payment,
+		})
+
+		if math.Abs(line.Fee-shippingOrder.CODFee) > codAmountTolerance {
+			line.Status = model.CODLineStatusFeeMismatch
+			line.Message = fmt.Sprintf("fee %.0f, expected %.0f", line.Fee, shippingOrder.CODFee)
+			continue
+		}
+		line.Status = model.CODLineStatusMatched
+	}
+
+	var orderPayments []repository.CODOrderPayment
+	for _, orderID := range orderIDs {
+		state := orders[orderID]
+		if !state.changed || state.paid < state.order.TotalAmount-codAmountTolerance {
+			continue
+		}
+
+		// Fully paid: the remaining pending COD payments are superseded
+		update := repository.CODOrderPayment{
+			OrderID:       orderID,
+			PaymentStatus: model.PaymentStatusPaid,
+			PaidAt:        &now,
+		}
+		for _, payment := range state.payments {
+			if payment.PaymentMethod == model.PaymentMethodCOD && payment.Status == model.PaymentStatusPending {
+				update.CancelPaymentIDs = append(update.CancelPaymentIDs, payment.ID)
+			}
+		}
+		orderPayments = append(orderPayments, update)
+	}
+
+	remittance.TotalLines = len(remittance.Lines)
+	for i := range remittance.Lines {
+		if remittance.Lines[i].IsDiscrepancy() {
+			remittance.DiscrepancyLines++
+		} else {
+			remittance.MatchedLines++
+		}
+	}
+	remittance.NetAmount = remittance.CollectedAmount - remittance.FeeAmount
+	remittance.Status = model.CODRemittanceStatusReconciled
+	if remittance.DiscrepancyLines > 0 {
+		remittance.Status = model.CODRemittanceStatusDiscrepancy
+	}
+
+	if err := s.remittanceRepo.SettleRemittance(remittance, settlements, orderPayments); err != nil {
+		if errors.Is(err, repository.ErrCODAlreadySettled) {
+			return nil, errors.New("shipping order was settled by another remittance, please retry")
+		}
+		logger.Errorf("Failed to save COD remittance %s: %v", remittance.StatementNumber, err)
+		return nil, fmt.Errorf("failed to import cod remittance")
+	}
+
+	remittance.Provider = provider
+	return toCODRemittanceResponse(remittance), nil
+}
+
+// loadOrderState loads the order of a shipping order with its payments
+func (s *codRemittanceService) loadOrderState(shippingOrder *model.ShippingOrder) (*codOrderState, error) {
+	order := shippingOrder.Order
+	if order == nil {
+		var err error
+		order, err = s.orderRepo.GetOrderByID(shippingOrder.OrderID)
+		if err != nil || order == nil {
+			logger.Errorf("Failed to get order %d for COD remittance: %v", shippingOrder.OrderID, err)
+			return nil, fmt.Errorf("failed to import cod remittance")
+		}
+	}
+
+	payments, err := s.orderRepo.GetPaymentsByOrder(order.ID)
+	if err != nil {
+		logger.Errorf("Failed to get payments of order %d for COD remittance: %v", order.ID, err)
+		return nil, fmt.Errorf("failed to import cod remittance")
+	}
+
+	state := &codOrderState{order: order, payments: payments}
+	for _, payment := range payments {
+		if payment.Status == model.PaymentStatusPaid {
+			state.paid += payment.Amount
+		}
+	}
+	return state, nil
+}
+
+// takePendingPayment returns the order's pending COD payment for the amount, if any,
+// so the remitted cash settles it instead of adding a new payment
+func (st *codOrderState) takePendingPayment(amount float64) *model.Payment {
+	for i := range st.payments {
+		payment := &st.payments[i]
+		if payment.PaymentMethod == model.PaymentMethodCOD && payment.Status == model.PaymentStatusPending &&
+			math.Abs(payment.Amount-amount) <= codAmountTolerance {
+			payment.Status = model.PaymentStatusPaid
+			payment.Order = nil
+			payment.User = nil
+			return payment
+		}
+	}
+	return nil
+}
+
+// ParseCODStatementCSV reads statement lines from a CSV file with columns
+// tracking_code,collected_amount[,fee[,note]]; a header row may name and reorder the columns
+func (s *codRemittanceService) ParseCODStatementCSV(reader io.Reader) ([]model.CODRemittanceLineInput, []string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV file: %v", err)
+	}
+
+	columns := map[string]int{"tracking_code": 0, "collected_amount": 1, "fee": 2, "note": 3}
+	var lines []model.CODRemittanceLineInput
+	var parseErrors []string
+	for i, record := range records {
+		if i == 0 {
+			if header, ok := codStatementHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		line := model.CODRemittanceLineInput{
+			TrackingCode: field("tracking_code"),
+			Note:         field("note"),
+		}
+		if line.TrackingCode == "" {
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: missing tracking code", i+1))
+			continue
+		}
+
+		amount, err := parseCODAmount(field("collected_amount"))
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: invalid collected amount %q", i+1, field("collected_amount")))
+			continue
+		}
+		fee, err := parseCODAmount(field("fee"))
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: invalid fee %q", i+1, field("fee")))
+			continue
+		}
+		line.CollectedAmount = amount
+		line.Fee = fee
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		if len(parseErrors) > 0 {
+			return nil, nil, fmt.Errorf("no valid rows in CSV file: %s", strings.Join(parseErrors, "; "))
+		}
+		return nil, nil, errors.New("CSV file contains no lines")
+	}
+	return lines, parseErrors, nil
+}
+
+// ParseCODStatementJSON reads statement lines from a JSON array or an object with a "lines" array
+func (s *codRemittanceService) ParseCODStatementJSON(reader io.Reader) ([]model.CODRemittanceLineInput, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON file: %v", err)
+	}
+
+	var lines []model.CODRemittanceLineInput
+	if err := json.Unmarshal(data, &lines); err != nil {
+		var statement struct {
+			Lines []model.CODRemittanceLineInput `json:"lines"`
+		}
+		if err := json.Unmarshal(data, &statement); err != nil {
+			return nil, fmt.Errorf("invalid JSON file: %v", err)
+		}
+		lines = statement.Lines
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("JSON file contains no lines")
+	}
+	return lines, nil
+}
+
+// codStatementHeader maps the column names of a header row, accepting the usual carrier aliases
+func codStatementHeader(record []string) (map[string]int, bool) {
+	aliases := map[string]string{
+		"tracking_code":    "tracking_code",
+		"tracking":         "tracking_code",
+		"label":            "tracking_code",
+		"label_id":         "tracking_code",
+		"order_code":       "tracking_code",
+		"collected_amount": "collected_amount",
+		"collected":        "collected_amount",
+		"cod":              "collected_amount",
+		"cod_amount":       "collected_amount",
+		"amount":           "collected_amount",
+		"fee":              "fee",
+		"cod_fee":          "fee",
+		"fees":             "fee",
+		"note":             "note",
+		"notes":            "note",
+	}
+
+	columns := make(map[string]int)
+	for i, name := range record {
+		key := strings.ToLower(strings.TrimSpace(name))
+		key = strings.ReplaceAll(key, " ", "_")
+		if column, ok := aliases[key]; ok {
+			if _, exists := columns[column]; !exists {
+				columns[column] = i
+			}
+		}
+	}
+
+	_, hasTracking := columns["tracking_code"]
+	_, hasAmount := columns["collected_amount"]
+	return columns, hasTracking && hasAmount
+}
+
+// parseCODAmount parses an amount, allowing thousands separators such as "1,250,000"
+func parseCODAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, errors.New("invalid amount")
+	}
+	return amount, nil
+}
+
+// GetCODRemittance gets a statement with its lines, optionally filtered by line status
+func (s *codRemittanceService) GetCODRemittance(id uint, lineStatus string) (*model.CODRemittanceResponse, error) {
+	remittance, err := s.remittanceRepo.GetRemittanceByID(id)
+	if err != nil {
+		logger.Errorf("Failed to get COD remittance %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get cod remittance")
+	}
+	if remittance == nil {
+		return nil, errors.New("cod remittance not found")
+	}
+
+	if lineStatus != "" {
+		lines, err := s.remittanceRepo.GetRemittanceLines(id, lineStatus)
+		if err != nil {
+			logger.Errorf("Failed to get lines of COD remittance %d: %v", id, err)
+			return nil, fmt.Errorf("failed to get cod remittance")
+		}
+		remittance.Lines = lines
+	}
+
+	return toCODRemittanceResponse(remittance), nil
+}
+
+// GetCODRemittances lists imported statements
+func (s *codRemittanceService) GetCODRemittances(page, limit int, filters map[string]interface{}) ([]model.CODRemittanceResponse, int64, error) {
+	remittances, total, err := s.remittanceRepo.GetRemittances(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Failed to get COD remittances: %v", err)
+		return nil, 0, fmt.Errorf("failed to get cod remittances")
+	}
+
+	responses := make([]model.CODRemittanceResponse, len(remittances))
+	for i := range remittances {
+		responses[i] = *toCODRemittanceResponse(&remittances[i])
+	}
+	return responses, total, nil
+}
+
+// GetOutstandingCOD reports the COD each carrier still owes
+func (s *codRemittanceService) GetOutstandingCOD() ([]model.CODOutstandingReport, error) {
+	reports, err := s.remittanceRepo.GetOutstandingCOD()
+	if err != nil {
+		logger.Errorf("Failed to get outstanding COD: %v", err)
+		return nil, fmt.Errorf("failed to get outstanding cod")
+	}
+	return reports, nil
+}
+
+func toCODRemittanceResponse(remittance *model.CODRemittance) *model.CODRemittanceResponse {
+	response := &model.CODRemittanceResponse{
+		ID:               remittance.ID,
+		ProviderID:       remittance.ProviderID,
+		StatementNumber:  remittance.StatementNumber,
+		StatementDate:    remittance.StatementDate,
+		Source:           remittance.Source,
+		FileName:         remittance.FileName,
+		TotalLines:       remittance.TotalLines,
+		MatchedLines:     remittance.MatchedLines,
+		DiscrepancyLines: remittance.DiscrepancyLines,
+		CollectedAmount:  remittance.CollectedAmount,
+		FeeAmount:        remittance.FeeAmount,
+		NetAmount:        remittance.NetAmount,
+		ExpectedAmount:   remittance.ExpectedAmount,
+		Status:           remittance.Status,
+		Lines:            remittance.Lines,
+		ImportedBy:       remittance.ImportedBy,
+		CreatedAt:        remittance.CreatedAt,
+	}
+	if remittance.Provider != nil {
+		response.ProviderName = remittance.Provider.DisplayName
+		response.ProviderCode = remittance.Provider.Code
+	}
+	return response
+}
@@ -0,0 +1,610 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	deliverySlotDateLayout      = "2006-01-02"
+	defaultDeliverySlotDays     = 7
+	maxDeliverySlotDays         = 14 // Customers can book up to two weeks ahead
+	maxDeliverySlotReportDays   = 31
+	defaultDeliverySlotHoldMins = 30
+)
+
+// DeliverySlotService defines methods for delivery slot business logic
+type DeliverySlotService interface {
+	// Templates
+	CreateTemplate(req *model.DeliverySlotTemplateRequest) (*model.DeliverySlotTemplate, error)
+	GetTemplateByID(id uint) (*model.DeliverySlotTemplate, error)
+	GetTemplates(filters map[string]interface{}) ([]model.DeliverySlotTemplate, error)
+	UpdateTemplate(id uint, req *model.DeliverySlotTemplateRequest) (*model.DeliverySlotTemplate, error)
+	DeleteTemplate(id uint) error
+
+	// Checkout
+	GetAvailability(province, district, from string, days int) ([]model.DeliverySlotAvailability, error)
+	ReserveSlot(req *model.DeliverySlotReserveRequest, userID uint) (*model.DeliverySlotReservation, error)
+	GetCartReservation(cartID, userID uint) (*model.DeliverySlotReservation, error)
+	ReleaseCartReservation(cartID, userID uint) error
+
+	// Orders
+	GetValidCartHold(cartID uint) (*model.DeliverySlotReservation, error)
+	ConfirmReservation(reservationID, orderID uint) error
+	ReleaseOrderReservation(orderID uint) error
+	ExpireHolds() (int64, error)
+
+	// Reporting
+	GetUtilization(from, to, province, district string) ([]model.DeliverySlotUtilization, error)
+}
+
+// deliverySlotService implements DeliverySlotService
+type deliverySlotService struct {
+	slotRepo  repository.DeliverySlotRepository
+	orderRepo repository.OrderRepository
+	locations LocationService
+	holdTTL   time.Duration
+}
+
+// NewDeliverySlotService creates a new DeliverySlotService
+func NewDeliverySlotService() DeliverySlotService {
+	holdMinutes := configs.Load().Shipping.SlotHoldMinutes
+	if holdMinutes <= 0 {
+		holdMinutes = defaultDeliverySlotHoldMins
+	}
+
+	return &deliverySlotService{
+		slotRepo:  repository.NewDeliverySlotRepository(),
+		orderRepo: repository.NewOrderRepository(),
+		locations: NewLocationService(),
+		holdTTL:   time.Duration(holdMinutes) * time.Minute,
+	}
+}
+
+// CreateTemplate creates a new delivery slot template
+func (s *deliverySlotService) CreateTemplate(req *model.DeliverySlotTemplateRequest) (*model.DeliverySlotTemplate, error) {
+	template := &model.DeliverySlotTemplate{IsActive: true, CutoffMinutes: 120}
+	if err := s.applyTemplateRequest(template, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.slotRepo.CreateTemplate(template); err != nil {
+		logger.Errorf("Error creating delivery slot template: %v", err)
+		return nil, fmt.Errorf("failed to create delivery slot template")
+	}
+
+	return template, nil
+}
+
+// GetTemplateByID retrieves a delivery slot template by its ID
+func (s *deliverySlotService) GetTemplateByID(id uint) (*model.DeliverySlotTemplate, error) {
+	template, err := s.slotRepo.GetTemplateByID(id)
+	if err != nil {
+		logger.Errorf("Error getting delivery slot template %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get delivery slot template")
+	}
+	if template == nil {
+		return nil, errors.New("delivery slot template not found")
+	}
+	return template, nil
+}
+
+// GetTemplates lists delivery slot templates
+func (s *deliverySlotService) GetTemplates(filters map[string]interface{}) ([]model.DeliverySlotTemplate, error) {
+	if province, ok := filters["province"].(string); ok {
+		district, _ := filters["district"].(string)
+		filters["province"], district = s.locations.CanonicalZone(province, district)
+		if district != "" {
+			filters["district"] = district
+		}
+	}
+
+	templates, err := s.slotRepo.GetTemplates(filters)
+	if err != nil {
+		logger.Errorf("Error getting delivery slot templates: %v", err)
+		return nil, fmt.Errorf("failed to get delivery slot templates")
+	}
+	return templates, nil
+}
+
+// UpdateTemplate updates a delivery slot template; existing bookings keep their window
+func (s *deliverySlotService) UpdateTemplate(id uint, req *model.DeliverySlotTemplateRequest) (*model.DeliverySlotTemplate, error) {
+	template, err := s.GetTemplateByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyTemplateRequest(template, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.slotRepo.UpdateTemplate(template); err != nil {
+		logger.Errorf("Error updating delivery slot template %d: %v", id, err)
+		return nil, fmt.Errorf("failed to update delivery slot template")
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate deletes a delivery slot template
+func (s *deliverySlotService) DeleteTemplate(id uint) error {
+	if _, err := s.GetTemplateByID(id); err != nil {
+		return err
+	}
+
+	if err := s.slotRepo.DeleteTemplate(id); err != nil {
+		logger.Errorf("Error deleting delivery slot template %d: %v", id, err)
+		return fmt.Errorf("failed to delete delivery slot template")
+	}
+	return nil
+}
+
+// GetAvailability lists the windows offered to a delivery address over the next days with remaining capacity
+func (s *deliverySlotService) GetAvailability(province, district, from string, days int) ([]model.DeliverySlotAvailability, error) {
+	now := time.Now()
+	today := dateOf(now)
+
+	start := today
+	if from != "" {
+		date, err := time.ParseInLocation(deliverySlotDateLayout, from, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		if date.After(start) {
+			start = date
+		}
+	}
+	if days <= 0 {
+		days = defaultDeliverySlotDays
+	}
+
+	// Never offer dates beyond the booking horizon
+	end := start.AddDate(0, 0, days-1)
+	if horizon := today.AddDate(0, 0, maxDeliverySlotDays-1); end.After(horizon) {
+		end = horizon
+	}
+	if end.Before(start) {
+		return []model.DeliverySlotAvailability{}, nil
+	}
+
+	province, district = s.locations.CanonicalZone(province, district)
+	templates, err := s.slotRepo.GetActiveTemplatesForZone(province, district)
+	if err != nil {
+		logger.Errorf("Error getting delivery slot templates for %s/%s: %v", province, district, err)
+		return nil, fmt.Errorf("failed to get delivery slots")
+	}
+
+	booked, err := s.bookingCounts(templates, start, end, now)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := []model.DeliverySlotAvailability{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		for _, template := range templates {
+			if !runsOn(&template, date) {
+				continue
+			}
+			windowStart, windowEnd, err := slotWindow(&template, date)
+			if err != nil {
+				logger.Warnf("Skipping delivery slot template %d with invalid window: %v", template.ID, err)
+				continue
+			}
+
+			key := bookingKey(template.ID, date)
+			count := booked[key].confirmed + booked[key].held
+			slot := model.DeliverySlotAvailability{
+				TemplateID:  template.ID,
+				Name:        template.Name,
+				Date:        date.Format(deliverySlotDateLayout),
+				WindowStart: windowStart,
+				WindowEnd:   windowEnd,
+				Capacity:    template.Capacity,
+				Booked:      count,
+				Remaining:   remainingCapacity(template.Capacity, count),
+				Fee:         template.Fee,
+				IsAvailable: true,
+			}
+
+			switch {
+			case !now.Before(bookingCutoff(&template, windowStart)):
+				slot.IsAvailable = false
+				slot.Reason = model.DeliverySlotReasonCutoff
+			case slot.Remaining == 0:
+				slot.IsAvailable = false
+				slot.Reason = model.DeliverySlotReasonFull
+			}
+
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots, nil
+}
+
+// ReserveSlot holds a slot for a cart until the hold expires or an order is placed from the cart
+func (s *deliverySlotService) ReserveSlot(req *model.DeliverySlotReserveRequest, userID uint) (*model.DeliverySlotReservation, error) {
+	if _, err := s.getUserCart(req.CartID, userID); err != nil {
+		return nil, err
+	}
+
+	template, err := s.GetTemplateByID(req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, errors.New("delivery slot is not available")
+	}
+
+	province, district := s.locations.CanonicalZone(req.Province, req.District)
+	if !zoneMatches(template.Province, province) || !zoneMatches(template.District, district) {
+		return nil, errors.New("delivery slot does not serve this address")
+	}
+
+	date, err := time.ParseInLocation(deliverySlotDateLayout, req.Date, time.Local)
+	if err != nil {
+		return nil, errors.New("invalid date, expected YYYY-MM-DD")
+	}
+
+	now := time.Now()
+	today := dateOf(now)
+	if date.Before(today) || date.After(today.AddDate(0, 0, maxDeliverySlotDays-1)) {
+		return nil, fmt.Errorf("delivery date must be within the next %d days", maxDeliverySlotDays)
+	}
+	if !runsOn(template, date) {
+		return nil, errors.New("delivery slot is not offered on this date")
+	}
+
+	windowStart, windowEnd, err := slotWindow(template, date)
+	if err != nil {
+		logger.Errorf("Delivery slot template %d has an invalid window: %v", template.ID, err)
+		return nil, fmt.Errorf("failed to reserve delivery slot")
+	}
+	if !now.Before(bookingCutoff(template, windowStart)) {
+		return nil, errors.New("delivery slot booking cutoff has passed")
+	}
+
+	expiresAt := now.Add(s.holdTTL)
+	cartID := req.CartID
+	reservation := &model.DeliverySlotReservation{
+		TemplateID:   template.ID,
+		DeliveryDate: date,
+		WindowStart:  windowStart,
+		WindowEnd:    windowEnd,
+		UserID:       userID,
+		CartID:       &cartID,
+		Status:       model.DeliverySlotStatusHeld,
+		ExpiresAt:    &expiresAt,
+		Province:     province,
+		District:     district,
+		Fee:          template.Fee,
+	}
+
+	if err := s.slotRepo.HoldSlot(reservation, template.Capacity); err != nil {
+		if errors.Is(err, repository.ErrDeliverySlotFull) {
+			return nil, err
+		}
+		logger.Errorf("Error holding delivery slot %d on %s for cart %d: %v", template.ID, req.Date, req.CartID, err)
+		return nil, fmt.Errorf("failed to reserve delivery slot")
+	}
+
+	reservation.Template = template
+	return reservation, nil
+}
+
+// GetCartReservation retrieves the slot currently held for a cart
+func (s *deliverySlotService) GetCartReservation(cartID, userID uint) (*model.DeliverySlotReservation, error) {
+	if _, err := s.getUserCart(cartID, userID); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.GetValidCartHold(cartID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil {
+		return nil, errors.New("delivery slot reservation not found")
+	}
+	return reservation, nil
+}
+
+// ReleaseCartReservation gives back the slot held for a cart
+func (s *deliverySlotService) ReleaseCartReservation(cartID, userID uint) error {
+	if _, err := s.getUserCart(cartID, userID); err != nil {
+		return err
+	}
+
+	if err := s.slotRepo.ReleaseByCart(cartID, time.Now()); err != nil {
+		logger.Errorf("Error releasing delivery slot for cart %d: %v", cartID, err)
+		return fmt.Errorf("failed to release delivery slot")
+	}
+	return nil
+}
+
+// GetValidCartHold retrieves the cart's hold; nil when the cart has none and an error when it has expired
+func (s *deliverySlotService) GetValidCartHold(cartID uint) (*model.DeliverySlotReservation, error) {
+	reservation, err := s.slotRepo.GetHeldByCart(cartID)
+	if err != nil {
+		logger.Errorf("Error getting delivery slot hold for cart %d: %v", cartID, err)
+		return nil, fmt.Errorf("failed to get delivery slot reservation")
+	}
+	if reservation == nil {
+		return nil, nil
+	}
+	if !reservation.IsActiveAt(time.Now()) {
+		return nil, errors.New("delivery slot reservation has expired, please choose a slot again")
+	}
+	return reservation, nil
+}
+
+// ConfirmReservation books the held slot for the order placed from the cart
+func (s *deliverySlotService) ConfirmReservation(reservationID, orderID uint) error {
+	if err := s.slotRepo.ConfirmReservation(reservationID, orderID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("delivery slot reservation has expired, please choose a slot again")
+		}
+		logger.Errorf("Error confirming delivery slot reservation %d for order %d: %v", reservationID, orderID, err)
+		return fmt.Errorf("failed to confirm delivery slot")
+	}
+	return nil
+}
+
+// ReleaseOrderReservation frees the slot booked by a cancelled order
+func (s *deliverySlotService) ReleaseOrderReservation(orderID uint) error {
+	if err := s.slotRepo.ReleaseByOrder(orderID, time.Now()); err != nil {
+		logger.Errorf("Error releasing delivery slot for order %d: %v", orderID, err)
+		return fmt.Errorf("failed to release delivery slot")
+	}
+	return nil
+}
+
+// ExpireHolds marks holds past their expiry as expired
+func (s *deliverySlotService) ExpireHolds() (int64, error) {
+	expired, err := s.slotRepo.ExpireHolds(time.Now())
+	if err != nil {
+		logger.Errorf("Error expiring delivery slot holds: %v", err)
+		return 0, fmt.Errorf("failed to expire delivery slot holds")
+	}
+	return expired, nil
+}
+
+// GetUtilization reports bookings against capacity for each window and day in a date range
+func (s *deliverySlotService) GetUtilization(from, to, province, district string) ([]model.DeliverySlotUtilization, error) {
+	today := dateOf(time.Now())
+	start, end := today, today.AddDate(0, 0, defaultDeliverySlotDays-1)
+
+	var err error
+	if from != "" {
+		if start, err = time.ParseInLocation(deliverySlotDateLayout, from, time.Local); err != nil {
+			return nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		if to == "" {
+			end = start.AddDate(0, 0, defaultDeliverySlotDays-1)
+		}
+	}
+	if to != "" {
+		if end, err = time.ParseInLocation(deliverySlotDateLayout, to, time.Local); err != nil {
+			return nil, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	if end.Before(start) {
+		return nil, errors.New("to date must not be before from date")
+	}
+	if end.Sub(start) >= maxDeliverySlotReportDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", maxDeliverySlotReportDays)
+	}
+
+	filters := map[string]interface{}{}
+	if province != "" {
+		filters["province"] = province
+		if district != "" {
+			filters["district"] = district
+		}
+	}
+	templates, err := s.GetTemplates(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := s.bookingCounts(templates, start, end, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report := []model.DeliverySlotUtilization{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		for _, template := range templates {
+			key := bookingKey(template.ID, date)
+			counts := booked[key]
+			// Inactive templates and days off only show up when they still have bookings
+			if (!template.IsActive || !runsOn(&template, date)) && counts.confirmed+counts.held == 0 {
+				continue
+			}
+			windowStart, windowEnd, err := slotWindow(&template, date)
+			if err != nil {
+				continue
+			}
+
+			row := model.DeliverySlotUtilization{
+				TemplateID:  template.ID,
+				Name:        template.Name,
+				Province:    template.Province,
+				District:    template.District,
+				Date:        date.Format(deliverySlotDateLayout),
+				WindowStart: windowStart,
+				WindowEnd:   windowEnd,
+				Capacity:    template.Capacity,
+				Confirmed:   counts.confirmed,
+				Held:        counts.held,
+				Remaining:   remainingCapacity(template.Capacity, counts.confirmed+counts.held),
+			}
+			if template.Capacity > 0 {
+				rate := float64(counts.confirmed+counts.held) / float64(template.Capacity) * 100
+				row.UtilizationRate = math.Round(rate*100) / 100
+			}
+			report = append(report, row)
+		}
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		return report[i].WindowStart.Before(report[j].WindowStart)
+	})
+	return report, nil
+}
+
+// Helper methods
+
+// slotBookings holds the active bookings of one window on one day
+type slotBookings struct {
+	confirmed int64
+	held      int64
+}
+
+// bookingCounts loads active bookings of templates between two dates keyed by bookingKey
+func (s *deliverySlotService) bookingCounts(templates []model.DeliverySlotTemplate, from, to, now time.Time) (map[string]slotBookings, error) {
+	ids := make([]uint, 0, len(templates))
+	for _, template := range templates {
+		ids = append(ids, template.ID)
+	}
+
+	counts, err := s.slotRepo.CountBookings(ids, from, to, now)
+	if err != nil {
+		logger.Errorf("Error counting delivery slot bookings: %v", err)
+		return nil, fmt.Errorf("failed to count delivery slot bookings")
+	}
+
+	booked := make(map[string]slotBookings, len(counts))
+	for _, count := range counts {
+		key := bookingKey(count.TemplateID, count.DeliveryDate)
+		entry := booked[key]
+		if count.Status == model.DeliverySlotStatusConfirmed {
+			entry.confirmed += count.Count
+		} else {
+			entry.held += count.Count
+		}
+		booked[key] = entry
+	}
+	return booked, nil
+}
+
+// getUserCart retrieves a cart owned by the user
+func (s *deliverySlotService) getUserCart(cartID, userID uint) (*model.Cart, error) {
+	cart, err := s.orderRepo.GetCartByID(cartID)
+	if err != nil {
+		logger.Errorf("Error getting cart %d: %v", cartID, err)
+		return nil, fmt.Errorf("failed to get cart")
+	}
+	if cart == nil || cart.UserID != userID {
+		return nil, errors.New("cart not found")
+	}
+	return cart, nil
+}
+
+// applyTemplateRequest validates a template request and copies it onto the template
+func (s *deliverySlotService) applyTemplateRequest(template *model.DeliverySlotTemplate, req *model.DeliverySlotTemplateRequest) error {
+	opens, err := parseClock(req.StartTime)
+	if err != nil || opens >= 24*60 {
+		return errors.New("invalid start time, expected HH:MM")
+	}
+	closes, err := parseClock(req.EndTime)
+	if err != nil {
+		return errors.New("invalid end time, expected HH:MM")
+	}
+	if closes <= opens {
+		return errors.New("end time must be after start time")
+	}
+
+	days := make([]string, 0, len(req.DaysOfWeek))
+	seen := make(map[int]bool)
+	sort.Ints(req.DaysOfWeek)
+	for _, day := range req.DaysOfWeek {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, strconv.Itoa(day))
+		}
+	}
+
+	template.Name = req.Name
+	template.Province, template.District = s.locations.CanonicalZone(req.Province, req.District)
+	template.DaysOfWeek = strings.Join(days, ",")
+	template.StartTime = req.StartTime
+	template.EndTime = req.EndTime
+	template.Capacity = req.Capacity
+	template.Fee = req.Fee
+	template.SortOrder = req.SortOrder
+	if req.CutoffMinutes != nil {
+		template.CutoffMinutes = *req.CutoffMinutes
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// runsOn checks if a template offers its window on a date
+func runsOn(template *model.DeliverySlotTemplate, date time.Time) bool {
+	if template.DaysOfWeek == "" {
+		return true
+	}
+	weekday := strconv.Itoa(int(date.Weekday()))
+	for _, day := range strings.Split(template.DaysOfWeek, ",") {
+		if strings.TrimSpace(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// slotWindow returns the start and end of a template's window on a date
+func slotWindow(template *model.DeliverySlotTemplate, date time.Time) (time.Time, time.Time, error) {
+	opens, err := parseClock(template.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	closes, err := parseClock(template.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return date.Add(time.Duration(opens) * time.Minute), date.Add(time.Duration(closes) * time.Minute), nil
+}
+
+// bookingCutoff returns the last moment a window can still be booked
+func bookingCutoff(template *model.DeliverySlotTemplate, windowStart time.Time) time.Time {
+	return windowStart.Add(-time.Duration(template.CutoffMinutes) * time.Minute)
+}
+
+// bookingKey identifies a template's window on a date
+func bookingKey(templateID uint, date time.Time) string {
+	return fmt.Sprintf("%d|%s", templateID, date.Format(deliverySlotDateLayout))
+}
+
+// remainingCapacity returns the capacity left after bookings, never negative
+func remainingCapacity(capacity int, booked int64) int64 {
+	if remaining := int64(capacity) - booked; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// zoneMatches checks if a template zone covers a province or district
+func zoneMatches(zone, value string) bool {
+	return zone == model.ShippingZoneWildcard || strings.EqualFold(zone, value)
+}
+
+// dateOf truncates a time to midnight local time
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.In(time.Local).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
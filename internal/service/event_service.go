@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go_app/internal/model"
 	"go_app/pkg/logger"
+	"strings"
 	"time"
 )
 
@@ -17,9 +18,14 @@ type EventService interface {
 	// Order events
 	OnOrderCreated(order *model.Order) error
 	OnOrderStatusUpdated(order *model.Order, oldStatus, newStatus model.OrderStatus) error
-	OnOrderShipped(order *model.Order, trackingNumber string) error
+	OnOrderShipped(order *model.Order, shipment *model.Shipment) error
 	OnOrderDelivered(order *model.Order) error
 	OnOrderCancelled(order *model.Order, reason string) error
+	OnBackorderAvailable(order *model.Order, item *model.OrderItem) error
+	OnOrderReadyForPickup(order *model.Order, location *model.PickupLocation) error
+
+	// Shipping events
+	OnShippingException(exception *model.ShippingException) error
 
 	// Payment events
 	OnPaymentSuccess(order *model.Order, payment *model.Payment) error
@@ -38,6 +44,7 @@ type EventService interface {
 
 	// Inventory events
 	OnLowStockAlert(product *model.Product, currentStock, minStock int) error
+	OnLotNearExpiry(lot *model.InventoryLot, daysLeft int) error
 
 	// Coupon events
 	OnCouponExpiring(coupon *model.Coupon, daysLeft int) error
@@ -45,6 +52,13 @@ type EventService interface {
 	// Point events
 	OnPointsEarned(userID uint, points int64, source string) error
 	OnPointsExpiring(userID uint, points int64, expiryDate time.Time) error
+
+	// Security events
+	OnSuspiciousLogin(attempt *model.LoginAttempt, reasons []string) error
+	OnAccountLocked(lockout *model.AccountLockout) error
+
+	// Privacy events
+	OnDataExportReady(export *model.DataExportRequest) error
 }
 
 // eventService implements EventService
@@ -133,6 +147,16 @@ func (s *eventService) OnOrderStatusUpdated(order *model.Order, oldStatus, newSt
 		message = fmt.Sprintf("Great news! Your order #%s has been shipped and is on its way to you.", order.OrderNumber)
 		priority = model.NotificationPriorityHigh
 
+	case model.OrderStatusPartiallyShipped:
+		title = fmt.Sprintf("Order Partially Shipped - #%s", order.OrderNumber)
+		message = fmt.Sprintf("Part of your order #%s has been shipped. The remaining backordered items will follow as soon as they are in stock.", order.OrderNumber)
+		priority = model.NotificationPriorityHigh
+
+	case model.OrderStatusReadyForPickup:
+		title = fmt.Sprintf("Order Ready for Pickup - #%s", order.OrderNumber)
+		message = fmt.Sprintf("Your order #%s is ready for pickup at the store you selected.", order.OrderNumber)
+		priority = model.NotificationPriorityHigh
+
 	case model.OrderStatusDelivered:
 		title = fmt.Sprintf("Order Delivered - #%s", order.OrderNumber)
 		message = fmt.Sprintf("Your order #%s has been delivered successfully. Thank you for your purchase!", order.OrderNumber)
@@ -181,7 +205,7 @@ func (s *eventService) OnOrderStatusUpdated(order *model.Order, oldStatus, newSt
 	}
 
 	// Also send in-app notification for important status changes
-	if newStatus == model.OrderStatusShipped || newStatus == model.OrderStatusDelivered || newStatus == model.OrderStatusCancelled {
+	if newStatus == model.OrderStatusShipped || newStatus == model.OrderStatusReadyForPickup || newStatus == model.OrderStatusDelivered || newStatus == model.OrderStatusCancelled {
 		notification.Channel = model.NotificationChannelInApp
 		if err := s.sendNotification(notification); err != nil {
 			logger.Errorf("Failed to create in-app order status notification: %v", err)
@@ -192,19 +216,57 @@ func (s *eventService) OnOrderStatusUpdated(order *model.Order, oldStatus, newSt
 	return nil
 }
 
-// OnOrderShipped handles order shipped event
-func (s *eventService) OnOrderShipped(order *model.Order, trackingNumber string) error {
+// OnOrderShipped handles a shipment of an order leaving the warehouse; orders sent in
+// several parcels get one notification per shipment
+func (s *eventService) OnOrderShipped(order *model.Order, shipment *model.Shipment) error {
+	orderItems := make(map[uint]*model.OrderItem, len(order.OrderItems))
+	for i := range order.OrderItems {
+		orderItems[order.OrderItems[i].ID] = &order.OrderItems[i]
+	}
+
+	var lines []string
+	items := make([]map[string]interface{}, 0, len(shipment.Items))
+	for _, item := range shipment.Items {
+		name := fmt.Sprintf("Item #%d", item.OrderItemID)
+		if orderItem := orderItems[item.OrderItemID]; orderItem != nil {
+			name = orderItem.ProductName
+		}
+		lines = append(lines, fmt.Sprintf("%s x%d", name, item.Quantity))
+		items = append(items, map[string]interface{}{
+			"order_item_id": item.OrderItemID,
+			"product_name":  name,
+			"quantity":      item.Quantity,
+		})
+	}
+
+	title := fmt.Sprintf("Order Shipped - #%s", order.OrderNumber)
+	message := fmt.Sprintf("Your order #%s has been shipped!", order.OrderNumber)
+	if order.ShippingStatus == model.ShippingStatusPartiallyShipped {
+		title = fmt.Sprintf("Part of Your Order Shipped - #%s", order.OrderNumber)
+		message = fmt.Sprintf("A package from your order #%s has been shipped. The remaining items will follow in a separate shipment.", order.OrderNumber)
+	}
+	if len(lines) > 0 {
+		message += fmt.Sprintf(" Items: %s.", strings.Join(lines, ", "))
+	}
+	if shipment.TrackingNumber != "" {
+		message += fmt.Sprintf(" Tracking number: %s. You can track your package using the link below.", shipment.TrackingNumber)
+	}
+
 	notification := &model.CreateNotificationRequest{
 		UserID:   &order.UserID,
 		Type:     model.NotificationTypeShipping,
 		Priority: model.NotificationPriorityHigh,
 		Channel:  model.NotificationChannelEmail,
-		Title:    fmt.Sprintf("Order Shipped - #%s", order.OrderNumber),
-		Message:  fmt.Sprintf("Your order #%s has been shipped! Tracking number: %s. You can track your package using the link below.", order.OrderNumber, trackingNumber),
+		Title:    title,
+		Message:  message,
 		Data: map[string]interface{}{
 			"order_id":           order.ID,
 			"order_number":       order.OrderNumber,
-			"tracking_number":    trackingNumber,
+			"shipment_id":        shipment.ID,
+			"carrier":            shipment.Carrier,
+			"tracking_number":    shipment.TrackingNumber,
+			"items":              items,
+			"shipping_status":    string(order.ShippingStatus),
 			"shipped_at":         time.Now().Format("2006-01-02 15:04:05"),
 			"estimated_delivery": "3-5 business days",
 		},
@@ -218,13 +280,16 @@ func (s *eventService) OnOrderShipped(order *model.Order, trackingNumber string)
 
 	// Also send SMS for important shipping updates
 	notification.Channel = model.NotificationChannelSMS
-	notification.Message = fmt.Sprintf("Your order #%s has been shipped! Track: %s", order.OrderNumber, trackingNumber)
+	notification.Message = fmt.Sprintf("Your order #%s has been shipped! Track: %s", order.OrderNumber, shipment.TrackingNumber)
+	if order.ShippingStatus == model.ShippingStatusPartiallyShipped {
+		notification.Message = fmt.Sprintf("Part of your order #%s has been shipped! Track: %s", order.OrderNumber, shipment.TrackingNumber)
+	}
 
 	if err := s.sendNotification(notification); err != nil {
 		logger.Errorf("Failed to create SMS shipping notification: %v", err)
 	}
 
-	logger.Infof("Order shipped notification sent for order #%s with tracking %s", order.OrderNumber, trackingNumber)
+	logger.Infof("Order shipped notification sent for order #%s, shipment %d with tracking %s", order.OrderNumber, shipment.ID, shipment.TrackingNumber)
 	return nil
 }
 
@@ -292,6 +357,125 @@ func (s *eventService) OnOrderCancelled(order *model.Order, reason string) error
 	return nil
 }
 
+// OnBackorderAvailable handles a backordered item becoming available
+func (s *eventService) OnBackorderAvailable(order *model.Order, item *model.OrderItem) error {
+	notification := &model.CreateNotificationRequest{
+		UserID:   &order.UserID,
+		Type:     model.NotificationTypeOrder,
+		Priority: model.NotificationPriorityNormal,
+		Channel:  model.NotificationChannelEmail,
+		Title:    fmt.Sprintf("Item Available - #%s", order.OrderNumber),
+		Message:  fmt.Sprintf("Good news! %s (x%d) from your order #%s is now in stock and will be shipped soon.", item.ProductName, item.BackorderedQuantity, order.OrderNumber),
+		Data: map[string]interface{}{
+			"order_id":             order.ID,
+			"order_number":         order.OrderNumber,
+			"order_item_id":        item.ID,
+			"product_id":           item.ProductID,
+			"product_name":         item.ProductName,
+			"backordered_quantity": item.BackorderedQuantity,
+			"backorder_type":       item.BackorderType,
+		},
+		ActionURL: fmt.Sprintf("/orders/%d", order.ID),
+	}
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create backorder available notification: %v", err)
+		return err
+	}
+
+	logger.Infof("Backorder available notification sent for order #%s item %d", order.OrderNumber, item.ID)
+	return nil
+}
+
+// OnOrderReadyForPickup handles a pickup order arriving at its pickup location
+func (s *eventService) OnOrderReadyForPickup(order *model.Order, location *model.PickupLocation) error {
+	deadline := ""
+	if order.PickupDeadline != nil {
+		deadline = order.PickupDeadline.Format("2006-01-02")
+	}
+
+	notification := &model.CreateNotificationRequest{
+		UserID:   &order.UserID,
+		Type:     model.NotificationTypeShipping,
+		Priority: model.NotificationPriorityHigh,
+		Channel:  model.NotificationChannelEmail,
+		Title:    fmt.Sprintf("Order Ready for Pickup - #%s", order.OrderNumber),
+		Message:  fmt.Sprintf("Your order #%s is ready for pickup at %s, %s. Please collect it by %s and bring your order number.", order.OrderNumber, location.Name, location.FullAddress(), deadline),
+		Data: map[string]interface{}{
+			"order_id":            order.ID,
+			"order_number":        order.OrderNumber,
+			"pickup_location_id":  location.ID,
+			"pickup_location":     location.Name,
+			"pickup_address":      location.FullAddress(),
+			"pickup_phone":        location.Phone,
+			"pickup_deadline":     deadline,
+			"ready_for_pickup_at": time.Now().Format("2006-01-02 15:04:05"),
+		},
+		ActionURL: fmt.Sprintf("/orders/%d", order.ID),
+	}
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create order ready for pickup notification: %v", err)
+		return err
+	}
+
+	// Also send SMS and in-app notifications, customers usually check these on the way to the store
+	notification.Channel = model.NotificationChannelSMS
+	notification.Message = fmt.Sprintf("Order #%s is ready for pickup at %s until %s", order.OrderNumber, location.Name, deadline)
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create SMS pickup notification: %v", err)
+	}
+
+	notification.Channel = model.NotificationChannelInApp
+	notification.Title = "Ready for Pickup"
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create in-app pickup notification: %v", err)
+	}
+
+	logger.Infof("Order ready for pickup notification sent for order #%s at %s", order.OrderNumber, location.Code)
+	return nil
+}
+
+// Shipping events
+
+// OnShippingException alerts admins to a shipment breaching the shipping SLA
+func (s *eventService) OnShippingException(exception *model.ShippingException) error {
+	priority := model.NotificationPriorityHigh
+	if exception.Type == model.ShippingExceptionDeliveryFailures {
+		priority = model.NotificationPriorityUrgent
+	}
+
+	dueAt := ""
+	if exception.DueAt != nil {
+		dueAt = exception.DueAt.Format("2006-01-02 15:04:05")
+	}
+
+	// Notify admin about the late shipment
+	notification := &model.CreateNotificationRequest{
+		UserID:   nil, // Admin notification
+		Type:     model.NotificationTypeShipping,
+		Priority: priority,
+		Channel:  model.NotificationChannelInApp,
+		Title:    "Shipping Exception",
+		Message:  exception.Message,
+		Data: map[string]interface{}{
+			"exception_id":    exception.ID,
+			"exception_type":  exception.Type,
+			"order_id":        exception.OrderID,
+			"shipment_id":     exception.ShipmentID,
+			"carrier":         exception.Carrier,
+			"tracking_number": exception.TrackingNumber,
+			"due_at":          dueAt,
+			"failed_attempts": exception.FailedAttempts,
+		},
+		ActionURL: fmt.Sprintf("/admin/shipping/exceptions/%d", exception.ID),
+	}
+
+	return s.sendNotification(notification)
+}
+
 // Payment events
 
 // OnPaymentSuccess handles payment success event
@@ -525,6 +709,42 @@ func (s *eventService) OnLowStockAlert(product *model.Product, currentStock, min
 	return nil
 }
 
+// OnLotNearExpiry handles near-expiry alert for an inventory lot
+func (s *eventService) OnLotNearExpiry(lot *model.InventoryLot, daysLeft int) error {
+	productName := fmt.Sprintf("#%d", lot.ProductID)
+	if lot.Product != nil {
+		productName = lot.Product.Name
+	}
+
+	expiryDate := ""
+	if lot.ExpiryDate != nil {
+		expiryDate = lot.ExpiryDate.Format("2006-01-02")
+	}
+
+	// Notify admin about the lot nearing expiry
+	notification := &model.CreateNotificationRequest{
+		UserID:   nil, // Admin notification
+		Type:     model.NotificationTypeInventory,
+		Priority: model.NotificationPriorityHigh,
+		Channel:  model.NotificationChannelInApp,
+		Title:    "Lot Near Expiry",
+		Message:  fmt.Sprintf("Lot %s of %s expires in %d days (%s). Remaining: %d", lot.LotNumber, productName, daysLeft, expiryDate, lot.AvailableQuantity),
+		Data: map[string]interface{}{
+			"lot_id":             lot.ID,
+			"lot_number":         lot.LotNumber,
+			"product_id":         lot.ProductID,
+			"product_name":       productName,
+			"variant_id":         lot.VariantID,
+			"expiry_date":        expiryDate,
+			"days_left":          daysLeft,
+			"available_quantity": lot.AvailableQuantity,
+		},
+		ActionURL: fmt.Sprintf("/admin/inventory/lots?product_id=%d", lot.ProductID),
+	}
+
+	return s.sendNotification(notification)
+}
+
 // Coupon events
 
 // OnCouponExpiring handles coupon expiring event
@@ -609,3 +829,96 @@ func (s *eventService) OnPointsExpiring(userID uint, points int64, expiryDate ti
 	logger.Infof("Points expiring notification sent for user %d", userID)
 	return nil
 }
+
+// Security events
+
+// OnSuspiciousLogin warns the user about a login from a new device or country
+func (s *eventService) OnSuspiciousLogin(attempt *model.LoginAttempt, reasons []string) error {
+	if attempt.UserID == nil {
+		return nil
+	}
+
+	notification := &model.CreateNotificationRequest{
+		UserID:   attempt.UserID,
+		Type:     model.NotificationTypeSecurity,
+		Priority: model.NotificationPriorityHigh,
+		Channel:  model.NotificationChannelInApp,
+		Title:    "New Login Detected",
+		Message:  fmt.Sprintf("Your account was signed in from a new device or location (IP %s). If this wasn't you, change your password now.", attempt.IPAddress),
+		Data: map[string]interface{}{
+			"attempt_id": attempt.ID,
+			"reasons":    reasons,
+			"ip_address": attempt.IPAddress,
+			"country":    attempt.Country,
+			"device_id":  attempt.DeviceID,
+			"user_agent": attempt.UserAgent,
+			"login_at":   attempt.CreatedAt.Format("2006-01-02 15:04:05"),
+		},
+		ActionURL: "/account/sessions",
+	}
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create suspicious login notification: %v", err)
+		return err
+	}
+
+	logger.Infof("Suspicious login notification sent for user %d (%s)", *attempt.UserID, strings.Join(reasons, ","))
+	return nil
+}
+
+// OnAccountLocked tells the user the account was locked after too many failed logins
+func (s *eventService) OnAccountLocked(lockout *model.AccountLockout) error {
+	userID := lockout.UserID
+	notification := &model.CreateNotificationRequest{
+		UserID:   &userID,
+		Type:     model.NotificationTypeSecurity,
+		Priority: model.NotificationPriorityHigh,
+		Channel:  model.NotificationChannelInApp,
+		Title:    "Account Temporarily Locked",
+		Message:  fmt.Sprintf("Your account was locked until %s after %d failed login attempts.", lockout.LockedUntil.Format("2006-01-02 15:04"), lockout.FailedAttempts),
+		Data: map[string]interface{}{
+			"lockout_id":      lockout.ID,
+			"failed_attempts": lockout.FailedAttempts,
+			"ip_address":      lockout.IPAddress,
+			"locked_until":    lockout.LockedUntil.Format("2006-01-02 15:04:05"),
+		},
+		ActionURL: "/account/security",
+	}
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create account locked notification: %v", err)
+		return err
+	}
+
+	logger.Infof("Account locked notification sent for user %d", userID)
+	return nil
+}
+
+// Privacy events
+
+// OnDataExportReady tells the user their personal data archive can be downloaded
+func (s *eventService) OnDataExportReady(export *model.DataExportRequest) error {
+	userID := export.UserID
+	notification := &model.CreateNotificationRequest{
+		UserID:   &userID,
+		Type:     model.NotificationTypeSecurity,
+		Priority: model.NotificationPriorityNormal,
+		Channel:  model.NotificationChannelInApp,
+		Title:    "Your Data Export Is Ready",
+		Message:  fmt.Sprintf("The copy of your personal data can be downloaded until %s.", export.ExpiresAt.Format("2006-01-02 15:04")),
+		Data: map[string]interface{}{
+			"export_id":  export.ID,
+			"file_size":  export.FileSize,
+			"expires_at": export.ExpiresAt.Format("2006-01-02 15:04:05"),
+		},
+		ActionURL: "/account/privacy",
+	}
+
+	if err := s.sendNotification(notification); err != nil {
+		logger.Errorf("Failed to create data export notification: %v", err)
+		return err
+	}
+
+	logger.Infof("Data export notification sent for user %d", userID)
+	return nil
+}
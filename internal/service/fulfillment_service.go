@@ -0,0 +1,572 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/database"
+	"go_app/pkg/logger"
+	"sort"
+	"time"
+)
+
+// FulfillmentService keeps the shipments of an order and their single event timeline.
+// Shipping orders, order trackings and the order's shipping status are kept in sync from it.
+type FulfillmentService interface {
+	// Shipments
+	CreateShipment(req *model.ShipmentCreateRequest) (*model.Shipment, error)
+	ShipmentForShippingOrder(shippingOrder *model.ShippingOrder, provider *model.ShippingProvider) (*model.Shipment, error)
+	ShipmentForTracking(tracking *model.OrderTracking) (*model.Shipment, error)
+	GetShipmentsByOrder(orderID uint) ([]model.Shipment, error)
+
+	// Timeline
+	RecordEvent(shipment *model.Shipment, event *model.ShipmentEvent) (bool, error)
+	GetOrderFulfillment(orderID uint) (*model.OrderFulfillmentResponse, error)
+}
+
+// fulfillmentService implements FulfillmentService
+type fulfillmentService struct {
+	shipmentRepo      repository.ShipmentRepository
+	orderRepo         repository.OrderRepository
+	shippingRepo      repository.ShippingRepository
+	orderTrackingRepo *repository.OrderTrackingRepository
+	eventService      EventService
+}
+
+// NewFulfillmentService creates a new FulfillmentService
+func NewFulfillmentService() FulfillmentService {
+	// Shipments are dispatched from staff actions, carrier webhooks and polling alike,
+	// so the service notifies customers itself instead of relying on each caller
+	notificationService := NewNotificationService(repository.NewNotificationRepository(), repository.NewUserRepository())
+
+	return &fulfillmentService{
+		shipmentRepo:      repository.NewShipmentRepository(),
+		orderRepo:         repository.NewOrderRepository(),
+		shippingRepo:      repository.NewShippingRepository(database.GetDB()),
+		orderTrackingRepo: repository.NewOrderTrackingRepository(),
+		eventService:      NewEventService(notificationService, nil, nil),
+	}
+}
+
+// shipmentProgress ranks the statuses an order moves through on its way to the customer
+var shipmentProgress = map[string]int{
+	model.TrackingStatusPending:        0,
+	model.TrackingStatusPickedUp:       1,
+	model.TrackingStatusInTransit:      2,
+	model.TrackingStatusOutForDelivery: 2,
+	model.TrackingStatusDelivered:      3,
+}
+
+var progressShippingStatuses = []model.ShippingStatus{
+	model.ShippingStatusPending,
+	model.ShippingStatusPickedUp,
+	model.ShippingStatusInTransit,
+	model.ShippingStatusDelivered,
+}
+
+// CreateShipment opens a shipment for an order, or adds the items to the shipment with the same tracking number.
+// The item quantities are counted as shipped on the order.
+func (s *fulfillmentService) CreateShipment(req *model.ShipmentCreateRequest) (*model.Shipment, error) {
+	var shipment *model.Shipment
+	if req.TrackingNumber != "" {
+		existing, err := s.shipmentRepo.GetShipmentByTrackingNumber(req.OrderID, req.TrackingNumber)
+		if err != nil {
+			logger.Errorf("Error getting shipment %s of order %d: %v", req.TrackingNumber, req.OrderID, err)
+			return nil, fmt.Errorf("failed to retrieve shipment")
+		}
+		shipment = existing
+	}
+
+	items := make([]model.ShipmentItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, model.ShipmentItem{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+
+	if shipment == nil {
+		shipment = &model.Shipment{
+			OrderID:         req.OrderID,
+			ShippingOrderID: req.ShippingOrderID,
+			OrderTrackingID: req.OrderTrackingID,
+			Carrier:         req.Carrier,
+			CarrierCode:     req.CarrierCode,
+			TrackingNumber:  req.TrackingNumber,
+			Warehouse:       req.Warehouse,
+			Status:          model.TrackingStatusPending,
+			StatusText:      "Awaiting pickup",
+			CreatedBy:       req.CreatedBy,
+		}
+		if err := s.allocateItems(shipment, items); err != nil {
+			return nil, err
+		}
+		return shipment, nil
+	}
+
+	// Link the records that describe the same parcel
+	if shipment.ShippingOrderID == nil {
+		shipment.ShippingOrderID = req.ShippingOrderID
+	}
+	if shipment.OrderTrackingID == nil {
+		shipment.OrderTrackingID = req.OrderTrackingID
+	}
+	if shipment.Carrier == "" {
+		shipment.Carrier = req.Carrier
+	}
+	if shipment.CarrierCode == "" {
+		shipment.CarrierCode = req.CarrierCode
+	}
+	if shipment.Warehouse == "" {
+		shipment.Warehouse = req.Warehouse
+	}
+	if err := s.shipmentRepo.UpdateShipment(shipment); err != nil {
+		logger.Errorf("Error updating shipment %d: %v", shipment.ID, err)
+		return nil, fmt.Errorf("failed to update shipment")
+	}
+
+	if len(items) > 0 {
+		if err := s.allocateItems(shipment, items); err != nil {
+			return nil, err
+		}
+	}
+
+	return shipment, nil
+}
+
+// allocateItems saves a shipment with its items and counts the item quantities as shipped
+func (s *fulfillmentService) allocateItems(shipment *model.Shipment, items []model.ShipmentItem) error {
+	if len(items) == 0 {
+		if err := s.shipmentRepo.CreateShipment(shipment); err != nil {
+			logger.Errorf("Error creating shipment for order %d: %v", shipment.OrderID, err)
+			return fmt.Errorf("failed to create shipment")
+		}
+		return nil
+	}
+
+	if err := s.shipmentRepo.AllocateShipmentItems(shipment, items); err != nil {
+		if errors.Is(err, repository.ErrShipmentQuantityExceeded) || errors.Is(err, repository.ErrShipmentItemNotInOrder) {
+			return err
+		}
+		logger.Errorf("Error packing items into shipment for order %d: %v", shipment.OrderID, err)
+		return fmt.Errorf("failed to create shipment")
+	}
+	return nil
+}
+
+// ShipmentForShippingOrder returns the shipment booked through a shipping order, opening it if needed
+func (s *fulfillmentService) ShipmentForShippingOrder(shippingOrder *model.ShippingOrder, provider *model.ShippingProvider) (*model.Shipment, error) {
+	shipment, err := s.shipmentRepo.GetShipmentByShippingOrderID(shippingOrder.ID)
+	if err != nil {
+		logger.Errorf("Error getting shipment of shipping order %d: %v", shippingOrder.ID, err)
+		return nil, fmt.Errorf("failed to retrieve shipment")
+	}
+	if shipment != nil {
+		// The tracking code is only known once the carrier has accepted the booking
+		if shipment.TrackingNumber == "" && shippingOrder.TrackingCode != "" {
+			shipment.TrackingNumber = shippingOrder.TrackingCode
+			if err := s.shipmentRepo.UpdateShipment(shipment); err != nil {
+				logger.Errorf("Error updating shipment %d: %v", shipment.ID, err)
+				return nil, fmt.Errorf("failed to update shipment")
+			}
+		}
+		return shipment, nil
+	}
+
+	req := &model.ShipmentCreateRequest{
+		OrderID:         shippingOrder.OrderID,
+		TrackingNumber:  shippingOrder.TrackingCode,
+		ShippingOrderID: &shippingOrder.ID,
+	}
+	if provider != nil {
+		req.Carrier = provider.DisplayName
+		req.CarrierCode = provider.Code
+	}
+	return s.CreateShipment(req)
+}
+
+// ShipmentForTracking returns the shipment followed by an order tracking, opening it if needed
+func (s *fulfillmentService) ShipmentForTracking(tracking *model.OrderTracking) (*model.Shipment, error) {
+	shipment, err := s.shipmentRepo.GetShipmentByOrderTrackingID(tracking.ID)
+	if err != nil {
+		logger.Errorf("Error getting shipment of tracking %d: %v", tracking.ID, err)
+		return nil, fmt.Errorf("failed to retrieve shipment")
+	}
+	if shipment != nil {
+		return shipment, nil
+	}
+
+	return s.CreateShipment(&model.ShipmentCreateRequest{
+		OrderID:         tracking.OrderID,
+		Carrier:         tracking.Carrier,
+		CarrierCode:     tracking.CarrierCode,
+		TrackingNumber:  tracking.TrackingNumber,
+		OrderTrackingID: &tracking.ID,
+	})
+}
+
+// GetShipmentsByOrder retrieves all shipments of an order
+func (s *fulfillmentService) GetShipmentsByOrder(orderID uint) ([]model.Shipment, error) {
+	shipments, err := s.shipmentRepo.GetShipmentsByOrder(orderID)
+	if err != nil {
+		logger.Errorf("Error getting shipments of order %d: %v", orderID, err)
+		return nil, fmt.Errorf("failed to retrieve shipments")
+	}
+	return shipments, nil
+}
+
+// RecordEvent appends an event to a shipment's timeline and propagates the new status.
+// It returns false when the same event was already recorded.
+func (s *fulfillmentService) RecordEvent(shipment *model.Shipment, event *model.ShipmentEvent) (bool, error) {
+	event.ShipmentID = shipment.ID
+	event.OrderID = shipment.OrderID
+	if event.EventTime.IsZero() {
+		event.EventTime = time.Now()
+	}
+	if event.StatusText == "" {
+		event.StatusText = event.Status
+	}
+
+	exists, err := s.shipmentRepo.HasShipmentEvent(shipment.ID, event.Status, event.EventCode, event.EventTime)
+	if err != nil {
+		logger.Errorf("Error checking timeline of shipment %d: %v", shipment.ID, err)
+		return false, fmt.Errorf("failed to record shipment event")
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := s.shipmentRepo.CreateShipmentEvent(event); err != nil {
+		logger.Errorf("Error recording event for shipment %d: %v", shipment.ID, err)
+		return false, fmt.Errorf("failed to record shipment event")
+	}
+
+	// Events arriving out of order only fill in the history
+	if shipment.LastEventAt == nil || !event.EventTime.Before(*shipment.LastEventAt) {
+		eventTime := event.EventTime
+		shipment.Status = event.Status
+		shipment.StatusText = event.StatusText
+		shipment.Location = event.Location
+		shipment.LastEventAt = &eventTime
+	}
+	dispatched := shipment.ShippedAt == nil && shipmentProgress[event.Status] > 0
+	if dispatched {
+		shippedAt := event.EventTime
+		shipment.ShippedAt = &shippedAt
+	}
+	if shipment.DeliveredAt == nil && event.Status == model.TrackingStatusDelivered {
+		deliveredAt := event.EventTime
+		shipment.DeliveredAt = &deliveredAt
+	}
+
+	if err := s.shipmentRepo.UpdateShipment(shipment); err != nil {
+		logger.Errorf("Error updating shipment %d: %v", shipment.ID, err)
+		return true, fmt.Errorf("failed to update shipment")
+	}
+
+	s.syncLinkedRecords(shipment)
+	order := s.syncOrderShippingStatus(shipment.OrderID)
+
+	// Each parcel gets its own shipped notification when it leaves the warehouse
+	if dispatched && order != nil && s.eventService != nil {
+		if err := s.eventService.OnOrderShipped(order, shipment); err != nil {
+			logger.Warnf("Failed to send shipped notification for shipment %d: %v", shipment.ID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// GetOrderFulfillment retrieves every shipment of an order with the merged timeline
+func (s *fulfillmentService) GetOrderFulfillment(orderID uint) (*model.OrderFulfillmentResponse, error) {
+	order, err := s.orderRepo.GetOrderByID(orderID)
+	if err != nil {
+		logger.Errorf("Error getting order %d for fulfillment: %v", orderID, err)
+		return nil, fmt.Errorf("failed to retrieve order")
+	}
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+
+	shipments, err := s.GetShipmentsByOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.OrderFulfillmentResponse{
+		OrderID:        order.ID,
+		OrderNumber:    order.OrderNumber,
+		OrderStatus:    order.Status,
+		ShippingStatus: order.ShippingStatus,
+		Shipments:      make([]model.ShipmentResponse, 0, len(shipments)),
+		Timeline:       []model.ShipmentEventResponse{},
+	}
+	for i := range shipments {
+		shipmentResponse := s.toShipmentResponse(&shipments[i])
+		response.Shipments = append(response.Shipments, shipmentResponse)
+		response.Timeline = append(response.Timeline, shipmentResponse.Events...)
+	}
+	sort.SliceStable(response.Timeline, func(i, j int) bool {
+		return response.Timeline[i].EventTime.After(response.Timeline[j].EventTime)
+	})
+
+	return response, nil
+}
+
+// syncLinkedRecords mirrors a shipment's status onto its shipping order and order tracking
+func (s *fulfillmentService) syncLinkedRecords(shipment *model.Shipment) {
+	if shipment.ShippingOrderID != nil {
+		shippingOrder, err := s.shippingRepo.GetShippingOrderByID(*shipment.ShippingOrderID)
+		if err != nil {
+			logger.Errorf("Error getting shipping order %d: %v", *shipment.ShippingOrderID, err)
+		} else if status := shippingOrderStatusForShipment(shipment.Status, shippingOrder.Status); status != shippingOrder.Status {
+			shippingOrder.Status = status
+			shippingOrder.StatusText = shipment.StatusText
+			if shippingOrder.ShippedAt == nil {
+				shippingOrder.ShippedAt = shipment.ShippedAt
+			}
+			if shippingOrder.DeliveredAt == nil {
+				shippingOrder.DeliveredAt = shipment.DeliveredAt
+			}
+			if err := s.shippingRepo.UpdateShippingOrder(shippingOrder); err != nil {
+				logger.Errorf("Error updating shipping order %d: %v", shippingOrder.ID, err)
+			}
+		}
+	}
+
+	if shipment.OrderTrackingID != nil {
+		tracking, err := s.orderTrackingRepo.GetOrderTrackingByID(*shipment.OrderTrackingID)
+		if err != nil {
+			logger.Errorf("Error getting order tracking %d: %v", *shipment.OrderTrackingID, err)
+		} else if tracking.Status != shipment.Status {
+			tracking.Status = shipment.Status
+			tracking.StatusText = shipment.StatusText
+			tracking.Location = shipment.Location
+			tracking.LastUpdatedAt = time.Now()
+			if tracking.ActualDelivery == nil {
+				tracking.ActualDelivery = shipment.DeliveredAt
+			}
+			if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
+				logger.Errorf("Error updating order tracking %d: %v", tracking.ID, err)
+			}
+		}
+	}
+}
+
+// syncOrderShippingStatus derives the order's shipping and order status from all of its shipments
+// and returns the order, or nil when it could not be loaded
+func (s *fulfillmentService) syncOrderShippingStatus(orderID uint) *model.Order {
+	order, err := s.orderRepo.GetOrderByID(orderID)
+	if err != nil || order == nil {
+		logger.Errorf("Error getting order %d for shipping status: %v", orderID, err)
+		return nil
+	}
+	shipments, err := s.shipmentRepo.GetShipmentsByOrder(orderID)
+	if err != nil {
+		logger.Errorf("Error getting shipments of order %d: %v", orderID, err)
+		return order
+	}
+
+	status, ok := aggregateShippingStatus(order, shipments)
+	if !ok {
+		return order
+	}
+	orderStatus := orderStatusForShipping(order, status)
+	if status == order.ShippingStatus && orderStatus == order.Status {
+		return order
+	}
+
+	now := time.Now()
+	order.ShippingStatus = status
+	order.Status = orderStatus
+	switch orderStatus {
+	case model.OrderStatusShipped, model.OrderStatusPartiallyShipped:
+		if order.ShippedAt == nil {
+			order.ShippedAt = &now
+		}
+	case model.OrderStatusDelivered:
+		if order.DeliveredAt == nil {
+			order.DeliveredAt = &now
+		}
+	}
+
+	if err := s.orderRepo.UpdateOrder(order); err != nil {
+		logger.Errorf("Error updating shipping status of order %d: %v", orderID, err)
+	}
+	return order
+}
+
+// orderStatusForShipping moves an order that is being fulfilled along with its shipping status
+func orderStatusForShipping(order *model.Order, status model.ShippingStatus) model.OrderStatus {
+	switch status {
+	case model.ShippingStatusDelivered:
+		if order.CanBeDelivered() {
+			return model.OrderStatusDelivered
+		}
+	case model.ShippingStatusPartiallyShipped:
+		switch order.Status {
+		case model.OrderStatusConfirmed, model.OrderStatusProcessing, model.OrderStatusShipped:
+			return model.OrderStatusPartiallyShipped
+		}
+	case model.ShippingStatusPickedUp, model.ShippingStatusInTransit:
+		switch order.Status {
+		case model.OrderStatusConfirmed, model.OrderStatusProcessing, model.OrderStatusPartiallyShipped:
+			return model.OrderStatusShipped
+		}
+	}
+	return order.Status
+}
+
+// aggregateShippingStatus returns the status of the least advanced shipment; a failed or
+// returned shipment wins. The order counts as partially shipped while some parcels are on
+// their way and other items are still waiting in the warehouse.
+func aggregateShippingStatus(order *model.Order, shipments []model.Shipment) (model.ShippingStatus, bool) {
+	active := 0
+	lowest := len(progressShippingStatuses) - 1
+	failed, returned, dispatched := false, false, false
+	for i := range shipments {
+		switch shipments[i].Status {
+		case model.TrackingStatusCancelled:
+			continue
+		case model.TrackingStatusFailed:
+			failed = true
+		case model.TrackingStatusReturned:
+			returned = true
+		default:
+			progress := shipmentProgress[shipments[i].Status]
+			if progress > 0 {
+				dispatched = true
+			}
+			if progress < lowest {
+				lowest = progress
+			}
+		}
+		active++
+	}
+
+	switch {
+	case active == 0:
+		return "", false
+	case failed:
+		return model.ShippingStatusFailed, true
+	case returned:
+		return model.ShippingStatusReturned, true
+	}
+
+	status := progressShippingStatuses[lowest]
+	if dispatched && (status == model.ShippingStatusPending || !fullyShipped(order)) {
+		return model.ShippingStatusPartiallyShipped, true
+	}
+	return status, true
+}
+
+// fullyShipped checks if every ordered quantity has been packed into a shipment
+func fullyShipped(order *model.Order) bool {
+	for i := range order.OrderItems {
+		if order.OrderItems[i].ShippedQuantity < order.OrderItems[i].Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// checkShipmentItems validates shipment items against what is left to ship on the order
+func checkShipmentItems(order *model.Order, items []model.ShipmentItemRequest) error {
+	requested := make(map[uint]int, len(items))
+	for _, item := range items {
+		requested[item.OrderItemID] += item.Quantity
+	}
+
+	ready := make(map[uint]int, len(order.OrderItems))
+	for i := range order.OrderItems {
+		ready[order.OrderItems[i].ID] = order.OrderItems[i].ReadyToShipQuantity()
+	}
+
+	for orderItemID, quantity := range requested {
+		left, ok := ready[orderItemID]
+		if !ok {
+			return fmt.Errorf("order item %d does not belong to order %d", orderItemID, order.ID)
+		}
+		if quantity > left {
+			return fmt.Errorf("order item %d has only %d left to ship", orderItemID, left)
+		}
+	}
+	return nil
+}
+
+// readyToShipItems lists the quantities of an order that can be packed into a shipment now
+func readyToShipItems(order *model.Order) []model.ShipmentItemRequest {
+	var items []model.ShipmentItemRequest
+	for i := range order.OrderItems {
+		if ready := order.OrderItems[i].ReadyToShipQuantity(); ready > 0 {
+			items = append(items, model.ShipmentItemRequest{OrderItemID: order.OrderItems[i].ID, Quantity: ready})
+		}
+	}
+	return items
+}
+
+// shippingOrderStatusForShipment maps a shipment status to a shipping order status
+func shippingOrderStatusForShipment(status, current string) string {
+	switch status {
+	case model.TrackingStatusPending:
+		// A booked shipping order stays "created" until the carrier picks it up
+		if current == model.ShippingOrderStatusCreated {
+			return current
+		}
+		return model.ShippingOrderStatusPending
+	case model.TrackingStatusOutForDelivery:
+		return model.ShippingOrderStatusInTransit
+	default:
+		return status
+	}
+}
+
+func (s *fulfillmentService) toShipmentResponse(shipment *model.Shipment) model.ShipmentResponse {
+	response := model.ShipmentResponse{
+		ID:              shipment.ID,
+		OrderID:         shipment.OrderID,
+		ShippingOrderID: shipment.ShippingOrderID,
+		OrderTrackingID: shipment.OrderTrackingID,
+		Carrier:         shipment.Carrier,
+		CarrierCode:     shipment.CarrierCode,
+		TrackingNumber:  shipment.TrackingNumber,
+		Warehouse:       shipment.Warehouse,
+		Status:          shipment.Status,
+		StatusText:      shipment.StatusText,
+		Location:        shipment.Location,
+		LastEventAt:     shipment.LastEventAt,
+		ShippedAt:       shipment.ShippedAt,
+		DeliveredAt:     shipment.DeliveredAt,
+		DeliveryDue:     shipment.DeliveryDue,
+		Items:           make([]model.ShipmentItemResponse, 0, len(shipment.Items)),
+		Events:          make([]model.ShipmentEventResponse, 0, len(shipment.Events)),
+		CreatedAt:       shipment.CreatedAt,
+	}
+
+	for _, item := range shipment.Items {
+		itemResponse := model.ShipmentItemResponse{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		}
+		if item.OrderItem != nil {
+			itemResponse.ProductID = item.OrderItem.ProductID
+			itemResponse.ProductName = item.OrderItem.ProductName
+			itemResponse.ProductSKU = item.OrderItem.ProductSKU
+		}
+		response.Items = append(response.Items, itemResponse)
+	}
+
+	for _, event := range shipment.Events {
+		response.Events = append(response.Events, model.ShipmentEventResponse{
+			ID:         event.ID,
+			ShipmentID: event.ShipmentID,
+			Status:     event.Status,
+			StatusText: event.StatusText,
+			Location:   event.Location,
+			Note:       event.Note,
+			EventCode:  event.EventCode,
+			Source:     event.Source,
+			EventTime:  event.EventTime,
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/database"
+	"go_app/pkg/jwt"
+	"go_app/pkg/logger"
+)
+
+// StartImpersonationRequest represents a support agent's request to act as a customer
+type StartImpersonationRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"` // Why, e.g. a support ticket number
+}
+
+// StartImpersonationResponse carries the short-lived token acting as the customer
+type StartImpersonationResponse struct {
+	AccessToken string                    `json:"access_token"`
+	TokenType   string                    `json:"token_type"`
+	ExpiresAt   time.Time                 `json:"expires_at"`
+	Banner      model.ImpersonationBanner `json:"banner"`
+}
+
+// ImpersonationService lets support agents act as a customer to reproduce problems. Tokens are
+// time-limited, tied to the agent's session, and every request made with them is audited.
+type ImpersonationService struct {
+	repo       repository.ImpersonationRepository
+	userRepo   repository.UserRepository
+	auditRepo  repository.AuditRepository
+	jwtManager *jwt.JWTManager
+	config     configs.ImpersonationConfig
+}
+
+// NewImpersonationService creates a new impersonation service
+func NewImpersonationService() *ImpersonationService {
+	config := configs.Load()
+
+	return &ImpersonationService{
+		repo:       repository.NewImpersonationRepository(),
+		userRepo:   repository.NewUserRepository(),
+		auditRepo:  repository.NewAuditRepository(database.GetDB()),
+		jwtManager: jwt.NewJWTManager(),
+		config:     config.Impersonation,
+	}
+}
+
+// StartImpersonation issues a token acting as the customer. A running impersonation of the same
+// agent is ended first, so an agent only ever acts as one customer.
+func (s *ImpersonationService) StartImpersonation(actorID, actorSessionID, subjectID uint, req *StartImpersonationRequest, info AuditRequestInfo) (*StartImpersonationResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if len([]rune(reason)) < s.config.MinReasonChars {
+		return nil, fmt.Errorf("reason must be at least %d characters, e.g. the support ticket number", s.config.MinReasonChars)
+	}
+	if actorID == subjectID {
+		return nil, errors.New("you cannot impersonate yourself")
+	}
+
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	subject, err := s.userRepo.GetByID(subjectID)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if !subject.IsActive {
+		return nil, errors.New("cannot impersonate an inactive account")
+	}
+	// Every role the customer holds, granted ones included, must allow impersonation
+	roles := subject.RoleNames()
+	if len(roles) == 0 {
+		return nil, errors.New("this account's role cannot be impersonated")
+	}
+	for _, role := range roles {
+		if !containsString(s.config.AllowedRoles, role) {
+			return nil, errors.New("this account's role cannot be impersonated")
+		}
+	}
+
+	if _, err := s.repo.EndActiveByActor(actorID, model.ImpersonationEndReplaced); err != nil {
+		logger.Errorf("Failed to end previous impersonations of user %d: %v", actorID, err)
+		return nil, fmt.Errorf("failed to start impersonation")
+	}
+
+	impersonation := &model.Impersonation{
+		ActorID:        actorID,
+		SubjectID:      subjectID,
+		ActorSessionID: actorSessionID,
+		Reason:         reason,
+		IPAddress:      info.IPAddress,
+		UserAgent:      truncateString(info.UserAgent, 500),
+		ExpiresAt:      time.Now().Add(time.Duration(s.config.TTLMinutes) * time.Minute),
+	}
+	if err := s.repo.Create(impersonation); err != nil {
+		logger.Errorf("Failed to create impersonation of user %d by %d: %v", subjectID, actorID, err)
+		return nil, fmt.Errorf("failed to start impersonation")
+	}
+	impersonation.Actor = actor
+	impersonation.Subject = subject
+
+	token, err := s.jwtManager.GenerateImpersonationToken(
+		subject.ID,
+		subject.Username,
+		subject.Email,
+		subject.UserRole.Name,
+		fmt.Sprintf("%d", actorSessionID),
+		jwt.ActorClaims{UserID: actor.ID, Username: actor.Username, ImpersonationID: impersonation.ID},
+		impersonation.ExpiresAt,
+	)
+	if err != nil {
+		logger.Errorf("Failed to sign impersonation token %d: %v", impersonation.ID, err)
+		s.repo.End(impersonation.ID, model.ImpersonationEndStopped)
+		return nil, fmt.Errorf("failed to start impersonation")
+	}
+
+	s.audit(impersonation, model.ActionImpersonateStart, model.OperationLogin, model.StatusSuccess, model.SeverityCritical,
+		fmt.Sprintf("%s started acting as %s", actor.Username, subject.Username), map[string]interface{}{
+			"reason":     reason,
+			"expires_at": impersonation.ExpiresAt,
+		}, info)
+
+	return &StartImpersonationResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   impersonation.ExpiresAt,
+		Banner:      ImpersonationBannerFor(impersonation),
+	}, nil
+}
+
+// ValidateImpersonation checks that the impersonation behind a token is still running and
+// matches the token's agent and customer
+func (s *ImpersonationService) ValidateImpersonation(impersonationID, actorID, subjectID uint) (*model.Impersonation, error) {
+	impersonation, err := s.repo.GetByID(impersonationID)
+	if err != nil {
+		logger.Errorf("Failed to get impersonation %d: %v", impersonationID, err)
+		return nil, fmt.Errorf("failed to validate impersonation")
+	}
+	if impersonation == nil || impersonation.ActorID != actorID || impersonation.SubjectID != subjectID {
+		return nil, errors.New("impersonation not found")
+	}
+	if !impersonation.IsActive() {
+		return nil, errors.New("impersonation has ended")
+	}
+	return impersonation, nil
+}
+
+// GetImpersonation returns the running impersonation for the banner
+func (s *ImpersonationService) GetImpersonation(impersonationID uint) (*model.ImpersonationBanner, error) {
+	impersonation, err := s.repo.GetByID(impersonationID)
+	if err != nil {
+		logger.Errorf("Failed to get impersonation %d: %v", impersonationID, err)
+		return nil, fmt.Errorf("failed to retrieve impersonation")
+	}
+	if impersonation == nil {
+		return nil, errors.New("impersonation not found")
+	}
+	banner := ImpersonationBannerFor(impersonation)
+	return &banner, nil
+}
+
+// StopImpersonation ends the agent's impersonation; the agent continues with their own token
+func (s *ImpersonationService) StopImpersonation(impersonationID, actorID uint, info AuditRequestInfo) error {
+	impersonation, err := s.repo.GetByID(impersonationID)
+	if err != nil {
+		logger.Errorf("Failed to get impersonation %d: %v", impersonationID, err)
+		return fmt.Errorf("failed to stop impersonation")
+	}
+	if impersonation == nil || impersonation.ActorID != actorID {
+		return errors.New("impersonation not found")
+	}
+	return s.end(impersonation, model.ImpersonationEndStopped, actorID, info)
+}
+
+// GetImpersonations lists impersonations for admins reviewing support activity
+func (s *ImpersonationService) GetImpersonations(page, limit int, filters map[string]interface{}) ([]model.Impersonation, int64, error) {
+	impersonations, total, err := s.repo.GetAll(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Failed to get impersonations: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve impersonations")
+	}
+	return impersonations, total, nil
+}
+
+// RevokeImpersonation lets an admin end another agent's impersonation immediately
+func (s *ImpersonationService) RevokeImpersonation(impersonationID, adminID uint, info AuditRequestInfo) error {
+	impersonation, err := s.repo.GetByID(impersonationID)
+	if err != nil {
+		logger.Errorf("Failed to get impersonation %d: %v", impersonationID, err)
+		return fmt.Errorf("failed to revoke impersonation")
+	}
+	if impersonation == nil {
+		return errors.New("impersonation not found")
+	}
+	return s.end(impersonation, model.ImpersonationEndRevoked, adminID, info)
+}
+
+func (s *ImpersonationService) end(impersonation *model.Impersonation, reason string, endedBy uint, info AuditRequestInfo) error {
+	ended, err := s.repo.End(impersonation.ID, reason)
+	if err != nil {
+		logger.Errorf("Failed to end impersonation %d: %v", impersonation.ID, err)
+		return fmt.Errorf("failed to end impersonation")
+	}
+	if !ended {
+		return errors.New("impersonation has already ended")
+	}
+
+	s.audit(impersonation, model.ActionImpersonateStop, model.OperationLogout, model.StatusSuccess, model.SeverityWarning,
+		fmt.Sprintf("Impersonation %s", reason), map[string]interface{}{"ended_by": endedBy}, info)
+	return nil
+}
+
+// RecordRequest stamps a request made while impersonating into the audit log with the real agent
+func (s *ImpersonationService) RecordRequest(impersonation *model.Impersonation, method, path string, status int, info AuditRequestInfo) {
+	auditStatus := model.StatusSuccess
+	if status >= http.StatusBadRequest {
+		auditStatus = model.StatusFailure
+	}
+
+	s.audit(impersonation, model.ActionImpersonateUse, operationForMethod(method), auditStatus, model.SeverityInfo,
+		fmt.Sprintf("%s %s", method, path), map[string]interface{}{
+			"method": method,
+			"path":   path,
+			"status": status,
+		}, info)
+}
+
+// RecordBlocked audits a sensitive action that was refused because the request was impersonated
+func (s *ImpersonationService) RecordBlocked(impersonation *model.Impersonation, method, path string, info AuditRequestInfo) {
+	s.audit(impersonation, model.ActionImpersonateBlock, operationForMethod(method), model.StatusFailure, model.SeverityWarning,
+		fmt.Sprintf("Blocked %s %s while impersonating", method, path), map[string]interface{}{
+			"method": method,
+			"path":   path,
+		}, info)
+}
+
+// audit writes an impersonation event with the agent as the user and the customer as the target
+func (s *ImpersonationService) audit(impersonation *model.Impersonation, action, operation, status, severity, message string, metadata map[string]interface{}, info AuditRequestInfo) {
+	metadata["impersonation_id"] = impersonation.ID
+	encoded, _ := json.Marshal(metadata)
+
+	entry := &model.AuditLog{
+		UserID:       &impersonation.ActorID,
+		Action:       action,
+		Resource:     model.ResourceUser,
+		ResourceID:   &impersonation.SubjectID,
+		Operation:    operation,
+		Status:       status,
+		Message:      message,
+		IPAddress:    info.IPAddress,
+		UserAgent:    info.UserAgent,
+		SessionID:    fmt.Sprintf("%d", impersonation.ActorSessionID),
+		Metadata:     string(encoded),
+		Tags:         "impersonation,security",
+		Severity:     severity,
+		TargetUserID: &impersonation.SubjectID,
+	}
+	if impersonation.Subject != nil {
+		entry.ResourceName = impersonation.Subject.Username
+	}
+
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		logger.Errorf("Failed to write impersonation audit log (%s): %v", action, err)
+	}
+}
+
+// ImpersonationBannerFor builds the banner state the frontend shows while impersonating
+func ImpersonationBannerFor(impersonation *model.Impersonation) model.ImpersonationBanner {
+	banner := model.ImpersonationBanner{
+		Active:          impersonation.IsActive(),
+		ImpersonationID: impersonation.ID,
+		ActorID:         impersonation.ActorID,
+		SubjectID:       impersonation.SubjectID,
+		ExpiresAt:       impersonation.ExpiresAt,
+	}
+	if impersonation.Actor != nil {
+		banner.ActorUsername = impersonation.Actor.Username
+	}
+	if impersonation.Subject != nil {
+		banner.SubjectUsername = impersonation.Subject.Username
+	}
+	return banner
+}
+
+// operationForMethod maps an HTTP method to the audit operation
+func operationForMethod(method string) string {
+	switch method {
+	case http.MethodPost:
+		return model.OperationCreate
+	case http.MethodPut, http.MethodPatch:
+		return model.OperationUpdate
+	case http.MethodDelete:
+		return model.OperationDelete
+	default:
+		return model.OperationRead
+	}
+}
@@ -45,12 +45,27 @@ type InventoryService interface {
 	ReserveStock(productID uint, variantID *uint, quantity int) error
 	ReleaseStock(productID uint, variantID *uint, quantity int) error
 	ProcessStockMovement(movement *model.InventoryMovement) error
+	DeductStock(productID uint, variantID *uint, quantity int, reference, referenceType string) error
+	RestoreStock(productID uint, variantID *uint, quantity int, reference, referenceType string) error
+	GetAvailableQuantity(productID uint, variantID *uint) (int, error)
+
+	// Bundles
+	GetBundleAvailability(bundleID uint) (*model.BundleAvailabilityResponse, error)
+
+	// Inventory Lots
+	GetLots(page, limit int, filters map[string]interface{}) ([]model.InventoryLotResponse, int64, error)
+	GetLotByID(id uint) (*model.InventoryLotResponse, error)
+	GetPickPlan(productID uint, variantID *uint, quantity int) (*model.LotPickPlan, error)
+	GetLotStockReport(nearExpiryDays int, filters map[string]interface{}) (*model.LotStockReport, error)
+	CheckNearExpiryLots(days int) (*model.NearExpiryAlertResult, error)
 }
 
 // inventoryService implements InventoryService
 type inventoryService struct {
 	inventoryRepo repository.InventoryRepository
 	productRepo   *repository.ProductRepository
+	bundleRepo    *repository.ProductBundleRepository
+	eventService  EventService
 }
 
 // NewInventoryService creates a new InventoryService
@@ -58,6 +73,18 @@ func NewInventoryService() InventoryService {
 	return &inventoryService{
 		inventoryRepo: repository.NewInventoryRepository(),
 		productRepo:   repository.NewProductRepository(),
+		bundleRepo:    repository.NewProductBundleRepository(),
+		eventService:  nil, // Will be set by dependency injection
+	}
+}
+
+// NewInventoryServiceWithEvent creates a new InventoryService with EventService
+func NewInventoryServiceWithEvent(eventService EventService) InventoryService {
+	return &inventoryService{
+		inventoryRepo: repository.NewInventoryRepository(),
+		productRepo:   repository.NewProductRepository(),
+		bundleRepo:    repository.NewProductBundleRepository(),
+		eventService:  eventService,
 	}
 }
 
@@ -82,21 +109,35 @@ func (s *inventoryService) CreateMovement(req *model.InventoryMovementCreateRequ
 		// For now, we'll skip this validation
 	}
 
+	// Bundle stock is derived from its components
+	if product.IsBundle() {
+		return nil, errors.New("bundle products have no own stock, move their components instead")
+	}
+
+	// Lot-tracked products must state the lot being received
+	if product.TrackLots && req.LotNumber == "" &&
+		(req.Type == model.MovementTypeInbound || req.Type == model.MovementTypeReturn) {
+		return nil, errors.New("lot number is required for lot-tracked products")
+	}
+
 	// Calculate total cost
 	totalCost := float64(req.Quantity) * req.UnitCost
 
 	movement := &model.InventoryMovement{
-		ProductID:     req.ProductID,
-		VariantID:     req.VariantID,
-		Type:          req.Type,
-		Status:        model.MovementStatusPending,
-		Quantity:      req.Quantity,
-		UnitCost:      req.UnitCost,
-		TotalCost:     totalCost,
-		Reference:     req.Reference,
-		ReferenceType: req.ReferenceType,
-		Notes:         req.Notes,
-		CreatedBy:     userID,
+		ProductID:      req.ProductID,
+		VariantID:      req.VariantID,
+		Type:           req.Type,
+		Status:         model.MovementStatusPending,
+		Quantity:       req.Quantity,
+		UnitCost:       req.UnitCost,
+		TotalCost:      totalCost,
+		Reference:      req.Reference,
+		ReferenceType:  req.ReferenceType,
+		Notes:          req.Notes,
+		LotNumber:      req.LotNumber,
+		ExpiryDate:     req.ExpiryDate,
+		ManufacturedAt: req.ManufacturedAt,
+		CreatedBy:      userID,
 	}
 
 	if err := s.inventoryRepo.CreateMovement(movement); err != nil {
@@ -325,6 +366,7 @@ func (s *inventoryService) UpdateStockLevelSettings(productID uint, variantID *u
 	stockLevel.MinStockLevel = req.MinStockLevel
 	stockLevel.MaxStockLevel = req.MaxStockLevel
 	stockLevel.ReorderPoint = req.ReorderPoint
+	stockLevel.Location = req.Location
 
 	if err := s.inventoryRepo.UpdateStockLevel(stockLevel); err != nil {
 		logger.Errorf("Error updating stock level for product %d: %v", productID, err)
@@ -536,77 +578,410 @@ func (s *inventoryService) ReleaseStock(productID uint, variantID *uint, quantit
 
 // ProcessStockMovement processes a stock movement and updates stock levels
 func (s *inventoryService) ProcessStockMovement(movement *model.InventoryMovement) error {
-	// Get or create stock level
-	stockLevel, err := s.inventoryRepo.GetStockLevelByProduct(movement.ProductID, movement.VariantID)
+	product, err := s.productRepo.GetByID(movement.ProductID)
 	if err != nil {
-		logger.Errorf("Error getting stock level for product %d: %v", movement.ProductID, err)
-		return fmt.Errorf("failed to retrieve stock level")
+		logger.Errorf("Error getting product by ID %d: %v", movement.ProductID, err)
+		return fmt.Errorf("failed to retrieve product")
+	}
+
+	// Received stock must name its lot; returns without one go back to the lots picked for their reference
+	if product.TrackLots && movement.LotNumber == "" {
+		switch movement.Type {
+		case model.MovementTypeInbound:
+			return errors.New("lot number is required for lot-tracked products")
+		case model.MovementTypeReturn:
+			if movement.Reference == "" {
+				return errors.New("lot number is required for lot-tracked products")
+			}
+		}
 	}
 
-	if stockLevel == nil {
-		// Create new stock level
-		stockLevel = &model.StockLevel{
-			ProductID:         movement.ProductID,
-			VariantID:         movement.VariantID,
-			AvailableQuantity: 0,
-			ReservedQuantity:  0,
-			IncomingQuantity:  0,
-			TotalQuantity:     0,
+	// Stock level and lot quantities are updated in one transaction
+	err = s.inventoryRepo.ApplyMovement(movement, product.TrackLots)
+	switch {
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return errors.New("insufficient stock for outbound movement")
+	case errors.Is(err, repository.ErrInsufficientLotStock), errors.Is(err, repository.ErrLotNotFound):
+		return err
+	case err != nil:
+		logger.Errorf("Error applying movement %d to stock: %v", movement.ID, err)
+		return fmt.Errorf("failed to update stock level")
+	}
+
+	return nil
+}
+
+// DeductStock records a completed outbound movement and deducts it from stock
+func (s *inventoryService) DeductStock(productID uint, variantID *uint, quantity int, reference, referenceType string) error {
+	return s.applyStockMovement(model.MovementTypeOutbound, productID, variantID, quantity, reference, referenceType)
+}
+
+// RestoreStock records a completed return movement and adds it back to stock
+func (s *inventoryService) RestoreStock(productID uint, variantID *uint, quantity int, reference, referenceType string) error {
+	return s.applyStockMovement(model.MovementTypeReturn, productID, variantID, quantity, reference, referenceType)
+}
+
+// applyStockMovement creates a completed movement and applies it to stock levels,
+// removing the movement again when it cannot be applied
+func (s *inventoryService) applyStockMovement(movementType model.InventoryMovementType, productID uint, variantID *uint, quantity int, reference, referenceType string) error {
+	if quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+
+	now := time.Now()
+	movement := &model.InventoryMovement{
+		ProductID:     productID,
+		VariantID:     variantID,
+		Type:          movementType,
+		Status:        model.MovementStatusCompleted,
+		Quantity:      quantity,
+		Reference:     reference,
+		ReferenceType: referenceType,
+		CompletedAt:   &now,
+	}
+
+	if err := s.inventoryRepo.CreateMovement(movement); err != nil {
+		logger.Errorf("Error creating %s movement for product %d: %v", movementType, productID, err)
+		return fmt.Errorf("failed to create inventory movement")
+	}
+
+	if err := s.ProcessStockMovement(movement); err != nil {
+		if delErr := s.inventoryRepo.DeleteMovement(movement.ID); delErr != nil {
+			logger.Errorf("Error removing unapplied movement %d: %v", movement.ID, delErr)
 		}
-		if err := s.inventoryRepo.CreateStockLevel(stockLevel); err != nil {
-			logger.Errorf("Error creating stock level: %v", err)
-			return fmt.Errorf("failed to create stock level")
+		return err
+	}
+
+	return nil
+}
+
+// GetAvailableQuantity returns the quantity of a product that can be sold right now
+func (s *inventoryService) GetAvailableQuantity(productID uint, variantID *uint) (int, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		logger.Errorf("Error getting product %d: %v", productID, err)
+		return 0, fmt.Errorf("failed to retrieve product")
+	}
+
+	// Bundles are limited by their scarcest component
+	if product.IsBundle() {
+		availability, err := s.GetBundleAvailability(productID)
+		if err != nil {
+			return 0, err
 		}
+		return availability.AvailableQuantity, nil
+	}
+
+	stockLevel, err := s.inventoryRepo.GetStockLevelByProduct(productID, variantID)
+	if err != nil {
+		logger.Errorf("Error getting stock level for product %d: %v", productID, err)
+		return 0, fmt.Errorf("failed to retrieve stock level")
+	}
+	if stockLevel != nil {
+		return stockLevel.AvailableQuantity, nil
 	}
 
-	// Update stock based on movement type
-	var newQuantity int
-	switch movement.Type {
-	case model.MovementTypeInbound, model.MovementTypeReturn:
-		newQuantity = stockLevel.AvailableQuantity + movement.Quantity
-	case model.MovementTypeOutbound, model.MovementTypeTransfer:
-		newQuantity = stockLevel.AvailableQuantity - movement.Quantity
-		if newQuantity < 0 {
-			return errors.New("insufficient stock for outbound movement")
+	// Fall back to the catalog quantity when the product has no stock level yet
+	if variantID != nil {
+		for _, variant := range product.Variants {
+			if variant.ID == *variantID {
+				return variant.StockQuantity, nil
+			}
 		}
-	case model.MovementTypeAdjustment:
-		newQuantity = movement.Quantity
+		return 0, nil
 	}
+	return product.StockQuantity, nil
+}
 
-	// Update stock level
-	stockLevel.AvailableQuantity = newQuantity
-	stockLevel.TotalQuantity = newQuantity
-	stockLevel.LastMovementAt = &movement.CreatedAt
+// Bundles
 
-	if err := s.inventoryRepo.UpdateStockLevel(stockLevel); err != nil {
-		logger.Errorf("Error updating stock level: %v", err)
-		return fmt.Errorf("failed to update stock level")
+// GetBundleAvailability computes how many bundles can be assembled from the stock of its components
+func (s *inventoryService) GetBundleAvailability(bundleID uint) (*model.BundleAvailabilityResponse, error) {
+	items, err := s.bundleRepo.GetByBundleID(bundleID)
+	if err != nil {
+		logger.Errorf("Error getting bundle items for product %d: %v", bundleID, err)
+		return nil, fmt.Errorf("failed to retrieve bundle items")
 	}
 
-	return nil
+	availability := &model.BundleAvailabilityResponse{
+		BundleID:   bundleID,
+		Components: []model.ProductBundleItemResponse{},
+	}
+
+	for i, item := range items {
+		stockLevel, err := s.inventoryRepo.GetStockLevelByProduct(item.ComponentProductID, item.ComponentVariantID)
+		if err != nil {
+			logger.Errorf("Error getting stock level for product %d: %v", item.ComponentProductID, err)
+			return nil, fmt.Errorf("failed to retrieve stock level")
+		}
+
+		component := item.ToResponse()
+		if stockLevel != nil {
+			component.AvailableQuantity = stockLevel.AvailableQuantity
+		}
+		if item.Quantity > 0 {
+			component.AvailableBundles = component.AvailableQuantity / item.Quantity
+		}
+
+		// The scarcest component limits the bundle
+		if i == 0 || component.AvailableBundles < availability.AvailableQuantity {
+			availability.AvailableQuantity = component.AvailableBundles
+		}
+		availability.Components = append(availability.Components, component)
+	}
+
+	availability.StockStatus = "outofstock"
+	if availability.AvailableQuantity > 0 {
+		availability.StockStatus = "instock"
+	}
+
+	return availability, nil
+}
+
+// Inventory Lots
+
+// GetLots retrieves inventory lots with pagination and filters
+func (s *inventoryService) GetLots(page, limit int, filters map[string]interface{}) ([]model.InventoryLotResponse, int64, error) {
+	lots, total, err := s.inventoryRepo.GetLots(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Error getting inventory lots: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve inventory lots")
+	}
+
+	now := time.Now()
+	var responses []model.InventoryLotResponse
+	for _, lot := range lots {
+		responses = append(responses, *s.toLotResponse(&lot, now))
+	}
+	return responses, total, nil
+}
+
+// GetLotByID retrieves an inventory lot by its ID
+func (s *inventoryService) GetLotByID(id uint) (*model.InventoryLotResponse, error) {
+	lot, err := s.inventoryRepo.GetLotByID(id)
+	if err != nil {
+		logger.Errorf("Error getting inventory lot by ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to retrieve inventory lot")
+	}
+	if lot == nil {
+		return nil, errors.New("lot not found")
+	}
+	return s.toLotResponse(lot, time.Now()), nil
+}
+
+// GetPickPlan previews which lots a FEFO pick of quantity would take, without reserving anything
+func (s *inventoryService) GetPickPlan(productID uint, variantID *uint, quantity int) (*model.LotPickPlan, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+
+	lots, err := s.inventoryRepo.GetAvailableLotsFEFO(productID, variantID, time.Now())
+	if err != nil {
+		logger.Errorf("Error getting available lots for product %d: %v", productID, err)
+		return nil, fmt.Errorf("failed to retrieve inventory lots")
+	}
+
+	plan := &model.LotPickPlan{
+		ProductID:         productID,
+		VariantID:         variantID,
+		RequestedQuantity: quantity,
+		Lines:             []model.LotPickLine{},
+	}
+
+	remaining := quantity
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		picked := lot.AvailableQuantity
+		if picked > remaining {
+			picked = remaining
+		}
+		plan.Lines = append(plan.Lines, model.LotPickLine{
+			LotID:      lot.ID,
+			LotNumber:  lot.LotNumber,
+			ExpiryDate: lot.ExpiryDate,
+			Quantity:   picked,
+		})
+		plan.PickedQuantity += picked
+		remaining -= picked
+	}
+	plan.ShortQuantity = remaining
+
+	return plan, nil
+}
+
+// GetLotStockReport builds the stock-by-lot report grouped by product/variant
+func (s *inventoryService) GetLotStockReport(nearExpiryDays int, filters map[string]interface{}) (*model.LotStockReport, error) {
+	if nearExpiryDays <= 0 {
+		nearExpiryDays = 30
+	}
+
+	filters["in_stock"] = true
+	lots, _, err := s.inventoryRepo.GetLots(0, 0, filters)
+	if err != nil {
+		logger.Errorf("Error getting inventory lots for report: %v", err)
+		return nil, fmt.Errorf("failed to retrieve inventory lots")
+	}
+
+	now := time.Now()
+	report := &model.LotStockReport{
+		GeneratedAt:    now,
+		NearExpiryDays: nearExpiryDays,
+		Items:          []model.LotStockReportItem{},
+	}
+
+	index := make(map[string]int)
+	for _, lot := range lots {
+		key := stockLevelKey(lot.ProductID, lot.VariantID)
+		i, ok := index[key]
+		if !ok {
+			item := model.LotStockReportItem{
+				ProductID: lot.ProductID,
+				VariantID: lot.VariantID,
+			}
+			if lot.Product != nil {
+				item.ProductName = lot.Product.Name
+			}
+			if lot.Variant != nil {
+				item.VariantName = lot.Variant.Name
+			}
+			report.Items = append(report.Items, item)
+			i = len(report.Items) - 1
+			index[key] = i
+		}
+
+		item := &report.Items[i]
+		response := s.toLotResponse(&lot, now)
+		item.Lots = append(item.Lots, *response)
+		item.TotalQuantity += lot.AvailableQuantity
+		report.TotalLots++
+		report.TotalQuantity += lot.AvailableQuantity
+
+		switch {
+		case lot.IsExpired(now):
+			item.ExpiredQuantity += lot.AvailableQuantity
+			report.ExpiredLots++
+		case response.DaysUntilExpiry != nil && *response.DaysUntilExpiry <= nearExpiryDays:
+			item.NearExpiryQty += lot.AvailableQuantity
+			report.NearExpiryLots++
+		}
+	}
+
+	return report, nil
+}
+
+// CheckNearExpiryLots marks expired lots and sends one alert per lot expiring within days
+func (s *inventoryService) CheckNearExpiryLots(days int) (*model.NearExpiryAlertResult, error) {
+	if days <= 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	expired, err := s.inventoryRepo.MarkExpiredLots(now)
+	if err != nil {
+		logger.Errorf("Error marking expired lots: %v", err)
+		return nil, fmt.Errorf("failed to mark expired lots")
+	}
+
+	lots, err := s.inventoryRepo.GetLotsExpiringBefore(now.AddDate(0, 0, days))
+	if err != nil {
+		logger.Errorf("Error getting lots expiring within %d days: %v", days, err)
+		return nil, fmt.Errorf("failed to retrieve expiring lots")
+	}
+
+	result := &model.NearExpiryAlertResult{
+		Days:        days,
+		LotsChecked: len(lots),
+		ExpiredLots: int(expired),
+	}
+
+	for i := range lots {
+		lot := &lots[i]
+		if lot.IsExpired(now) || lot.NearExpiryNotifiedAt != nil {
+			continue
+		}
+
+		if s.eventService != nil {
+			if err := s.eventService.OnLotNearExpiry(lot, *lot.DaysUntilExpiry(now)); err != nil {
+				logger.Errorf("Failed to send near-expiry alert for lot %d: %v", lot.ID, err)
+				continue
+			}
+		}
+
+		lot.NearExpiryNotifiedAt = &now
+		if err := s.inventoryRepo.UpdateLot(lot); err != nil {
+			logger.Errorf("Error updating lot %d: %v", lot.ID, err)
+			continue
+		}
+		result.AlertsSent++
+		result.AlertedLots = append(result.AlertedLots, lot.ID)
+	}
+
+	return result, nil
+}
+
+// stockLevelKey builds a map key for a product/variant pair
+func stockLevelKey(productID uint, variantID *uint) string {
+	if variantID == nil {
+		return fmt.Sprintf("%d", productID)
+	}
+	return fmt.Sprintf("%d:%d", productID, *variantID)
 }
 
 // Helper methods for converting models to responses
 
+func (s *inventoryService) toLotResponse(lot *model.InventoryLot, now time.Time) *model.InventoryLotResponse {
+	response := &model.InventoryLotResponse{
+		ID:                lot.ID,
+		ProductID:         lot.ProductID,
+		VariantID:         lot.VariantID,
+		LotNumber:         lot.LotNumber,
+		ExpiryDate:        lot.ExpiryDate,
+		ManufacturedAt:    lot.ManufacturedAt,
+		DaysUntilExpiry:   lot.DaysUntilExpiry(now),
+		ReceivedQuantity:  lot.ReceivedQuantity,
+		AvailableQuantity: lot.AvailableQuantity,
+		UnitCost:          lot.UnitCost,
+		Status:            lot.Status,
+		ReceivedAt:        lot.ReceivedAt,
+		CreatedAt:         lot.CreatedAt,
+		UpdatedAt:         lot.UpdatedAt,
+	}
+
+	if lot.Product != nil {
+		response.ProductName = lot.Product.Name
+	}
+	if lot.Variant != nil {
+		response.VariantName = lot.Variant.Name
+	}
+
+	return response
+}
+
 func (s *inventoryService) toMovementResponse(movement *model.InventoryMovement) *model.InventoryMovementResponse {
 	response := &model.InventoryMovementResponse{
-		ID:            movement.ID,
-		ProductID:     movement.ProductID,
-		VariantID:     movement.VariantID,
-		Type:          movement.Type,
-		Status:        movement.Status,
-		Quantity:      movement.Quantity,
-		UnitCost:      movement.UnitCost,
-		TotalCost:     movement.TotalCost,
-		Reference:     movement.Reference,
-		ReferenceType: movement.ReferenceType,
-		Notes:         movement.Notes,
-		CreatedBy:     movement.CreatedBy,
-		ApprovedBy:    movement.ApprovedBy,
-		ApprovedAt:    movement.ApprovedAt,
-		CompletedAt:   movement.CompletedAt,
-		CreatedAt:     movement.CreatedAt,
-		UpdatedAt:     movement.UpdatedAt,
+		ID:             movement.ID,
+		ProductID:      movement.ProductID,
+		VariantID:      movement.VariantID,
+		Type:           movement.Type,
+		Status:         movement.Status,
+		Quantity:       movement.Quantity,
+		UnitCost:       movement.UnitCost,
+		TotalCost:      movement.TotalCost,
+		Reference:      movement.Reference,
+		ReferenceType:  movement.ReferenceType,
+		Notes:          movement.Notes,
+		LotID:          movement.LotID,
+		LotNumber:      movement.LotNumber,
+		ExpiryDate:     movement.ExpiryDate,
+		ManufacturedAt: movement.ManufacturedAt,
+		CreatedBy:      movement.CreatedBy,
+		ApprovedBy:     movement.ApprovedBy,
+		ApprovedAt:     movement.ApprovedAt,
+		CompletedAt:    movement.CompletedAt,
+		CreatedAt:      movement.CreatedAt,
+		UpdatedAt:      movement.UpdatedAt,
 	}
 
 	if movement.Product != nil {
@@ -637,6 +1012,7 @@ func (s *inventoryService) toStockLevelResponse(stockLevel *model.StockLevel) *m
 		MinStockLevel:     stockLevel.MinStockLevel,
 		MaxStockLevel:     stockLevel.MaxStockLevel,
 		ReorderPoint:      stockLevel.ReorderPoint,
+		Location:          stockLevel.Location,
 		LastMovementAt:    stockLevel.LastMovementAt,
 		CreatedAt:         stockLevel.CreatedAt,
 		UpdatedAt:         stockLevel.UpdatedAt,
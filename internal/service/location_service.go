@@ -0,0 +1,775 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/logger"
+	"go_app/pkg/vnaddress"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// locationCacheTTL bounds how long a process serves a directory snapshot after another process imported a dataset
+	locationCacheTTL = 10 * time.Minute
+	// locationNormalizeBatch is the number of records normalized per query
+	locationNormalizeBatch = 200
+	// maxNormalizeIssues caps the unresolved records listed in a normalization result
+	maxNormalizeIssues = 100
+)
+
+// LocationService defines methods for the Vietnamese administrative directory
+type LocationService interface {
+	// Directory
+	ImportBundledDataset() (*model.LocationImportResult, error)
+	ImportDataset(reader io.Reader) (*model.LocationImportResult, error)
+	GetProvinces() ([]model.Province, error)
+	GetDistricts(provinceCode string) ([]model.District, error)
+	GetWards(districtCode string) ([]model.Ward, error)
+
+	// Matching
+	Resolve(province, district, ward string) (*model.ResolvedLocation, error)
+	ApplyToAddress(address *model.Address) error
+	CanonicalZone(province, district string) (string, string)
+	NormalizeLocations(req *model.LocationNormalizeRequest) ([]model.LocationNormalizeResult, error)
+
+	// Carrier codes
+	GetCarrierCodes(carrier, level string) ([]model.CarrierLocationCode, error)
+	UpsertCarrierCodes(carrier string, req *model.CarrierLocationCodeRequest) ([]model.CarrierLocationCode, error)
+	GetCarrierLocation(carrier, provinceCode, districtCode, wardCode string) (*model.CarrierLocation, error)
+}
+
+// locationService implements LocationService
+type locationService struct {
+	locationRepo repository.LocationRepository
+}
+
+// NewLocationService creates a new LocationService
+func NewLocationService() LocationService {
+	return &locationService{
+		locationRepo: repository.NewLocationRepository(),
+	}
+}
+
+// locationCache holds the directory snapshot shared by every LocationService in the process
+var locationCache struct {
+	sync.Mutex
+	directory *locationDirectory
+}
+
+// locationIndex finds the code of a unit by any of its names
+type locationIndex struct {
+	byFullKey map[string]string   // "quan 1" => "760"
+	byKey     map[string][]string // "1" => ["760"]; several codes when names collide
+}
+
+func newLocationIndex() *locationIndex {
+	return &locationIndex{byFullKey: make(map[string]string), byKey: make(map[string][]string)}
+}
+
+func (ix *locationIndex) add(code, nameKey, fullName, aliases string) {
+	ix.byFullKey[vnaddress.FullKey(fullName)] = code
+
+	keys := []string{nameKey}
+	if aliases != "" {
+		keys = append(keys, strings.Split(aliases, "|")...)
+	}
+	for _, key := range keys {
+		if key == "" || containsString(ix.byKey[key], code) {
+			continue
+		}
+		ix.byKey[key] = append(ix.byKey[key], code)
+	}
+}
+
+// match returns the code of the unit named name, or "" when unknown or ambiguous
+func (ix *locationIndex) match(level, name string) string {
+	if ix == nil || strings.TrimSpace(name) == "" {
+		return ""
+	}
+	if code, ok := ix.byFullKey[vnaddress.FullKey(name)]; ok {
+		return code
+	}
+	if codes := ix.byKey[vnaddress.Key(level, name)]; len(codes) == 1 {
+		return codes[0]
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// locationDirectory is an in-memory snapshot of the directory used to match names
+type locationDirectory struct {
+	provinces     map[string]*model.Province
+	districts     map[string]*model.District
+	wards         map[string]*model.Ward
+	provinceIndex *locationIndex
+	districtIndex map[string]*locationIndex // by province code
+	wardIndex     map[string]*locationIndex // by district code
+	loadedAt      time.Time
+}
+
+// empty checks if no dataset has been imported yet
+func (d *locationDirectory) empty() bool {
+	return len(d.provinces) == 0
+}
+
+// directory returns the cached directory snapshot, reloading it when stale
+func (s *locationService) directory() (*locationDirectory, error) {
+	locationCache.Lock()
+	defer locationCache.Unlock()
+
+	if locationCache.directory != nil && time.Since(locationCache.directory.loadedAt) < locationCacheTTL {
+		return locationCache.directory, nil
+	}
+
+	provinces, err := s.locationRepo.GetProvinces()
+	if err != nil {
+		return nil, err
+	}
+	districts, err := s.locationRepo.GetAllDistricts()
+	if err != nil {
+		return nil, err
+	}
+	wards, err := s.locationRepo.GetAllWards()
+	if err != nil {
+		return nil, err
+	}
+
+	directory := &locationDirectory{
+		provinces:     make(map[string]*model.Province, len(provinces)),
+		districts:     make(map[string]*model.District, len(districts)),
+		wards:         make(map[string]*model.Ward, len(wards)),
+		provinceIndex: newLocationIndex(),
+		districtIndex: make(map[string]*locationIndex),
+		wardIndex:     make(map[string]*locationIndex),
+		loadedAt:      time.Now(),
+	}
+	for i := range provinces {
+		province := &provinces[i]
+		directory.provinces[province.Code] = province
+		directory.provinceIndex.add(province.Code, province.NameKey, province.FullName, province.Aliases)
+	}
+	for i := range districts {
+		district := &districts[i]
+		directory.districts[district.Code] = district
+		index, ok := directory.districtIndex[district.ProvinceCode]
+		if !ok {
+			index = newLocationIndex()
+			directory.districtIndex[district.ProvinceCode] = index
+		}
+		index.add(district.Code, district.NameKey, district.FullName, district.Aliases)
+	}
+	for i := range wards {
+		ward := &wards[i]
+		directory.wards[ward.Code] = ward
+		index, ok := directory.wardIndex[ward.DistrictCode]
+		if !ok {
+			index = newLocationIndex()
+			directory.wardIndex[ward.DistrictCode] = index
+		}
+		index.add(ward.Code, ward.NameKey, ward.FullName, ward.Aliases)
+	}
+
+	locationCache.directory = directory
+	return directory, nil
+}
+
+// invalidateLocationCache drops the directory snapshot after an import
+func invalidateLocationCache() {
+	locationCache.Lock()
+	defer locationCache.Unlock()
+	locationCache.directory = nil
+}
+
+// Directory
+
+// ImportBundledDataset loads the directory shipped with the application
+func (s *locationService) ImportBundledDataset() (*model.LocationImportResult, error) {
+	dataset, err := vnaddress.Bundled()
+	if err != nil {
+		logger.Errorf("Failed to read bundled address dataset: %v", err)
+		return nil, fmt.Errorf("failed to import address dataset")
+	}
+	return s.importDataset(dataset)
+}
+
+// ImportDataset loads a directory in the bundled JSON format, e.g. a complete GSO export
+func (s *locationService) ImportDataset(reader io.Reader) (*model.LocationImportResult, error) {
+	dataset, err := vnaddress.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	return s.importDataset(dataset)
+}
+
+// importDataset upserts the dataset, then renames shipping rate zones to the directory names
+// so rate lookups keep matching the normalized request
+func (s *locationService) importDataset(dataset *vnaddress.Dataset) (*model.LocationImportResult, error) {
+	var provinces []model.Province
+	var districts []model.District
+	var wards []model.Ward
+	for _, p := range dataset.Provinces {
+		provinces = append(provinces, model.Province{
+			Code:     p.Code,
+			Name:     p.Name,
+			FullName: p.FullName,
+			Type:     p.Type,
+			NameKey:  vnaddress.Key(vnaddress.LevelProvince, p.Name),
+			Aliases:  aliasKeys(vnaddress.LevelProvince, p.FullName, p.Aliases),
+		})
+		for _, d := range p.Districts {
+			districts = append(districts, model.District{
+				Code:         d.Code,
+				ProvinceCode: p.Code,
+				Name:         d.Name,
+				FullName:     d.FullName,
+				Type:         d.Type,
+				NameKey:      vnaddress.Key(vnaddress.LevelDistrict, d.Name),
+				Aliases:      aliasKeys(vnaddress.LevelDistrict, d.FullName, d.Aliases),
+			})
+			for _, w := range d.Wards {
+				wards = append(wards, model.Ward{
+					Code:         w.Code,
+					DistrictCode: d.Code,
+					ProvinceCode: p.Code,
+					Name:         w.Name,
+					FullName:     w.FullName,
+					Type:         w.Type,
+					NameKey:      vnaddress.Key(vnaddress.LevelWard, w.Name),
+					Aliases:      aliasKeys(vnaddress.LevelWard, w.FullName, w.Aliases),
+				})
+			}
+		}
+	}
+
+	codes := make([]model.CarrierLocationCode, 0, len(dataset.CarrierCodes))
+	for _, c := range dataset.CarrierCodes {
+		codes = append(codes, model.CarrierLocationCode{
+			Carrier:      c.Carrier,
+			Level:        c.Level,
+			Code:         c.Code,
+			ExternalID:   c.ExternalID,
+			ExternalName: c.ExternalName,
+		})
+	}
+
+	if err := s.locationRepo.ImportDirectory(provinces, districts, wards, codes); err != nil {
+		logger.Errorf("Failed to import address dataset %s: %v", dataset.Version, err)
+		return nil, fmt.Errorf("failed to import address dataset")
+	}
+	invalidateLocationCache()
+
+	if _, err := s.NormalizeLocations(&model.LocationNormalizeRequest{Targets: []string{model.LocationTargetShippingRates}}); err != nil {
+		logger.Warnf("Failed to normalize shipping rate zones after address import: %v", err)
+	}
+
+	return &model.LocationImportResult{
+		Version:      dataset.Version,
+		Provinces:    len(provinces),
+		Districts:    len(districts),
+		Wards:        len(wards),
+		CarrierCodes: len(codes),
+	}, nil
+}
+
+// aliasKeys joins the comparison keys of a unit's other names
+func aliasKeys(level, fullName string, aliases []string) string {
+	keys := []string{vnaddress.Key(level, fullName)}
+	for _, alias := range aliases {
+		if key := vnaddress.Key(level, alias); key != "" && !containsString(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return strings.Join(keys, "|")
+}
+
+// GetProvinces lists all provinces
+func (s *locationService) GetProvinces() ([]model.Province, error) {
+	provinces, err := s.locationRepo.GetProvinces()
+	if err != nil {
+		logger.Errorf("Failed to get provinces: %v", err)
+		return nil, fmt.Errorf("failed to retrieve provinces")
+	}
+	return provinces, nil
+}
+
+// GetDistricts lists the districts of a province
+func (s *locationService) GetDistricts(provinceCode string) ([]model.District, error) {
+	province, err := s.locationRepo.GetProvinceByCode(provinceCode)
+	if err != nil {
+		logger.Errorf("Failed to get province %s: %v", provinceCode, err)
+		return nil, fmt.Errorf("failed to retrieve districts")
+	}
+	if province == nil {
+		return nil, errors.New("province not found")
+	}
+
+	districts, err := s.locationRepo.GetDistrictsByProvince(provinceCode)
+	if err != nil {
+		logger.Errorf("Failed to get districts of province %s: %v", provinceCode, err)
+		return nil, fmt.Errorf("failed to retrieve districts")
+	}
+	return districts, nil
+}
+
+// GetWards lists the wards of a district
+func (s *locationService) GetWards(districtCode string) ([]model.Ward, error) {
+	district, err := s.locationRepo.GetDistrictByCode(districtCode)
+	if err != nil {
+		logger.Errorf("Failed to get district %s: %v", districtCode, err)
+		return nil, fmt.Errorf("failed to retrieve wards")
+	}
+	if district == nil {
+		return nil, errors.New("district not found")
+	}
+
+	wards, err := s.locationRepo.GetWardsByDistrict(districtCode)
+	if err != nil {
+		logger.Errorf("Failed to get wards of district %s: %v", districtCode, err)
+		return nil, fmt.Errorf("failed to retrieve wards")
+	}
+	return wards, nil
+}
+
+// Matching
+
+// Resolve matches free-text names to the directory, level by level
+func (s *locationService) Resolve(province, district, ward string) (*model.ResolvedLocation, error) {
+	directory, err := s.directory()
+	if err != nil {
+		logger.Errorf("Failed to load address directory: %v", err)
+		return nil, fmt.Errorf("failed to resolve location")
+	}
+
+	resolved := &model.ResolvedLocation{}
+	p := directory.provinces[directory.provinceIndex.match(vnaddress.LevelProvince, province)]
+	if p == nil {
+		resolved.Unresolved = model.LocationLevelProvince
+		return resolved, nil
+	}
+	resolved.ProvinceCode, resolved.Province = p.Code, p.FullName
+
+	if district != "" {
+		d := directory.districts[directory.districtIndex[p.Code].match(vnaddress.LevelDistrict, district)]
+		if d == nil {
+			resolved.Unresolved = model.LocationLevelDistrict
+			return resolved, nil
+		}
+		resolved.DistrictCode, resolved.District = d.Code, d.FullName
+
+		if ward != "" {
+			w := directory.wards[directory.wardIndex[d.Code].match(vnaddress.LevelWard, ward)]
+			if w == nil {
+				resolved.Unresolved = model.LocationLevelWard
+				return resolved, nil
+			}
+			resolved.WardCode, resolved.Ward = w.Code, w.FullName
+		}
+	} else if ward != "" {
+		resolved.Unresolved = model.LocationLevelDistrict
+		return resolved, nil
+	}
+
+	resolved.Complete = true
+	return resolved, nil
+}
+
+// ApplyToAddress checks a Vietnamese address against the directory and fills in its codes and
+// official names. Codes win over names; names are only required to match down to the deepest
+// level the directory holds for the province, so a partial dataset does not reject addresses.
+func (s *locationService) ApplyToAddress(address *model.Address) error {
+	if !isVietnamAddress(address) {
+		return nil
+	}
+
+	directory, err := s.directory()
+	if err != nil {
+		logger.Errorf("Failed to load address directory: %v", err)
+		return fmt.Errorf("failed to validate address location")
+	}
+	if directory.empty() {
+		return nil
+	}
+	return directory.locate(address)
+}
+
+// locate fills the codes and names of an address, walking down from the province
+func (d *locationDirectory) locate(address *model.Address) error {
+	// Codes given for a lower level fill in and must agree with the upper levels
+	if address.WardCode != "" {
+		ward := d.wards[address.WardCode]
+		if ward == nil {
+			return fmt.Errorf("invalid ward code %s", address.WardCode)
+		}
+		if address.DistrictCode == "" {
+			address.DistrictCode = ward.DistrictCode
+		} else if address.DistrictCode != ward.DistrictCode {
+			return errors.New("ward does not belong to the district")
+		}
+	}
+	if address.DistrictCode != "" {
+		district := d.districts[address.DistrictCode]
+		if district == nil {
+			return fmt.Errorf("invalid district code %s", address.DistrictCode)
+		}
+		if address.ProvinceCode == "" {
+			address.ProvinceCode = district.ProvinceCode
+		} else if address.ProvinceCode != district.ProvinceCode {
+			return errors.New("district does not belong to the province")
+		}
+	}
+
+	// Province
+	if address.ProvinceCode == "" {
+		address.ProvinceCode = d.provinceIndex.match(vnaddress.LevelProvince, address.City)
+		if address.ProvinceCode == "" {
+			return fmt.Errorf("unknown province %q", address.City)
+		}
+	}
+	province := d.provinces[address.ProvinceCode]
+	if province == nil {
+		return fmt.Errorf("invalid province code %s", address.ProvinceCode)
+	}
+	address.City = province.FullName
+
+	// District
+	if address.DistrictCode == "" {
+		index := d.districtIndex[province.Code]
+		if index == nil {
+			return nil
+		}
+		address.DistrictCode = index.match(vnaddress.LevelDistrict, address.District)
+		if address.DistrictCode == "" {
+			return fmt.Errorf("unknown district %q in %s", address.District, province.FullName)
+		}
+	}
+	district := d.districts[address.DistrictCode]
+	address.District = district.FullName
+
+	// Ward
+	if address.WardCode == "" {
+		index := d.wardIndex[district.Code]
+		if index == nil {
+			return nil
+		}
+		address.WardCode = index.match(vnaddress.LevelWard, address.Ward)
+		if address.WardCode == "" {
+			return fmt.Errorf("unknown ward %q in %s", address.Ward, district.FullName)
+		}
+	}
+	address.Ward = d.wards[address.WardCode].FullName
+
+	return nil
+}
+
+// isVietnamAddress checks if an address is in Vietnam; the country defaults to Vietnam
+func isVietnamAddress(address *model.Address) bool {
+	switch vnaddress.FullKey(address.Country) {
+	case "", "vietnam", "viet nam", "vn":
+		return true
+	}
+	return false
+}
+
+// CanonicalZone renames a province and district to the directory's official names, as stored on
+// normalized shipping rates. Wildcards and unknown names are returned unchanged.
+func (s *locationService) CanonicalZone(province, district string) (string, string) {
+	if province == model.ShippingZoneWildcard || province == "" {
+		return province, district
+	}
+
+	directory, err := s.directory()
+	if err != nil {
+		logger.Warnf("Failed to load address directory for zone lookup: %v", err)
+		return province, district
+	}
+	return directory.canonicalZone(province, district)
+}
+
+func (d *locationDirectory) canonicalZone(province, district string) (string, string) {
+	p := d.provinces[d.provinceIndex.match(vnaddress.LevelProvince, province)]
+	if p == nil {
+		return province, district
+	}
+
+	if district != model.ShippingZoneWildcard {
+		if dist := d.districts[d.districtIndex[p.Code].match(vnaddress.LevelDistrict, district)]; dist != nil {
+			district = dist.FullName
+		}
+	}
+	return p.FullName, district
+}
+
+// NormalizeLocations rewrites stored free-text locations to the directory's names and codes
+func (s *locationService) NormalizeLocations(req *model.LocationNormalizeRequest) ([]model.LocationNormalizeResult, error) {
+	directory, err := s.directory()
+	if err != nil {
+		logger.Errorf("Failed to load address directory: %v", err)
+		return nil, fmt.Errorf("failed to normalize locations")
+	}
+	if directory.empty() {
+		return nil, errors.New("address directory is empty, import a dataset first")
+	}
+
+	targets := req.Targets
+	if len(targets) == 0 {
+		targets = []string{model.LocationTargetAddresses, model.LocationTargetShippingRates}
+	}
+
+	var results []model.LocationNormalizeResult
+	for _, target := range targets {
+		var result *model.LocationNormalizeResult
+		switch target {
+		case model.LocationTargetAddresses:
+			result, err = s.normalizeAddresses(directory, req.DryRun)
+		case model.LocationTargetShippingRates:
+			result, err = s.normalizeShippingRates(directory, req.DryRun)
+		default:
+			return nil, fmt.Errorf("unknown normalization target %q", target)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// normalizeAddresses fills codes and official names on every Vietnamese address
+func (s *locationService) normalizeAddresses(directory *locationDirectory, dryRun bool) (*model.LocationNormalizeResult, error) {
+	result := &model.LocationNormalizeResult{Target: model.LocationTargetAddresses, DryRun: dryRun}
+
+	var afterID uint
+	for {
+		addresses, err := s.locationRepo.GetAddressesAfter(afterID, locationNormalizeBatch)
+		if err != nil {
+			logger.Errorf("Failed to get addresses for normalization: %v", err)
+			return nil, fmt.Errorf("failed to normalize addresses")
+		}
+		if len(addresses) == 0 {
+			break
+		}
+
+		for i := range addresses {
+			address := &addresses[i]
+			afterID = address.ID
+			if !isVietnamAddress(address) {
+				continue
+			}
+			result.Scanned++
+
+			normalized := *address
+			if err := directory.locate(&normalized); err != nil {
+				result.Unresolved++
+				addNormalizeIssue(result, address.ID, address.GetShortAddress(), err.Error())
+				continue
+			}
+
+			if normalized.City == address.City && normalized.District == address.District && normalized.Ward == address.Ward &&
+				normalized.ProvinceCode == address.ProvinceCode && normalized.DistrictCode == address.DistrictCode && normalized.WardCode == address.WardCode {
+				result.Unchanged++
+				continue
+			}
+
+			result.Updated++
+			if dryRun {
+				continue
+			}
+			if err := s.locationRepo.UpdateAddressLocation(&normalized); err != nil {
+				logger.Errorf("Failed to normalize address %d: %v", address.ID, err)
+				return nil, fmt.Errorf("failed to normalize addresses")
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// normalizeShippingRates renames rate zones to the directory's official names
+func (s *locationService) normalizeShippingRates(directory *locationDirectory, dryRun bool) (*model.LocationNormalizeResult, error) {
+	result := &model.LocationNormalizeResult{Target: model.LocationTargetShippingRates, DryRun: dryRun}
+
+	var afterID uint
+	for {
+		rates, err := s.locationRepo.GetShippingRatesAfter(afterID, locationNormalizeBatch)
+		if err != nil {
+			logger.Errorf("Failed to get shipping rates for normalization: %v", err)
+			return nil, fmt.Errorf("failed to normalize shipping rates")
+		}
+		if len(rates) == 0 {
+			break
+		}
+
+		for i := range rates {
+			rate := &rates[i]
+			afterID = rate.ID
+			result.Scanned++
+
+			normalized := *rate
+			normalized.FromProvince, normalized.FromDistrict = directory.canonicalZone(rate.FromProvince, rate.FromDistrict)
+			normalized.ToProvince, normalized.ToDistrict = directory.canonicalZone(rate.ToProvince, rate.ToDistrict)
+
+			for _, zone := range []string{rate.FromProvince, rate.ToProvince} {
+				if zone != model.ShippingZoneWildcard && directory.provinceIndex.match(vnaddress.LevelProvince, zone) == "" {
+					result.Unresolved++
+					addNormalizeIssue(result, rate.ID, zone, "unknown province")
+					break
+				}
+			}
+
+			if normalized.FromProvince == rate.FromProvince && normalized.FromDistrict == rate.FromDistrict &&
+				normalized.ToProvince == rate.ToProvince && normalized.ToDistrict == rate.ToDistrict {
+				result.Unchanged++
+				continue
+			}
+
+			result.Updated++
+			if dryRun {
+				continue
+			}
+			if err := s.locationRepo.UpdateShippingRateZones(&normalized); err != nil {
+				logger.Errorf("Failed to normalize shipping rate %d: %v", rate.ID, err)
+				return nil, fmt.Errorf("failed to normalize shipping rates")
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// addNormalizeIssue lists an unresolved record, up to maxNormalizeIssues
+func addNormalizeIssue(result *model.LocationNormalizeResult, id uint, value, reason string) {
+	if len(result.Issues) < maxNormalizeIssues {
+		result.Issues = append(result.Issues, model.LocationNormalizeIssue{ID: id, Value: value, Reason: reason})
+	}
+}
+
+// Carrier codes
+
+// GetCarrierCodes lists a carrier's code mappings, optionally for one level
+func (s *locationService) GetCarrierCodes(carrier, level string) ([]model.CarrierLocationCode, error) {
+	codes, err := s.locationRepo.GetCarrierCodes(carrier, level)
+	if err != nil {
+		logger.Errorf("Failed to get %s location codes: %v", carrier, err)
+		return nil, fmt.Errorf("failed to retrieve carrier location codes")
+	}
+	return codes, nil
+}
+
+// UpsertCarrierCodes creates or updates a carrier's code mappings for directory units
+func (s *locationService) UpsertCarrierCodes(carrier string, req *model.CarrierLocationCodeRequest) ([]model.CarrierLocationCode, error) {
+	directory, err := s.directory()
+	if err != nil {
+		logger.Errorf("Failed to load address directory: %v", err)
+		return nil, fmt.Errorf("failed to save carrier location codes")
+	}
+
+	codes := make([]model.CarrierLocationCode, 0, len(req.Codes))
+	for _, input := range req.Codes {
+		if !directory.hasUnit(input.Level, input.Code) {
+			return nil, fmt.Errorf("unknown %s code %s", input.Level, input.Code)
+		}
+		if input.ExternalID == "" && input.ExternalName == "" {
+			return nil, fmt.Errorf("%s %s: external_id or external_name is required", input.Level, input.Code)
+		}
+		codes = append(codes, model.CarrierLocationCode{
+			Carrier:      carrier,
+			Level:        input.Level,
+			Code:         input.Code,
+			ExternalID:   input.ExternalID,
+			ExternalName: input.ExternalName,
+		})
+	}
+
+	if err := s.locationRepo.UpsertCarrierCodes(codes); err != nil {
+		logger.Errorf("Failed to save %s location codes: %v", carrier, err)
+		return nil, fmt.Errorf("failed to save carrier location codes")
+	}
+	return codes, nil
+}
+
+func (d *locationDirectory) hasUnit(level, code string) bool {
+	switch level {
+	case model.LocationLevelProvince:
+		return d.provinces[code] != nil
+	case model.LocationLevelDistrict:
+		return d.districts[code] != nil
+	case model.LocationLevelWard:
+		return d.wards[code] != nil
+	}
+	return false
+}
+
+// GetCarrierLocation translates directory codes to the identifiers and names a carrier expects.
+// Levels without a mapping fall back to the official name, which name-based carriers such as GHTK accept.
+func (s *locationService) GetCarrierLocation(carrier, provinceCode, districtCode, wardCode string) (*model.CarrierLocation, error) {
+	directory, err := s.directory()
+	if err != nil {
+		logger.Errorf("Failed to load address directory: %v", err)
+		return nil, fmt.Errorf("failed to retrieve carrier location")
+	}
+
+	location := &model.CarrierLocation{Carrier: carrier}
+	levels := make(map[string]string)
+	if province := directory.provinces[provinceCode]; province != nil {
+		location.ProvinceCode, location.ProvinceName = province.Code, province.FullName
+		levels[model.LocationLevelProvince] = province.Code
+	} else {
+		return nil, errors.New("province not found")
+	}
+	if district := directory.districts[districtCode]; district != nil {
+		location.DistrictCode, location.DistrictName = district.Code, district.FullName
+		levels[model.LocationLevelDistrict] = district.Code
+	}
+	if ward := directory.wards[wardCode]; ward != nil {
+		location.WardCode, location.WardName = ward.Code, ward.FullName
+		levels[model.LocationLevelWard] = ward.Code
+	}
+
+	mappings, err := s.locationRepo.GetCarrierCodesFor(carrier, levels)
+	if err != nil {
+		logger.Errorf("Failed to get %s location codes: %v", carrier, err)
+		return nil, fmt.Errorf("failed to retrieve carrier location")
+	}
+
+	mapped := make(map[string]bool, len(mappings))
+	for _, mapping := range mappings {
+		mapped[mapping.Level] = true
+		switch mapping.Level {
+		case model.LocationLevelProvince:
+			location.ProvinceID = mapping.ExternalID
+			if mapping.ExternalName != "" {
+				location.ProvinceName = mapping.ExternalName
+			}
+		case model.LocationLevelDistrict:
+			location.DistrictID = mapping.ExternalID
+			if mapping.ExternalName != "" {
+				location.DistrictName = mapping.ExternalName
+			}
+		case model.LocationLevelWard:
+			location.WardID = mapping.ExternalID
+			if mapping.ExternalName != "" {
+				location.WardName = mapping.ExternalName
+			}
+		}
+	}
+	for level := range levels {
+		if !mapped[level] {
+			location.MissingMapping = true
+		}
+	}
+
+	return location, nil
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/email"
+	"go_app/pkg/logger"
+)
+
+// LoginThrottledError is returned when an account must wait before the next login attempt
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // Account is locked, not just slowed down
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "account temporarily locked due to too many failed login attempts"
+	}
+	return "too many failed login attempts, please wait before trying again"
+}
+
+// LoginSecurityService tracks failed logins per account, applies progressive delays and
+// temporary lockouts, and alerts users about logins from a new device or country
+type LoginSecurityService struct {
+	repo         repository.LoginSecurityRepository
+	userRepo     repository.UserRepository
+	emailService *email.EmailService
+	eventService EventService
+	config       configs.LoginSecurityConfig
+}
+
+// NewLoginSecurityService creates a new login security service
+func NewLoginSecurityService() *LoginSecurityService {
+	config := configs.Load()
+	notificationService := NewNotificationService(repository.NewNotificationRepository(), repository.NewUserRepository())
+
+	return &LoginSecurityService{
+		repo:         repository.NewLoginSecurityRepository(),
+		userRepo:     repository.NewUserRepository(),
+		emailService: email.NewEmailService(),
+		eventService: NewEventService(notificationService, nil, nil),
+		config:       config.Login,
+	}
+}
+
+// CheckAllowed rejects the attempt while the account is locked or still inside its retry delay
+func (s *LoginSecurityService) CheckAllowed(user *model.User, req *LoginRequest) error {
+	now := time.Now()
+
+	if user.IsLocked() {
+		s.recordAttempt(user, req, false, model.LoginFailureLocked, "")
+		return &LoginThrottledError{RetryAfter: user.LockedUntil.Sub(now), Locked: true}
+	}
+
+	if delay := s.retryDelay(user.FailedLoginAttempts); delay > 0 && user.LastFailedLoginAt != nil {
+		if wait := user.LastFailedLoginAt.Add(delay).Sub(now); wait > 0 {
+			s.recordAttempt(user, req, false, model.LoginFailureThrottled, "")
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+
+	return nil
+}
+
+// RecordUnknownEmail records a login attempt for an email without an account
+func (s *LoginSecurityService) RecordUnknownEmail(req *LoginRequest) {
+	s.recordAttempt(nil, req, false, model.LoginFailureUnknownEmail, "")
+}
+
+// RecordInactive records a login attempt on a deactivated account
+func (s *LoginSecurityService) RecordInactive(user *model.User, req *LoginRequest) {
+	s.recordAttempt(user, req, false, model.LoginFailureInactive, "")
+}
+
+// RecordFailure counts a wrong password and locks the account once the limit is reached
+func (s *LoginSecurityService) RecordFailure(user *model.User, req *LoginRequest) error {
+	return s.recordFailure(user, req, model.LoginFailureInvalidPassword)
+}
+
+// RecordTwoFactorFailure counts a wrong second-factor code like a wrong password, so the
+// lockout also applies to guessing codes after the password was right
+func (s *LoginSecurityService) RecordTwoFactorFailure(user *model.User, req *LoginRequest) error {
+	return s.recordFailure(user, req, model.LoginFailureInvalidTwoFactor)
+}
+
+func (s *LoginSecurityService) recordFailure(user *model.User, req *LoginRequest, reason string) error {
+	s.recordAttempt(user, req, false, reason, "")
+
+	updated, err := s.repo.RecordFailure(user.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	if s.config.MaxFailedAttempts <= 0 || updated.FailedLoginAttempts < s.config.MaxFailedAttempts {
+		return nil
+	}
+
+	now := time.Now()
+	lockout := &model.AccountLockout{
+		UserID:         user.ID,
+		FailedAttempts: updated.FailedLoginAttempts,
+		IPAddress:      req.IPAddress,
+		LockedAt:       now,
+		LockedUntil:    now.Add(s.lockoutDuration(updated.LockoutCount)),
+	}
+	if err := s.repo.LockAccount(lockout); err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	logger.Warnf("Account %d locked until %s after %d failed logins (last IP %s)",
+		user.ID, lockout.LockedUntil.Format(time.RFC3339), lockout.FailedAttempts, req.IPAddress)
+
+	go s.notifyLocked(user.Email, lockout)
+
+	return &LoginThrottledError{RetryAfter: lockout.LockedUntil.Sub(now), Locked: true}
+}
+
+// RecordSuccess clears the failure counters and alerts the user when the login comes from
+// a device or country never seen on a previous successful login
+func (s *LoginSecurityService) RecordSuccess(user *model.User, req *LoginRequest) {
+	if user.FailedLoginAttempts > 0 || user.LockoutCount > 0 || user.LockedUntil != nil || user.LastFailedLoginAt != nil {
+		if err := s.repo.ResetFailures(user.ID); err != nil {
+			logger.Warnf("Failed to reset failed logins for user %d: %v", user.ID, err)
+		}
+		// Keep the loaded user in sync so a later full save does not restore the old counters
+		user.FailedLoginAttempts = 0
+		user.LastFailedLoginAt = nil
+		user.LockedUntil = nil
+		user.LockoutCount = 0
+	}
+
+	reasons := s.detectSuspicious(user.ID, req)
+	attempt := s.recordAttempt(user, req, true, "", strings.Join(reasons, ","))
+	if len(reasons) > 0 && attempt != nil {
+		go s.notifySuspicious(user.Email, attempt, reasons)
+	}
+}
+
+// GetLockedAccounts lists accounts that are currently locked
+func (s *LoginSecurityService) GetLockedAccounts(page, limit int) ([]model.LockedAccountResponse, int64, error) {
+	lockouts, total, err := s.repo.GetActiveLockouts(page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get locked accounts: %w", err)
+	}
+
+	accounts := make([]model.LockedAccountResponse, 0, len(lockouts))
+	for _, lockout := range lockouts {
+		account := model.LockedAccountResponse{
+			UserID:         lockout.UserID,
+			LockedAt:       lockout.LockedAt,
+			LockedUntil:    lockout.LockedUntil,
+			FailedAttempts: lockout.FailedAttempts,
+			LastIPAddress:  lockout.IPAddress,
+		}
+		if lockout.User != nil {
+			account.Username = lockout.User.Username
+			account.Email = lockout.User.Email
+			account.LockoutCount = lockout.User.LockoutCount
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, total, nil
+}
+
+// UnlockAccount lets an admin lift a lock before it expires
+func (s *LoginSecurityService) UnlockAccount(userID, adminID uint) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	unlocked, err := s.repo.UnlockAccount(userID, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	if !unlocked {
+		return errors.New("account is not locked")
+	}
+
+	logger.Infof("Account %d unlocked by admin %d", userID, adminID)
+	return nil
+}
+
+// GetLoginAttempts lists the login history of a user
+func (s *LoginSecurityService) GetLoginAttempts(userID uint, page, limit int) ([]model.LoginAttempt, int64, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, 0, errors.New("user not found")
+	}
+
+	attempts, total, err := s.repo.GetAttempts(userID, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	return attempts, total, nil
+}
+
+// retryDelay is the wait before the next attempt: BASE seconds once DelayAfterAttempts
+// failures were reached, doubled for every further failure and capped at DelayMaxSeconds
+func (s *LoginSecurityService) retryDelay(failedAttempts int) time.Duration {
+	if s.config.DelayBaseSeconds <= 0 || failedAttempts < s.config.DelayAfterAttempts {
+		return 0
+	}
+
+	maxDelay := time.Duration(s.config.DelayMaxSeconds) * time.Second
+	delay := time.Duration(s.config.DelayBaseSeconds) * time.Second
+	for i := s.config.DelayAfterAttempts; i < failedAttempts; i++ {
+		delay *= 2
+		if maxDelay > 0 && delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
+
+// lockoutDuration doubles the lock for every earlier lockout without a successful login in between
+func (s *LoginSecurityService) lockoutDuration(previousLockouts int) time.Duration {
+	maxDuration := time.Duration(s.config.LockoutMaxMinutes) * time.Minute
+	duration := time.Duration(s.config.LockoutMinutes) * time.Minute
+	if duration <= 0 {
+		duration = 15 * time.Minute
+	}
+	for i := 0; i < previousLockouts; i++ {
+		duration *= 2
+		if maxDuration > 0 && duration >= maxDuration {
+			return maxDuration
+		}
+	}
+	return duration
+}
+
+// detectSuspicious compares the login with the user's previous successful logins. The very
+// first login has nothing to compare against and is never flagged.
+func (s *LoginSecurityService) detectSuspicious(userID uint, req *LoginRequest) []string {
+	count, err := s.repo.CountSuccessfulLogins(userID)
+	if err != nil {
+		logger.Warnf("Failed to count successful logins for user %d: %v", userID, err)
+		return nil
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var reasons []string
+	if req.DeviceID != "" {
+		known, err := s.repo.HasLoginFromDevice(userID, req.DeviceID)
+		if err != nil {
+			logger.Warnf("Failed to check known devices for user %d: %v", userID, err)
+		} else if !known {
+			reasons = append(reasons, model.SuspiciousLoginNewDevice)
+		}
+	}
+	if req.Country != "" {
+		known, err := s.repo.HasLoginFromCountry(userID, req.Country)
+		if err != nil {
+			logger.Warnf("Failed to check known countries for user %d: %v", userID, err)
+		} else if !known {
+			reasons = append(reasons, model.SuspiciousLoginNewCountry)
+		}
+	}
+	return reasons
+}
+
+func (s *LoginSecurityService) recordAttempt(user *model.User, req *LoginRequest, success bool, failureReason, suspicious string) *model.LoginAttempt {
+	attempt := &model.LoginAttempt{
+		Email:         req.Email,
+		IPAddress:     req.IPAddress,
+		Country:       req.Country,
+		DeviceID:      req.DeviceID,
+		UserAgent:     req.UserAgent,
+		Success:       success,
+		FailureReason: failureReason,
+		Suspicious:    suspicious,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+
+	if err := s.repo.CreateAttempt(attempt); err != nil {
+		logger.Warnf("Failed to record login attempt for %s: %v", req.Email, err)
+		return nil
+	}
+	return attempt
+}
+
+func (s *LoginSecurityService) notifySuspicious(to string, attempt *model.LoginAttempt, reasons []string) {
+	if err := s.emailService.SendLoginAlertEmail(to, attempt.IPAddress, attempt.Country, attempt.UserAgent, attempt.CreatedAt); err != nil {
+		logger.Errorf("Failed to send login alert email to %s: %v", to, err)
+	}
+	if err := s.eventService.OnSuspiciousLogin(attempt, reasons); err != nil {
+		logger.Errorf("Failed to trigger suspicious login event: %v", err)
+	}
+}
+
+func (s *LoginSecurityService) notifyLocked(to string, lockout *model.AccountLockout) {
+	if err := s.emailService.SendAccountLockedEmail(to, lockout.IPAddress, lockout.LockedUntil); err != nil {
+		logger.Errorf("Failed to send account locked email to %s: %v", to, err)
+	}
+	if err := s.eventService.OnAccountLocked(lockout); err != nil {
+		logger.Errorf("Failed to trigger account locked event: %v", err)
+	}
+}
@@ -24,6 +24,15 @@ type OrderService interface {
 	ConfirmOrder(id uint, userID uint) error
 	ShipOrder(id uint, userID uint, trackingNumber string) error
 	DeliverOrder(id uint, userID uint) error
+	MarkReadyForPickup(id uint, userID uint) error
+
+	// Shipments
+	CreateShipment(id uint, req *model.OrderShipmentRequest, userID uint) (*model.Shipment, error)
+	GetShipments(id uint) (*model.OrderFulfillmentResponse, error)
+
+	// Backorders
+	GetBackorders(page, limit int, filters map[string]interface{}) ([]model.BackorderItemResponse, int64, error)
+	ProcessBackorders() (*model.BackorderProcessResult, error)
 
 	// Order Items
 	AddOrderItem(orderID uint, req *model.OrderItemCreateRequest, userID uint) (*model.OrderItemResponse, error)
@@ -70,32 +79,47 @@ type OrderService interface {
 
 // orderService implements OrderService
 type orderService struct {
-	orderRepo     repository.OrderRepository
-	productRepo   *repository.ProductRepository
-	inventoryRepo repository.InventoryRepository
-	userRepo      repository.UserRepository
-	eventService  EventService
+	orderRepo        repository.OrderRepository
+	productRepo      *repository.ProductRepository
+	bundleRepo       *repository.ProductBundleRepository
+	inventoryRepo    repository.InventoryRepository
+	inventoryService InventoryService
+	userRepo         repository.UserRepository
+	fulfillment      FulfillmentService
+	pickupService    PickupLocationService
+	slotService      DeliverySlotService
+	eventService     EventService
 }
 
 // NewOrderService creates a new OrderService
 func NewOrderService() OrderService {
 	return &orderService{
-		orderRepo:     repository.NewOrderRepository(),
-		productRepo:   repository.NewProductRepository(),
-		inventoryRepo: repository.NewInventoryRepository(),
-		userRepo:      repository.NewUserRepository(),
-		eventService:  nil, // Will be set by dependency injection
+		orderRepo:        repository.NewOrderRepository(),
+		productRepo:      repository.NewProductRepository(),
+		bundleRepo:       repository.NewProductBundleRepository(),
+		inventoryRepo:    repository.NewInventoryRepository(),
+		inventoryService: NewInventoryService(),
+		userRepo:         repository.NewUserRepository(),
+		fulfillment:      NewFulfillmentService(),
+		pickupService:    NewPickupLocationService(),
+		slotService:      NewDeliverySlotService(),
+		eventService:     nil, // Will be set by dependency injection
 	}
 }
 
 // NewOrderServiceWithEvent creates a new OrderService with EventService
 func NewOrderServiceWithEvent(eventService EventService) OrderService {
 	return &orderService{
-		orderRepo:     repository.NewOrderRepository(),
-		productRepo:   repository.NewProductRepository(),
-		inventoryRepo: repository.NewInventoryRepository(),
-		userRepo:      repository.NewUserRepository(),
-		eventService:  eventService,
+		orderRepo:        repository.NewOrderRepository(),
+		productRepo:      repository.NewProductRepository(),
+		bundleRepo:       repository.NewProductBundleRepository(),
+		inventoryRepo:    repository.NewInventoryRepository(),
+		inventoryService: NewInventoryServiceWithEvent(eventService),
+		userRepo:         repository.NewUserRepository(),
+		fulfillment:      NewFulfillmentService(),
+		pickupService:    NewPickupLocationService(),
+		slotService:      NewDeliverySlotService(),
+		eventService:     eventService,
 	}
 }
 
@@ -117,7 +141,7 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 		}
 
 		// Only admin users can create orders for other users
-		if currentUser.UserRole == nil || (currentUser.UserRole.Name != "admin" && currentUser.UserRole.Name != "super_admin") {
+		if !currentUser.HasAnyRole("admin", "super_admin") {
 			return nil, errors.New("only admin users can create orders for other users")
 		}
 
@@ -134,6 +158,20 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 		return nil, errors.New("target user not found")
 	}
 
+	// Click-and-collect orders are held at a pickup location instead of being delivered
+	var pickupLocation *model.PickupLocation
+	if req.ShippingMethod == model.ShippingMethodPickup {
+		if req.PickupLocationID == nil {
+			return nil, errors.New("pickup location is required for pickup orders")
+		}
+		pickupLocation, err = s.pickupService.CheckAvailability(*req.PickupLocationID)
+		if err != nil {
+			return nil, err
+		}
+	} else if req.PickupLocationID != nil {
+		return nil, errors.New("pickup location is only allowed with the pickup shipping method")
+	}
+
 	// Generate order number
 	orderNumber := s.GenerateOrderNumber()
 
@@ -158,6 +196,10 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 		DiscountAmount:  0,
 		TotalAmount:     0,
 	}
+	if pickupLocation != nil {
+		order.PickupLocationID = &pickupLocation.ID
+		order.ShippingAddress = fmt.Sprintf("%s - %s", pickupLocation.Name, pickupLocation.FullAddress())
+	}
 
 	// If creating from cart, copy items
 	if req.CartID != nil {
@@ -170,6 +212,15 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 			return nil, errors.New("cart not found")
 		}
 
+		// A delivery slot held during checkout becomes the order's delivery window
+		var slotHold *model.DeliverySlotReservation
+		if pickupLocation == nil {
+			slotHold, err = s.slotService.GetValidCartHold(*req.CartID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// Copy cart items to order
 		cartItems, err := s.orderRepo.GetCartItemsByCart(*req.CartID)
 		if err != nil {
@@ -177,9 +228,28 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 			return nil, fmt.Errorf("failed to retrieve cart items")
 		}
 
+		// Work out which quantities have to wait for stock before anything is written
+		backordered := make([]int, len(cartItems))
+		for i, cartItem := range cartItems {
+			qty, err := s.evaluateBackorder(cartItem.Product, cartItem.ProductVariantID, cartItem.Quantity)
+			if err != nil {
+				return nil, err
+			}
+			backordered[i] = qty
+		}
+
 		order.SubTotal = cart.SubTotal
 		order.TaxAmount = cart.TaxAmount
 		order.ShippingCost = cart.ShippingCost
+		if pickupLocation != nil {
+			order.ShippingCost = pickupLocation.PickupFee
+		}
+		if slotHold != nil {
+			order.DeliverySlotID = &slotHold.ID
+			order.DeliveryWindowStart = &slotHold.WindowStart
+			order.DeliveryWindowEnd = &slotHold.WindowEnd
+			order.ShippingCost += slotHold.Fee
+		}
 		order.DiscountAmount = cart.DiscountAmount
 		order.CalculateTotal()
 
@@ -189,8 +259,24 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 			return nil, fmt.Errorf("failed to create order")
 		}
 
+		if slotHold != nil {
+			if err := s.slotService.ConfirmReservation(slotHold.ID, order.ID); err != nil {
+				// The hold lapsed between the check and the insert; keep the order without a window
+				// and without the slot fee
+				logger.Warnf("Failed to confirm delivery slot %d for order %d: %v", slotHold.ID, order.ID, err)
+				order.DeliverySlotID = nil
+				order.DeliveryWindowStart = nil
+				order.DeliveryWindowEnd = nil
+				order.ShippingCost -= slotHold.Fee
+				order.CalculateTotal()
+				if err := s.orderRepo.UpdateOrder(order); err != nil {
+					logger.Errorf("Error clearing delivery slot of order %d: %v", order.ID, err)
+				}
+			}
+		}
+
 		// Create order items
-		for _, cartItem := range cartItems {
+		for i, cartItem := range cartItems {
 			orderItem := &model.OrderItem{
 				OrderID:          order.ID,
 				ProductID:        cartItem.ProductID,
@@ -205,6 +291,23 @@ func (s *orderService) CreateOrder(req *model.OrderCreateRequest, userID uint) (
 			}
 			orderItem.CalculateTotal()
 
+			// Flag the quantity that ships once stock arrives
+			if backordered[i] > 0 {
+				orderItem.BackorderedQuantity = backordered[i]
+				orderItem.BackorderType = cartItem.Product.BackorderPolicy
+				orderItem.ExpectedAvailableAt = cartItem.Product.BackorderExpectedAt
+			}
+
+			// Snapshot the component breakdown of bundles
+			if cartItem.Product != nil && cartItem.Product.IsBundle() {
+				components, err := s.buildOrderItemComponents(cartItem.ProductID, cartItem.Quantity)
+				if err != nil {
+					logger.Errorf("Error getting bundle components for product %d: %v", cartItem.ProductID, err)
+					return nil, fmt.Errorf("failed to retrieve bundle components")
+				}
+				orderItem.Components = components
+			}
+
 			if err := s.orderRepo.CreateOrderItem(orderItem); err != nil {
 				logger.Errorf("Error creating order item: %v", err)
 				return nil, fmt.Errorf("failed to create order item")
@@ -368,9 +471,19 @@ func (s *orderService) UpdateOrder(id uint, req *model.OrderUpdateRequest, userI
 		switch *req.Status {
 		case model.OrderStatusConfirmed:
 			// Order confirmed
-		case model.OrderStatusShipped:
+		case model.OrderStatusShipped, model.OrderStatusPartiallyShipped:
 			now := time.Now()
 			order.ShippedAt = &now
+		case model.OrderStatusReadyForPickup:
+			if !order.IsPickup() {
+				return nil, errors.New("only pickup orders can be ready for pickup")
+			}
+			now := time.Now()
+			order.ReadyForPickupAt = &now
+			if order.PickupLocation != nil {
+				deadline := now.AddDate(0, 0, order.PickupLocation.HoldDays)
+				order.PickupDeadline = &deadline
+			}
 		case model.OrderStatusDelivered:
 			now := time.Now()
 			order.DeliveredAt = &now
@@ -454,6 +567,13 @@ func (s *orderService) CancelOrder(id uint, userID uint, reason string) error {
 		logger.Warnf("Failed to restore inventory for cancelled order %d: %v", id, err)
 	}
 
+	// Free the delivery slot for other customers
+	if order.DeliverySlotID != nil {
+		if err := s.slotService.ReleaseOrderReservation(order.ID); err != nil {
+			logger.Warnf("Failed to release delivery slot for cancelled order %d: %v", id, err)
+		}
+	}
+
 	return nil
 }
 
@@ -504,33 +624,122 @@ func (s *orderService) ShipOrder(id uint, userID uint, trackingNumber string) er
 		return errors.New("order cannot be shipped")
 	}
 
-	// Update order status
-	order.Status = model.OrderStatusShipped
-	order.ShippingStatus = model.ShippingStatusInTransit
-	order.TrackingNumber = trackingNumber
+	// Ship what is in stock now; backordered quantities follow in a later shipment
+	items := readyToShipItems(order)
+	if len(order.OrderItems) > 0 && len(items) == 0 {
+		return errors.New("no items are ready to ship")
+	}
+
+	_, err = s.dispatchShipment(order, &model.ShipmentCreateRequest{
+		OrderID:        order.ID,
+		Carrier:        order.ShippingMethod,
+		TrackingNumber: trackingNumber,
+		Items:          items,
+		CreatedBy:      &userID,
+	}, userID)
+	return err
+}
+
+// CreateShipment ships part of an order as a parcel of its own, e.g. from another warehouse or carrier
+func (s *orderService) CreateShipment(id uint, req *model.OrderShipmentRequest, userID uint) (*model.Shipment, error) {
+	order, err := s.orderRepo.GetOrderByID(id)
+	if err != nil {
+		logger.Errorf("Error getting order by ID %d for shipment: %v", id, err)
+		return nil, fmt.Errorf("failed to retrieve order")
+	}
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+
+	if !order.CanBeShipped() {
+		return nil, errors.New("order cannot be shipped")
+	}
+
+	carrier := req.Carrier
+	if carrier == "" {
+		carrier = order.ShippingMethod
+	}
+
+	return s.dispatchShipment(order, &model.ShipmentCreateRequest{
+		OrderID:        order.ID,
+		Carrier:        carrier,
+		CarrierCode:    req.CarrierCode,
+		TrackingNumber: req.TrackingNumber,
+		Warehouse:      req.Warehouse,
+		Items:          req.Items,
+		CreatedBy:      &userID,
+	}, userID)
+}
+
+// GetShipments retrieves every shipment of an order with the merged timeline
+func (s *orderService) GetShipments(id uint) (*model.OrderFulfillmentResponse, error) {
+	return s.fulfillment.GetOrderFulfillment(id)
+}
+
+// dispatchShipment packs items into a shipment handed to the carrier. The order's shipping
+// and order status follow from all of its shipments, and the customer is notified per shipment.
+func (s *orderService) dispatchShipment(order *model.Order, req *model.ShipmentCreateRequest, userID uint) (*model.Shipment, error) {
+	shipment, err := s.fulfillment.CreateShipment(req)
+	if err != nil {
+		return nil, err
+	}
+
+	shipped := make(map[uint]int, len(req.Items))
+	for _, item := range req.Items {
+		shipped[item.OrderItemID] += item.Quantity
+	}
+	fullyShipped := true
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		if item.ShippedQuantity+shipped[item.ID] < item.Quantity {
+			fullyShipped = false
+		}
+	}
+
+	description := "Order shipped"
+	if !fullyShipped {
+		description = "Order partially shipped, remaining items will follow"
+	}
 	now := time.Now()
-	order.ShippedAt = &now
 
-	if err := s.orderRepo.UpdateOrder(order); err != nil {
-		logger.Errorf("Error shipping order %d: %v", id, err)
-		return fmt.Errorf("failed to ship order")
+	// The first tracking number stays on the order for older clients
+	if order.TrackingNumber == "" {
+		order.TrackingNumber = req.TrackingNumber
+		if err := s.orderRepo.UpdateOrder(order); err != nil {
+			logger.Warnf("Failed to set tracking number of order %d: %v", order.ID, err)
+		}
 	}
 
 	// Create shipping history entry
 	history := &model.ShippingHistory{
 		OrderID:     order.ID,
 		Status:      model.ShippingStatusInTransit,
-		Description: "Order shipped",
+		Description: description,
 		Location:    "Warehouse",
-		Notes:       fmt.Sprintf("Tracking number: %s", trackingNumber),
+		Notes:       fmt.Sprintf("Tracking number: %s", req.TrackingNumber),
 		UpdatedBy:   userID,
 	}
+	if req.Warehouse != "" {
+		history.Location = req.Warehouse
+	}
 
 	if err := s.orderRepo.CreateShippingHistory(history); err != nil {
-		logger.Warnf("Failed to create shipping history for order %d: %v", id, err)
+		logger.Warnf("Failed to create shipping history for order %d: %v", order.ID, err)
 	}
 
-	return nil
+	if _, err := s.fulfillment.RecordEvent(shipment, &model.ShipmentEvent{
+		Status:     model.TrackingStatusInTransit,
+		StatusText: description,
+		Location:   history.Location,
+		EventCode:  model.TrackingStatusInTransit,
+		Source:     model.SourceManual,
+		CreatedBy:  &userID,
+		EventTime:  now,
+	}); err != nil {
+		logger.Warnf("Failed to record shipment event for order %d: %v", order.ID, err)
+	}
+
+	return shipment, nil
 }
 
 // DeliverOrder delivers an order
@@ -568,14 +777,234 @@ func (s *orderService) DeliverOrder(id uint, userID uint) error {
 		Notes:       "Order delivered to customer",
 		UpdatedBy:   userID,
 	}
+	if order.IsPickup() {
+		history.Description = "Order collected at pickup location"
+		history.Notes = "Order handed over to customer at the counter"
+	}
 
 	if err := s.orderRepo.CreateShippingHistory(history); err != nil {
 		logger.Warnf("Failed to create shipping history for order %d: %v", id, err)
 	}
 
+	// Close the shipments that are still on their way
+	shipments, err := s.fulfillment.GetShipmentsByOrder(order.ID)
+	if err != nil {
+		logger.Warnf("Failed to get shipments for order %d: %v", id, err)
+		return nil
+	}
+	for i := range shipments {
+		if shipments[i].IsFinished() {
+			continue
+		}
+		if _, err := s.fulfillment.RecordEvent(&shipments[i], &model.ShipmentEvent{
+			Status:     model.TrackingStatusDelivered,
+			StatusText: "Order delivered successfully",
+			Location:   order.ShippingAddress,
+			EventCode:  model.TrackingStatusDelivered,
+			Source:     model.SourceManual,
+			CreatedBy:  &userID,
+			EventTime:  now,
+		}); err != nil {
+			logger.Warnf("Failed to record delivery of shipment %d: %v", shipments[i].ID, err)
+		}
+	}
+
 	return nil
 }
 
+// MarkReadyForPickup marks a pickup order as waiting at its pickup location and notifies the customer
+func (s *orderService) MarkReadyForPickup(id uint, userID uint) error {
+	order, err := s.orderRepo.GetOrderByID(id)
+	if err != nil {
+		logger.Errorf("Error getting order by ID %d for pickup: %v", id, err)
+		return fmt.Errorf("failed to retrieve order")
+	}
+	if order == nil {
+		return errors.New("order not found")
+	}
+
+	if !order.IsPickup() {
+		return errors.New("order is not a pickup order")
+	}
+	if !order.CanBeReadyForPickup() {
+		return errors.New("order cannot be marked ready for pickup")
+	}
+
+	location, err := s.pickupService.GetPickupLocationByID(*order.PickupLocationID)
+	if err != nil {
+		return err
+	}
+
+	// Update order status
+	order.Status = model.OrderStatusReadyForPickup
+	order.ShippingStatus = model.ShippingStatusReadyForPickup
+	now := time.Now()
+	deadline := now.AddDate(0, 0, location.HoldDays)
+	order.ReadyForPickupAt = &now
+	order.PickupDeadline = &deadline
+
+	if err := s.orderRepo.UpdateOrder(order); err != nil {
+		logger.Errorf("Error marking order %d ready for pickup: %v", id, err)
+		return fmt.Errorf("failed to mark order ready for pickup")
+	}
+
+	// Create shipping history entry
+	history := &model.ShippingHistory{
+		OrderID:     order.ID,
+		Status:      model.ShippingStatusReadyForPickup,
+		Description: "Order ready for pickup",
+		Location:    location.Name,
+		Notes:       fmt.Sprintf("Held until %s", deadline.Format("2006-01-02")),
+		UpdatedBy:   userID,
+	}
+
+	if err := s.orderRepo.CreateShippingHistory(history); err != nil {
+		logger.Warnf("Failed to create shipping history for order %d: %v", id, err)
+	}
+
+	if s.eventService != nil {
+		if err := s.eventService.OnOrderReadyForPickup(order, &location.PickupLocation); err != nil {
+			logger.Errorf("Failed to trigger order ready for pickup event: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Backorders
+
+// GetBackorders retrieves backordered items that are still waiting for stock
+func (s *orderService) GetBackorders(page, limit int, filters map[string]interface{}) ([]model.BackorderItemResponse, int64, error) {
+	items, total, err := s.orderRepo.GetOpenBackorderItems(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Error getting backorders: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve backorders")
+	}
+
+	responses := make([]model.BackorderItemResponse, 0, len(items))
+	for _, item := range items {
+		response := model.BackorderItemResponse{
+			OrderID: item.OrderID,
+			Item:    *s.toOrderItemResponse(&item),
+		}
+		if item.Order != nil {
+			response.OrderNumber = item.Order.OrderNumber
+			response.OrderStatus = item.Order.Status
+			response.CustomerName = item.Order.CustomerName
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, total, nil
+}
+
+// ProcessBackorders fulfills backordered items, oldest first, as stock becomes available. Only
+// orders holding their stock are served; pending orders reserve everything when confirmed.
+func (s *orderService) ProcessBackorders() (*model.BackorderProcessResult, error) {
+	items, _, err := s.orderRepo.GetOpenBackorderItems(1, 0, map[string]interface{}{})
+	if err != nil {
+		logger.Errorf("Error getting backorders: %v", err)
+		return nil, fmt.Errorf("failed to retrieve backorders")
+	}
+
+	result := &model.BackorderProcessResult{}
+	now := time.Now()
+
+	for i := range items {
+		item := &items[i]
+		if item.Order == nil {
+			continue
+		}
+		result.ItemsChecked++
+
+		if item.Order.StockReservedAt == nil {
+			continue
+		}
+
+		// Pre-orders are held until their release date
+		if item.BackorderType == model.BackorderPolicyPreorder && item.ExpectedAvailableAt != nil && now.Before(*item.ExpectedAvailableAt) {
+			continue
+		}
+
+		available, err := s.inventoryService.GetAvailableQuantity(item.ProductID, item.ProductVariantID)
+		if err != nil {
+			logger.Warnf("Failed to check availability for backordered item %d: %v", item.ID, err)
+			continue
+		}
+		if available < item.BackorderedQuantity {
+			continue
+		}
+
+		// The stock is taken and the item marked fulfilled together, checked against the locked level
+		lines, err := s.itemStockLines(item, item.BackorderedQuantity)
+		if err != nil {
+			logger.Warnf("Failed to build stock lines for backordered item %d: %v", item.ID, err)
+			continue
+		}
+		fulfilled, err := s.inventoryRepo.FulfillBackorderItem(item.ID, lines, item.Order.OrderNumber)
+		if err != nil {
+			if !errors.Is(err, repository.ErrInsufficientStock) {
+				logger.Errorf("Failed to reserve stock for backordered item %d: %v", item.ID, err)
+			}
+			continue
+		}
+		if !fulfilled {
+			continue
+		}
+		fulfilledAt := time.Now()
+		item.BackorderFulfilledAt = &fulfilledAt
+		result.ItemsFulfilled++
+		result.FulfilledItems = append(result.FulfilledItems, item.ID)
+
+		if s.eventService != nil {
+			if err := s.eventService.OnBackorderAvailable(item.Order, item); err != nil {
+				logger.Errorf("Failed to trigger backorder available event: %v", err)
+				continue
+			}
+			result.NotificationsSent++
+		}
+	}
+
+	return result, nil
+}
+
+// evaluateBackorder returns how much of the requested quantity has to be backordered
+func (s *orderService) evaluateBackorder(product *model.Product, variantID *uint, quantity int) (int, error) {
+	if product == nil || !product.ManageStock {
+		return 0, nil
+	}
+
+	available, err := s.inventoryService.GetAvailableQuantity(product.ID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	if available < 0 {
+		available = 0
+	}
+
+	shortfall := quantity - available
+	if shortfall <= 0 {
+		return 0, nil
+	}
+	if !product.AllowsBackorder() {
+		return 0, fmt.Errorf("insufficient stock for %s", product.Name)
+	}
+
+	// Cap the quantity that can be waiting for stock at any one time
+	if product.BackorderLimit > 0 {
+		outstanding, err := s.orderRepo.GetOpenBackorderedQuantity(product.ID, variantID)
+		if err != nil {
+			logger.Errorf("Error getting backordered quantity for product %d: %v", product.ID, err)
+			return 0, fmt.Errorf("failed to retrieve backordered quantity")
+		}
+		if outstanding+shortfall > product.BackorderLimit {
+			return 0, fmt.Errorf("backorder limit reached for %s", product.Name)
+		}
+	}
+
+	return shortfall, nil
+}
+
 // Helper methods
 
 // GenerateOrderNumber generates a unique order number
@@ -640,95 +1069,190 @@ func (s *orderService) getVariantName(variant *model.ProductVariant) string {
 	return variant.Name
 }
 
-// reserveInventoryForOrder reserves inventory for order items
+// reserveInventoryForOrder takes every in-stock quantity of the order from stock in one transaction,
+// so the order is either fully reserved or not at all. An order that already holds its stock, e.g.
+// when confirmation is retried after a failed status update, is not deducted again.
 func (s *orderService) reserveInventoryForOrder(order *model.Order) error {
 	orderItems, err := s.orderRepo.GetOrderItemsByOrder(order.ID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range orderItems {
-		// Create inventory movement for reservation
-		movement := &model.InventoryMovement{
-			ProductID:     item.ProductID,
-			Type:          model.MovementTypeOutbound,
-			Quantity:      -item.Quantity, // Negative for outbound
-			Reference:     order.OrderNumber,
-			ReferenceType: "order",
-			Status:        model.MovementStatusCompleted,
-		}
+	lines, err := s.orderStockLines(orderItems)
+	if err != nil {
+		return err
+	}
 
-		if err := s.inventoryRepo.CreateMovement(movement); err != nil {
-			return fmt.Errorf("failed to create inventory movement for product %d: %v", item.ProductID, err)
+	reserved, err := s.inventoryRepo.ReserveOrderStock(order.ID, lines, order.OrderNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return fmt.Errorf("insufficient stock for order %s", order.OrderNumber)
 		}
+		logger.Errorf("Failed to reserve stock for order %s: %v", order.OrderNumber, err)
+		return fmt.Errorf("failed to reserve stock for order %s", order.OrderNumber)
+	}
+	if !reserved {
+		logger.Infof("Stock of order %s is already reserved", order.OrderNumber)
 	}
 
+	// Keep the reservation when the order is saved
+	if order.StockReservedAt == nil {
+		now := time.Now()
+		order.StockReservedAt = &now
+	}
 	return nil
 }
 
-// restoreInventoryForOrder restores inventory for cancelled order
+// restoreInventoryForOrder returns the stock held by a cancelled order. Orders that never reserved
+// stock, or whose stock was already returned, are left alone.
 func (s *orderService) restoreInventoryForOrder(order *model.Order) error {
 	orderItems, err := s.orderRepo.GetOrderItemsByOrder(order.ID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range orderItems {
-		// Create inventory movement for restoration
-		movement := &model.InventoryMovement{
-			ProductID:     item.ProductID,
-			Type:          model.MovementTypeReturn,
-			Quantity:      item.Quantity, // Positive for return
-			Reference:     order.OrderNumber,
-			ReferenceType: "order_cancellation",
-			Status:        model.MovementStatusCompleted,
+	lines, err := s.orderStockLines(orderItems)
+	if err != nil {
+		return err
+	}
+
+	// Picked quantities go back to their original lots in the same transaction
+	if _, err := s.inventoryRepo.ReleaseOrderStock(order.ID, lines, order.OrderNumber); err != nil {
+		return fmt.Errorf("failed to restore stock for order %s: %v", order.OrderNumber, err)
+	}
+
+	return nil
+}
+
+// orderStockLines builds the stock lines of the quantities an order takes from stock
+func (s *orderService) orderStockLines(orderItems []model.OrderItem) ([]repository.StockLine, error) {
+	var lines []repository.StockLine
+	for i := range orderItems {
+		// Backordered quantities are reserved once stock arrives
+		itemLines, err := s.itemStockLines(&orderItems[i], orderItems[i].InStockQuantity())
+		if err != nil {
+			return nil, err
 		}
+		lines = append(lines, itemLines...)
+	}
+	return lines, nil
+}
+
+// itemStockLines builds the stock lines of a quantity of an order item; bundles are taken from
+// stock through their components, lot-tracked products from their earliest expiring lots
+func (s *orderService) itemStockLines(item *model.OrderItem, quantity int) ([]repository.StockLine, error) {
+	if quantity <= 0 {
+		return nil, nil
+	}
+	if item.Product != nil && item.Product.IsBundle() {
+		return s.bundleComponentLines(item, quantity)
+	}
+	return []repository.StockLine{{
+		ProductID:    item.ProductID,
+		VariantID:    item.ProductVariantID,
+		Quantity:     quantity,
+		ManageStock:  item.Product == nil || item.Product.ManageStock,
+		AllocateLots: item.Product != nil && item.Product.TrackLots,
+	}}, nil
+}
+
+// buildOrderItemComponents snapshots the current components of a bundle, scaled to the ordered quantity
+func (s *orderService) buildOrderItemComponents(bundleID uint, quantity int) ([]model.OrderItemComponent, error) {
+	bundleItems, err := s.bundleRepo.GetByBundleID(bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundleItems) == 0 {
+		return nil, errors.New("bundle has no components")
+	}
 
-		if err := s.inventoryRepo.CreateMovement(movement); err != nil {
-			return fmt.Errorf("failed to create inventory movement for product %d: %v", item.ProductID, err)
+	components := make([]model.OrderItemComponent, 0, len(bundleItems))
+	for _, bundleItem := range bundleItems {
+		component := model.OrderItemComponent{
+			ProductID:         bundleItem.ComponentProductID,
+			ProductVariantID:  bundleItem.ComponentVariantID,
+			VariantName:       s.getVariantName(bundleItem.ComponentVariant),
+			QuantityPerBundle: bundleItem.Quantity,
+			Quantity:          bundleItem.Quantity * quantity,
 		}
+		if bundleItem.ComponentProduct != nil {
+			component.ProductName = bundleItem.ComponentProduct.Name
+			component.ProductSKU = bundleItem.ComponentProduct.SKU
+		}
+		if bundleItem.ComponentVariant != nil && bundleItem.ComponentVariant.SKU != "" {
+			component.ProductSKU = bundleItem.ComponentVariant.SKU
+		}
+		components = append(components, component)
 	}
+	return components, nil
+}
 
-	return nil
+// bundleComponentLines builds the stock lines of a bundle's components for the given number of bundles
+func (s *orderService) bundleComponentLines(item *model.OrderItem, bundles int) ([]repository.StockLine, error) {
+	lines := make([]repository.StockLine, 0, len(item.Components))
+	for _, component := range item.Components {
+		product, err := s.productRepo.GetByID(component.ProductID)
+		if err != nil {
+			logger.Errorf("Error getting component product %d of bundle %s: %v", component.ProductID, item.ProductName, err)
+			return nil, fmt.Errorf("failed to retrieve component %s of bundle %s", component.ProductSKU, item.ProductName)
+		}
+		lines = append(lines, repository.StockLine{
+			ProductID:    component.ProductID,
+			VariantID:    component.ProductVariantID,
+			Quantity:     component.QuantityPerBundle * bundles,
+			ManageStock:  true,
+			AllocateLots: product.TrackLots,
+		})
+	}
+	return lines, nil
 }
 
 // Response conversion methods
 
 func (s *orderService) toOrderResponse(order *model.Order) *model.OrderResponse {
 	response := &model.OrderResponse{
-		ID:               order.ID,
-		OrderNumber:      order.OrderNumber,
-		UserID:           order.UserID,
-		Status:           order.Status,
-		PaymentStatus:    order.PaymentStatus,
-		ShippingStatus:   order.ShippingStatus,
-		CustomerName:     order.CustomerName,
-		CustomerEmail:    order.CustomerEmail,
-		CustomerPhone:    order.CustomerPhone,
-		ShippingAddress:  order.ShippingAddress,
-		BillingAddress:   order.BillingAddress,
-		SubTotal:         order.SubTotal,
-		TaxAmount:        order.TaxAmount,
-		ShippingCost:     order.ShippingCost,
-		DiscountAmount:   order.DiscountAmount,
-		TotalAmount:      order.TotalAmount,
-		PaymentMethod:    order.PaymentMethod,
-		PaymentReference: order.PaymentReference,
-		PaidAt:           order.PaidAt,
-		ShippingMethod:   order.ShippingMethod,
-		TrackingNumber:   order.TrackingNumber,
-		ShippedAt:        order.ShippedAt,
-		DeliveredAt:      order.DeliveredAt,
-		Notes:            order.Notes,
-		AdminNotes:       order.AdminNotes,
-		Tags:             order.Tags,
-		CreatedAt:        order.CreatedAt,
-		UpdatedAt:        order.UpdatedAt,
+		ID:                  order.ID,
+		OrderNumber:         order.OrderNumber,
+		UserID:              order.UserID,
+		Status:              order.Status,
+		PaymentStatus:       order.PaymentStatus,
+		ShippingStatus:      order.ShippingStatus,
+		CustomerName:        order.CustomerName,
+		CustomerEmail:       order.CustomerEmail,
+		CustomerPhone:       order.CustomerPhone,
+		ShippingAddress:     order.ShippingAddress,
+		BillingAddress:      order.BillingAddress,
+		SubTotal:            order.SubTotal,
+		TaxAmount:           order.TaxAmount,
+		ShippingCost:        order.ShippingCost,
+		DiscountAmount:      order.DiscountAmount,
+		TotalAmount:         order.TotalAmount,
+		PaymentMethod:       order.PaymentMethod,
+		PaymentReference:    order.PaymentReference,
+		PaidAt:              order.PaidAt,
+		ShippingMethod:      order.ShippingMethod,
+		TrackingNumber:      order.TrackingNumber,
+		ShippedAt:           order.ShippedAt,
+		DeliveredAt:         order.DeliveredAt,
+		PickupLocationID:    order.PickupLocationID,
+		ReadyForPickupAt:    order.ReadyForPickupAt,
+		PickupDeadline:      order.PickupDeadline,
+		DeliverySlotID:      order.DeliverySlotID,
+		DeliveryWindowStart: order.DeliveryWindowStart,
+		DeliveryWindowEnd:   order.DeliveryWindowEnd,
+		Notes:               order.Notes,
+		AdminNotes:          order.AdminNotes,
+		Tags:                order.Tags,
+		CreatedAt:           order.CreatedAt,
+		UpdatedAt:           order.UpdatedAt,
 	}
 
 	if order.User != nil {
 		response.UserName = order.User.Username
 	}
+	if order.PickupLocation != nil {
+		response.PickupLocation = order.PickupLocation.Name
+	}
 
 	// Convert order items
 	if len(order.OrderItems) > 0 {
@@ -761,7 +1285,7 @@ func (s *orderService) toOrderResponse(order *model.Order) *model.OrderResponse
 }
 
 func (s *orderService) toOrderItemResponse(item *model.OrderItem) *model.OrderItemResponse {
-	return &model.OrderItemResponse{
+	response := &model.OrderItemResponse{
 		ID:               item.ID,
 		OrderID:          item.OrderID,
 		ProductID:        item.ProductID,
@@ -778,7 +1302,28 @@ func (s *orderService) toOrderItemResponse(item *model.OrderItem) *model.OrderIt
 		Notes:            item.Notes,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
+
+		IsBackordered:        item.IsBackordered(),
+		BackorderedQuantity:  item.BackorderedQuantity,
+		BackorderType:        item.BackorderType,
+		ExpectedAvailableAt:  item.ExpectedAvailableAt,
+		BackorderFulfilledAt: item.BackorderFulfilledAt,
+		ShippedQuantity:      item.ShippedQuantity,
+	}
+
+	for _, component := range item.Components {
+		response.Components = append(response.Components, model.OrderItemComponentResponse{
+			ProductID:         component.ProductID,
+			ProductName:       component.ProductName,
+			ProductSKU:        component.ProductSKU,
+			ProductVariantID:  component.ProductVariantID,
+			VariantName:       component.VariantName,
+			QuantityPerBundle: component.QuantityPerBundle,
+			Quantity:          component.Quantity,
+		})
 	}
+
+	return response
 }
 
 func (s *orderService) toPaymentResponse(payment *model.Payment) *model.PaymentResponse {
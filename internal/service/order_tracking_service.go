@@ -1,11 +1,19 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go_app/internal/model"
 	"go_app/internal/repository"
+	"go_app/pkg/database"
 	"go_app/pkg/logger"
+	"go_app/pkg/shipping"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,16 +21,47 @@ type OrderTrackingService struct {
 	orderTrackingRepo *repository.OrderTrackingRepository
 	orderRepo         repository.OrderRepository
 	userRepo          repository.UserRepository
+	shippingRepo      repository.ShippingRepository
+	carriers          *shipping.Registry
+	fulfillment       FulfillmentService
 }
 
 func NewOrderTrackingService() *OrderTrackingService {
+	return NewOrderTrackingServiceWithCarriers(newCarrierRegistry(GHTKConfigFromApp()))
+}
+
+// NewOrderTrackingServiceWithCarriers creates an OrderTrackingService that polls carriers from the given registry
+func NewOrderTrackingServiceWithCarriers(carriers *shipping.Registry) *OrderTrackingService {
 	return &OrderTrackingService{
 		orderTrackingRepo: repository.NewOrderTrackingRepository(),
 		orderRepo:         repository.NewOrderRepository(),
 		userRepo:          repository.NewUserRepository(),
+		shippingRepo:      repository.NewShippingRepository(database.GetDB()),
+		carriers:          carriers,
+		fulfillment:       NewFulfillmentService(),
 	}
 }
 
+// carrierSyncLimit bounds how hard a sync run may hit a single carrier API
+type carrierSyncLimit struct {
+	Concurrency       int
+	RequestsPerSecond int
+}
+
+var (
+	carrierSyncLimits = map[string]carrierSyncLimit{
+		shipping.CarrierCodeGHTK: {Concurrency: 4, RequestsPerSecond: 5},
+		shipping.CarrierCodeFake: {Concurrency: 8, RequestsPerSecond: 100},
+	}
+	defaultCarrierSyncLimit = carrierSyncLimit{Concurrency: 2, RequestsPerSecond: 2}
+)
+
+// Failing tracking numbers are retried after 5m, 10m, 20m, ... up to once a day
+const (
+	trackingSyncBackoffBase = 5 * time.Minute
+	trackingSyncBackoffMax  = 24 * time.Hour
+)
+
 // ===== ORDER TRACKING SERVICE =====
 
 // CreateOrderTracking creates a new order tracking
@@ -89,6 +128,7 @@ func (s *OrderTrackingService) CreateOrderTracking(req *model.OrderTrackingCreat
 	if err := s.orderTrackingRepo.CreateOrderTrackingEvent(event); err != nil {
 		logger.Warnf("Failed to create initial tracking event: %v", err)
 	}
+	s.recordShipmentEvent(tracking, event, &userID)
 
 	return s.convertOrderTrackingToResponse(tracking), nil
 }
@@ -113,6 +153,11 @@ func (s *OrderTrackingService) GetOrderTrackingByOrderID(orderID uint) (*model.O
 	return s.convertOrderTrackingToResponse(tracking), nil
 }
 
+// GetOrderFulfillment gets every shipment of an order with the merged tracking timeline
+func (s *OrderTrackingService) GetOrderFulfillment(orderID uint) (*model.OrderFulfillmentResponse, error) {
+	return s.fulfillment.GetOrderFulfillment(orderID)
+}
+
 // GetOrderTrackingByTrackingNumber gets order tracking by tracking number
 func (s *OrderTrackingService) GetOrderTrackingByTrackingNumber(trackingNumber string) (*model.OrderTrackingResponse, error) {
 	tracking, err := s.orderTrackingRepo.GetOrderTrackingByTrackingNumber(trackingNumber)
@@ -146,6 +191,7 @@ func (s *OrderTrackingService) UpdateOrderTracking(id uint, req *model.OrderTrac
 	}
 
 	// Update fields
+	statusChanged := req.Status != "" && req.Status != tracking.Status
 	if req.Status != "" {
 		tracking.Status = req.Status
 	}
@@ -183,6 +229,18 @@ func (s *OrderTrackingService) UpdateOrderTracking(id uint, req *model.OrderTrac
 		return nil, err
 	}
 
+	if statusChanged {
+		s.recordShipmentEvent(tracking, &model.OrderTrackingEvent{
+			Status:      tracking.Status,
+			StatusText:  tracking.StatusText,
+			Location:    tracking.Location,
+			Description: tracking.Description,
+			EventCode:   tracking.Status,
+			Source:      model.SourceManual,
+			EventTime:   tracking.LastUpdatedAt,
+		}, &userID)
+	}
+
 	return s.convertOrderTrackingToResponse(tracking), nil
 }
 
@@ -228,6 +286,7 @@ func (s *OrderTrackingService) AddTrackingEvent(trackingID uint, req *model.Orde
 	if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
 		return err
 	}
+	s.recordShipmentEvent(tracking, event, nil)
 
 	// Create notification if needed
 	if tracking.NotifyUser {
@@ -256,7 +315,39 @@ func (s *OrderTrackingService) GetTrackingEvents(trackingID uint, page, limit in
 
 // ===== WEBHOOK SERVICE =====
 
-// ProcessWebhook processes incoming webhook
+// VerifyWebhook checks the signature of a webhook call: an HMAC-SHA256 of the body with the webhook's
+// secret, or the carrier integration's own check when no secret is configured. Unsigned calls fail.
+func (s *OrderTrackingService) VerifyWebhook(carrier, carrierCode, signature string, body []byte) error {
+	webhook, err := s.orderTrackingRepo.GetOrderTrackingWebhookByCarrier(carrier, carrierCode)
+	if err != nil {
+		return fmt.Errorf("webhook configuration not found")
+	}
+	if signature == "" {
+		return errors.New("missing webhook signature")
+	}
+
+	if webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(strings.ToLower(strings.TrimSpace(signature))), []byte(expected)) {
+			return errors.New("invalid webhook signature")
+		}
+		return nil
+	}
+
+	integration, err := s.resolveTrackingCarrier(strings.ToLower(carrierCode))
+	if err != nil {
+		return errors.New("webhook has no secret configured")
+	}
+	if !integration.VerifyWebhook(signature, body) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
+
+// ProcessWebhook applies a verified webhook call. A carrier can only update its own trackings:
+// a tracking of another carrier is treated as unknown.
 func (s *OrderTrackingService) ProcessWebhook(carrier, carrierCode string, req *model.OrderTrackingWebhookRequest) error {
 	// Get webhook configuration
 	_, err := s.orderTrackingRepo.GetOrderTrackingWebhookByCarrier(carrier, carrierCode)
@@ -266,7 +357,7 @@ func (s *OrderTrackingService) ProcessWebhook(carrier, carrierCode string, req *
 
 	// Get tracking by tracking number
 	tracking, err := s.orderTrackingRepo.GetOrderTrackingByTrackingNumber(req.TrackingNumber)
-	if err != nil {
+	if err != nil || tracking == nil || !strings.EqualFold(tracking.CarrierCode, carrierCode) {
 		return fmt.Errorf("tracking not found")
 	}
 
@@ -306,6 +397,7 @@ func (s *OrderTrackingService) ProcessWebhook(carrier, carrierCode string, req *
 	if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
 		return err
 	}
+	s.recordShipmentEvent(tracking, event, nil)
 
 	// Create notification if needed
 	if tracking.NotifyUser {
@@ -319,21 +411,34 @@ func (s *OrderTrackingService) ProcessWebhook(carrier, carrierCode string, req *
 
 // ===== SYNC SERVICE =====
 
-// SyncOrderTrackings syncs order trackings with external providers
-func (s *OrderTrackingService) SyncOrderTrackings(limit int) error {
+// SyncOrderTrackings polls carriers for active trackings and records new events
+func (s *OrderTrackingService) SyncOrderTrackings(limit int) (*model.OrderTrackingSyncResult, error) {
 	trackings, err := s.orderTrackingRepo.GetOrderTrackingsForSync(limit)
 	if err != nil {
-		return err
+		logger.Errorf("Failed to get trackings for sync: %v", err)
+		return nil, fmt.Errorf("failed to get trackings for sync")
 	}
 
-	for _, tracking := range trackings {
-		if err := s.syncTrackingWithProvider(&tracking); err != nil {
-			logger.Errorf("Failed to sync tracking %d: %v", tracking.ID, err)
-			continue
-		}
+	// Each carrier is synced independently so a slow carrier does not hold up the others
+	groups := make(map[string][]*model.OrderTracking)
+	for i := range trackings {
+		code := strings.ToLower(trackings[i].CarrierCode)
+		groups[code] = append(groups[code], &trackings[i])
 	}
 
-	return nil
+	result := &model.OrderTrackingSyncResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for code, group := range groups {
+		wg.Add(1)
+		go func(code string, group []*model.OrderTracking) {
+			defer wg.Done()
+			s.syncCarrierTrackings(code, group, result, &mu)
+		}(code, group)
+	}
+	wg.Wait()
+
+	return result, nil
 }
 
 // ===== STATISTICS SERVICE =====
@@ -362,6 +467,9 @@ func (s *OrderTrackingService) convertOrderTrackingToResponse(tracking *model.Or
 		TrackingURL:       tracking.TrackingURL,
 		LastUpdatedAt:     tracking.LastUpdatedAt,
 		LastSyncAt:        tracking.LastSyncAt,
+		SyncFailures:      tracking.SyncFailures,
+		NextSyncAt:        tracking.NextSyncAt,
+		LastSyncError:     tracking.LastSyncError,
 		AutoSync:          tracking.AutoSync,
 		NotifyUser:        tracking.NotifyUser,
 		IsActive:          tracking.IsActive,
@@ -472,10 +580,265 @@ func (s *OrderTrackingService) createTrackingNotification(tracking *model.OrderT
 	return s.orderTrackingRepo.CreateOrderTrackingNotification(notification)
 }
 
-// syncTrackingWithProvider syncs tracking with external provider
-func (s *OrderTrackingService) syncTrackingWithProvider(tracking *model.OrderTracking) error {
-	// This would integrate with external shipping providers
-	// For now, just update the last sync time
-	tracking.LastSyncAt = time.Now()
-	return s.orderTrackingRepo.UpdateOrderTracking(tracking)
+// syncCarrierTrackings syncs the trackings of one carrier within its concurrency and rate limits
+func (s *OrderTrackingService) syncCarrierTrackings(code string, trackings []*model.OrderTracking, result *model.OrderTrackingSyncResult, mu *sync.Mutex) {
+	if !s.carriers.Supports(code) {
+		// Carriers without an integration are only marked as synced
+		for _, tracking := range trackings {
+			tracking.LastSyncAt = time.Now()
+			if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
+				logger.Errorf("Failed to update tracking %d: %v", tracking.ID, err)
+			}
+		}
+		mu.Lock()
+		result.Checked += len(trackings)
+		result.Skipped += len(trackings)
+		mu.Unlock()
+		return
+	}
+
+	carrier, err := s.resolveTrackingCarrier(code)
+	if err != nil {
+		// A misconfigured carrier is a sync failure, retried with backoff once it is fixed
+		for _, tracking := range trackings {
+			s.recordSyncFailure(tracking, err)
+		}
+		mu.Lock()
+		result.Checked += len(trackings)
+		result.Failed += len(trackings)
+		mu.Unlock()
+		return
+	}
+
+	limit, ok := carrierSyncLimits[code]
+	if !ok {
+		limit = defaultCarrierSyncLimit
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(limit.RequestsPerSecond))
+	defer ticker.Stop()
+
+	jobs := make(chan *model.OrderTracking)
+	var wg sync.WaitGroup
+	for i := 0; i < limit.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tracking := range jobs {
+				updated, eventsCreated, err := s.syncTrackingWithCarrier(carrier, tracking)
+
+				mu.Lock()
+				result.Checked++
+				result.EventsCreated += eventsCreated
+				if err != nil {
+					result.Failed++
+				} else if updated {
+					result.Updated++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, tracking := range trackings {
+		<-ticker.C
+		jobs <- tracking
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// resolveTrackingCarrier resolves the carrier integration for a tracking's carrier code
+func (s *OrderTrackingService) resolveTrackingCarrier(code string) (shipping.Carrier, error) {
+	if !s.carriers.Supports(code) {
+		return nil, fmt.Errorf("carrier %s is not supported", code)
+	}
+
+	config := ""
+	if provider, err := s.shippingRepo.GetShippingProviderByCode(code); err == nil {
+		config = provider.Config
+	}
+
+	carrier, err := s.carriers.Resolve(code, config)
+	if err != nil {
+		logger.Warnf("Tracking sync skipped for carrier %s: %v", code, err)
+		return nil, err
+	}
+	return carrier, nil
+}
+
+// syncTrackingWithCarrier polls a carrier for one tracking and applies new events and status
+func (s *OrderTrackingService) syncTrackingWithCarrier(carrier shipping.Carrier, tracking *model.OrderTracking) (bool, int, error) {
+	info, err := carrier.Track(tracking.TrackingNumber)
+	if err != nil {
+		logger.Errorf("Failed to sync tracking %d (%s): %v", tracking.ID, tracking.TrackingNumber, err)
+		s.recordSyncFailure(tracking, err)
+		return false, 0, err
+	}
+
+	now := time.Now()
+	eventsCreated := 0
+	var latest *model.OrderTrackingEvent
+	for _, carrierEvent := range info.Events {
+		exists, err := s.orderTrackingRepo.HasOrderTrackingEvent(tracking.ID, carrierEvent.Status, carrierEvent.Time)
+		if err != nil {
+			logger.Errorf("Failed to check events of tracking %d: %v", tracking.ID, err)
+			s.recordSyncFailure(tracking, err)
+			return false, eventsCreated, err
+		}
+		if exists {
+			continue
+		}
+
+		status := trackingStatusFromCarrier(carrierEvent.Status)
+		eventTime := carrierEvent.Time
+		if eventTime.IsZero() {
+			eventTime = now
+		}
+		statusText := carrierEvent.StatusText
+		if statusText == "" {
+			statusText = status
+		}
+
+		event := &model.OrderTrackingEvent{
+			OrderTrackingID: tracking.ID,
+			Status:          status,
+			StatusText:      statusText,
+			Location:        carrierEvent.Location,
+			Description:     carrierEvent.Note,
+			EventType:       s.mapStatusToEventType(status),
+			EventCode:       carrierEvent.Status,
+			IsImportant:     s.isImportantEvent(status),
+			Source:          model.SourceSync,
+			SourceData:      s.serializeCarrierEvent(&carrierEvent),
+			EventTime:       eventTime,
+		}
+		if err := s.orderTrackingRepo.CreateOrderTrackingEvent(event); err != nil {
+			logger.Errorf("Failed to create event for tracking %d: %v", tracking.ID, err)
+			s.recordSyncFailure(tracking, err)
+			return false, eventsCreated, err
+		}
+		eventsCreated++
+		latest = event
+		s.recordShipmentEvent(tracking, event, nil)
+
+		if tracking.NotifyUser && event.IsImportant {
+			if err := s.createTrackingNotification(tracking, event); err != nil {
+				logger.Warnf("Failed to create tracking notification: %v", err)
+			}
+		}
+	}
+
+	status := trackingStatusFromCarrier(info.Status)
+	changed := status != tracking.Status
+
+	tracking.LastSyncAt = now
+	tracking.SyncFailures = 0
+	tracking.NextSyncAt = nil
+	tracking.LastSyncError = ""
+	if changed || latest != nil {
+		tracking.Status = status
+		tracking.StatusText = info.StatusText
+		if tracking.StatusText == "" {
+			tracking.StatusText = status
+		}
+		if latest != nil {
+			tracking.Location = latest.Location
+			tracking.Description = latest.Description
+		}
+		tracking.LastUpdatedAt = now
+	}
+	if status == model.TrackingStatusDelivered && tracking.ActualDelivery == nil {
+		tracking.ActualDelivery = &now
+	}
+	// Finished shipments no longer need polling
+	switch status {
+	case model.TrackingStatusDelivered, model.TrackingStatusReturned, model.TrackingStatusCancelled:
+		tracking.AutoSync = false
+	}
+
+	if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
+		logger.Errorf("Failed to update tracking %d: %v", tracking.ID, err)
+		return false, eventsCreated, err
+	}
+
+	return changed, eventsCreated, nil
+}
+
+// recordSyncFailure schedules the next sync of a failing tracking with exponential backoff
+func (s *OrderTrackingService) recordSyncFailure(tracking *model.OrderTracking, syncErr error) {
+	tracking.SyncFailures++
+
+	backoff := trackingSyncBackoffMax
+	if tracking.SyncFailures <= 10 {
+		backoff = trackingSyncBackoffBase << (tracking.SyncFailures - 1)
+		if backoff > trackingSyncBackoffMax {
+			backoff = trackingSyncBackoffMax
+		}
+	}
+
+	now := time.Now()
+	nextSyncAt := now.Add(backoff)
+	tracking.LastSyncAt = now
+	tracking.NextSyncAt = &nextSyncAt
+	tracking.LastSyncError = syncErr.Error()
+	if len(tracking.LastSyncError) > 500 {
+		tracking.LastSyncError = tracking.LastSyncError[:500]
+	}
+
+	if err := s.orderTrackingRepo.UpdateOrderTracking(tracking); err != nil {
+		logger.Errorf("Failed to record sync failure for tracking %d: %v", tracking.ID, err)
+	}
+}
+
+// recordShipmentEvent adds a tracking event to the timeline of the tracking's shipment
+func (s *OrderTrackingService) recordShipmentEvent(tracking *model.OrderTracking, event *model.OrderTrackingEvent, userID *uint) {
+	shipment, err := s.fulfillment.ShipmentForTracking(tracking)
+	if err != nil {
+		logger.Warnf("Failed to get shipment for tracking %d: %v", tracking.ID, err)
+		return
+	}
+
+	if _, err := s.fulfillment.RecordEvent(shipment, &model.ShipmentEvent{
+		Status:     event.Status,
+		StatusText: event.StatusText,
+		Location:   event.Location,
+		Note:       event.Description,
+		EventCode:  event.EventCode,
+		Source:     event.Source,
+		SourceData: event.SourceData,
+		CreatedBy:  userID,
+		EventTime:  event.EventTime,
+	}); err != nil {
+		logger.Warnf("Failed to record shipment event for tracking %d: %v", tracking.ID, err)
+	}
+}
+
+// serializeCarrierEvent serializes a carrier tracking event
+func (s *OrderTrackingService) serializeCarrierEvent(event *shipping.TrackingEvent) string {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// trackingStatusFromCarrier maps a normalized carrier status to a tracking status
+func trackingStatusFromCarrier(status string) string {
+	switch status {
+	case shipping.StatusPickedUp:
+		return model.TrackingStatusPickedUp
+	case shipping.StatusInTransit:
+		return model.TrackingStatusInTransit
+	case shipping.StatusDelivered:
+		return model.TrackingStatusDelivered
+	case shipping.StatusFailed:
+		return model.TrackingStatusFailed
+	case shipping.StatusReturned:
+		return model.TrackingStatusReturned
+	case shipping.StatusCancelled:
+		return model.TrackingStatusCancelled
+	default:
+		return model.TrackingStatusPending
+	}
 }
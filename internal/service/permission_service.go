@@ -1,13 +1,18 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go_app/internal/model"
 	"go_app/internal/repository"
 	"go_app/pkg/logger"
+	"time"
 )
 
+// expiryBatchSize limits how many grants the worker expires per run
+const expiryBatchSize = 200
+
 // PermissionService defines methods for permission business logic
 type PermissionService interface {
 	// Permissions
@@ -32,6 +37,7 @@ type PermissionService interface {
 	RevokePermissionFromRole(roleID, permissionID, userID uint) error
 	GetRolePermissions(roleID uint) ([]model.PermissionResponse, error)
 	UpdateRolePermissions(roleID uint, req *model.RolePermissionRequest, userID uint) error
+	UpdateRolePermissionConditions(roleID, permissionID uint, req *model.RolePermissionConditionsRequest, userID uint) error
 
 	// User Permissions
 	AssignPermissionToUser(userID, permissionID, grantedBy uint, req *model.UserPermissionRequest) error
@@ -39,8 +45,17 @@ type PermissionService interface {
 	GetUserPermissions(userID uint) ([]model.UserPermissionResponse, error)
 	GetUserEffectivePermissions(userID uint) ([]model.PermissionResponse, error)
 
+	// Time-bound Role Grants
+	GrantRoleToUser(userID uint, req *model.UserRoleGrantRequest, grantedBy uint) (*model.UserRoleGrantResponse, error)
+	GetUserRoleGrants(userID uint) ([]model.UserRoleGrantResponse, error)
+	RevokeUserRoleGrant(userID, grantID, revokedBy uint) error
+	ProcessRoleGrantSchedule() (activated int, expired int, err error)
+	ExpireUserPermissions() (int, error)
+
 	// Permission Checking
 	CheckPermission(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint) (*model.PermissionCheckResponse, error)
+	CheckPermissionWithAttributes(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}) (*model.PermissionCheckResponse, error)
+	CheckPermissionDeferred(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}) (*model.PermissionCheckResponse, error)
 	GetUserPermissionsForResource(userID uint, resource model.ResourceType) ([]model.PermissionResponse, error)
 
 	// Audit & Logging
@@ -538,10 +553,63 @@ func (s *permissionService) UpdateRolePermissions(roleID uint, req *model.RolePe
 	return nil
 }
 
+// UpdateRolePermissionConditions limits a permission of a role by attribute conditions
+func (s *permissionService) UpdateRolePermissionConditions(roleID, permissionID uint, req *model.RolePermissionConditionsRequest, userID uint) error {
+	conditions, err := model.EncodePermissionConditions(req.Conditions)
+	if err != nil {
+		return fmt.Errorf("invalid conditions: %v", err)
+	}
+
+	role, err := s.permissionRepo.GetRoleByID(roleID)
+	if err != nil {
+		logger.Errorf("Error getting role by ID %d: %v", roleID, err)
+		return fmt.Errorf("failed to retrieve role")
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
+	permission, err := s.permissionRepo.GetPermissionByID(permissionID)
+	if err != nil {
+		logger.Errorf("Error getting permission by ID %d: %v", permissionID, err)
+		return fmt.Errorf("failed to retrieve permission")
+	}
+	if permission == nil {
+		return errors.New("permission not found")
+	}
+
+	updated, err := s.permissionRepo.UpdateRolePermissionConditions(roleID, permissionID, conditions)
+	if err != nil {
+		logger.Errorf("Error updating conditions of role permission: %v", err)
+		return fmt.Errorf("failed to update role permission conditions")
+	}
+	if !updated {
+		return errors.New("role permission not found")
+	}
+
+	// Log the action
+	if err := s.permissionRepo.LogPermissionAction(
+		&userID, nil, "update", "role_permission", roleID,
+		permissionLogDetails(fmt.Sprintf("Set conditions of permission %s on role %s", permission.Name, role.Name), map[string]interface{}{
+			"permission": permission.Name,
+			"conditions": req.Conditions,
+		}), "", "",
+	); err != nil {
+		logger.Warnf("Failed to log permission action: %v", err)
+	}
+
+	return nil
+}
+
 // User Permissions
 
 // AssignPermissionToUser assigns a permission to a user
 func (s *permissionService) AssignPermissionToUser(userID, permissionID, grantedBy uint, req *model.UserPermissionRequest) error {
+	conditions, err := model.EncodePermissionConditions(req.Conditions)
+	if err != nil {
+		return fmt.Errorf("invalid conditions: %v", err)
+	}
+
 	// Check if user exists
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
@@ -562,7 +630,7 @@ func (s *permissionService) AssignPermissionToUser(userID, permissionID, granted
 		return errors.New("permission not found")
 	}
 
-	if err := s.permissionRepo.AssignPermissionToUser(userID, permissionID, grantedBy, req.Reason, req.ExpiresAt); err != nil {
+	if err := s.permissionRepo.AssignPermissionToUser(userID, permissionID, grantedBy, req.Reason, req.ExpiresAt, conditions); err != nil {
 		logger.Errorf("Error assigning permission to user: %v", err)
 		return fmt.Errorf("failed to assign permission to user")
 	}
@@ -570,7 +638,11 @@ func (s *permissionService) AssignPermissionToUser(userID, permissionID, granted
 	// Log the action
 	if err := s.permissionRepo.LogPermissionAction(
 		&grantedBy, &userID, "grant", "user_permission", userID,
-		fmt.Sprintf("Assigned permission %s to user %s", permission.Name, user.Username), "", "",
+		permissionLogDetails(fmt.Sprintf("Assigned permission %s to user %s", permission.Name, user.Username), map[string]interface{}{
+			"permission": permission.Name,
+			"expires_at": req.ExpiresAt,
+			"conditions": req.Conditions,
+		}), "", "",
 	); err != nil {
 		logger.Warnf("Failed to log permission action: %v", err)
 	}
@@ -646,28 +718,326 @@ func (s *permissionService) GetUserEffectivePermissions(userID uint) ([]model.Pe
 	return responses, nil
 }
 
+// Time-bound Role Grants
+
+// GrantRoleToUser gives a user an additional role between StartsAt and EndsAt
+func (s *permissionService) GrantRoleToUser(userID uint, req *model.UserRoleGrantRequest, grantedBy uint) (*model.UserRoleGrantResponse, error) {
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		if !req.EndsAt.After(startsAt) {
+			return nil, errors.New("ends_at must be after starts_at")
+		}
+		if !req.EndsAt.After(now) {
+			return nil, errors.New("ends_at must be in the future")
+		}
+	}
+
+	// Check if user exists
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		logger.Errorf("Error getting user by ID %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to retrieve user")
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Check if role exists
+	role, err := s.permissionRepo.GetRoleByID(req.RoleID)
+	if err != nil {
+		logger.Errorf("Error getting role by ID %d: %v", req.RoleID, err)
+		return nil, fmt.Errorf("failed to retrieve role")
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	if !role.IsActive {
+		return nil, errors.New("role is not active")
+	}
+	if user.RoleID == role.ID {
+		return nil, errors.New("user already has this role")
+	}
+
+	grant := &model.UserRoleGrant{
+		UserID:    userID,
+		RoleID:    role.ID,
+		GrantedBy: grantedBy,
+		Reason:    req.Reason,
+		StartsAt:  startsAt,
+		EndsAt:    req.EndsAt,
+	}
+	if err := s.permissionRepo.CreateUserRoleGrant(grant); err != nil {
+		logger.Errorf("Error granting role %d to user %d: %v", role.ID, userID, err)
+		return nil, fmt.Errorf("failed to grant role")
+	}
+	grant.User = user
+	grant.Role = role
+
+	// Log the action
+	if err := s.permissionRepo.LogPermissionAction(
+		&grantedBy, &userID, "grant", "user_role_grant", grant.ID,
+		permissionLogDetails(fmt.Sprintf("Granted role %s to user %s", role.Name, user.Username), map[string]interface{}{
+			"role":      role.Name,
+			"starts_at": grant.StartsAt,
+			"ends_at":   grant.EndsAt,
+			"reason":    grant.Reason,
+		}), "", "",
+	); err != nil {
+		logger.Warnf("Failed to log permission action: %v", err)
+	}
+
+	response := grant.ToResponse()
+	return &response, nil
+}
+
+// GetUserRoleGrants retrieves the role grants of a user, including scheduled and ended ones
+func (s *permissionService) GetUserRoleGrants(userID uint) ([]model.UserRoleGrantResponse, error) {
+	grants, err := s.permissionRepo.GetUserRoleGrants(userID)
+	if err != nil {
+		logger.Errorf("Error getting role grants of user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to retrieve role grants")
+	}
+
+	responses := make([]model.UserRoleGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		responses = append(responses, grant.ToResponse())
+	}
+	return responses, nil
+}
+
+// RevokeUserRoleGrant ends a role grant before its end time
+func (s *permissionService) RevokeUserRoleGrant(userID, grantID, revokedBy uint) error {
+	grant, err := s.permissionRepo.GetUserRoleGrantByID(grantID)
+	if err != nil {
+		logger.Errorf("Error getting role grant %d: %v", grantID, err)
+		return fmt.Errorf("failed to retrieve role grant")
+	}
+	if grant == nil || grant.UserID != userID {
+		return errors.New("role grant not found")
+	}
+
+	revoked, err := s.permissionRepo.RevokeUserRoleGrant(grantID, revokedBy)
+	if err != nil {
+		logger.Errorf("Error revoking role grant %d: %v", grantID, err)
+		return fmt.Errorf("failed to revoke role grant")
+	}
+	if !revoked {
+		return errors.New("role grant has already ended")
+	}
+
+	// Log the action
+	if err := s.permissionRepo.LogPermissionAction(
+		&revokedBy, &userID, "revoke", "user_role_grant", grant.ID,
+		permissionLogDetails(fmt.Sprintf("Revoked role %s from user %s", roleNameOf(grant), userNameOf(grant)), map[string]interface{}{
+			"role": roleNameOf(grant),
+		}), "", "",
+	); err != nil {
+		logger.Warnf("Failed to log permission action: %v", err)
+	}
+
+	return nil
+}
+
+// ProcessRoleGrantSchedule records role grants that took effect or ran out, logging each to the
+// permission log. Permission checks already honour the start and end times on their own.
+func (s *permissionService) ProcessRoleGrantSchedule() (int, int, error) {
+	toActivate, err := s.permissionRepo.GetUserRoleGrantsToActivate(expiryBatchSize)
+	if err != nil {
+		logger.Errorf("Error getting role grants to activate: %v", err)
+		return 0, 0, fmt.Errorf("failed to retrieve role grants to activate")
+	}
+
+	activated := 0
+	for i := range toActivate {
+		grant := &toActivate[i]
+		marked, err := s.permissionRepo.MarkUserRoleGrantActivated(grant.ID)
+		if err != nil {
+			logger.Errorf("Error activating role grant %d: %v", grant.ID, err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		activated++
+		s.logRoleGrantChange(grant, "activate", fmt.Sprintf("Role %s took effect for user %d", roleNameOf(grant), grant.UserID))
+	}
+
+	toExpire, err := s.permissionRepo.GetUserRoleGrantsToExpire(expiryBatchSize)
+	if err != nil {
+		logger.Errorf("Error getting role grants to expire: %v", err)
+		return activated, 0, fmt.Errorf("failed to retrieve role grants to expire")
+	}
+
+	expired := 0
+	for i := range toExpire {
+		grant := &toExpire[i]
+		marked, err := s.permissionRepo.MarkUserRoleGrantExpired(grant.ID)
+		if err != nil {
+			logger.Errorf("Error expiring role grant %d: %v", grant.ID, err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		expired++
+		s.logRoleGrantChange(grant, "expire", fmt.Sprintf("Role %s expired for user %d", roleNameOf(grant), grant.UserID))
+	}
+
+	return activated, expired, nil
+}
+
+// ExpireUserPermissions removes direct user permissions past their expiry, logging each removal
+func (s *permissionService) ExpireUserPermissions() (int, error) {
+	userPermissions, err := s.permissionRepo.GetExpiredUserPermissions(expiryBatchSize)
+	if err != nil {
+		logger.Errorf("Error getting expired user permissions: %v", err)
+		return 0, fmt.Errorf("failed to retrieve expired user permissions")
+	}
+
+	expired := 0
+	for _, up := range userPermissions {
+		deleted, err := s.permissionRepo.DeleteExpiredUserPermission(up.ID)
+		if err != nil {
+			logger.Errorf("Error expiring user permission %d: %v", up.ID, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		expired++
+
+		permissionName := fmt.Sprintf("%d", up.PermissionID)
+		if up.Permission != nil {
+			permissionName = up.Permission.Name
+		}
+		userID := up.UserID
+		if err := s.permissionRepo.LogPermissionAction(
+			&up.GrantedBy, &userID, "expire", "user_permission", up.UserID,
+			permissionLogDetails(fmt.Sprintf("Permission %s expired for user %d", permissionName, up.UserID), map[string]interface{}{
+				"permission": permissionName,
+				"expires_at": up.ExpiresAt,
+			}), "", "",
+		); err != nil {
+			logger.Warnf("Failed to log permission action: %v", err)
+		}
+	}
+
+	return expired, nil
+}
+
+// logRoleGrantChange writes a scheduled change of a role grant to the permission log, attributed
+// to the user who made the grant
+func (s *permissionService) logRoleGrantChange(grant *model.UserRoleGrant, action, message string) {
+	userID := grant.UserID
+	if err := s.permissionRepo.LogPermissionAction(
+		&grant.GrantedBy, &userID, action, "user_role_grant", grant.ID,
+		permissionLogDetails(message, map[string]interface{}{
+			"role":      roleNameOf(grant),
+			"starts_at": grant.StartsAt,
+			"ends_at":   grant.EndsAt,
+		}), "", "",
+	); err != nil {
+		logger.Warnf("Failed to log permission action: %v", err)
+	}
+}
+
+func roleNameOf(grant *model.UserRoleGrant) string {
+	if grant.Role != nil {
+		return grant.Role.Name
+	}
+	return fmt.Sprintf("%d", grant.RoleID)
+}
+
+func userNameOf(grant *model.UserRoleGrant) string {
+	if grant.User != nil {
+		return grant.User.Username
+	}
+	return fmt.Sprintf("%d", grant.UserID)
+}
+
 // Permission Checking
 
-// CheckPermission checks if a user has permission for a specific resource and action
+// CheckPermission checks if a user has permission for a specific resource and action. Grants
+// limited by conditions only count if the conditions can be decided without resource attributes.
 func (s *permissionService) CheckPermission(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint) (*model.PermissionCheckResponse, error) {
-	hasPermission, source, err := s.permissionRepo.CheckPermission(userID, resource, action, resourceID)
+	return s.checkPermission(userID, resource, action, resourceID, nil, false)
+}
+
+// CheckPermissionWithAttributes checks a permission against request and resource attributes. A
+// conditional grant applies only if all of its conditions hold; a missing attribute fails it.
+func (s *permissionService) CheckPermissionWithAttributes(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}) (*model.PermissionCheckResponse, error) {
+	return s.checkPermission(userID, resource, action, resourceID, attributes, false)
+}
+
+// CheckPermissionDeferred is like CheckPermissionWithAttributes, but a conditional grant that needs
+// attributes not known yet, typically of the resource, is reported as Conditional instead of failing.
+// The caller must then check again with the resource attributes.
+func (s *permissionService) CheckPermissionDeferred(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}) (*model.PermissionCheckResponse, error) {
+	return s.checkPermission(userID, resource, action, resourceID, attributes, true)
+}
+
+func (s *permissionService) checkPermission(userID uint, resource model.ResourceType, action model.PermissionType, resourceID *uint, attributes map[string]interface{}, deferMissing bool) (*model.PermissionCheckResponse, error) {
+	grants, err := s.permissionRepo.GetActivePermissionGrants(userID, model.GetPermissionName(resource, action))
 	if err != nil {
 		logger.Errorf("Error checking permission: %v", err)
 		return nil, fmt.Errorf("failed to check permission")
 	}
 
-	response := &model.PermissionCheckResponse{
-		HasPermission: hasPermission,
-		Source:        source,
+	attrs := permissionAttributes(userID, resourceID, attributes)
+	conditionalSource := ""
+	for _, grant := range grants {
+		conditions, err := model.ParsePermissionConditions(grant.Conditions)
+		if err != nil {
+			// A grant with unreadable conditions never applies
+			logger.Warnf("Invalid conditions on %s %d: %v", grant.Source, grant.SourceID, err)
+			continue
+		}
+
+		switch model.EvaluatePermissionConditions(conditions, attrs) {
+		case model.ConditionMet:
+			return &model.PermissionCheckResponse{HasPermission: true, Source: grant.Source}, nil
+		case model.ConditionUndecided:
+			if deferMissing && conditionalSource == "" {
+				conditionalSource = grant.Source
+			}
+		}
 	}
 
-	if !hasPermission {
-		response.Reason = "User does not have the required permission"
+	if conditionalSource != "" {
+		return &model.PermissionCheckResponse{HasPermission: true, Source: conditionalSource, Conditional: true}, nil
 	}
 
+	response := &model.PermissionCheckResponse{
+		Reason: "User does not have the required permission",
+	}
+	if len(grants) > 0 {
+		response.Reason = "The conditions of the user's permission are not met"
+	}
 	return response, nil
 }
 
+// permissionAttributes adds the attributes known for every check, such as the time of day, to the
+// caller's request and resource attributes
+func permissionAttributes(userID uint, resourceID *uint, attributes map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	attrs := map[string]interface{}{
+		"hour":    now.Hour(),
+		"weekday": int(now.Weekday()),
+	}
+	if resourceID != nil {
+		attrs["resource_id"] = *resourceID
+	}
+	for key, value := range attributes {
+		attrs[key] = value
+	}
+	attrs["user_id"] = userID
+	return attrs
+}
+
 // GetUserPermissionsForResource retrieves user permissions for a specific resource
 func (s *permissionService) GetUserPermissionsForResource(userID uint, resource model.ResourceType) ([]model.PermissionResponse, error) {
 	permissions, err := s.permissionRepo.GetUserPermissionsForResource(userID, resource)
@@ -791,6 +1161,9 @@ func (s *permissionService) toUserPermissionResponse(up *model.UserPermission) *
 		UpdatedAt:  up.UpdatedAt,
 	}
 
+	if conditions, err := model.ParsePermissionConditions(up.Conditions); err == nil {
+		response.Conditions = conditions
+	}
 	if up.User != nil {
 		response.UserName = up.User.Username
 	}
@@ -800,3 +1173,13 @@ func (s *permissionService) toUserPermissionResponse(up *model.UserPermission) *
 
 	return response
 }
+
+// permissionLogDetails encodes a permission log message with the values that changed
+func permissionLogDetails(message string, values map[string]interface{}) string {
+	values["message"] = message
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return message
+	}
+	return string(encoded)
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/logger"
+)
+
+const (
+	// earthRadiusKm is the mean Earth radius used by the haversine distance
+	earthRadiusKm = 6371.0
+	// kmPerDegreeLat is the length of one degree of latitude
+	kmPerDegreeLat = 111.32
+
+	defaultPickupRadiusKm = 10.0
+	maxPickupRadiusKm     = 100.0
+	defaultPickupLimit    = 10
+	maxPickupLimit        = 50
+	defaultPickupHoldDays = 7
+)
+
+// PickupLocationService defines methods for pickup location business logic
+type PickupLocationService interface {
+	CreatePickupLocation(req *model.PickupLocationRequest) (*model.PickupLocationResponse, error)
+	GetPickupLocationByID(id uint) (*model.PickupLocationResponse, error)
+	GetPickupLocations(page, limit int, filters map[string]interface{}) ([]model.PickupLocationResponse, int64, error)
+	UpdatePickupLocation(id uint, req *model.PickupLocationRequest) (*model.PickupLocationResponse, error)
+	DeletePickupLocation(id uint) error
+
+	// Locator
+	FindNearest(latitude, longitude, radiusKm float64, limit int) ([]model.PickupLocationResponse, error)
+
+	// Checkout
+	CheckAvailability(id uint) (*model.PickupLocation, error)
+}
+
+// pickupLocationService implements PickupLocationService
+type pickupLocationService struct {
+	pickupRepo repository.PickupLocationRepository
+	locations  LocationService
+}
+
+// NewPickupLocationService creates a new PickupLocationService
+func NewPickupLocationService() PickupLocationService {
+	return &pickupLocationService{
+		pickupRepo: repository.NewPickupLocationRepository(),
+		locations:  NewLocationService(),
+	}
+}
+
+// CreatePickupLocation creates a new pickup location
+func (s *pickupLocationService) CreatePickupLocation(req *model.PickupLocationRequest) (*model.PickupLocationResponse, error) {
+	existing, err := s.pickupRepo.GetByCode(req.Code)
+	if err != nil {
+		logger.Errorf("Error checking pickup location code %s: %v", req.Code, err)
+		return nil, fmt.Errorf("failed to create pickup location")
+	}
+	if existing != nil {
+		return nil, errors.New("pickup location code already exists")
+	}
+
+	location := &model.PickupLocation{IsActive: true, HoldDays: defaultPickupHoldDays}
+	if err := s.applyRequest(location, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.pickupRepo.Create(location); err != nil {
+		logger.Errorf("Error creating pickup location: %v", err)
+		return nil, fmt.Errorf("failed to create pickup location")
+	}
+
+	return s.toResponses([]model.PickupLocation{*location}, time.Now())[0], nil
+}
+
+// GetPickupLocationByID retrieves a pickup location by its ID
+func (s *pickupLocationService) GetPickupLocationByID(id uint) (*model.PickupLocationResponse, error) {
+	location, err := s.getLocation(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponses([]model.PickupLocation{*location}, time.Now())[0], nil
+}
+
+// GetPickupLocations retrieves pickup locations with pagination and filters
+func (s *pickupLocationService) GetPickupLocations(page, limit int, filters map[string]interface{}) ([]model.PickupLocationResponse, int64, error) {
+	locations, total, err := s.pickupRepo.GetAll(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Error getting pickup locations: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve pickup locations")
+	}
+
+	responses := make([]model.PickupLocationResponse, 0, len(locations))
+	for _, response := range s.toResponses(locations, time.Now()) {
+		responses = append(responses, *response)
+	}
+	return responses, total, nil
+}
+
+// UpdatePickupLocation updates an existing pickup location
+func (s *pickupLocationService) UpdatePickupLocation(id uint, req *model.PickupLocationRequest) (*model.PickupLocationResponse, error) {
+	location, err := s.getLocation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code != location.Code {
+		existing, err := s.pickupRepo.GetByCode(req.Code)
+		if err != nil {
+			logger.Errorf("Error checking pickup location code %s: %v", req.Code, err)
+			return nil, fmt.Errorf("failed to update pickup location")
+		}
+		if existing != nil {
+			return nil, errors.New("pickup location code already exists")
+		}
+	}
+
+	if err := s.applyRequest(location, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.pickupRepo.Update(location); err != nil {
+		logger.Errorf("Error updating pickup location %d: %v", id, err)
+		return nil, fmt.Errorf("failed to update pickup location")
+	}
+
+	return s.toResponses([]model.PickupLocation{*location}, time.Now())[0], nil
+}
+
+// DeletePickupLocation deletes a pickup location that has no orders waiting at it
+func (s *pickupLocationService) DeletePickupLocation(id uint) error {
+	if _, err := s.getLocation(id); err != nil {
+		return err
+	}
+
+	counts, err := s.pickupRepo.CountOpenOrders([]uint{id})
+	if err != nil {
+		logger.Errorf("Error counting open orders at pickup location %d: %v", id, err)
+		return fmt.Errorf("failed to delete pickup location")
+	}
+	if counts[id] > 0 {
+		return fmt.Errorf("pickup location still has %d open orders, deactivate it instead", counts[id])
+	}
+
+	if err := s.pickupRepo.Delete(id); err != nil {
+		logger.Errorf("Error deleting pickup location %d: %v", id, err)
+		return fmt.Errorf("failed to delete pickup location")
+	}
+	return nil
+}
+
+// Locator
+
+// FindNearest returns the active pickup locations within radiusKm, nearest first. A bounding box
+// narrows the candidates in the database before the exact haversine distance is computed.
+func (s *pickupLocationService) FindNearest(latitude, longitude, radiusKm float64, limit int) ([]model.PickupLocationResponse, error) {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, errors.New("invalid coordinates")
+	}
+	if radiusKm <= 0 {
+		radiusKm = defaultPickupRadiusKm
+	}
+	if radiusKm > maxPickupRadiusKm {
+		radiusKm = maxPickupRadiusKm
+	}
+	if limit <= 0 {
+		limit = defaultPickupLimit
+	}
+	if limit > maxPickupLimit {
+		limit = maxPickupLimit
+	}
+
+	minLat, maxLat, minLng, maxLng := boundingBox(latitude, longitude, radiusKm)
+	candidates, err := s.pickupRepo.GetActiveWithinBounds(minLat, maxLat, minLng, maxLng)
+	if err != nil {
+		logger.Errorf("Error getting pickup locations near %f,%f: %v", latitude, longitude, err)
+		return nil, fmt.Errorf("failed to find pickup locations")
+	}
+
+	// The box corners lie outside the circle, so drop what is beyond the radius
+	type nearby struct {
+		location model.PickupLocation
+		distance float64
+	}
+	var matches []nearby
+	for _, location := range candidates {
+		distance := haversineKm(latitude, longitude, location.Latitude, location.Longitude)
+		if distance <= radiusKm {
+			matches = append(matches, nearby{location: location, distance: distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	locations := make([]model.PickupLocation, len(matches))
+	for i := range matches {
+		locations[i] = matches[i].location
+	}
+
+	responses := make([]model.PickupLocationResponse, 0, len(matches))
+	for i, response := range s.toResponses(locations, time.Now()) {
+		distance := math.Round(matches[i].distance*100) / 100
+		response.DistanceKm = &distance
+		responses = append(responses, *response)
+	}
+	return responses, nil
+}
+
+// Checkout
+
+// CheckAvailability returns a pickup location that can take another order
+func (s *pickupLocationService) CheckAvailability(id uint) (*model.PickupLocation, error) {
+	location, err := s.getLocation(id)
+	if err != nil {
+		return nil, err
+	}
+	if !location.IsActive {
+		return nil, errors.New("pickup location is not active")
+	}
+
+	if location.Capacity > 0 {
+		counts, err := s.pickupRepo.CountOpenOrders([]uint{id})
+		if err != nil {
+			logger.Errorf("Error counting open orders at pickup location %d: %v", id, err)
+			return nil, fmt.Errorf("failed to check pickup location capacity")
+		}
+		if counts[id] >= int64(location.Capacity) {
+			return nil, errors.New("pickup location is at capacity")
+		}
+	}
+
+	return location, nil
+}
+
+// Helper methods
+
+func (s *pickupLocationService) getLocation(id uint) (*model.PickupLocation, error) {
+	location, err := s.pickupRepo.GetByID(id)
+	if err != nil {
+		logger.Errorf("Error getting pickup location by ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to retrieve pickup location")
+	}
+	if location == nil {
+		return nil, errors.New("pickup location not found")
+	}
+	return location, nil
+}
+
+// applyRequest copies a request onto a location, checking its schedule and its address against
+// the administrative directory
+func (s *pickupLocationService) applyRequest(location *model.PickupLocation, req *model.PickupLocationRequest) error {
+	for _, period := range req.OpeningHours {
+		opens, errOpen := parseClock(period.Open)
+		closes, errClose := parseClock(period.Close)
+		if errOpen != nil || errClose != nil {
+			return fmt.Errorf("invalid opening hours %s-%s, use HH:MM", period.Open, period.Close)
+		}
+		if opens >= closes {
+			return fmt.Errorf("opening hours %s-%s must open before they close", period.Open, period.Close)
+		}
+	}
+	openingHours, err := json.Marshal(req.OpeningHours)
+	if err != nil {
+		return fmt.Errorf("failed to encode opening hours")
+	}
+
+	// Reuse the address directory so pickup points share codes with customer addresses
+	address := &model.Address{
+		Ward:         req.Ward,
+		District:     req.District,
+		City:         req.City,
+		ProvinceCode: req.ProvinceCode,
+		DistrictCode: req.DistrictCode,
+		WardCode:     req.WardCode,
+	}
+	if err := s.locations.ApplyToAddress(address); err != nil {
+		return err
+	}
+	if address.City == "" || address.District == "" {
+		return errors.New("pickup location city and district are required")
+	}
+
+	location.Code = req.Code
+	location.Name = req.Name
+	location.Description = req.Description
+	location.Phone = req.Phone
+	location.Email = req.Email
+	location.AddressLine = req.AddressLine
+	location.Ward = address.Ward
+	location.District = address.District
+	location.City = address.City
+	location.ProvinceCode = address.ProvinceCode
+	location.DistrictCode = address.DistrictCode
+	location.WardCode = address.WardCode
+	location.Latitude = req.Latitude
+	location.Longitude = req.Longitude
+	location.OpeningHours = string(openingHours)
+	location.Capacity = req.Capacity
+	location.PickupFee = req.PickupFee
+	if req.HoldDays > 0 {
+		location.HoldDays = req.HoldDays
+	}
+	if req.IsActive != nil {
+		location.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// toResponses builds responses with the schedule, open-now flag and remaining capacity
+func (s *pickupLocationService) toResponses(locations []model.PickupLocation, now time.Time) []*model.PickupLocationResponse {
+	ids := make([]uint, len(locations))
+	for i := range locations {
+		ids[i] = locations[i].ID
+	}
+	counts, err := s.pickupRepo.CountOpenOrders(ids)
+	if err != nil {
+		logger.Warnf("Failed to count open pickup orders: %v", err)
+		counts = map[uint]int64{}
+	}
+
+	responses := make([]*model.PickupLocationResponse, len(locations))
+	for i := range locations {
+		location := locations[i]
+		response := &model.PickupLocationResponse{
+			PickupLocation: location,
+			FullAddress:    location.FullAddress(),
+			OpeningHours:   pickupOpeningHours(&location),
+			OpenOrders:     counts[location.ID],
+		}
+		response.IsOpenNow = location.IsActive && isOpenAt(response.OpeningHours, now)
+		if location.Capacity > 0 {
+			available := location.Capacity - int(response.OpenOrders)
+			if available < 0 {
+				available = 0
+			}
+			response.AvailableCapacity = &available
+		}
+		responses[i] = response
+	}
+	return responses
+}
+
+// pickupOpeningHours decodes the stored weekly schedule of a location
+func pickupOpeningHours(location *model.PickupLocation) []model.PickupOpeningHours {
+	hours := []model.PickupOpeningHours{}
+	if location.OpeningHours == "" {
+		return hours
+	}
+	if err := json.Unmarshal([]byte(location.OpeningHours), &hours); err != nil {
+		logger.Warnf("Invalid opening hours on pickup location %d: %v", location.ID, err)
+	}
+	return hours
+}
+
+// isOpenAt reports whether any opening period covers the given time
+func isOpenAt(hours []model.PickupOpeningHours, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, period := range hours {
+		if period.Weekday != int(t.Weekday()) {
+			continue
+		}
+		opens, errOpen := parseClock(period.Open)
+		closes, errClose := parseClock(period.Close)
+		if errOpen == nil && errClose == nil && minute >= opens && minute < closes {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock converts HH:MM to minutes after midnight; "24:00" closes at the end of the day
+func parseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// boundingBox returns the latitude and longitude bounds of a square around a point
+func boundingBox(latitude, longitude, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	latDelta := radiusKm / kmPerDegreeLat
+	lngDelta := 180.0
+	if cosLat := math.Cos(latitude * math.Pi / 180); cosLat > 1e-6 {
+		lngDelta = math.Min(radiusKm/(kmPerDegreeLat*cosLat), 180)
+	}
+	return latitude - latDelta, latitude + latDelta, longitude - lngDelta, longitude + lngDelta
+}
+
+// haversineKm returns the great-circle distance between two points in kilometers
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/email"
+	"go_app/pkg/logger"
+	"go_app/pkg/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// exportBatchSize is how many pending exports one worker run builds
+const exportBatchSize = 5
+
+// erasureBatchSize is how many due account erasures one worker run processes
+const erasureBatchSize = 20
+
+// DeleteAccountRequest confirms an account erasure request
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // Bắt buộc nếu tài khoản có mật khẩu
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+// PrivacyService handles the personal data rights of Decree 13/2023: users can download a
+// copy of their data and have their account erased after a grace period
+type PrivacyService struct {
+	repo         repository.PrivacyRepository
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	emailService *email.EmailService
+	eventService EventService
+	config       configs.PrivacyConfig
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService() *PrivacyService {
+	config := configs.Load()
+	notificationService := NewNotificationService(repository.NewNotificationRepository(), repository.NewUserRepository())
+
+	return &PrivacyService{
+		repo:         repository.NewPrivacyRepository(),
+		userRepo:     repository.NewUserRepository(),
+		sessionRepo:  repository.NewSessionRepository(),
+		emailService: email.NewEmailService(),
+		eventService: NewEventService(notificationService, nil, nil),
+		config:       config.Privacy,
+	}
+}
+
+// RequestDataExport queues a new export of the user's personal data
+func (s *PrivacyService) RequestDataExport(userID uint, ipAddress string) (*model.DataExportRequest, error) {
+	latest, err := s.repo.GetLatestExport(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data exports: %w", err)
+	}
+	if latest != nil {
+		if latest.Status == model.DataExportStatusPending || latest.Status == model.DataExportStatusProcessing {
+			return nil, errors.New("a data export is already being prepared")
+		}
+		cooldown := time.Duration(s.config.ExportCooldownHours) * time.Hour
+		if latest.Status != model.DataExportStatusFailed && time.Since(latest.CreatedAt) < cooldown {
+			return nil, fmt.Errorf("a data export can only be requested once every %d hours", s.config.ExportCooldownHours)
+		}
+	}
+
+	export := &model.DataExportRequest{
+		UserID:      userID,
+		Status:      model.DataExportStatusPending,
+		RequestedIP: ipAddress,
+	}
+	if err := s.repo.CreateExport(export); err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+	return export, nil
+}
+
+// GetDataExports lists the user's export requests, newest first
+func (s *PrivacyService) GetDataExports(userID uint) ([]model.DataExportRequest, error) {
+	exports, err := s.repo.GetExportsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetDataExportFile returns the archive path of one of the user's finished exports
+func (s *PrivacyService) GetDataExportFile(userID, exportID uint) (string, error) {
+	export, err := s.repo.GetExportByID(exportID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get data export: %w", err)
+	}
+	if export == nil || export.UserID != userID {
+		return "", errors.New("data export not found")
+	}
+	if !export.IsDownloadable() {
+		if export.Status == model.DataExportStatusExpired || export.Status == model.DataExportStatusCompleted {
+			return "", errors.New("data export has expired, please request a new one")
+		}
+		return "", errors.New("data export is not ready yet")
+	}
+
+	if err := s.repo.IncrementDownloads(export.ID); err != nil {
+		logger.Warnf("Failed to count download of data export %d: %v", export.ID, err)
+	}
+	return export.FilePath, nil
+}
+
+// ProcessDataExports builds the archives of pending exports and returns how many completed
+func (s *PrivacyService) ProcessDataExports() (int, error) {
+	exports, err := s.repo.ClaimPendingExports(exportBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim data exports: %w", err)
+	}
+
+	completed := 0
+	for i := range exports {
+		export := &exports[i]
+		path, size, err := s.buildArchive(export.UserID, export.ID)
+		if err != nil {
+			logger.Errorf("Failed to build data export %d for user %d: %v", export.ID, export.UserID, err)
+			export.Status = model.DataExportStatusFailed
+			export.Error = "the archive could not be created, please request a new export"
+			if err := s.repo.UpdateExport(export); err != nil {
+				logger.Errorf("Failed to mark data export %d as failed: %v", export.ID, err)
+			}
+			continue
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(time.Duration(s.config.ExportTTLHours) * time.Hour)
+		export.Status = model.DataExportStatusCompleted
+		export.FilePath = path
+		export.FileSize = size
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+		if err := s.repo.UpdateExport(export); err != nil {
+			logger.Errorf("Failed to complete data export %d: %v", export.ID, err)
+			os.Remove(path)
+			continue
+		}
+
+		completed++
+		go s.notifyExportReady(*export)
+	}
+
+	return completed, nil
+}
+
+// CleanupExpiredExports deletes archives past their download window and returns how many were removed
+func (s *PrivacyService) CleanupExpiredExports() (int, error) {
+	exports, err := s.repo.GetExpiredExports(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired data exports: %w", err)
+	}
+
+	removed := 0
+	for i := range exports {
+		if err := s.expireExport(&exports[i]); err != nil {
+			logger.Errorf("Failed to expire data export %d: %v", exports[i].ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *PrivacyService) expireExport(export *model.DataExportRequest) error {
+	if export.FilePath != "" {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	export.Status = model.DataExportStatusExpired
+	export.FilePath = ""
+	return s.repo.UpdateExport(export)
+}
+
+// buildArchive writes the user's data as one JSON file per section into a ZIP archive
+func (s *PrivacyService) buildArchive(userID, exportID uint) (string, int64, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.Sessions = nil
+	user.OTPs = nil
+
+	sections := []struct {
+		name string
+		load func() (interface{}, error)
+	}{
+		{"profile.json", func() (interface{}, error) { return user, nil }},
+		{"linked_accounts.json", func() (interface{}, error) { return s.repo.GetIdentities(userID) }},
+		{"orders.json", func() (interface{}, error) { return s.repo.GetOrders(userID) }},
+		{"addresses.json", func() (interface{}, error) { return s.repo.GetAddresses(userID) }},
+		{"reviews.json", func() (interface{}, error) { return s.repo.GetReviews(userID) }},
+		{"wishlists.json", func() (interface{}, error) { return s.repo.GetWishlists(userID) }},
+		{"favorites.json", func() (interface{}, error) { return s.repo.GetFavorites(userID) }},
+		{"notifications.json", func() (interface{}, error) { return s.repo.GetNotifications(userID) }},
+		{"audit_logs.json", func() (interface{}, error) { return s.repo.GetAuditLogs(userID) }},
+		{"login_history.json", func() (interface{}, error) { return s.repo.GetLoginAttempts(userID) }},
+	}
+
+	if err := os.MkdirAll(s.config.ExportPath, 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	suffix, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(s.config.ExportPath, fmt.Sprintf("data-export-%d-%d-%s.zip", userID, exportID, suffix))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	archive := zip.NewWriter(file)
+	writeErr := func() error {
+		files := make([]string, 0, len(sections))
+		for _, section := range sections {
+			data, err := section.load()
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", section.name, err)
+			}
+			if err := writeJSONEntry(archive, section.name, data); err != nil {
+				return err
+			}
+			files = append(files, section.name)
+		}
+		manifest := map[string]interface{}{
+			"user_id":      userID,
+			"export_id":    exportID,
+			"generated_at": time.Now().Format(time.RFC3339),
+			"files":        files,
+		}
+		if err := writeJSONEntry(archive, "manifest.json", manifest); err != nil {
+			return err
+		}
+		return archive.Close()
+	}()
+	closeErr := file.Close()
+
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(path)
+		return "", 0, writeErr
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+func writeJSONEntry(archive *zip.Writer, name string, data interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (s *PrivacyService) notifyExportReady(export model.DataExportRequest) {
+	if err := s.eventService.OnDataExportReady(&export); err != nil {
+		logger.Warnf("Failed to notify user %d about data export %d: %v", export.UserID, export.ID, err)
+	}
+
+	user, err := s.userRepo.GetByID(export.UserID)
+	if err != nil {
+		logger.Warnf("Failed to load user %d for data export email: %v", export.UserID, err)
+		return
+	}
+	if err := s.emailService.SendDataExportReadyEmail(user.Email, *export.ExpiresAt); err != nil {
+		logger.Warnf("Failed to send data export email to user %d: %v", export.UserID, err)
+	}
+}
+
+// RequestAccountErasure schedules the user's account for erasure after the grace period
+func (s *PrivacyService) RequestAccountErasure(userID uint, req *DeleteAccountRequest, ipAddress string) (*model.AccountErasureRequest, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Social login accounts have no password; the access token is the only proof available
+	if user.Password != "" {
+		if req.Password == "" {
+			return nil, errors.New("password is required to delete the account")
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+			return nil, errors.New("invalid password")
+		}
+	}
+
+	pending, err := s.repo.GetPendingErasure(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account erasure request: %w", err)
+	}
+	if pending != nil {
+		return nil, errors.New("account deletion is already scheduled")
+	}
+
+	openOrders, err := s.repo.CountOpenOrders(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open orders: %w", err)
+	}
+	if openOrders > 0 {
+		return nil, fmt.Errorf("account has %d orders in progress; it can be deleted once they are completed or cancelled", openOrders)
+	}
+
+	erasure := &model.AccountErasureRequest{
+		UserID:      userID,
+		Status:      model.AccountErasureStatusPending,
+		Reason:      req.Reason,
+		RequestedIP: ipAddress,
+		ScheduledAt: time.Now().AddDate(0, 0, s.config.ErasureGraceDays),
+	}
+	if err := s.repo.CreateErasure(erasure); err != nil {
+		return nil, fmt.Errorf("failed to create account erasure request: %w", err)
+	}
+
+	go func(to string, scheduledAt time.Time) {
+		if err := s.emailService.SendAccountErasureScheduledEmail(to, scheduledAt); err != nil {
+			logger.Warnf("Failed to send account erasure email to user %d: %v", userID, err)
+		}
+	}(user.Email, erasure.ScheduledAt)
+
+	return erasure, nil
+}
+
+// GetAccountErasure returns the user's most recent account erasure request
+func (s *PrivacyService) GetAccountErasure(userID uint) (*model.AccountErasureRequest, error) {
+	erasure, err := s.repo.GetLatestErasure(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account erasure request: %w", err)
+	}
+	if erasure == nil {
+		return nil, errors.New("account deletion request not found")
+	}
+	return erasure, nil
+}
+
+// CancelAccountErasure cancels a scheduled account erasure during the grace period
+func (s *PrivacyService) CancelAccountErasure(userID uint) error {
+	erasure, err := s.repo.GetPendingErasure(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get account erasure request: %w", err)
+	}
+	if erasure == nil {
+		return errors.New("account deletion request not found")
+	}
+
+	now := time.Now()
+	erasure.Status = model.AccountErasureStatusCancelled
+	erasure.CancelledAt = &now
+	if err := s.repo.UpdateErasure(erasure); err != nil {
+		return fmt.Errorf("failed to cancel account erasure request: %w", err)
+	}
+	return nil
+}
+
+// ProcessDueErasures erases the accounts whose grace period has ended and returns how many
+// were erased. Accounts with orders still in progress are retried on a later run.
+func (s *PrivacyService) ProcessDueErasures() (int, error) {
+	erasures, err := s.repo.GetDueErasures(time.Now(), erasureBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due account erasures: %w", err)
+	}
+
+	erased := 0
+	for i := range erasures {
+		if err := s.eraseAccount(&erasures[i]); err != nil {
+			logger.Errorf("Failed to erase account of user %d: %v", erasures[i].UserID, err)
+			erasures[i].LastError = truncateString(err.Error(), 500)
+			if err := s.repo.UpdateErasure(&erasures[i]); err != nil {
+				logger.Errorf("Failed to update account erasure request %d: %v", erasures[i].ID, err)
+			}
+			continue
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+func (s *PrivacyService) eraseAccount(erasure *model.AccountErasureRequest) error {
+	openOrders, err := s.repo.CountOpenOrders(erasure.UserID)
+	if err != nil {
+		return err
+	}
+	if openOrders > 0 {
+		return fmt.Errorf("%d orders still in progress", openOrders)
+	}
+
+	user, err := s.userRepo.GetByID(erasure.UserID)
+	if err != nil {
+		return err
+	}
+	originalEmail := user.Email
+
+	// Exported archives are personal data too
+	exports, err := s.repo.GetExportsByUserID(erasure.UserID)
+	if err != nil {
+		return err
+	}
+	for i := range exports {
+		if exports[i].FilePath == "" {
+			continue
+		}
+		if err := s.expireExport(&exports[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := s.sessionRepo.RevokeAllUserSessions(erasure.UserID, model.SessionRevokeAccountErased); err != nil {
+		return err
+	}
+	if err := s.repo.EraseUser(erasure); err != nil {
+		return err
+	}
+
+	logger.Infof("Account of user %d erased (request %d)", erasure.UserID, erasure.ID)
+
+	go func() {
+		if err := s.emailService.SendAccountErasedEmail(originalEmail); err != nil {
+			logger.Warnf("Failed to send account erased email for user %d: %v", erasure.UserID, err)
+		}
+	}()
+	return nil
+}
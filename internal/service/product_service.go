@@ -14,6 +14,8 @@ type ProductService struct {
 	productRepo          *repository.ProductRepository
 	productVariantRepo   *repository.ProductVariantRepository
 	productAttributeRepo *repository.ProductAttributeRepository
+	productBundleRepo    *repository.ProductBundleRepository
+	inventoryService     InventoryService
 }
 
 func NewProductService() *ProductService {
@@ -21,6 +23,8 @@ func NewProductService() *ProductService {
 		productRepo:          repository.NewProductRepository(),
 		productVariantRepo:   repository.NewProductVariantRepository(),
 		productAttributeRepo: repository.NewProductAttributeRepository(),
+		productBundleRepo:    repository.NewProductBundleRepository(),
+		inventoryService:     NewInventoryService(),
 	}
 }
 
@@ -75,6 +79,28 @@ func (s *ProductService) CreateProduct(req *model.ProductCreateRequest) (*model.
 		isDownloadable = *req.IsDownloadable
 	}
 
+	trackLots := false
+	if req.TrackLots != nil {
+		trackLots = *req.TrackLots
+	}
+
+	backorderPolicy := req.BackorderPolicy
+	if backorderPolicy == "" {
+		backorderPolicy = model.BackorderPolicyNone
+	}
+
+	// Validate bundle components
+	var bundleItems []model.ProductBundleItem
+	if req.Type == model.ProductTypeBundle {
+		if len(req.BundleItems) == 0 {
+			return nil, fmt.Errorf("bundle products require at least one component")
+		}
+		bundleItems, err = s.buildBundleItems(0, req.BundleItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create product
 	product := &model.Product{
 		Name:              strings.TrimSpace(req.Name),
@@ -91,6 +117,10 @@ func (s *ProductService) CreateProduct(req *model.ProductCreateRequest) (*model.
 		StockQuantity:     req.StockQuantity,
 		LowStockThreshold: req.LowStockThreshold,
 		StockStatus:       req.StockStatus,
+		TrackLots:         trackLots,
+		BackorderPolicy:     backorderPolicy,
+		BackorderLimit:      req.BackorderLimit,
+		BackorderExpectedAt: req.BackorderExpectedAt,
 		Weight:            req.Weight,
 		Length:            req.Length,
 		Width:             req.Width,
@@ -122,6 +152,15 @@ func (s *ProductService) CreateProduct(req *model.ProductCreateRequest) (*model.
 		}
 	}
 
+	// Create bundle components if product type is bundle
+	if product.Type == model.ProductTypeBundle {
+		if err := s.productBundleRepo.ReplaceItems(product.ID, bundleItems); err != nil {
+			// Rollback product creation
+			s.productRepo.Delete(product.ID)
+			return nil, fmt.Errorf("failed to create bundle items: %w", err)
+		}
+	}
+
 	// Create attributes
 	if len(req.Attributes) > 0 {
 		if err := s.createProductAttributes(product.ID, req.Attributes); err != nil {
@@ -237,7 +276,22 @@ func (s *ProductService) UpdateProduct(id uint, req *model.ProductUpdateRequest)
 		product.SKU = strings.TrimSpace(req.SKU)
 	}
 
-	if req.Type != "" {
+	if req.Type != "" && req.Type != product.Type {
+		if req.Type == model.ProductTypeBundle {
+			// A product sold inside other bundles cannot become a bundle itself
+			isComponent, err := s.productBundleRepo.ExistsAsComponent(id)
+			if err != nil {
+				return nil, err
+			}
+			if isComponent {
+				return nil, fmt.Errorf("product is a component of another bundle")
+			}
+		}
+		if product.Type == model.ProductTypeBundle {
+			if err := s.productBundleRepo.DeleteByBundleID(id); err != nil {
+				return nil, err
+			}
+		}
 		product.Type = req.Type
 	}
 
@@ -333,6 +387,20 @@ func (s *ProductService) UpdateProduct(id uint, req *model.ProductUpdateRequest)
 		product.IsDownloadable = *req.IsDownloadable
 	}
 
+	if req.TrackLots != nil {
+		product.TrackLots = *req.TrackLots
+	}
+
+	if req.BackorderPolicy != "" {
+		product.BackorderPolicy = req.BackorderPolicy
+	}
+	if req.BackorderLimit != nil {
+		product.BackorderLimit = *req.BackorderLimit
+	}
+	if req.BackorderExpectedAt != nil {
+		product.BackorderExpectedAt = req.BackorderExpectedAt
+	}
+
 	// Update product
 	if err := s.productRepo.Update(product); err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
@@ -605,6 +673,20 @@ func (s *ProductService) createProductAttributes(productID uint, attributeReqs [
 func (s *ProductService) convertToResponse(product *model.Product) model.ProductResponse {
 	response := product.ToResponse()
 
+	// Bundle stock is derived from its components
+	if product.IsBundle() {
+		if availability, err := s.inventoryService.GetBundleAvailability(product.ID); err == nil {
+			response.StockQuantity = availability.AvailableQuantity
+			response.StockStatus = availability.StockStatus
+			response.BundleItems = availability.Components
+		}
+	}
+
+	// Out of stock products that accept backorders can still be ordered
+	if product.AllowsBackorder() && response.StockQuantity <= 0 {
+		response.StockStatus = "onbackorder"
+	}
+
 	// Parse images JSON
 	if product.Images != "" {
 		var images []string
@@ -626,6 +708,88 @@ func (s *ProductService) convertToResponse(product *model.Product) model.Product
 	return response
 }
 
+// ===== PRODUCT BUNDLES SERVICE METHODS =====
+
+// GetBundleItems gets the components of a bundle with their stock and the bundle availability
+func (s *ProductService) GetBundleItems(productID uint) (*model.BundleAvailabilityResponse, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if !product.IsBundle() {
+		return nil, fmt.Errorf("product is not a bundle")
+	}
+
+	return s.inventoryService.GetBundleAvailability(productID)
+}
+
+// UpdateBundleItems replaces the components of a bundle
+func (s *ProductService) UpdateBundleItems(productID uint, req *model.ProductBundleItemsUpdateRequest) (*model.BundleAvailabilityResponse, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if !product.IsBundle() {
+		return nil, fmt.Errorf("product is not a bundle")
+	}
+
+	items, err := s.buildBundleItems(productID, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.productBundleRepo.ReplaceItems(productID, items); err != nil {
+		return nil, err
+	}
+
+	return s.inventoryService.GetBundleAvailability(productID)
+}
+
+// buildBundleItems validates bundle component requests and converts them to models
+func (s *ProductService) buildBundleItems(bundleID uint, itemReqs []model.ProductBundleItemRequest) ([]model.ProductBundleItem, error) {
+	seen := make(map[string]bool)
+	items := make([]model.ProductBundleItem, 0, len(itemReqs))
+
+	for _, itemReq := range itemReqs {
+		if itemReq.Quantity <= 0 {
+			return nil, fmt.Errorf("component quantity must be positive")
+		}
+		if bundleID != 0 && itemReq.ComponentProductID == bundleID {
+			return nil, fmt.Errorf("bundle cannot contain itself")
+		}
+
+		component, err := s.productRepo.GetByID(itemReq.ComponentProductID)
+		if err != nil {
+			return nil, fmt.Errorf("component product %d not found", itemReq.ComponentProductID)
+		}
+		if component.IsBundle() {
+			return nil, fmt.Errorf("bundle cannot contain another bundle")
+		}
+
+		key := fmt.Sprintf("%d", itemReq.ComponentProductID)
+		if itemReq.ComponentVariantID != nil {
+			variant, err := s.productVariantRepo.GetByID(*itemReq.ComponentVariantID)
+			if err != nil || variant.ProductID != itemReq.ComponentProductID {
+				return nil, fmt.Errorf("component variant %d not found", *itemReq.ComponentVariantID)
+			}
+			key = fmt.Sprintf("%d:%d", itemReq.ComponentProductID, *itemReq.ComponentVariantID)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate bundle component %s", key)
+		}
+		seen[key] = true
+
+		items = append(items, model.ProductBundleItem{
+			ComponentProductID: itemReq.ComponentProductID,
+			ComponentVariantID: itemReq.ComponentVariantID,
+			Quantity:           itemReq.Quantity,
+			SortOrder:          itemReq.SortOrder,
+		})
+	}
+
+	return items, nil
+}
+
 // ===== PRODUCT VARIANTS SERVICE METHODS =====
 
 // GetProductVariants gets all variants for a product
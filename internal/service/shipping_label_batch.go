@@ -0,0 +1,286 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/pkg/document"
+	"go_app/pkg/logger"
+	"go_app/pkg/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CreateShippingLabelBatch prints labels and packing slips for many shipping orders into one PDF.
+// Carrier labels are used when available; otherwise a label is generated from the shipping order.
+func (s *shippingService) CreateShippingLabelBatch(req *model.ShippingLabelBatchRequest) (*model.ShippingLabelBatchResponse, error) {
+	includeLabels := req.IncludeLabels == nil || *req.IncludeLabels
+	includeSlips := req.IncludePackingSlips == nil || *req.IncludePackingSlips
+	if !includeLabels && !includeSlips {
+		return nil, errors.New("nothing to print")
+	}
+
+	batch := document.NewShippingBatch(s.labelFontPath)
+	record := &model.ShippingLabelBatch{CreatedBy: req.CreatedBy}
+
+	var items []model.ShippingLabelBatchItem
+	seen := make(map[uint]bool)
+	for _, id := range req.ShippingOrderIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item := s.addToLabelBatch(batch, id, includeLabels, includeSlips)
+		if item.Error != "" {
+			record.FailedCount++
+		}
+		if item.LabelSource != "" {
+			record.LabelCount++
+		}
+		if item.PackingSlip {
+			record.PackingSlipCount++
+		}
+		items = append(items, item)
+	}
+
+	if batch.PageCount() == 0 {
+		return nil, errors.New("no shipping orders could be printed")
+	}
+
+	data, err := batch.Output()
+	if err != nil {
+		logger.Errorf("Failed to render shipping label batch: %v", err)
+		return nil, fmt.Errorf("failed to generate shipping labels")
+	}
+
+	fileName, filePath, err := s.saveLabelBatchFile(data)
+	if err != nil {
+		logger.Errorf("Failed to store shipping label batch: %v", err)
+		return nil, fmt.Errorf("failed to generate shipping labels")
+	}
+
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		logger.Errorf("Failed to serialize shipping label batch items: %v", err)
+		return nil, fmt.Errorf("failed to generate shipping labels")
+	}
+
+	record.FileName = fileName
+	record.FilePath = filePath
+	record.FileSize = int64(len(data))
+	record.PageCount = batch.PageCount()
+	record.Items = string(itemsJSON)
+	if err := s.shippingRepo.CreateShippingLabelBatch(record); err != nil {
+		logger.Errorf("Failed to save shipping label batch: %v", err)
+		return nil, fmt.Errorf("failed to generate shipping labels")
+	}
+
+	return toShippingLabelBatchResponse(record), nil
+}
+
+// GetShippingLabelBatch gets a generated label batch
+func (s *shippingService) GetShippingLabelBatch(id uint) (*model.ShippingLabelBatchResponse, error) {
+	record, err := s.shippingRepo.GetShippingLabelBatchByID(id)
+	if err != nil {
+		logger.Errorf("Failed to get shipping label batch %d: %v", id, err)
+		return nil, fmt.Errorf("shipping label batch not found")
+	}
+	return toShippingLabelBatchResponse(record), nil
+}
+
+// DownloadShippingLabelBatch returns the stored PDF of a label batch for re-printing
+func (s *shippingService) DownloadShippingLabelBatch(id uint) (*model.ShippingLabelBatch, []byte, error) {
+	record, err := s.shippingRepo.GetShippingLabelBatchByID(id)
+	if err != nil {
+		logger.Errorf("Failed to get shipping label batch %d: %v", id, err)
+		return nil, nil, fmt.Errorf("shipping label batch not found")
+	}
+
+	data, err := s.readLabelBatchFile(record.FilePath)
+	if err != nil {
+		logger.Errorf("Failed to read shipping label batch file %s: %v", record.FilePath, err)
+		return nil, nil, fmt.Errorf("shipping label file not found")
+	}
+	return record, data, nil
+}
+
+// saveLabelBatchFile writes a batch PDF to the private label directory. Labels carry customer
+// names, phones and addresses, so they are only handed out by the authenticated download endpoint.
+func (s *shippingService) saveLabelBatchFile(data []byte) (string, string, error) {
+	if err := os.MkdirAll(s.labelPath, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create label directory: %w", err)
+	}
+	suffix, err := utils.GenerateRandomString(8)
+	if err != nil {
+		return "", "", err
+	}
+	fileName := fmt.Sprintf("labels-%s-%s.pdf", time.Now().Format("20060102-150405"), suffix)
+	filePath := filepath.Join(s.labelPath, fileName)
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write label file: %w", err)
+	}
+	return fileName, filePath, nil
+}
+
+// readLabelBatchFile reads a batch PDF; batches printed before labels moved to the private
+// directory are still read from uploads
+func (s *shippingService) readLabelBatchFile(filePath string) ([]byte, error) {
+	labelDir, err := filepath.Abs(s.labelPath)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(absPath, labelDir+string(filepath.Separator)) {
+		return s.uploadService.ReadFile(filePath)
+	}
+	return os.ReadFile(absPath)
+}
+
+// addToLabelBatch adds the label and packing slip of one shipping order to the batch
+func (s *shippingService) addToLabelBatch(batch *document.ShippingBatch, shippingOrderID uint, includeLabel, includeSlip bool) model.ShippingLabelBatchItem {
+	item := model.ShippingLabelBatchItem{ShippingOrderID: shippingOrderID}
+
+	shippingOrder, err := s.shippingRepo.GetShippingOrderByID(shippingOrderID)
+	if err != nil {
+		item.Error = "shipping order not found"
+		return item
+	}
+	item.TrackingCode = shippingOrder.TrackingCode
+
+	order, err := s.orderRepo.GetOrderByID(shippingOrder.OrderID)
+	if err != nil || order == nil {
+		logger.Errorf("Failed to get order %d for shipping label: %v", shippingOrder.OrderID, err)
+		item.Error = "order not found"
+		return item
+	}
+	item.OrderNumber = order.OrderNumber
+
+	carrierName := ""
+	if shippingOrder.Provider != nil {
+		carrierName = shippingOrder.Provider.DisplayName
+	}
+
+	if includeLabel {
+		item.LabelSource = s.addShippingLabel(batch, shippingOrder, order, carrierName)
+	}
+
+	if includeSlip {
+		batch.AddPackingSlip(&document.PackingSlip{
+			OrderNumber:     order.OrderNumber,
+			OrderDate:       order.CreatedAt,
+			CustomerName:    order.CustomerName,
+			CustomerPhone:   order.CustomerPhone,
+			ShippingAddress: order.ShippingAddress,
+			Carrier:         carrierName,
+			TrackingCode:    shippingOrder.TrackingCode,
+			Lines:           s.packingSlipLines(shippingOrder, order),
+			Note:            order.Notes,
+		})
+		item.PackingSlip = true
+	}
+
+	return item
+}
+
+// addShippingLabel adds the carrier's label when it can be fetched and merged, or a generated one
+func (s *shippingService) addShippingLabel(batch *document.ShippingBatch, shippingOrder *model.ShippingOrder, order *model.Order, carrierName string) string {
+	if shippingOrder.TrackingCode != "" && shippingOrder.Provider != nil {
+		if carrier, err := s.carrierFor(shippingOrder.Provider); err == nil {
+			label, err := carrier.Label(shippingOrder.TrackingCode)
+			if err == nil {
+				err = batch.AddCarrierLabel(label)
+			}
+			if err == nil {
+				return model.LabelSourceCarrier
+			}
+			logger.Warnf("Using generated label for shipment %s: %v", shippingOrder.TrackingCode, err)
+		}
+	}
+
+	batch.AddShippingLabel(&document.ShippingLabel{
+		Carrier:      carrierName,
+		TrackingCode: shippingOrder.TrackingCode,
+		OrderNumber:  order.OrderNumber,
+		FromName:     shippingOrder.FromName,
+		FromPhone:    shippingOrder.FromPhone,
+		FromAddress:  shippingOrder.FromAddress,
+		ToName:       shippingOrder.ToName,
+		ToPhone:      shippingOrder.ToPhone,
+		ToAddress:    shippingOrder.ToAddress,
+		Weight:       shippingOrder.Weight,
+		COD:          shippingOrder.COD,
+		Note:         order.Notes,
+	})
+	return model.LabelSourceGenerated
+}
+
+// packingSlipLines lists the items of the shipment booked with the shipping order,
+// or the in-stock order items when no shipment is linked yet
+func (s *shippingService) packingSlipLines(shippingOrder *model.ShippingOrder, order *model.Order) []document.PackingSlipLine {
+	var lines []document.PackingSlipLine
+
+	shipments, err := s.fulfillment.GetShipmentsByOrder(order.ID)
+	if err != nil {
+		logger.Warnf("Failed to get shipments of order %d for packing slip: %v", order.ID, err)
+	}
+	for _, shipment := range shipments {
+		if shipment.ShippingOrderID == nil || *shipment.ShippingOrderID != shippingOrder.ID || len(shipment.Items) == 0 {
+			continue
+		}
+		for _, item := range shipment.Items {
+			if item.OrderItem == nil {
+				continue
+			}
+			lines = append(lines, packingSlipLine(item.OrderItem, item.Quantity))
+		}
+		return lines
+	}
+
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		if quantity := item.InStockQuantity(); quantity > 0 {
+			lines = append(lines, packingSlipLine(item, quantity))
+		}
+	}
+	return lines
+}
+
+func packingSlipLine(item *model.OrderItem, quantity int) document.PackingSlipLine {
+	return document.PackingSlipLine{
+		SKU:      item.ProductSKU,
+		Name:     item.ProductName,
+		Variant:  item.VariantName,
+		Quantity: quantity,
+	}
+}
+
+func toShippingLabelBatchResponse(record *model.ShippingLabelBatch) *model.ShippingLabelBatchResponse {
+	var items []model.ShippingLabelBatchItem
+	if record.Items != "" {
+		if err := json.Unmarshal([]byte(record.Items), &items); err != nil {
+			logger.Warnf("Failed to parse items of shipping label batch %d: %v", record.ID, err)
+		}
+	}
+
+	return &model.ShippingLabelBatchResponse{
+		ID:               record.ID,
+		FileName:         record.FileName,
+		FileURL:          record.FileURL,
+		DownloadURL:      fmt.Sprintf("/api/v1/shipping/orders/labels/%d/download", record.ID),
+		FileSize:         record.FileSize,
+		PageCount:        record.PageCount,
+		LabelCount:       record.LabelCount,
+		PackingSlipCount: record.PackingSlipCount,
+		FailedCount:      record.FailedCount,
+		Items:            items,
+		CreatedBy:        record.CreatedBy,
+		CreatedAt:        record.CreatedAt,
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/pkg/logger"
+	"math"
+	"sort"
+	"strings"
+)
+
+// volumetricDivisor converts a package volume in cm³ to a weight in kg
+const volumetricDivisor = 6000
+
+// shippingPackage holds the measured weight and value of a package to be priced
+type shippingPackage struct {
+	ActualWeight     float64
+	VolumetricWeight float64
+	ChargeableWeight float64
+	Value            float64
+}
+
+// buildShippingPackage computes the package weight and value from the request items or cart,
+// falling back to the weight and value sent by the client
+func (s *shippingService) buildShippingPackage(req *model.CalculateShippingRequest) (*shippingPackage, error) {
+	pkg := &shippingPackage{}
+	itemsValue := 0.0
+
+	if len(req.Items) > 0 {
+		for _, item := range req.Items {
+			if item.Quantity <= 0 {
+				return nil, errors.New("invalid item quantity")
+			}
+
+			product, err := s.productRepo.GetByID(item.ProductID)
+			if err != nil {
+				return nil, errors.New("product not found")
+			}
+
+			price := product.RegularPrice
+			if product.SalePrice != nil && *product.SalePrice > 0 {
+				price = *product.SalePrice
+			}
+
+			addProductToPackage(pkg, product, item.Quantity)
+			itemsValue += price * float64(item.Quantity)
+		}
+	} else if req.CartID != nil {
+		cartItems, err := s.orderRepo.GetCartItemsByCart(*req.CartID)
+		if err != nil {
+			logger.Errorf("Failed to get cart items for shipping calculation: %v", err)
+			return nil, fmt.Errorf("failed to calculate shipping")
+		}
+		if len(cartItems) == 0 {
+			return nil, errors.New("cart is empty")
+		}
+
+		for _, item := range cartItems {
+			if item.Product != nil {
+				addProductToPackage(pkg, item.Product, item.Quantity)
+			}
+			itemsValue += item.TotalPrice
+		}
+	}
+
+	if pkg.ActualWeight <= 0 {
+		pkg.ActualWeight = req.Weight
+	}
+	pkg.VolumetricWeight = roundWeight(pkg.VolumetricWeight)
+	pkg.ActualWeight = roundWeight(pkg.ActualWeight)
+	pkg.ChargeableWeight = math.Max(pkg.ActualWeight, pkg.VolumetricWeight)
+	if pkg.ChargeableWeight <= 0 {
+		return nil, errors.New("package weight is required")
+	}
+
+	pkg.Value = req.Value
+	if pkg.Value <= 0 {
+		pkg.Value = itemsValue
+	}
+
+	return pkg, nil
+}
+
+// addProductToPackage adds the actual and volumetric weight of a product line to the package
+func addProductToPackage(pkg *shippingPackage, product *model.Product, quantity int) {
+	if product.Weight != nil {
+		pkg.ActualWeight += *product.Weight * float64(quantity)
+	}
+	if product.Length != nil && product.Width != nil && product.Height != nil {
+		volume := *product.Length * *product.Width * *product.Height
+		pkg.VolumetricWeight += volume * float64(quantity) / volumetricDivisor
+	}
+}
+
+func roundWeight(weight float64) float64 {
+	return math.Round(weight*1000) / 1000
+}
+
+// freeShippingCoupon loads the free shipping coupon of the request, if any
+func (s *shippingService) freeShippingCoupon(req *model.CalculateShippingRequest, value float64) (*model.Coupon, error) {
+	if req.CouponCode == "" {
+		return nil, nil
+	}
+
+	coupon, err := s.couponRepo.GetCouponByCode(strings.ToUpper(strings.TrimSpace(req.CouponCode)))
+	if err != nil {
+		logger.Errorf("Failed to get coupon for shipping calculation: %v", err)
+		return nil, fmt.Errorf("failed to calculate shipping")
+	}
+	if coupon == nil {
+		return nil, errors.New("coupon not found")
+	}
+	if coupon.Type != model.CouponTypeFreeShipping {
+		return nil, errors.New("coupon is not a free shipping coupon")
+	}
+	if !coupon.CanUse(0, value) {
+		return nil, errors.New("coupon cannot be applied to this order")
+	}
+
+	return coupon, nil
+}
+
+// matchZoneRates keeps the most specific matching rate of each provider.
+// An exact district beats an exact province, which beats a wildcard; ties go to the cheaper base fee.
+func matchZoneRates(rates []model.ShippingRate, req *model.CalculateShippingRequest) map[uint]*model.ShippingRate {
+	best := make(map[uint]*model.ShippingRate)
+	for i := range rates {
+		rate := &rates[i]
+		current, ok := best[rate.ProviderID]
+		if !ok {
+			best[rate.ProviderID] = rate
+			continue
+		}
+
+		score, currentScore := zoneScore(rate, req), zoneScore(current, req)
+		if score > currentScore || (score == currentScore && rate.BaseFee < current.BaseFee) {
+			best[rate.ProviderID] = rate
+		}
+	}
+	return best
+}
+
+// zoneScore ranks how specifically a rate matches the route, destination first
+func zoneScore(rate *model.ShippingRate, req *model.CalculateShippingRequest) int {
+	score := 0
+	if rate.ToDistrict == req.ToDistrict {
+		score += 8
+	}
+	if rate.ToProvince == req.ToProvince {
+		score += 4
+	}
+	if rate.FromDistrict == req.FromDistrict {
+		score += 2
+	}
+	if rate.FromProvince == req.FromProvince {
+		score++
+	}
+	return score
+}
+
+// quoteTableRate prices a package with a provider's matched rate
+func quoteTableRate(provider *model.ShippingProvider, rate *model.ShippingRate, req *model.CalculateShippingRequest, pkg *shippingPackage) model.CalculateShippingResponse {
+	response := model.CalculateShippingResponse{
+		ProviderID:   provider.ID,
+		ProviderName: provider.DisplayName,
+		ProviderCode: provider.Code,
+		Source:       model.QuoteSourceTableRate,
+	}
+
+	if rate == nil {
+		response.Message = "no shipping rate for this zone"
+		return response
+	}
+
+	shippingFee := rate.BaseFee
+	if rate.WeightFee > 0 {
+		shippingFee += (pkg.ChargeableWeight - rate.MinWeight) * rate.WeightFee
+	}
+	if rate.ValueFee > 0 {
+		shippingFee += (pkg.Value - rate.MinValue) * rate.ValueFee / 1000000 // Convert to VND
+	}
+	response.ShippingFee = math.Round(shippingFee)
+
+	if req.COD > 0 && rate.COD > 0 {
+		response.COD = rate.COD
+		response.Surcharges = append(response.Surcharges, model.ShippingSurcharge{Code: "cod", Name: "Phí thu hộ", Amount: rate.COD})
+	}
+	if req.Insurance > 0 && rate.Insurance > 0 {
+		response.InsuranceFee = rate.Insurance
+		response.Surcharges = append(response.Surcharges, model.ShippingSurcharge{Code: "insurance", Name: "Phí bảo hiểm", Amount: rate.Insurance})
+	}
+	if req.Fragile && rate.Fragile > 0 {
+		response.Surcharges = append(response.Surcharges, model.ShippingSurcharge{Code: "fragile", Name: "Phí hàng dễ vỡ", Amount: rate.Fragile})
+	}
+
+	response.MinDays = rate.MinDays
+	response.MaxDays = rate.MaxDays
+	response.IsAvailable = true
+	return response
+}
+
+// checkProviderLimits marks a quote unavailable when the provider cannot carry the package
+func checkProviderLimits(response *model.CalculateShippingResponse, provider *model.ShippingProvider, req *model.CalculateShippingRequest, pkg *shippingPackage) {
+	var message string
+	switch {
+	case provider.MaxWeight > 0 && pkg.ChargeableWeight > provider.MaxWeight:
+		message = "package exceeds provider weight limit"
+	case provider.MinWeight > 0 && pkg.ChargeableWeight < provider.MinWeight:
+		message = "package is below provider minimum weight"
+	case provider.MaxValue > 0 && pkg.Value > provider.MaxValue:
+		message = "package value exceeds provider limit"
+	case req.COD > 0 && !provider.SupportsCOD:
+		message = "provider does not support COD"
+	case req.Fragile && !provider.SupportsFragile:
+		message = "provider does not support fragile items"
+	}
+
+	if message != "" {
+		response.IsAvailable = false
+		response.Message = message
+	}
+}
+
+// applyFreeShipping waives the shipping fee when the order reaches the rate's threshold,
+// or up to the coupon's cap for free shipping coupons. Surcharges are still charged.
+func applyFreeShipping(response *model.CalculateShippingResponse, rate *model.ShippingRate, coupon *model.Coupon, pkg *shippingPackage) {
+	if !response.IsAvailable || response.ShippingFee <= 0 {
+		return
+	}
+
+	if rate != nil && rate.FreeShippingThreshold > 0 && pkg.Value >= rate.FreeShippingThreshold {
+		response.ShippingDiscount = response.ShippingFee
+		response.FreeShipping = true
+		response.FreeShippingReason = model.FreeShippingReasonThreshold
+		return
+	}
+
+	if coupon != nil {
+		discount := response.ShippingFee
+		if coupon.MaxDiscountAmount > 0 && discount > coupon.MaxDiscountAmount {
+			discount = coupon.MaxDiscountAmount
+		}
+		response.ShippingDiscount = discount
+		response.FreeShipping = discount >= response.ShippingFee
+		response.FreeShippingReason = model.FreeShippingReasonCoupon
+	}
+}
+
+// finalizeQuote fills in the package breakdown and the total fee
+func finalizeQuote(response *model.CalculateShippingResponse, pkg *shippingPackage) {
+	response.ActualWeight = pkg.ActualWeight
+	response.VolumetricWeight = pkg.VolumetricWeight
+	response.ChargeableWeight = pkg.ChargeableWeight
+
+	if !response.IsAvailable {
+		return
+	}
+
+	surcharges := 0.0
+	for _, surcharge := range response.Surcharges {
+		surcharges += surcharge.Amount
+	}
+	response.TotalFee = response.ShippingFee - response.ShippingDiscount + surcharges
+}
+
+// rankQuotes orders quotes available first, then cheapest, then fastest, and numbers them
+func rankQuotes(responses []model.CalculateShippingResponse) {
+	sort.SliceStable(responses, func(i, j int) bool {
+		if responses[i].IsAvailable != responses[j].IsAvailable {
+			return responses[i].IsAvailable
+		}
+		if responses[i].TotalFee != responses[j].TotalFee {
+			return responses[i].TotalFee < responses[j].TotalFee
+		}
+		return responses[i].MaxDays < responses[j].MaxDays
+	})
+
+	for i := range responses {
+		responses[i].Rank = i + 1
+	}
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go_app/configs"
 	"go_app/internal/model"
 	"go_app/internal/repository"
 	"go_app/pkg/logger"
@@ -39,9 +42,15 @@ type ShippingService interface {
 	CancelShippingOrder(id uint, reason string) error
 	GetShippingOrders(page, limit int, filters map[string]interface{}) ([]model.ShippingOrderResponse, int64, error)
 
+	GetShippingLabel(id uint) ([]byte, error)
+	CreateShippingLabelBatch(req *model.ShippingLabelBatchRequest) (*model.ShippingLabelBatchResponse, error)
+	GetShippingLabelBatch(id uint) (*model.ShippingLabelBatchResponse, error)
+	DownloadShippingLabelBatch(id uint) (*model.ShippingLabelBatch, []byte, error)
+
 	// Tracking
-	GetShippingTracking(orderID uint) ([]model.ShippingTracking, error)
-	UpdateShippingStatusFromWebhook(webhookData *model.WebhookData) error
+	GetShippingTracking(orderID uint) (*model.OrderFulfillmentResponse, error)
+	VerifyWebhook(providerCode, signature string, body []byte) error
+	UpdateShippingStatusFromWebhook(providerCode string, webhookData *model.WebhookData) error
 
 	// Statistics
 	GetShippingStats() (*model.ShippingStats, error)
@@ -49,18 +58,74 @@ type ShippingService interface {
 }
 
 type shippingService struct {
-	shippingRepo repository.ShippingRepository
-	orderRepo    repository.OrderRepository
-	ghtkClient   *shipping.GHTKClient
+	shippingRepo  repository.ShippingRepository
+	orderRepo     repository.OrderRepository
+	productRepo   *repository.ProductRepository
+	couponRepo    repository.CouponRepository
+	addressRepo   repository.AddressRepository
+	locations     LocationService
+	carriers      *shipping.Registry
+	fulfillment   FulfillmentService
+	uploadService *UploadService
+	labelFontPath string
+	labelPath     string
 }
 
 func NewShippingService(shippingRepo repository.ShippingRepository, orderRepo repository.OrderRepository, ghtkConfig shipping.GHTKConfig) ShippingService {
-	ghtkClient := shipping.NewGHTKClient(ghtkConfig)
+	return NewShippingServiceWithCarriers(shippingRepo, orderRepo, newCarrierRegistry(ghtkConfig))
+}
+
+// GHTKConfigFromApp returns the application's GHTK account configuration
+func GHTKConfigFromApp() shipping.GHTKConfig {
+	config := configs.Load().Shipping
+	return shipping.GHTKConfig{
+		BaseURL:       config.GHTKBaseURL,
+		Token:         config.GHTKToken,
+		ShopID:        config.GHTKShopID,
+		Timeout:       30,
+		WebhookSecret: config.GHTKWebhookSecret,
+	}
+}
+
+// newCarrierRegistry creates the carrier registry shared by shipping and tracking: a GHTK
+// provider's config is layered over the application config, so providers that only set a base
+// URL or timeout still get the token and shop from the environment, and the fake carrier is only
+// available when the environment allows it
+func newCarrierRegistry(ghtkConfig shipping.GHTKConfig) *shipping.Registry {
+	carriers := shipping.NewDefaultRegistry()
+	carriers.RegisterFactory(shipping.CarrierCodeGHTK, func(config []byte) (shipping.Carrier, error) {
+		merged := ghtkConfig
+		if len(config) > 0 {
+			if err := json.Unmarshal(config, &merged); err != nil {
+				return nil, fmt.Errorf("invalid GHTK config: %v", err)
+			}
+		}
+		if merged.Token == "" {
+			return nil, fmt.Errorf("GHTK token is required")
+		}
+		return shipping.NewGHTKCarrier(merged), nil
+	})
+	if config := configs.Load(); config.Shipping.FakeCarrierAllowed(config.GinMode) {
+		carriers.RegisterFactory(shipping.CarrierCodeFake, shipping.NewFakeCarrierFromConfig)
+	}
+	return carriers
+}
 
+// NewShippingServiceWithCarriers creates a ShippingService that resolves carriers from the given registry
+func NewShippingServiceWithCarriers(shippingRepo repository.ShippingRepository, orderRepo repository.OrderRepository, carriers *shipping.Registry) ShippingService {
+	uploadService := NewUploadService()
 	return &shippingService{
-		shippingRepo: shippingRepo,
-		orderRepo:    orderRepo,
-		ghtkClient:   ghtkClient,
+		shippingRepo:  shippingRepo,
+		orderRepo:     orderRepo,
+		productRepo:   repository.NewProductRepository(),
+		couponRepo:    repository.NewCouponRepository(),
+		addressRepo:   repository.NewAddressRepository(),
+		locations:     NewLocationService(),
+		carriers:      carriers,
+		fulfillment:   NewFulfillmentService(),
+		uploadService: uploadService,
+		labelFontPath: uploadService.config.Shipping.LabelFontPath,
+		labelPath:     uploadService.config.Shipping.LabelPath,
 	}
 }
 
@@ -128,6 +193,7 @@ func (s *shippingService) DeleteShippingProvider(id uint) error {
 
 // Shipping Rates
 func (s *shippingService) CreateShippingRate(req *model.ShippingRate) (*model.ShippingRate, error) {
+	s.canonicalRateZones(req)
 	if err := s.shippingRepo.CreateShippingRate(req); err != nil {
 		logger.Errorf("Failed to create shipping rate: %v", err)
 		return nil, fmt.Errorf("failed to create shipping rate")
@@ -155,6 +221,7 @@ func (s *shippingService) GetShippingRatesByProvider(providerID uint) ([]model.S
 
 func (s *shippingService) UpdateShippingRate(id uint, req *model.ShippingRate) (*model.ShippingRate, error) {
 	req.ID = id
+	s.canonicalRateZones(req)
 	if err := s.shippingRepo.UpdateShippingRate(req); err != nil {
 		logger.Errorf("Failed to update shipping rate %d: %v", id, err)
 		return nil, fmt.Errorf("failed to update shipping rate")
@@ -162,6 +229,12 @@ func (s *shippingService) UpdateShippingRate(id uint, req *model.ShippingRate) (
 	return req, nil
 }
 
+// canonicalRateZones stores rate zones under the directory's official names, as requests are matched
+func (s *shippingService) canonicalRateZones(rate *model.ShippingRate) {
+	rate.FromProvince, rate.FromDistrict = s.locations.CanonicalZone(rate.FromProvince, rate.FromDistrict)
+	rate.ToProvince, rate.ToDistrict = s.locations.CanonicalZone(rate.ToProvince, rate.ToDistrict)
+}
+
 func (s *shippingService) DeleteShippingRate(id uint) error {
 	if err := s.shippingRepo.DeleteShippingRate(id); err != nil {
 		logger.Errorf("Failed to delete shipping rate %d: %v", id, err)
@@ -172,80 +245,65 @@ func (s *shippingService) DeleteShippingRate(id uint) error {
 
 // Shipping Calculation
 func (s *shippingService) CalculateShipping(req *model.CalculateShippingRequest) ([]model.CalculateShippingResponse, error) {
-	// Get shipping rates for calculation
-	rates, err := s.shippingRepo.GetShippingRatesForCalculation(req)
+	pkg, err := s.buildShippingPackage(req)
 	if err != nil {
-		logger.Errorf("Failed to get shipping rates for calculation: %v", err)
+		return nil, err
+	}
+
+	coupon, err := s.freeShippingCoupon(req, pkg.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := s.shippingRepo.GetActiveShippingProviders()
+	if err != nil {
+		logger.Errorf("Failed to get active shipping providers: %v", err)
 		return nil, fmt.Errorf("failed to calculate shipping")
 	}
 
-	var responses []model.CalculateShippingResponse
-	for _, rate := range rates {
-		// Calculate fees
-		shippingFee := rate.BaseFee
-		if rate.WeightFee > 0 {
-			shippingFee += (req.Weight - rate.MinWeight) * rate.WeightFee
-		}
-		if rate.ValueFee > 0 {
-			shippingFee += (req.Value - rate.MinValue) * rate.ValueFee / 1000000 // Convert to VND
-		}
+	// Rates and carrier quotes are priced on the chargeable weight
+	priced := *req
+	priced.Weight = pkg.ChargeableWeight
+	priced.Value = pkg.Value
 
-		codFee := 0.0
-		if req.COD > 0 && rate.COD > 0 {
-			codFee = rate.COD
-		}
+	// Zones are matched on the directory's names, so "TP. HCM" finds rates for "Thành phố Hồ Chí Minh"
+	priced.FromProvince, priced.FromDistrict = s.locations.CanonicalZone(req.FromProvince, req.FromDistrict)
+	priced.ToProvince, priced.ToDistrict = s.locations.CanonicalZone(req.ToProvince, req.ToDistrict)
 
-		insuranceFee := 0.0
-		if req.Insurance > 0 && rate.Insurance > 0 {
-			insuranceFee = rate.Insurance
+	rates, err := s.shippingRepo.GetShippingRatesForCalculation(&priced)
+	if err != nil {
+		logger.Errorf("Failed to get shipping rates for calculation: %v", err)
+		return nil, fmt.Errorf("failed to calculate shipping")
+	}
+	zoneRates := matchZoneRates(rates, &priced)
+
+	var responses []model.CalculateShippingResponse
+	for i := range providers {
+		provider := &providers[i]
+		if req.ProviderID != nil && provider.ID != *req.ProviderID {
+			continue
 		}
 
-		totalFee := shippingFee + codFee + insuranceFee
-
-		response := model.CalculateShippingResponse{
-			ProviderID:   rate.ProviderID,
-			ProviderName: rate.Provider.DisplayName,
-			ProviderCode: rate.Provider.Code,
-			ShippingFee:  shippingFee,
-			COD:          codFee,
-			InsuranceFee: insuranceFee,
-			TotalFee:     totalFee,
-			MinDays:      rate.MinDays,
-			MaxDays:      rate.MaxDays,
-			IsAvailable:  true,
+		// Providers without a carrier integration are priced from the rate table
+		rate := zoneRates[provider.ID]
+		var response model.CalculateShippingResponse
+		if s.carriers.Supports(provider.Code) {
+			response = s.quoteCarrier(provider, &priced)
+		} else {
+			response = quoteTableRate(provider, rate, &priced, pkg)
 		}
 
+		checkProviderLimits(&response, provider, &priced, pkg)
+		applyFreeShipping(&response, rate, coupon, pkg)
+		finalizeQuote(&response, pkg)
 		responses = append(responses, response)
 	}
 
+	rankQuotes(responses)
 	return responses, nil
 }
 
 func (s *shippingService) CalculateShippingWithGHTK(req *model.CalculateShippingRequest) (*model.CalculateShippingResponse, error) {
-	// Convert to GHTK format
-	ghtkReq := &shipping.CalculateFeeRequest{
-		PickProvince: req.FromProvince,
-		PickDistrict: req.FromDistrict,
-		PickWard:     "", // Will be filled from address
-		Province:     req.ToProvince,
-		District:     req.ToDistrict,
-		Ward:         "", // Will be filled from address
-		Value:        int(req.Value),
-		Transport:    "road",                 // Default transport method
-		Weight:       int(req.Weight * 1000), // Convert kg to grams
-	}
-
-	// Call GHTK API
-	ghtkResp, err := s.ghtkClient.CalculateFee(ghtkReq)
-	if err != nil {
-		logger.Errorf("Failed to calculate GHTK shipping fee: %v", err)
-		return nil, fmt.Errorf("failed to calculate shipping fee")
-	}
-
-	if !ghtkResp.Success {
-		return nil, fmt.Errorf("GHTK API error: %s", ghtkResp.Message)
-	}
-
 	// Get GHTK provider
 	ghtkProvider, err := s.shippingRepo.GetShippingProviderByCode(model.ProviderCodeGHTK)
 	if err != nil {
@@ -253,20 +311,116 @@ func (s *shippingService) CalculateShippingWithGHTK(req *model.CalculateShipping
 		return nil, fmt.Errorf("GHTK provider not found")
 	}
 
-	response := &model.CalculateShippingResponse{
-		ProviderID:   ghtkProvider.ID,
-		ProviderName: ghtkProvider.DisplayName,
-		ProviderCode: ghtkProvider.Code,
-		ShippingFee:  float64(ghtkResp.Fee.Fee),
-		COD:          0, // GHTK handles COD separately
-		InsuranceFee: float64(ghtkResp.Fee.InsuranceFee),
-		TotalFee:     float64(ghtkResp.Fee.TotalFee),
-		MinDays:      1, // GHTK typically delivers in 1-2 days
-		MaxDays:      2,
-		IsAvailable:  true,
+	pkg, err := s.buildShippingPackage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := *req
+	priced.Weight = pkg.ChargeableWeight
+	priced.Value = pkg.Value
+
+	response := s.quoteCarrier(ghtkProvider, &priced)
+	if !response.IsAvailable {
+		return nil, fmt.Errorf("failed to calculate shipping fee: %s", response.Message)
+	}
+	finalizeQuote(&response, pkg)
+	response.Rank = 1
+
+	return &response, nil
+}
+
+// quoteCarrier asks a provider's carrier for a live quote
+func (s *shippingService) quoteCarrier(provider *model.ShippingProvider, req *model.CalculateShippingRequest) model.CalculateShippingResponse {
+	response := model.CalculateShippingResponse{
+		ProviderID:   provider.ID,
+		ProviderName: provider.DisplayName,
+		ProviderCode: provider.Code,
+		Source:       model.QuoteSourceCarrier,
+	}
+
+	carrier, err := s.carrierFor(provider)
+	if err != nil {
+		response.Message = err.Error()
+		return response
+	}
+
+	quote, err := carrier.Quote(&shipping.QuoteRequest{
+		From:      s.carrierAddress(provider.Code, shipping.Address{Province: req.FromProvince, District: req.FromDistrict}),
+		To:        s.carrierAddress(provider.Code, shipping.Address{Province: req.ToProvince, District: req.ToDistrict}),
+		Weight:    req.Weight,
+		Value:     req.Value,
+		COD:       req.COD,
+		Insurance: req.Insurance,
+	})
+	if err != nil {
+		logger.Warnf("Failed to get quote from carrier %s: %v", provider.Code, err)
+		response.Message = "carrier quote unavailable"
+		return response
+	}
+
+	response.ShippingFee = quote.Fee
+	response.COD = quote.CODFee
+	response.InsuranceFee = quote.InsuranceFee
+	response.TotalFee = quote.TotalFee
+	if quote.CODFee > 0 {
+		response.Surcharges = append(response.Surcharges, model.ShippingSurcharge{Code: "cod", Name: "Phí thu hộ", Amount: quote.CODFee})
+	}
+	if quote.InsuranceFee > 0 {
+		response.Surcharges = append(response.Surcharges, model.ShippingSurcharge{Code: "insurance", Name: "Phí bảo hiểm", Amount: quote.InsuranceFee})
 	}
+	response.MinDays = quote.MinDays
+	response.MaxDays = quote.MaxDays
+	response.IsAvailable = true
+	return response
+}
 
-	return response, nil
+// carrierFor resolves the carrier integration of a provider from its configuration
+func (s *shippingService) carrierFor(provider *model.ShippingProvider) (shipping.Carrier, error) {
+	carrier, err := s.carriers.Resolve(provider.Code, provider.Config)
+	if err != nil {
+		logger.Errorf("Failed to resolve carrier for provider %s: %v", provider.Code, err)
+		return nil, fmt.Errorf("carrier %s is not configured", provider.Code)
+	}
+	return carrier, nil
+}
+
+// carrierAddress translates the location names of an address to the names and IDs a carrier expects.
+// Names the directory cannot match are passed through unchanged.
+func (s *shippingService) carrierAddress(carrierCode string, address shipping.Address) shipping.Address {
+	resolved, err := s.locations.Resolve(address.Province, address.District, address.Ward)
+	if err != nil || resolved.ProvinceCode == "" {
+		return address
+	}
+
+	location, err := s.locations.GetCarrierLocation(carrierCode, resolved.ProvinceCode, resolved.DistrictCode, resolved.WardCode)
+	if err != nil {
+		logger.Warnf("Failed to map location for carrier %s: %v", carrierCode, err)
+		return address
+	}
+
+	address.Province, address.ProvinceID = location.ProvinceName, location.ProvinceID
+	if location.DistrictCode != "" {
+		address.District, address.DistrictID = location.DistrictName, location.DistrictID
+	}
+	if location.WardCode != "" {
+		address.Ward, address.WardID = location.WardName, location.WardID
+	}
+	return address
+}
+
+// orderDestination returns the saved address an order ships to, if it was placed with one
+func (s *shippingService) orderDestination(order *model.Order) *model.Address {
+	if order.ShippingAddressID == nil {
+		return nil
+	}
+
+	address, err := s.addressRepo.GetAddressByID(*order.ShippingAddressID)
+	if err != nil {
+		logger.Warnf("Failed to get shipping address %d of order %d: %v", *order.ShippingAddressID, order.ID, err)
+		return nil
+	}
+	return address
 }
 
 // Shipping Orders
@@ -277,6 +431,9 @@ func (s *shippingService) CreateShippingOrder(req *model.ShippingOrderRequest) (
 		logger.Errorf("Failed to get order %d: %v", req.OrderID, err)
 		return nil, fmt.Errorf("order not found")
 	}
+	if order == nil {
+		return nil, fmt.Errorf("order not found")
+	}
 
 	// Get provider
 	provider, err := s.shippingRepo.GetShippingProviderByID(req.ProviderID)
@@ -285,6 +442,15 @@ func (s *shippingService) CreateShippingOrder(req *model.ShippingOrderRequest) (
 		return nil, fmt.Errorf("shipping provider not found")
 	}
 
+	// Split shipments book only some items; by default the parcel holds everything left to ship
+	items := req.Items
+	if len(items) == 0 {
+		items = readyToShipItems(order)
+	}
+	if err := checkShipmentItems(order, items); err != nil {
+		return nil, err
+	}
+
 	// Create shipping order
 	shippingOrder := &model.ShippingOrder{
 		OrderID:     req.OrderID,
@@ -306,11 +472,15 @@ func (s *shippingService) CreateShippingOrder(req *model.ShippingOrderRequest) (
 	}
 
 	// Calculate fees
+	toProvince, toDistrict := "TP. Hồ Chí Minh", "Quận 1" // Used when the order has no saved address
+	if destination := s.orderDestination(order); destination != nil {
+		toProvince, toDistrict = destination.City, destination.District
+	}
 	calcReq := &model.CalculateShippingRequest{
 		FromProvince: "TP. Hồ Chí Minh", // Should be configurable
 		FromDistrict: "Quận 1",          // Should be configurable
-		ToProvince:   "TP. Hồ Chí Minh", // Should be extracted from address
-		ToDistrict:   "Quận 1",          // Should be extracted from address
+		ToProvince:   toProvince,
+		ToDistrict:   toDistrict,
 		Weight:       req.Weight,
 		Value:        req.Value,
 		ProviderID:   &req.ProviderID,
@@ -318,21 +488,15 @@ func (s *shippingService) CreateShippingOrder(req *model.ShippingOrderRequest) (
 		Insurance:    req.Insurance,
 	}
 
-	var calcResp *model.CalculateShippingResponse
-	if provider.Code == model.ProviderCodeGHTK {
-		calcResp, err = s.CalculateShippingWithGHTK(calcReq)
-	} else {
-		responses, err := s.CalculateShipping(calcReq)
-		if err != nil || len(responses) == 0 {
-			return nil, fmt.Errorf("failed to calculate shipping fee")
-		}
-		calcResp = &responses[0]
-	}
-
+	responses, err := s.CalculateShipping(calcReq)
 	if err != nil {
 		logger.Errorf("Failed to calculate shipping fee: %v", err)
 		return nil, fmt.Errorf("failed to calculate shipping fee")
 	}
+	if len(responses) == 0 || !responses[0].IsAvailable {
+		return nil, fmt.Errorf("failed to calculate shipping fee")
+	}
+	calcResp := &responses[0]
 
 	shippingOrder.ShippingFee = calcResp.ShippingFee
 	shippingOrder.COD = calcResp.COD
@@ -345,73 +509,116 @@ func (s *shippingService) CreateShippingOrder(req *model.ShippingOrderRequest) (
 		return nil, fmt.Errorf("failed to create shipping order")
 	}
 
-	// Create with external provider if needed
-	if provider.Code == model.ProviderCodeGHTK {
-		if err := s.createGHTKOrder(shippingOrder, order); err != nil {
-			logger.Errorf("Failed to create GHTK order: %v", err)
+	// Pack the items into the shipment before the carrier is booked
+	if _, err := s.fulfillment.CreateShipment(&model.ShipmentCreateRequest{
+		OrderID:         order.ID,
+		Carrier:         provider.DisplayName,
+		CarrierCode:     provider.Code,
+		ShippingOrderID: &shippingOrder.ID,
+		Warehouse:       req.Warehouse,
+		Items:           items,
+	}); err != nil {
+		shippingOrder.Status = model.ShippingOrderStatusCancelled
+		shippingOrder.StatusText = "Cancelled: items could not be packed"
+		if updateErr := s.shippingRepo.UpdateShippingOrder(shippingOrder); updateErr != nil {
+			logger.Errorf("Failed to cancel shipping order %d: %v", shippingOrder.ID, updateErr)
+		}
+		return nil, err
+	}
+
+	// Book with the carrier if the provider has an integration
+	if s.carriers.Supports(provider.Code) {
+		if err := s.createCarrierShipment(provider, shippingOrder, order, items); err != nil {
+			logger.Errorf("Failed to create %s shipment: %v", provider.Code, err)
 			// Don't fail the entire operation, just log the error
 		}
 	}
 
+	// Open the shipment on the order's fulfillment timeline
+	s.recordShipmentEvent(shippingOrder, provider, &model.ShipmentEvent{
+		Status:     trackingStatusFromCarrier(shippingOrder.Status),
+		StatusText: shippingOrder.StatusText,
+		EventCode:  shippingOrder.Status,
+		Source:     model.SourceAPI,
+	})
+
 	return s.toShippingOrderResponse(shippingOrder, provider), nil
 }
 
-func (s *shippingService) createGHTKOrder(shippingOrder *model.ShippingOrder, order *model.Order) error {
-	// Convert to GHTK format
-	ghtkReq := &shipping.CreateOrderRequest{
-		Products: []shipping.GHTKProduct{
-			{
-				Name:        "Order Items",                    // Should be detailed
-				Weight:      int(shippingOrder.Weight * 1000), // Convert to grams
-				Quantity:    1,                                // Should be calculated from order items
-				ProductCode: fmt.Sprintf("ORDER_%d", order.ID),
-				Price:       shippingOrder.Value,
-			},
-		},
-		Order: shipping.GHTKOrder{
-			ID:             fmt.Sprintf("ORDER_%d", order.ID),
-			PickName:       shippingOrder.FromName,
-			PickAddress:    shippingOrder.FromAddress,
-			PickProvince:   "TP. Hồ Chí Minh",  // Should be configurable
-			PickDistrict:   "Quận 1",           // Should be configurable
-			PickWard:       "Phường Bến Nghé",  // Should be configurable
-			PickStreet:     "123 Store Street", // Should be configurable
-			PickTel:        shippingOrder.FromPhone,
-			PickEmail:      shippingOrder.FromEmail,
-			Name:           shippingOrder.ToName,
-			Address:        shippingOrder.ToAddress,
-			Province:       "TP. Hồ Chí Minh",       // Should be extracted from address
-			District:       "Quận 1",                // Should be extracted from address
-			Ward:           "Phường Bến Nghé",       // Should be extracted from address
-			Street:         shippingOrder.ToAddress, // Should be extracted
-			Tel:            shippingOrder.ToPhone,
-			Email:          shippingOrder.ToEmail,
-			Note:           "E-commerce order",
-			Value:          int(shippingOrder.Value),
-			Transport:      "road",
-			PickOption:     "cod", // Cash on delivery
-			DeliverOption:  "cod",
-			PickSession:    2, // Afternoon
-			DeliverSession: 2, // Afternoon
-		},
-	}
-
-	// Call GHTK API
-	ghtkResp, err := s.ghtkClient.CreateOrder(ghtkReq)
-	if err != nil {
-		return fmt.Errorf("failed to create GHTK order: %v", err)
-	}
-
-	if !ghtkResp.Success {
-		return fmt.Errorf("GHTK API error: %s", ghtkResp.Message)
-	}
-
-	// Update shipping order with GHTK response
-	shippingOrder.ExternalID = ghtkResp.Order.PartnerID
-	shippingOrder.LabelID = ghtkResp.Order.LabelID
-	shippingOrder.TrackingCode = ghtkResp.Order.LabelID
-	shippingOrder.Status = model.ShippingOrderStatusCreated
-	shippingOrder.StatusText = "Created in GHTK"
+func (s *shippingService) createCarrierShipment(provider *model.ShippingProvider, shippingOrder *model.ShippingOrder, order *model.Order, parcel []model.ShipmentItemRequest) error {
+	carrier, err := s.carrierFor(provider)
+	if err != nil {
+		return err
+	}
+
+	// Only the items packed into this parcel are declared to the carrier
+	quantities := make(map[uint]int, len(parcel))
+	for _, item := range parcel {
+		quantities[item.OrderItemID] += item.Quantity
+	}
+	items := make([]shipping.ShipmentItem, 0, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		quantity := item.Quantity
+		if len(parcel) > 0 {
+			quantity = quantities[item.ID]
+		}
+		if quantity == 0 {
+			continue
+		}
+		items = append(items, shipping.ShipmentItem{
+			Name:     item.ProductName,
+			SKU:      item.ProductSKU,
+			Quantity: quantity,
+			Weight:   item.Weight,
+			Price:    item.UnitPrice,
+		})
+	}
+
+	to := shipping.Address{
+		Name:     shippingOrder.ToName,
+		Phone:    shippingOrder.ToPhone,
+		Email:    shippingOrder.ToEmail,
+		Address:  shippingOrder.ToAddress,
+		Province: "TP. Hồ Chí Minh",       // Used when the order has no saved address
+		District: "Quận 1",                // Used when the order has no saved address
+		Ward:     "Phường Bến Nghé",       // Used when the order has no saved address
+		Street:   shippingOrder.ToAddress, // Should be extracted
+	}
+	if destination := s.orderDestination(order); destination != nil {
+		to.Province, to.District, to.Ward = destination.City, destination.District, destination.Ward
+		to.Street = destination.AddressLine1
+	}
+
+	shipment, err := carrier.CreateShipment(&shipping.ShipmentRequest{
+		Reference: order.OrderNumber,
+		From: s.carrierAddress(provider.Code, shipping.Address{
+			Name:     shippingOrder.FromName,
+			Phone:    shippingOrder.FromPhone,
+			Email:    shippingOrder.FromEmail,
+			Address:  shippingOrder.FromAddress,
+			Province: "TP. Hồ Chí Minh",  // Should be configurable
+			District: "Quận 1",           // Should be configurable
+			Ward:     "Phường Bến Nghé",  // Should be configurable
+			Street:   "123 Store Street", // Should be configurable
+		}),
+		To:        s.carrierAddress(provider.Code, to),
+		Items:     items,
+		Weight:    shippingOrder.Weight,
+		Value:     shippingOrder.Value,
+		COD:       shippingOrder.COD,
+		Insurance: shippingOrder.Insurance,
+		Note:      "E-commerce order",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create shipment: %v", err)
+	}
+
+	// Update shipping order with carrier response
+	shippingOrder.ExternalID = shipment.ExternalID
+	shippingOrder.LabelID = shipment.LabelID
+	shippingOrder.TrackingCode = shipment.TrackingCode
+	shippingOrder.Status = shipment.Status
+	shippingOrder.StatusText = shipment.StatusText
 
 	// Update in database
 	if err := s.shippingRepo.UpdateShippingOrder(shippingOrder); err != nil {
@@ -498,21 +705,16 @@ func (s *shippingService) CancelShippingOrder(id uint, reason string) error {
 		return fmt.Errorf("provider not found")
 	}
 
-	// Cancel with external provider if needed
-	if provider.Code == model.ProviderCodeGHTK && shippingOrder.LabelID != "" {
-		ghtkReq := &shipping.CancelOrderRequest{
-			LabelID: shippingOrder.LabelID,
-			Note:    reason,
-		}
-
-		ghtkResp, err := s.ghtkClient.CancelOrder(ghtkReq)
+	// Cancel with the carrier if the shipment was booked there
+	if s.carriers.Supports(provider.Code) && shippingOrder.TrackingCode != "" {
+		carrier, err := s.carrierFor(provider)
 		if err != nil {
-			logger.Errorf("Failed to cancel GHTK order: %v", err)
-			return fmt.Errorf("failed to cancel order with provider")
+			return err
 		}
 
-		if !ghtkResp.Success {
-			return fmt.Errorf("provider API error: %s", ghtkResp.Message)
+		if err := carrier.CancelShipment(shippingOrder.TrackingCode, reason); err != nil {
+			logger.Errorf("Failed to cancel %s shipment %s: %v", provider.Code, shippingOrder.TrackingCode, err)
+			return fmt.Errorf("failed to cancel order with provider")
 		}
 	}
 
@@ -525,6 +727,14 @@ func (s *shippingService) CancelShippingOrder(id uint, reason string) error {
 		return fmt.Errorf("failed to update shipping order")
 	}
 
+	s.recordShipmentEvent(shippingOrder, provider, &model.ShipmentEvent{
+		Status:     model.TrackingStatusCancelled,
+		StatusText: "Cancelled",
+		Note:       reason,
+		EventCode:  model.ShippingOrderStatusCancelled,
+		Source:     model.SourceManual,
+	})
+
 	return nil
 }
 
@@ -548,17 +758,61 @@ func (s *shippingService) GetShippingOrders(page, limit int, filters map[string]
 	return responses, total, nil
 }
 
+func (s *shippingService) GetShippingLabel(id uint) ([]byte, error) {
+	shippingOrder, err := s.shippingRepo.GetShippingOrderByID(id)
+	if err != nil {
+		logger.Errorf("Failed to get shipping order %d: %v", id, err)
+		return nil, fmt.Errorf("shipping order not found")
+	}
+	if shippingOrder.TrackingCode == "" {
+		return nil, errors.New("shipping order has not been booked with the carrier")
+	}
+
+	provider, err := s.shippingRepo.GetShippingProviderByID(shippingOrder.ProviderID)
+	if err != nil {
+		logger.Errorf("Failed to get provider %d: %v", shippingOrder.ProviderID, err)
+		return nil, fmt.Errorf("provider not found")
+	}
+
+	carrier, err := s.carrierFor(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := carrier.Label(shippingOrder.TrackingCode)
+	if err != nil {
+		logger.Errorf("Failed to get label for shipment %s: %v", shippingOrder.TrackingCode, err)
+		return nil, fmt.Errorf("failed to get shipping label")
+	}
+	return label, nil
+}
+
 // Tracking
-func (s *shippingService) GetShippingTracking(orderID uint) ([]model.ShippingTracking, error) {
-	tracking, err := s.shippingRepo.GetShippingTrackingByOrderID(orderID)
+func (s *shippingService) GetShippingTracking(orderID uint) (*model.OrderFulfillmentResponse, error) {
+	return s.fulfillment.GetOrderFulfillment(orderID)
+}
+
+func (s *shippingService) VerifyWebhook(providerCode, signature string, body []byte) error {
+	provider, err := s.shippingRepo.GetShippingProviderByCode(providerCode)
+	if err != nil {
+		logger.Errorf("Failed to get shipping provider by code %s: %v", providerCode, err)
+		return fmt.Errorf("shipping provider not found")
+	}
+
+	carrier, err := s.carrierFor(provider)
 	if err != nil {
-		logger.Errorf("Failed to get shipping tracking for order %d: %v", orderID, err)
-		return nil, fmt.Errorf("failed to get shipping tracking")
+		return err
 	}
-	return tracking, nil
+
+	if !carrier.VerifyWebhook(signature, body) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
 }
 
-func (s *shippingService) UpdateShippingStatusFromWebhook(webhookData *model.WebhookData) error {
+// UpdateShippingStatusFromWebhook applies a verified webhook of the given provider. A carrier can
+// only update its own shipments: an order shipped with another provider is treated as unknown.
+func (s *shippingService) UpdateShippingStatusFromWebhook(providerCode string, webhookData *model.WebhookData) error {
 	// Find shipping order by label ID
 	shippingOrder, err := s.shippingRepo.GetShippingOrderByLabelID(webhookData.LabelID)
 	if err != nil {
@@ -566,51 +820,80 @@ func (s *shippingService) UpdateShippingStatusFromWebhook(webhookData *model.Web
 		return fmt.Errorf("shipping order not found")
 	}
 
-	// Update shipping order status
-	shippingOrder.Status = webhookData.Status
-	shippingOrder.StatusText = webhookData.StatusText
+	provider, err := s.shippingRepo.GetShippingProviderByID(shippingOrder.ProviderID)
+	if err != nil {
+		logger.Errorf("Failed to get provider %d: %v", shippingOrder.ProviderID, err)
+		return fmt.Errorf("provider not found")
+	}
+	if provider.Code != providerCode {
+		logger.Warnf("Rejected %s webhook for shipping order %d of provider %s", providerCode, shippingOrder.ID, provider.Code)
+		return fmt.Errorf("shipping order not found")
+	}
 
-	if webhookData.DeliverDate != "" {
-		if deliveredAt, err := time.Parse("2006-01-02 15:04:05", webhookData.DeliverDate); err == nil {
-			shippingOrder.DeliveredAt = &deliveredAt
+	shipment, err := s.fulfillment.ShipmentForShippingOrder(shippingOrder, provider)
+	if err != nil {
+		return err
+	}
+
+	// Replay the carrier timeline; events already on the shipment are skipped
+	for _, step := range webhookData.Timeline {
+		event := &model.ShipmentEvent{
+			Status:     trackingStatusFromCarrier(webhookCarrierStatus(provider.Code, step.Status)),
+			StatusText: step.StatusText,
+			Location:   step.Location,
+			Note:       step.Note,
+			EventCode:  step.Status,
+			Source:     model.SourceWebhook,
+		}
+		if eventTime, err := time.Parse("2006-01-02 15:04:05", step.Time); err == nil {
+			event.EventTime = eventTime
+		}
+		if _, err := s.fulfillment.RecordEvent(shipment, event); err != nil {
+			return fmt.Errorf("failed to update shipping order")
 		}
 	}
 
-	if err := s.shippingRepo.UpdateShippingOrder(shippingOrder); err != nil {
-		logger.Errorf("Failed to update shipping order: %v", err)
+	// Record the current status reported by the webhook, unless it closed the timeline
+	if n := len(webhookData.Timeline); n > 0 && webhookData.Timeline[n-1].Status == webhookData.Status {
+		return nil
+	}
+	event := &model.ShipmentEvent{
+		Status:     trackingStatusFromCarrier(webhookCarrierStatus(provider.Code, webhookData.Status)),
+		StatusText: webhookData.StatusText,
+		EventCode:  webhookData.Status,
+		Source:     model.SourceWebhook,
+	}
+	for _, value := range []string{webhookData.DeliverDate, webhookData.Updated} {
+		if eventTime, err := time.Parse("2006-01-02 15:04:05", value); err == nil && value != "" {
+			event.EventTime = eventTime
+			break
+		}
+	}
+	if _, err := s.fulfillment.RecordEvent(shipment, event); err != nil {
 		return fmt.Errorf("failed to update shipping order")
 	}
 
-	// Create tracking entry
-	tracking := &model.ShippingTracking{
-		ShippingOrderID: shippingOrder.ID,
-		Status:          webhookData.Status,
-		StatusText:      webhookData.StatusText,
-		Location:        "", // Will be extracted from timeline
-		Note:            "Status updated from webhook",
-	}
+	return nil
+}
 
-	if err := s.shippingRepo.CreateShippingTracking(tracking); err != nil {
-		logger.Errorf("Failed to create shipping tracking: %v", err)
-		// Don't fail the entire operation
+// recordShipmentEvent adds an event to the timeline of a shipping order's shipment
+func (s *shippingService) recordShipmentEvent(shippingOrder *model.ShippingOrder, provider *model.ShippingProvider, event *model.ShipmentEvent) {
+	shipment, err := s.fulfillment.ShipmentForShippingOrder(shippingOrder, provider)
+	if err != nil {
+		logger.Errorf("Failed to get shipment for shipping order %d: %v", shippingOrder.ID, err)
+		return
 	}
-
-	// Update order status if needed
-	if webhookData.Status == model.ShippingOrderStatusDelivered {
-		// Get order and update status
-		order, err := s.orderRepo.GetOrderByID(shippingOrder.OrderID)
-		if err == nil && order != nil {
-			order.Status = model.OrderStatusDelivered
-			order.ShippingStatus = model.ShippingStatusDelivered
-			now := time.Now()
-			order.DeliveredAt = &now
-			if err := s.orderRepo.UpdateOrder(order); err != nil {
-				logger.Errorf("Failed to update order status: %v", err)
-			}
-		}
+	if _, err := s.fulfillment.RecordEvent(shipment, event); err != nil {
+		logger.Errorf("Failed to record event for shipping order %d: %v", shippingOrder.ID, err)
 	}
+}
 
-	return nil
+// webhookCarrierStatus normalizes the status sent in a carrier's webhook
+func webhookCarrierStatus(providerCode, status string) string {
+	if providerCode == shipping.CarrierCodeGHTK {
+		return shipping.GHTKStatus(status)
+	}
+	return status
 }
 
 // Statistics
@@ -639,5 +922,8 @@ func (s *shippingService) toShippingOrderResponse(order *model.ShippingOrder, pr
 		CreatedAt:    order.CreatedAt,
 		ShippedAt:    order.ShippedAt,
 		DeliveredAt:  order.DeliveredAt,
+
+		COD:           order.COD,
+		CODRemittedAt: order.CODRemittedAt,
 	}
 }
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go_app/configs"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/database"
+	"go_app/pkg/logger"
+)
+
+const dashboardOldestExceptions = 20
+
+// shippingExceptionTypes lists the SLA checks in the order they are evaluated
+var shippingExceptionTypes = []string{
+	model.ShippingExceptionNotPickedUp,
+	model.ShippingExceptionTransitDelayed,
+	model.ShippingExceptionDeliveryFailures,
+}
+
+// ShippingSLAService defines methods for shipping SLA monitoring
+type ShippingSLAService interface {
+	RunMonitor() (*model.ShippingSLAResult, error)
+	GetExceptions(page, limit int, filters map[string]interface{}) ([]model.ShippingException, int64, error)
+	GetExceptionByID(id uint) (*model.ShippingException, error)
+	UpdateException(id uint, req *model.ShippingExceptionUpdateRequest, userID uint) (*model.ShippingException, error)
+	GetDashboard() (*model.ShippingExceptionDashboard, error)
+}
+
+// shippingSLAService implements ShippingSLAService
+type shippingSLAService struct {
+	exceptionRepo      repository.ShippingExceptionRepository
+	shippingRepo       repository.ShippingRepository
+	eventService       EventService
+	pickupWindow       time.Duration
+	transitGrace       time.Duration
+	maxFailedAttempts  int
+	defaultTransitDays int
+}
+
+// NewShippingSLAService creates a new ShippingSLAService
+func NewShippingSLAService() ShippingSLAService {
+	return NewShippingSLAServiceWithEvent(NewEventService(NewNotificationService(
+		repository.NewNotificationRepository(),
+		repository.NewUserRepository(),
+	), nil, nil))
+}
+
+// NewShippingSLAServiceWithEvent creates a new ShippingSLAService that raises alerts through the given event service
+func NewShippingSLAServiceWithEvent(eventService EventService) ShippingSLAService {
+	config := configs.Load().Shipping
+
+	return &shippingSLAService{
+		exceptionRepo:      repository.NewShippingExceptionRepository(),
+		shippingRepo:       repository.NewShippingRepository(database.GetDB()),
+		eventService:       eventService,
+		pickupWindow:       time.Duration(max(config.SLAPickupHours, 1)) * time.Hour,
+		transitGrace:       time.Duration(max(config.SLATransitGraceHours, 0)) * time.Hour,
+		maxFailedAttempts:  max(config.SLAMaxFailedAttempts, 1),
+		defaultTransitDays: max(config.SLADefaultTransitDays, 1),
+	}
+}
+
+// slaRun caches carrier lookups during one monitor run
+type slaRun struct {
+	now         time.Time
+	providers   map[string]*model.ShippingProvider // By carrier code
+	transitDays map[uint]int                       // By provider ID
+	result      *model.ShippingSLAResult
+}
+
+// RunMonitor checks every shipment on the way against the SLA, raising an exception and an
+// admin alert for each new breach and closing exceptions whose shipment has recovered
+func (s *shippingSLAService) RunMonitor() (*model.ShippingSLAResult, error) {
+	shipments, err := s.exceptionRepo.GetShipmentsForSLA()
+	if err != nil {
+		logger.Errorf("Failed to load shipments for SLA monitoring: %v", err)
+		return nil, fmt.Errorf("failed to load shipments")
+	}
+
+	run := &slaRun{
+		now:         time.Now(),
+		providers:   make(map[string]*model.ShippingProvider),
+		transitDays: make(map[uint]int),
+		result:      &model.ShippingSLAResult{},
+	}
+	if len(shipments) == 0 {
+		return run.result, nil
+	}
+
+	ids := make([]uint, len(shipments))
+	for i := range shipments {
+		ids[i] = shipments[i].ID
+	}
+
+	failedAttempts, err := s.exceptionRepo.GetFailedAttempts(ids)
+	if err != nil {
+		logger.Errorf("Failed to count failed delivery attempts: %v", err)
+		return nil, fmt.Errorf("failed to count failed delivery attempts")
+	}
+
+	exceptions, err := s.exceptionRepo.GetUnresolvedExceptions(ids)
+	if err != nil {
+		logger.Errorf("Failed to load shipping exceptions: %v", err)
+		return nil, fmt.Errorf("failed to load shipping exceptions")
+	}
+	unresolved := make(map[uint]map[string]*model.ShippingException)
+	for i := range exceptions {
+		exception := &exceptions[i]
+		if unresolved[exception.ShipmentID] == nil {
+			unresolved[exception.ShipmentID] = make(map[string]*model.ShippingException)
+		}
+		unresolved[exception.ShipmentID][exception.Type] = exception
+	}
+
+	for i := range shipments {
+		shipment := &shipments[i]
+		run.result.Checked++
+		if err := s.checkShipment(run, shipment, failedAttempts[shipment.ID], unresolved[shipment.ID]); err != nil {
+			logger.Errorf("Failed to check SLA of shipment %d: %v", shipment.ID, err)
+			run.result.Failed++
+		}
+	}
+
+	return run.result, nil
+}
+
+// checkShipment evaluates one shipment and reconciles its exceptions with the breaches found
+func (s *shippingSLAService) checkShipment(run *slaRun, shipment *model.Shipment, failedAttempts int, current map[string]*model.ShippingException) error {
+	if shipment.DeliveryDue == nil && shipment.ShippedAt != nil {
+		due := s.deliveryDue(run, shipment)
+		if err := s.exceptionRepo.SetShipmentDeliveryDue(shipment.ID, due); err != nil {
+			return err
+		}
+		shipment.DeliveryDue = &due
+	}
+
+	breaches := s.findBreaches(run, shipment, failedAttempts)
+	for _, exceptionType := range shippingExceptionTypes {
+		breach := breaches[exceptionType]
+		exception := current[exceptionType]
+
+		switch {
+		case breach != nil && exception == nil:
+			if err := s.exceptionRepo.CreateException(breach); err != nil {
+				return err
+			}
+			run.result.Opened++
+			if err := s.eventService.OnShippingException(breach); err != nil {
+				logger.Warnf("Failed to send shipping exception alert %d: %v", breach.ID, err)
+			}
+
+		case breach != nil:
+			exception.Message = breach.Message
+			exception.DueAt = breach.DueAt
+			exception.FailedAttempts = breach.FailedAttempts
+			exception.LastCheckedAt = run.now
+			if err := s.exceptionRepo.UpdateException(exception); err != nil {
+				return err
+			}
+
+		case exception != nil:
+			resolvedAt := run.now
+			exception.Status = model.ShippingExceptionStatusResolved
+			exception.ResolvedAt = &resolvedAt
+			exception.LastCheckedAt = run.now
+			if err := s.exceptionRepo.UpdateException(exception); err != nil {
+				return err
+			}
+			run.result.Resolved++
+		}
+	}
+
+	return nil
+}
+
+// findBreaches returns a new exception for every SLA the shipment currently breaches
+func (s *shippingSLAService) findBreaches(run *slaRun, shipment *model.Shipment, failedAttempts int) map[string]*model.ShippingException {
+	breaches := make(map[string]*model.ShippingException)
+	if shipment.IsFinished() {
+		return breaches
+	}
+
+	label := shipment.TrackingNumber
+	if label == "" {
+		label = fmt.Sprintf("#%d", shipment.ID)
+	}
+
+	if shipment.ShippedAt == nil {
+		pickupDue := shipment.CreatedAt.Add(s.pickupWindow)
+		if run.now.After(pickupDue) {
+			exception := s.newException(run, shipment, model.ShippingExceptionNotPickedUp)
+			exception.DueAt = &pickupDue
+			exception.Message = fmt.Sprintf("Shipment %s of order %d has not been picked up by %s after %.0f hours",
+				label, shipment.OrderID, carrierName(shipment), run.now.Sub(shipment.CreatedAt).Hours())
+			breaches[exception.Type] = exception
+		}
+	} else if shipment.DeliveryDue != nil && run.now.After(shipment.DeliveryDue.Add(s.transitGrace)) {
+		due := *shipment.DeliveryDue
+		exception := s.newException(run, shipment, model.ShippingExceptionTransitDelayed)
+		exception.DueAt = &due
+		exception.Message = fmt.Sprintf("Shipment %s of order %d with %s is %.0f hours past its estimated delivery",
+			label, shipment.OrderID, carrierName(shipment), run.now.Sub(due).Hours())
+		breaches[exception.Type] = exception
+	}
+
+	if failedAttempts >= s.maxFailedAttempts {
+		exception := s.newException(run, shipment, model.ShippingExceptionDeliveryFailures)
+		exception.FailedAttempts = failedAttempts
+		exception.Message = fmt.Sprintf("Delivery of shipment %s of order %d with %s failed %d times",
+			label, shipment.OrderID, carrierName(shipment), failedAttempts)
+		breaches[exception.Type] = exception
+	}
+
+	return breaches
+}
+
+// newException builds an open exception of the given type for a shipment
+func (s *shippingSLAService) newException(run *slaRun, shipment *model.Shipment, exceptionType string) *model.ShippingException {
+	exception := &model.ShippingException{
+		OrderID:        shipment.OrderID,
+		ShipmentID:     shipment.ID,
+		Carrier:        shipment.Carrier,
+		CarrierCode:    shipment.CarrierCode,
+		TrackingNumber: shipment.TrackingNumber,
+		Type:           exceptionType,
+		Status:         model.ShippingExceptionStatusOpen,
+		DetectedAt:     run.now,
+		LastCheckedAt:  run.now,
+	}
+	if provider := s.provider(run, shipment); provider != nil {
+		exception.ProviderID = &provider.ID
+	}
+	return exception
+}
+
+// deliveryDue works out when a dispatched shipment should be delivered: the carrier's estimate when
+// tracking provides one, otherwise the dispatch date plus the carrier's longest rate transit time
+func (s *shippingSLAService) deliveryDue(run *slaRun, shipment *model.Shipment) time.Time {
+	if shipment.OrderTracking != nil && shipment.OrderTracking.EstimatedDelivery != nil {
+		return *shipment.OrderTracking.EstimatedDelivery
+	}
+
+	days := s.defaultTransitDays
+	if provider := s.provider(run, shipment); provider != nil {
+		days = s.providerTransitDays(run, provider.ID)
+	}
+	return shipment.ShippedAt.AddDate(0, 0, days)
+}
+
+// provider resolves the carrier of a shipment from its shipping order or carrier code
+func (s *shippingSLAService) provider(run *slaRun, shipment *model.Shipment) *model.ShippingProvider {
+	if shipment.ShippingOrder != nil && shipment.ShippingOrder.ProviderID != 0 {
+		return &model.ShippingProvider{ID: shipment.ShippingOrder.ProviderID}
+	}
+	if shipment.CarrierCode == "" {
+		return nil
+	}
+
+	provider, cached := run.providers[shipment.CarrierCode]
+	if !cached {
+		provider, _ = s.shippingRepo.GetShippingProviderByCode(shipment.CarrierCode)
+		run.providers[shipment.CarrierCode] = provider
+	}
+	return provider
+}
+
+// providerTransitDays returns the longest transit time among a carrier's active rates
+func (s *shippingSLAService) providerTransitDays(run *slaRun, providerID uint) int {
+	if days, ok := run.transitDays[providerID]; ok {
+		return days
+	}
+
+	days := 0
+	rates, err := s.shippingRepo.GetShippingRatesByProvider(providerID)
+	if err != nil {
+		logger.Warnf("Failed to load shipping rates of provider %d: %v", providerID, err)
+	}
+	for _, rate := range rates {
+		if rate.IsActive && rate.MaxDays > days {
+			days = rate.MaxDays
+		}
+	}
+	if days == 0 {
+		days = s.defaultTransitDays
+	}
+
+	run.transitDays[providerID] = days
+	return days
+}
+
+// carrierName returns a shipment's carrier for alert messages
+func carrierName(shipment *model.Shipment) string {
+	if shipment.Carrier != "" {
+		return shipment.Carrier
+	}
+	if shipment.CarrierCode != "" {
+		return shipment.CarrierCode
+	}
+	return "the carrier"
+}
+
+// GetExceptions retrieves shipping exceptions with pagination and filters
+func (s *shippingSLAService) GetExceptions(page, limit int, filters map[string]interface{}) ([]model.ShippingException, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	exceptions, total, err := s.exceptionRepo.GetExceptions(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Failed to get shipping exceptions: %v", err)
+		return nil, 0, fmt.Errorf("failed to get shipping exceptions")
+	}
+	return exceptions, total, nil
+}
+
+// GetExceptionByID retrieves a shipping exception by ID
+func (s *shippingSLAService) GetExceptionByID(id uint) (*model.ShippingException, error) {
+	exception, err := s.exceptionRepo.GetExceptionByID(id)
+	if err != nil {
+		logger.Errorf("Failed to get shipping exception %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get shipping exception")
+	}
+	if exception == nil {
+		return nil, errors.New("shipping exception not found")
+	}
+	return exception, nil
+}
+
+// UpdateException acknowledges or resolves a shipping exception
+func (s *shippingSLAService) UpdateException(id uint, req *model.ShippingExceptionUpdateRequest, userID uint) (*model.ShippingException, error) {
+	exception, err := s.GetExceptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if exception.IsResolved() {
+		return nil, errors.New("shipping exception is already resolved")
+	}
+
+	now := time.Now()
+	switch req.Status {
+	case model.ShippingExceptionStatusAcknowledged:
+		if exception.Status == model.ShippingExceptionStatusAcknowledged {
+			return nil, errors.New("shipping exception is already acknowledged")
+		}
+		exception.AcknowledgedBy = &userID
+		exception.AcknowledgedAt = &now
+	case model.ShippingExceptionStatusResolved:
+		exception.ResolvedBy = &userID
+		exception.ResolvedAt = &now
+	}
+	exception.Status = req.Status
+	if req.Note != "" {
+		exception.Note = req.Note
+	}
+
+	if err := s.exceptionRepo.UpdateException(exception); err != nil {
+		logger.Errorf("Failed to update shipping exception %d: %v", id, err)
+		return nil, fmt.Errorf("failed to update shipping exception")
+	}
+	return exception, nil
+}
+
+// GetDashboard summarizes the unresolved shipping exceptions by status, type and carrier
+func (s *shippingSLAService) GetDashboard() (*model.ShippingExceptionDashboard, error) {
+	byStatus, err := s.exceptionRepo.CountUnresolvedByStatus()
+	if err != nil {
+		logger.Errorf("Failed to count shipping exceptions: %v", err)
+		return nil, fmt.Errorf("failed to get shipping exception dashboard")
+	}
+
+	byType, err := s.exceptionRepo.CountUnresolvedByType()
+	if err != nil {
+		logger.Errorf("Failed to count shipping exceptions by type: %v", err)
+		return nil, fmt.Errorf("failed to get shipping exception dashboard")
+	}
+
+	byCarrier, err := s.exceptionRepo.CountUnresolvedByCarrier()
+	if err != nil {
+		logger.Errorf("Failed to count shipping exceptions by carrier: %v", err)
+		return nil, fmt.Errorf("failed to get shipping exception dashboard")
+	}
+
+	oldest, err := s.exceptionRepo.GetOldestUnresolved(dashboardOldestExceptions)
+	if err != nil {
+		logger.Errorf("Failed to get oldest shipping exceptions: %v", err)
+		return nil, fmt.Errorf("failed to get shipping exception dashboard")
+	}
+
+	return &model.ShippingExceptionDashboard{
+		Open:         byStatus[model.ShippingExceptionStatusOpen],
+		Acknowledged: byStatus[model.ShippingExceptionStatusAcknowledged],
+		ByType:       byType,
+		ByCarrier:    byCarrier,
+		Oldest:       oldest,
+	}, nil
+}
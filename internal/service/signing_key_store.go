@@ -0,0 +1,51 @@
+package service
+
+import (
+	"time"
+
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/jwt"
+)
+
+// signingKeyStore stores the JWT signing keys of pkg/jwt in the jwt_signing_keys table
+type signingKeyStore struct {
+	repo repository.SigningKeyRepository
+}
+
+// NewSigningKeyStore creates the database-backed key store passed to jwt.SetKeyStore
+func NewSigningKeyStore() jwt.KeyStore {
+	return &signingKeyStore{repo: repository.NewSigningKeyRepository()}
+}
+
+func (s *signingKeyStore) GetPublishedKeys() ([]*jwt.StoredKey, error) {
+	records, err := s.repo.GetPublishedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*jwt.StoredKey, len(records))
+	for i, record := range records {
+		keys[i] = &jwt.StoredKey{
+			KID:        record.KID,
+			Algorithm:  record.Algorithm,
+			PublicKey:  record.PublicKey,
+			PrivateKey: record.PrivateKey,
+			Active:     record.Status == model.SigningKeyStatusActive,
+		}
+	}
+	return keys, nil
+}
+
+func (s *signingKeyStore) RotateActiveKey(next *jwt.StoredKey, dueBefore, retireUntil time.Time, force bool) (bool, error) {
+	return s.repo.RotateActiveKey(&model.SigningKey{
+		KID:        next.KID,
+		Algorithm:  next.Algorithm,
+		PublicKey:  next.PublicKey,
+		PrivateKey: next.PrivateKey,
+	}, dueBefore, retireUntil, force)
+}
+
+func (s *signingKeyStore) DeleteExpired() (int64, error) {
+	return s.repo.DeleteExpired()
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go_app/internal/model"
+	"go_app/internal/repository"
+	"go_app/pkg/logger"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StocktakeService defines methods for stocktake (cycle count) business logic
+type StocktakeService interface {
+	CreateStocktake(req *model.StocktakeCreateRequest, userID uint) (*model.StocktakeResponse, error)
+	GetStocktakeByID(id uint) (*model.StocktakeResponse, error)
+	GetStocktakes(page, limit int, filters map[string]interface{}) ([]model.StocktakeResponse, int64, error)
+	StartStocktake(id uint) (*model.StocktakeResponse, error)
+	SubmitCounts(id uint, req *model.StocktakeCountRequest, userID uint) (*model.StocktakeCountResult, error)
+	ImportCountsCSV(id uint, reader io.Reader, userID uint) (*model.StocktakeCountResult, error)
+	SubmitForReview(id uint) (*model.StocktakeResponse, error)
+	GetVarianceReport(id uint) (*model.StocktakeVarianceReport, error)
+	ApproveStocktake(id uint, req *model.StocktakeApproveRequest, userID uint) (*model.StocktakeResponse, error)
+	CancelStocktake(id uint) (*model.StocktakeResponse, error)
+}
+
+// stocktakeService implements StocktakeService
+type stocktakeService struct {
+	stocktakeRepo repository.StocktakeRepository
+	categoryRepo  *repository.CategoryRepository
+}
+
+// NewStocktakeService creates a new StocktakeService
+func NewStocktakeService() StocktakeService {
+	return &stocktakeService{
+		stocktakeRepo: repository.NewStocktakeRepository(),
+		categoryRepo:  repository.NewCategoryRepository(),
+	}
+}
+
+// CreateStocktake creates a new draft stocktake session
+func (s *stocktakeService) CreateStocktake(req *model.StocktakeCreateRequest, userID uint) (*model.StocktakeResponse, error) {
+	if req.CategoryID == nil && req.Location == "" {
+		return nil, errors.New("stocktake must be scoped to a category or a location")
+	}
+
+	if req.CategoryID != nil {
+		if _, err := s.categoryRepo.GetByID(*req.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := s.generateCode()
+	if err != nil {
+		logger.Errorf("Error generating stocktake code: %v", err)
+		return nil, fmt.Errorf("failed to create stocktake")
+	}
+
+	stocktake := &model.Stocktake{
+		Code:       code,
+		Name:       req.Name,
+		CategoryID: req.CategoryID,
+		Location:   req.Location,
+		Status:     model.StocktakeStatusDraft,
+		Notes:      req.Notes,
+		CreatedBy:  userID,
+	}
+
+	if err := s.stocktakeRepo.CreateStocktake(stocktake); err != nil {
+		logger.Errorf("Error creating stocktake: %v", err)
+		return nil, fmt.Errorf("failed to create stocktake")
+	}
+
+	return s.GetStocktakeByID(stocktake.ID)
+}
+
+// GetStocktakeByID retrieves a stocktake session with its items
+func (s *stocktakeService) GetStocktakeByID(id uint) (*model.StocktakeResponse, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toStocktakeResponse(stocktake, true), nil
+}
+
+// GetStocktakes retrieves stocktake sessions with pagination and filters
+func (s *stocktakeService) GetStocktakes(page, limit int, filters map[string]interface{}) ([]model.StocktakeResponse, int64, error) {
+	stocktakes, total, err := s.stocktakeRepo.GetStocktakes(page, limit, filters)
+	if err != nil {
+		logger.Errorf("Error getting stocktakes: %v", err)
+		return nil, 0, fmt.Errorf("failed to retrieve stocktakes")
+	}
+
+	var responses []model.StocktakeResponse
+	for i := range stocktakes {
+		responses = append(responses, *s.toStocktakeResponse(&stocktakes[i], false))
+	}
+	return responses, total, nil
+}
+
+// StartStocktake freezes the expected quantities for every SKU in scope and opens counting
+func (s *stocktakeService) StartStocktake(id uint) (*model.StocktakeResponse, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stocktake.Status != model.StocktakeStatusDraft {
+		return nil, errors.New("only draft stocktakes can be started")
+	}
+
+	stockLevels, err := s.stocktakeRepo.GetStockLevelsForScope(stocktake.CategoryID, stocktake.Location)
+	if err != nil {
+		logger.Errorf("Error getting stock levels for stocktake %d: %v", id, err)
+		return nil, fmt.Errorf("failed to retrieve stock levels")
+	}
+	if len(stockLevels) == 0 {
+		return nil, errors.New("no stock levels found for the stocktake scope")
+	}
+
+	items := make([]model.StocktakeItem, 0, len(stockLevels))
+	for _, stockLevel := range stockLevels {
+		items = append(items, model.StocktakeItem{
+			StocktakeID:      stocktake.ID,
+			ProductID:        stockLevel.ProductID,
+			VariantID:        stockLevel.VariantID,
+			SKU:              stockLevelSKU(&stockLevel),
+			ExpectedQuantity: stockLevel.AvailableQuantity + stockLevel.ReservedQuantity, // Reserved units are still on the shelf
+		})
+	}
+
+	if err := s.stocktakeRepo.FreezeStocktake(stocktake, items); err != nil {
+		logger.Errorf("Error freezing stocktake %d: %v", id, err)
+		return nil, fmt.Errorf("failed to start stocktake")
+	}
+
+	return s.GetStocktakeByID(id)
+}
+
+// SubmitCounts records counted quantities by SKU
+func (s *stocktakeService) SubmitCounts(id uint, req *model.StocktakeCountRequest, userID uint) (*model.StocktakeCountResult, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stocktake.Status != model.StocktakeStatusCounting {
+		return nil, errors.New("counts can only be submitted while the stocktake is counting")
+	}
+
+	itemsBySKU := make(map[string]*model.StocktakeItem, len(stocktake.Items))
+	for i := range stocktake.Items {
+		itemsBySKU[strings.ToUpper(stocktake.Items[i].SKU)] = &stocktake.Items[i]
+	}
+
+	result := &model.StocktakeCountResult{}
+	now := time.Now()
+	var updated []model.StocktakeItem
+	for _, line := range req.Counts {
+		if line.Quantity < 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: quantity must not be negative", line.SKU))
+			continue
+		}
+
+		item, ok := itemsBySKU[strings.ToUpper(strings.TrimSpace(line.SKU))]
+		if !ok {
+			result.UnknownSKUs = append(result.UnknownSKUs, line.SKU)
+			continue
+		}
+
+		quantity := line.Quantity
+		item.CountedQuantity = &quantity
+		item.Variance = quantity - item.ExpectedQuantity
+		item.CountedBy = &userID
+		item.CountedAt = &now
+		if line.Notes != "" {
+			item.Notes = line.Notes
+		}
+		updated = append(updated, *item)
+	}
+
+	if len(updated) > 0 {
+		if err := s.stocktakeRepo.UpdateItems(updated); err != nil {
+			logger.Errorf("Error updating counts for stocktake %d: %v", id, err)
+			return nil, fmt.Errorf("failed to save counts")
+		}
+	}
+
+	result.Updated = len(updated)
+	return result, nil
+}
+
+// ImportCountsCSV records counted quantities from a CSV file with columns sku,quantity[,notes]
+func (s *stocktakeService) ImportCountsCSV(id uint, reader io.Reader, userID uint) (*model.StocktakeCountResult, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %v", err)
+	}
+
+	req := &model.StocktakeCountRequest{}
+	var parseErrors []string
+	for i, record := range records {
+		if len(record) < 2 {
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: expected at least sku and quantity columns", i+1))
+			continue
+		}
+
+		sku := strings.TrimSpace(record[0])
+		quantity, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			// Skip a header row such as "sku,quantity"
+			if i == 0 {
+				continue
+			}
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: invalid quantity %q", i+1, record[1]))
+			continue
+		}
+		if sku == "" {
+			parseErrors = append(parseErrors, fmt.Sprintf("line %d: missing SKU", i+1))
+			continue
+		}
+
+		line := model.StocktakeCountLine{SKU: sku, Quantity: quantity}
+		if len(record) > 2 {
+			line.Notes = strings.TrimSpace(record[2])
+		}
+		req.Counts = append(req.Counts, line)
+	}
+
+	if len(req.Counts) == 0 {
+		if len(parseErrors) > 0 {
+			return nil, fmt.Errorf("no valid rows in CSV file: %s", strings.Join(parseErrors, "; "))
+		}
+		return nil, errors.New("CSV file contains no counts")
+	}
+
+	result, err := s.SubmitCounts(id, req, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.Errors = append(parseErrors, result.Errors...)
+	return result, nil
+}
+
+// SubmitForReview closes counting and moves the stocktake into variance review
+func (s *stocktakeService) SubmitForReview(id uint) (*model.StocktakeResponse, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stocktake.Status != model.StocktakeStatusCounting {
+		return nil, errors.New("only counting stocktakes can be submitted for review")
+	}
+
+	counted := 0
+	for _, item := range stocktake.Items {
+		if item.IsCounted() {
+			counted++
+		}
+	}
+	if counted == 0 {
+		return nil, errors.New("no items have been counted")
+	}
+
+	now := time.Now()
+	stocktake.Status = model.StocktakeStatusReview
+	stocktake.SubmittedAt = &now
+	if err := s.stocktakeRepo.UpdateStocktake(stocktake); err != nil {
+		logger.Errorf("Error submitting stocktake %d for review: %v", id, err)
+		return nil, fmt.Errorf("failed to submit stocktake")
+	}
+
+	return s.toStocktakeResponse(stocktake, true), nil
+}
+
+// GetVarianceReport summarizes the counted variances of a stocktake
+func (s *stocktakeService) GetVarianceReport(id uint) (*model.StocktakeVarianceReport, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.StocktakeVarianceReport{
+		StocktakeID: stocktake.ID,
+		Code:        stocktake.Code,
+		Status:      stocktake.Status,
+		TotalItems:  len(stocktake.Items),
+		Items:       []model.StocktakeItemResponse{},
+	}
+
+	for i := range stocktake.Items {
+		item := &stocktake.Items[i]
+		if !item.IsCounted() {
+			report.UncountedItems++
+			report.UncountedSKUs = append(report.UncountedSKUs, item.SKU)
+			continue
+		}
+
+		report.CountedItems++
+		if item.Variance == 0 {
+			continue
+		}
+
+		report.VarianceItems++
+		if item.Variance < 0 {
+			report.TotalShortage += -item.Variance
+		} else {
+			report.TotalSurplus += item.Variance
+		}
+		report.VarianceValue += float64(item.Variance) * stocktakeItemCost(item)
+		report.Items = append(report.Items, s.toItemResponse(item))
+	}
+
+	return report, nil
+}
+
+// ApproveStocktake approves the variances and bulk-generates adjustments and movements
+func (s *stocktakeService) ApproveStocktake(id uint, req *model.StocktakeApproveRequest, userID uint) (*model.StocktakeResponse, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stocktake.Status != model.StocktakeStatusReview {
+		return nil, errors.New("only stocktakes in review can be approved")
+	}
+
+	if req != nil && req.Notes != "" {
+		stocktake.Notes = strings.TrimSpace(stocktake.Notes + "\n" + req.Notes)
+	}
+
+	adjustments, err := s.stocktakeRepo.ApproveStocktake(stocktake, userID)
+	if errors.Is(err, repository.ErrStocktakeNotInReview) {
+		return nil, errors.New("only stocktakes in review can be approved")
+	}
+	if err != nil {
+		logger.Errorf("Error approving stocktake %d: %v", id, err)
+		return nil, fmt.Errorf("failed to approve stocktake")
+	}
+
+	logger.Infof("Stocktake %s approved by user %d with %d adjustments", stocktake.Code, userID, len(adjustments))
+
+	return s.GetStocktakeByID(id)
+}
+
+// CancelStocktake cancels a stocktake that has not been approved
+func (s *stocktakeService) CancelStocktake(id uint) (*model.StocktakeResponse, error) {
+	stocktake, err := s.getStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if stocktake.Status == model.StocktakeStatusApproved || stocktake.Status == model.StocktakeStatusCancelled {
+		return nil, fmt.Errorf("cannot cancel a stocktake with status %s", stocktake.Status)
+	}
+
+	stocktake.Status = model.StocktakeStatusCancelled
+	if err := s.stocktakeRepo.UpdateStocktake(stocktake); err != nil {
+		logger.Errorf("Error cancelling stocktake %d: %v", id, err)
+		return nil, fmt.Errorf("failed to cancel stocktake")
+	}
+
+	return s.toStocktakeResponse(stocktake, true), nil
+}
+
+// Helper methods
+
+func (s *stocktakeService) getStocktake(id uint) (*model.Stocktake, error) {
+	stocktake, err := s.stocktakeRepo.GetStocktakeByID(id)
+	if err != nil {
+		logger.Errorf("Error getting stocktake by ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to retrieve stocktake")
+	}
+	if stocktake == nil {
+		return nil, errors.New("stocktake not found")
+	}
+	return stocktake, nil
+}
+
+// generateCode generates a stocktake code in the form ST-YYYYMMDD-NNN
+func (s *stocktakeService) generateCode() (string, error) {
+	now := time.Now()
+	count, err := s.stocktakeRepo.CountStocktakesByDate(now)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ST-%s-%03d", now.Format("20060102"), count+1), nil
+}
+
+// stockLevelSKU returns the SKU used to count a stock level, preferring the variant SKU
+func stockLevelSKU(stockLevel *model.StockLevel) string {
+	if stockLevel.Variant != nil && stockLevel.Variant.SKU != "" {
+		return stockLevel.Variant.SKU
+	}
+	if stockLevel.Product != nil && stockLevel.Product.SKU != "" {
+		return stockLevel.Product.SKU
+	}
+	if stockLevel.VariantID != nil {
+		return fmt.Sprintf("P%d-V%d", stockLevel.ProductID, *stockLevel.VariantID)
+	}
+	return fmt.Sprintf("P%d", stockLevel.ProductID)
+}
+
+// stocktakeItemCost returns the unit cost used to value a variance
+func stocktakeItemCost(item *model.StocktakeItem) float64 {
+	if item.Variant != nil && item.Variant.CostPrice != nil {
+		return *item.Variant.CostPrice
+	}
+	if item.Product != nil && item.Product.CostPrice != nil {
+		return *item.Product.CostPrice
+	}
+	return 0
+}
+
+func (s *stocktakeService) toStocktakeResponse(stocktake *model.Stocktake, withItems bool) *model.StocktakeResponse {
+	response := &model.StocktakeResponse{
+		ID:          stocktake.ID,
+		Code:        stocktake.Code,
+		Name:        stocktake.Name,
+		CategoryID:  stocktake.CategoryID,
+		Location:    stocktake.Location,
+		Status:      stocktake.Status,
+		Notes:       stocktake.Notes,
+		TotalItems:  len(stocktake.Items),
+		FrozenAt:    stocktake.FrozenAt,
+		SubmittedAt: stocktake.SubmittedAt,
+		ApprovedAt:  stocktake.ApprovedAt,
+		ApprovedBy:  stocktake.ApprovedBy,
+		CreatedBy:   stocktake.CreatedBy,
+		CreatedAt:   stocktake.CreatedAt,
+		UpdatedAt:   stocktake.UpdatedAt,
+	}
+
+	for i := range stocktake.Items {
+		item := &stocktake.Items[i]
+		if item.IsCounted() {
+			response.CountedItems++
+			if item.Variance != 0 {
+				response.VarianceItems++
+			}
+		}
+		if withItems {
+			response.Items = append(response.Items, s.toItemResponse(item))
+		}
+	}
+
+	if stocktake.Category != nil {
+		response.CategoryName = stocktake.Category.Name
+	}
+	if stocktake.CreatedByUser != nil {
+		response.CreatedByName = fmt.Sprintf("%s %s", stocktake.CreatedByUser.FirstName, stocktake.CreatedByUser.LastName)
+	}
+	if stocktake.ApprovedByUser != nil {
+		response.ApprovedByName = fmt.Sprintf("%s %s", stocktake.ApprovedByUser.FirstName, stocktake.ApprovedByUser.LastName)
+	}
+
+	return response
+}
+
+func (s *stocktakeService) toItemResponse(item *model.StocktakeItem) model.StocktakeItemResponse {
+	response := model.StocktakeItemResponse{
+		ID:               item.ID,
+		ProductID:        item.ProductID,
+		VariantID:        item.VariantID,
+		SKU:              item.SKU,
+		ExpectedQuantity: item.ExpectedQuantity,
+		CountedQuantity:  item.CountedQuantity,
+		Variance:         item.Variance,
+		Notes:            item.Notes,
+		CountedBy:        item.CountedBy,
+		CountedAt:        item.CountedAt,
+	}
+
+	if item.Product != nil {
+		response.ProductName = item.Product.Name
+	}
+	if item.Variant != nil {
+		response.VariantName = item.Variant.Name
+	}
+
+	return response
+}
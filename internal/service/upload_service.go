@@ -134,6 +134,64 @@ func (s *UploadService) UploadFile(req *UploadFileRequest) (*UploadFileResponse,
 	return response, nil
 }
 
+// SaveFile stores generated content (e.g. PDF documents) in the uploads directory
+func (s *UploadService) SaveFile(data []byte, fileName, folder string) (*UploadFileResponse, error) {
+	if folder == "" {
+		folder = "uploads"
+	}
+
+	// Generate unique filename
+	uniqueName := s.generateUniqueFileName(fileName)
+
+	// Create upload directory
+	uploadDir := filepath.Join("uploads", folder)
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	filePath := filepath.Join(uploadDir, uniqueName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	response := &UploadFileResponse{
+		FileName:     uniqueName,
+		OriginalName: fileName,
+		FilePath:     filePath,
+		FileURL:      s.generateFileURL(folder, uniqueName),
+		FileSize:     int64(len(data)),
+		MimeType:     s.getMimeType(filePath),
+		UploadedAt:   time.Now(),
+	}
+
+	logger.Infof("File saved successfully: %s", filePath)
+	return response, nil
+}
+
+// ReadFile reads a stored file from the uploads directory
+func (s *UploadService) ReadFile(filePath string) ([]byte, error) {
+	// Security check: ensure file is within uploads directory
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	uploadsDir, err := filepath.Abs("uploads")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uploads directory: %w", err)
+	}
+
+	if !strings.HasPrefix(absPath, uploadsDir) {
+		return nil, fmt.Errorf("file path is outside uploads directory")
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
 // UploadMultipleFiles uploads multiple files
 func (s *UploadService) UploadMultipleFiles(files []*multipart.FileHeader, folder string, allowedExts []string, maxSize int64) ([]*UploadFileResponse, error) {
 	var responses []*UploadFileResponse
@@ -4,26 +4,41 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
-	"os"
-	"strconv"
+	"sort"
+	"strings"
 	"time"
 
+	"go_app/configs"
 	"go_app/internal/model"
 	"go_app/internal/repository"
 	"go_app/pkg/email"
 	"go_app/pkg/jwt"
 	"go_app/pkg/logger"
+	"go_app/pkg/oauth"
+	"go_app/pkg/utils"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	userRepo     repository.UserRepository
-	sessionRepo  repository.SessionRepository
-	otpRepo      repository.OTPRepository
-	jwtManager   *jwt.JWTManager
-	emailService *email.EmailService
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	otpRepo        repository.OTPRepository
+	twoFactorRepo  repository.TwoFactorRepository
+	permissionRepo repository.PermissionRepository
+	identityRepo   repository.IdentityRepository
+	jwtManager     *jwt.JWTManager
+	emailService   *email.EmailService
+	loginSecurity  *LoginSecurityService
+
+	refreshExpiry   time.Duration
+	maxSessions     int
+	roleMaxSessions map[string]int
+	twoFactorConfig configs.TwoFactorConfig
+	twoFactorKey    []byte // Khóa mã hóa secret TOTP
+	oauthConfig     configs.OAuthConfig
+	oauthProviders  *oauth.Registry
 }
 
 type LoginRequest struct {
@@ -32,6 +47,7 @@ type LoginRequest struct {
 	DeviceID  string `json:"device_id" validate:"required"`
 	UserAgent string `json:"user_agent"`
 	IPAddress string `json:"ip_address"`
+	Country   string `json:"-"` // Mã quốc gia từ header của CDN, dùng phát hiện đăng nhập bất thường
 }
 
 type RegisterRequest struct {
@@ -52,20 +68,62 @@ type ResetPasswordRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+type UpdateProfileRequest struct {
+	FirstName string `json:"first_name" validate:"max=50"`
+	LastName  string `json:"last_name" validate:"max=50"`
+	Phone     string `json:"phone" validate:"omitempty,phone"`
+	Avatar    string `json:"avatar" validate:"max=255"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"` // Bỏ trống nếu tài khoản đăng ký bằng mạng xã hội chưa có mật khẩu
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 type AuthResponse struct {
-	User         *model.User `json:"user"`
-	Token        string      `json:"token"`
-	RefreshToken string      `json:"refresh_token"`
-	ExpiresAt    time.Time   `json:"expires_at"`
+	User             *model.User `json:"user"`
+	Token            string      `json:"token"`         // Short-lived access token
+	RefreshToken     string      `json:"refresh_token"` // Single-use, exchanged at /auth/refresh
+	ExpiresAt        time.Time   `json:"expires_at"`    // Access token expiry
+	RefreshExpiresAt time.Time   `json:"refresh_expires_at"`
+
+	// Two-step login: set instead of tokens when the password was accepted but a second factor is needed
+	TwoFactorRequired      bool       `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool       `json:"two_factor_setup_required,omitempty"` // Vai trò bắt buộc 2FA, cần đăng ký trước
+	TwoFactorMethods       []string   `json:"two_factor_methods,omitempty"`
+	ChallengeToken         string     `json:"challenge_token,omitempty"` // Gửi kèm mã tới /auth/2fa/verify
+	ChallengeExpiresAt     *time.Time `json:"challenge_expires_at,omitempty"`
+	RecoveryCodes          []string   `json:"recovery_codes,omitempty"` // Chỉ trả về một lần khi vừa bật 2FA
 }
 
 func NewAuthService() *AuthService {
+	config := configs.Load()
+	refreshHours := config.JWT.RefreshExpiry
+	if refreshHours <= 0 {
+		refreshHours = 168
+	}
+
 	return &AuthService{
-		userRepo:     repository.NewUserRepository(),
-		sessionRepo:  repository.NewSessionRepository(),
-		otpRepo:      repository.NewOTPRepository(),
-		jwtManager:   jwt.NewJWTManager(),
-		emailService: email.NewEmailService(),
+		userRepo:        repository.NewUserRepository(),
+		sessionRepo:     repository.NewSessionRepository(),
+		otpRepo:         repository.NewOTPRepository(),
+		twoFactorRepo:   repository.NewTwoFactorRepository(),
+		permissionRepo:  repository.NewPermissionRepository(),
+		identityRepo:    repository.NewIdentityRepository(),
+		jwtManager:      jwt.NewJWTManager(),
+		emailService:    email.NewEmailService(),
+		loginSecurity:   NewLoginSecurityService(),
+		refreshExpiry:   time.Duration(refreshHours) * time.Hour,
+		maxSessions:     config.Session.MaxSessions,
+		roleMaxSessions: config.Session.RoleMaxSessions,
+		twoFactorConfig: config.TwoFactor,
+		twoFactorKey:    utils.DeriveKey(config.JWT.SecretKey, "two-factor-secret"),
+		oauthConfig:     config.OAuth,
+		oauthProviders:  newOAuthRegistry(config.OAuth, config.GinMode),
 	}
 }
 
@@ -118,31 +176,124 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
+		s.loginSecurity.RecordUnknownEmail(req)
 		return nil, errors.New("invalid email or password")
 	}
 
 	// Check if user is active
 	if !user.IsActive {
+		s.loginSecurity.RecordInactive(user, req)
 		return nil, errors.New("account is deactivated")
 	}
 
+	// Reject the attempt while the account is locked or inside its retry delay
+	if err := s.loginSecurity.CheckAllowed(user, req); err != nil {
+		return nil, err
+	}
+
 	// Verify password
 	if !s.verifyPassword(req.Password, user.Password) {
+		if err := s.loginSecurity.RecordFailure(user, req); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid email or password")
 	}
 
-	// Deactivate all existing sessions for this user (single device login)
-	if err := s.sessionRepo.DeactivateAllUserSessions(user.ID); err != nil {
-		logger.Warnf("Failed to deactivate existing sessions: %v", err)
+	// Ask for a second factor when 2FA is on (or required by the user's role)
+	challenge, err := s.startTwoFactorChallenge(user, req)
+	if err != nil {
+		return nil, err
 	}
+	if challenge != nil {
+		return challenge, nil
+	}
+
+	return s.completeLogin(user, req)
+}
+
+// completeLogin records the successful login and creates the session once every login step has passed
+func (s *AuthService) completeLogin(user *model.User, req *LoginRequest) (*AuthResponse, error) {
+	s.loginSecurity.RecordSuccess(user, req)
 
-	// Create new session
-	session, err := s.createSession(user.ID, req.DeviceID, req.UserAgent, req.IPAddress)
+	// Make room for the new session under the role's concurrent session limit
+	s.enforceSessionLimit(user, req.DeviceID)
+
+	// Create new session with its first refresh token
+	session, refreshToken, err := s.createSession(user.ID, req.DeviceID, req.UserAgent, req.IPAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	// Generate JWT token
+	// Update last login
+	now := time.Now()
+	user.LastLogin = &now
+	if err := s.userRepo.Update(user); err != nil {
+		logger.Warnf("Failed to update last login: %v", err)
+	}
+
+	return s.issueTokens(user, session, refreshToken, session.ExpiresAt)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token. Each refresh
+// token works once: presenting one that was already rotated means it leaked, so the whole session
+// family is revoked and the user has to log in again.
+func (s *AuthService) Refresh(req *RefreshTokenRequest) (*AuthResponse, error) {
+	current, err := s.sessionRepo.GetRefreshTokenByHash(jwt.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if current == nil || current.Session == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if current.IsRotated() {
+		return nil, s.revokeReusedFamily(current)
+	}
+	if !current.IsUsable() || !current.Session.IsValid() {
+		return nil, errors.New("refresh token expired or revoked")
+	}
+
+	user, err := s.userRepo.GetByID(current.Session.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive {
+		if err := s.sessionRepo.RevokeSession(current.SessionID, model.SessionRevokeLogout); err != nil {
+			logger.Warnf("Failed to revoke session %d: %v", current.SessionID, err)
+		}
+		return nil, errors.New("account is deactivated")
+	}
+
+	refreshToken, err := jwt.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	next := &model.RefreshToken{
+		TokenHash: jwt.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshExpiry),
+	}
+
+	if err := s.sessionRepo.RotateRefreshToken(current.ID, next); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			return nil, s.revokeReusedFamily(current)
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issueTokens(user, current.Session, refreshToken, next.ExpiresAt)
+}
+
+// revokeReusedFamily revokes the session of a refresh token that was presented after rotation
+func (s *AuthService) revokeReusedFamily(token *model.RefreshToken) error {
+	logger.Warnf("Refresh token reuse detected for session %d, revoking session", token.SessionID)
+	if err := s.sessionRepo.RevokeSession(token.SessionID, model.SessionRevokeTokenReuse); err != nil {
+		logger.Errorf("Failed to revoke session %d after refresh token reuse: %v", token.SessionID, err)
+	}
+	return errors.New("refresh token reuse detected, session revoked")
+}
+
+// issueTokens signs an access token for the session and builds the auth response
+func (s *AuthService) issueTokens(user *model.User, session *model.Session, refreshToken string, refreshExpiresAt time.Time) (*AuthResponse, error) {
 	token, err := s.jwtManager.GenerateToken(
 		user.ID,
 		user.Username,
@@ -154,17 +305,12 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// Update last login
-	now := time.Now()
-	user.LastLogin = &now
-	if err := s.userRepo.Update(user); err != nil {
-		logger.Warnf("Failed to update last login: %v", err)
-	}
-
 	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: session.ExpiresAt,
+		User:             user,
+		Token:            token,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        time.Now().Add(s.jwtManager.AccessExpiry()),
+		RefreshExpiresAt: refreshExpiresAt,
 	}, nil
 }
 
@@ -230,31 +376,76 @@ func (s *AuthService) ResetPassword(req *ResetPasswordRequest) error {
 		logger.Warnf("Failed to mark OTP as used: %v", err)
 	}
 
-	// Deactivate all sessions for security
-	if err := s.sessionRepo.DeactivateAllUserSessions(user.ID); err != nil {
-		logger.Warnf("Failed to deactivate sessions: %v", err)
+	// Revoke all sessions for security
+	if err := s.sessionRepo.RevokeAllUserSessions(user.ID, model.SessionRevokePasswordReset); err != nil {
+		logger.Warnf("Failed to revoke sessions: %v", err)
 	}
 
 	return nil
 }
 
-// Logout deactivates user session
-func (s *AuthService) Logout(sessionID string) error {
-	// Get session by token (assuming sessionID is the token)
-	session, err := s.sessionRepo.GetByToken(sessionID)
-	if err != nil {
+// Logout deactivates user session and revokes its refresh tokens
+func (s *AuthService) Logout(sessionID uint) error {
+	if _, err := s.sessionRepo.GetByID(sessionID); err != nil {
 		return errors.New("session not found")
 	}
 
-	// Deactivate session
-	session.IsActive = false
-	if err := s.sessionRepo.Update(session); err != nil {
+	if err := s.sessionRepo.RevokeSession(sessionID, model.SessionRevokeLogout); err != nil {
 		return fmt.Errorf("failed to deactivate session: %w", err)
 	}
 
 	return nil
 }
 
+// GetSessions lists the user's active sessions, most recently used first
+func (s *AuthService) GetSessions(userID, currentSessionID uint) ([]model.SessionResponse, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity().After(sessions[j].LastActivity())
+	})
+
+	responses := make([]model.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, model.SessionResponse{
+			ID:         session.ID,
+			DeviceID:   session.DeviceID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastActivity(),
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return responses, nil
+}
+
+// RevokeSession signs the user out of one of their sessions
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil || session.UserID != userID || !session.IsValid() {
+		return errors.New("session not found")
+	}
+
+	if err := s.sessionRepo.RevokeSession(sessionID, model.SessionRevokeByUser); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere except the current session
+func (s *AuthService) RevokeOtherSessions(userID, currentSessionID uint) (int, error) {
+	revoked, err := s.sessionRepo.RevokeOtherUserSessions(userID, currentSessionID, model.SessionRevokeByUser)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return revoked, nil
+}
+
 // VerifyEmail verifies user email using OTP
 func (s *AuthService) VerifyEmail(code string) error {
 	// Get valid OTP
@@ -302,34 +493,88 @@ func (s *AuthService) generateOTPCode() string {
 	return fmt.Sprintf("%06d", rand.Intn(1000000))
 }
 
-func (s *AuthService) createSession(userID uint, deviceID, userAgent, ipAddress string) (*model.Session, error) {
-	// Generate session token
-	sessionToken := uuid.New().String()
+// enforceSessionLimit replaces the user's previous session on the same device and, when the role allows a
+// limited number of concurrent sessions, signs out the least recently used ones to make room for a new login
+func (s *AuthService) enforceSessionLimit(user *model.User, deviceID string) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(user.ID)
+	if err != nil {
+		logger.Warnf("Failed to get sessions of user %d: %v", user.ID, err)
+		return
+	}
+
+	remaining := make([]*model.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.DeviceID == deviceID {
+			if err := s.sessionRepo.RevokeSession(session.ID, model.SessionRevokeReplaced); err != nil {
+				logger.Warnf("Failed to revoke session %d: %v", session.ID, err)
+			}
+			continue
+		}
+		remaining = append(remaining, session)
+	}
+
+	limit := s.sessionLimit(user)
+	if limit <= 0 || len(remaining) < limit {
+		return
+	}
+
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].LastActivity().Before(remaining[j].LastActivity())
+	})
+	for _, session := range remaining[:len(remaining)-limit+1] {
+		if err := s.sessionRepo.RevokeSession(session.ID, model.SessionRevokeLimit); err != nil {
+			logger.Warnf("Failed to revoke session %d: %v", session.ID, err)
+		}
+	}
+}
 
-	// Calculate expiry time
-	expireHours := 24
-	if hours := os.Getenv("JWT_EXPIRE_HOURS"); hours != "" {
-		if h, err := strconv.Atoi(hours); err == nil {
-			expireHours = h
+// sessionLimit returns how many concurrent sessions the user's roles allow (0 = unlimited).
+// With overrides for several of the user's roles the strictest one applies.
+func (s *AuthService) sessionLimit(user *model.User) int {
+	limit, overridden := 0, false
+	for _, name := range user.RoleNames() {
+		roleLimit, ok := s.roleMaxSessions[name]
+		if !ok {
+			continue
+		}
+		if !overridden || (roleLimit > 0 && (limit == 0 || roleLimit < limit)) {
+			limit = roleLimit
 		}
+		overridden = true
+	}
+	if !overridden {
+		return s.maxSessions
+	}
+	return limit
+}
+
+func (s *AuthService) createSession(userID uint, deviceID, userAgent, ipAddress string) (*model.Session, string, error) {
+	// Generate refresh token; only its hash is stored
+	refreshToken, err := jwt.GenerateRefreshToken()
+	if err != nil {
+		return nil, "", err
 	}
-	expiresAt := time.Now().Add(time.Duration(expireHours) * time.Hour)
+	expiresAt := time.Now().Add(s.refreshExpiry)
 
 	session := &model.Session{
 		UserID:    userID,
-		Token:     sessionToken,
+		Token:     uuid.New().String(),
 		DeviceID:  deviceID,
 		UserAgent: userAgent,
 		IPAddress: ipAddress,
 		IsActive:  true,
 		ExpiresAt: expiresAt,
 	}
+	token := &model.RefreshToken{
+		TokenHash: jwt.HashRefreshToken(refreshToken),
+		ExpiresAt: expiresAt,
+	}
 
-	if err := s.sessionRepo.Create(session); err != nil {
-		return nil, err
+	if err := s.sessionRepo.CreateWithRefreshToken(session, token); err != nil {
+		return nil, "", err
 	}
 
-	return session, nil
+	return session, refreshToken, nil
 }
 
 func (s *AuthService) sendEmailVerificationOTP(user *model.User) error {
@@ -355,3 +600,51 @@ func (s *AuthService) sendEmailVerificationOTP(user *model.User) error {
 func (s *AuthService) GetUserByID(userID uint) (*model.User, error) {
 	return s.userRepo.GetByID(userID)
 }
+
+// UpdateProfile updates the user's own name, phone and avatar
+func (s *AuthService) UpdateProfile(userID uint, req *UpdateProfileRequest) (*model.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	user.FirstName = strings.TrimSpace(req.FirstName)
+	user.LastName = strings.TrimSpace(req.LastName)
+	user.Phone = strings.TrimSpace(req.Phone)
+	user.Avatar = strings.TrimSpace(req.Avatar)
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword sets a new password after checking the current one and signs the user
+// out of every other session. Accounts created through social login may set a first
+// password without one.
+func (s *AuthService) ChangePassword(userID, currentSessionID uint, req *ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.Password != "" && !s.verifyPassword(req.CurrentPassword, user.Password) {
+		return errors.New("current password is incorrect")
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := s.sessionRepo.RevokeOtherUserSessions(userID, currentSessionID, model.SessionRevokePasswordChange); err != nil {
+		logger.Warnf("Failed to revoke sessions: %v", err)
+	}
+
+	return nil
+}
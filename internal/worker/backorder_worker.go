@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// BackorderWorker fulfills backordered order items as stock becomes available
+type BackorderWorker struct {
+	orderService service.OrderService
+	stopChan     chan bool
+}
+
+// NewBackorderWorker creates a new BackorderWorker
+func NewBackorderWorker(orderService service.OrderService) *BackorderWorker {
+	return &BackorderWorker{
+		orderService: orderService,
+		stopChan:     make(chan bool),
+	}
+}
+
+// Start starts the backorder worker
+func (w *BackorderWorker) Start() {
+	logger.Info("Starting backorder worker...")
+
+	ticker := time.NewTicker(15 * time.Minute) // Check restocked backorders every 15 minutes
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			result, err := w.orderService.ProcessBackorders()
+			if err != nil {
+				logger.Errorf("Failed to process backorders: %v", err)
+				continue
+			}
+			if result.ItemsFulfilled > 0 {
+				logger.Infof("Backorder run: %d of %d items fulfilled, %d customers notified", result.ItemsFulfilled, result.ItemsChecked, result.NotificationsSent)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping backorder worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the backorder worker
+func (w *BackorderWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// DeliverySlotWorker expires delivery slot holds of carts that were not checked out
+type DeliverySlotWorker struct {
+	slotService service.DeliverySlotService
+	stopChan    chan bool
+}
+
+// NewDeliverySlotWorker creates a new DeliverySlotWorker
+func NewDeliverySlotWorker(slotService service.DeliverySlotService) *DeliverySlotWorker {
+	return &DeliverySlotWorker{
+		slotService: slotService,
+		stopChan:    make(chan bool),
+	}
+}
+
+// Start starts the delivery slot worker
+func (w *DeliverySlotWorker) Start() {
+	logger.Info("Starting delivery slot worker...")
+
+	ticker := time.NewTicker(1 * time.Minute) // Expire stale holds every minute
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			expired, err := w.slotService.ExpireHolds()
+			if err != nil {
+				logger.Errorf("Failed to expire delivery slot holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				logger.Infof("Expired %d delivery slot holds", expired)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping delivery slot worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the delivery slot worker
+func (w *DeliverySlotWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// nearExpiryDays is how far ahead lots are checked for near-expiry alerts
+const nearExpiryDays = 30
+
+// InventoryWorker handles background inventory processing such as lot expiry checks
+type InventoryWorker struct {
+	inventoryService service.InventoryService
+	stopChan         chan bool
+}
+
+// NewInventoryWorker creates a new InventoryWorker
+func NewInventoryWorker(inventoryService service.InventoryService) *InventoryWorker {
+	return &InventoryWorker{
+		inventoryService: inventoryService,
+		stopChan:         make(chan bool),
+	}
+}
+
+// Start starts the inventory worker
+func (w *InventoryWorker) Start() {
+	logger.Info("Starting inventory worker...")
+
+	ticker := time.NewTicker(1 * time.Hour) // Check lot expiry every hour
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Mark expired lots and alert on lots nearing expiry
+			result, err := w.inventoryService.CheckNearExpiryLots(nearExpiryDays)
+			if err != nil {
+				logger.Errorf("Failed to check near-expiry lots: %v", err)
+				continue
+			}
+			if result.AlertsSent > 0 || result.ExpiredLots > 0 {
+				logger.Infof("Lot expiry check: %d alerts sent, %d lots expired", result.AlertsSent, result.ExpiredLots)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping inventory worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the inventory worker
+func (w *InventoryWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"go_app/pkg/jwt"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// JWTKeyWorker rotates the access token signing key on schedule
+type JWTKeyWorker struct {
+	jwtManager *jwt.JWTManager
+	stopChan   chan bool
+}
+
+// NewJWTKeyWorker creates a new JWTKeyWorker
+func NewJWTKeyWorker(jwtManager *jwt.JWTManager) *JWTKeyWorker {
+	return &JWTKeyWorker{
+		jwtManager: jwtManager,
+		stopChan:   make(chan bool),
+	}
+}
+
+// Start starts the JWT key worker
+func (w *JWTKeyWorker) Start() {
+	logger.Info("Starting JWT key worker...")
+
+	ticker := time.NewTicker(1 * time.Hour) // Check whether the signing key is due every hour
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := w.jwtManager.RotateSigningKey(); err != nil {
+				logger.Errorf("Failed to rotate JWT signing key: %v", err)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping JWT key worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the JWT key worker
+func (w *JWTKeyWorker) Stop() {
+	w.stopChan <- true
+}
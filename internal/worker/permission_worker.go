@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// PermissionWorker activates and expires time-bound role grants and removes expired user
+// permissions, writing each change to the permission log
+type PermissionWorker struct {
+	permissionService service.PermissionService
+	stopChan          chan bool
+}
+
+// NewPermissionWorker creates a new PermissionWorker
+func NewPermissionWorker(permissionService service.PermissionService) *PermissionWorker {
+	return &PermissionWorker{
+		permissionService: permissionService,
+		stopChan:          make(chan bool),
+	}
+}
+
+// Start starts the permission worker
+func (w *PermissionWorker) Start() {
+	logger.Info("Starting permission worker...")
+
+	ticker := time.NewTicker(1 * time.Minute) // Check grant start and end times every minute
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if activated, expired, err := w.permissionService.ProcessRoleGrantSchedule(); err != nil {
+				logger.Errorf("Failed to process role grants: %v", err)
+			} else if activated > 0 || expired > 0 {
+				logger.Infof("Role grants: %d took effect, %d expired", activated, expired)
+			}
+
+			if expired, err := w.permissionService.ExpireUserPermissions(); err != nil {
+				logger.Errorf("Failed to expire user permissions: %v", err)
+			} else if expired > 0 {
+				logger.Infof("Removed %d expired user permissions", expired)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping permission worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the permission worker
+func (w *PermissionWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// PrivacyWorker builds personal data exports, deletes expired archives and erases accounts
+// whose grace period has ended
+type PrivacyWorker struct {
+	privacyService *service.PrivacyService
+	stopChan       chan bool
+}
+
+// NewPrivacyWorker creates a new PrivacyWorker
+func NewPrivacyWorker(privacyService *service.PrivacyService) *PrivacyWorker {
+	return &PrivacyWorker{
+		privacyService: privacyService,
+		stopChan:       make(chan bool),
+	}
+}
+
+// Start starts the privacy worker
+func (w *PrivacyWorker) Start() {
+	logger.Info("Starting privacy worker...")
+
+	exportTicker := time.NewTicker(1 * time.Minute) // Build requested exports every minute
+	defer exportTicker.Stop()
+	cleanupTicker := time.NewTicker(1 * time.Hour) // Expire archives and run due erasures every hour
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-exportTicker.C:
+			completed, err := w.privacyService.ProcessDataExports()
+			if err != nil {
+				logger.Errorf("Failed to process data exports: %v", err)
+				continue
+			}
+			if completed > 0 {
+				logger.Infof("Built %d personal data exports", completed)
+			}
+
+		case <-cleanupTicker.C:
+			if removed, err := w.privacyService.CleanupExpiredExports(); err != nil {
+				logger.Errorf("Failed to clean up data exports: %v", err)
+			} else if removed > 0 {
+				logger.Infof("Deleted %d expired data export archives", removed)
+			}
+
+			if erased, err := w.privacyService.ProcessDueErasures(); err != nil {
+				logger.Errorf("Failed to process account erasures: %v", err)
+			} else if erased > 0 {
+				logger.Infof("Erased %d accounts", erased)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping privacy worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the privacy worker
+func (w *PrivacyWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// ShippingSLAWorker checks shipments against the shipping SLA and raises exceptions
+type ShippingSLAWorker struct {
+	slaService service.ShippingSLAService
+	stopChan   chan bool
+}
+
+// NewShippingSLAWorker creates a new ShippingSLAWorker
+func NewShippingSLAWorker(slaService service.ShippingSLAService) *ShippingSLAWorker {
+	return &ShippingSLAWorker{
+		slaService: slaService,
+		stopChan:   make(chan bool),
+	}
+}
+
+// Start starts the shipping SLA worker
+func (w *ShippingSLAWorker) Start() {
+	logger.Info("Starting shipping SLA worker...")
+
+	ticker := time.NewTicker(15 * time.Minute) // Check shipments every 15 minutes
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			result, err := w.slaService.RunMonitor()
+			if err != nil {
+				logger.Errorf("Failed to run shipping SLA monitor: %v", err)
+				continue
+			}
+			if result.Opened > 0 || result.Resolved > 0 || result.Failed > 0 {
+				logger.Infof("Shipping SLA: %d checked, %d exceptions opened, %d resolved, %d failed", result.Checked, result.Opened, result.Resolved, result.Failed)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping shipping SLA worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the shipping SLA worker
+func (w *ShippingSLAWorker) Stop() {
+	w.stopChan <- true
+}
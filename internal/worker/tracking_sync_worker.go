@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"go_app/internal/service"
+	"go_app/pkg/logger"
+	"time"
+)
+
+// TrackingSyncWorker polls carriers for updates on active order trackings
+type TrackingSyncWorker struct {
+	orderTrackingService *service.OrderTrackingService
+	stopChan             chan bool
+}
+
+// NewTrackingSyncWorker creates a new TrackingSyncWorker
+func NewTrackingSyncWorker(orderTrackingService *service.OrderTrackingService) *TrackingSyncWorker {
+	return &TrackingSyncWorker{
+		orderTrackingService: orderTrackingService,
+		stopChan:             make(chan bool),
+	}
+}
+
+// Start starts the tracking sync worker
+func (w *TrackingSyncWorker) Start() {
+	logger.Info("Starting tracking sync worker...")
+
+	ticker := time.NewTicker(5 * time.Minute) // Poll carriers every 5 minutes
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			result, err := w.orderTrackingService.SyncOrderTrackings(100)
+			if err != nil {
+				logger.Errorf("Failed to sync order trackings: %v", err)
+				continue
+			}
+			if result.Checked > 0 {
+				logger.Infof("Tracking sync: %d checked, %d updated, %d failed, %d skipped, %d new events", result.Checked, result.Updated, result.Failed, result.Skipped, result.EventsCreated)
+			}
+
+		case <-w.stopChan:
+			logger.Info("Stopping tracking sync worker...")
+			return
+		}
+	}
+}
+
+// Stop stops the tracking sync worker
+func (w *TrackingSyncWorker) Stop() {
+	w.stopChan <- true
+}
@@ -0,0 +1,64 @@
+-- +migrate Up
+ALTER TABLE stock_levels ADD COLUMN location VARCHAR(100) NULL AFTER reorder_point; -- Vị trí kho (kệ, khu vực)
+CREATE INDEX idx_stock_levels_location ON stock_levels (location);
+
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE, -- ST-YYYYMMDD-NNN
+    name VARCHAR(255) NOT NULL,
+    category_id BIGINT UNSIGNED NULL,
+    location VARCHAR(100) NULL,
+    status VARCHAR(20) DEFAULT 'draft', -- draft, counting, review, approved, cancelled
+    notes TEXT,
+    frozen_at TIMESTAMP NULL,    -- Thời điểm chốt số lượng dự kiến
+    submitted_at TIMESTAMP NULL, -- Thời điểm gửi duyệt
+    approved_at TIMESTAMP NULL,
+    approved_by BIGINT UNSIGNED NULL,
+    created_by BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+
+    INDEX idx_stocktakes_category_id (category_id),
+    INDEX idx_stocktakes_location (location),
+    INDEX idx_stocktakes_status (status),
+    INDEX idx_stocktakes_approved_by (approved_by),
+    INDEX idx_stocktakes_created_by (created_by),
+    INDEX idx_stocktakes_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS stocktake_items (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    stocktake_id BIGINT UNSIGNED NOT NULL,
+    product_id BIGINT UNSIGNED NOT NULL,
+    variant_id BIGINT UNSIGNED NULL,
+    sku VARCHAR(100),
+    expected_quantity INT NOT NULL,   -- Số lượng hệ thống tại thời điểm chốt
+    counted_quantity INT NULL,        -- Số lượng thực đếm
+    variance INT DEFAULT 0,           -- Chênh lệch (thực đếm - dự kiến)
+    notes TEXT,
+    counted_by BIGINT UNSIGNED NULL,
+    counted_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (stocktake_id) REFERENCES stocktakes(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE CASCADE,
+    FOREIGN KEY (counted_by) REFERENCES users(id) ON DELETE SET NULL,
+
+    INDEX idx_stocktake_items_stocktake_id (stocktake_id),
+    INDEX idx_stocktake_items_product_id (product_id),
+    INDEX idx_stocktake_items_variant_id (variant_id),
+    INDEX idx_stocktake_items_sku (sku)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS stocktake_items;
+DROP TABLE IF EXISTS stocktakes;
+DROP INDEX idx_stock_levels_location ON stock_levels;
+ALTER TABLE stock_levels DROP COLUMN location;
@@ -0,0 +1,68 @@
+-- +migrate Up
+ALTER TABLE products ADD COLUMN track_lots BOOLEAN DEFAULT FALSE; -- Quản lý theo lô/hạn sử dụng
+
+ALTER TABLE inventory_movements
+    ADD COLUMN lot_id BIGINT UNSIGNED NULL,
+    ADD COLUMN lot_number VARCHAR(100) NULL,    -- Số lô
+    ADD COLUMN expiry_date TIMESTAMP NULL,      -- Hạn sử dụng
+    ADD COLUMN manufactured_at TIMESTAMP NULL;  -- Ngày sản xuất
+CREATE INDEX idx_inventory_movements_lot_id ON inventory_movements (lot_id);
+CREATE INDEX idx_inventory_movements_lot_number ON inventory_movements (lot_number);
+
+CREATE TABLE IF NOT EXISTS inventory_lots (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    product_id BIGINT UNSIGNED NOT NULL,
+    variant_id BIGINT UNSIGNED NULL,
+    lot_number VARCHAR(100) NOT NULL,       -- Số lô
+    expiry_date TIMESTAMP NULL,             -- Hạn sử dụng
+    manufactured_at TIMESTAMP NULL,         -- Ngày sản xuất
+    received_quantity INT DEFAULT 0,        -- Tổng số lượng đã nhập
+    available_quantity INT DEFAULT 0,       -- Số lượng còn lại
+    unit_cost DECIMAL(10,2) DEFAULT 0.00,
+    status VARCHAR(20) DEFAULT 'active',    -- active, depleted, expired
+    near_expiry_notified_at TIMESTAMP NULL, -- Lần cảnh báo sắp hết hạn gần nhất
+    received_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE CASCADE,
+
+    UNIQUE KEY uk_inventory_lots_product_variant_lot (product_id, variant_id, lot_number),
+    INDEX idx_inventory_lots_product_id (product_id),
+    INDEX idx_inventory_lots_variant_id (variant_id),
+    INDEX idx_inventory_lots_lot_number (lot_number),
+    INDEX idx_inventory_lots_expiry_date (expiry_date),
+    INDEX idx_inventory_lots_status (status),
+    INDEX idx_inventory_lots_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS inventory_lot_allocations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    movement_id BIGINT UNSIGNED NOT NULL,
+    lot_id BIGINT UNSIGNED NOT NULL,
+    quantity INT NOT NULL,
+    reference VARCHAR(255),     -- Số tham chiếu của phiếu xuất
+    released_at TIMESTAMP NULL, -- Thời điểm hoàn trả về lô (hủy đơn)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (movement_id) REFERENCES inventory_movements(id) ON DELETE CASCADE,
+    FOREIGN KEY (lot_id) REFERENCES inventory_lots(id) ON DELETE CASCADE,
+
+    INDEX idx_inventory_lot_allocations_movement_id (movement_id),
+    INDEX idx_inventory_lot_allocations_lot_id (lot_id),
+    INDEX idx_inventory_lot_allocations_reference (reference)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS inventory_lot_allocations;
+DROP TABLE IF EXISTS inventory_lots;
+DROP INDEX idx_inventory_movements_lot_number ON inventory_movements;
+DROP INDEX idx_inventory_movements_lot_id ON inventory_movements;
+ALTER TABLE inventory_movements
+    DROP COLUMN manufactured_at,
+    DROP COLUMN expiry_date,
+    DROP COLUMN lot_number,
+    DROP COLUMN lot_id;
+ALTER TABLE products DROP COLUMN track_lots;
@@ -0,0 +1,47 @@
+-- +migrate Up
+ALTER TABLE products MODIFY COLUMN type ENUM('simple', 'variable', 'bundle') DEFAULT 'simple';
+
+CREATE TABLE IF NOT EXISTS product_bundle_items (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    bundle_id BIGINT UNSIGNED NOT NULL,            -- Sản phẩm combo
+    component_product_id BIGINT UNSIGNED NOT NULL, -- Sản phẩm thành phần
+    component_variant_id BIGINT UNSIGNED NULL,
+    quantity INT NOT NULL DEFAULT 1,               -- Số lượng thành phần trong một combo
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (bundle_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (component_product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (component_variant_id) REFERENCES product_variants(id) ON DELETE CASCADE,
+
+    INDEX idx_product_bundle_items_bundle_id (bundle_id),
+    INDEX idx_product_bundle_items_component_product_id (component_product_id),
+    INDEX idx_product_bundle_items_component_variant_id (component_variant_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_item_components (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    order_item_id BIGINT UNSIGNED NOT NULL,
+    product_id BIGINT UNSIGNED NOT NULL,
+    product_variant_id BIGINT UNSIGNED NULL,
+    product_name VARCHAR(255) NOT NULL,
+    product_sku VARCHAR(100),
+    variant_name VARCHAR(255),
+    quantity_per_bundle INT NOT NULL, -- Số lượng trong một combo
+    quantity INT NOT NULL,            -- Tổng số lượng (x số combo)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_variant_id) REFERENCES product_variants(id) ON DELETE SET NULL,
+
+    INDEX idx_order_item_components_order_item_id (order_item_id),
+    INDEX idx_order_item_components_product_id (product_id),
+    INDEX idx_order_item_components_product_variant_id (product_variant_id)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS order_item_components;
+DROP TABLE IF EXISTS product_bundle_items;
+ALTER TABLE products MODIFY COLUMN type ENUM('simple', 'variable') DEFAULT 'simple';
@@ -0,0 +1,39 @@
+-- +migrate Up
+ALTER TABLE products
+    ADD COLUMN backorder_policy VARCHAR(20) DEFAULT 'none', -- none, backorder, preorder
+    ADD COLUMN backorder_limit INT DEFAULT 0,               -- Số lượng đặt trước tối đa (0 = không giới hạn)
+    ADD COLUMN backorder_expected_at TIMESTAMP NULL;        -- Ngày dự kiến có hàng
+
+ALTER TABLE order_items
+    ADD COLUMN backordered_quantity INT DEFAULT 0,     -- Số lượng chờ hàng về
+    ADD COLUMN backorder_type VARCHAR(20) NULL,        -- backorder, preorder
+    ADD COLUMN expected_available_at TIMESTAMP NULL,   -- Ngày dự kiến có hàng
+    ADD COLUMN backorder_fulfilled_at TIMESTAMP NULL,  -- Ngày hàng về đủ
+    ADD COLUMN shipped_quantity INT DEFAULT 0;         -- Số lượng đã giao
+CREATE INDEX idx_order_items_backorder ON order_items (backordered_quantity, backorder_fulfilled_at);
+
+ALTER TABLE orders DROP CHECK chk_order_status;
+ALTER TABLE orders ADD CONSTRAINT chk_order_status
+CHECK (status IN ('pending', 'confirmed', 'processing', 'shipped', 'partially_shipped', 'delivered', 'cancelled', 'returned', 'refunded'));
+
+-- Đơn hàng đã giao trước đó coi như đã giao đủ
+UPDATE order_items oi
+    JOIN orders o ON o.id = oi.order_id
+    SET oi.shipped_quantity = oi.quantity
+    WHERE o.status IN ('shipped', 'delivered', 'returned');
+
+-- +migrate Down
+ALTER TABLE orders DROP CHECK chk_order_status;
+ALTER TABLE orders ADD CONSTRAINT chk_order_status
+CHECK (status IN ('pending', 'confirmed', 'processing', 'shipped', 'delivered', 'cancelled', 'returned', 'refunded'));
+DROP INDEX idx_order_items_backorder ON order_items;
+ALTER TABLE order_items
+    DROP COLUMN shipped_quantity,
+    DROP COLUMN backorder_fulfilled_at,
+    DROP COLUMN expected_available_at,
+    DROP COLUMN backorder_type,
+    DROP COLUMN backordered_quantity;
+ALTER TABLE products
+    DROP COLUMN backorder_expected_at,
+    DROP COLUMN backorder_limit,
+    DROP COLUMN backorder_policy;
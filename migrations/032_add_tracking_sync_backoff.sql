@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE order_trackings
+    ADD COLUMN sync_failures INT DEFAULT 0,          -- Số lần đồng bộ lỗi liên tiếp
+    ADD COLUMN next_sync_at TIMESTAMP NULL,          -- Thời điểm được đồng bộ lại
+    ADD COLUMN last_sync_error VARCHAR(500) NULL;    -- Lỗi đồng bộ gần nhất
+CREATE INDEX idx_order_trackings_next_sync_at ON order_trackings (next_sync_at);
+
+-- +migrate Down
+DROP INDEX idx_order_trackings_next_sync_at ON order_trackings;
+ALTER TABLE order_trackings
+    DROP COLUMN last_sync_error,
+    DROP COLUMN next_sync_at,
+    DROP COLUMN sync_failures;
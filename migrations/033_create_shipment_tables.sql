@@ -0,0 +1,109 @@
+-- +migrate Up
+-- Shipments: one row per parcel of an order, linking the carrier booking and the tracking
+CREATE TABLE IF NOT EXISTS shipments (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    order_id BIGINT UNSIGNED NOT NULL,
+    shipping_order_id BIGINT UNSIGNED NULL,      -- Vận đơn đặt qua hãng vận chuyển
+    order_tracking_id BIGINT UNSIGNED NULL,      -- Theo dõi vận chuyển
+    carrier VARCHAR(50),
+    carrier_code VARCHAR(20),
+    tracking_number VARCHAR(100),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    status_text VARCHAR(255),
+    location VARCHAR(255),
+    last_event_at TIMESTAMP NULL,                -- Thời điểm sự kiện mới nhất
+    shipped_at TIMESTAMP NULL,
+    delivered_at TIMESTAMP NULL,
+    created_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_shipments_order_id (order_id),
+    INDEX idx_shipments_shipping_order_id (shipping_order_id),
+    INDEX idx_shipments_order_tracking_id (order_tracking_id),
+    INDEX idx_shipments_carrier_code (carrier_code),
+    INDEX idx_shipments_tracking_number (tracking_number),
+    INDEX idx_shipments_status (status),
+    INDEX idx_shipments_created_by (created_by),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (shipping_order_id) REFERENCES shipping_orders(id) ON DELETE SET NULL,
+    FOREIGN KEY (order_tracking_id) REFERENCES order_trackings(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Order item quantities packed into each shipment
+CREATE TABLE IF NOT EXISTS shipment_items (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    shipment_id BIGINT UNSIGNED NOT NULL,
+    order_item_id BIGINT UNSIGNED NOT NULL,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_shipment_items_shipment_id (shipment_id),
+    INDEX idx_shipment_items_order_item_id (order_item_id),
+
+    FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_item_id) REFERENCES order_items(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Single timeline fed by webhooks, carrier polling and staff actions
+CREATE TABLE IF NOT EXISTS shipment_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    shipment_id BIGINT UNSIGNED NOT NULL,
+    order_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    status_text VARCHAR(255),
+    location VARCHAR(255),
+    note TEXT,
+    event_code VARCHAR(50),                      -- Mã trạng thái gốc của hãng vận chuyển
+    source VARCHAR(50) NOT NULL,                 -- api, webhook, manual, sync
+    source_data TEXT,
+    created_by BIGINT UNSIGNED NULL,
+    event_time TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_shipment_events_shipment_id (shipment_id),
+    INDEX idx_shipment_events_order_id (order_id),
+    INDEX idx_shipment_events_created_by (created_by),
+    INDEX idx_shipment_events_event_time (event_time),
+
+    FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Chuyển dữ liệu cũ: mỗi vận đơn là một shipment
+INSERT INTO shipments (order_id, shipping_order_id, carrier, carrier_code, tracking_number, status, status_text, shipped_at, delivered_at, created_at, updated_at)
+SELECT so.order_id, so.id, sp.display_name, sp.code, so.tracking_code,
+       CASE so.status WHEN 'created' THEN 'pending' ELSE so.status END,
+       so.status_text, so.shipped_at, so.delivered_at, so.created_at, so.updated_at
+FROM shipping_orders so
+JOIN shipping_providers sp ON sp.id = so.provider_id;
+
+-- Theo dõi vận chuyển trùng mã vận đơn được gắn vào cùng shipment
+UPDATE shipments s
+JOIN order_trackings ot ON ot.order_id = s.order_id AND ot.tracking_number = s.tracking_number AND ot.deleted_at IS NULL
+SET s.order_tracking_id = ot.id
+WHERE s.tracking_number <> '';
+
+INSERT INTO shipments (order_id, order_tracking_id, carrier, carrier_code, tracking_number, status, status_text, location, delivered_at, created_at, updated_at)
+SELECT ot.order_id, ot.id, ot.carrier, ot.carrier_code, ot.tracking_number, ot.status, ot.status_text, ot.location, ot.actual_delivery, ot.created_at, ot.updated_at
+FROM order_trackings ot
+WHERE ot.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM shipments s WHERE s.order_tracking_id = ot.id);
+
+INSERT INTO shipment_events (shipment_id, order_id, status, status_text, location, note, event_code, source, event_time, created_at)
+SELECT s.id, s.order_id, st.status, st.status_text, st.location, st.note, st.status, 'webhook', st.created_at, st.created_at
+FROM shipping_tracking st
+JOIN shipments s ON s.shipping_order_id = st.shipping_order_id;
+
+INSERT INTO shipment_events (shipment_id, order_id, status, status_text, location, note, event_code, source, source_data, event_time, created_at)
+SELECT s.id, s.order_id, ote.status, ote.status_text, ote.location, ote.description, ote.event_code, ote.source, ote.source_data, ote.event_time, ote.created_at
+FROM order_tracking_events ote
+JOIN shipments s ON s.order_tracking_id = ote.order_tracking_id;
+
+UPDATE shipments s
+SET s.last_event_at = (SELECT MAX(e.event_time) FROM shipment_events e WHERE e.shipment_id = s.id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS shipment_events;
+DROP TABLE IF EXISTS shipment_items;
+DROP TABLE IF EXISTS shipments;
@@ -0,0 +1,7 @@
+-- +migrate Up
+-- Miễn phí vận chuyển cho đơn hàng đạt giá trị tối thiểu (0 = không áp dụng)
+ALTER TABLE shipping_rates
+    ADD COLUMN free_shipping_threshold DECIMAL(10,2) DEFAULT 0 AFTER fragile_fee;
+
+-- +migrate Down
+ALTER TABLE shipping_rates DROP COLUMN free_shipping_threshold;
@@ -0,0 +1,22 @@
+-- +migrate Up
+-- Lô in nhãn vận chuyển và phiếu đóng gói (file PDF lưu trong uploads/shipping-labels)
+CREATE TABLE IF NOT EXISTS shipping_label_batches (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_url VARCHAR(500) NOT NULL,
+    file_size BIGINT DEFAULT 0,
+    page_count INT DEFAULT 0,
+    label_count INT DEFAULT 0,                   -- Số nhãn vận chuyển
+    packing_slip_count INT DEFAULT 0,            -- Số phiếu đóng gói
+    failed_count INT DEFAULT 0,                  -- Số vận đơn không in được
+    items JSON,                                  -- Kết quả in từng vận đơn
+    created_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_shipping_label_batches_created_by (created_by),
+    INDEX idx_shipping_label_batches_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS shipping_label_batches;
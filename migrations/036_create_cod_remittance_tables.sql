@@ -0,0 +1,69 @@
+-- +migrate Up
+-- Bảng kê đối soát tiền thu hộ (COD) của hãng vận chuyển
+CREATE TABLE IF NOT EXISTS cod_remittances (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    provider_id BIGINT UNSIGNED NOT NULL,
+    statement_number VARCHAR(100) NOT NULL,      -- Mã bảng kê của hãng vận chuyển
+    statement_date DATETIME NULL,
+    source VARCHAR(20) NOT NULL,                 -- csv, json
+    file_name VARCHAR(255),
+    total_lines INT DEFAULT 0,
+    matched_lines INT DEFAULT 0,
+    discrepancy_lines INT DEFAULT 0,             -- Dòng lệch cần kiểm tra
+    collected_amount DECIMAL(12,2) DEFAULT 0,    -- Tổng tiền thu hộ
+    fee_amount DECIMAL(12,2) DEFAULT 0,          -- Tổng phí
+    net_amount DECIMAL(12,2) DEFAULT 0,          -- Thực nhận
+    expected_amount DECIMAL(12,2) DEFAULT 0,     -- Tiền thu hộ theo vận đơn
+    status VARCHAR(20) NOT NULL,                 -- reconciled, discrepancy
+    imported_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_cod_remittances_statement (provider_id, statement_number),
+    INDEX idx_cod_remittances_status (status),
+    INDEX idx_cod_remittances_imported_by (imported_by),
+    FOREIGN KEY (provider_id) REFERENCES shipping_providers(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Từng vận đơn trong bảng kê
+CREATE TABLE IF NOT EXISTS cod_remittance_lines (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    remittance_id BIGINT UNSIGNED NOT NULL,
+    tracking_code VARCHAR(100) NOT NULL,
+    shipping_order_id BIGINT UNSIGNED NULL,
+    order_id BIGINT UNSIGNED NULL,
+    payment_id BIGINT UNSIGNED NULL,             -- Thanh toán COD được ghi nhận
+    collected_amount DECIMAL(12,2) DEFAULT 0,
+    fee DECIMAL(12,2) DEFAULT 0,
+    net_amount DECIMAL(12,2) DEFAULT 0,
+    expected_amount DECIMAL(12,2) DEFAULT 0,     -- shipping_orders.cod
+    expected_fee DECIMAL(12,2) DEFAULT 0,        -- shipping_orders.cod_fee
+    difference DECIMAL(12,2) DEFAULT 0,          -- Thu hộ - dự kiến
+    status VARCHAR(30) NOT NULL,                 -- matched, amount_mismatch, fee_mismatch, unmatched, not_cod, duplicate, already_settled
+    message VARCHAR(500),
+    raw_data TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_cod_remittance_lines_remittance_id (remittance_id),
+    INDEX idx_cod_remittance_lines_tracking_code (tracking_code),
+    INDEX idx_cod_remittance_lines_shipping_order_id (shipping_order_id),
+    INDEX idx_cod_remittance_lines_order_id (order_id),
+    INDEX idx_cod_remittance_lines_payment_id (payment_id),
+    INDEX idx_cod_remittance_lines_status (status),
+    FOREIGN KEY (remittance_id) REFERENCES cod_remittances(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Liên kết vận đơn với bảng kê đã đối soát
+ALTER TABLE shipping_orders
+    ADD COLUMN cod_remittance_id BIGINT UNSIGNED NULL AFTER cod,
+    ADD COLUMN cod_remitted_at TIMESTAMP NULL AFTER cod_remittance_id,
+    ADD INDEX idx_shipping_orders_cod_remittance_id (cod_remittance_id);
+
+-- +migrate Down
+ALTER TABLE shipping_orders
+    DROP INDEX idx_shipping_orders_cod_remittance_id,
+    DROP COLUMN cod_remitted_at,
+    DROP COLUMN cod_remittance_id;
+
+DROP TABLE IF EXISTS cod_remittance_lines;
+DROP TABLE IF EXISTS cod_remittances;
@@ -0,0 +1,91 @@
+-- +migrate Up
+-- Danh mục đơn vị hành chính Việt Nam (mã GSO)
+CREATE TABLE IF NOT EXISTS provinces (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(10) NOT NULL,                   -- Mã tỉnh/thành phố
+    name VARCHAR(100) NOT NULL,                  -- Hồ Chí Minh
+    full_name VARCHAR(150) NOT NULL,             -- Thành phố Hồ Chí Minh
+    type VARCHAR(30),
+    name_key VARCHAR(150),                       -- Tên không dấu để so khớp
+    aliases TEXT,                                -- Tên gọi khác, phân cách bằng "|"
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_provinces_code (code),
+    INDEX idx_provinces_name_key (name_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Quận/huyện/thị xã/thành phố thuộc tỉnh
+CREATE TABLE IF NOT EXISTS districts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(10) NOT NULL,
+    province_code VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    full_name VARCHAR(150) NOT NULL,
+    type VARCHAR(30),                            -- quan, huyen, thi_xa, thanh_pho
+    name_key VARCHAR(150),
+    aliases TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_districts_code (code),
+    INDEX idx_districts_province_code (province_code),
+    INDEX idx_districts_name_key (name_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Phường/xã/thị trấn
+CREATE TABLE IF NOT EXISTS wards (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(10) NOT NULL,
+    district_code VARCHAR(10) NOT NULL,
+    province_code VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    full_name VARCHAR(150) NOT NULL,
+    type VARCHAR(30),                            -- phuong, xa, thi_tran
+    name_key VARCHAR(150),
+    aliases TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_wards_code (code),
+    INDEX idx_wards_district_code (district_code),
+    INDEX idx_wards_province_code (province_code),
+    INDEX idx_wards_name_key (name_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Mã địa giới theo từng hãng vận chuyển (GHN dùng ID, GHTK so khớp theo tên)
+CREATE TABLE IF NOT EXISTS carrier_location_codes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    carrier VARCHAR(50) NOT NULL,                -- Mã hãng: ghtk, ghn...
+    level VARCHAR(20) NOT NULL,                  -- province, district, ward
+    code VARCHAR(10) NOT NULL,                   -- Mã GSO
+    external_id VARCHAR(50),
+    external_name VARCHAR(150),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_carrier_location_code (carrier, level, code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Lưu mã đơn vị hành chính trên địa chỉ
+ALTER TABLE addresses
+    ADD COLUMN province_code VARCHAR(10) NULL,
+    ADD COLUMN district_code VARCHAR(10) NULL,
+    ADD COLUMN ward_code VARCHAR(10) NULL,
+    ADD INDEX idx_addresses_province_code (province_code),
+    ADD INDEX idx_addresses_district_code (district_code),
+    ADD INDEX idx_addresses_ward_code (ward_code);
+
+-- +migrate Down
+ALTER TABLE addresses
+    DROP INDEX idx_addresses_ward_code,
+    DROP INDEX idx_addresses_district_code,
+    DROP INDEX idx_addresses_province_code,
+    DROP COLUMN ward_code,
+    DROP COLUMN district_code,
+    DROP COLUMN province_code;
+
+DROP TABLE IF EXISTS carrier_location_codes;
+DROP TABLE IF EXISTS wards;
+DROP TABLE IF EXISTS districts;
+DROP TABLE IF EXISTS provinces;
@@ -0,0 +1,52 @@
+-- +migrate Up
+-- Cửa hàng/điểm nhận hàng cho đơn nhận tại cửa hàng (click-and-collect)
+CREATE TABLE IF NOT EXISTS pickup_locations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,                   -- Mã điểm nhận hàng
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    address_line VARCHAR(255) NOT NULL,
+    ward VARCHAR(100),
+    district VARCHAR(100) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    province_code VARCHAR(10),
+    district_code VARCHAR(10),
+    ward_code VARCHAR(10),
+    latitude DECIMAL(10,8) NOT NULL,
+    longitude DECIMAL(11,8) NOT NULL,
+    opening_hours JSON,                          -- [{"weekday":1,"open":"08:00","close":"21:00"}]
+    capacity INT DEFAULT 0,                      -- Số đơn tối đa đang chờ nhận (0 = không giới hạn)
+    hold_days INT DEFAULT 7,                     -- Số ngày giữ hàng
+    pickup_fee DECIMAL(10,2) DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+
+    UNIQUE KEY uk_pickup_locations_code (code),
+    INDEX idx_pickup_locations_coordinates (latitude, longitude),
+    INDEX idx_pickup_locations_province_code (province_code),
+    INDEX idx_pickup_locations_district_code (district_code),
+    INDEX idx_pickup_locations_is_active (is_active),
+    INDEX idx_pickup_locations_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Đơn hàng nhận tại cửa hàng
+ALTER TABLE orders
+    ADD COLUMN pickup_location_id BIGINT UNSIGNED NULL,
+    ADD COLUMN ready_for_pickup_at DATETIME NULL,   -- Thời gian hàng sẵn sàng tại điểm nhận
+    ADD COLUMN pickup_deadline DATETIME NULL,       -- Hạn chót khách đến nhận
+    ADD INDEX idx_orders_pickup_location_id (pickup_location_id),
+    ADD CONSTRAINT fk_orders_pickup_location FOREIGN KEY (pickup_location_id) REFERENCES pickup_locations(id);
+
+-- +migrate Down
+ALTER TABLE orders
+    DROP FOREIGN KEY fk_orders_pickup_location,
+    DROP INDEX idx_orders_pickup_location_id,
+    DROP COLUMN pickup_deadline,
+    DROP COLUMN ready_for_pickup_at,
+    DROP COLUMN pickup_location_id;
+
+DROP TABLE IF EXISTS pickup_locations;
@@ -0,0 +1,68 @@
+-- +migrate Up
+-- Khung giờ giao hàng theo khu vực (giao nội thành)
+CREATE TABLE IF NOT EXISTS delivery_slot_templates (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,                  -- Sáng, Chiều, Tối...
+    province VARCHAR(100) NOT NULL,              -- "*" = mọi tỉnh/thành
+    district VARCHAR(100) NOT NULL,              -- "*" = mọi quận/huyện
+    days_of_week VARCHAR(20),                    -- "1,2,3,4,5" (0 = Chủ nhật), rỗng = mọi ngày
+    start_time VARCHAR(5) NOT NULL,              -- HH:MM
+    end_time VARCHAR(5) NOT NULL,                -- HH:MM
+    capacity INT NOT NULL,                       -- Số đơn tối đa mỗi khung giờ mỗi ngày
+    cutoff_minutes INT DEFAULT 120,              -- Ngừng nhận đặt trước giờ bắt đầu (phút)
+    fee DECIMAL(10,2) DEFAULT 0,                 -- Phụ phí chọn khung giờ
+    sort_order INT DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+
+    INDEX idx_delivery_slot_templates_zone (province, district),
+    INDEX idx_delivery_slot_templates_is_active (is_active),
+    INDEX idx_delivery_slot_templates_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Giữ chỗ khung giờ giao: giữ tạm theo giỏ hàng, xác nhận khi đặt hàng
+CREATE TABLE IF NOT EXISTS delivery_slot_reservations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    template_id BIGINT UNSIGNED NOT NULL,
+    delivery_date DATE NOT NULL,
+    window_start DATETIME NOT NULL,              -- Thời điểm bắt đầu khung giờ
+    window_end DATETIME NOT NULL,                -- Thời điểm kết thúc khung giờ
+    user_id BIGINT UNSIGNED NOT NULL,
+    cart_id BIGINT UNSIGNED NULL,
+    order_id BIGINT UNSIGNED NULL,
+    status VARCHAR(20) NOT NULL,                 -- held, confirmed, released, expired
+    expires_at DATETIME NULL,                    -- Hạn giữ chỗ khi chưa đặt hàng
+    released_at DATETIME NULL,
+    province VARCHAR(100),
+    district VARCHAR(100),
+    fee DECIMAL(10,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_delivery_slot_reservations_slot (template_id, delivery_date),
+    INDEX idx_delivery_slot_reservations_user_id (user_id),
+    INDEX idx_delivery_slot_reservations_cart_id (cart_id),
+    INDEX idx_delivery_slot_reservations_order_id (order_id),
+    INDEX idx_delivery_slot_reservations_status (status),
+    INDEX idx_delivery_slot_reservations_expires_at (expires_at),
+    CONSTRAINT fk_delivery_slot_reservations_template FOREIGN KEY (template_id) REFERENCES delivery_slot_templates(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Khung giờ giao đã chọn của đơn hàng
+ALTER TABLE orders
+    ADD COLUMN delivery_slot_id BIGINT UNSIGNED NULL,  -- Mã giữ chỗ khung giờ giao
+    ADD COLUMN delivery_window_start DATETIME NULL,
+    ADD COLUMN delivery_window_end DATETIME NULL,
+    ADD INDEX idx_orders_delivery_slot_id (delivery_slot_id);
+
+-- +migrate Down
+ALTER TABLE orders
+    DROP INDEX idx_orders_delivery_slot_id,
+    DROP COLUMN delivery_window_end,
+    DROP COLUMN delivery_window_start,
+    DROP COLUMN delivery_slot_id;
+
+DROP TABLE IF EXISTS delivery_slot_reservations;
+DROP TABLE IF EXISTS delivery_slot_templates;
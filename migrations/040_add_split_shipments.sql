@@ -0,0 +1,34 @@
+-- +migrate Up
+ALTER TABLE shipments
+    ADD COLUMN warehouse VARCHAR(100) NULL; -- Kho xuất hàng
+CREATE INDEX idx_shipments_warehouse ON shipments (warehouse);
+
+-- Đơn hàng giao nhiều kiện và nhận tại cửa hàng
+ALTER TABLE orders DROP CHECK chk_order_status;
+ALTER TABLE orders ADD CONSTRAINT chk_order_status
+CHECK (status IN ('pending', 'confirmed', 'processing', 'shipped', 'partially_shipped', 'ready_for_pickup', 'delivered', 'cancelled', 'returned', 'refunded'));
+
+ALTER TABLE orders DROP CHECK chk_shipping_status;
+ALTER TABLE orders ADD CONSTRAINT chk_shipping_status
+CHECK (shipping_status IN ('pending', 'picked_up', 'in_transit', 'partially_shipped', 'ready_for_pickup', 'delivered', 'failed', 'returned'));
+
+ALTER TABLE shipping_history DROP CHECK chk_shipping_status_history;
+ALTER TABLE shipping_history ADD CONSTRAINT chk_shipping_status_history
+CHECK (status IN ('pending', 'picked_up', 'in_transit', 'partially_shipped', 'ready_for_pickup', 'delivered', 'failed', 'returned'));
+
+-- +migrate Down
+ALTER TABLE shipping_history DROP CHECK chk_shipping_status_history;
+ALTER TABLE shipping_history ADD CONSTRAINT chk_shipping_status_history
+CHECK (status IN ('pending', 'picked_up', 'in_transit', 'delivered', 'failed', 'returned'));
+
+ALTER TABLE orders DROP CHECK chk_shipping_status;
+ALTER TABLE orders ADD CONSTRAINT chk_shipping_status
+CHECK (shipping_status IN ('pending', 'picked_up', 'in_transit', 'delivered', 'failed', 'returned'));
+
+ALTER TABLE orders DROP CHECK chk_order_status;
+ALTER TABLE orders ADD CONSTRAINT chk_order_status
+CHECK (status IN ('pending', 'confirmed', 'processing', 'shipped', 'partially_shipped', 'delivered', 'cancelled', 'returned', 'refunded'));
+
+DROP INDEX idx_shipments_warehouse ON shipments;
+ALTER TABLE shipments
+    DROP COLUMN warehouse;
@@ -0,0 +1,45 @@
+-- +migrate Up
+ALTER TABLE shipments
+    ADD COLUMN delivery_due TIMESTAMP NULL; -- Hạn giao theo SLA
+
+-- Shipping SLA exceptions: shipments not picked up, late in transit or failing delivery
+CREATE TABLE IF NOT EXISTS shipping_exceptions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    order_id BIGINT UNSIGNED NOT NULL,
+    shipment_id BIGINT UNSIGNED NOT NULL,
+    provider_id BIGINT UNSIGNED NULL,            -- Hãng vận chuyển (nếu đặt qua hãng)
+    carrier VARCHAR(50),
+    carrier_code VARCHAR(20),
+    tracking_number VARCHAR(100),
+    type VARCHAR(30) NOT NULL,                   -- not_picked_up, transit_delayed, delivery_failures
+    status VARCHAR(20) NOT NULL,                 -- open, acknowledged, resolved
+    message VARCHAR(500),
+    due_at TIMESTAMP NULL,                       -- Hạn SLA bị vi phạm
+    failed_attempts INT DEFAULT 0,               -- Số lần giao thất bại
+    detected_at TIMESTAMP NOT NULL,
+    last_checked_at TIMESTAMP NULL,
+    acknowledged_by BIGINT UNSIGNED NULL,
+    acknowledged_at TIMESTAMP NULL,
+    resolved_by BIGINT UNSIGNED NULL,            -- Trống nếu hệ thống tự đóng
+    resolved_at TIMESTAMP NULL,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_shipping_exceptions_order_id (order_id),
+    INDEX idx_shipping_exceptions_shipment_id (shipment_id),
+    INDEX idx_shipping_exceptions_provider_id (provider_id),
+    INDEX idx_shipping_exceptions_carrier_code (carrier_code),
+    INDEX idx_shipping_exceptions_type (type),
+    INDEX idx_shipping_exceptions_status (status),
+    INDEX idx_shipping_exceptions_detected_at (detected_at),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (shipment_id) REFERENCES shipments(id) ON DELETE CASCADE,
+    FOREIGN KEY (provider_id) REFERENCES shipping_providers(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS shipping_exceptions;
+ALTER TABLE shipments
+    DROP COLUMN delivery_due;
@@ -0,0 +1,30 @@
+-- +migrate Up
+ALTER TABLE sessions
+    ADD COLUMN revoked_at TIMESTAMP NULL,
+    ADD COLUMN revoke_reason VARCHAR(50) NULL; -- logout, new_login, password_reset, refresh_token_reuse
+
+-- Refresh token xoay vòng: chỉ lưu bản băm, mỗi token dùng một lần
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    session_id BIGINT UNSIGNED NOT NULL,
+    token_hash CHAR(64) NOT NULL,                -- SHA-256 của refresh token
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP NULL,                   -- Đã đổi sang token mới
+    replaced_by_id BIGINT UNSIGNED NULL,         -- Token thay thế
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_refresh_tokens_token_hash (token_hash),
+    INDEX idx_refresh_tokens_session_id (session_id),
+
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Session cũ không có refresh token, buộc đăng nhập lại
+UPDATE sessions SET is_active = FALSE WHERE is_active = TRUE;
+
+-- +migrate Down
+DROP TABLE IF EXISTS refresh_tokens;
+ALTER TABLE sessions
+    DROP COLUMN revoke_reason,
+    DROP COLUMN revoked_at;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE sessions
+    ADD COLUMN last_seen_at TIMESTAMP NULL, -- Lần hoạt động gần nhất
+    ADD INDEX idx_sessions_user_active (user_id, is_active);
+
+-- +migrate Down
+ALTER TABLE sessions
+    DROP INDEX idx_sessions_user_active,
+    DROP COLUMN last_seen_at;
@@ -0,0 +1,21 @@
+-- +migrate Up
+-- Khóa ký access token (RS256/EdDSA), công bố qua /.well-known/jwks.json
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    kid VARCHAR(64) NOT NULL,
+    algorithm VARCHAR(10) NOT NULL,              -- RS256, EdDSA
+    public_key TEXT NOT NULL,                    -- PEM (PKIX)
+    private_key TEXT NOT NULL,                   -- PEM (PKCS8) mã hóa AES-GCM bằng JWT_SECRET
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, retired
+    retired_at TIMESTAMP NULL,                   -- Ngừng ký token mới
+    expires_at TIMESTAMP NULL,                   -- Gỡ khỏi JWKS sau thời điểm này
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_jwt_signing_keys_kid (kid),
+    INDEX idx_jwt_signing_keys_status (status),
+    INDEX idx_jwt_signing_keys_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS jwt_signing_keys;
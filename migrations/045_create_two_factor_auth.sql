@@ -0,0 +1,62 @@
+-- +migrate Up
+-- Cho phép OTP đăng nhập bước 2 qua email (ràng buộc inline ở 003 được MySQL đặt tên otps_chk_1)
+ALTER TABLE otps DROP CHECK otps_chk_1;
+ALTER TABLE otps ADD CONSTRAINT chk_otps_type CHECK (type IN ('password_reset', 'email_verify', 'two_factor'));
+
+-- Đăng ký TOTP (RFC 6238) của người dùng
+CREATE TABLE IF NOT EXISTS user_two_factors (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    secret TEXT NOT NULL,                        -- Secret base32 mã hóa AES-GCM
+    enabled BOOLEAN DEFAULT FALSE,               -- Bật sau khi xác nhận mã đầu tiên
+    enabled_at TIMESTAMP NULL,
+    last_used_step BIGINT DEFAULT 0,             -- Chống dùng lại mã TOTP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_user_two_factors_user_id (user_id),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Mã khôi phục dùng một lần
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    code_hash CHAR(64) NOT NULL,                 -- SHA-256 của mã khôi phục
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_two_factor_recovery_codes_user_id (user_id),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Bước 2 của đăng nhập sau khi mật khẩu đúng
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token_hash CHAR(64) NOT NULL,                -- SHA-256 của challenge token
+    device_id VARCHAR(100) NOT NULL,
+    user_agent VARCHAR(500),
+    ip_address VARCHAR(45),
+    setup_required BOOLEAN DEFAULT FALSE,        -- Vai trò bắt buộc 2FA nhưng chưa đăng ký
+    attempts INT DEFAULT 0,                      -- Số lần nhập sai
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_login_challenges_token_hash (token_hash),
+    INDEX idx_login_challenges_user_id (user_id),
+    INDEX idx_login_challenges_expires_at (expires_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS user_two_factors;
+DELETE FROM otps WHERE type = 'two_factor';
+ALTER TABLE otps DROP CHECK chk_otps_type;
+ALTER TABLE otps ADD CONSTRAINT otps_chk_1 CHECK (type IN ('password_reset', 'email_verify'));
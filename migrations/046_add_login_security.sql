@@ -0,0 +1,60 @@
+-- +migrate Up
+-- Đếm số lần sai mật khẩu và khóa tạm thời theo từng tài khoản
+ALTER TABLE users
+    ADD COLUMN failed_login_attempts INT DEFAULT 0, -- Số lần sai liên tiếp
+    ADD COLUMN last_failed_login_at TIMESTAMP NULL, -- Dùng tính thời gian chờ tăng dần
+    ADD COLUMN locked_until TIMESTAMP NULL,          -- Khóa tạm thời đến thời điểm này
+    ADD COLUMN lockout_count INT DEFAULT 0,          -- Số lần bị khóa liên tiếp
+    ADD INDEX idx_users_locked_until (locked_until);
+
+-- Lịch sử đăng nhập (cả thành công và thất bại)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NULL,                -- NULL nếu email không tồn tại
+    email VARCHAR(100) NOT NULL,
+    ip_address VARCHAR(45),
+    country VARCHAR(2),                          -- Mã quốc gia từ header của CDN
+    device_id VARCHAR(100),
+    user_agent VARCHAR(500),
+    success BOOLEAN DEFAULT FALSE,
+    failure_reason VARCHAR(30),                  -- invalid_password, unknown_email, locked, throttled, inactive
+    suspicious VARCHAR(100),                     -- new_device, new_country
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_login_attempts_user_id (user_id),
+    INDEX idx_login_attempts_email (email),
+    INDEX idx_login_attempts_ip_address (ip_address),
+    INDEX idx_login_attempts_success (success),
+    INDEX idx_login_attempts_created_at (created_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Các lần khóa tài khoản
+CREATE TABLE IF NOT EXISTS account_lockouts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    failed_attempts INT DEFAULT 0,
+    ip_address VARCHAR(45),                      -- IP của lần sai cuối cùng
+    locked_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    unlocked_at TIMESTAMP NULL,
+    unlocked_by BIGINT UNSIGNED NULL,            -- Admin mở khóa thủ công
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_account_lockouts_user_id (user_id),
+    INDEX idx_account_lockouts_locked_until (locked_until),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (unlocked_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS account_lockouts;
+DROP TABLE IF EXISTS login_attempts;
+ALTER TABLE users
+    DROP INDEX idx_users_locked_until,
+    DROP COLUMN lockout_count,
+    DROP COLUMN locked_until,
+    DROP COLUMN last_failed_login_at,
+    DROP COLUMN failed_login_attempts;
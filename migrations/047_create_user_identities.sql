@@ -0,0 +1,46 @@
+-- +migrate Up
+-- Tài khoản mạng xã hội (Google, Facebook, Zalo) liên kết với người dùng
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    provider VARCHAR(30) NOT NULL,               -- google, facebook, zalo
+    subject VARCHAR(255) NOT NULL,               -- ID người dùng phía nhà cung cấp
+    email VARCHAR(100),
+    email_verified BOOLEAN DEFAULT FALSE,
+    name VARCHAR(100),
+    avatar_url VARCHAR(500),
+    last_login_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_user_identities_provider_subject (provider, subject),
+    INDEX idx_user_identities_user_id (user_id),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Yêu cầu ủy quyền đang chờ callback (state + PKCE), dùng một lần
+CREATE TABLE IF NOT EXISTS oauth_states (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    state_hash CHAR(64) NOT NULL,                -- SHA-256 của tham số state
+    provider VARCHAR(30) NOT NULL,
+    purpose VARCHAR(10) NOT NULL,                -- login, link
+    user_id BIGINT UNSIGNED NULL,                -- Người dùng liên kết (purpose = link)
+    code_verifier VARCHAR(128) NOT NULL,         -- PKCE code verifier
+    nonce VARCHAR(64),
+    redirect_uri VARCHAR(500) NOT NULL,
+    device_id VARCHAR(100),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_oauth_states_state_hash (state_hash),
+    INDEX idx_oauth_states_user_id (user_id),
+    INDEX idx_oauth_states_expires_at (expires_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS oauth_states;
+DROP TABLE IF EXISTS user_identities;
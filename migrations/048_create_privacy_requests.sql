@@ -0,0 +1,48 @@
+-- +migrate Up
+-- Yêu cầu xuất dữ liệu cá nhân (Nghị định 13/2023/NĐ-CP)
+CREATE TABLE IF NOT EXISTS data_export_requests (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, processing, completed, failed, expired
+    file_path VARCHAR(500),                        -- File ZIP trong thư mục riêng, không public
+    file_size BIGINT DEFAULT 0,
+    error VARCHAR(500),
+    requested_ip VARCHAR(45),
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,                     -- Sau thời điểm này file bị xóa
+    downloads INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_data_export_requests_user_id (user_id),
+    INDEX idx_data_export_requests_status (status),
+    INDEX idx_data_export_requests_expires_at (expires_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Yêu cầu xóa tài khoản; dữ liệu cá nhân được ẩn danh sau thời gian chờ
+CREATE TABLE IF NOT EXISTS account_erasure_requests (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, cancelled, completed
+    reason VARCHAR(500),
+    requested_ip VARCHAR(45),
+    scheduled_at TIMESTAMP NOT NULL,               -- Hết thời gian chờ, worker sẽ ẩn danh dữ liệu
+    cancelled_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    last_error VARCHAR(500),                       -- Ví dụ còn đơn hàng đang giao
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_account_erasure_requests_user_id (user_id),
+    INDEX idx_account_erasure_requests_status (status),
+    INDEX idx_account_erasure_requests_scheduled_at (scheduled_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS account_erasure_requests;
+DROP TABLE IF EXISTS data_export_requests;
@@ -0,0 +1,28 @@
+-- +migrate Up
+-- API key cho tích hợp (ERP, sàn TMĐT) thay vì đăng nhập bằng tài khoản admin
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,      -- Chủ key, key chỉ có quyền trong phạm vi quyền của chủ
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,           -- Vài ký tự đầu để nhận diện key
+    key_hash VARCHAR(64) NOT NULL,         -- SHA-256, key gốc chỉ hiển thị một lần khi tạo
+    scopes TEXT NOT NULL,                  -- "resource.action" phân tách bằng dấu phẩy, ví dụ product.read,order.*
+    allowed_ips TEXT,                      -- IP hoặc CIDR, rỗng = mọi IP
+    expires_at TIMESTAMP NULL,             -- NULL = không hết hạn
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(45),
+    revoked_at TIMESTAMP NULL,
+    revoked_by BIGINT UNSIGNED NULL,       -- Chủ key hoặc admin đã thu hồi
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_api_keys_key_hash (key_hash),
+    INDEX idx_api_keys_user_id (user_id),
+    INDEX idx_api_keys_expires_at (expires_at),
+    INDEX idx_api_keys_revoked_at (revoked_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,40 @@
+-- +migrate Up
+-- Nhân viên hỗ trợ đăng nhập thay khách hàng để tái hiện lỗi giỏ hàng / thanh toán
+CREATE TABLE IF NOT EXISTS impersonations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT UNSIGNED NOT NULL,          -- Nhân viên thực sự thao tác
+    subject_id BIGINT UNSIGNED NOT NULL,        -- Khách hàng bị đăng nhập thay
+    actor_session_id BIGINT UNSIGNED NOT NULL,  -- Phiên của nhân viên; đăng xuất là token hết hiệu lực
+    reason VARCHAR(500) NOT NULL,               -- Ví dụ mã ticket hỗ trợ
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NULL,
+    end_reason VARCHAR(20),                     -- stopped, replaced, revoked
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_impersonations_actor_id (actor_id),
+    INDEX idx_impersonations_subject_id (subject_id),
+    INDEX idx_impersonations_actor_session_id (actor_session_id),
+    INDEX idx_impersonations_expires_at (expires_at),
+
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (subject_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Quyền riêng cho việc đăng nhập thay, mặc định chỉ super_admin; cấp thêm cho nhân viên hỗ trợ qua user_permissions
+INSERT IGNORE INTO permissions (name, display_name, description, resource, action, is_system, is_active) VALUES
+('customer.impersonate', 'Impersonate Customers', 'Act as a customer to reproduce cart and checkout problems', 'customer', 'impersonate', TRUE, TRUE);
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id, granted_by)
+SELECT r.id, p.id, 1
+FROM roles r, permissions p
+WHERE r.name = 'super_admin' AND p.name = 'customer.impersonate';
+
+-- +migrate Down
+DELETE rp FROM role_permissions rp
+JOIN permissions p ON p.id = rp.permission_id
+WHERE p.name = 'customer.impersonate';
+DELETE FROM permissions WHERE name = 'customer.impersonate';
+DROP TABLE IF EXISTS impersonations;
@@ -0,0 +1,41 @@
+-- +migrate Up
+-- Điều kiện ABAC gắn với quyền của vai trò / người dùng, ví dụ:
+-- [{"attribute":"amount","operator":"lt","value":10000000}] hoặc [{"attribute":"warehouse","operator":"in","value":["HN01"]}]
+ALTER TABLE role_permissions ADD COLUMN conditions TEXT NULL AFTER granted_at;
+ALTER TABLE user_permissions ADD COLUMN conditions TEXT NULL AFTER expires_at;
+
+-- Cấp thêm vai trò cho người dùng trong một khoảng thời gian (ví dụ: thay quản lý kho khi nghỉ phép)
+CREATE TABLE IF NOT EXISTS user_role_grants (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    role_id BIGINT UNSIGNED NOT NULL,
+    granted_by BIGINT UNSIGNED NOT NULL,
+    reason TEXT,
+    starts_at TIMESTAMP NOT NULL,             -- Bắt đầu có hiệu lực
+    ends_at TIMESTAMP NULL,                   -- Hết hạn; NULL = không thời hạn
+    activated_at TIMESTAMP NULL,              -- Worker ghi nhận bắt đầu hiệu lực
+    expired_at TIMESTAMP NULL,                -- Worker ghi nhận hết hạn
+    revoked_at TIMESTAMP NULL,
+    revoked_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+
+    INDEX idx_user_role_grants_user_id (user_id),
+    INDEX idx_user_role_grants_role_id (role_id),
+    INDEX idx_user_role_grants_granted_by (granted_by),
+    INDEX idx_user_role_grants_starts_at (starts_at),
+    INDEX idx_user_role_grants_ends_at (ends_at),
+    INDEX idx_user_role_grants_expired_at (expired_at),
+    INDEX idx_user_role_grants_revoked_at (revoked_at),
+    INDEX idx_user_role_grants_deleted_at (deleted_at),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (granted_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_role_grants;
+ALTER TABLE user_permissions DROP COLUMN conditions;
+ALTER TABLE role_permissions DROP COLUMN conditions;
@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE orders
+    ADD COLUMN stock_reserved_at TIMESTAMP NULL AFTER delivery_window_end; -- Thời điểm đã trừ kho cho đơn; NULL = chưa giữ hàng hoặc đã trả lại kho
+
+-- Đơn đã xác nhận trở đi đều đã được trừ kho khi xác nhận
+UPDATE orders
+    SET stock_reserved_at = updated_at
+    WHERE status IN ('confirmed', 'processing', 'shipped', 'partially_shipped', 'ready_for_pickup', 'delivered');
+
+-- +migrate Down
+ALTER TABLE orders DROP COLUMN stock_reserved_at;
@@ -119,27 +119,55 @@ func Migrate() error {
 	models := []interface{}{
 		&model.User{},
 		&model.Session{},
+		&model.RefreshToken{},
+		&model.SigningKey{},
+		&model.UserTwoFactor{},
+		&model.TwoFactorRecoveryCode{},
+		&model.LoginChallenge{},
+		&model.LoginAttempt{},
+		&model.AccountLockout{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.DataExportRequest{},
+		&model.AccountErasureRequest{},
+		&model.APIKey{},
+		&model.Impersonation{},
 		&model.OTP{},
 		&model.Brand{},
 		&model.Category{},
 		&model.Product{},
 		&model.ProductVariant{},
 		&model.ProductAttribute{},
+		&model.ProductBundleItem{},
 		&model.InventoryMovement{},
 		&model.StockLevel{},
 		&model.InventoryAdjustment{},
+		&model.InventoryLot{},
+		&model.InventoryLotAllocation{},
+		&model.Stocktake{},
+		&model.StocktakeItem{},
 		&model.Permission{},
 		&model.Role{},
 		&model.RolePermission{},
 		&model.UserPermission{},
+		&model.UserRoleGrant{},
 		&model.PermissionLog{},
 		&model.Order{},
 		&model.OrderItem{},
+		&model.OrderItemComponent{},
 		&model.Cart{},
 		&model.CartItem{},
 		&model.Payment{},
 		&model.ShippingHistory{},
 		&model.Address{},
+		&model.Province{},
+		&model.District{},
+		&model.Ward{},
+		&model.CarrierLocationCode{},
+		&model.PickupLocation{},
+		&model.DeliverySlotTemplate{},
+		&model.DeliverySlotReservation{},
+		&model.ShippingException{},
 		&model.Review{},
 		&model.ReviewImage{},
 		&model.ReviewHelpfulVote{},
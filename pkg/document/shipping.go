@@ -0,0 +1,405 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/go-pdf/fpdf/contrib/barcode"
+	"github.com/go-pdf/fpdf/contrib/gofpdi"
+	realgofpdi "github.com/phpdave11/gofpdi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Page sizes in mm
+var (
+	labelPageSize = fpdf.SizeType{Wd: 105, Ht: 148} // A6
+	slipPageSize  = fpdf.SizeType{Wd: 210, Ht: 297} // A4
+)
+
+const (
+	pointsPerMM = 72 / 25.4
+	utf8Family  = "label"
+	coreFamily  = "Helvetica"
+)
+
+// ShippingLabel holds the data printed on a generated shipping label
+type ShippingLabel struct {
+	Carrier      string
+	TrackingCode string
+	OrderNumber  string
+	FromName     string
+	FromPhone    string
+	FromAddress  string
+	ToName       string
+	ToPhone      string
+	ToAddress    string
+	Weight       float64 // in kg
+	COD          float64 // in VND
+	Note         string
+}
+
+// PackingSlip holds the data printed on a packing slip
+type PackingSlip struct {
+	OrderNumber     string
+	OrderDate       time.Time
+	CustomerName    string
+	CustomerPhone   string
+	ShippingAddress string
+	Carrier         string
+	TrackingCode    string
+	Lines           []PackingSlipLine
+	Note            string
+}
+
+// PackingSlipLine represents an order line packed into the parcel
+type PackingSlipLine struct {
+	SKU      string
+	Name     string
+	Variant  string
+	Quantity int
+}
+
+// ShippingBatch builds a single printable PDF out of carrier labels, generated labels and packing slips
+type ShippingBatch struct {
+	pdf      *fpdf.Fpdf
+	importer *gofpdi.Importer
+	sources  []*io.ReadSeeker // kept alive: gofpdi keys imported sources by their address
+	family   string
+	text     func(string) string
+	pages    int
+}
+
+// NewShippingBatch creates an empty batch. fontPath may point to a UTF-8 TTF font;
+// without it, the core Helvetica font is used and Vietnamese accents are stripped.
+func NewShippingBatch(fontPath string) *ShippingBatch {
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "mm", Size: slipPageSize})
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+
+	batch := &ShippingBatch{
+		pdf:      pdf,
+		importer: gofpdi.NewImporter(),
+		family:   coreFamily,
+		text:     removeAccents,
+	}
+
+	if fontPath != "" {
+		pdf.AddUTF8Font(utf8Family, "", fontPath)
+		pdf.AddUTF8Font(utf8Family, "B", fontPath)
+		batch.family = utf8Family
+		batch.text = func(s string) string { return s }
+	}
+
+	return batch
+}
+
+// PageCount returns the number of pages added so far
+func (b *ShippingBatch) PageCount() int {
+	return b.pages
+}
+
+// AddCarrierLabel appends every page of a carrier label PDF to the batch
+func (b *ShippingBatch) AddCarrierLabel(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("%PDF")) {
+		return fmt.Errorf("label is not a PDF document")
+	}
+
+	sizes, err := pdfPageSizes(data)
+	if err != nil {
+		return err
+	}
+
+	return b.guard(func() {
+		rs := io.ReadSeeker(bytes.NewReader(data))
+		b.sources = append(b.sources, &rs)
+
+		for i, size := range sizes {
+			orientation := "P"
+			if size.Wd > size.Ht {
+				orientation = "L"
+			}
+			b.pdf.AddPageFormat(orientation, size)
+			tpl := b.importer.ImportPageFromStream(b.pdf, &rs, i+1, "/MediaBox")
+			b.importer.UseImportedTemplate(b.pdf, tpl, 0, 0, size.Wd, size.Ht)
+			b.pages++
+		}
+	})
+}
+
+// AddShippingLabel appends an A6 label generated from the shipping order
+func (b *ShippingBatch) AddShippingLabel(label *ShippingLabel) {
+	pdf := b.pdf
+	pdf.AddPageFormat("P", labelPageSize)
+	b.pages++
+
+	const margin = 5.0
+	width := labelPageSize.Wd - 2*margin
+
+	b.setFont("B", 14)
+	pdf.SetXY(margin, margin)
+	pdf.CellFormat(width, 7, b.text(strings.ToUpper(label.Carrier)), "", 1, "L", false, 0, "")
+
+	y := margin + 9
+	if b.barcode(label.TrackingCode, margin, y, width, 16) {
+		y += 17
+	}
+	b.setFont("B", 11)
+	pdf.SetXY(margin, y)
+	pdf.CellFormat(width, 6, b.text(label.TrackingCode), "", 1, "C", false, 0, "")
+	y += 8
+
+	pdf.Line(margin, y, labelPageSize.Wd-margin, y)
+	y = b.addressBlock("Người gửi", label.FromName, label.FromPhone, label.FromAddress, margin, y+2, width, 9)
+
+	pdf.Line(margin, y, labelPageSize.Wd-margin, y)
+	y = b.addressBlock("Người nhận", label.ToName, label.ToPhone, label.ToAddress, margin, y+2, width, 11)
+
+	pdf.Line(margin, y, labelPageSize.Wd-margin, y)
+	b.setFont("", 9)
+	pdf.SetXY(margin, y+2)
+	pdf.CellFormat(width, 5, b.text(fmt.Sprintf("Mã đơn: %s", label.OrderNumber)), "", 1, "L", false, 0, "")
+	pdf.SetX(margin)
+	pdf.CellFormat(width, 5, b.text(fmt.Sprintf("Khối lượng: %.2f kg", label.Weight)), "", 1, "L", false, 0, "")
+	if label.Note != "" {
+		pdf.SetX(margin)
+		pdf.MultiCell(width, 4, b.text(fmt.Sprintf("Ghi chú: %s", label.Note)), "", "L", false)
+	}
+
+	b.setFont("B", 14)
+	pdf.SetXY(margin, labelPageSize.Ht-margin-10)
+	pdf.CellFormat(width, 10, b.text(fmt.Sprintf("Thu hộ (COD): %s", formatVND(label.COD))), "1", 1, "C", false, 0, "")
+}
+
+// AddPackingSlip appends an A4 packing slip listing the parcel's order lines
+func (b *ShippingBatch) AddPackingSlip(slip *PackingSlip) {
+	pdf := b.pdf
+	pdf.AddPageFormat("P", slipPageSize)
+	b.pages++
+
+	const margin = 15.0
+	width := slipPageSize.Wd - 2*margin
+
+	b.setFont("B", 18)
+	pdf.SetXY(margin, margin)
+	pdf.CellFormat(width/2, 10, b.text("PHIẾU ĐÓNG GÓI"), "", 0, "L", false, 0, "")
+	b.barcode(slip.OrderNumber, margin+width/2, margin, width/2, 14)
+
+	b.setFont("", 10)
+	pdf.SetXY(margin+width/2, margin+15)
+	pdf.CellFormat(width/2, 5, b.text(slip.OrderNumber), "", 1, "C", false, 0, "")
+
+	y := margin + 24
+	rows := [][2]string{
+		{"Mã đơn hàng", slip.OrderNumber},
+		{"Ngày đặt", slip.OrderDate.Format("02/01/2006 15:04")},
+		{"Khách hàng", slip.CustomerName},
+		{"Điện thoại", slip.CustomerPhone},
+		{"Địa chỉ giao", slip.ShippingAddress},
+	}
+	if slip.Carrier != "" {
+		rows = append(rows, [2]string{"Vận chuyển", strings.TrimSpace(slip.Carrier + " " + slip.TrackingCode)})
+	}
+	for _, row := range rows {
+		pdf.SetXY(margin, y)
+		b.setFont("B", 10)
+		pdf.CellFormat(35, 6, b.text(row[0]), "", 0, "L", false, 0, "")
+		b.setFont("", 10)
+		pdf.MultiCell(width-35, 6, b.text(row[1]), "", "L", false)
+		y = pdf.GetY()
+	}
+
+	// Order lines
+	y += 4
+	columns := []struct {
+		title string
+		width float64
+		align string
+	}{
+		{"#", 10, "C"},
+		{"SKU", 40, "L"},
+		{"Sản phẩm", width - 70, "L"},
+		{"SL", 20, "C"},
+	}
+
+	pdf.SetXY(margin, y)
+	b.setFont("B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, 7, b.text(col.title), "1", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	b.setFont("", 10)
+	totalQuantity := 0
+	for i, line := range slip.Lines {
+		if pdf.GetY() > slipPageSize.Ht-margin-20 {
+			pdf.AddPageFormat("P", slipPageSize)
+			b.pages++
+			pdf.SetY(margin)
+		}
+
+		name := line.Name
+		if line.Variant != "" {
+			name += " (" + line.Variant + ")"
+		}
+		values := []string{fmt.Sprintf("%d", i+1), line.SKU, name, fmt.Sprintf("%d", line.Quantity)}
+
+		pdf.SetX(margin)
+		for j, col := range columns {
+			pdf.CellFormat(col.width, 7, b.fit(b.text(values[j]), col.width-2), "1", 0, col.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+		totalQuantity += line.Quantity
+	}
+
+	pdf.SetX(margin)
+	b.setFont("B", 10)
+	pdf.CellFormat(width-20, 7, b.text("Tổng số lượng"), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(20, 7, fmt.Sprintf("%d", totalQuantity), "1", 1, "C", false, 0, "")
+
+	if slip.Note != "" {
+		pdf.Ln(4)
+		pdf.SetX(margin)
+		b.setFont("", 10)
+		pdf.MultiCell(width, 5, b.text(fmt.Sprintf("Ghi chú: %s", slip.Note)), "", "L", false)
+	}
+}
+
+// Output renders the batch to PDF bytes
+func (b *ShippingBatch) Output() ([]byte, error) {
+	if b.pages == 0 {
+		return nil, fmt.Errorf("batch has no pages")
+	}
+
+	var buf bytes.Buffer
+	if err := b.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// addressBlock prints a sender/recipient block and returns the y position below it
+func (b *ShippingBatch) addressBlock(title, name, phone, address string, x, y, width, size float64) float64 {
+	pdf := b.pdf
+	b.setFont("", 8)
+	pdf.SetXY(x, y)
+	pdf.CellFormat(width, 4, b.text(title), "", 1, "L", false, 0, "")
+
+	b.setFont("B", size)
+	pdf.SetX(x)
+	pdf.CellFormat(width, size/2+1, b.fit(b.text(strings.TrimSpace(name+" - "+phone)), width), "", 1, "L", false, 0, "")
+
+	b.setFont("", size-1)
+	pdf.SetX(x)
+	pdf.MultiCell(width, size/2, b.text(address), "", "L", false)
+	return pdf.GetY() + 2
+}
+
+// barcode draws a Code128 barcode; codes it cannot encode are skipped
+func (b *ShippingBatch) barcode(code string, x, y, w, h float64) bool {
+	if code == "" {
+		return false
+	}
+	for _, r := range code {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	key := barcode.RegisterCode128(b.pdf, code)
+	barcode.Barcode(b.pdf, key, x, y, w, h, false)
+	return true
+}
+
+func (b *ShippingBatch) setFont(style string, size float64) {
+	b.pdf.SetFont(b.family, style, size)
+}
+
+// fit truncates text to the given width
+func (b *ShippingBatch) fit(text string, width float64) string {
+	if b.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && b.pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// guard converts panics raised by the PDF importer into errors
+func (b *ShippingBatch) guard(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to import PDF: %v", r)
+		}
+	}()
+	fn()
+	return b.pdf.Error()
+}
+
+// pdfPageSizes reads the page sizes of a PDF in mm
+func pdfPageSizes(data []byte) (sizes []fpdf.SizeType, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	importer := realgofpdi.NewImporter()
+	rs := io.ReadSeeker(bytes.NewReader(data))
+	importer.SetSourceStream(&rs)
+
+	pages := importer.GetNumPages()
+	boxes := importer.GetPageSizes()
+	for page := 1; page <= pages; page++ {
+		size := labelPageSize
+		if box, ok := boxes[page]["/MediaBox"]; ok && box["w"] > 0 && box["h"] > 0 {
+			size = fpdf.SizeType{Wd: box["w"] / pointsPerMM, Ht: box["h"] / pointsPerMM}
+		}
+		sizes = append(sizes, size)
+	}
+
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("PDF has no pages")
+	}
+	return sizes, nil
+}
+
+// removeAccents converts Vietnamese text to plain ASCII for the core fonts
+func removeAccents(s string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			sb.WriteRune('d')
+		case r == 'Đ':
+			sb.WriteRune('D')
+		case r > unicode.MaxASCII:
+			sb.WriteRune('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// formatVND formats an amount as Vietnamese dong, e.g. 150.000 đ
+func formatVND(amount float64) string {
+	digits := fmt.Sprintf("%.0f", amount)
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 && digits[i-1] != '-' {
+			sb.WriteRune('.')
+		}
+		sb.WriteRune(d)
+	}
+	return sb.String() + " đ"
+}
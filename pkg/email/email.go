@@ -2,8 +2,11 @@ package email
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"go_app/pkg/logger"
 
@@ -62,6 +65,83 @@ func (e *EmailService) SendEmailVerification(to, otpCode string) error {
 	return e.sendEmail(to, subject, body)
 }
 
+// SendLoginAlertEmail warns the user about a login from a new device or country
+func (e *EmailService) SendLoginAlertEmail(to, ipAddress, country, userAgent string, loginAt time.Time) error {
+	subject := "Cảnh báo đăng nhập mới"
+	body := e.generateSecurityEmailBody(
+		"Phát hiện đăng nhập mới",
+		"Tài khoản của bạn vừa được đăng nhập từ một thiết bị hoặc vị trí chưa từng sử dụng trước đây.",
+		[][2]string{
+			{"Thời gian", loginAt.Format("02/01/2006 15:04:05")},
+			{"Địa chỉ IP", ipAddress},
+			{"Quốc gia", country},
+			{"Thiết bị", userAgent},
+		},
+		"Nếu không phải bạn, hãy đổi mật khẩu ngay và đăng xuất khỏi các phiên đăng nhập khác.",
+	)
+
+	return e.sendEmail(to, subject, body)
+}
+
+// SendAccountLockedEmail tells the user the account is temporarily locked after too many failed logins
+func (e *EmailService) SendAccountLockedEmail(to, ipAddress string, lockedUntil time.Time) error {
+	subject := "Tài khoản tạm thời bị khóa"
+	body := e.generateSecurityEmailBody(
+		"Tài khoản tạm thời bị khóa",
+		"Tài khoản của bạn đã bị khóa tạm thời do đăng nhập sai mật khẩu nhiều lần.",
+		[][2]string{
+			{"Địa chỉ IP", ipAddress},
+			{"Mở khóa lúc", lockedUntil.Format("02/01/2006 15:04:05")},
+		},
+		"Nếu không phải bạn, có thể ai đó đang cố đoán mật khẩu của bạn. Hãy đặt lại mật khẩu sau khi tài khoản được mở khóa.",
+	)
+
+	return e.sendEmail(to, subject, body)
+}
+
+// SendDataExportReadyEmail tells the user their personal data archive can be downloaded
+func (e *EmailService) SendDataExportReadyEmail(to string, expiresAt time.Time) error {
+	subject := "Bản sao dữ liệu cá nhân của bạn đã sẵn sàng"
+	body := e.generateSecurityEmailBody(
+		"Dữ liệu cá nhân đã sẵn sàng",
+		"Bản sao dữ liệu cá nhân bạn yêu cầu đã được tạo. Đăng nhập và mở mục Quyền riêng tư để tải về.",
+		[][2]string{
+			{"Hạn tải về", expiresAt.Format("02/01/2006 15:04:05")},
+		},
+		"Nếu bạn không yêu cầu, hãy đổi mật khẩu ngay vì có thể ai đó đang truy cập tài khoản của bạn.",
+	)
+
+	return e.sendEmail(to, subject, body)
+}
+
+// SendAccountErasureScheduledEmail confirms an account deletion request and when it takes effect
+func (e *EmailService) SendAccountErasureScheduledEmail(to string, scheduledAt time.Time) error {
+	subject := "Xác nhận yêu cầu xóa tài khoản"
+	body := e.generateSecurityEmailBody(
+		"Yêu cầu xóa tài khoản",
+		"Chúng tôi đã nhận được yêu cầu xóa tài khoản và dữ liệu cá nhân của bạn.",
+		[][2]string{
+			{"Thực hiện lúc", scheduledAt.Format("02/01/2006 15:04:05")},
+		},
+		"Bạn có thể hủy yêu cầu trước thời điểm trên trong mục Quyền riêng tư. Nếu không phải bạn, hãy đăng nhập, hủy yêu cầu và đổi mật khẩu.",
+	)
+
+	return e.sendEmail(to, subject, body)
+}
+
+// SendAccountErasedEmail confirms the account was deleted; it is sent to the address the account had
+func (e *EmailService) SendAccountErasedEmail(to string) error {
+	subject := "Tài khoản của bạn đã được xóa"
+	body := e.generateSecurityEmailBody(
+		"Tài khoản đã được xóa",
+		"Dữ liệu cá nhân của bạn đã được xóa theo yêu cầu.",
+		nil,
+		"Thông tin đơn hàng và thanh toán được lưu ẩn danh theo quy định về kế toán, không còn gắn với thông tin liên hệ của bạn.",
+	)
+
+	return e.sendEmail(to, subject, body)
+}
+
 func (e *EmailService) sendEmail(to, subject, body string) error {
 	if e.smtpUsername == "" || e.smtpPassword == "" || e.fromEmail == "" {
 		logger.Warn("Email configuration not set, skipping email send")
@@ -92,6 +172,8 @@ func (e *EmailService) generateOTPEmailBody(otpCode, otpType string) string {
 		action = "đặt lại mật khẩu"
 	case "email_verify":
 		action = "xác thực email"
+	case "two_factor":
+		action = "đăng nhập"
 	default:
 		action = "xác thực"
 	}
@@ -121,6 +203,38 @@ func (e *EmailService) generateOTPEmailBody(otpCode, otpType string) string {
 	`, action, otpCode, action)
 }
 
+func (e *EmailService) generateSecurityEmailBody(title, intro string, details [][2]string, advice string) string {
+	var rows strings.Builder
+	for _, detail := range details {
+		if detail[1] == "" {
+			continue
+		}
+		rows.WriteString(fmt.Sprintf(`<tr><td style="color: #999; padding: 4px 12px 4px 0;">%s</td><td style="color: #333;">%s</td></tr>`,
+			detail[0], html.EscapeString(detail[1])))
+	}
+
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<title>%s</title>
+		</head>
+		<body style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
+			<div style="background-color: #f8f9fa; padding: 30px; border-radius: 10px;">
+				<h2 style="color: #dc3545; text-align: center;">%s</h2>
+				<p style="color: #666; font-size: 16px;">Xin chào,</p>
+				<p style="color: #666; font-size: 16px;">%s</p>
+				<table style="font-size: 14px; margin: 20px 0;">%s</table>
+				<p style="color: #666; font-size: 14px;">%s</p>
+				<hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+				<p style="color: #999; font-size: 12px; text-align: center;">Email này được gửi tự động, vui lòng không trả lời.</p>
+			</div>
+		</body>
+		</html>
+	`, title, title, intro, rows.String(), advice)
+}
+
 func (e *EmailService) generatePasswordResetEmailBody(otpCode string) string {
 	return e.generateOTPEmailBody(otpCode, "password_reset")
 }
@@ -1,45 +1,112 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
+	"strings"
 	"time"
 
+	"go_app/configs"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const defaultAccessExpiry = 15 * time.Minute
+
+// Supported signing algorithms
+const (
+	AlgorithmHS256 = "HS256" // Shared secret, chỉ dùng khi một service duy nhất xác thực token
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
 type Claims struct {
 	UserID    uint   `json:"user_id"`
 	Username  string `json:"username"`
 	Email     string `json:"email"`
 	Role      string `json:"role"`
 	SessionID string `json:"session_id"`
+	// Actor is set when a support agent acts as this user; SessionID is then the agent's session
+	Actor *ActorClaims `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaims identifies who really makes the requests of an impersonation token (RFC 8693 "act")
+type ActorClaims struct {
+	UserID          uint   `json:"user_id"`
+	Username        string `json:"username"`
+	ImpersonationID uint   `json:"impersonation_id"`
+}
+
+// IsImpersonation checks if the token was issued to an agent acting as the user
+func (c *Claims) IsImpersonation() bool {
+	return c.Actor != nil
+}
+
 type JWTManager struct {
-	secretKey []byte
+	secretKey    []byte
+	algorithm    string
+	accessExpiry time.Duration
+	keys         *keyRing
 }
 
 func NewJWTManager() *JWTManager {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "default-secret-key" // Fallback for development
+	config := configs.Load().JWT
+
+	accessExpiry := defaultAccessExpiry
+	if config.AccessExpiry > 0 {
+		accessExpiry = time.Duration(config.AccessExpiry) * time.Minute
 	}
-	return &JWTManager{
-		secretKey: []byte(secretKey),
+
+	manager := &JWTManager{
+		secretKey:    []byte(config.SecretKey),
+		algorithm:    normalizeAlgorithm(config.Algorithm),
+		accessExpiry: accessExpiry,
 	}
-}
 
-// GenerateToken tạo JWT token mới
-func (j *JWTManager) GenerateToken(userID uint, username, email, role, sessionID string) (string, error) {
-	expireHours := 24
-	if hours := os.Getenv("JWT_EXPIRE_HOURS"); hours != "" {
-		if h, err := time.ParseDuration(hours + "h"); err == nil {
-			expireHours = int(h.Hours())
+	if manager.algorithm != AlgorithmHS256 {
+		rotateEvery := time.Duration(config.KeyRotationDays) * 24 * time.Hour
+		if rotateEvery <= 0 {
+			rotateEvery = 30 * 24 * time.Hour
 		}
+		// Khóa cũ phải còn trong JWKS ít nhất đến khi token cuối cùng nó ký hết hạn
+		retainFor := time.Duration(config.KeyRetentionHours) * time.Hour
+		if minRetain := accessExpiry + keyRefreshInterval; retainFor < minRetain {
+			retainFor = minRetain
+		}
+		manager.keys = getKeyRing(manager.algorithm, config.SecretKey, rotateEvery, retainFor)
 	}
 
+	return manager
+}
+
+// normalizeAlgorithm maps the JWT_ALGORITHM setting to a supported algorithm, defaulting to RS256
+func normalizeAlgorithm(algorithm string) string {
+	switch strings.ToUpper(strings.TrimSpace(algorithm)) {
+	case "HS256":
+		return AlgorithmHS256
+	case "EDDSA", "ED25519":
+		return AlgorithmEdDSA
+	default:
+		return AlgorithmRS256
+	}
+}
+
+// Algorithm trả về thuật toán ký access token
+func (j *JWTManager) Algorithm() string {
+	return j.algorithm
+}
+
+// AccessExpiry trả về thời hạn của access token
+func (j *JWTManager) AccessExpiry() time.Duration {
+	return j.accessExpiry
+}
+
+// GenerateToken tạo access token (JWT) ngắn hạn cho session
+func (j *JWTManager) GenerateToken(userID uint, username, email, role, sessionID string) (string, error) {
 	claims := Claims{
 		UserID:    userID,
 		Username:  username,
@@ -47,25 +114,80 @@ func (j *JWTManager) GenerateToken(userID uint, username, email, role, sessionID
 		Role:      role,
 		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expireHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.accessExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "go_app",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	return j.sign(claims)
+}
+
+// GenerateImpersonationToken tạo access token cho nhân viên hỗ trợ đăng nhập thay người dùng.
+// Token không có refresh token và hết hạn cùng lúc với phiên đăng nhập thay.
+func (j *JWTManager) GenerateImpersonationToken(userID uint, username, email, role, actorSessionID string, actor ActorClaims, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		SessionID: actorSessionID,
+		Actor:     &actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "go_app",
+		},
+	}
+
+	return j.sign(claims)
+}
+
+// sign ký claims bằng khóa hiện tại (hoặc secret khi dùng HS256)
+func (j *JWTManager) sign(claims Claims) (string, error) {
+	if j.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(j.secretKey)
+	}
+
+	key, err := j.keys.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
 }
 
 // ValidateToken xác thực JWT token
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return j.secretKey, nil
-	})
+	var (
+		token *jwt.Token
+		err   error
+	)
+	if j.keys == nil {
+		token, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			return j.secretKey, nil
+		}, jwt.WithValidMethods([]string{AlgorithmHS256}))
+	} else {
+		// Token cũ có thể được ký bằng thuật toán khác nếu JWT_ALGORITHM vừa đổi
+		token, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			if kid == "" {
+				return nil, errors.New("missing key id")
+			}
+			key, err := j.keys.lookup(kid)
+			if err != nil {
+				return nil, err
+			}
+			if token.Method.Alg() != key.algorithm {
+				return nil, errors.New("unexpected signing method")
+			}
+			return key.public, nil
+		}, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
+	}
 
 	if err != nil {
 		return nil, err
@@ -78,13 +200,43 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken làm mới token
-func (j *JWTManager) RefreshToken(tokenString string) (string, error) {
-	claims, err := j.ValidateToken(tokenString)
-	if err != nil {
+// EnsureSigningKey makes sure a usable signing key exists, generating the first one on a new database
+func (j *JWTManager) EnsureSigningKey() error {
+	if j.keys == nil {
+		return nil
+	}
+	_, err := j.keys.current()
+	return err
+}
+
+// RotateSigningKey generates a new signing key once the active one is older than the rotation
+// period; retired keys keep verifying tokens until they drop out of the JWKS
+func (j *JWTManager) RotateSigningKey() (bool, error) {
+	if j.keys == nil {
+		return false, nil
+	}
+	return j.keys.rotate()
+}
+
+// JWKS returns the public keys other services use to verify access tokens
+func (j *JWTManager) JWKS() (*JWKS, error) {
+	if j.keys == nil {
+		return &JWKS{Keys: []JWK{}}, nil
+	}
+	return j.keys.jwks()
+}
+
+// GenerateRefreshToken tạo refresh token ngẫu nhiên (opaque), chỉ lưu bản băm vào database
+func GenerateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	// Tạo token mới với thông tin từ token cũ
-	return j.GenerateToken(claims.UserID, claims.Username, claims.Email, claims.Role, claims.SessionID)
+// HashRefreshToken băm refresh token (SHA-256) để lưu và tra cứu
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,403 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"go_app/pkg/logger"
+	"go_app/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	rsaKeyBits = 2048
+
+	// keyRefreshInterval là chu kỳ đọc lại khóa từ database để nhận khóa do instance khác xoay vòng
+	keyRefreshInterval = time.Minute
+	// unknownKIDReloadInterval giới hạn số lần đọc lại khi gặp kid chưa biết
+	unknownKIDReloadInterval = 10 * time.Second
+)
+
+// StoredKey is a signing key as persisted by a KeyStore
+type StoredKey struct {
+	KID        string
+	Algorithm  string
+	PublicKey  string // PEM (PKIX)
+	PrivateKey string // PEM (PKCS8) mã hóa AES-GCM bằng JWT_SECRET
+	Active     bool   // false khi đã ngừng ký nhưng còn dùng để xác thực
+}
+
+// KeyStore persists the signing keys shared by all app instances
+type KeyStore interface {
+	// GetPublishedKeys returns the active key and the retired keys still accepted, newest first
+	GetPublishedKeys() ([]*StoredKey, error)
+	// RotateActiveKey retires the active key and stores next as the new one; unless force is set,
+	// nothing happens when the active key was created after dueBefore
+	RotateActiveKey(next *StoredKey, dueBefore, retireUntil time.Time, force bool) (bool, error)
+	// DeleteExpired removes retired keys that are no longer published
+	DeleteExpired() (int64, error)
+}
+
+var (
+	keyStore   KeyStore
+	keyStoreMu sync.RWMutex
+)
+
+// SetKeyStore sets where asymmetric signing keys are stored; it must be called before tokens are
+// signed or verified with RS256 or EdDSA
+func SetKeyStore(store KeyStore) {
+	keyStoreMu.Lock()
+	keyStore = store
+	keyStoreMu.Unlock()
+}
+
+func getKeyStore() (KeyStore, error) {
+	keyStoreMu.RLock()
+	defer keyStoreMu.RUnlock()
+	if keyStore == nil {
+		return nil, errors.New("signing key store is not configured")
+	}
+	return keyStore, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the key set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// signingKey is a decoded key pair from the jwt_signing_keys table
+type signingKey struct {
+	kid       string
+	algorithm string
+	method    jwt.SigningMethod
+	public    crypto.PublicKey
+	private   crypto.Signer // nil khi không giải mã được (JWT_SECRET đã đổi)
+	active    bool
+}
+
+// keyRing caches the published signing keys of all app instances
+type keyRing struct {
+	mu             sync.RWMutex
+	algorithm      string
+	cipherKey      []byte
+	rotateEvery    time.Duration
+	retainFor      time.Duration
+	active         *signingKey
+	keys           map[string]*signingKey
+	activeUnusable bool // Có khóa active nhưng instance này không dùng được để ký
+	loadedAt       time.Time
+}
+
+var (
+	sharedKeyRing     *keyRing
+	sharedKeyRingOnce sync.Once
+)
+
+// getKeyRing returns the process-wide key ring; JWTManager is created per request in places
+func getKeyRing(algorithm, secret string, rotateEvery, retainFor time.Duration) *keyRing {
+	sharedKeyRingOnce.Do(func() {
+		sharedKeyRing = &keyRing{
+			algorithm:   algorithm,
+			cipherKey:   utils.DeriveKey(secret, "jwt-signing-key"),
+			rotateEvery: rotateEvery,
+			retainFor:   retainFor,
+			keys:        make(map[string]*signingKey),
+		}
+	})
+	return sharedKeyRing
+}
+
+// load reads the published keys from the key store
+func (r *keyRing) load() error {
+	store, err := getKeyStore()
+	if err != nil {
+		return err
+	}
+	records, err := store.GetPublishedKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make(map[string]*signingKey, len(records))
+	var active *signingKey
+	activeUnusable := false
+	for _, record := range records {
+		key, err := r.decodeKey(record)
+		if err != nil {
+			logger.Warnf("Skipping JWT signing key %s: %v", record.KID, err)
+			if record.Active {
+				activeUnusable = true
+			}
+			continue
+		}
+		keys[key.kid] = key
+
+		if !key.active || active != nil {
+			continue
+		}
+		if key.private == nil || key.algorithm != r.algorithm {
+			activeUnusable = true
+			continue
+		}
+		active = key
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.active = active
+	r.activeUnusable = activeUnusable && active == nil
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// current returns the key used for signing, loading or generating one when needed
+func (r *keyRing) current() (*signingKey, error) {
+	r.mu.RLock()
+	active, stale := r.active, time.Since(r.loadedAt) > keyRefreshInterval
+	r.mu.RUnlock()
+
+	if active == nil || stale {
+		if err := r.load(); err != nil {
+			if active != nil {
+				logger.Warnf("Using cached JWT signing key: %v", err)
+				return active, nil
+			}
+			return nil, err
+		}
+		r.mu.RLock()
+		active = r.active
+		r.mu.RUnlock()
+	}
+
+	if active == nil {
+		if _, err := r.rotate(); err != nil {
+			return nil, err
+		}
+		r.mu.RLock()
+		active = r.active
+		r.mu.RUnlock()
+		if active == nil {
+			return nil, errors.New("no usable signing key")
+		}
+	}
+	return active, nil
+}
+
+// lookup returns the verification key for kid, reloading once in a while for keys created elsewhere
+func (r *keyRing) lookup(kid string) (*signingKey, error) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	sinceLoad := time.Since(r.loadedAt)
+	r.mu.RUnlock()
+
+	if ok && sinceLoad <= keyRefreshInterval {
+		return key, nil
+	}
+	if !ok && sinceLoad < unknownKIDReloadInterval {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if err := r.load(); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	r.mu.RLock()
+	key, ok = r.keys[kid]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	return key, nil
+}
+
+// rotate generates a new signing key when the active one is due (or unusable) and retires the old one
+func (r *keyRing) rotate() (bool, error) {
+	r.mu.RLock()
+	force := r.activeUnusable
+	r.mu.RUnlock()
+
+	store, err := getKeyStore()
+	if err != nil {
+		return false, err
+	}
+	record, err := r.generateKey()
+	if err != nil {
+		return false, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	now := time.Now()
+	rotated, err := store.RotateActiveKey(record, now.Add(-r.rotateEvery), now.Add(r.retainFor), force)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate signing key: %w", err)
+	}
+	if rotated {
+		logger.Infof("Rotated JWT signing key, new kid %s (%s)", record.KID, record.Algorithm)
+	}
+
+	if removed, err := store.DeleteExpired(); err != nil {
+		logger.Warnf("Failed to delete expired JWT signing keys: %v", err)
+	} else if removed > 0 {
+		logger.Infof("Deleted %d expired JWT signing keys", removed)
+	}
+
+	if err := r.load(); err != nil {
+		return rotated, err
+	}
+	return rotated, nil
+}
+
+// jwks returns the public part of every published key
+func (r *keyRing) jwks() (*JWKS, error) {
+	r.mu.RLock()
+	stale := time.Since(r.loadedAt) > keyRefreshInterval
+	r.mu.RUnlock()
+	if stale {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := &JWKS{Keys: make([]JWK, 0, len(r.keys))}
+	for _, key := range r.keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// generateKey creates a new key pair for the configured algorithm, with the private key encrypted
+func (r *keyRing) generateKey() (*StoredKey, error) {
+	var (
+		public  crypto.PublicKey
+		private crypto.Signer
+	)
+	switch r.algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		public, private = &key.PublicKey, key
+	case AlgorithmEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		public, private = pub, priv
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", r.algorithm)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptString(r.cipherKey, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	return &StoredKey{
+		KID:        time.Now().Format("20060102") + "-" + hex.EncodeToString(suffix),
+		Algorithm:  r.algorithm,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		PrivateKey: encrypted,
+	}, nil
+}
+
+// decodeKey parses a stored key; the private key is left nil when it cannot be decrypted
+func (r *keyRing) decodeKey(record *StoredKey) (*signingKey, error) {
+	method := jwt.GetSigningMethod(record.Algorithm)
+	if method == nil || (record.Algorithm != AlgorithmRS256 && record.Algorithm != AlgorithmEdDSA) {
+		return nil, fmt.Errorf("unsupported algorithm %q", record.Algorithm)
+	}
+
+	block, _ := pem.Decode([]byte(record.PublicKey))
+	if block == nil {
+		return nil, errors.New("invalid public key PEM")
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		kid:       record.KID,
+		algorithm: record.Algorithm,
+		method:    method,
+		public:    public,
+		active:    record.Active,
+	}
+	if !key.active {
+		return key, nil
+	}
+
+	privatePEM, err := utils.DecryptString(r.cipherKey, record.PrivateKey)
+	if err != nil {
+		logger.Warnf("Cannot decrypt JWT signing key %s, was JWT_SECRET changed? %v", record.KID, err)
+		return key, nil
+	}
+	if block, _ = pem.Decode([]byte(privatePEM)); block == nil {
+		return key, nil
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return key, nil
+	}
+	if signer, ok := private.(crypto.Signer); ok {
+		key.private = signer
+	}
+	return key, nil
+}
@@ -1,32 +1,60 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"go_app/internal/model"
 	"go_app/internal/repository"
+	"go_app/internal/service"
 	"go_app/pkg/jwt"
 	"go_app/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Authentication methods stored as "auth_method" in the request context
+const (
+	AuthMethodSession       = "session"
+	AuthMethodAPIKey        = "api_key"
+	AuthMethodImpersonation = "impersonation" // Support agent acting as a customer
+)
+
 type AuthMiddleware struct {
-	jwtManager  *jwt.JWTManager
-	sessionRepo repository.SessionRepository
+	jwtManager           *jwt.JWTManager
+	sessionRepo          repository.SessionRepository
+	apiKeyService        *service.APIKeyService
+	impersonationService *service.ImpersonationService
 }
 
 func NewAuthMiddleware() *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtManager:  jwt.NewJWTManager(),
-		sessionRepo: repository.NewSessionRepository(),
+		jwtManager:           jwt.NewJWTManager(),
+		sessionRepo:          repository.NewSessionRepository(),
+		apiKeyService:        service.NewAPIKeyService(),
+		impersonationService: service.NewImpersonationService(),
 	}
 }
 
-// AuthMiddleware validates JWT token and session
+// AuthMiddleware validates JWT token and session, or an API key sent as X-API-Key or "Bearer ak_..."
 func (m *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := extractAPIKey(c); rawKey != "" {
+			if err := m.authenticateAPIKey(c, rawKey); err != nil {
+				status := http.StatusUnauthorized
+				if strings.HasPrefix(err.Error(), "failed to") {
+					status = http.StatusInternalServerError
+				}
+				response.ErrorResponse(c, status, "Invalid API key", err.Error())
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -61,24 +89,39 @@ func (m *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if session exists and is active
-		session, err := m.sessionRepo.GetByToken(token)
+		// Check if session is still active (cached in Redis for a short while)
+		session, err := m.sessionRepo.GetValidSession(uint(sessionID))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusUnauthorized, "Session not found")
 			c.Abort()
 			return
 		}
-
-		// Verify session ID matches
-		if session.ID != uint(sessionID) {
-			response.ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
+		if session == nil {
+			response.ErrorResponse(c, http.StatusUnauthorized, "Session expired or inactive")
 			c.Abort()
 			return
 		}
 
-		// Check if session is valid (active and not expired)
-		if !session.IsValid() {
-			response.ErrorResponse(c, http.StatusUnauthorized, "Session expired or inactive")
+		// Impersonation tokens carry the agent's session and act as the customer
+		if claims.IsImpersonation() {
+			impersonation, err := m.authenticateImpersonation(c, claims, session)
+			if err != nil {
+				status := http.StatusUnauthorized
+				if strings.HasPrefix(err.Error(), "failed to") {
+					status = http.StatusInternalServerError
+				}
+				response.ErrorResponse(c, status, "Invalid impersonation token", err.Error())
+				c.Abort()
+				return
+			}
+			c.Next()
+			m.impersonationService.RecordRequest(impersonation, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), impersonationRequestInfo(c))
+			return
+		}
+
+		// Verify session belongs to the token's user
+		if session.UserID != claims.UserID {
+			response.ErrorResponse(c, http.StatusUnauthorized, "Invalid session")
 			c.Abort()
 			return
 		}
@@ -90,6 +133,7 @@ func (m *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 		c.Set("role", claims.Role)
 		c.Set("session_id", session.ID)
 		c.Set("session_token", token)
+		c.Set("auth_method", AuthMethodSession)
 
 		c.Next()
 	}
@@ -107,9 +151,54 @@ func (m *AuthMiddleware) ModeratorMiddleware() gin.HandlerFunc {
 	return roleHelper.RequireAnyRole("admin", "moderator")
 }
 
-// OptionalAuthMiddleware validates JWT token if present but doesn't require it
+// SessionOnlyMiddleware rejects requests authenticated with an API key or made while impersonating.
+// Used for account management (password, sessions, 2FA, API keys themselves) that only the
+// signed-in account holder may do.
+func (m *AuthMiddleware) SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.GetString("auth_method") {
+		case AuthMethodAPIKey:
+			response.ErrorResponse(c, http.StatusForbidden, "This endpoint is not available to API keys", "sign in with a user session")
+			c.Abort()
+			return
+		case AuthMethodImpersonation:
+			m.blockImpersonation(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// NoImpersonationMiddleware blocks sensitive actions such as payments while a support agent
+// is acting as the customer; the attempt is written to the audit log
+func (m *AuthMiddleware) NoImpersonationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodImpersonation {
+			m.blockImpersonation(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (m *AuthMiddleware) blockImpersonation(c *gin.Context) {
+	if value, exists := c.Get("impersonation"); exists {
+		impersonation := value.(*model.Impersonation)
+		m.impersonationService.RecordBlocked(impersonation, c.Request.Method, c.Request.URL.Path, impersonationRequestInfo(c))
+	}
+	response.ErrorResponse(c, http.StatusForbidden, "This action is not allowed while impersonating a customer", "stop impersonating and ask the customer to do it")
+	c.Abort()
+}
+
+// OptionalAuthMiddleware validates JWT token or API key if present but doesn't require it
 func (m *AuthMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := extractAPIKey(c); rawKey != "" {
+			_ = m.authenticateAPIKey(c, rawKey)
+			c.Next()
+			return
+		}
+
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -140,21 +229,24 @@ func (m *AuthMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if session exists and is active
-		session, err := m.sessionRepo.GetByToken(token)
-		if err != nil {
+		// Check if session is still active (cached in Redis for a short while)
+		session, err := m.sessionRepo.GetValidSession(uint(sessionID))
+		if err != nil || session == nil {
 			c.Next()
 			return
 		}
 
-		// Verify session ID matches
-		if session.ID != uint(sessionID) {
+		if claims.IsImpersonation() {
+			impersonation, err := m.authenticateImpersonation(c, claims, session)
 			c.Next()
+			if err == nil {
+				m.impersonationService.RecordRequest(impersonation, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), impersonationRequestInfo(c))
+			}
 			return
 		}
 
-		// Check if session is valid (active and not expired)
-		if !session.IsValid() {
+		// Verify session belongs to the token's user
+		if session.UserID != claims.UserID {
 			c.Next()
 			return
 		}
@@ -166,7 +258,90 @@ func (m *AuthMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
 		c.Set("role", claims.Role)
 		c.Set("session_id", session.ID)
 		c.Set("session_token", token)
+		c.Set("auth_method", AuthMethodSession)
 
 		c.Next()
 	}
 }
+
+// authenticateAPIKey validates the key and stores its owner and scopes in the context
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, rawKey string) error {
+	key, err := m.apiKeyService.Authenticate(rawKey, service.AuditRequestInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		return err
+	}
+
+	role := ""
+	if key.User.UserRole != nil {
+		role = key.User.UserRole.Name
+	}
+
+	c.Set("user_id", key.UserID)
+	c.Set("username", key.User.Username)
+	c.Set("email", key.User.Email)
+	c.Set("role", role)
+	c.Set("auth_method", AuthMethodAPIKey)
+	c.Set("api_key_id", key.ID)
+	c.Set("api_key_scopes", key.ScopeList())
+	return nil
+}
+
+// extractAPIKey returns the raw API key from X-API-Key or an "ak_" bearer token, if any
+func extractAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) == 2 && tokenParts[0] == "Bearer" && strings.HasPrefix(tokenParts[1], model.APIKeyPrefix) {
+		return tokenParts[1]
+	}
+	return ""
+}
+
+// apiKeyScopeAllows checks the API key scopes of the request, if it was authenticated with a key.
+// Requests with a user session are not limited by scopes.
+func apiKeyScopeAllows(c *gin.Context, resource model.ResourceType, action model.PermissionType) bool {
+	scopes, exists := c.Get("api_key_scopes")
+	if !exists {
+		return true
+	}
+	list, _ := scopes.([]string)
+	return model.APIKeyScopeAllows(list, resource, action)
+}
+
+// authenticateImpersonation checks the agent's session and the impersonation record, then stores
+// the customer as the user and the agent as the impersonator in the context
+func (m *AuthMiddleware) authenticateImpersonation(c *gin.Context, claims *jwt.Claims, session *model.Session) (*model.Impersonation, error) {
+	if session.UserID != claims.Actor.UserID {
+		return nil, errors.New("invalid session")
+	}
+
+	impersonation, err := m.impersonationService.ValidateImpersonation(claims.Actor.ImpersonationID, claims.Actor.UserID, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("auth_method", AuthMethodImpersonation)
+	c.Set("impersonation", impersonation)
+	c.Set("impersonation_id", impersonation.ID)
+	c.Set("impersonator_id", claims.Actor.UserID)
+
+	// Lets the frontend show the "acting as customer" banner on every response
+	c.Header("X-Impersonation-Id", strconv.FormatUint(uint64(impersonation.ID), 10))
+	c.Header("X-Impersonated-By", claims.Actor.Username)
+	return impersonation, nil
+}
+
+func impersonationRequestInfo(c *gin.Context) service.AuditRequestInfo {
+	return service.AuditRequestInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
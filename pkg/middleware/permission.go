@@ -31,9 +31,16 @@ func PermissionMiddleware(resource model.ResourceType, action model.PermissionTy
 			}
 		}
 
+		// API keys are limited to their scopes
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+
 		// Check permission
 		permissionService := service.NewPermissionService()
-		permissionCheck, err := permissionService.CheckPermission(userID.(uint), resource, action, resourceID)
+		permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), resource, action, resourceID, requestPermissionAttributes(c))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 			c.Abort()
@@ -75,9 +82,16 @@ func ResourcePermissionMiddleware(resource model.ResourceType, action model.Perm
 			}
 		}
 
+		// API keys are limited to their scopes
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+
 		// Check permission
 		permissionService := service.NewPermissionService()
-		permissionCheck, err := permissionService.CheckPermission(userID.(uint), resource, action, resourceID)
+		permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), resource, action, resourceID, requestPermissionAttributes(c))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 			c.Abort()
@@ -100,6 +114,56 @@ func ResourcePermissionMiddleware(resource model.ResourceType, action model.Perm
 	}
 }
 
+// AttributePermissionMiddleware checks permission like PermissionMiddleware, but lets a grant whose
+// conditions need resource attributes (e.g. the amount of a movement) through as conditional. The
+// handler must then check the conditions against the loaded resource before acting.
+func AttributePermissionMiddleware(resource model.ResourceType, action model.PermissionType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			response.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", "user_id not found in context")
+			c.Abort()
+			return
+		}
+
+		var resourceID *uint
+		if idStr := c.Param("id"); idStr != "" {
+			if id, err := strconv.ParseUint(idStr, 10, 32); err == nil {
+				resourceIDUint := uint(id)
+				resourceID = &resourceIDUint
+			}
+		}
+
+		// API keys are limited to their scopes
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+
+		permissionService := service.NewPermissionService()
+		permissionCheck, err := permissionService.CheckPermissionDeferred(userID.(uint), resource, action, resourceID, requestPermissionAttributes(c))
+		if err != nil {
+			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !permissionCheck.HasPermission {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", permissionCheck.Reason)
+			c.Abort()
+			return
+		}
+
+		c.Set("permission_source", permissionCheck.Source)
+		c.Set("permission_resource", resource)
+		c.Set("permission_action", action)
+		c.Set("permission_conditional", permissionCheck.Conditional)
+
+		c.Next()
+	}
+}
+
 // MultiplePermissionMiddleware checks if user has any of the specified permissions
 func MultiplePermissionMiddleware(permissions []PermissionRequirement) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -116,6 +180,10 @@ func MultiplePermissionMiddleware(permissions []PermissionRequirement) gin.Handl
 		var lastError error
 
 		for _, perm := range permissions {
+			if !apiKeyScopeAllows(c, perm.Resource, perm.Action) {
+				continue
+			}
+
 			// Get resource ID if specified
 			var resourceID *uint
 			if perm.ResourceIDParam != "" {
@@ -127,7 +195,7 @@ func MultiplePermissionMiddleware(permissions []PermissionRequirement) gin.Handl
 				}
 			}
 
-			permissionCheck, err := permissionService.CheckPermission(userID.(uint), perm.Resource, perm.Action, resourceID)
+			permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), perm.Resource, perm.Action, resourceID, requestPermissionAttributes(c))
 			if err != nil {
 				lastError = err
 				continue
@@ -188,6 +256,19 @@ func DeletePermissionMiddleware(resource model.ResourceType) gin.HandlerFunc {
 	return PermissionMiddleware(resource, model.PermissionTypeDelete)
 }
 
+// APIKeyScopeMiddleware checks only the API key scope. Used on endpoints every signed-in user may
+// call for their own data, so an API key still needs a scope that matches the route.
+func APIKeyScopeMiddleware(resource model.ResourceType, action model.PermissionType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // OwnershipPermissionMiddleware checks if user owns the resource or has admin permission
 func OwnershipPermissionMiddleware(resource model.ResourceType, ownerIDParam string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -221,9 +302,14 @@ func OwnershipPermissionMiddleware(resource model.ResourceType, ownerIDParam str
 			return
 		}
 
-		// Check if user has admin permission
+		// Check if user has admin permission (within the API key scopes, if any)
+		if !apiKeyScopeAllows(c, resource, model.PermissionTypeAdmin) {
+			response.ErrorResponse(c, http.StatusForbidden, "Access denied", "API key requires scope "+model.GetPermissionName(resource, model.PermissionTypeAdmin))
+			c.Abort()
+			return
+		}
 		permissionService := service.NewPermissionService()
-		permissionCheck, err := permissionService.CheckPermission(userID.(uint), resource, model.PermissionTypeAdmin, nil)
+		permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), resource, model.PermissionTypeAdmin, nil, requestPermissionAttributes(c))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 			c.Abort()
@@ -285,9 +371,16 @@ func ResourceTypePermissionMiddleware(resourceTypeParam string, action model.Per
 			}
 		}
 
+		// API keys are limited to their scopes
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+
 		// Check permission
 		permissionService := service.NewPermissionService()
-		permissionCheck, err := permissionService.CheckPermission(userID.(uint), resource, action, resourceID)
+		permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), resource, action, resourceID, requestPermissionAttributes(c))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 			c.Abort()
@@ -332,9 +425,16 @@ func AuditPermissionMiddleware(resource model.ResourceType, action model.Permiss
 			}
 		}
 
+		// API keys are limited to their scopes
+		if !apiKeyScopeAllows(c, resource, action) {
+			response.ErrorResponse(c, http.StatusForbidden, "Insufficient API key scope", "API key requires scope "+model.GetPermissionName(resource, action))
+			c.Abort()
+			return
+		}
+
 		// Check permission
 		permissionService := service.NewPermissionService()
-		permissionCheck, err := permissionService.CheckPermission(userID.(uint), resource, action, resourceID)
+		permissionCheck, err := permissionService.CheckPermissionWithAttributes(userID.(uint), resource, action, resourceID, requestPermissionAttributes(c))
 		if err != nil {
 			response.ErrorResponse(c, http.StatusInternalServerError, "Failed to check permission", err.Error())
 			c.Abort()
@@ -425,3 +525,22 @@ var (
 	SystemManagePermission = NewPermissionRequirement(model.ResourceTypeSystem, model.PermissionTypeManage, "")
 	SystemAdminPermission  = NewPermissionRequirement(model.ResourceTypeSystem, model.PermissionTypeAdmin, "")
 )
+
+// requestPermissionAttributes collects the request attributes that permission conditions can use,
+// e.g. {"attribute":"ip","operator":"cidr","value":"10.0.0.0/8"}, and keeps them in the context so
+// handlers can add resource attributes for a second check
+func requestPermissionAttributes(c *gin.Context) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"ip":          c.ClientIP(),
+		"auth_method": c.GetString("auth_method"),
+		"role":        c.GetString("role"),
+	}
+	if apiKeyID, exists := c.Get("api_key_id"); exists {
+		attributes["api_key_id"] = apiKeyID
+	}
+	if impersonatorID, exists := c.Get("impersonator_id"); exists {
+		attributes["impersonator_id"] = impersonatorID
+	}
+	c.Set("permission_attributes", attributes)
+	return attributes
+}
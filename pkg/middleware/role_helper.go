@@ -29,11 +29,11 @@ func (h *RoleHelper) CheckUserRole(c *gin.Context, roleName string) bool {
 	}
 
 	user, err := h.userRepo.GetByID(userID.(uint))
-	if err != nil || user == nil || user.UserRole == nil {
+	if err != nil || user == nil {
 		return false
 	}
 
-	return user.UserRole.Name == roleName
+	return user.HasRole(roleName)
 }
 
 // CheckUserAnyRole checks if the authenticated user has any of the specified roles
@@ -44,17 +44,11 @@ func (h *RoleHelper) CheckUserAnyRole(c *gin.Context, roleNames ...string) bool
 	}
 
 	user, err := h.userRepo.GetByID(userID.(uint))
-	if err != nil || user == nil || user.UserRole == nil {
+	if err != nil || user == nil {
 		return false
 	}
 
-	for _, roleName := range roleNames {
-		if user.UserRole.Name == roleName {
-			return true
-		}
-	}
-
-	return false
+	return user.HasAnyRole(roleNames...)
 }
 
 // RequireRole middleware that requires a specific role
@@ -74,7 +68,7 @@ func (h *RoleHelper) RequireRole(roleName string) gin.HandlerFunc {
 			return
 		}
 
-		if user.UserRole == nil || user.UserRole.Name != roleName {
+		if !user.HasRole(roleName) {
 			response.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", "Required role: "+roleName)
 			c.Abort()
 			return
@@ -101,21 +95,7 @@ func (h *RoleHelper) RequireAnyRole(roleNames ...string) gin.HandlerFunc {
 			return
 		}
 
-		if user.UserRole == nil {
-			response.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", "No role assigned")
-			c.Abort()
-			return
-		}
-
-		hasRole := false
-		for _, roleName := range roleNames {
-			if user.UserRole.Name == roleName {
-				hasRole = true
-				break
-			}
-		}
-
-		if !hasRole {
+		if !user.HasAnyRole(roleNames...) {
 			response.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", "Required one of roles: "+joinStrings(roleNames, ", "))
 			c.Abort()
 			return
@@ -0,0 +1,303 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Client credentials the mock issuer accepts
+const (
+	MockClientID     = "mock-client"
+	MockClientSecret = "mock-secret"
+)
+
+const mockKeyID = "mock-key"
+
+// MockServerConfig represents the configuration of the mock OpenID Connect issuer
+type MockServerConfig struct {
+	Issuer       string // Public URL the server is mounted at, e.g. http://localhost:8080/oauth/mock
+	ClientID     string
+	ClientSecret string
+	DefaultEmail string // Identity used when the authorize request has no login_hint
+}
+
+// MockServer is an in-memory OpenID Connect issuer for offline development and tests. Its
+// authorize endpoint approves every request at once, for the email given as login_hint, so
+// the whole authorization code + PKCE flow can be driven with curl. Add email_verified=false
+// to the authorize URL to simulate an unverified email.
+type MockServer struct {
+	config MockServerConfig
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	tokens map[string]mockGrant
+}
+
+type mockGrant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	emailVerified bool
+	expiresAt     time.Time
+}
+
+// NewMockServer creates a mock issuer with a fresh signing key
+func NewMockServer(config MockServerConfig) (*MockServer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if config.DefaultEmail == "" {
+		config.DefaultEmail = "mock.user@example.com"
+	}
+
+	return &MockServer{
+		config: config,
+		key:    key,
+		codes:  make(map[string]mockGrant),
+		tokens: make(map[string]mockGrant),
+	}, nil
+}
+
+// NewMockProvider creates the OIDC provider that signs in through the mock issuer
+func NewMockProvider(config MockServerConfig) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         ProviderMock,
+		Issuer:       config.Issuer,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+	})
+}
+
+// ServeHTTP routes the OpenID Connect endpoints; the server expects its mount prefix stripped
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		s.discovery(w)
+	case "/authorize":
+		s.authorize(w, r)
+	case "/token":
+		s.token(w, r)
+	case "/userinfo":
+		s.userInfo(w, r)
+	case "/jwks":
+		s.jwks(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *MockServer) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.config.Issuer,
+		"authorization_endpoint":                s.config.Issuer + "/authorize",
+		"token_endpoint":                        s.config.Issuer + "/token",
+		"userinfo_endpoint":                     s.config.Issuer + "/userinfo",
+		"jwks_uri":                              s.config.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *MockServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != s.config.ClientID || redirectURI == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "unknown client or missing redirect_uri")
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "PKCE with S256 is required")
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(query.Get("login_hint")))
+	if email == "" {
+		email = s.config.DefaultEmail
+	}
+
+	code, err := randomToken(24)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.purgeExpired()
+	s.codes[code] = mockGrant{
+		clientID:      s.config.ClientID,
+		redirectURI:   redirectURI,
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		email:         email,
+		emailVerified: query.Get("email_verified") != "false",
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	target, err := buildURL(redirectURI, url.Values{"code": {code}, "state": {query.Get("state")}})
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (s *MockServer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOAuthError(w, http.StatusMethodNotAllowed, "invalid_request", "POST required")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.config.ClientID || clientSecret != s.config.ClientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	// Codes are single-use
+	s.mu.Lock()
+	grant, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(grant.expiresAt) || grant.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid or expired code")
+		return
+	}
+	if CodeChallenge(r.PostForm.Get("code_verifier")) != grant.codeChallenge {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.config.Issuer,
+		"sub":            s.subject(grant.email),
+		"aud":            grant.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.email,
+		"email_verified": grant.emailVerified,
+		"name":           strings.Split(grant.email, "@")[0],
+	})
+	idToken.Header["kid"] = mockKeyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	accessToken, err := randomToken(24)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	grant.expiresAt = now.Add(time.Hour)
+	s.mu.Lock()
+	s.tokens[accessToken] = grant
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, Token{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		IDToken:     signed,
+		ExpiresIn:   3600,
+	})
+}
+
+func (s *MockServer) userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	grant, ok := s.tokens[accessToken]
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(grant.expiresAt) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "invalid or expired access token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            s.subject(grant.email),
+		"email":          grant.email,
+		"email_verified": grant.emailVerified,
+		"name":           strings.Split(grant.email, "@")[0],
+	})
+}
+
+func (s *MockServer) jwks(w http.ResponseWriter) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// purgeExpired drops expired codes and tokens; the caller holds s.mu
+func (s *MockServer) purgeExpired() {
+	now := time.Now()
+	for code, grant := range s.codes {
+		if now.After(grant.expiresAt) {
+			delete(s.codes, code)
+		}
+	}
+	for token, grant := range s.tokens {
+		if now.After(grant.expiresAt) {
+			delete(s.tokens, token)
+		}
+	}
+}
+
+// subject derives a stable user ID from the email, like a real provider's account ID
+func (s *MockServer) subject(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return "mock-" + hex.EncodeToString(sum[:8])
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
@@ -0,0 +1,206 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider names
+const (
+	ProviderGoogle   = "google"
+	ProviderFacebook = "facebook"
+	ProviderZalo     = "zalo"
+	ProviderMock     = "mock"
+)
+
+// ErrProviderNotFound is returned when no provider is registered under a name
+var ErrProviderNotFound = errors.New("oauth provider not found")
+
+// Provider is implemented by every social login integration. All providers use the
+// authorization code flow with PKCE (S256).
+type Provider interface {
+	// Name returns the name the provider is registered under
+	Name() string
+	// AuthCodeURL builds the URL the user is sent to for consent
+	AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error)
+	// Exchange trades the authorization code for tokens
+	Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (*Token, error)
+	// UserInfo returns the identity behind the tokens; OIDC providers verify the ID token and nonce
+	UserInfo(ctx context.Context, token *Token, nonce string) (*UserInfo, error)
+}
+
+// Token is the token response of the provider
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+}
+
+// UserInfo is the normalized identity returned by a provider
+type UserInfo struct {
+	Subject       string // Stable user ID at the provider
+	Email         string
+	EmailVerified bool // Only verified emails are used to link existing accounts
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Picture       string
+}
+
+// Registry resolves providers by name
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register registers a provider under its name
+func (r *Registry) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider.Name()] = provider
+}
+
+// Get returns the provider registered under name
+func (r *Registry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return provider, nil
+}
+
+// Names lists the registered provider names in alphabetical order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GenerateVerifier creates a PKCE code verifier (RFC 7636, 43 characters)
+func GenerateVerifier() (string, error) {
+	return randomToken(32)
+}
+
+// CodeChallenge derives the S256 code challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// GenerateState creates a random state or nonce value
+func GenerateState() (string, error) {
+	return randomToken(32)
+}
+
+func randomToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// buildURL appends query parameters to an endpoint
+func buildURL(endpoint string, params url.Values) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %s: %v", endpoint, err)
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				query.Add(key, value)
+			}
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// postForm sends a form-encoded request and decodes the JSON response into out
+func postForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return doJSON(client, req, out)
+}
+
+// getJSON sends a GET request and decodes the JSON response into out
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return doJSON(client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %d: %s", req.Method, req.URL.Host+req.URL.Path, resp.StatusCode, truncate(string(body), 200))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %v", req.URL.Host+req.URL.Path, err)
+	}
+	return nil
+}
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max] + "..."
+}
@@ -0,0 +1,349 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval throttles JWKS reloads triggered by an unknown key ID
+const jwksRefreshInterval = time.Minute
+
+// OIDCConfig represents the configuration of an OpenID Connect provider. Endpoints left
+// empty are read from the issuer's discovery document.
+type OIDCConfig struct {
+	Name             string
+	Issuer           string
+	ClientID         string
+	ClientSecret     string
+	Scopes           []string
+	AuthorizationURL string
+	TokenURL         string
+	UserInfoURL      string
+	JWKSURL          string
+	Timeout          time.Duration
+}
+
+// OIDCProvider implements Provider for any OpenID Connect compliant identity provider
+// (Google, or a local mock issuer during development)
+type OIDCProvider struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	discoverMu sync.Mutex
+	discovered bool
+
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	keysLoaded time.Time
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // bool, or "true"/"false" for some providers
+	Name          string      `json:"name"`
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	Picture       string      `json:"picture"`
+}
+
+// NewOIDCProvider creates a new OpenID Connect provider. Discovery runs lazily on first use
+// so the provider can point at an issuer that is not reachable yet at startup.
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	return &OIDCProvider{
+		config:     config,
+		httpClient: newHTTPClient(config.Timeout),
+		keys:       make(map[string]crypto.PublicKey),
+	}
+}
+
+// NewGoogleProvider creates the Google OpenID Connect provider
+func NewGoogleProvider(clientID, clientSecret string) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         ProviderGoogle,
+		Issuer:       "https://accounts.google.com",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	})
+}
+
+// Name returns the provider name
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL builds the authorization URL with PKCE and nonce
+func (p *OIDCProvider) AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error) {
+	if err := p.discover(context.Background()); err != nil {
+		return "", err
+	}
+	return buildURL(p.config.AuthorizationURL, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	})
+}
+
+// Exchange trades the authorization code for tokens
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (*Token, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	var token Token
+	err := postForm(ctx, p.httpClient, p.config.TokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}, nil, &token)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %v", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return &token, nil
+}
+
+// UserInfo verifies the ID token (signature, issuer, audience, expiry and nonce) and returns its claims
+func (p *OIDCProvider) UserInfo(ctx context.Context, token *Token, nonce string) (*UserInfo, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(token.IDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %v", err)
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id_token: missing subject")
+	}
+
+	return &UserInfo{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+// discover fills the endpoints missing from the configuration from the discovery document.
+// A failed discovery is retried on the next request, e.g. when the issuer was down.
+func (p *OIDCProvider) discover(ctx context.Context) error {
+	p.discoverMu.Lock()
+	defer p.discoverMu.Unlock()
+
+	if p.discovered {
+		return nil
+	}
+	if p.config.AuthorizationURL != "" && p.config.TokenURL != "" && p.config.JWKSURL != "" {
+		p.discovered = true
+		return nil
+	}
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.httpClient, p.config.Issuer+"/.well-known/openid-configuration", nil, &doc); err != nil {
+		return fmt.Errorf("oidc discovery failed for %s: %v", p.config.Name, err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.config.Issuer {
+		return fmt.Errorf("oidc discovery for %s returned issuer %s", p.config.Name, doc.Issuer)
+	}
+	if p.config.AuthorizationURL == "" {
+		p.config.AuthorizationURL = doc.AuthorizationEndpoint
+	}
+	if p.config.TokenURL == "" {
+		p.config.TokenURL = doc.TokenEndpoint
+	}
+	if p.config.UserInfoURL == "" {
+		p.config.UserInfoURL = doc.UserInfoEndpoint
+	}
+	if p.config.JWKSURL == "" {
+		p.config.JWKSURL = doc.JWKSURI
+	}
+	p.discovered = true
+	return nil
+}
+
+// publicKey returns the signing key for kid, reloading the JWKS when the key is unknown
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, loadedAt := p.lookupKey(kid)
+	if key != nil {
+		return key, nil
+	}
+	if time.Since(loadedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := p.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+	if key, _ := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, time.Time) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, p.keysLoaded
+	}
+	// A key set with a single key may omit the kid
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, p.keysLoaded
+		}
+	}
+	return nil, p.keysLoaded
+}
+
+func (p *OIDCProvider) loadKeys(ctx context.Context) error {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, p.httpClient, p.config.JWKSURL, nil, &set); err != nil {
+		return fmt.Errorf("failed to load jwks: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysLoaded = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	zaloAuthURL  = "https://oauth.zaloapp.com/v4/permission"
+	zaloTokenURL = "https://oauth.zaloapp.com/v4/access_token"
+	zaloGraphURL = "https://graph.zalo.me/v2.0/me"
+)
+
+// ZaloProvider implements Provider for Zalo Login (OAuth v4). Zalo does not share the
+// user's email, so Zalo accounts can only be linked to an existing user, not matched by email.
+type ZaloProvider struct {
+	appID      string
+	secretKey  string
+	httpClient *http.Client
+}
+
+type zaloTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            int    `json:"error"`
+	ErrorName        string `json:"error_name"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type zaloUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Picture struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	} `json:"picture"`
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+}
+
+// NewZaloProvider creates the Zalo provider
+func NewZaloProvider(appID, secretKey string) *ZaloProvider {
+	return &ZaloProvider{
+		appID:      appID,
+		secretKey:  secretKey,
+		httpClient: newHTTPClient(0),
+	}
+}
+
+// Name returns the provider name
+func (p *ZaloProvider) Name() string {
+	return ProviderZalo
+}
+
+// AuthCodeURL builds the Zalo permission URL with PKCE
+func (p *ZaloProvider) AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error) {
+	return buildURL(zaloAuthURL, url.Values{
+		"app_id":         {p.appID},
+		"redirect_uri":   {redirectURI},
+		"code_challenge": {codeChallenge},
+		"state":          {state},
+	})
+}
+
+// Exchange trades the authorization code for an access token. Zalo reports errors with
+// HTTP 200 and a non-zero error field.
+func (p *ZaloProvider) Exchange(ctx context.Context, code, codeVerifier, redirectURI string) (*Token, error) {
+	var resp zaloTokenResponse
+	err := postForm(ctx, p.httpClient, zaloTokenURL, url.Values{
+		"app_id":        {p.appID},
+		"code":          {code},
+		"grant_type":    {"authorization_code"},
+		"code_verifier": {codeVerifier},
+	}, map[string]string{"secret_key": p.secretKey}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %v", err)
+	}
+	if resp.Error != 0 || resp.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange failed: %s (%d) %s", resp.ErrorName, resp.Error, resp.ErrorDescription)
+	}
+
+	return &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		TokenType:    "Bearer",
+	}, nil
+}
+
+// UserInfo reads the Zalo profile
+func (p *ZaloProvider) UserInfo(ctx context.Context, token *Token, nonce string) (*UserInfo, error) {
+	endpoint, err := buildURL(zaloGraphURL, url.Values{"fields": {"id,name,picture"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var user zaloUser
+	if err := getJSON(ctx, p.httpClient, endpoint, map[string]string{"access_token": token.AccessToken}, &user); err != nil {
+		return nil, fmt.Errorf("failed to get zalo profile: %v", err)
+	}
+	if user.Error != 0 {
+		return nil, fmt.Errorf("failed to get zalo profile: %s (%d)", user.Message, user.Error)
+	}
+	if user.ID == "" {
+		return nil, errors.New("zalo profile has no id")
+	}
+
+	return &UserInfo{
+		Subject: user.ID,
+		Name:    user.Name,
+		Picture: user.Picture.Data.URL,
+	}, nil
+}
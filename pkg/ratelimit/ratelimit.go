@@ -2,6 +2,8 @@ package ratelimit
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"time"
@@ -264,14 +266,20 @@ func EndpointSpecificRateLimit(redis *redis.Client, endpoint string, requests in
 // Helper functions
 
 func getClientIdentifier(c *gin.Context) string {
+	// Requests authenticated with an API key get a bucket per key, not per owner
+	if keyID, exists := c.Get("api_key_id"); exists {
+		return fmt.Sprintf("api_key:%v", keyID)
+	}
+
 	// Try to get user ID from context first (if authenticated)
 	if userID, exists := c.Get("user_id"); exists {
 		return fmt.Sprintf("user:%v", userID)
 	}
 
-	// Try to get API key from header
+	// Try to get API key from header (hashed, the raw key must not end up in Redis)
 	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
-		return fmt.Sprintf("api_key:%s", apiKey)
+		sum := sha256.Sum256([]byte(apiKey))
+		return fmt.Sprintf("api_key:%s", hex.EncodeToString(sum[:8]))
 	}
 
 	// Fall back to IP address
@@ -0,0 +1,129 @@
+package shipping
+
+import (
+	"errors"
+	"time"
+)
+
+// Normalized shipment statuses shared by all carriers
+const (
+	StatusPending   = "pending"
+	StatusCreated   = "created"
+	StatusPickedUp  = "picked_up"
+	StatusInTransit = "in_transit"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusReturned  = "returned"
+)
+
+// ErrNotSupported is returned when a carrier does not offer an operation
+var ErrNotSupported = errors.New("operation not supported by carrier")
+
+// Carrier is implemented by every shipping carrier integration
+type Carrier interface {
+	// Code returns the provider code the carrier is registered under
+	Code() string
+	// Quote calculates the shipping fee for a package
+	Quote(req *QuoteRequest) (*Quote, error)
+	// CreateShipment books a shipment with the carrier
+	CreateShipment(req *ShipmentRequest) (*Shipment, error)
+	// CancelShipment cancels a booked shipment
+	CancelShipment(trackingCode, reason string) error
+	// Track returns the current status and history of a shipment
+	Track(trackingCode string) (*TrackingInfo, error)
+	// Label returns the printable shipping label of a shipment
+	Label(trackingCode string) ([]byte, error)
+	// VerifyWebhook checks the signature of a webhook call from the carrier
+	VerifyWebhook(signature string, body []byte) bool
+}
+
+// Address represents a pickup or delivery address
+type Address struct {
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+	Address  string `json:"address"`  // Địa chỉ đầy đủ
+	Province string `json:"province"` // Tỉnh/TP
+	District string `json:"district"` // Quận/Huyện
+	Ward     string `json:"ward"`     // Phường/Xã
+	Street   string `json:"street"`   // Đường
+
+	// Carrier-specific administrative IDs (e.g. GHN ProvinceID/DistrictID/WardCode), when mapped
+	ProvinceID string `json:"province_id,omitempty"`
+	DistrictID string `json:"district_id,omitempty"`
+	WardID     string `json:"ward_id,omitempty"`
+}
+
+// QuoteRequest represents a request for a shipping fee
+type QuoteRequest struct {
+	From      Address `json:"from"`
+	To        Address `json:"to"`
+	Weight    float64 `json:"weight"` // in kg
+	Value     float64 `json:"value"`  // in VND
+	COD       float64 `json:"cod"`
+	Insurance float64 `json:"insurance"`
+}
+
+// Quote represents a shipping fee returned by a carrier
+type Quote struct {
+	Service      string  `json:"service"`
+	Fee          float64 `json:"fee"`
+	CODFee       float64 `json:"cod_fee"`
+	InsuranceFee float64 `json:"insurance_fee"`
+	TotalFee     float64 `json:"total_fee"`
+	MinDays      int     `json:"min_days"`
+	MaxDays      int     `json:"max_days"`
+}
+
+// ShipmentItem represents an item inside a shipment
+type ShipmentItem struct {
+	Name     string  `json:"name"`
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Weight   float64 `json:"weight"` // in kg
+	Price    float64 `json:"price"`
+}
+
+// ShipmentRequest represents a request to book a shipment
+type ShipmentRequest struct {
+	Reference string         `json:"reference"` // Mã đơn hàng của hệ thống
+	From      Address        `json:"from"`
+	To        Address        `json:"to"`
+	Items     []ShipmentItem `json:"items"`
+	Weight    float64        `json:"weight"` // in kg
+	Value     float64        `json:"value"`  // in VND
+	COD       float64        `json:"cod"`    // Tiền thu hộ
+	Insurance float64        `json:"insurance"`
+	Note      string         `json:"note"`
+	Tags      []string       `json:"tags"`
+}
+
+// Shipment represents a shipment booked with a carrier
+type Shipment struct {
+	ExternalID   string  `json:"external_id"`
+	LabelID      string  `json:"label_id"`
+	TrackingCode string  `json:"tracking_code"`
+	Status       string  `json:"status"`
+	StatusText   string  `json:"status_text"`
+	Fee          float64 `json:"fee"`
+	InsuranceFee float64 `json:"insurance_fee"`
+	TotalFee     float64 `json:"total_fee"`
+}
+
+// TrackingEvent represents a single step in a shipment's journey
+type TrackingEvent struct {
+	Status     string    `json:"status"`
+	StatusText string    `json:"status_text"`
+	Location   string    `json:"location"`
+	Note       string    `json:"note"`
+	Time       time.Time `json:"time"`
+}
+
+// TrackingInfo represents the tracking state of a shipment
+type TrackingInfo struct {
+	TrackingCode string          `json:"tracking_code"`
+	Status       string          `json:"status"`
+	StatusText   string          `json:"status_text"`
+	Events       []TrackingEvent `json:"events"`
+}
@@ -0,0 +1,231 @@
+package shipping
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CarrierCodeFake is the provider code of the offline fake carrier
+const CarrierCodeFake = "fake"
+
+// FakeCarrierConfig represents the configuration of the fake carrier
+type FakeCarrierConfig struct {
+	Code          string  `json:"code"`           // Override the provider code
+	BaseFee       float64 `json:"base_fee"`       // Phí cơ bản
+	FeePerKg      float64 `json:"fee_per_kg"`     // Phí mỗi kg vượt 1kg đầu
+	CODRate       float64 `json:"cod_rate"`       // Tỷ lệ phí thu hộ (0.01 = 1%)
+	InsuranceRate float64 `json:"insurance_rate"` // Tỷ lệ phí bảo hiểm
+	MinDays       int     `json:"min_days"`
+	MaxDays       int     `json:"max_days"`
+	WebhookSecret string  `json:"webhook_secret"`
+	FailQuotes    bool    `json:"fail_quotes"` // Simulate an unavailable carrier
+}
+
+// FakeCarrier is an in-memory carrier for offline development and tests
+type FakeCarrier struct {
+	config    FakeCarrierConfig
+	mu        sync.Mutex
+	sequence  int
+	shipments map[string]*fakeShipment
+}
+
+type fakeShipment struct {
+	request ShipmentRequest
+	info    TrackingInfo
+}
+
+// NewFakeCarrier creates a new fake carrier
+func NewFakeCarrier(config FakeCarrierConfig) *FakeCarrier {
+	if config.Code == "" {
+		config.Code = CarrierCodeFake
+	}
+	if config.BaseFee == 0 {
+		config.BaseFee = 20000
+	}
+	if config.FeePerKg == 0 {
+		config.FeePerKg = 5000
+	}
+	if config.MinDays == 0 {
+		config.MinDays = 1
+	}
+	if config.MaxDays < config.MinDays {
+		config.MaxDays = config.MinDays + 2
+	}
+
+	return &FakeCarrier{
+		config:    config,
+		shipments: make(map[string]*fakeShipment),
+	}
+}
+
+// NewFakeCarrierFromConfig builds a fake carrier from a provider's JSON configuration
+func NewFakeCarrierFromConfig(config []byte) (Carrier, error) {
+	var fakeConfig FakeCarrierConfig
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &fakeConfig); err != nil {
+			return nil, fmt.Errorf("invalid fake carrier config: %v", err)
+		}
+	}
+	return NewFakeCarrier(fakeConfig), nil
+}
+
+// Code returns the fake carrier's provider code
+func (c *FakeCarrier) Code() string {
+	return c.config.Code
+}
+
+// Quote calculates a deterministic fee from weight, COD and insurance
+func (c *FakeCarrier) Quote(req *QuoteRequest) (*Quote, error) {
+	if c.config.FailQuotes {
+		return nil, fmt.Errorf("fake carrier is unavailable")
+	}
+
+	fee := c.config.BaseFee
+	if req.Weight > 1 {
+		fee += math.Ceil(req.Weight-1) * c.config.FeePerKg
+	}
+	codFee := req.COD * c.config.CODRate
+	insuranceFee := req.Insurance * c.config.InsuranceRate
+
+	return &Quote{
+		Service:      "standard",
+		Fee:          fee,
+		CODFee:       codFee,
+		InsuranceFee: insuranceFee,
+		TotalFee:     fee + codFee + insuranceFee,
+		MinDays:      c.config.MinDays,
+		MaxDays:      c.config.MaxDays,
+	}, nil
+}
+
+// CreateShipment books a shipment in memory
+func (c *FakeCarrier) CreateShipment(req *ShipmentRequest) (*Shipment, error) {
+	quote, err := c.Quote(&QuoteRequest{From: req.From, To: req.To, Weight: req.Weight, Value: req.Value, COD: req.COD, Insurance: req.Insurance})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sequence++
+	trackingCode := fmt.Sprintf("FAKE%08d", c.sequence)
+	c.shipments[trackingCode] = &fakeShipment{
+		request: *req,
+		info: TrackingInfo{
+			TrackingCode: trackingCode,
+			Status:       StatusCreated,
+			StatusText:   "Created",
+			Events: []TrackingEvent{
+				{Status: StatusCreated, StatusText: "Created", Location: req.From.Province, Time: time.Now()},
+			},
+		},
+	}
+
+	return &Shipment{
+		ExternalID:   req.Reference,
+		LabelID:      trackingCode,
+		TrackingCode: trackingCode,
+		Status:       StatusCreated,
+		StatusText:   "Created",
+		Fee:          quote.Fee,
+		InsuranceFee: quote.InsuranceFee,
+		TotalFee:     quote.TotalFee,
+	}, nil
+}
+
+// CancelShipment cancels a shipment that has not been picked up yet
+func (c *FakeCarrier) CancelShipment(trackingCode, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shipment, ok := c.shipments[trackingCode]
+	if !ok {
+		return fmt.Errorf("shipment %s not found", trackingCode)
+	}
+	if shipment.info.Status != StatusCreated {
+		return fmt.Errorf("shipment %s can no longer be cancelled", trackingCode)
+	}
+
+	c.appendEvent(shipment, StatusCancelled, "Cancelled", "", reason)
+	return nil
+}
+
+// Track returns the in-memory tracking state of a shipment
+func (c *FakeCarrier) Track(trackingCode string) (*TrackingInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shipment, ok := c.shipments[trackingCode]
+	if !ok {
+		return nil, fmt.Errorf("shipment %s not found", trackingCode)
+	}
+
+	info := shipment.info
+	info.Events = append([]TrackingEvent(nil), shipment.info.Events...)
+	return &info, nil
+}
+
+// Label returns a plain-text label for a shipment
+func (c *FakeCarrier) Label(trackingCode string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shipment, ok := c.shipments[trackingCode]
+	if !ok {
+		return nil, fmt.Errorf("shipment %s not found", trackingCode)
+	}
+
+	req := shipment.request
+	label := fmt.Sprintf("%s\nRef: %s\nFrom: %s, %s\nTo: %s, %s, %s\nCOD: %.0f\n",
+		trackingCode, req.Reference, req.From.Name, req.From.Address,
+		req.To.Name, req.To.Phone, req.To.Address, req.COD)
+	return []byte(label), nil
+}
+
+// VerifyWebhook checks an HMAC-SHA256 signature of the body; without a secret every call is rejected
+func (c *FakeCarrier) VerifyWebhook(signature string, body []byte) bool {
+	if c.config.WebhookSecret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(c.SignWebhook(body)))
+}
+
+// SignWebhook signs a webhook body the way VerifyWebhook expects
+func (c *FakeCarrier) SignWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(c.config.WebhookSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Advance moves a shipment to a new status, simulating carrier progress
+func (c *FakeCarrier) Advance(trackingCode, status, location, note string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shipment, ok := c.shipments[trackingCode]
+	if !ok {
+		return fmt.Errorf("shipment %s not found", trackingCode)
+	}
+
+	c.appendEvent(shipment, status, status, location, note)
+	return nil
+}
+
+func (c *FakeCarrier) appendEvent(shipment *fakeShipment, status, statusText, location, note string) {
+	shipment.info.Status = status
+	shipment.info.StatusText = statusText
+	shipment.info.Events = append(shipment.info.Events, TrackingEvent{
+		Status:     status,
+		StatusText: statusText,
+		Location:   location,
+		Note:       note,
+		Time:       time.Now(),
+	})
+}
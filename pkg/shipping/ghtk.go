@@ -2,10 +2,14 @@ package shipping
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -16,6 +20,8 @@ type GHTKConfig struct {
 	ShopID     string `json:"shop_id"`
 	Timeout    int    `json:"timeout"` // in seconds
 	IsTestMode bool   `json:"is_test_mode"`
+
+	WebhookSecret string `json:"webhook_secret"` // Shared secret for webhook signatures
 }
 
 // GHTKClient handles GHTK API interactions
@@ -338,9 +344,14 @@ func (c *GHTKClient) makeRequest(method, url string, body []byte) (*http.Respons
 	return resp, nil
 }
 
-// VerifyWebhookSignature verifies GHTK webhook signature
+// VerifyWebhookSignature verifies the hex HMAC-SHA256 signature of a GHTK webhook body.
+// Without a configured secret every call is rejected.
 func (c *GHTKClient) VerifyWebhookSignature(signature string, body []byte) bool {
-	// GHTK webhook verification logic would go here
-	// For now, return true (implement proper verification)
-	return true
+	if c.config.WebhookSecret == "" || signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(c.config.WebhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(strings.ToLower(strings.TrimSpace(signature))), []byte(expected))
 }
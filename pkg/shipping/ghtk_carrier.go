@@ -0,0 +1,222 @@
+package shipping
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CarrierCodeGHTK is the provider code of Giao Hàng Tiết Kiệm
+const CarrierCodeGHTK = "ghtk"
+
+// GHTKCarrier adapts GHTKClient to the Carrier interface
+type GHTKCarrier struct {
+	client *GHTKClient
+}
+
+// NewGHTKCarrier creates a new GHTK carrier
+func NewGHTKCarrier(config GHTKConfig) *GHTKCarrier {
+	return &GHTKCarrier{client: NewGHTKClient(config)}
+}
+
+// NewGHTKCarrierFromConfig builds a GHTK carrier from a provider's JSON configuration
+func NewGHTKCarrierFromConfig(config []byte) (Carrier, error) {
+	var ghtkConfig GHTKConfig
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &ghtkConfig); err != nil {
+			return nil, fmt.Errorf("invalid GHTK config: %v", err)
+		}
+	}
+	if ghtkConfig.Token == "" {
+		return nil, fmt.Errorf("GHTK token is required")
+	}
+	return NewGHTKCarrier(ghtkConfig), nil
+}
+
+// Code returns the GHTK provider code
+func (c *GHTKCarrier) Code() string {
+	return CarrierCodeGHTK
+}
+
+// Quote calculates the GHTK shipping fee
+func (c *GHTKCarrier) Quote(req *QuoteRequest) (*Quote, error) {
+	resp, err := c.client.CalculateFee(&CalculateFeeRequest{
+		PickProvince: req.From.Province,
+		PickDistrict: req.From.District,
+		PickWard:     req.From.Ward,
+		Province:     req.To.Province,
+		District:     req.To.District,
+		Ward:         req.To.Ward,
+		Value:        int(req.Value),
+		Transport:    "road",                 // Default transport method
+		Weight:       int(req.Weight * 1000), // Convert kg to grams
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("GHTK API error: %s", resp.Message)
+	}
+
+	return &Quote{
+		Service:      resp.Fee.Name,
+		Fee:          float64(resp.Fee.Fee),
+		InsuranceFee: float64(resp.Fee.InsuranceFee),
+		TotalFee:     float64(resp.Fee.TotalFee),
+		MinDays:      1, // GHTK typically delivers in 1-2 days
+		MaxDays:      2,
+	}, nil
+}
+
+// CreateShipment creates a GHTK order
+func (c *GHTKCarrier) CreateShipment(req *ShipmentRequest) (*Shipment, error) {
+	products := make([]GHTKProduct, 0, len(req.Items))
+	for _, item := range req.Items {
+		products = append(products, GHTKProduct{
+			Name:        item.Name,
+			Weight:      int(item.Weight * 1000), // Convert to grams
+			Quantity:    item.Quantity,
+			ProductCode: item.SKU,
+			Price:       item.Price,
+		})
+	}
+	if len(products) == 0 {
+		products = append(products, GHTKProduct{
+			Name:        "Order Items",
+			Weight:      int(req.Weight * 1000),
+			Quantity:    1,
+			ProductCode: req.Reference,
+			Price:       req.Value,
+		})
+	}
+
+	pickOption := "post"
+	if req.COD > 0 {
+		pickOption = "cod"
+	}
+
+	resp, err := c.client.CreateOrder(&CreateOrderRequest{
+		Products: products,
+		Order: GHTKOrder{
+			ID:           req.Reference,
+			PickName:     req.From.Name,
+			PickAddress:  req.From.Address,
+			PickProvince: req.From.Province,
+			PickDistrict: req.From.District,
+			PickWard:     req.From.Ward,
+			PickStreet:   req.From.Street,
+			PickTel:      req.From.Phone,
+			PickEmail:    req.From.Email,
+			Name:         req.To.Name,
+			Address:      req.To.Address,
+			Province:     req.To.Province,
+			District:     req.To.District,
+			Ward:         req.To.Ward,
+			Street:       req.To.Street,
+			Tel:          req.To.Phone,
+			Email:        req.To.Email,
+			Note:         req.Note,
+			Value:        int(req.Value),
+			Transport:    "road",
+			PickOption:   pickOption,
+			PickMoney:    int(req.COD),
+			PickSession:  2, // Afternoon
+			Tags:         req.Tags,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("GHTK API error: %s", resp.Message)
+	}
+
+	return &Shipment{
+		ExternalID:   resp.Order.PartnerID,
+		LabelID:      resp.Order.LabelID,
+		TrackingCode: resp.Order.LabelID,
+		Status:       StatusCreated,
+		StatusText:   "Created in GHTK",
+		Fee:          float64(resp.Order.Fee),
+		InsuranceFee: float64(resp.Order.InsuranceFee),
+		TotalFee:     float64(resp.Order.Fee + resp.Order.InsuranceFee),
+	}, nil
+}
+
+// CancelShipment cancels a GHTK order
+func (c *GHTKCarrier) CancelShipment(trackingCode, reason string) error {
+	resp, err := c.client.CancelOrder(&CancelOrderRequest{
+		LabelID: trackingCode,
+		Note:    reason,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("GHTK API error: %s", resp.Message)
+	}
+	return nil
+}
+
+// Track returns the status and timeline of a GHTK order
+func (c *GHTKCarrier) Track(trackingCode string) (*TrackingInfo, error) {
+	resp, err := c.client.GetOrderStatus(trackingCode)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("GHTK API error: %s", resp.Message)
+	}
+
+	info := &TrackingInfo{
+		TrackingCode: resp.Order.LabelID,
+		Status:       GHTKStatus(resp.Order.Status),
+		StatusText:   resp.Order.StatusText,
+	}
+	for _, step := range resp.Order.Timeline {
+		event := TrackingEvent{
+			Status:     GHTKStatus(step.Status),
+			StatusText: step.StatusText,
+			Location:   step.Location,
+			Note:       step.Note,
+		}
+		if t, err := time.Parse("2006-01-02 15:04:05", step.Time); err == nil {
+			event.Time = t
+		}
+		info.Events = append(info.Events, event)
+	}
+
+	return info, nil
+}
+
+// Label returns the GHTK label PDF
+func (c *GHTKCarrier) Label(trackingCode string) ([]byte, error) {
+	return c.client.PrintLabel(trackingCode)
+}
+
+// VerifyWebhook verifies a GHTK webhook call
+func (c *GHTKCarrier) VerifyWebhook(signature string, body []byte) bool {
+	return c.client.VerifyWebhookSignature(signature, body)
+}
+
+// GHTKStatus maps a GHTK status ID to a normalized shipment status
+func GHTKStatus(statusID string) string {
+	switch statusID {
+	case "-1":
+		return StatusCancelled // Hủy đơn hàng
+	case "1", "2", "12", "8", "128":
+		return StatusCreated // Chưa tiếp nhận / Đã tiếp nhận / Đang lấy hàng / Hoãn lấy hàng
+	case "3":
+		return StatusPickedUp // Đã lấy hàng
+	case "4", "10", "410":
+		return StatusInTransit // Đang giao hàng / Delay giao hàng
+	case "5", "6", "45":
+		return StatusDelivered // Đã giao hàng / Đã đối soát
+	case "7", "9", "49":
+		return StatusFailed // Không lấy được hàng / Không giao được hàng
+	case "11", "13", "20", "21":
+		return StatusReturned // Đang trả hàng / Đã trả hàng
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,94 @@
+package shipping
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// CarrierFactory builds a carrier from its provider configuration (JSON)
+type CarrierFactory func(config []byte) (Carrier, error)
+
+// Registry resolves carriers by provider code
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]CarrierFactory
+	carriers  map[string]registeredCarrier
+}
+
+type registeredCarrier struct {
+	config  string
+	carrier Carrier
+	direct  bool // registered as an instance rather than built from config
+}
+
+// NewRegistry creates an empty carrier registry
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]CarrierFactory),
+		carriers:  make(map[string]registeredCarrier),
+	}
+}
+
+// NewDefaultRegistry creates a registry with the built-in production carriers. The fake carrier
+// is not included; development and test setups register it themselves.
+func NewDefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.RegisterFactory(CarrierCodeGHTK, NewGHTKCarrierFromConfig)
+	return registry
+}
+
+// RegisterFactory registers how to build the carrier for a provider code
+func (r *Registry) RegisterFactory(code string, factory CarrierFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[code] = factory
+	delete(r.carriers, code)
+}
+
+// Register registers a ready-made carrier instance, e.g. a fake carrier in tests
+func (r *Registry) Register(carrier Carrier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.carriers[carrier.Code()] = registeredCarrier{carrier: carrier, direct: true}
+}
+
+// Supports checks if a carrier is available for a provider code
+func (r *Registry) Supports(code string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, hasFactory := r.factories[code]
+	_, hasCarrier := r.carriers[code]
+	return hasFactory || hasCarrier
+}
+
+// Resolve returns the carrier for a provider code, rebuilding it when the provider configuration changed
+func (r *Registry) Resolve(code, config string) (Carrier, error) {
+	r.mu.RLock()
+	registered, ok := r.carriers[code]
+	factory, hasFactory := r.factories[code]
+	r.mu.RUnlock()
+
+	// Direct instances are used as is, built ones while their configuration is unchanged
+	if ok && (registered.direct || registered.config == config) {
+		return registered.carrier, nil
+	}
+	if !hasFactory {
+		return nil, fmt.Errorf("no carrier registered for provider %s", code)
+	}
+
+	if config != "" && !json.Valid([]byte(config)) {
+		return nil, fmt.Errorf("invalid configuration for provider %s", code)
+	}
+
+	carrier, err := factory([]byte(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build carrier %s: %v", code, err)
+	}
+
+	r.mu.Lock()
+	r.carriers[code] = registeredCarrier{config: config, carrier: carrier}
+	r.mu.Unlock()
+
+	return carrier, nil
+}
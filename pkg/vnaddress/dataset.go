@@ -0,0 +1,128 @@
+package vnaddress
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// bundledDataset is the directory shipped with the application. It lists every province
+// with its GSO code and the districts and wards of the main urban areas; a complete
+// GSO export in the same format can be imported on top of it.
+//
+//go:embed data/vietnam_administrative_units.json
+var bundledDataset []byte
+
+// Dataset is a province/district/ward directory with carrier code mappings
+type Dataset struct {
+	Version      string        `json:"version"`
+	Provinces    []Province    `json:"provinces"`
+	CarrierCodes []CarrierCode `json:"carrier_codes"`
+}
+
+// Province is a province or centrally-run city (Tỉnh/Thành phố trực thuộc TW)
+type Province struct {
+	Code      string     `json:"code"`      // Mã GSO, e.g. "79"
+	Name      string     `json:"name"`      // "Hồ Chí Minh"
+	FullName  string     `json:"full_name"` // "Thành phố Hồ Chí Minh"
+	Type      string     `json:"type"`
+	Aliases   []string   `json:"aliases,omitempty"`
+	Districts []District `json:"districts,omitempty"`
+}
+
+// District is a district-level unit (Quận/Huyện/Thị xã/Thành phố thuộc tỉnh)
+type District struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	Type     string   `json:"type"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Wards    []Ward   `json:"wards,omitempty"`
+}
+
+// Ward is a commune-level unit (Phường/Xã/Thị trấn)
+type Ward struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	Type     string   `json:"type"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// CarrierCode maps a unit to the identifier or name a carrier expects
+type CarrierCode struct {
+	Carrier      string `json:"carrier"` // Provider code, e.g. "ghtk", "ghn"
+	Level        string `json:"level"`   // province, district, ward
+	Code         string `json:"code"`    // Mã GSO
+	ExternalID   string `json:"external_id,omitempty"`
+	ExternalName string `json:"external_name,omitempty"`
+}
+
+// Bundled returns the directory shipped with the application
+func Bundled() (*Dataset, error) {
+	return Parse(bytes.NewReader(bundledDataset))
+}
+
+// Parse reads a directory in the bundled JSON format and checks its codes
+func Parse(r io.Reader) (*Dataset, error) {
+	var dataset Dataset
+	if err := json.NewDecoder(r).Decode(&dataset); err != nil {
+		return nil, fmt.Errorf("invalid address dataset: %v", err)
+	}
+	if err := dataset.validate(); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// validate checks that every unit has a name and a code unique within its level
+func (d *Dataset) validate() error {
+	if len(d.Provinces) == 0 {
+		return fmt.Errorf("address dataset contains no provinces")
+	}
+
+	seen := map[string]map[string]bool{
+		LevelProvince: {},
+		LevelDistrict: {},
+		LevelWard:     {},
+	}
+	check := func(level, code, name string) error {
+		if code == "" || name == "" {
+			return fmt.Errorf("address dataset: %s %q is missing a code or name", level, name)
+		}
+		if seen[level][code] {
+			return fmt.Errorf("address dataset: duplicate %s code %s", level, code)
+		}
+		seen[level][code] = true
+		return nil
+	}
+
+	for _, province := range d.Provinces {
+		if err := check(LevelProvince, province.Code, province.Name); err != nil {
+			return err
+		}
+		for _, district := range province.Districts {
+			if err := check(LevelDistrict, district.Code, district.Name); err != nil {
+				return err
+			}
+			for _, ward := range district.Wards {
+				if err := check(LevelWard, ward.Code, ward.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, code := range d.CarrierCodes {
+		if code.Carrier == "" || code.Code == "" {
+			return fmt.Errorf("address dataset: carrier code mapping is missing a carrier or code")
+		}
+		if _, ok := seen[code.Level]; !ok {
+			return fmt.Errorf("address dataset: invalid carrier code level %q", code.Level)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package vnaddress
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Administrative levels
+const (
+	LevelProvince = "province"
+	LevelDistrict = "district"
+	LevelWard     = "ward"
+)
+
+// unitPrefixes are the type words written before a unit name, longest first,
+// e.g. "Thành phố Hồ Chí Minh", "TP. HCM", "Q.1", "P. Bến Nghé"
+var unitPrefixes = map[string][]string{
+	LevelProvince: {"thanh pho", "tinh", "tp"},
+	LevelDistrict: {"thanh pho", "thi xa", "quan", "huyen", "district", "tp", "tx", "q", "h"},
+	LevelWard:     {"thi tran", "phuong", "xa", "ward", "tt", "p", "x"},
+}
+
+var (
+	nonAlnum      = regexp.MustCompile(`[^a-z0-9]+`)
+	leadingZeroes = regexp.MustCompile(`^0+(\d)`)
+)
+
+// Key returns the comparison key of a unit name: lowercase ASCII without accents,
+// punctuation or the type prefix, so "TP. Hồ Chí Minh" and "Thành phố Hồ Chí Minh"
+// share the key "ho chi minh" and "Quận 01", "Q.1" and "quan 1" share "1"
+func Key(level, name string) string {
+	key := fold(name)
+	if key == "" {
+		return ""
+	}
+
+	for _, prefix := range unitPrefixes[level] {
+		if key == prefix {
+			break
+		}
+		if strings.HasPrefix(key, prefix+" ") {
+			key = strings.TrimPrefix(key, prefix+" ")
+			break
+		}
+		// Abbreviations glued to a number: "q1", "p12"
+		if len(prefix) == 1 && len(key) > 1 && strings.HasPrefix(key, prefix) && isDigits(key[1:]) {
+			key = key[1:]
+			break
+		}
+	}
+
+	return leadingZeroes.ReplaceAllString(key, "$1")
+}
+
+// FullKey returns the comparison key of a name keeping its type prefix, which tells apart
+// units sharing a name in one province, e.g. "Thị xã Kỳ Anh" and "Huyện Kỳ Anh"
+func FullKey(name string) string {
+	return fold(name)
+}
+
+// fold lowercases a name, removes Vietnamese accents and collapses punctuation to single spaces
+func fold(name string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			sb.WriteRune('d')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(nonAlnum.ReplaceAllString(sb.String(), " "))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -27,7 +27,7 @@ export REDIS_DB=0
 
 # JWT configuration
 export JWT_SECRET=your-jwt-secret-key-change-in-production
-export JWT_ACCESS_EXPIRY=24
+export JWT_ACCESS_EXPIRY_MINUTES=15
 export JWT_REFRESH_EXPIRY=168
 
 echo "📋 Environment Configuration:"